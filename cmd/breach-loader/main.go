@@ -0,0 +1,131 @@
+/*
+Load a Have I Been Pwned password file into the breach table (BREACH_TABLE).
+
+	go run ./cmd/breach-loader pwned-passwords-sha1-ordered-by-hash.txt
+	go run ./cmd/breach-loader -dry-run lib/breach/fixture.txt
+
+The file must be in the ordered by hash format, a "HASH:COUNT" line per
+SHA-1 hash, so the hashes of a prefix can be written as soon as the next
+prefix starts. With -dry-run the file is only checked and counted
+*/
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+
+	"password-caddy/api/core/container"
+	"password-caddy/api/lib/breach"
+	"password-caddy/api/lib/dynamoclient"
+)
+
+func main() {
+	dryRun := flag.Bool("dry-run", false, "Only check and count the hashes of the file")
+
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	file, err := os.Open(flag.Arg(0))
+
+	if err != nil {
+		fail(err.Error())
+	}
+
+	defer file.Close()
+
+	var client *dynamoclient.DynamoClient
+
+	if !*dryRun {
+		client = container.BreachClient()
+	}
+
+	current := breach.Range{}
+	pending := []dynamoclient.DynamoPutRequest{}
+	prefixes, hashes := 0, 0
+
+	// Ranges are written in full batches across prefixes, most prefixes only have a single range
+	write := func(puts []dynamoclient.DynamoPutRequest) {
+		response := client.BatchWrite(dynamoclient.DynamoBatchWriteRequest{Puts: puts})
+
+		if !response.IsSuccess {
+			fail(fmt.Sprintf("Failed to write the ranges up to prefix %s: %s", current.Prefix, response.Error.Message))
+		}
+	}
+
+	flush := func() {
+		if len(current.Hashes) == 0 {
+			return
+		}
+
+		prefixes++
+		hashes += len(current.Hashes)
+
+		if client != nil {
+			pending = append(pending, breach.RangePuts(current)...)
+
+			if full := len(pending) - len(pending)%dynamoclient.MAX_BATCH_WRITE_ITEMS; full > 0 {
+				write(pending[:full])
+				pending = append([]dynamoclient.DynamoPutRequest{}, pending[full:]...)
+			}
+		}
+
+		if prefixes%10000 == 0 {
+			fmt.Fprintf(os.Stderr, "Loaded %d prefixes\n", prefixes)
+		}
+	}
+
+	scanner := bufio.NewScanner(file)
+	line := 0
+
+	for scanner.Scan() {
+		line++
+
+		if scanner.Text() == "" {
+			continue
+		}
+
+		prefix, hash, err := breach.ParseLine(scanner.Text())
+
+		if err == nil && prefix == "" {
+			err = fmt.Errorf("Hash must be a full SHA-1 hash")
+		}
+
+		if err != nil {
+			fail(fmt.Sprintf("Line %d: %s", line, err.Error()))
+		}
+
+		if prefix != current.Prefix {
+			if prefix < current.Prefix {
+				fail(fmt.Sprintf("Line %d: the file must be ordered by hash", line))
+			}
+
+			flush()
+			current = breach.Range{Prefix: prefix}
+		}
+
+		current.Hashes = append(current.Hashes, hash)
+	}
+
+	if err := scanner.Err(); err != nil {
+		fail(err.Error())
+	}
+
+	flush()
+
+	if len(pending) > 0 {
+		write(pending)
+	}
+
+	fmt.Printf("%d hashes of %d prefixes\n", hashes, prefixes)
+}
+
+func fail(message string) {
+	fmt.Fprintln(os.Stderr, message)
+	os.Exit(1)
+}
@@ -0,0 +1,65 @@
+/*
+Convert an export of another password manager into the normalized items of the vault.
+
+	go run ./cmd/importer -format bitwarden export.json > items.json
+	go run ./cmd/importer -format keepass -dry-run export.xml
+
+The items are printed as JSON for a client to encrypt and send to
+POST /api/v1/vault/import. With -dry-run only the report of what would be
+imported and what was skipped is printed
+*/
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"password-caddy/api/lib/importers"
+)
+
+func main() {
+	format := flag.String("format", "", "Format of the export ("+strings.Join(importers.Formats(), ", ")+")")
+	dryRun := flag.Bool("dry-run", false, "Only print the report of the import")
+
+	flag.Parse()
+
+	if flag.NArg() != 1 || !importers.IsValidFormat(*format) {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	data, err := os.ReadFile(flag.Arg(0))
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	result, err := importers.Parse(*format, data)
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	var output interface{} = result
+
+	if *dryRun {
+		output = result.Report()
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(output); err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	if len(result.Skipped) > 0 {
+		fmt.Fprintf(os.Stderr, "Skipped %d of %d entries\n", len(result.Skipped), len(result.Skipped)+len(result.Items))
+	}
+}
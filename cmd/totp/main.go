@@ -0,0 +1,59 @@
+/*
+Print the TOTP code of an otpauth URI, steam:// URI or base32 secret, i.e to
+log into a test account protected with TOTP.
+
+	go run ./cmd/totp 'otpauth://totp/ACME:bob?secret=JBSWY3DPEHPK3PXP'
+	go run ./cmd/totp -at 1111111109 JBSWY3DPEHPK3PXP
+	go run ./cmd/totp -verify 123456 JBSWY3DPEHPK3PXP
+
+With -verify the exit code tells if the code is valid, allowing -skew periods of clock drift
+*/
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"password-caddy/api/lib/totp"
+)
+
+func main() {
+	at := flag.Int64("at", 0, "Unix time of the code instead of now")
+	verify := flag.String("verify", "", "Verify the code instead of printing one")
+	skew := flag.Int("skew", 1, "Periods of clock drift allowed by -verify")
+
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	key, err := totp.Parse(flag.Arg(0))
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	now := time.Now()
+
+	if *at != 0 {
+		now = time.Unix(*at, 0)
+	}
+
+	if *verify != "" {
+		if !key.Verify(*verify, now, *skew) {
+			fmt.Println("invalid")
+			os.Exit(1)
+		}
+
+		fmt.Println("valid")
+		return
+	}
+
+	fmt.Println(key.Code(now))
+	fmt.Fprintf(os.Stderr, "Valid for %d seconds\n", key.Remaining(now))
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/accounts"
+	"password-caddy/api/lib/admin"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/dynamoclient"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/orgs"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/userevents"
+	"password-caddy/api/lib/util"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// The reason comes from the body, the operator from the admin token
+type ForceLogoutRequest struct {
+	OperatorId string                  `json:"-"`
+	Email      string                  `json:"-"`
+	SourceIp   string                  `json:"-"`
+	Reason     string                  `json:"reason"`
+	User       types.PasswordCaddyUser `json:"-"`
+}
+
+// Initialize the Force Logout Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	operatorId, ok := auth.OperatorId(event)
+
+	if !ok {
+		return result.Failure(403, "Admin scope required")
+	}
+
+	var request ForceLogoutRequest
+
+	if err := util.DeserializeJson(event.Body, &request); err != nil {
+		return result.Failure(400, "Invalid request body")
+	}
+
+	request.OperatorId = operatorId
+	request.Email = orgs.EmailParameter(event.PathParameters["email"])
+	request.SourceIp = auth.SourceIp(event)
+
+	if err := admin.ValidateEmail(request.Email); err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	if err := admin.ValidateReason(request.Reason); err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Write the action to the audit trail of the user before taking it
+func RecordAudit(res result.ResultValue) *result.Result {
+	request := res.(ForceLogoutRequest)
+
+	response := admin.Record(container.VaultClient(), request.Email, admin.Entry{
+		OperatorId: request.OperatorId,
+		Action:     admin.ACTION_FORCE_LOGOUT,
+		Reason:     request.Reason,
+		IpAddress:  request.SourceIp,
+	})
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to write the admin audit trail",
+			struct {
+				Email      string
+				OperatorId string
+				Action     string
+				Error      types.PasswordCaddyError
+			}{
+				Email:      request.Email,
+				OperatorId: request.OperatorId,
+				Action:     admin.ACTION_FORCE_LOGOUT,
+				Error:      response.Error,
+			},
+		)
+
+		return admin.AuditFailureResult()
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Get the user from the users table
+func GetUser(res result.ResultValue) *result.Result {
+	request := res.(ForceLogoutRequest)
+
+	response := container.DynamoClient().
+		Get(dynamoclient.DynamoGetRequest{Key: request.Email}).
+		AsUser()
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch user data",
+			struct {
+				Email string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.Email,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.User = response.Data.(types.PasswordCaddyUser)
+
+	if request.User.UserId.Value == "" {
+		return result.Failure(404, "User not found")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Revoke all sessions of the user, who has to log in again
+func RevokeSessions(res result.ResultValue) *result.Result {
+	request := res.(ForceLogoutRequest)
+
+	response := accounts.RevokeSessions(container.DynamoClient(), request.Email)
+
+	if !response.IsSuccess && response.Error.StatusCode == 409 {
+		return result.Failure(404, "User not found")
+	}
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to revoke sessions",
+			struct {
+				Email string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.Email,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	logger.Security(
+		"Support revoked the sessions of a user",
+		struct {
+			Email      string
+			OperatorId string
+			Reason     string
+		}{
+			Email:      request.Email,
+			OperatorId: request.OperatorId,
+			Reason:     request.Reason,
+		},
+	)
+
+	userevents.RecordOrLog(container.VaultClient(), request.Email, userevents.Event{
+		Type:      userevents.TYPE_SESSIONS_REVOKED,
+		ActorId:   request.OperatorId,
+		IpAddress: request.SourceIp,
+	})
+
+	return result.SuccessWithValue(200, admin.ToUserResponse(response.Data.(types.PasswordCaddyUser), ""))
+}
+
+// Handle the force logout request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(RecordAudit).
+		Then(GetUser).
+		Then(RevokeSessions).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
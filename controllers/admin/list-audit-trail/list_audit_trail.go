@@ -0,0 +1,129 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/admin"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/orgs"
+	"password-caddy/api/lib/result"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// The reason comes from the reason query parameter, the operator from the admin token
+type ListAuditTrailRequest struct {
+	OperatorId string
+	Email      string
+	SourceIp   string
+	Reason     string
+	Filter     admin.Filter
+}
+
+// Initialize the List Audit Trail Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	operatorId, ok := auth.OperatorId(event)
+
+	if !ok {
+		return result.Failure(403, "Admin scope required")
+	}
+
+	request := ListAuditTrailRequest{
+		OperatorId: operatorId,
+		Email:      orgs.EmailParameter(event.PathParameters["email"]),
+		SourceIp:   auth.SourceIp(event),
+		Reason:     event.QueryStringParameters["reason"],
+	}
+
+	if err := admin.ValidateEmail(request.Email); err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	if err := admin.ValidateReason(request.Reason); err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	filter, err := admin.ParseFilter(event.QueryStringParameters)
+
+	if err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	request.Filter = filter
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Write the action to the audit trail of the user before taking it
+func RecordAudit(res result.ResultValue) *result.Result {
+	request := res.(ListAuditTrailRequest)
+
+	response := admin.Record(container.VaultClient(), request.Email, admin.Entry{
+		OperatorId: request.OperatorId,
+		Action:     admin.ACTION_VIEW_AUDIT,
+		Reason:     request.Reason,
+		IpAddress:  request.SourceIp,
+	})
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to write the admin audit trail",
+			struct {
+				Email      string
+				OperatorId string
+				Action     string
+				Error      types.PasswordCaddyError
+			}{
+				Email:      request.Email,
+				OperatorId: request.OperatorId,
+				Action:     admin.ACTION_VIEW_AUDIT,
+				Error:      response.Error,
+			},
+		)
+
+		return admin.AuditFailureResult()
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Get a page of the audit trail of the user, including the entry of this request
+func GetEntries(res result.ResultValue) *result.Result {
+	request := res.(ListAuditTrailRequest)
+
+	response := admin.List(container.VaultClient(), request.Email, request.Filter)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch the admin audit trail",
+			struct {
+				Email string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.Email,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	return result.SuccessWithValue(200, admin.ToEntriesResponse(response.Data.(admin.Page)))
+}
+
+// Handle the list audit trail request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(RecordAudit).
+		Then(GetEntries).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/admin"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/dynamoclient"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/orgs"
+	"password-caddy/api/lib/result"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	sesTypes "github.com/aws/aws-sdk-go-v2/service/ses/types"
+)
+
+// The reason comes from the reason query parameter, the operator from the admin token
+type LookupUserRequest struct {
+	OperatorId string
+	Email      string
+	SourceIp   string
+	Reason     string
+	User       types.PasswordCaddyUser
+}
+
+// Initialize the Lookup User Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	operatorId, ok := auth.OperatorId(event)
+
+	if !ok {
+		return result.Failure(403, "Admin scope required")
+	}
+
+	request := LookupUserRequest{
+		OperatorId: operatorId,
+		Email:      orgs.EmailParameter(event.PathParameters["email"]),
+		SourceIp:   auth.SourceIp(event),
+		Reason:     event.QueryStringParameters["reason"],
+	}
+
+	if err := admin.ValidateEmail(request.Email); err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	if err := admin.ValidateReason(request.Reason); err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Write the action to the audit trail of the user before taking it
+func RecordAudit(res result.ResultValue) *result.Result {
+	request := res.(LookupUserRequest)
+
+	response := admin.Record(container.VaultClient(), request.Email, admin.Entry{
+		OperatorId: request.OperatorId,
+		Action:     admin.ACTION_LOOKUP_USER,
+		Reason:     request.Reason,
+		IpAddress:  request.SourceIp,
+	})
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to write the admin audit trail",
+			struct {
+				Email      string
+				OperatorId string
+				Action     string
+				Error      types.PasswordCaddyError
+			}{
+				Email:      request.Email,
+				OperatorId: request.OperatorId,
+				Action:     admin.ACTION_LOOKUP_USER,
+				Error:      response.Error,
+			},
+		)
+
+		return admin.AuditFailureResult()
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Get the user from the users table
+func GetUser(res result.ResultValue) *result.Result {
+	request := res.(LookupUserRequest)
+
+	response := container.DynamoClient().
+		Get(dynamoclient.DynamoGetRequest{Key: request.Email}).
+		AsUser()
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch user data",
+			struct {
+				Email string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.Email,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.User = response.Data.(types.PasswordCaddyUser)
+
+	if request.User.UserId.Value == "" {
+		return result.Failure(404, "User not found")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+/*
+Add the SES verification status of the email address to the user. The user is
+still returned without it when SES can not be reached
+*/
+func GetEmailVerification(res result.ResultValue) *result.Result {
+	request := res.(LookupUserRequest)
+
+	response := container.SesClient().
+		GetVerificationStatus(request.Email)
+
+	if !response.IsSuccess {
+		logger.Warn(
+			"Failed to get the verification status of email address",
+			struct {
+				Email string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.Email,
+				Error: response.Error,
+			},
+		)
+
+		return result.SuccessWithValue(200, admin.ToUserResponse(request.User, ""))
+	}
+
+	data := response.Data.(struct{ Status sesTypes.VerificationStatus })
+
+	return result.SuccessWithValue(200, admin.ToUserResponse(request.User, string(data.Status)))
+}
+
+// Handle the lookup user request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(RecordAudit).
+		Then(GetUser).
+		Then(GetEmailVerification).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/admin"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/dynamoclient"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/orgs"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/util"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// The reason comes from the body, the operator from the admin token
+type ResendVerificationRequest struct {
+	OperatorId string                  `json:"-"`
+	Email      string                  `json:"-"`
+	SourceIp   string                  `json:"-"`
+	Reason     string                  `json:"reason"`
+	User       types.PasswordCaddyUser `json:"-"`
+}
+
+// Initialize the Resend Verification Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	operatorId, ok := auth.OperatorId(event)
+
+	if !ok {
+		return result.Failure(403, "Admin scope required")
+	}
+
+	var request ResendVerificationRequest
+
+	if err := util.DeserializeJson(event.Body, &request); err != nil {
+		return result.Failure(400, "Invalid request body")
+	}
+
+	request.OperatorId = operatorId
+	request.Email = orgs.EmailParameter(event.PathParameters["email"])
+	request.SourceIp = auth.SourceIp(event)
+
+	if err := admin.ValidateEmail(request.Email); err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	if err := admin.ValidateReason(request.Reason); err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Write the action to the audit trail of the user before taking it
+func RecordAudit(res result.ResultValue) *result.Result {
+	request := res.(ResendVerificationRequest)
+
+	response := admin.Record(container.VaultClient(), request.Email, admin.Entry{
+		OperatorId: request.OperatorId,
+		Action:     admin.ACTION_RESEND_VERIFICATION,
+		Reason:     request.Reason,
+		IpAddress:  request.SourceIp,
+	})
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to write the admin audit trail",
+			struct {
+				Email      string
+				OperatorId string
+				Action     string
+				Error      types.PasswordCaddyError
+			}{
+				Email:      request.Email,
+				OperatorId: request.OperatorId,
+				Action:     admin.ACTION_RESEND_VERIFICATION,
+				Error:      response.Error,
+			},
+		)
+
+		return admin.AuditFailureResult()
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Get the user from the users table
+func GetUser(res result.ResultValue) *result.Result {
+	request := res.(ResendVerificationRequest)
+
+	response := container.DynamoClient().
+		Get(dynamoclient.DynamoGetRequest{Key: request.Email}).
+		AsUser()
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch user data",
+			struct {
+				Email string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.Email,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.User = response.Data.(types.PasswordCaddyUser)
+
+	if request.User.UserId.Value == "" {
+		return result.Failure(404, "User not found")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Send the SES verification email to the email address of the user again
+func SendVerification(res result.ResultValue) *result.Result {
+	request := res.(ResendVerificationRequest)
+
+	response := container.SesClient().
+		SendVerificationEmail(request.Email)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to send verification email",
+			struct {
+				Email string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.Email,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	logger.Info(
+		"Support resent the verification email of a user",
+		struct {
+			Email      string
+			OperatorId string
+		}{
+			Email:      request.Email,
+			OperatorId: request.OperatorId,
+		},
+	)
+
+	return result.Success(202)
+}
+
+// Handle the resend verification request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(RecordAudit).
+		Then(GetUser).
+		Then(SendVerification).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
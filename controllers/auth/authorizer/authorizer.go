@@ -0,0 +1,147 @@
+package main
+
+import (
+	"time"
+
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/accounts"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/dynamoclient"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/result"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type AuthorizerRequest struct {
+	Token    string
+	SourceIp string
+	Claims   auth.Claims
+}
+
+// Initialize the Authorizer Request. HTTP APIs pass the headers in lower case
+func Init(event events.APIGatewayV2HTTPRequest) *result.Result {
+	token, ok := auth.BearerToken(event.Headers["authorization"])
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	return result.SuccessWithValue(200, AuthorizerRequest{
+		Token:    token,
+		SourceIp: event.RequestContext.HTTP.SourceIP,
+	})
+}
+
+// Verify the signature and expiry of the session token
+func VerifyToken(res result.ResultValue) *result.Result {
+	request := res.(AuthorizerRequest)
+
+	claims, err := auth.VerifyToken(auth.TokenSecret(), request.Token, time.Now())
+
+	if err == auth.ErrNoSecret {
+		logger.Error("Session token secret is not configured", struct{}{})
+
+		return result.Failure(500, err.Error())
+	}
+
+	if err != nil {
+		logger.Warn(
+			"Rejected session token",
+			struct {
+				SourceIp string
+				Error    string
+			}{
+				SourceIp: request.SourceIp,
+				Error:    err.Error(),
+			},
+		)
+
+		return result.Failure(401, err.Error())
+	}
+
+	request.Claims = claims
+
+	return result.SuccessWithValue(200, request)
+}
+
+/*
+Check that the user still exists, is not blocked and did not have their
+sessions revoked after the token was issued, i.e when locking the account or
+by support
+*/
+func CheckUser(res result.ResultValue) *result.Result {
+	request := res.(AuthorizerRequest)
+
+	response := container.DynamoClient().
+		Get(dynamoclient.DynamoGetRequest{Key: request.Claims.Subject}).
+		AsUser()
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch user data",
+			struct {
+				Email string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.Claims.Subject,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	user := response.Data.(types.PasswordCaddyUser)
+
+	if user.UserId.Value == "" || accounts.IsBlocked(user) || accounts.IsRevoked(user, time.Unix(request.Claims.IssuedAt, 0)) {
+		logger.Warn(
+			"Rejected revoked session token",
+			struct {
+				Email    string
+				Status   string
+				SourceIp string
+			}{
+				Email:    request.Claims.Subject,
+				Status:   user.Status.Value,
+				SourceIp: request.SourceIp,
+			},
+		)
+
+		return result.Failure(401, "Session has been revoked")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+/*
+Handle the authorization of a request to the API. The id of the user is passed
+on to the endpoints in the authorizer context, where auth.CallerId reads it
+*/
+func Handler(event events.APIGatewayV2HTTPRequest) (events.APIGatewayV2CustomAuthorizerSimpleResponse, error) {
+	res := Init(event).
+		Then(VerifyToken).
+		Then(CheckUser)
+
+	if !res.IsSuccess {
+		return events.APIGatewayV2CustomAuthorizerSimpleResponse{IsAuthorized: false}, nil
+	}
+
+	request := res.Value.(AuthorizerRequest)
+
+	return events.APIGatewayV2CustomAuthorizerSimpleResponse{
+		IsAuthorized: true,
+		Context: map[string]interface{}{
+			"userId": request.Claims.Subject,
+		},
+	}, nil
+}
+
+func main() {
+	lambda.Start(Handler)
+}
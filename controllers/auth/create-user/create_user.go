@@ -3,9 +3,12 @@ package main
 import (
 	"password-caddy/api/core/container"
 	"password-caddy/api/core/types"
+	"password-caddy/api/lib/accounts"
+	"password-caddy/api/lib/auth"
 	"password-caddy/api/lib/dynamoclient"
 	"password-caddy/api/lib/logger"
 	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/userevents"
 	"password-caddy/api/lib/util"
 
 	"github.com/aws/aws-lambda-go/events"
@@ -13,7 +16,9 @@ import (
 )
 
 type CreateUserRequest struct {
-	Email string `json:"userId"`
+	Email     string `json:"userId"`
+	SourceIp  string `json:"-"`
+	UserAgent string `json:"-"`
 }
 
 // Initialize the Create User Request
@@ -26,6 +31,9 @@ func Init(event events.APIGatewayProxyRequest) *result.Result {
 		return result.Failure(500, err.Error())
 	}
 
+	request.SourceIp = auth.SourceIp(event)
+	request.UserAgent = auth.UserAgent(event)
+
 	return result.SuccessWithValue(200, request)
 }
 
@@ -63,7 +71,7 @@ func CheckIfUserAlreadyExists(res result.ResultValue) *result.Result {
 
 	// If the requested email is associated with an active user,
 	// fail and do not create another record
-	if user.Status.Value == "ACTIVE" {
+	if user.Status.Value == accounts.STATUS_ACTIVE {
 		logger.Warn(
 			"Failed to create user since email already exists and is active",
 			struct{ Email string }{
@@ -74,6 +82,22 @@ func CheckIfUserAlreadyExists(res result.ResultValue) *result.Result {
 		return result.Failure(409, "Email is already active")
 	}
 
+	// Registering again must not lift the block of an account
+	if accounts.IsBlocked(user) {
+		logger.Security(
+			"Attempted to create a user for a blocked account",
+			struct {
+				Email  string
+				Status string
+			}{
+				Email:  user.UserId.Value,
+				Status: user.Status.Value,
+			},
+		)
+
+		return result.Failure(409, accounts.BlockedMessage(user))
+	}
+
 	logger.Info(
 		"Requested email to create account is acceptable",
 		struct{ Email string }{
@@ -90,8 +114,8 @@ func CreateUser(res result.ResultValue) *result.Result {
 
 	dynamoRequest := dynamoclient.DynamoPutRequest{
 		Key: request.Email,
-		Values: map[string]string{
-			"STATUS": "PENDING_REGISTRATION",
+		Values: map[string]interface{}{
+			"STATUS": accounts.STATUS_PENDING_REGISTRATION,
 		},
 	}
 
@@ -125,6 +149,12 @@ func CreateUser(res result.ResultValue) *result.Result {
 		},
 	)
 
+	userevents.RecordOrLog(container.VaultClient(), request.Email, userevents.Event{
+		Type:      userevents.TYPE_ACCOUNT_CREATED,
+		IpAddress: request.SourceIp,
+		UserAgent: request.UserAgent,
+	})
+
 	return result.SuccessWithValue(201, request)
 }
 
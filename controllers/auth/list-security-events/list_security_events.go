@@ -0,0 +1,77 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/userevents"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// Filter comes from the type, cursor and limit query parameters
+type ListSecurityEventsRequest struct {
+	UserId string
+	Filter userevents.Filter
+}
+
+// Initialize the List Security Events Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	filter, err := userevents.ParseFilter(event.QueryStringParameters)
+
+	if err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	return result.SuccessWithValue(200, ListSecurityEventsRequest{
+		UserId: userId,
+		Filter: filter,
+	})
+}
+
+// Get a page of the user's security events
+func GetEvents(res result.ResultValue) *result.Result {
+	request := res.(ListSecurityEventsRequest)
+
+	response := userevents.List(container.VaultClient(), request.UserId, request.Filter)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch security events",
+			struct {
+				Email string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	return result.SuccessWithValue(200, userevents.ToEventsResponse(response.Data.(userevents.Page)))
+}
+
+// Handle the list security events request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(GetEvents).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"time"
+
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/accounts"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/devices"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/userevents"
+	"password-caddy/api/lib/util"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+/*
+Sent by the web app page of the "this wasn't me" link of a new device email.
+The token proves the request comes from the email, so the caller does not
+need to be logged in
+*/
+type LockAccountRequest struct {
+	Email     string          `json:"-"`
+	Token     string          `json:"token"`
+	SourceIp  string          `json:"-"`
+	UserAgent string          `json:"-"`
+	LockToken types.LockToken `json:"-"`
+}
+
+// Initialize the Lock Account Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	var request LockAccountRequest
+
+	if err := util.DeserializeJson(event.Body, &request); err != nil {
+		return result.Failure(400, "Invalid request body")
+	}
+
+	request.Email = event.PathParameters["email"]
+	request.SourceIp = auth.SourceIp(event)
+	request.UserAgent = auth.UserAgent(event)
+
+	if !accounts.IsValidLockToken(request.Token) {
+		return result.Failure(400, "Invalid lock token")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Check that the token of the link was issued for the user and did not expire
+func VerifyToken(res result.ResultValue) *result.Result {
+	request := res.(LockAccountRequest)
+
+	response := accounts.GetLockToken(container.VaultClient(), request.Email, request.Token)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch lock token",
+			struct {
+				Email string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.Email,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.LockToken = response.Data.(types.LockToken)
+
+	if request.LockToken.ItemKey.Value == "" || accounts.IsExpired(request.LockToken, time.Now()) {
+		logger.Warn(
+			"Attempted to lock an account with an invalid or expired token",
+			struct {
+				Email    string
+				SourceIp string
+			}{
+				Email:    request.Email,
+				SourceIp: request.SourceIp,
+			},
+		)
+
+		return result.Failure(404, "Lock link is invalid or expired")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+/*
+Lock the account and revoke all its sessions. The device and IP address of
+the reported login are forgotten, so logging in from them again after the
+account is unlocked sends another email
+*/
+func LockAccount(res result.ResultValue) *result.Result {
+	request := res.(LockAccountRequest)
+
+	response := accounts.Lock(container.DynamoClient(), request.Email)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to lock account",
+			struct {
+				Email string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.Email,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	logger.Security(
+		"Locked account after a login was reported",
+		struct {
+			Email             string
+			SourceIp          string
+			ReportedIp        string
+			ReportedUserAgent string
+		}{
+			Email:             request.Email,
+			SourceIp:          request.SourceIp,
+			ReportedIp:        request.LockToken.IpAddress.Value,
+			ReportedUserAgent: request.LockToken.UserAgent.Value,
+		},
+	)
+
+	forgotten := devices.Forget(
+		container.VaultClient(),
+		request.Email,
+		request.LockToken.IpAddress.Value,
+		request.LockToken.UserAgent.Value,
+	)
+
+	if !forgotten.IsSuccess {
+		logger.Error(
+			"Failed to forget reported device",
+			struct {
+				Email string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.Email,
+				Error: forgotten.Error,
+			},
+		)
+	}
+
+	deleted := accounts.DeleteLockToken(container.VaultClient(), request.Email, request.Token)
+
+	if !deleted.IsSuccess {
+		logger.Error(
+			"Failed to delete lock token",
+			struct {
+				Email string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.Email,
+				Error: deleted.Error,
+			},
+		)
+	}
+
+	userevents.RecordOrLog(container.VaultClient(), request.Email, userevents.Event{
+		Type:      userevents.TYPE_ACCOUNT_LOCKED,
+		IpAddress: request.SourceIp,
+		UserAgent: request.UserAgent,
+		Detail:    request.LockToken.IpAddress.Value,
+	})
+
+	return result.Success(204)
+}
+
+// Handle the lock account request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(VerifyToken).
+		Then(LockAccount).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
@@ -1,11 +1,16 @@
 package main
 
 import (
+	"time"
+
 	"password-caddy/api/core/container"
 	coreTypes "password-caddy/api/core/types"
+	"password-caddy/api/lib/accounts"
+	"password-caddy/api/lib/auth"
 	"password-caddy/api/lib/dynamoclient"
 	"password-caddy/api/lib/logger"
 	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/userevents"
 	"password-caddy/api/lib/util"
 
 	"github.com/aws/aws-lambda-go/events"
@@ -15,8 +20,10 @@ import (
 )
 
 type LoginChallengeRequest struct {
-	Email string
-	Code  string
+	Email     string
+	Code      string
+	SourceIp  string
+	UserAgent string
 }
 
 // Initialize the Login Challenge Request
@@ -27,8 +34,10 @@ func Init(event events.APIGatewayProxyRequest) *result.Result {
 	return result.SuccessWithValue(
 		200,
 		LoginChallengeRequest{
-			Email: email,
-			Code:  code,
+			Email:     email,
+			Code:      code,
+			SourceIp:  auth.SourceIp(event),
+			UserAgent: auth.UserAgent(event),
 		},
 	)
 }
@@ -89,7 +98,7 @@ func GetEmailStatus(res result.ResultValue) *result.Result {
 	return result.SuccessWithValue(200, request)
 }
 
-// Update the user status in DynamoDB to ACTIVE
+// Update the user status in DynamoDB to ACTIVE. Blocked users stay blocked and can not be challenged
 func UpdateEmailStatusInDynamo(res result.ResultValue) *result.Result {
 	request := res.(LoginChallengeRequest)
 
@@ -98,14 +107,30 @@ func UpdateEmailStatusInDynamo(res result.ResultValue) *result.Result {
 		Values: map[string]dynamoclient.DynamoUpdateItem{
 			"STATUS": {
 				Action: types.AttributeActionPut,
-				Value:  "ACTIVE",
+				Value:  accounts.STATUS_ACTIVE,
 			},
 		},
+		Condition: accounts.NotBlockedCondition(),
 	}
 
 	response := container.DynamoClient().
 		Update(dynamoRequest)
 
+	if !response.IsSuccess && response.Error.StatusCode == 409 {
+		logger.Security(
+			"Attempted to challenge a blocked account",
+			struct {
+				Email    string
+				SourceIp string
+			}{
+				Email:    request.Email,
+				SourceIp: request.SourceIp,
+			},
+		)
+
+		return result.Failure(403, "Account is locked, suspended or scheduled for deletion")
+	}
+
 	if !response.IsSuccess {
 		logger.Error(
 			"Failed to update email status in DynamoDB",
@@ -131,7 +156,7 @@ func UpdateEmailStatusInDynamo(res result.ResultValue) *result.Result {
 			Status string
 		}{
 			Email:  request.Email,
-			Status: "ACTIVE",
+			Status: accounts.STATUS_ACTIVE,
 		},
 	)
 
@@ -139,7 +164,6 @@ func UpdateEmailStatusInDynamo(res result.ResultValue) *result.Result {
 }
 
 // Send the user an OTP via email
-// TODO - add a TTL to the verification code
 func SendEmailChallenge(res result.ResultValue) *result.Result {
 	request := res.(LoginChallengeRequest)
 
@@ -179,22 +203,11 @@ func SendEmailChallenge(res result.ResultValue) *result.Result {
 	return result.SuccessWithValue(202, request)
 }
 
-// Save the OTP in DynamoDB for verification use later
+// Save the OTP in DynamoDB for verification use later. It expires after accounts.CodeTTL
 func AddOTPToDynamo(res result.ResultValue) *result.Result {
 	request := res.(LoginChallengeRequest)
 
-	dynamoRequest := dynamoclient.DyanamoUpdateRequest{
-		Key: request.Email,
-		Values: map[string]dynamoclient.DynamoUpdateItem{
-			"VERIFICATION_CODE": {
-				Action: types.AttributeActionPut,
-				Value:  request.Code,
-			},
-		},
-	}
-
-	response := container.DynamoClient().
-		Update(dynamoRequest)
+	response := accounts.SaveCode(container.DynamoClient(), request.Email, request.Code, time.Now())
 
 	if !response.IsSuccess {
 		logger.Error(
@@ -221,6 +234,12 @@ func AddOTPToDynamo(res result.ResultValue) *result.Result {
 		},
 	)
 
+	userevents.RecordOrLog(container.VaultClient(), request.Email, userevents.Event{
+		Type:      userevents.TYPE_LOGIN_CHALLENGED,
+		IpAddress: request.SourceIp,
+		UserAgent: request.UserAgent,
+	})
+
 	return result.Success(202)
 }
 
@@ -1,20 +1,52 @@
 package main
 
 import (
+	"time"
+
 	"password-caddy/api/core/container"
 	"password-caddy/api/core/types"
+	"password-caddy/api/lib/accounts"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/devices"
 	"password-caddy/api/lib/dynamoclient"
+	"password-caddy/api/lib/geoip"
 	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/policies"
 	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/userevents"
 	"password-caddy/api/lib/util"
 
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 )
 
+// MasterPasswordScore is the strength score (0-4) of the master password,
+// measured by the client since the master password never reaches the server.
+// The score is only as honest as the client, the master password policy is
+// advisory against a modified client
 type LoginVerificationRequest struct {
-	Email string `json:"email"`
-	Code  string `json:"code"`
+	Email               string                  `json:"email"`
+	Code                string                  `json:"code"`
+	MasterPasswordScore int                     `json:"masterPasswordScore"`
+	SourceIp            string                  `json:"-"`
+	UserAgent           string                  `json:"-"`
+	User                types.PasswordCaddyUser `json:"-"`
+	SessionTimeout      int                     `json:"-"`
+	Sighting            devices.Sighting        `json:"-"`
+	Token               string                  `json:"-"`
+	Claims              auth.Claims             `json:"-"`
+}
+
+/*
+Token is the session token the client sends as a bearer token to every other
+endpoint. SessionTimeout is the longest the session can last in minutes when
+an organization limits it. The token expires by then at the latest, locking the
+vault sooner on inactivity is up to the client
+*/
+type LoginVerificationResponse struct {
+	Token          string `json:"token"`
+	ExpiresAt      string `json:"expiresAt"`
+	SessionTimeout int    `json:"sessionTimeout,omitempty"`
 }
 
 func Init(event events.APIGatewayProxyRequest) *result.Result {
@@ -27,11 +59,25 @@ func Init(event events.APIGatewayProxyRequest) *result.Result {
 	}
 
 	request.Email = event.PathParameters["email"]
+	request.SourceIp = auth.SourceIp(event)
+	request.UserAgent = auth.UserAgent(event)
+
+	if request.Code == "" {
+		return result.Failure(400, "Verification code is required")
+	}
+
+	if request.MasterPasswordScore < 0 || request.MasterPasswordScore > policies.MAX_PASSWORD_SCORE {
+		return result.Failure(400, "Master password score must be between 0 and 4")
+	}
 
 	return result.SuccessWithValue(200, request)
 }
 
-// Check to see if the request code matches the one stored
+/*
+Check to see if the request code matches the one stored. Every code is counted
+against the stored one before it is compared, and a matching code is removed
+so it can not log in twice
+*/
 func VerifyCode(res result.ResultValue) *result.Result {
 	request := res.(LoginVerificationRequest)
 
@@ -63,15 +109,102 @@ func VerifyCode(res result.ResultValue) *result.Result {
 
 	user := response.Data.(types.PasswordCaddyUser)
 
-	if user.VerificationCode.Value != request.Code {
+	if accounts.IsBlocked(user) {
+		logger.Security(
+			"Attempted to log into a blocked account",
+			struct {
+				Email    string
+				Status   string
+				SourceIp string
+			}{
+				Email:    request.Email,
+				Status:   user.Status.Value,
+				SourceIp: request.SourceIp,
+			},
+		)
+
+		return result.Failure(403, accounts.BlockedMessage(user))
+	}
+
+	// Users who do not exist or have no code can not be guessed against
+	if user.UserId.Value == "" || user.VerificationCode.Value == "" {
 		logger.Warn(
-			"Requested verification code does not match one on record",
+			"Requested verification code without a code on record",
 			struct{ Email string }{
-				Email: user.UserId.Value,
+				Email: request.Email,
+			},
+		)
+
+		return result.Failure(401, accounts.ErrInvalidCode.Error())
+	}
+
+	reserved := accounts.ReserveCodeAttempt(container.DynamoClient(), request.Email)
+
+	if !reserved.IsSuccess {
+		if reserved.Error.StatusCode != 429 {
+			logger.Error(
+				"Failed to count verification code attempt",
+				struct {
+					Email string
+					Error types.PasswordCaddyError
+				}{
+					Email: request.Email,
+					Error: reserved.Error,
+				},
+			)
+		}
+
+		return result.Failure(
+			reserved.Error.StatusCode,
+			reserved.Error.Message,
+		)
+	}
+
+	if err := accounts.CheckCode(user, request.Code, time.Now()); err != nil {
+		logger.Warn(
+			"Requested verification code does not match one on record",
+			struct {
+				Email string
+				Error string
+			}{
+				Email: request.Email,
+				Error: err.Error(),
 			},
 		)
 
-		return result.Failure(401, "Unauthorized login attempt")
+		userevents.RecordOrLog(container.VaultClient(), request.Email, userevents.Event{
+			Type:      userevents.TYPE_LOGIN_FAILED,
+			IpAddress: request.SourceIp,
+			UserAgent: request.UserAgent,
+		})
+
+		if err == accounts.ErrTooManyAttempts {
+			return result.Failure(429, err.Error())
+		}
+
+		return result.Failure(401, err.Error())
+	}
+
+	consumed := accounts.ConsumeCode(container.DynamoClient(), request.Email, request.Code)
+
+	if !consumed.IsSuccess {
+		if consumed.Error.StatusCode != 401 {
+			logger.Error(
+				"Failed to remove used verification code",
+				struct {
+					Email string
+					Error types.PasswordCaddyError
+				}{
+					Email: request.Email,
+					Error: consumed.Error,
+				},
+			)
+		}
+
+		return result.Failure(
+			consumed.Error.StatusCode,
+			consumed.Error.Message,
+		)
 	}
 
 	logger.Info(
@@ -81,14 +214,246 @@ func VerifyCode(res result.ResultValue) *result.Result {
 		},
 	)
 
-	return result.Success(201)
+	request.User = user
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Check the login against the policies of the user's organizations
+func CheckPolicies(res result.ResultValue) *result.Result {
+	request := res.(LoginVerificationRequest)
+
+	response := policies.Resolve(container.VaultClient(), request.Email)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch organization policies",
+			struct {
+				Email string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.Email,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	effective := response.Data.(policies.Effective)
+
+	violation := effective.CheckPasswordScore(request.MasterPasswordScore)
+
+	if violation != nil {
+		logger.Security(
+			"Login blocked by organization policy",
+			struct {
+				Email  string
+				OrgId  string
+				Policy string
+			}{
+				Email:  request.Email,
+				OrgId:  violation.OrgId,
+				Policy: violation.Policy,
+			},
+		)
+
+		userevents.RecordOrLog(container.VaultClient(), request.Email, userevents.Event{
+			Type:      userevents.TYPE_LOGIN_BLOCKED,
+			IpAddress: request.SourceIp,
+			UserAgent: request.UserAgent,
+			Detail:    violation.Policy,
+		})
+
+		return policies.ViolationResult(violation)
+	}
+
+	userevents.RecordOrLog(container.VaultClient(), request.Email, userevents.Event{
+		Type:      userevents.TYPE_LOGIN_SUCCEEDED,
+		IpAddress: request.SourceIp,
+		UserAgent: request.UserAgent,
+	})
+
+	request.SessionTimeout = effective.SessionTimeout()
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Issue the session token of the user, which lasts no longer than the session timeout policy allows
+func IssueToken(res result.ResultValue) *result.Result {
+	request := res.(LoginVerificationRequest)
+
+	ttl := auth.TokenTTL()
+
+	if timeout := time.Duration(request.SessionTimeout) * time.Minute; timeout > 0 && timeout < ttl {
+		ttl = timeout
+	}
+
+	token, claims, err := auth.IssueToken(auth.TokenSecret(), request.Email, time.Now(), ttl)
+
+	if err != nil {
+		logger.Error(
+			"Failed to issue session token",
+			struct {
+				Email string
+				Error string
+			}{
+				Email: request.Email,
+				Error: err.Error(),
+			},
+		)
+
+		return result.Failure(500, "Failed to issue session token")
+	}
+
+	request.Token = token
+	request.Claims = claims
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Compare the device and IP address of the login to the known ones, then remember them
+func CheckDevice(res result.ResultValue) *result.Result {
+	request := res.(LoginVerificationRequest)
+
+	response := devices.Check(container.VaultClient(), request.Email, request.SourceIp, request.UserAgent)
+
+	// The login goes through without a new device email when the known devices can not be read
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch known devices",
+			struct {
+				Email string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.Email,
+				Error: response.Error,
+			},
+		)
+
+		return result.SuccessWithValue(200, request)
+	}
+
+	request.Sighting = response.Data.(devices.Sighting)
+
+	remembered := devices.Remember(container.VaultClient(), request.Email, request.SourceIp, request.UserAgent, request.Sighting)
+
+	if !remembered.IsSuccess {
+		logger.Error(
+			"Failed to remember device",
+			struct {
+				Email string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.Email,
+				Error: remembered.Error,
+			},
+		)
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+/*
+Email the user when the login comes from a device or IP address they never
+logged in from, with a link to lock the account when it was not them
+*/
+func NotifyNewDevice(res result.ResultValue) *result.Result {
+	request := res.(LoginVerificationRequest)
+
+	response := LoginVerificationResponse{
+		Token:          request.Token,
+		ExpiresAt:      time.Unix(request.Claims.ExpiresAt, 0).UTC().Format(time.RFC3339),
+		SessionTimeout: request.SessionTimeout,
+	}
+
+	if !request.Sighting.IsSuspicious() {
+		return result.SuccessWithValue(201, response)
+	}
+
+	location := geoip.Describe(request.SourceIp)
+
+	logger.Security(
+		"Login from a new device or IP address",
+		struct {
+			Email     string
+			SourceIp  string
+			UserAgent string
+			Location  string
+			NewDevice bool
+			NewIp     bool
+		}{
+			Email:     request.Email,
+			SourceIp:  request.SourceIp,
+			UserAgent: request.UserAgent,
+			Location:  location,
+			NewDevice: request.Sighting.NewDevice,
+			NewIp:     request.Sighting.NewIp,
+		},
+	)
+
+	userevents.RecordOrLog(container.VaultClient(), request.Email, userevents.Event{
+		Type:      userevents.TYPE_NEW_DEVICE_LOGIN,
+		IpAddress: request.SourceIp,
+		UserAgent: request.UserAgent,
+		Detail:    location,
+	})
+
+	token := accounts.CreateLockToken(container.VaultClient(), request.Email, request.SourceIp, request.UserAgent)
+
+	if !token.IsSuccess {
+		logger.Error(
+			"Failed to create lock token",
+			struct {
+				Email string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.Email,
+				Error: token.Error,
+			},
+		)
+
+		return result.SuccessWithValue(201, response)
+	}
+
+	sent := container.SesClient().
+		BuildNewDeviceEmailRequest(
+			request.Email,
+			time.Now().UTC().Format(time.RFC1123),
+			request.UserAgent,
+			request.SourceIp,
+			location,
+			accounts.LockLink(request.Email, token.Data.(string)),
+		).
+		Send()
+
+	if !sent.IsSuccess {
+		logger.Error(
+			"Failed to send new device email",
+			struct {
+				Email string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.Email,
+				Error: sent.Error,
+			},
+		)
+	}
+
+	return result.SuccessWithValue(201, response)
 }
 
 // Handle the login verification request
-// TODO - Create JWT Auth token and send to in response
 func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
 	return Init(event).
 		Then(VerifyCode).
+		Then(CheckPolicies).
+		Then(IssueToken).
+		Then(CheckDevice).
+		Then(NotifyNewDevice).
 		ToAPIGatewayResponse()
 }
 
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/emergency"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/result"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type AcceptEmergencyAccessRequest struct {
+	UserId   string
+	AccessId string
+	Access   types.EmergencyAccess
+}
+
+// Initialize the Accept Emergency Access Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	request := AcceptEmergencyAccessRequest{
+		UserId:   userId,
+		AccessId: event.PathParameters["id"],
+	}
+
+	if !emergency.IsValidAccessId(request.AccessId) {
+		return result.Failure(400, "Emergency access id must be a UUID")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Get the emergency access the user was invited to
+func GetAccess(res result.ResultValue) *result.Result {
+	request := res.(AcceptEmergencyAccessRequest)
+
+	response := emergency.GetGranted(container.VaultClient(), request.UserId, request.AccessId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch emergency access",
+			struct {
+				Email    string
+				AccessId string
+				Error    types.PasswordCaddyError
+			}{
+				Email:    request.UserId,
+				AccessId: request.AccessId,
+				Error:    response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Access = response.Data.(types.EmergencyAccess)
+
+	if request.Access.AccessId.Value == "" {
+		return result.Failure(404, "Emergency access not found")
+	}
+
+	if request.Access.Status.Value != emergency.STATUS_INVITED {
+		return result.Failure(409, "Emergency access was already accepted")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Accept the invite. The grantor confirms it afterwards
+func AcceptAccess(res result.ResultValue) *result.Result {
+	request := res.(AcceptEmergencyAccessRequest)
+
+	response := emergency.Accept(container.VaultClient(), request.Access)
+
+	if !response.IsSuccess && response.Error.StatusCode == 409 {
+		return result.Failure(409, "Emergency access is no longer invited")
+	}
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to accept emergency access",
+			struct {
+				Email    string
+				AccessId string
+				Error    types.PasswordCaddyError
+			}{
+				Email:    request.UserId,
+				AccessId: request.AccessId,
+				Error:    response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	logger.Info(
+		"Accepted emergency access",
+		struct {
+			Email    string
+			AccessId string
+			Grantor  string
+		}{
+			Email:    request.UserId,
+			AccessId: request.AccessId,
+			Grantor:  request.Access.GrantorId.Value,
+		},
+	)
+
+	return result.SuccessWithValue(200, emergency.ToGranteeResponse(response.Data.(types.EmergencyAccess)))
+}
+
+// Handle the accept emergency access request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(GetAccess).
+		Then(AcceptAccess).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
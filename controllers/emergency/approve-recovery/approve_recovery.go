@@ -0,0 +1,169 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/emergency"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/result"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type ApproveRecoveryRequest struct {
+	UserId   string
+	AccessId string
+	Access   types.EmergencyAccess
+}
+
+// Initialize the Approve Recovery Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	request := ApproveRecoveryRequest{
+		UserId:   userId,
+		AccessId: event.PathParameters["id"],
+	}
+
+	if !emergency.IsValidAccessId(request.AccessId) {
+		return result.Failure(400, "Emergency access id must be a UUID")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Get the emergency access the user granted
+func GetAccess(res result.ResultValue) *result.Result {
+	request := res.(ApproveRecoveryRequest)
+
+	response := emergency.Get(container.VaultClient(), request.UserId, request.AccessId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch emergency access",
+			struct {
+				Email    string
+				AccessId string
+				Error    types.PasswordCaddyError
+			}{
+				Email:    request.UserId,
+				AccessId: request.AccessId,
+				Error:    response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Access = response.Data.(types.EmergencyAccess)
+
+	if request.Access.AccessId.Value == "" {
+		return result.Failure(404, "Emergency access not found")
+	}
+
+	if request.Access.Status.Value != emergency.STATUS_RECOVERY_INITIATED {
+		return result.Failure(409, "No recovery was initiated for this emergency access")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Approve the recovery without waiting for the end of the waiting period
+func ApproveRecovery(res result.ResultValue) *result.Result {
+	request := res.(ApproveRecoveryRequest)
+
+	response := emergency.Approve(container.VaultClient(), request.Access)
+
+	if !response.IsSuccess && response.Error.StatusCode == 409 {
+		return result.Failure(409, "Recovery is no longer initiated")
+	}
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to approve emergency recovery",
+			struct {
+				Email    string
+				AccessId string
+				Error    types.PasswordCaddyError
+			}{
+				Email:    request.UserId,
+				AccessId: request.AccessId,
+				Error:    response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Access = response.Data.(types.EmergencyAccess)
+
+	logger.Security(
+		"Approved emergency recovery",
+		struct {
+			Email    string
+			AccessId string
+			Grantee  string
+			Type     string
+		}{
+			Email:    request.UserId,
+			AccessId: request.AccessId,
+			Grantee:  request.Access.GranteeId.Value,
+			Type:     request.Access.Type.Value,
+		},
+	)
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Email the grantee that the vault is accessible
+func SendApprovedEmail(res result.ResultValue) *result.Result {
+	request := res.(ApproveRecoveryRequest)
+
+	response := container.SesClient().
+		BuildEmergencyApprovedEmailRequest(request.Access.GranteeId.Value, request.UserId, emergency.AccessUrl()).
+		Send()
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to send emergency recovery approved email",
+			struct {
+				Email    string
+				AccessId string
+				Grantee  string
+				Error    types.PasswordCaddyError
+			}{
+				Email:    request.UserId,
+				AccessId: request.AccessId,
+				Grantee:  request.Access.GranteeId.Value,
+				Error:    response.Error,
+			},
+		)
+	}
+
+	return result.SuccessWithValue(200, emergency.ToAccessResponse(request.Access))
+}
+
+// Handle the approve recovery request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(GetAccess).
+		Then(ApproveRecovery).
+		Then(SendApprovedEmail).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
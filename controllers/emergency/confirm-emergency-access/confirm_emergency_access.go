@@ -0,0 +1,150 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/emergency"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/util"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type ConfirmEmergencyAccessRequest struct {
+	UserId   string
+	AccessId string
+	Confirm  emergency.ConfirmRequest
+	Access   types.EmergencyAccess
+}
+
+// Initialize the Confirm Emergency Access Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	var request ConfirmEmergencyAccessRequest
+
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	err := util.DeserializeJson(event.Body, &request.Confirm)
+
+	if err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	request.UserId = userId
+	request.AccessId = event.PathParameters["id"]
+
+	if !emergency.IsValidAccessId(request.AccessId) {
+		return result.Failure(400, "Emergency access id must be a UUID")
+	}
+
+	if err := request.Confirm.Validate(); err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Get the emergency access the user granted
+func GetAccess(res result.ResultValue) *result.Result {
+	request := res.(ConfirmEmergencyAccessRequest)
+
+	response := emergency.Get(container.VaultClient(), request.UserId, request.AccessId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch emergency access",
+			struct {
+				Email    string
+				AccessId string
+				Error    types.PasswordCaddyError
+			}{
+				Email:    request.UserId,
+				AccessId: request.AccessId,
+				Error:    response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Access = response.Data.(types.EmergencyAccess)
+
+	if request.Access.AccessId.Value == "" {
+		return result.Failure(404, "Emergency access not found")
+	}
+
+	if request.Access.Status.Value != emergency.STATUS_ACCEPTED {
+		return result.Failure(409, "Only accepted emergency contacts can be confirmed")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Confirm the grantee with the user key wrapped with the grantee's public key
+func ConfirmAccess(res result.ResultValue) *result.Result {
+	request := res.(ConfirmEmergencyAccessRequest)
+
+	response := emergency.Confirm(container.VaultClient(), request.Access, request.Confirm.Key)
+
+	if !response.IsSuccess && response.Error.StatusCode == 409 {
+		return result.Failure(409, "Emergency access is no longer accepted")
+	}
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to confirm emergency access",
+			struct {
+				Email    string
+				AccessId string
+				Error    types.PasswordCaddyError
+			}{
+				Email:    request.UserId,
+				AccessId: request.AccessId,
+				Error:    response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	logger.Security(
+		"Confirmed emergency contact",
+		struct {
+			Email    string
+			AccessId string
+			Grantee  string
+			Type     string
+		}{
+			Email:    request.UserId,
+			AccessId: request.AccessId,
+			Grantee:  request.Access.GranteeId.Value,
+			Type:     request.Access.Type.Value,
+		},
+	)
+
+	return result.SuccessWithValue(200, emergency.ToAccessResponse(response.Data.(types.EmergencyAccess)))
+}
+
+// Handle the confirm emergency access request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(GetAccess).
+		Then(ConfirmAccess).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
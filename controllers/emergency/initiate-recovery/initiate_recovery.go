@@ -0,0 +1,191 @@
+package main
+
+import (
+	"time"
+
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/emergency"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/userevents"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type InitiateRecoveryRequest struct {
+	UserId    string
+	SourceIp  string
+	UserAgent string
+	AccessId  string
+	Access    types.EmergencyAccess
+}
+
+// Initialize the Initiate Recovery Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	request := InitiateRecoveryRequest{
+		UserId:    userId,
+		SourceIp:  auth.SourceIp(event),
+		UserAgent: auth.UserAgent(event),
+		AccessId:  event.PathParameters["id"],
+	}
+
+	if !emergency.IsValidAccessId(request.AccessId) {
+		return result.Failure(400, "Emergency access id must be a UUID")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Get the emergency access the user was granted
+func GetAccess(res result.ResultValue) *result.Result {
+	request := res.(InitiateRecoveryRequest)
+
+	response := emergency.GetGranted(container.VaultClient(), request.UserId, request.AccessId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch emergency access",
+			struct {
+				Email    string
+				AccessId string
+				Error    types.PasswordCaddyError
+			}{
+				Email:    request.UserId,
+				AccessId: request.AccessId,
+				Error:    response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Access = response.Data.(types.EmergencyAccess)
+
+	if request.Access.AccessId.Value == "" {
+		return result.Failure(404, "Emergency access not found")
+	}
+
+	if request.Access.Status.Value != emergency.STATUS_CONFIRMED {
+		return result.Failure(409, "Recovery can only be initiated for a confirmed emergency access")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Start the waiting period of the recovery
+func InitiateRecovery(res result.ResultValue) *result.Result {
+	request := res.(InitiateRecoveryRequest)
+
+	response := emergency.Initiate(container.VaultClient(), request.Access, time.Now())
+
+	if !response.IsSuccess && response.Error.StatusCode == 409 {
+		return result.Failure(409, "Emergency access is no longer confirmed")
+	}
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to initiate emergency recovery",
+			struct {
+				Email    string
+				AccessId string
+				Error    types.PasswordCaddyError
+			}{
+				Email:    request.UserId,
+				AccessId: request.AccessId,
+				Error:    response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Access = response.Data.(types.EmergencyAccess)
+
+	logger.Security(
+		"Initiated emergency recovery",
+		struct {
+			Email      string
+			AccessId   string
+			Grantor    string
+			Type       string
+			RecoveryAt string
+		}{
+			Email:      request.UserId,
+			AccessId:   request.AccessId,
+			Grantor:    request.Access.GrantorId.Value,
+			Type:       request.Access.Type.Value,
+			RecoveryAt: request.Access.RecoveryAt.Value,
+		},
+	)
+
+	userevents.RecordOrLog(container.VaultClient(), request.Access.GrantorId.Value, userevents.Event{
+		Type:      userevents.TYPE_EMERGENCY_INITIATED,
+		ActorId:   request.UserId,
+		IpAddress: request.SourceIp,
+		UserAgent: request.UserAgent,
+	})
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Email the grantor so they can reject the recovery before the waiting period elapses
+func SendRequestEmail(res result.ResultValue) *result.Result {
+	request := res.(InitiateRecoveryRequest)
+
+	response := container.SesClient().
+		BuildEmergencyRequestEmailRequest(
+			request.Access.GrantorId.Value,
+			request.UserId,
+			request.Access.Type.Value,
+			request.Access.WaitDays.Value,
+			emergency.AccessUrl(),
+		).
+		Send()
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to send emergency recovery request email",
+			struct {
+				Email    string
+				AccessId string
+				Grantor  string
+				Error    types.PasswordCaddyError
+			}{
+				Email:    request.UserId,
+				AccessId: request.AccessId,
+				Grantor:  request.Access.GrantorId.Value,
+				Error:    response.Error,
+			},
+		)
+	}
+
+	return result.SuccessWithValue(200, emergency.ToGranteeResponse(request.Access))
+}
+
+// Handle the initiate recovery request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(GetAccess).
+		Then(InitiateRecovery).
+		Then(SendRequestEmail).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/emergency"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/util"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type InviteContactRequest struct {
+	UserId string
+	Invite emergency.InviteRequest
+	Access types.EmergencyAccess
+}
+
+// Initialize the Invite Contact Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	var request InviteContactRequest
+
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	err := util.DeserializeJson(event.Body, &request.Invite)
+
+	if err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	request.UserId = userId
+
+	if err := request.Invite.Validate(userId); err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Save the invited emergency access
+func SaveInvite(res result.ResultValue) *result.Result {
+	request := res.(InviteContactRequest)
+
+	response := emergency.Invite(container.VaultClient(), request.UserId, request.Invite)
+
+	if !response.IsSuccess && response.Error.StatusCode == 409 {
+		return result.Failure(409, response.Error.Message)
+	}
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to invite emergency contact",
+			struct {
+				Email   string
+				Grantee string
+				Error   types.PasswordCaddyError
+			}{
+				Email:   request.UserId,
+				Grantee: request.Invite.Grantee,
+				Error:   response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Access = response.Data.(types.EmergencyAccess)
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Email the invite. The invite stays valid when the email fails, it is listed in the grantee's emergency access
+func SendInviteEmail(res result.ResultValue) *result.Result {
+	request := res.(InviteContactRequest)
+
+	response := container.SesClient().
+		BuildEmergencyInviteEmailRequest(request.Invite.Grantee, request.UserId, emergency.AccessUrl()).
+		Send()
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to send emergency contact invite email",
+			struct {
+				Email   string
+				Grantee string
+				Error   types.PasswordCaddyError
+			}{
+				Email:   request.UserId,
+				Grantee: request.Invite.Grantee,
+				Error:   response.Error,
+			},
+		)
+	}
+
+	logger.Security(
+		"Invited emergency contact",
+		struct {
+			Email    string
+			Grantee  string
+			Type     string
+			WaitDays int
+		}{
+			Email:    request.UserId,
+			Grantee:  request.Invite.Grantee,
+			Type:     request.Access.Type.Value,
+			WaitDays: request.Access.WaitDays.Value,
+		},
+	)
+
+	return result.SuccessWithValue(201, emergency.ToAccessResponse(request.Access))
+}
+
+// Handle the invite emergency contact request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(SaveInvite).
+		Then(SendInviteEmail).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
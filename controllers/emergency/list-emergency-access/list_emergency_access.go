@@ -0,0 +1,83 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/emergency"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/result"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type ListEmergencyAccessRequest struct {
+	UserId string
+}
+
+// Initialize the List Emergency Access Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	return result.SuccessWithValue(200, ListEmergencyAccessRequest{UserId: userId})
+}
+
+// List the user's trusted contacts and the users who trust the user
+func ListEmergencyAccess(res result.ResultValue) *result.Result {
+	request := res.(ListEmergencyAccessRequest)
+
+	list := emergency.EmergencyAccessListResponse{
+		Trusted: []emergency.EmergencyAccessResponse{},
+		Granted: []emergency.EmergencyAccessResponse{},
+	}
+
+	response := emergency.ListTrusted(container.VaultClient(), request.UserId)
+
+	if response.IsSuccess {
+		for _, access := range response.Data.([]types.EmergencyAccess) {
+			list.Trusted = append(list.Trusted, emergency.ToAccessResponse(access))
+		}
+
+		response = emergency.ListGranted(container.VaultClient(), request.UserId)
+	}
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to list emergency access",
+			struct {
+				Email string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	for _, access := range response.Data.([]types.EmergencyAccess) {
+		list.Granted = append(list.Granted, emergency.ToGranteeResponse(access))
+	}
+
+	return result.SuccessWithValue(200, list)
+}
+
+// Handle the list emergency access request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(ListEmergencyAccess).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/emergency"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/result"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type RejectRecoveryRequest struct {
+	UserId   string
+	AccessId string
+	Access   types.EmergencyAccess
+}
+
+// Initialize the Reject Recovery Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	request := RejectRecoveryRequest{
+		UserId:   userId,
+		AccessId: event.PathParameters["id"],
+	}
+
+	if !emergency.IsValidAccessId(request.AccessId) {
+		return result.Failure(400, "Emergency access id must be a UUID")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Get the emergency access the user granted
+func GetAccess(res result.ResultValue) *result.Result {
+	request := res.(RejectRecoveryRequest)
+
+	response := emergency.Get(container.VaultClient(), request.UserId, request.AccessId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch emergency access",
+			struct {
+				Email    string
+				AccessId string
+				Error    types.PasswordCaddyError
+			}{
+				Email:    request.UserId,
+				AccessId: request.AccessId,
+				Error:    response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Access = response.Data.(types.EmergencyAccess)
+
+	if request.Access.AccessId.Value == "" {
+		return result.Failure(404, "Emergency access not found")
+	}
+
+	status := request.Access.Status.Value
+
+	if status != emergency.STATUS_RECOVERY_INITIATED && status != emergency.STATUS_RECOVERY_APPROVED {
+		return result.Failure(409, "No recovery was initiated for this emergency access")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Reject the recovery, or take back an approved one. The grantee stays a trusted contact
+func RejectRecovery(res result.ResultValue) *result.Result {
+	request := res.(RejectRecoveryRequest)
+
+	response := emergency.Reject(container.VaultClient(), request.Access)
+
+	if !response.IsSuccess && response.Error.StatusCode == 409 {
+		return result.Failure(409, "Recovery changed in the meantime")
+	}
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to reject emergency recovery",
+			struct {
+				Email    string
+				AccessId string
+				Error    types.PasswordCaddyError
+			}{
+				Email:    request.UserId,
+				AccessId: request.AccessId,
+				Error:    response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	logger.Security(
+		"Rejected emergency recovery",
+		struct {
+			Email    string
+			AccessId string
+			Grantee  string
+			Status   string
+		}{
+			Email:    request.UserId,
+			AccessId: request.AccessId,
+			Grantee:  request.Access.GranteeId.Value,
+			Status:   request.Access.Status.Value,
+		},
+	)
+
+	request.Access = response.Data.(types.EmergencyAccess)
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Email the grantee that the recovery was rejected
+func SendRejectedEmail(res result.ResultValue) *result.Result {
+	request := res.(RejectRecoveryRequest)
+
+	response := container.SesClient().
+		BuildEmergencyRejectedEmailRequest(request.Access.GranteeId.Value, request.UserId).
+		Send()
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to send emergency recovery rejected email",
+			struct {
+				Email    string
+				AccessId string
+				Grantee  string
+				Error    types.PasswordCaddyError
+			}{
+				Email:    request.UserId,
+				AccessId: request.AccessId,
+				Grantee:  request.Access.GranteeId.Value,
+				Error:    response.Error,
+			},
+		)
+	}
+
+	return result.SuccessWithValue(200, emergency.ToAccessResponse(request.Access))
+}
+
+// Handle the reject recovery request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(GetAccess).
+		Then(RejectRecovery).
+		Then(SendRejectedEmail).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/emergency"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/result"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type RemoveEmergencyAccessRequest struct {
+	UserId   string
+	AccessId string
+	Access   types.EmergencyAccess
+}
+
+// Initialize the Remove Emergency Access Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	request := RemoveEmergencyAccessRequest{
+		UserId:   userId,
+		AccessId: event.PathParameters["id"],
+	}
+
+	if !emergency.IsValidAccessId(request.AccessId) {
+		return result.Failure(400, "Emergency access id must be a UUID")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Get the emergency access, either granted by the user or to the user
+func GetAccess(res result.ResultValue) *result.Result {
+	request := res.(RemoveEmergencyAccessRequest)
+
+	response := emergency.Get(container.VaultClient(), request.UserId, request.AccessId)
+
+	if response.IsSuccess && response.Data.(types.EmergencyAccess).AccessId.Value == "" {
+		response = emergency.GetGranted(container.VaultClient(), request.UserId, request.AccessId)
+	}
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch emergency access",
+			struct {
+				Email    string
+				AccessId string
+				Error    types.PasswordCaddyError
+			}{
+				Email:    request.UserId,
+				AccessId: request.AccessId,
+				Error:    response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Access = response.Data.(types.EmergencyAccess)
+
+	if request.Access.AccessId.Value == "" {
+		return result.Failure(404, "Emergency access not found")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Remove the emergency access for both the grantor and the grantee
+func RemoveAccess(res result.ResultValue) *result.Result {
+	request := res.(RemoveEmergencyAccessRequest)
+
+	response := emergency.Remove(container.VaultClient(), request.Access)
+
+	if !response.IsSuccess && response.Error.StatusCode == 409 {
+		return result.Failure(404, "Emergency access not found")
+	}
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to remove emergency access",
+			struct {
+				Email    string
+				AccessId string
+				Error    types.PasswordCaddyError
+			}{
+				Email:    request.UserId,
+				AccessId: request.AccessId,
+				Error:    response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	logger.Security(
+		"Removed emergency access",
+		struct {
+			Email    string
+			AccessId string
+			Grantor  string
+			Grantee  string
+			Status   string
+		}{
+			Email:    request.UserId,
+			AccessId: request.AccessId,
+			Grantor:  request.Access.GrantorId.Value,
+			Grantee:  request.Access.GranteeId.Value,
+			Status:   request.Access.Status.Value,
+		},
+	)
+
+	return result.Success(204)
+}
+
+// Handle the remove emergency access request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(GetAccess).
+		Then(RemoveAccess).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
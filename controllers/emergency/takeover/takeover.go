@@ -0,0 +1,308 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/emergency"
+	"password-caddy/api/lib/keys"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/orgs"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/shares"
+	"password-caddy/api/lib/userevents"
+	"password-caddy/api/lib/util"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// KeyPair is the grantor's new key pair, its private key encrypted with a key
+// chosen during the takeover and its organization and share keys re-wrapped
+type TakeoverRequest struct {
+	UserId    string
+	SourceIp  string
+	UserAgent string
+	AccessId  string
+	KeyPair   keys.KeyPairRequest
+	Access    types.EmergencyAccess
+	Received  []types.ItemShare
+}
+
+// Initialize the Takeover Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	var request TakeoverRequest
+
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	err := util.DeserializeJson(event.Body, &request.KeyPair)
+
+	if err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	request.UserId = userId
+	request.SourceIp = auth.SourceIp(event)
+	request.UserAgent = auth.UserAgent(event)
+	request.AccessId = event.PathParameters["id"]
+
+	if !emergency.IsValidAccessId(request.AccessId) {
+		return result.Failure(400, "Emergency access id must be a UUID")
+	}
+
+	if err := request.KeyPair.Validate(); err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Get the emergency access the user was granted and check that it allows a takeover
+func GetAccess(res result.ResultValue) *result.Result {
+	request := res.(TakeoverRequest)
+
+	response := emergency.GetGranted(container.VaultClient(), request.UserId, request.AccessId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch emergency access",
+			struct {
+				Email    string
+				AccessId string
+				Error    types.PasswordCaddyError
+			}{
+				Email:    request.UserId,
+				AccessId: request.AccessId,
+				Error:    response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Access = response.Data.(types.EmergencyAccess)
+
+	if request.Access.AccessId.Value == "" {
+		return result.Failure(404, "Emergency access not found")
+	}
+
+	if !emergency.CanTakeover(request.Access) {
+		logger.Security(
+			"Attempted an account takeover without an approved emergency recovery",
+			struct {
+				Email    string
+				AccessId string
+				Grantor  string
+				Type     string
+				Status   string
+			}{
+				Email:    request.UserId,
+				AccessId: request.AccessId,
+				Grantor:  request.Access.GrantorId.Value,
+				Type:     request.Access.Type.Value,
+				Status:   request.Access.Status.Value,
+			},
+		)
+
+		return result.Failure(403, "Emergency access does not allow a takeover")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Check that the client saw the grantor's current key pair
+func CheckRevision(res result.ResultValue) *result.Result {
+	request := res.(TakeoverRequest)
+
+	response := keys.Get(container.VaultClient(), request.Access.GrantorId.Value)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch key pair",
+			struct {
+				Email   string
+				Grantor string
+				Error   types.PasswordCaddyError
+			}{
+				Email:   request.UserId,
+				Grantor: request.Access.GrantorId.Value,
+				Error:   response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	current := response.Data.(types.UserKeys)
+
+	if current.Revision.Value != request.KeyPair.Revision {
+		return keys.ConflictResult(current)
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Check that the key of every organization the grantor is a confirmed member of is re-wrapped
+func CheckOrgKeys(res result.ResultValue) *result.Result {
+	request := res.(TakeoverRequest)
+
+	response := orgs.ListUserOrgs(container.VaultClient(), request.Access.GrantorId.Value)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch organizations",
+			struct {
+				Email   string
+				Grantor string
+				Error   types.PasswordCaddyError
+			}{
+				Email:   request.UserId,
+				Grantor: request.Access.GrantorId.Value,
+				Error:   response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	missing, unknown := keys.CompareOrgKeys(request.KeyPair.OrgKeys, response.Data.([]orgs.UserOrg))
+
+	if len(unknown) > 0 {
+		return result.FailureWithDetails(400, "Organization keys can only be re-wrapped for confirmed memberships", unknown)
+	}
+
+	if len(missing) > 0 {
+		return result.FailureWithDetails(409, "The key of every organization must be re-wrapped with the new public key", missing)
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Check that the item key of every share the grantor received is re-wrapped
+func CheckShareKeys(res result.ResultValue) *result.Result {
+	request := res.(TakeoverRequest)
+
+	response := shares.ListReceived(container.VaultClient(), request.Access.GrantorId.Value)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch received shares",
+			struct {
+				Email   string
+				Grantor string
+				Error   types.PasswordCaddyError
+			}{
+				Email:   request.UserId,
+				Grantor: request.Access.GrantorId.Value,
+				Error:   response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Received = response.Data.([]types.ItemShare)
+
+	missing, unknown := keys.CompareShareKeys(request.KeyPair.ShareKeys, request.Received)
+
+	if len(unknown) > 0 {
+		return result.FailureWithDetails(400, "Share keys can only be re-wrapped for received shares", unknown)
+	}
+
+	if len(missing) > 0 {
+		return result.FailureWithDetails(409, "The key of every received share must be re-wrapped with the new public key", missing)
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Replace the grantor's key pair
+func SaveKeys(res result.ResultValue) *result.Result {
+	request := res.(TakeoverRequest)
+	grantorId := request.Access.GrantorId.Value
+
+	response := keys.Save(container.VaultClient(), grantorId, request.KeyPair, request.Received)
+
+	if !response.IsSuccess && response.Error.StatusCode == 409 {
+		return result.Failure(409, "Key pair, memberships or shares changed during the takeover")
+	}
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to save key pair during takeover",
+			struct {
+				Email   string
+				Grantor string
+				Error   types.PasswordCaddyError
+			}{
+				Email:   request.UserId,
+				Grantor: grantorId,
+				Error:   response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	saved := response.Data.(types.UserKeys)
+
+	logger.Security(
+		"Took over account through emergency access",
+		struct {
+			Email       string
+			AccessId    string
+			Grantor     string
+			Fingerprint string
+			Revision    int
+		}{
+			Email:       request.UserId,
+			AccessId:    request.AccessId,
+			Grantor:     grantorId,
+			Fingerprint: saved.Fingerprint.Value,
+			Revision:    saved.Revision.Value,
+		},
+	)
+
+	userevents.RecordOrLog(container.VaultClient(), grantorId, userevents.Event{
+		Type:      userevents.TYPE_ACCOUNT_TAKEN_OVER,
+		ActorId:   request.UserId,
+		IpAddress: request.SourceIp,
+		UserAgent: request.UserAgent,
+	})
+
+	return result.SuccessWithValue(200, keys.ToKeyPairResponse(saved))
+}
+
+// Handle the emergency takeover request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(GetAccess).
+		Then(CheckRevision).
+		Then(CheckOrgKeys).
+		Then(CheckShareKeys).
+		Then(SaveKeys).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
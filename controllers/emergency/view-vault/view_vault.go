@@ -0,0 +1,183 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/emergency"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/userevents"
+	"password-caddy/api/lib/vault"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type ViewVaultRequest struct {
+	UserId    string
+	SourceIp  string
+	UserAgent string
+	AccessId  string
+	Access    types.EmergencyAccess
+}
+
+// Initialize the View Vault Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	request := ViewVaultRequest{
+		UserId:    userId,
+		SourceIp:  auth.SourceIp(event),
+		UserAgent: auth.UserAgent(event),
+		AccessId:  event.PathParameters["id"],
+	}
+
+	if !emergency.IsValidAccessId(request.AccessId) {
+		return result.Failure(400, "Emergency access id must be a UUID")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Get the emergency access the user was granted and check that its recovery was approved
+func GetAccess(res result.ResultValue) *result.Result {
+	request := res.(ViewVaultRequest)
+
+	response := emergency.GetGranted(container.VaultClient(), request.UserId, request.AccessId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch emergency access",
+			struct {
+				Email    string
+				AccessId string
+				Error    types.PasswordCaddyError
+			}{
+				Email:    request.UserId,
+				AccessId: request.AccessId,
+				Error:    response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Access = response.Data.(types.EmergencyAccess)
+
+	if request.Access.AccessId.Value == "" {
+		return result.Failure(404, "Emergency access not found")
+	}
+
+	if !emergency.CanView(request.Access) {
+		logger.Security(
+			"Attempted to view a vault without an approved emergency recovery",
+			struct {
+				Email    string
+				AccessId string
+				Grantor  string
+				Status   string
+			}{
+				Email:    request.UserId,
+				AccessId: request.AccessId,
+				Grantor:  request.Access.GrantorId.Value,
+				Status:   request.Access.Status.Value,
+			},
+		)
+
+		return result.Failure(403, "Emergency recovery was not approved")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Get the items and folders of the grantor's vault, leaving out the trash
+func GetVault(res result.ResultValue) *result.Result {
+	request := res.(ViewVaultRequest)
+	grantorId := request.Access.GrantorId.Value
+
+	vaultResponse := emergency.EmergencyVaultResponse{
+		Access:  emergency.ToGranteeResponse(request.Access),
+		Items:   []vault.VaultItemResponse{},
+		Folders: []vault.VaultFolderResponse{},
+	}
+
+	response := vault.ListItems(container.VaultClient(), grantorId)
+
+	if response.IsSuccess {
+		for _, item := range response.Data.([]types.VaultItem) {
+			if item.DeletedAt.Value == "" {
+				vaultResponse.Items = append(vaultResponse.Items, vault.ToItemResponse(item))
+			}
+		}
+
+		response = vault.ListFolders(container.VaultClient(), grantorId)
+	}
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch vault of emergency access",
+			struct {
+				Email    string
+				AccessId string
+				Grantor  string
+				Error    types.PasswordCaddyError
+			}{
+				Email:    request.UserId,
+				AccessId: request.AccessId,
+				Grantor:  grantorId,
+				Error:    response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	for _, folder := range response.Data.([]types.VaultFolder) {
+		vaultResponse.Folders = append(vaultResponse.Folders, vault.ToFolderResponse(folder))
+	}
+
+	logger.Security(
+		"Viewed vault through emergency access",
+		struct {
+			Email    string
+			AccessId string
+			Grantor  string
+		}{
+			Email:    request.UserId,
+			AccessId: request.AccessId,
+			Grantor:  grantorId,
+		},
+	)
+
+	userevents.RecordOrLog(container.VaultClient(), grantorId, userevents.Event{
+		Type:      userevents.TYPE_EMERGENCY_VAULT_VIEWED,
+		ActorId:   request.UserId,
+		IpAddress: request.SourceIp,
+		UserAgent: request.UserAgent,
+	})
+
+	return result.SuccessWithValue(200, vaultResponse)
+}
+
+// Handle the view emergency vault request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(GetAccess).
+		Then(GetVault).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
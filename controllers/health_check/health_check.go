@@ -17,7 +17,7 @@ func Init() *result.Result {
 	request := HealthCheckResponse{
 		Status:  200,
 		Message: "Password Caddy is up and running",
-		Version: "0.0.9",
+		Version: "0.0.34",
 	}
 
 	return result.SuccessWithValue(200, request)
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/keys"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/result"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// Initialize the Get Keys Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	return result.SuccessWithValue(200, userId)
+}
+
+// Get the key pair of the user with the encrypted private key
+func GetKeys(res result.ResultValue) *result.Result {
+	userId := res.(string)
+
+	response := keys.Get(container.VaultClient(), userId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch key pair",
+			struct {
+				Email string
+				Error types.PasswordCaddyError
+			}{
+				Email: userId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	current := response.Data.(types.UserKeys)
+
+	if current.PublicKey.Value == "" {
+		return result.Failure(404, "Key pair not found")
+	}
+
+	return result.SuccessWithValue(200, keys.ToKeyPairResponse(current))
+}
+
+// Handle the get keys request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(GetKeys).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
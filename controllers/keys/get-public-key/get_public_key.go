@@ -0,0 +1,85 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/keys"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/orgs"
+	"password-caddy/api/lib/result"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type GetPublicKeyRequest struct {
+	UserId   string
+	TargetId string
+}
+
+// Initialize the Get Public Key Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	request := GetPublicKeyRequest{
+		UserId:   userId,
+		TargetId: orgs.EmailParameter(event.PathParameters["id"]),
+	}
+
+	if request.TargetId == "" {
+		return result.Failure(400, "User id is required")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Get the public key of the requested user, never the encrypted private key
+func GetPublicKey(res result.ResultValue) *result.Result {
+	request := res.(GetPublicKeyRequest)
+
+	response := keys.Get(container.VaultClient(), request.TargetId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch public key",
+			struct {
+				Email  string
+				Target string
+				Error  types.PasswordCaddyError
+			}{
+				Email:  request.UserId,
+				Target: request.TargetId,
+				Error:  response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	current := response.Data.(types.UserKeys)
+
+	if current.PublicKey.Value == "" {
+		return result.Failure(404, "Public key not found")
+	}
+
+	return result.SuccessWithValue(200, keys.ToPublicKeyResponse(current))
+}
+
+// Handle the get public key request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(GetPublicKey).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
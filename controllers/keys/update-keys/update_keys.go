@@ -0,0 +1,237 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/keys"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/orgs"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/shares"
+	"password-caddy/api/lib/userevents"
+	"password-caddy/api/lib/util"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type UpdateKeysRequest struct {
+	UserId    string
+	SourceIp  string
+	UserAgent string
+	KeyPair   keys.KeyPairRequest
+	Received  []types.ItemShare
+}
+
+// Initialize the Update Keys Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	var request UpdateKeysRequest
+
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	err := util.DeserializeJson(event.Body, &request.KeyPair)
+
+	if err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	request.UserId = userId
+	request.SourceIp = auth.SourceIp(event)
+	request.UserAgent = auth.UserAgent(event)
+
+	if err := request.KeyPair.Validate(); err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Check that the client saw the current key pair
+func CheckRevision(res result.ResultValue) *result.Result {
+	request := res.(UpdateKeysRequest)
+
+	response := keys.Get(container.VaultClient(), request.UserId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch key pair",
+			struct {
+				Email string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	current := response.Data.(types.UserKeys)
+
+	if current.Revision.Value != request.KeyPair.Revision {
+		return keys.ConflictResult(current)
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Check that the key of every organization the user is a confirmed member of is re-wrapped
+func CheckOrgKeys(res result.ResultValue) *result.Result {
+	request := res.(UpdateKeysRequest)
+
+	response := orgs.ListUserOrgs(container.VaultClient(), request.UserId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch organizations",
+			struct {
+				Email string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	missing, unknown := keys.CompareOrgKeys(request.KeyPair.OrgKeys, response.Data.([]orgs.UserOrg))
+
+	if len(unknown) > 0 {
+		return result.FailureWithDetails(400, "Organization keys can only be re-wrapped for confirmed memberships", unknown)
+	}
+
+	if len(missing) > 0 {
+		return result.FailureWithDetails(409, "The key of every organization must be re-wrapped with the new public key", missing)
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Check that the item key of every share the user received is re-wrapped
+func CheckShareKeys(res result.ResultValue) *result.Result {
+	request := res.(UpdateKeysRequest)
+
+	response := shares.ListReceived(container.VaultClient(), request.UserId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch received shares",
+			struct {
+				Email string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Received = response.Data.([]types.ItemShare)
+
+	missing, unknown := keys.CompareShareKeys(request.KeyPair.ShareKeys, request.Received)
+
+	if len(unknown) > 0 {
+		return result.FailureWithDetails(400, "Share keys can only be re-wrapped for received shares", unknown)
+	}
+
+	if len(missing) > 0 {
+		return result.FailureWithDetails(409, "The key of every received share must be re-wrapped with the new public key", missing)
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Save the key pair together with the re-wrapped organization and share keys
+func SaveKeys(res result.ResultValue) *result.Result {
+	request := res.(UpdateKeysRequest)
+
+	response := keys.Save(container.VaultClient(), request.UserId, request.KeyPair, request.Received)
+
+	if !response.IsSuccess && response.Error.StatusCode == 409 {
+		current := keys.Get(container.VaultClient(), request.UserId)
+
+		if current.IsSuccess && current.Data.(types.UserKeys).Revision.Value != request.KeyPair.Revision {
+			return keys.ConflictResult(current.Data.(types.UserKeys))
+		}
+
+		return result.Failure(409, "Memberships or shares changed while the key pair was saved")
+	}
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to save key pair",
+			struct {
+				Email string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	saved := response.Data.(types.UserKeys)
+
+	logger.Security(
+		"Saved key pair",
+		struct {
+			Email       string
+			Fingerprint string
+			Revision    int
+			OrgKeys     int
+			ShareKeys   int
+		}{
+			Email:       request.UserId,
+			Fingerprint: saved.Fingerprint.Value,
+			Revision:    saved.Revision.Value,
+			OrgKeys:     len(request.KeyPair.OrgKeys),
+			ShareKeys:   len(request.KeyPair.ShareKeys),
+		},
+	)
+
+	userevents.RecordOrLog(container.VaultClient(), request.UserId, userevents.Event{
+		Type:      userevents.TYPE_KEYS_ROTATED,
+		IpAddress: request.SourceIp,
+		UserAgent: request.UserAgent,
+	})
+
+	return result.SuccessWithValue(200, keys.ToKeyPairResponse(saved))
+}
+
+// Handle the update keys request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(CheckRevision).
+		Then(CheckOrgKeys).
+		Then(CheckShareKeys).
+		Then(SaveKeys).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/orgs"
+	"password-caddy/api/lib/policies"
+	"password-caddy/api/lib/result"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type AcceptInviteRequest struct {
+	UserId string
+	OrgId  string
+	Member types.OrgMember
+}
+
+// Initialize the Accept Invite Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	request := AcceptInviteRequest{
+		UserId: userId,
+		OrgId:  event.PathParameters["id"],
+	}
+
+	if !orgs.IsValidOrgId(request.OrgId) {
+		return result.Failure(400, "Organization id must be a UUID")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Check that the user has an open invite to the organization
+func GetInvite(res result.ResultValue) *result.Result {
+	request := res.(AcceptInviteRequest)
+
+	response := orgs.GetMember(container.VaultClient(), request.OrgId, request.UserId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch organization invite",
+			struct {
+				Email string
+				OrgId string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Member = response.Data.(types.OrgMember)
+
+	if request.Member.Status.Value != orgs.STATUS_INVITED {
+		return result.Failure(404, "Invite not found")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Check that the single organization policy lets the user join the organization
+func CheckPolicies(res result.ResultValue) *result.Result {
+	request := res.(AcceptInviteRequest)
+
+	response := policies.Resolve(container.VaultClient(), request.UserId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch organization policies",
+			struct {
+				Email string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	violation := response.Data.(policies.Effective).CheckJoin(request.Member)
+
+	if violation != nil {
+		logger.Security(
+			"Organization invite blocked by organization policy",
+			struct {
+				Email  string
+				OrgId  string
+				Policy string
+			}{
+				Email:  request.UserId,
+				OrgId:  violation.OrgId,
+				Policy: violation.Policy,
+			},
+		)
+
+		return policies.ViolationResult(violation)
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Accept the invite. A member who can manage members confirms the membership next
+func AcceptInvite(res result.ResultValue) *result.Result {
+	request := res.(AcceptInviteRequest)
+
+	response := orgs.Accept(container.VaultClient(), request.Member)
+
+	if !response.IsSuccess && response.Error.StatusCode == 409 {
+		return result.Failure(404, "Invite not found")
+	}
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to accept organization invite",
+			struct {
+				Email string
+				OrgId string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	logger.Info(
+		"Accepted organization invite",
+		struct {
+			Email string
+			OrgId string
+		}{
+			Email: request.UserId,
+			OrgId: request.OrgId,
+		},
+	)
+
+	return result.SuccessWithValue(200, orgs.ToMemberResponse(response.Data.(types.OrgMember)))
+}
+
+// Handle the accept invite request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(GetInvite).
+		Then(CheckPolicies).
+		Then(AcceptInvite).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/orgevents"
+	"password-caddy/api/lib/orgs"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/util"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type ConfirmMemberRequest struct {
+	UserId   string
+	SourceIp string
+	OrgId    string
+	Email    string
+	Confirm  orgs.ConfirmMemberRequest
+	Actor    types.OrgMember
+	Member   types.OrgMember
+	Members  []types.OrgMember
+}
+
+// Initialize the Confirm Member Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	var request ConfirmMemberRequest
+
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	err := util.DeserializeJson(event.Body, &request.Confirm)
+
+	if err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	request.UserId = userId
+	request.SourceIp = auth.SourceIp(event)
+	request.OrgId = event.PathParameters["id"]
+	request.Email = orgs.EmailParameter(event.PathParameters["email"])
+
+	if !orgs.IsValidOrgId(request.OrgId) {
+		return result.Failure(400, "Organization id must be a UUID")
+	}
+
+	if err := request.Confirm.Validate(); err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Get the members of the organization to find the user and the member
+func GetMembers(res result.ResultValue) *result.Result {
+	request := res.(ConfirmMemberRequest)
+
+	response := orgs.ListMembers(container.VaultClient(), request.OrgId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch organization members",
+			struct {
+				Email string
+				OrgId string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Members = response.Data.([]types.OrgMember)
+
+	for _, member := range request.Members {
+		if member.Email.Value == request.UserId {
+			request.Actor = member
+		}
+
+		if member.Email.Value == request.Email {
+			request.Member = member
+		}
+	}
+
+	if request.Actor.Status.Value != orgs.STATUS_CONFIRMED {
+		return result.Failure(404, "Organization not found")
+	}
+
+	if request.Member.Email.Value == "" {
+		return result.Failure(404, "Member not found")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Check that the user can manage the member and the member accepted the invite
+func CheckPermission(res result.ResultValue) *result.Result {
+	request := res.(ConfirmMemberRequest)
+
+	if !orgs.CanManageRole(request.Actor, request.Member.Role.Value) {
+		logger.Security(
+			"Member is not allowed to confirm members",
+			struct {
+				Email  string
+				OrgId  string
+				Member string
+			}{
+				Email:  request.UserId,
+				OrgId:  request.OrgId,
+				Member: request.Email,
+			},
+		)
+
+		return result.Failure(403, "Not allowed to confirm this member")
+	}
+
+	if request.Member.Status.Value != orgs.STATUS_ACCEPTED {
+		return result.Failure(409, "Only members who accepted the invite can be confirmed")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Confirm the member with the organization key wrapped with the member's public key
+func ConfirmMember(res result.ResultValue) *result.Result {
+	request := res.(ConfirmMemberRequest)
+
+	response := orgs.Confirm(container.VaultClient(), request.Member, request.Confirm.Key)
+
+	if !response.IsSuccess && response.Error.StatusCode == 409 {
+		return result.Failure(409, "Only members who accepted the invite can be confirmed")
+	}
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to confirm organization member",
+			struct {
+				Email  string
+				OrgId  string
+				Member string
+				Error  types.PasswordCaddyError
+			}{
+				Email:  request.UserId,
+				OrgId:  request.OrgId,
+				Member: request.Email,
+				Error:  response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	logger.Info(
+		"Confirmed organization member",
+		struct {
+			Email  string
+			OrgId  string
+			Member string
+		}{
+			Email:  request.UserId,
+			OrgId:  request.OrgId,
+			Member: request.Email,
+		},
+	)
+
+	recorded := orgevents.Record(container.VaultClient(), request.OrgId, orgevents.Event{
+		Type:      orgevents.TYPE_MEMBER_CONFIRMED,
+		ActorId:   request.UserId,
+		MemberId:  request.Email,
+		IpAddress: request.SourceIp,
+	})
+
+	if !recorded.IsSuccess {
+		logger.Error(
+			"Failed to record organization event",
+			struct {
+				Email string
+				OrgId string
+				Type  string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Type:  orgevents.TYPE_MEMBER_CONFIRMED,
+				Error: recorded.Error,
+			},
+		)
+	}
+
+	return result.SuccessWithValue(200, orgs.ToMemberResponse(response.Data.(types.OrgMember)))
+}
+
+// Handle the confirm member request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(GetMembers).
+		Then(CheckPermission).
+		Then(ConfirmMember).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/access"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/orgs"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/util"
+	"password-caddy/api/lib/vault"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/google/uuid"
+)
+
+type CreateCollectionRequest struct {
+	UserId     string
+	OrgId      string
+	Collection access.CollectionRequest
+	Scope      access.Scope
+}
+
+// Initialize the Create Collection Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	var request CreateCollectionRequest
+
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	err := util.DeserializeJson(event.Body, &request.Collection)
+
+	if err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	request.UserId = userId
+	request.OrgId = event.PathParameters["id"]
+
+	if !orgs.IsValidOrgId(request.OrgId) {
+		return result.Failure(400, "Organization id must be a UUID")
+	}
+
+	if err := request.Collection.Validate(); err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Resolve the organization's vault with the user's membership and the collections
+func ResolveScope(res result.ResultValue) *result.Result {
+	request := res.(CreateCollectionRequest)
+
+	response := access.Resolve(container.VaultClient(), request.UserId, request.OrgId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to resolve the vault of the request",
+			struct {
+				Email string
+				OrgId string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Scope = response.Data.(access.Scope)
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Only members who manage all collections create new ones
+func CheckPermission(res result.ResultValue) *result.Result {
+	request := res.(CreateCollectionRequest)
+
+	if !request.Scope.ManagesAllCollections() {
+		logger.Security(
+			"Member is not allowed to create collections",
+			struct {
+				Email string
+				OrgId string
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+			},
+		)
+
+		return result.Failure(403, "Not allowed to create collections")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Access can only be granted to members of the organization
+func CheckMembers(res result.ResultValue) *result.Result {
+	request := res.(CreateCollectionRequest)
+
+	response := orgs.ListMembers(container.VaultClient(), request.OrgId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch organization members",
+			struct {
+				Email string
+				OrgId string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	unknown := access.UnknownMembers(request.Collection.Access, response.Data.([]types.OrgMember))
+
+	if len(unknown) > 0 {
+		return result.FailureWithDetails(400, "Access can only be granted to members of the organization", unknown)
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Save the new collection
+func SaveCollection(res result.ResultValue) *result.Result {
+	request := res.(CreateCollectionRequest)
+
+	collection := types.VaultCollection{
+		UserId:       types.StringValue{Value: request.Scope.Partition()},
+		CollectionId: types.StringValue{Value: uuid.NewString()},
+		Name:         types.StringValue{Value: request.Collection.Name},
+		Access:       types.StringValue{Value: access.EncodeAccess(request.Collection.Access)},
+	}
+
+	response := vault.PutCollection(container.VaultClient(), collection, 0)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to create collection",
+			struct {
+				Email string
+				OrgId string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	collection = response.Data.(types.VaultCollection)
+
+	logger.Info(
+		"Created collection",
+		struct {
+			Email        string
+			OrgId        string
+			CollectionId string
+		}{
+			Email:        request.UserId,
+			OrgId:        request.OrgId,
+			CollectionId: collection.CollectionId.Value,
+		},
+	)
+
+	return result.SuccessWithValue(201, request.Scope.ToCollectionResponse(collection))
+}
+
+// Handle the create collection request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(ResolveScope).
+		Then(CheckPermission).
+		Then(CheckMembers).
+		Then(SaveCollection).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
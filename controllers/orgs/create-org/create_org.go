@@ -0,0 +1,143 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/orgs"
+	"password-caddy/api/lib/policies"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/util"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type CreateOrgRequest struct {
+	UserId string
+	Org    orgs.CreateOrgRequest
+}
+
+// Initialize the Create Org Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	var request CreateOrgRequest
+
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	err := util.DeserializeJson(event.Body, &request.Org)
+
+	if err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	request.UserId = userId
+
+	if err := request.Org.Validate(); err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Check that no organization of the user limits it to a single organization
+func CheckPolicies(res result.ResultValue) *result.Result {
+	request := res.(CreateOrgRequest)
+
+	response := policies.Resolve(container.VaultClient(), request.UserId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch organization policies",
+			struct {
+				Email string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	violation := response.Data.(policies.Effective).CheckJoin(types.OrgMember{})
+
+	if violation != nil {
+		logger.Security(
+			"Organization creation blocked by organization policy",
+			struct {
+				Email  string
+				OrgId  string
+				Policy string
+			}{
+				Email:  request.UserId,
+				OrgId:  violation.OrgId,
+				Policy: violation.Policy,
+			},
+		)
+
+		return policies.ViolationResult(violation)
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Create the organization with the user as its owner
+func CreateOrg(res result.ResultValue) *result.Result {
+	request := res.(CreateOrgRequest)
+
+	response := orgs.CreateOrg(container.VaultClient(), request.UserId, request.Org)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to create organization",
+			struct {
+				Email string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	userOrg := response.Data.(orgs.UserOrg)
+
+	logger.Info(
+		"Created organization",
+		struct {
+			Email string
+			OrgId string
+		}{
+			Email: request.UserId,
+			OrgId: userOrg.Org.OrgId.Value,
+		},
+	)
+
+	return result.SuccessWithValue(201, orgs.ToOrgResponse(userOrg.Org, userOrg.Member))
+}
+
+// Handle the create org request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(CheckPolicies).
+		Then(CreateOrg).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
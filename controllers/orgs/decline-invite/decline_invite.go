@@ -0,0 +1,126 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/orgs"
+	"password-caddy/api/lib/result"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type DeclineInviteRequest struct {
+	UserId string
+	OrgId  string
+	Member types.OrgMember
+}
+
+// Initialize the Decline Invite Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	request := DeclineInviteRequest{
+		UserId: userId,
+		OrgId:  event.PathParameters["id"],
+	}
+
+	if !orgs.IsValidOrgId(request.OrgId) {
+		return result.Failure(400, "Organization id must be a UUID")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Check that the user has an open invite to the organization
+func GetInvite(res result.ResultValue) *result.Result {
+	request := res.(DeclineInviteRequest)
+
+	response := orgs.GetMember(container.VaultClient(), request.OrgId, request.UserId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch organization invite",
+			struct {
+				Email string
+				OrgId string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Member = response.Data.(types.OrgMember)
+
+	if request.Member.Status.Value != orgs.STATUS_INVITED {
+		return result.Failure(404, "Invite not found")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Decline the invite, removing the membership
+func DeclineInvite(res result.ResultValue) *result.Result {
+	request := res.(DeclineInviteRequest)
+
+	response := orgs.Remove(container.VaultClient(), request.Member)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to decline organization invite",
+			struct {
+				Email string
+				OrgId string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	logger.Info(
+		"Declined organization invite",
+		struct {
+			Email string
+			OrgId string
+		}{
+			Email: request.UserId,
+			OrgId: request.OrgId,
+		},
+	)
+
+	return result.Success(204)
+}
+
+// Handle the decline invite request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(GetInvite).
+		Then(DeclineInvite).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
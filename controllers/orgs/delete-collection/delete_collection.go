@@ -0,0 +1,161 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/access"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/orgs"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/vault"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type DeleteCollectionRequest struct {
+	UserId       string
+	OrgId        string
+	CollectionId string
+	Scope        access.Scope
+}
+
+// Initialize the Delete Collection Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	request := DeleteCollectionRequest{
+		UserId:       userId,
+		OrgId:        event.PathParameters["id"],
+		CollectionId: event.PathParameters["collectionId"],
+	}
+
+	if !orgs.IsValidOrgId(request.OrgId) || !access.IsValidCollectionId(request.CollectionId) {
+		return result.Failure(400, "Organization id and collection id must be UUIDs")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Resolve the organization's vault with the user's membership and the collections
+func ResolveScope(res result.ResultValue) *result.Result {
+	request := res.(DeleteCollectionRequest)
+
+	response := access.Resolve(container.VaultClient(), request.UserId, request.OrgId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to resolve the vault of the request",
+			struct {
+				Email string
+				OrgId string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Scope = response.Data.(access.Scope)
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Only members who manage all collections delete them
+func CheckPermission(res result.ResultValue) *result.Result {
+	request := res.(DeleteCollectionRequest)
+
+	current, ok := request.Scope.Collection(request.CollectionId)
+
+	if !ok || request.Scope.LevelOf(current) == access.LEVEL_NONE {
+		return result.Failure(404, "Collection not found")
+	}
+
+	if !request.Scope.ManagesAllCollections() {
+		logger.Security(
+			"Member is not allowed to delete collections",
+			struct {
+				Email        string
+				OrgId        string
+				CollectionId string
+			}{
+				Email:        request.UserId,
+				OrgId:        request.OrgId,
+				CollectionId: request.CollectionId,
+			},
+		)
+
+		return result.Failure(403, "Not allowed to delete collections")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Delete the collection, its items stay in the organization's vault
+func DeleteCollection(res result.ResultValue) *result.Result {
+	request := res.(DeleteCollectionRequest)
+
+	response := vault.DeleteCollection(container.VaultClient(), request.Scope.Partition(), request.CollectionId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to delete collection",
+			struct {
+				Email        string
+				OrgId        string
+				CollectionId string
+				Error        types.PasswordCaddyError
+			}{
+				Email:        request.UserId,
+				OrgId:        request.OrgId,
+				CollectionId: request.CollectionId,
+				Error:        response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	logger.Info(
+		"Deleted collection",
+		struct {
+			Email        string
+			OrgId        string
+			CollectionId string
+		}{
+			Email:        request.UserId,
+			OrgId:        request.OrgId,
+			CollectionId: request.CollectionId,
+		},
+	)
+
+	return result.Success(204)
+}
+
+// Handle the delete collection request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(ResolveScope).
+		Then(CheckPermission).
+		Then(DeleteCollection).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/orgevents"
+	"password-caddy/api/lib/orgs"
+	"password-caddy/api/lib/result"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// Filter comes from the start, end, actor, type and cursor query parameters. An
+// export holds up to MAX_EXPORT_EVENTS events, when the range has more the
+// X-Next-Cursor header has the cursor of the next export
+type ExportEventsRequest struct {
+	UserId string
+	OrgId  string
+	Filter orgevents.Filter
+}
+
+// Initialize the Export Events Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	request := ExportEventsRequest{
+		UserId: userId,
+		OrgId:  event.PathParameters["id"],
+	}
+
+	if !orgs.IsValidOrgId(request.OrgId) {
+		return result.Failure(400, "Organization id must be a UUID")
+	}
+
+	filter, err := orgevents.ParseFilter(event.QueryStringParameters)
+
+	if err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	request.Filter = filter
+	request.Filter.Limit = orgevents.MAX_EXPORT_EVENTS
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Check that the user is allowed to access the event log of the organization
+func CheckPermission(res result.ResultValue) *result.Result {
+	request := res.(ExportEventsRequest)
+
+	response := orgs.GetMember(container.VaultClient(), request.OrgId, request.UserId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch organization member",
+			struct {
+				Email string
+				OrgId string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	member := response.Data.(types.OrgMember)
+
+	if member.Status.Value != orgs.STATUS_CONFIRMED {
+		return result.Failure(404, "Organization not found")
+	}
+
+	if !orgs.HasPermission(member, orgs.PERMISSION_ACCESS_EVENT_LOGS) {
+		logger.Security(
+			"Member is not allowed to access the organization event log",
+			struct {
+				Email string
+				OrgId string
+				Role  string
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Role:  member.Role.Value,
+			},
+		)
+
+		return result.Failure(403, "Not allowed to access the event log")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Get the events of the organization and write them as CSV. Every export is logged as a security event
+func ExportEvents(res result.ResultValue) *result.Result {
+	request := res.(ExportEventsRequest)
+
+	response := orgevents.List(container.VaultClient(), request.OrgId, request.Filter)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch organization events",
+			struct {
+				Email string
+				OrgId string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	page := response.Data.(orgevents.Page)
+
+	body, err := orgevents.ToCSV(page.Events)
+
+	if err != nil {
+		logger.Error(
+			"Failed to write organization events as CSV",
+			struct {
+				Email string
+				OrgId string
+				Error string
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Error: err.Error(),
+			},
+		)
+
+		return result.Failure(500, "Failed to export the event log")
+	}
+
+	logger.Security(
+		"Exported organization event log",
+		struct {
+			Email     string
+			OrgId     string
+			Events    int
+			Truncated bool
+		}{
+			Email:     request.UserId,
+			OrgId:     request.OrgId,
+			Events:    len(page.Events),
+			Truncated: page.LastKey != "",
+		},
+	)
+
+	raw := result.RawValue{
+		ContentType: "text/csv",
+		Filename:    "events-" + request.OrgId + ".csv",
+		Body:        body,
+	}
+
+	if page.LastKey != "" {
+		raw.Headers = map[string]string{
+			orgevents.CURSOR_HEADER: orgevents.EncodeCursor(page.LastKey),
+		}
+	}
+
+	return result.SuccessWithValue(200, raw)
+}
+
+// Handle the export events request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(CheckPermission).
+		Then(ExportEvents).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/orgevents"
+	"password-caddy/api/lib/orgs"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/util"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type InviteMemberRequest struct {
+	UserId   string
+	SourceIp string
+	OrgId    string
+	Invite   orgs.InviteRequest
+	Org      types.Organization
+	Member   types.OrgMember
+}
+
+// Initialize the Invite Member Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	var request InviteMemberRequest
+
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	err := util.DeserializeJson(event.Body, &request.Invite)
+
+	if err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	request.UserId = userId
+	request.SourceIp = auth.SourceIp(event)
+	request.OrgId = event.PathParameters["id"]
+
+	if !orgs.IsValidOrgId(request.OrgId) {
+		return result.Failure(400, "Organization id must be a UUID")
+	}
+
+	if err := request.Invite.Validate(); err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Check that the user can invite members with the requested role and its permissions
+func CheckPermission(res result.ResultValue) *result.Result {
+	request := res.(InviteMemberRequest)
+
+	response := orgs.GetMember(container.VaultClient(), request.OrgId, request.UserId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch organization member",
+			struct {
+				Email string
+				OrgId string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	actor := response.Data.(types.OrgMember)
+
+	if actor.Status.Value != orgs.STATUS_CONFIRMED {
+		return result.Failure(404, "Organization not found")
+	}
+
+	if !orgs.CanManageRole(actor, request.Invite.Role) || !orgs.CanGrantPermissions(actor, request.Invite.Role, request.Invite.Permissions) {
+		logger.Security(
+			"Member is not allowed to invite members",
+			struct {
+				Email string
+				OrgId string
+				Role  string
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Role:  request.Invite.Role,
+			},
+		)
+
+		return result.Failure(403, "Not allowed to invite members with this role")
+	}
+
+	response = orgs.GetOrg(container.VaultClient(), request.OrgId)
+
+	if !response.IsSuccess {
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Org = response.Data.(types.Organization)
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Save the invited membership
+func SaveInvite(res result.ResultValue) *result.Result {
+	request := res.(InviteMemberRequest)
+
+	response := orgs.Invite(container.VaultClient(), request.OrgId, request.UserId, request.Invite)
+
+	if !response.IsSuccess && response.Error.StatusCode == 409 {
+		return result.Failure(409, "User is already a member or invited")
+	}
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to invite organization member",
+			struct {
+				Email   string
+				OrgId   string
+				Invited string
+				Error   types.PasswordCaddyError
+			}{
+				Email:   request.UserId,
+				OrgId:   request.OrgId,
+				Invited: request.Invite.Email,
+				Error:   response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Member = response.Data.(types.OrgMember)
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Email the invite. The invite stays valid when the email fails, it is listed in the user's organizations
+func SendInviteEmail(res result.ResultValue) *result.Result {
+	request := res.(InviteMemberRequest)
+
+	response := container.SesClient().
+		BuildInviteEmailRequest(request.Invite.Email, request.Org.Name.Value, request.UserId, orgs.InviteUrl()).
+		Send()
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to send organization invite email",
+			struct {
+				Email   string
+				OrgId   string
+				Invited string
+				Error   types.PasswordCaddyError
+			}{
+				Email:   request.UserId,
+				OrgId:   request.OrgId,
+				Invited: request.Invite.Email,
+				Error:   response.Error,
+			},
+		)
+	}
+
+	logger.Info(
+		"Invited organization member",
+		struct {
+			Email   string
+			OrgId   string
+			Invited string
+			Role    string
+		}{
+			Email:   request.UserId,
+			OrgId:   request.OrgId,
+			Invited: request.Invite.Email,
+			Role:    request.Invite.Role,
+		},
+	)
+
+	recorded := orgevents.Record(container.VaultClient(), request.OrgId, orgevents.Event{
+		Type:      orgevents.TYPE_MEMBER_INVITED,
+		ActorId:   request.UserId,
+		MemberId:  request.Invite.Email,
+		IpAddress: request.SourceIp,
+	})
+
+	if !recorded.IsSuccess {
+		logger.Error(
+			"Failed to record organization event",
+			struct {
+				Email string
+				OrgId string
+				Type  string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Type:  orgevents.TYPE_MEMBER_INVITED,
+				Error: recorded.Error,
+			},
+		)
+	}
+
+	return result.SuccessWithValue(201, orgs.ToMemberResponse(request.Member))
+}
+
+// Handle the invite member request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(CheckPermission).
+		Then(SaveInvite).
+		Then(SendInviteEmail).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/access"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/orgs"
+	"password-caddy/api/lib/result"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type ListCollectionsRequest struct {
+	UserId string
+	OrgId  string
+	Scope  access.Scope
+}
+
+// Initialize the List Collections Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	request := ListCollectionsRequest{
+		UserId: userId,
+		OrgId:  event.PathParameters["id"],
+	}
+
+	if !orgs.IsValidOrgId(request.OrgId) {
+		return result.Failure(400, "Organization id must be a UUID")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Resolve the organization's vault with the user's membership and the collections
+func ResolveScope(res result.ResultValue) *result.Result {
+	request := res.(ListCollectionsRequest)
+
+	response := access.Resolve(container.VaultClient(), request.UserId, request.OrgId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to resolve the vault of the request",
+			struct {
+				Email string
+				OrgId string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Scope = response.Data.(access.Scope)
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Return the collections the user has access to with the user's level
+func GetCollections(res result.ResultValue) *result.Result {
+	request := res.(ListCollectionsRequest)
+
+	return result.SuccessWithValue(200, request.Scope.CollectionResponses())
+}
+
+// Handle the list collections request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(ResolveScope).
+		Then(GetCollections).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
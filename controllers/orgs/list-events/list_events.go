@@ -0,0 +1,142 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/orgevents"
+	"password-caddy/api/lib/orgs"
+	"password-caddy/api/lib/result"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// Filter comes from the start, end, actor, type, cursor and limit query parameters
+type ListEventsRequest struct {
+	UserId string
+	OrgId  string
+	Filter orgevents.Filter
+}
+
+// Initialize the List Events Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	request := ListEventsRequest{
+		UserId: userId,
+		OrgId:  event.PathParameters["id"],
+	}
+
+	if !orgs.IsValidOrgId(request.OrgId) {
+		return result.Failure(400, "Organization id must be a UUID")
+	}
+
+	filter, err := orgevents.ParseFilter(event.QueryStringParameters)
+
+	if err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	request.Filter = filter
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Check that the user is allowed to access the event log of the organization
+func CheckPermission(res result.ResultValue) *result.Result {
+	request := res.(ListEventsRequest)
+
+	response := orgs.GetMember(container.VaultClient(), request.OrgId, request.UserId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch organization member",
+			struct {
+				Email string
+				OrgId string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	member := response.Data.(types.OrgMember)
+
+	if member.Status.Value != orgs.STATUS_CONFIRMED {
+		return result.Failure(404, "Organization not found")
+	}
+
+	if !orgs.HasPermission(member, orgs.PERMISSION_ACCESS_EVENT_LOGS) {
+		logger.Security(
+			"Member is not allowed to access the organization event log",
+			struct {
+				Email string
+				OrgId string
+				Role  string
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Role:  member.Role.Value,
+			},
+		)
+
+		return result.Failure(403, "Not allowed to access the event log")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Get a page of the events of the organization
+func GetEvents(res result.ResultValue) *result.Result {
+	request := res.(ListEventsRequest)
+
+	response := orgevents.List(container.VaultClient(), request.OrgId, request.Filter)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch organization events",
+			struct {
+				Email string
+				OrgId string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	return result.SuccessWithValue(200, orgevents.ToEventsResponse(response.Data.(orgevents.Page)))
+}
+
+// Handle the list events request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(CheckPermission).
+		Then(GetEvents).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
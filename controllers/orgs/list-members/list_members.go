@@ -0,0 +1,118 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/orgs"
+	"password-caddy/api/lib/result"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type ListMembersRequest struct {
+	UserId string
+	OrgId  string
+}
+
+// Initialize the List Members Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	request := ListMembersRequest{
+		UserId: userId,
+		OrgId:  event.PathParameters["id"],
+	}
+
+	if !orgs.IsValidOrgId(request.OrgId) {
+		return result.Failure(400, "Organization id must be a UUID")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Check that the user is a confirmed member of the organization
+func CheckMembership(res result.ResultValue) *result.Result {
+	request := res.(ListMembersRequest)
+
+	response := orgs.GetMember(container.VaultClient(), request.OrgId, request.UserId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch organization member",
+			struct {
+				Email string
+				OrgId string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	if response.Data.(types.OrgMember).Status.Value != orgs.STATUS_CONFIRMED {
+		return result.Failure(404, "Organization not found")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Get the members of the organization
+func GetMembers(res result.ResultValue) *result.Result {
+	request := res.(ListMembersRequest)
+
+	response := orgs.ListMembers(container.VaultClient(), request.OrgId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch organization members",
+			struct {
+				Email string
+				OrgId string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	members := []orgs.MemberResponse{}
+
+	for _, member := range response.Data.([]types.OrgMember) {
+		members = append(members, orgs.ToMemberResponse(member))
+	}
+
+	return result.SuccessWithValue(200, members)
+}
+
+// Handle the list members request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(CheckMembership).
+		Then(GetMembers).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
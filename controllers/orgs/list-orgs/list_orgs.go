@@ -0,0 +1,68 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/orgs"
+	"password-caddy/api/lib/result"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// Initialize the List Orgs Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	return result.SuccessWithValue(200, userId)
+}
+
+// Get the organizations the user is a member of or invited to
+func GetOrgs(res result.ResultValue) *result.Result {
+	userId := res.(string)
+
+	response := orgs.ListUserOrgs(container.VaultClient(), userId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch organizations",
+			struct {
+				Email string
+				Error types.PasswordCaddyError
+			}{
+				Email: userId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	list := []orgs.OrgResponse{}
+
+	for _, userOrg := range response.Data.([]orgs.UserOrg) {
+		list = append(list, orgs.ToOrgResponse(userOrg.Org, userOrg.Member))
+	}
+
+	return result.SuccessWithValue(200, list)
+}
+
+// Handle the list orgs request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(GetOrgs).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
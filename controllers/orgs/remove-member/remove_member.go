@@ -0,0 +1,204 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/orgevents"
+	"password-caddy/api/lib/orgs"
+	"password-caddy/api/lib/result"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type RemoveMemberRequest struct {
+	UserId   string
+	SourceIp string
+	OrgId    string
+	Email    string
+	Actor    types.OrgMember
+	Member   types.OrgMember
+	Members  []types.OrgMember
+}
+
+// Initialize the Remove Member Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	request := RemoveMemberRequest{
+		UserId:   userId,
+		SourceIp: auth.SourceIp(event),
+		OrgId:    event.PathParameters["id"],
+		Email:    orgs.EmailParameter(event.PathParameters["email"]),
+	}
+
+	if !orgs.IsValidOrgId(request.OrgId) {
+		return result.Failure(400, "Organization id must be a UUID")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Get the members of the organization to find the user and the member
+func GetMembers(res result.ResultValue) *result.Result {
+	request := res.(RemoveMemberRequest)
+
+	response := orgs.ListMembers(container.VaultClient(), request.OrgId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch organization members",
+			struct {
+				Email string
+				OrgId string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Members = response.Data.([]types.OrgMember)
+
+	for _, member := range request.Members {
+		if member.Email.Value == request.UserId {
+			request.Actor = member
+		}
+
+		if member.Email.Value == request.Email {
+			request.Member = member
+		}
+	}
+
+	if request.Actor.Status.Value != orgs.STATUS_CONFIRMED {
+		return result.Failure(404, "Organization not found")
+	}
+
+	if request.Member.Email.Value == "" {
+		return result.Failure(404, "Member not found")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Members can always leave, removing others needs the permission to manage their role
+func CheckPermission(res result.ResultValue) *result.Result {
+	request := res.(RemoveMemberRequest)
+
+	if request.Email != request.UserId && !orgs.CanManageRole(request.Actor, request.Member.Role.Value) {
+		logger.Security(
+			"Member is not allowed to remove members",
+			struct {
+				Email  string
+				OrgId  string
+				Member string
+			}{
+				Email:  request.UserId,
+				OrgId:  request.OrgId,
+				Member: request.Email,
+			},
+		)
+
+		return result.Failure(403, "Not allowed to remove this member")
+	}
+
+	if request.Member.Role.Value == orgs.ROLE_OWNER && orgs.IsLastOwner(request.Members, request.Email) {
+		return result.Failure(409, "An organization needs at least one owner")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Remove the member from the organization
+func RemoveMember(res result.ResultValue) *result.Result {
+	request := res.(RemoveMemberRequest)
+
+	response := orgs.Remove(container.VaultClient(), request.Member)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to remove organization member",
+			struct {
+				Email  string
+				OrgId  string
+				Member string
+				Error  types.PasswordCaddyError
+			}{
+				Email:  request.UserId,
+				OrgId:  request.OrgId,
+				Member: request.Email,
+				Error:  response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	logger.Info(
+		"Removed organization member",
+		struct {
+			Email  string
+			OrgId  string
+			Member string
+		}{
+			Email:  request.UserId,
+			OrgId:  request.OrgId,
+			Member: request.Email,
+		},
+	)
+
+	recorded := orgevents.Record(container.VaultClient(), request.OrgId, orgevents.Event{
+		Type:      orgevents.TYPE_MEMBER_REMOVED,
+		ActorId:   request.UserId,
+		MemberId:  request.Email,
+		IpAddress: request.SourceIp,
+	})
+
+	if !recorded.IsSuccess {
+		logger.Error(
+			"Failed to record organization event",
+			struct {
+				Email string
+				OrgId string
+				Type  string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Type:  orgevents.TYPE_MEMBER_REMOVED,
+				Error: recorded.Error,
+			},
+		)
+	}
+
+	return result.Success(204)
+}
+
+// Handle the remove member request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(GetMembers).
+		Then(CheckPermission).
+		Then(RemoveMember).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
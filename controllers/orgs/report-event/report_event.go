@@ -0,0 +1,190 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/access"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/orgevents"
+	"password-caddy/api/lib/orgs"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/util"
+	"password-caddy/api/lib/vault"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type ReportEventRequest struct {
+	UserId   string
+	SourceIp string
+	OrgId    string
+	Report   orgevents.ReportRequest
+	Scope    access.Scope
+}
+
+// Initialize the Report Event Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	var request ReportEventRequest
+
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	err := util.DeserializeJson(event.Body, &request.Report)
+
+	if err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	request.UserId = userId
+	request.SourceIp = auth.SourceIp(event)
+	request.OrgId = event.PathParameters["id"]
+
+	if !orgs.IsValidOrgId(request.OrgId) {
+		return result.Failure(400, "Organization id must be a UUID")
+	}
+
+	if err := request.Report.Validate(); err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Resolve the organization's vault with the user's membership and the collections
+func ResolveScope(res result.ResultValue) *result.Result {
+	request := res.(ReportEventRequest)
+
+	response := access.Resolve(container.VaultClient(), request.UserId, request.OrgId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to resolve the vault of the request",
+			struct {
+				Email string
+				OrgId string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Scope = response.Data.(access.Scope)
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Check that the user can see the item, and its password for a copy
+func CheckItem(res result.ResultValue) *result.Result {
+	request := res.(ReportEventRequest)
+
+	response := vault.GetItem(container.VaultClient(), request.Scope.Partition(), request.Report.ItemId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch vault item",
+			struct {
+				Email  string
+				OrgId  string
+				ItemId string
+				Error  types.PasswordCaddyError
+			}{
+				Email:  request.UserId,
+				OrgId:  request.OrgId,
+				ItemId: request.Report.ItemId,
+				Error:  response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	item := response.Data.(types.VaultItem)
+
+	if item.ItemId.Value == "" || !request.Scope.CanRead(item) {
+		return result.Failure(404, "Vault item not found")
+	}
+
+	if request.Report.Type == orgevents.TYPE_PASSWORD_COPIED && !request.Scope.Can(item, access.LEVEL_READ_ONLY) {
+		logger.Security(
+			"Reported a password copy of an item with hidden passwords",
+			struct {
+				Email  string
+				OrgId  string
+				ItemId string
+			}{
+				Email:  request.UserId,
+				OrgId:  request.OrgId,
+				ItemId: request.Report.ItemId,
+			},
+		)
+
+		return result.Failure(403, "Passwords of this item are hidden")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Save the reported event
+func RecordEvent(res result.ResultValue) *result.Result {
+	request := res.(ReportEventRequest)
+
+	response := orgevents.Record(container.VaultClient(), request.OrgId, orgevents.Event{
+		Type:      request.Report.Type,
+		ActorId:   request.UserId,
+		ItemId:    request.Report.ItemId,
+		IpAddress: request.SourceIp,
+	})
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to record organization event",
+			struct {
+				Email string
+				OrgId string
+				Type  string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Type:  request.Report.Type,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	return result.Success(204)
+}
+
+// Handle the report event request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(ResolveScope).
+		Then(CheckItem).
+		Then(RecordEvent).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/access"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/orgs"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/util"
+	"password-caddy/api/lib/vault"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// Revision is the revision of the collection the client last saw. It can also be sent with the If-Match header
+type UpdateCollectionRequest struct {
+	UserId       string
+	OrgId        string
+	CollectionId string
+	Collection   access.CollectionRequest
+	Scope        access.Scope
+}
+
+// Initialize the Update Collection Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	var request UpdateCollectionRequest
+
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	err := util.DeserializeJson(event.Body, &request.Collection)
+
+	if err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	request.UserId = userId
+	request.OrgId = event.PathParameters["id"]
+	request.CollectionId = event.PathParameters["collectionId"]
+	request.Collection.Revision, err = vault.ExpectedRevision(event.Headers, request.Collection.Revision)
+
+	if err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	if !orgs.IsValidOrgId(request.OrgId) || !access.IsValidCollectionId(request.CollectionId) {
+		return result.Failure(400, "Organization id and collection id must be UUIDs")
+	}
+
+	if err := request.Collection.Validate(); err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Resolve the organization's vault with the user's membership and the collections
+func ResolveScope(res result.ResultValue) *result.Result {
+	request := res.(UpdateCollectionRequest)
+
+	response := access.Resolve(container.VaultClient(), request.UserId, request.OrgId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to resolve the vault of the request",
+			struct {
+				Email string
+				OrgId string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Scope = response.Data.(access.Scope)
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Check that the user manages the collection and saw its current revision
+func CheckPermission(res result.ResultValue) *result.Result {
+	request := res.(UpdateCollectionRequest)
+
+	current, ok := request.Scope.Collection(request.CollectionId)
+
+	if !ok || request.Scope.LevelOf(current) == access.LEVEL_NONE {
+		return result.Failure(404, "Collection not found")
+	}
+
+	if request.Scope.LevelOf(current) != access.LEVEL_MANAGE {
+		logger.Security(
+			"Member is not allowed to manage the collection",
+			struct {
+				Email        string
+				OrgId        string
+				CollectionId string
+			}{
+				Email:        request.UserId,
+				OrgId:        request.OrgId,
+				CollectionId: request.CollectionId,
+			},
+		)
+
+		return result.Failure(403, "Not allowed to manage this collection")
+	}
+
+	if current.Revision.Value != request.Collection.Revision {
+		return vault.CollectionConflictResult(current)
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Access can only be granted to members of the organization
+func CheckMembers(res result.ResultValue) *result.Result {
+	request := res.(UpdateCollectionRequest)
+
+	response := orgs.ListMembers(container.VaultClient(), request.OrgId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch organization members",
+			struct {
+				Email string
+				OrgId string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	unknown := access.UnknownMembers(request.Collection.Access, response.Data.([]types.OrgMember))
+
+	if len(unknown) > 0 {
+		return result.FailureWithDetails(400, "Access can only be granted to members of the organization", unknown)
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Save the new name and access of the collection
+func SaveCollection(res result.ResultValue) *result.Result {
+	request := res.(UpdateCollectionRequest)
+
+	collection := types.VaultCollection{
+		UserId:       types.StringValue{Value: request.Scope.Partition()},
+		CollectionId: types.StringValue{Value: request.CollectionId},
+		Name:         types.StringValue{Value: request.Collection.Name},
+		Access:       types.StringValue{Value: access.EncodeAccess(request.Collection.Access)},
+	}
+
+	response := vault.PutCollection(container.VaultClient(), collection, request.Collection.Revision)
+
+	if !response.IsSuccess && response.Error.StatusCode == 409 {
+		current := vault.GetCollection(container.VaultClient(), request.Scope.Partition(), request.CollectionId)
+
+		if current.IsSuccess {
+			return vault.CollectionConflictResult(current.Data.(types.VaultCollection))
+		}
+	}
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to update collection",
+			struct {
+				Email        string
+				OrgId        string
+				CollectionId string
+				Error        types.PasswordCaddyError
+			}{
+				Email:        request.UserId,
+				OrgId:        request.OrgId,
+				CollectionId: request.CollectionId,
+				Error:        response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	logger.Info(
+		"Updated collection",
+		struct {
+			Email        string
+			OrgId        string
+			CollectionId string
+			Access       int
+		}{
+			Email:        request.UserId,
+			OrgId:        request.OrgId,
+			CollectionId: request.CollectionId,
+			Access:       len(request.Collection.Access),
+		},
+	)
+
+	return result.SuccessWithValue(200, request.Scope.ToCollectionResponse(response.Data.(types.VaultCollection)))
+}
+
+// Handle the update collection request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(ResolveScope).
+		Then(CheckPermission).
+		Then(CheckMembers).
+		Then(SaveCollection).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/orgevents"
+	"password-caddy/api/lib/orgs"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/util"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type UpdateMemberRequest struct {
+	UserId   string
+	SourceIp string
+	OrgId    string
+	Email    string
+	Update   orgs.UpdateMemberRequest
+	Actor    types.OrgMember
+	Member   types.OrgMember
+	Members  []types.OrgMember
+}
+
+// Initialize the Update Member Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	var request UpdateMemberRequest
+
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	err := util.DeserializeJson(event.Body, &request.Update)
+
+	if err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	request.UserId = userId
+	request.SourceIp = auth.SourceIp(event)
+	request.OrgId = event.PathParameters["id"]
+	request.Email = orgs.EmailParameter(event.PathParameters["email"])
+
+	if !orgs.IsValidOrgId(request.OrgId) {
+		return result.Failure(400, "Organization id must be a UUID")
+	}
+
+	if request.Email == request.UserId {
+		return result.Failure(403, "Not allowed to change your own role")
+	}
+
+	if err := request.Update.Validate(); err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Get the members of the organization to find the user and the member
+func GetMembers(res result.ResultValue) *result.Result {
+	request := res.(UpdateMemberRequest)
+
+	response := orgs.ListMembers(container.VaultClient(), request.OrgId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch organization members",
+			struct {
+				Email string
+				OrgId string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Members = response.Data.([]types.OrgMember)
+
+	for _, member := range request.Members {
+		if member.Email.Value == request.UserId {
+			request.Actor = member
+		}
+
+		if member.Email.Value == request.Email {
+			request.Member = member
+		}
+	}
+
+	if request.Actor.Status.Value != orgs.STATUS_CONFIRMED {
+		return result.Failure(404, "Organization not found")
+	}
+
+	if request.Member.Email.Value == "" {
+		return result.Failure(404, "Member not found")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+/*
+Check that the user can manage both the current and the new role of the member
+and holds every permission the new role grants
+*/
+func CheckPermission(res result.ResultValue) *result.Result {
+	request := res.(UpdateMemberRequest)
+
+	if !orgs.CanManageRole(request.Actor, request.Member.Role.Value) ||
+		!orgs.CanManageRole(request.Actor, request.Update.Role) ||
+		!orgs.CanGrantPermissions(request.Actor, request.Update.Role, request.Update.Permissions) {
+		logger.Security(
+			"Member is not allowed to change the role of members",
+			struct {
+				Email  string
+				OrgId  string
+				Member string
+				Role   string
+			}{
+				Email:  request.UserId,
+				OrgId:  request.OrgId,
+				Member: request.Email,
+				Role:   request.Update.Role,
+			},
+		)
+
+		return result.Failure(403, "Not allowed to change the role of this member")
+	}
+
+	if request.Update.Role != orgs.ROLE_OWNER && request.Member.Role.Value == orgs.ROLE_OWNER && orgs.IsLastOwner(request.Members, request.Email) {
+		return result.Failure(409, "An organization needs at least one owner")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Save the new role of the member
+func SaveMember(res result.ResultValue) *result.Result {
+	request := res.(UpdateMemberRequest)
+
+	response := orgs.UpdateRole(container.VaultClient(), request.Member, request.Update.Role, request.Update.Permissions)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to update organization member",
+			struct {
+				Email  string
+				OrgId  string
+				Member string
+				Error  types.PasswordCaddyError
+			}{
+				Email:  request.UserId,
+				OrgId:  request.OrgId,
+				Member: request.Email,
+				Error:  response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	logger.Info(
+		"Updated organization member",
+		struct {
+			Email  string
+			OrgId  string
+			Member string
+			Role   string
+		}{
+			Email:  request.UserId,
+			OrgId:  request.OrgId,
+			Member: request.Email,
+			Role:   request.Update.Role,
+		},
+	)
+
+	recorded := orgevents.Record(container.VaultClient(), request.OrgId, orgevents.Event{
+		Type:      orgevents.TYPE_MEMBER_UPDATED,
+		ActorId:   request.UserId,
+		MemberId:  request.Email,
+		IpAddress: request.SourceIp,
+	})
+
+	if !recorded.IsSuccess {
+		logger.Error(
+			"Failed to record organization event",
+			struct {
+				Email string
+				OrgId string
+				Type  string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Type:  orgevents.TYPE_MEMBER_UPDATED,
+				Error: recorded.Error,
+			},
+		)
+	}
+
+	return result.SuccessWithValue(200, orgs.ToMemberResponse(response.Data.(types.OrgMember)))
+}
+
+// Handle the update member request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(GetMembers).
+		Then(CheckPermission).
+		Then(SaveMember).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/orgevents"
+	"password-caddy/api/lib/orgs"
+	"password-caddy/api/lib/policies"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/util"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type UpdatePolicyRequest struct {
+	UserId   string
+	SourceIp string
+	OrgId    string
+	Type     string
+	Policy   policies.PolicyRequest
+}
+
+// Initialize the Update Policy Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	var request UpdatePolicyRequest
+
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	err := util.DeserializeJson(event.Body, &request.Policy)
+
+	if err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	request.UserId = userId
+	request.SourceIp = auth.SourceIp(event)
+	request.OrgId = event.PathParameters["id"]
+	request.Type = event.PathParameters["type"]
+
+	if !orgs.IsValidOrgId(request.OrgId) {
+		return result.Failure(400, "Organization id must be a UUID")
+	}
+
+	if err := request.Policy.Validate(request.Type); err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Check that the user is allowed to manage the policies of the organization
+func CheckPermission(res result.ResultValue) *result.Result {
+	request := res.(UpdatePolicyRequest)
+
+	response := orgs.GetMember(container.VaultClient(), request.OrgId, request.UserId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch organization member",
+			struct {
+				Email string
+				OrgId string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	member := response.Data.(types.OrgMember)
+
+	if member.Status.Value != orgs.STATUS_CONFIRMED {
+		return result.Failure(404, "Organization not found")
+	}
+
+	if !orgs.HasPermission(member, orgs.PERMISSION_MANAGE_POLICIES) {
+		logger.Security(
+			"Member is not allowed to manage organization policies",
+			struct {
+				Email string
+				OrgId string
+				Type  string
+				Role  string
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Type:  request.Type,
+				Role:  member.Role.Value,
+			},
+		)
+
+		return result.Failure(403, "Not allowed to manage policies")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Save the policy
+func SavePolicy(res result.ResultValue) *result.Result {
+	request := res.(UpdatePolicyRequest)
+
+	response := policies.PutPolicy(container.VaultClient(), request.OrgId, request.Type, request.UserId, request.Policy)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to save organization policy",
+			struct {
+				Email string
+				OrgId string
+				Type  string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Type:  request.Type,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	logger.Security(
+		"Updated organization policy",
+		struct {
+			Email   string
+			OrgId   string
+			Type    string
+			Enabled bool
+		}{
+			Email:   request.UserId,
+			OrgId:   request.OrgId,
+			Type:    request.Type,
+			Enabled: request.Policy.Enabled,
+		},
+	)
+
+	recorded := orgevents.Record(container.VaultClient(), request.OrgId, orgevents.Event{
+		Type:       orgevents.TYPE_POLICY_UPDATED,
+		ActorId:    request.UserId,
+		PolicyType: request.Type,
+		IpAddress:  request.SourceIp,
+	})
+
+	if !recorded.IsSuccess {
+		logger.Error(
+			"Failed to record organization event",
+			struct {
+				Email string
+				OrgId string
+				Type  string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Type:  orgevents.TYPE_POLICY_UPDATED,
+				Error: recorded.Error,
+			},
+		)
+	}
+
+	return result.SuccessWithValue(200, policies.ToPolicyResponse(response.Data.(types.OrgPolicy)))
+}
+
+// Handle the update policy request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(CheckPermission).
+		Then(SavePolicy).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"time"
+
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/emergency"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/result"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type ApproveRecoveriesRequest struct {
+	Now      time.Time
+	Accesses []types.EmergencyAccess
+}
+
+// Initialize the Approve Recoveries Request
+func Init(event events.CloudWatchEvent) *result.Result {
+	return result.SuccessWithValue(200, ApproveRecoveriesRequest{Now: time.Now().UTC()})
+}
+
+// Find the initiated recoveries of all users whose waiting period elapsed
+func GetDueRecoveries(res result.ResultValue) *result.Result {
+	request := res.(ApproveRecoveriesRequest)
+
+	response := emergency.ListDue(container.VaultClient(), request.Now)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to find due emergency recoveries",
+			struct {
+				Now   string
+				Error types.PasswordCaddyError
+			}{
+				Now:   request.Now.Format(time.RFC3339),
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Accesses = response.Data.([]types.EmergencyAccess)
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Approve the due recoveries and email their grantees. Keeps going when a
+// single recovery fails so one bad record does not block the rest. A recovery
+// the grantor rejected in the meantime fails the status condition and is skipped
+func ApproveDueRecoveries(res result.ResultValue) *result.Result {
+	request := res.(ApproveRecoveriesRequest)
+	client := container.VaultClient()
+	approved := 0
+	failed := 0
+
+	for _, access := range request.Accesses {
+		if !emergency.IsDue(access, request.Now) {
+			continue
+		}
+
+		response := emergency.Approve(client, access)
+
+		if !response.IsSuccess && response.Error.StatusCode == 409 {
+			continue
+		}
+
+		if !response.IsSuccess {
+			failed++
+
+			logger.Error(
+				"Failed to approve emergency recovery",
+				struct {
+					Email    string
+					AccessId string
+					Grantee  string
+					Error    types.PasswordCaddyError
+				}{
+					Email:    access.GrantorId.Value,
+					AccessId: access.AccessId.Value,
+					Grantee:  access.GranteeId.Value,
+					Error:    response.Error,
+				},
+			)
+
+			continue
+		}
+
+		approved++
+
+		logger.Security(
+			"Approved emergency recovery after the waiting period",
+			struct {
+				Email    string
+				AccessId string
+				Grantee  string
+				Type     string
+			}{
+				Email:    access.GrantorId.Value,
+				AccessId: access.AccessId.Value,
+				Grantee:  access.GranteeId.Value,
+				Type:     access.Type.Value,
+			},
+		)
+
+		email := container.SesClient().
+			BuildEmergencyApprovedEmailRequest(access.GranteeId.Value, access.GrantorId.Value, emergency.AccessUrl()).
+			Send()
+
+		if !email.IsSuccess {
+			logger.Error(
+				"Failed to send emergency recovery approved email",
+				struct {
+					Email    string
+					AccessId string
+					Grantee  string
+					Error    types.PasswordCaddyError
+				}{
+					Email:    access.GrantorId.Value,
+					AccessId: access.AccessId.Value,
+					Grantee:  access.GranteeId.Value,
+					Error:    email.Error,
+				},
+			)
+		}
+	}
+
+	logger.Info(
+		"Approved due emergency recoveries",
+		struct {
+			Now      string
+			Approved int
+			Failed   int
+		}{
+			Now:      request.Now.Format(time.RFC3339),
+			Approved: approved,
+			Failed:   failed,
+		},
+	)
+
+	if failed > 0 {
+		return result.Failure(500, "Failed to approve some emergency recoveries")
+	}
+
+	return result.Success(200)
+}
+
+// Handle the scheduled approval of emergency recoveries
+func Handler(event events.CloudWatchEvent) error {
+	return Init(event).
+		Then(GetDueRecoveries).
+		Then(ApproveDueRecoveries).
+		ToError()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
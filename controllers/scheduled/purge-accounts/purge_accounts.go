@@ -0,0 +1,206 @@
+package main
+
+import (
+	"time"
+
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/accounts"
+	"password-caddy/api/lib/admin"
+	"password-caddy/api/lib/dynamoclient"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/result"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type PurgeAccountsRequest struct {
+	Now     time.Time
+	Markers []types.AccountDeletionMarker
+}
+
+// Initialize the Purge Accounts Request. Users whose grace period is over by now are purged
+func Init(event events.CloudWatchEvent) *result.Result {
+	return result.SuccessWithValue(200, PurgeAccountsRequest{Now: time.Now()})
+}
+
+// Find the markers of the deletions whose grace period is over
+func GetDueDeletions(res result.ResultValue) *result.Result {
+	request := res.(PurgeAccountsRequest)
+
+	response := accounts.ListDueDeletions(container.VaultClient(), request.Now)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to find users due for deletion",
+			struct {
+				Error types.PasswordCaddyError
+			}{
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Markers = response.Data.([]types.AccountDeletionMarker)
+
+	return result.SuccessWithValue(200, request)
+}
+
+/*
+Permanently delete the data of the due users. Keeps going when a single user
+fails so one account does not block the others. The marker of a user whose
+deletion was canceled or rescheduled in the meantime is dropped
+*/
+func PurgeDueUsers(res result.ResultValue) *result.Result {
+	request := res.(PurgeAccountsRequest)
+	vaultClient := container.VaultClient()
+	usersClient := container.DynamoClient()
+	store := container.BlobStore()
+	purged := 0
+	failed := 0
+
+	for _, marker := range request.Markers {
+		email := marker.Email.Value
+
+		response := usersClient.
+			Get(dynamoclient.DynamoGetRequest{Key: email}).
+			AsUser()
+
+		if !response.IsSuccess {
+			failed++
+
+			logger.Error(
+				"Failed to fetch user data",
+				struct {
+					Email string
+					Error types.PasswordCaddyError
+				}{
+					Email: email,
+					Error: response.Error,
+				},
+			)
+
+			continue
+		}
+
+		user := response.Data.(types.PasswordCaddyUser)
+
+		if !accounts.IsDueDeletion(user, marker, request.Now) {
+			removeMarker(vaultClient, marker)
+			continue
+		}
+
+		audit := admin.Record(vaultClient, email, admin.Entry{
+			OperatorId: admin.SYSTEM_OPERATOR_ID,
+			Action:     admin.ACTION_PURGE,
+			Reason:     "Deletion grace period is over",
+		})
+
+		if !audit.IsSuccess {
+			failed++
+
+			logger.Error(
+				"Failed to write the admin audit trail",
+				struct {
+					Email  string
+					Action string
+					Error  types.PasswordCaddyError
+				}{
+					Email:  email,
+					Action: admin.ACTION_PURGE,
+					Error:  audit.Error,
+				},
+			)
+
+			continue
+		}
+
+		response = accounts.Purge(vaultClient, usersClient, store, email)
+
+		if !response.IsSuccess {
+			failed++
+
+			logger.Error(
+				"Failed to purge user",
+				struct {
+					Email string
+					Error types.PasswordCaddyError
+				}{
+					Email: email,
+					Error: response.Error,
+				},
+			)
+
+			continue
+		}
+
+		logger.Security(
+			"Purged user after the deletion grace period",
+			struct {
+				Email       string
+				DeleteAfter string
+			}{
+				Email:       email,
+				DeleteAfter: user.DeleteAfter.Value,
+			},
+		)
+
+		purged++
+		removeMarker(vaultClient, marker)
+	}
+
+	logger.Info(
+		"Purged users due for deletion",
+		struct {
+			Purged int
+			Failed int
+		}{
+			Purged: purged,
+			Failed: failed,
+		},
+	)
+
+	if failed > 0 {
+		return result.Failure(500, "Failed to purge some users")
+	}
+
+	return result.Success(200)
+}
+
+// Drop the marker of a deletion. A marker that stays behind is dropped by the next run
+func removeMarker(client *dynamoclient.DynamoClient, marker types.AccountDeletionMarker) {
+	response := accounts.RemoveDeletionMarker(client, marker.Email.Value, marker.DeleteAfter.Value)
+
+	if !response.IsSuccess {
+		logger.Warn(
+			"Failed to remove the marker of an account deletion",
+			struct {
+				Email       string
+				DeleteAfter string
+				Error       types.PasswordCaddyError
+			}{
+				Email:       marker.Email.Value,
+				DeleteAfter: marker.DeleteAfter.Value,
+				Error:       response.Error,
+			},
+		)
+	}
+}
+
+// Handle the scheduled purge of deleted users
+func Handler(event events.CloudWatchEvent) error {
+	return Init(event).
+		Then(GetDueDeletions).
+		Then(PurgeDueUsers).
+		ToError()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"time"
+
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/dynamoclient"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/shares"
+	"password-caddy/api/lib/vault"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// Trash markers read per query
+const PAGE_SIZE = 100
+
+type PurgeTrashRequest struct {
+	Cutoff   time.Time
+	Deadline time.Time
+}
+
+// Initialize the Purge Trash Request. Items trashed before the cutoff are purged
+// until the time budget of the run is used up
+func Init(event events.CloudWatchEvent) *result.Result {
+	now := time.Now()
+
+	return result.SuccessWithValue(200, PurgeTrashRequest{
+		Cutoff:   now.Add(-vault.TrashRetention()),
+		Deadline: now.Add(vault.TrashPurgeBudget()),
+	})
+}
+
+/*
+Permanently delete the items of all vaults that were trashed before the cutoff,
+a page of trash markers at a time. Keeps going when a single item fails so one
+bad item does not block the rest of the trash. What is left when the time
+budget runs out is purged by the next run
+*/
+func PurgeExpiredItems(res result.ResultValue) *result.Result {
+	request := res.(PurgeTrashRequest)
+	client := container.VaultClient()
+	startAfter := ""
+	purged := 0
+	failed := 0
+	finished := false
+
+	for !finished && time.Now().Before(request.Deadline) {
+		response := vault.ListTrashedBefore(client, request.Cutoff, startAfter, PAGE_SIZE)
+
+		if !response.IsSuccess {
+			logger.Error(
+				"Failed to find expired items in the trash",
+				struct {
+					Cutoff string
+					Error  types.PasswordCaddyError
+				}{
+					Cutoff: request.Cutoff.UTC().Format(time.RFC3339),
+					Error:  response.Error,
+				},
+			)
+
+			return result.Failure(
+				response.Error.StatusCode,
+				response.Error.Message,
+			)
+		}
+
+		markers := response.Data.([]types.VaultTrashMarker)
+		finished = len(markers) < PAGE_SIZE
+
+		for _, marker := range markers {
+			if !time.Now().Before(request.Deadline) {
+				finished = false
+				break
+			}
+
+			// Failed markers stay in the trash partition, the next page starts after them
+			startAfter = marker.ItemKey.Value
+			response := purgeExpiredItem(client, marker)
+
+			if !response.IsSuccess {
+				failed++
+
+				logger.Error(
+					"Failed to purge vault item from the trash",
+					struct {
+						Email  string
+						ItemId string
+						Error  types.PasswordCaddyError
+					}{
+						Email:  marker.OwnerId.Value,
+						ItemId: marker.ItemId.Value,
+						Error:  response.Error,
+					},
+				)
+
+				continue
+			}
+
+			purged++
+		}
+	}
+
+	logger.Info(
+		"Purged expired items from the trash",
+		struct {
+			Cutoff   string
+			Purged   int
+			Failed   int
+			Finished bool
+		}{
+			Cutoff:   request.Cutoff.UTC().Format(time.RFC3339),
+			Purged:   purged,
+			Failed:   failed,
+			Finished: finished,
+		},
+	)
+
+	if failed > 0 {
+		return result.Failure(500, "Failed to purge some items from the trash")
+	}
+
+	return result.Success(200)
+}
+
+// Purge the item of a trash marker. A marker of an item that is gone or was
+// taken out of the trash since is dropped without touching the item
+func purgeExpiredItem(client *dynamoclient.DynamoClient, marker types.VaultTrashMarker) *dynamoclient.DynamoResponse {
+	response := vault.GetItem(client, marker.OwnerId.Value, marker.ItemId.Value)
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	item := response.Data.(types.VaultItem)
+
+	if item.ItemId.Value == "" || item.DeletedAt.Value != marker.DeletedAt.Value {
+		return vault.RemoveStaleTrashMarker(client, marker)
+	}
+
+	response = shares.RemoveForItem(client, item.UserId.Value, item.ItemId.Value)
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	return vault.PurgeItem(client, container.BlobStore(), item)
+}
+
+// Handle the scheduled purge of the trash
+func Handler(event events.CloudWatchEvent) error {
+	return Init(event).
+		Then(PurgeExpiredItems).
+		ToError()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"time"
+
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/sends"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+/*
+Recipients have no account, the request is anonymous. Sends with a password
+need it in the X-Send-Password header. The key to decrypt the send is in the
+fragment of the link and never reaches the server
+*/
+type AccessSendRequest struct {
+	SendId   string
+	Password string
+	SourceIp string
+	Send     types.Send
+}
+
+// Initialize the Access Send Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	request := AccessSendRequest{
+		SendId:   event.PathParameters["id"],
+		Password: sends.PasswordFromHeaders(event.Headers),
+		SourceIp: auth.SourceIp(event),
+	}
+
+	if !sends.IsValidSendId(request.SendId) {
+		return result.Failure(404, "Send not found")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+/*
+Check that the send is available and the password is correct. Every password
+attempt is counted on the send before the password is hashed, a send is locked
+after sends.MaxPasswordAttempts invalid passwords
+*/
+func CheckSend(res result.ResultValue) *result.Result {
+	request := res.(AccessSendRequest)
+
+	response := sends.Get(container.VaultClient(), request.SendId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch send",
+			struct {
+				SendId string
+				Error  types.PasswordCaddyError
+			}{
+				SendId: request.SendId,
+				Error:  response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Send = response.Data.(types.Send)
+
+	if !sends.IsAvailable(request.Send, time.Now()) {
+		return result.Failure(404, "Send not found")
+	}
+
+	if request.Send.PasswordHash.Value == "" {
+		return result.SuccessWithValue(200, request)
+	}
+
+	if sends.IsLocked(request.Send) {
+		return result.Failure(429, "Too many invalid send passwords")
+	}
+
+	response = sends.ReserveAttempt(container.VaultClient(), request.SendId)
+
+	if !response.IsSuccess {
+		if response.Error.StatusCode != 429 {
+			logger.Error(
+				"Failed to count send password attempt",
+				struct {
+					SendId string
+					Error  types.PasswordCaddyError
+				}{
+					SendId: request.SendId,
+					Error:  response.Error,
+				},
+			)
+		}
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	attempts := response.Data.(types.Send).FailedAttempts.Value
+
+	if !sends.VerifyPassword(request.Send.PasswordHash.Value, request.Password) {
+		logger.Security(
+			"Invalid send password",
+			struct {
+				SendId         string
+				SourceIp       string
+				FailedAttempts int
+				Locked         bool
+			}{
+				SendId:         request.SendId,
+				SourceIp:       request.SourceIp,
+				FailedAttempts: attempts,
+				Locked:         attempts >= sends.MaxPasswordAttempts(),
+			},
+		)
+
+		return result.Failure(401, "Invalid send password")
+	}
+
+	response = sends.ReleaseAttempt(container.VaultClient(), request.SendId)
+
+	if !response.IsSuccess {
+		logger.Warn(
+			"Failed to release send password attempt",
+			struct {
+				SendId string
+				Error  types.PasswordCaddyError
+			}{
+				SendId: request.SendId,
+				Error:  response.Error,
+			},
+		)
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Count the access and return the send, with its file for a file send
+func AccessSend(res result.ResultValue) *result.Result {
+	request := res.(AccessSendRequest)
+
+	response := sends.Access(container.VaultClient(), request.SendId, time.Now())
+
+	if !response.IsSuccess {
+		if response.Error.StatusCode != 404 {
+			logger.Error(
+				"Failed to access send",
+				struct {
+					SendId string
+					Error  types.PasswordCaddyError
+				}{
+					SendId: request.SendId,
+					Error:  response.Error,
+				},
+			)
+		}
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	send := response.Data.(types.Send)
+
+	var file []byte
+
+	if send.Type.Value == sends.TYPE_FILE {
+		response = sends.GetFile(container.BlobStore(), send)
+
+		if !response.IsSuccess {
+			logger.Error(
+				"Failed to read send file",
+				struct {
+					SendId string
+					Error  types.PasswordCaddyError
+				}{
+					SendId: request.SendId,
+					Error:  response.Error,
+				},
+			)
+
+			return result.Failure(
+				response.Error.StatusCode,
+				response.Error.Message,
+			)
+		}
+
+		file = response.Data.([]byte)
+	}
+
+	logger.Info(
+		"Accessed send",
+		struct {
+			SendId      string
+			AccessCount int
+		}{
+			SendId:      request.SendId,
+			AccessCount: send.AccessCount.Value,
+		},
+	)
+
+	return result.SuccessWithValue(200, sends.ToAccessResponse(send, file))
+}
+
+// Handle the access send request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(CheckSend).
+		Then(AccessSend).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
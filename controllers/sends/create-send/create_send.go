@@ -0,0 +1,159 @@
+package main
+
+import (
+	"time"
+
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/policies"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/sends"
+	"password-caddy/api/lib/util"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type CreateSendRequest struct {
+	UserId    string
+	Send      sends.SendRequest
+	ExpiresAt time.Time
+	File      []byte
+}
+
+// Initialize the Create Send Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	var request CreateSendRequest
+
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	err := util.DeserializeJson(event.Body, &request.Send)
+
+	if err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	request.UserId = userId
+	request.ExpiresAt, request.File, err = request.Send.Validate(time.Now())
+
+	if err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Check that no organization of the user disabled sends
+func CheckPolicies(res result.ResultValue) *result.Result {
+	request := res.(CreateSendRequest)
+
+	response := policies.Resolve(container.VaultClient(), request.UserId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch organization policies",
+			struct {
+				Email string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	violation := response.Data.(policies.Effective).Check(policies.TYPE_DISABLE_SEND)
+
+	if violation != nil {
+		logger.Security(
+			"Send blocked by organization policy",
+			struct {
+				Email  string
+				OrgId  string
+				Policy string
+			}{
+				Email:  request.UserId,
+				OrgId:  violation.OrgId,
+				Policy: violation.Policy,
+			},
+		)
+
+		return policies.ViolationResult(violation)
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Store the send and its file
+func SaveSend(res result.ResultValue) *result.Result {
+	request := res.(CreateSendRequest)
+
+	response := sends.Create(
+		container.VaultClient(),
+		container.BlobStore(),
+		request.UserId,
+		request.Send,
+		request.ExpiresAt,
+		request.File,
+	)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to create send",
+			struct {
+				Email string
+				Type  string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				Type:  request.Send.Type,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	send := response.Data.(types.Send)
+
+	logger.Info(
+		"Created send",
+		struct {
+			Email  string
+			SendId string
+			Type   string
+		}{
+			Email:  request.UserId,
+			SendId: send.SendId.Value,
+			Type:   send.Type.Value,
+		},
+	)
+
+	return result.SuccessWithValue(201, sends.ToSendResponse(send))
+}
+
+// Handle the create send request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(CheckPolicies).
+		Then(SaveSend).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/sends"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type DeleteSendRequest struct {
+	UserId string
+	SendId string
+	Send   types.Send
+}
+
+// Initialize the Delete Send Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	request := DeleteSendRequest{
+		UserId: userId,
+		SendId: event.PathParameters["id"],
+	}
+
+	if !sends.IsValidSendId(request.SendId) {
+		return result.Failure(400, "Send id must be a UUID")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Check that the send belongs to the user
+func CheckOwner(res result.ResultValue) *result.Result {
+	request := res.(DeleteSendRequest)
+
+	response := sends.Get(container.VaultClient(), request.SendId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch send",
+			struct {
+				Email  string
+				SendId string
+				Error  types.PasswordCaddyError
+			}{
+				Email:  request.UserId,
+				SendId: request.SendId,
+				Error:  response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Send = response.Data.(types.Send)
+
+	if request.Send.OwnerId.Value != request.UserId {
+		return result.Failure(404, "Send not found")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Delete the send and its file
+func DeleteSend(res result.ResultValue) *result.Result {
+	request := res.(DeleteSendRequest)
+
+	response := sends.Delete(container.VaultClient(), container.BlobStore(), request.Send)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to delete send",
+			struct {
+				Email  string
+				SendId string
+				Error  types.PasswordCaddyError
+			}{
+				Email:  request.UserId,
+				SendId: request.SendId,
+				Error:  response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	logger.Info(
+		"Deleted send",
+		struct {
+			Email  string
+			SendId string
+		}{
+			Email:  request.UserId,
+			SendId: request.SendId,
+		},
+	)
+
+	return result.Success(204)
+}
+
+// Handle the delete send request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(CheckOwner).
+		Then(DeleteSend).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
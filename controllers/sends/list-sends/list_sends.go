@@ -0,0 +1,70 @@
+package main
+
+import (
+	"time"
+
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/sends"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// Initialize the List Sends Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	return result.SuccessWithValue(200, userId)
+}
+
+// Get the sends of the user that did not expire yet
+func GetSends(res result.ResultValue) *result.Result {
+	userId := res.(string)
+
+	response := sends.List(container.VaultClient(), userId, time.Now())
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch sends",
+			struct {
+				Email string
+				Error types.PasswordCaddyError
+			}{
+				Email: userId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	list := []sends.SendResponse{}
+
+	for _, send := range response.Data.([]types.Send) {
+		list = append(list, sends.ToSendResponse(send))
+	}
+
+	return result.SuccessWithValue(200, list)
+}
+
+// Handle the list sends request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(GetSends).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
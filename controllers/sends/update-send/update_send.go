@@ -0,0 +1,141 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/sends"
+	"password-caddy/api/lib/util"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// Only the disabled flag of a send can change, everything else needs a new send
+type UpdateSendRequest struct {
+	UserId   string     `json:"-"`
+	SendId   string     `json:"-"`
+	Disabled *bool      `json:"disabled"`
+	Send     types.Send `json:"-"`
+}
+
+// Initialize the Update Send Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	var request UpdateSendRequest
+
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	err := util.DeserializeJson(event.Body, &request)
+
+	if err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	request.UserId = userId
+	request.SendId = event.PathParameters["id"]
+
+	if !sends.IsValidSendId(request.SendId) {
+		return result.Failure(400, "Send id must be a UUID")
+	}
+
+	if request.Disabled == nil {
+		return result.Failure(400, "Disabled is required")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Check that the send belongs to the user
+func CheckOwner(res result.ResultValue) *result.Result {
+	request := res.(UpdateSendRequest)
+
+	response := sends.Get(container.VaultClient(), request.SendId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch send",
+			struct {
+				Email  string
+				SendId string
+				Error  types.PasswordCaddyError
+			}{
+				Email:  request.UserId,
+				SendId: request.SendId,
+				Error:  response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Send = response.Data.(types.Send)
+
+	if request.Send.OwnerId.Value != request.UserId {
+		return result.Failure(404, "Send not found")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Disable or enable the send
+func SaveSend(res result.ResultValue) *result.Result {
+	request := res.(UpdateSendRequest)
+
+	response := sends.SetDisabled(container.VaultClient(), request.Send, *request.Disabled)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to update send",
+			struct {
+				Email  string
+				SendId string
+				Error  types.PasswordCaddyError
+			}{
+				Email:  request.UserId,
+				SendId: request.SendId,
+				Error:  response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	logger.Info(
+		"Updated send",
+		struct {
+			Email    string
+			SendId   string
+			Disabled bool
+		}{
+			Email:    request.UserId,
+			SendId:   request.SendId,
+			Disabled: *request.Disabled,
+		},
+	)
+
+	return result.SuccessWithValue(200, sends.ToSendResponse(response.Data.(types.Send)))
+}
+
+// Handle the update send request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(CheckOwner).
+		Then(SaveSend).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
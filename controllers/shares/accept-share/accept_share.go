@@ -0,0 +1,136 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/shares"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type AcceptShareRequest struct {
+	UserId  string
+	ShareId string
+	Share   types.ItemShare
+}
+
+// Initialize the Accept Share Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	request := AcceptShareRequest{
+		UserId:  userId,
+		ShareId: event.PathParameters["id"],
+	}
+
+	if !shares.IsValidShareId(request.ShareId) {
+		return result.Failure(400, "Share id must be a UUID")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Get the share the user received
+func GetShare(res result.ResultValue) *result.Result {
+	request := res.(AcceptShareRequest)
+
+	response := shares.GetReceived(container.VaultClient(), request.UserId, request.ShareId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch received share",
+			struct {
+				Email   string
+				ShareId string
+				Error   types.PasswordCaddyError
+			}{
+				Email:   request.UserId,
+				ShareId: request.ShareId,
+				Error:   response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Share = response.Data.(types.ItemShare)
+
+	if request.Share.ShareId.Value == "" {
+		return result.Failure(404, "Share not found")
+	}
+
+	if request.Share.Status.Value != shares.STATUS_PENDING {
+		return result.Failure(409, "Share was already accepted")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Accept the share, giving the user access to the item
+func AcceptShare(res result.ResultValue) *result.Result {
+	request := res.(AcceptShareRequest)
+
+	response := shares.Accept(container.VaultClient(), request.Share)
+
+	if !response.IsSuccess && response.Error.StatusCode == 409 {
+		return result.Failure(409, "Share is no longer pending")
+	}
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to accept share",
+			struct {
+				Email   string
+				ShareId string
+				Error   types.PasswordCaddyError
+			}{
+				Email:   request.UserId,
+				ShareId: request.ShareId,
+				Error:   response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	logger.Info(
+		"Accepted share",
+		struct {
+			Email   string
+			ShareId string
+			Owner   string
+		}{
+			Email:   request.UserId,
+			ShareId: request.ShareId,
+			Owner:   request.Share.OwnerId.Value,
+		},
+	)
+
+	return result.SuccessWithValue(200, shares.ToReceivedResponse(response.Data.(types.ItemShare)))
+}
+
+// Handle the accept share request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(GetShare).
+		Then(AcceptShare).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
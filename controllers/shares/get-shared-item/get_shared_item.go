@@ -0,0 +1,130 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/access"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/shares"
+	"password-caddy/api/lib/vault"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type GetSharedItemRequest struct {
+	UserId  string
+	ShareId string
+	Share   types.ItemShare
+}
+
+// Initialize the Get Shared Item Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	request := GetSharedItemRequest{
+		UserId:  userId,
+		ShareId: event.PathParameters["id"],
+	}
+
+	if !shares.IsValidShareId(request.ShareId) {
+		return result.Failure(400, "Share id must be a UUID")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Get the share the user received. Only accepted shares give access to the item
+func GetShare(res result.ResultValue) *result.Result {
+	request := res.(GetSharedItemRequest)
+
+	response := shares.GetReceived(container.VaultClient(), request.UserId, request.ShareId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch received share",
+			struct {
+				Email   string
+				ShareId string
+				Error   types.PasswordCaddyError
+			}{
+				Email:   request.UserId,
+				ShareId: request.ShareId,
+				Error:   response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Share = response.Data.(types.ItemShare)
+
+	if request.Share.ShareId.Value == "" {
+		return result.Failure(404, "Share not found")
+	}
+
+	if !shares.Allows(request.Share, access.LEVEL_HIDE_PASSWORDS) {
+		return result.Failure(403, "Share must be accepted first")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Get the item from the owner's vault
+func GetItem(res result.ResultValue) *result.Result {
+	request := res.(GetSharedItemRequest)
+
+	response := vault.GetItem(container.VaultClient(), request.Share.OwnerId.Value, request.Share.ItemId.Value)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch shared vault item",
+			struct {
+				Email   string
+				ShareId string
+				Owner   string
+				Error   types.PasswordCaddyError
+			}{
+				Email:   request.UserId,
+				ShareId: request.ShareId,
+				Owner:   request.Share.OwnerId.Value,
+				Error:   response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	item := response.Data.(types.VaultItem)
+
+	// Items in the owner's trash are not shared anymore until they are restored
+	if item.ItemId.Value == "" || item.DeletedAt.Value != "" {
+		return result.Failure(404, "Vault item not found")
+	}
+
+	return result.SuccessWithValue(200, shares.ToSharedItemResponse(request.Share, item))
+}
+
+// Handle the get shared item request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(GetShare).
+		Then(GetItem).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
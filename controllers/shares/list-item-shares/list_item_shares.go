@@ -0,0 +1,85 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/shares"
+	"password-caddy/api/lib/vault"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type ListItemSharesRequest struct {
+	UserId string
+	ItemId string
+}
+
+// Initialize the List Item Shares Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	request := ListItemSharesRequest{
+		UserId: userId,
+		ItemId: event.PathParameters["id"],
+	}
+
+	if !vault.IsValidItemId(request.ItemId) {
+		return result.Failure(400, "Item id must be a UUID")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// List who the item is shared with
+func ListShares(res result.ResultValue) *result.Result {
+	request := res.(ListItemSharesRequest)
+
+	response := shares.ListForItem(container.VaultClient(), request.UserId, request.ItemId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to list shares of vault item",
+			struct {
+				Email  string
+				ItemId string
+				Error  types.PasswordCaddyError
+			}{
+				Email:  request.UserId,
+				ItemId: request.ItemId,
+				Error:  response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	responses := []shares.ShareResponse{}
+
+	for _, share := range response.Data.([]types.ItemShare) {
+		responses = append(responses, shares.ToShareResponse(share))
+	}
+
+	return result.SuccessWithValue(200, responses)
+}
+
+// Handle the list item shares request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(ListShares).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/shares"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type ListSharesRequest struct {
+	UserId string
+}
+
+// Initialize the List Shares Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	return result.SuccessWithValue(200, ListSharesRequest{UserId: userId})
+}
+
+// List the items shared with the user, pending and accepted
+func ListShares(res result.ResultValue) *result.Result {
+	request := res.(ListSharesRequest)
+
+	response := shares.ListReceived(container.VaultClient(), request.UserId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to list received shares",
+			struct {
+				Email string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	responses := []shares.ShareResponse{}
+
+	for _, share := range response.Data.([]types.ItemShare) {
+		responses = append(responses, shares.ToReceivedResponse(share))
+	}
+
+	return result.SuccessWithValue(200, responses)
+}
+
+// Handle the list shares request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(ListShares).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/orgs"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/shares"
+	"password-caddy/api/lib/vault"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type RevokeItemShareRequest struct {
+	UserId    string
+	ItemId    string
+	Recipient string
+	Share     types.ItemShare
+}
+
+// Initialize the Revoke Item Share Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	request := RevokeItemShareRequest{
+		UserId:    userId,
+		ItemId:    event.PathParameters["id"],
+		Recipient: orgs.EmailParameter(event.PathParameters["email"]),
+	}
+
+	if !vault.IsValidItemId(request.ItemId) {
+		return result.Failure(400, "Item id must be a UUID")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Get the share of the item with the recipient
+func GetShare(res result.ResultValue) *result.Result {
+	request := res.(RevokeItemShareRequest)
+
+	response := shares.Get(container.VaultClient(), request.UserId, request.ItemId, request.Recipient)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch share of vault item",
+			struct {
+				Email     string
+				ItemId    string
+				Recipient string
+				Error     types.PasswordCaddyError
+			}{
+				Email:     request.UserId,
+				ItemId:    request.ItemId,
+				Recipient: request.Recipient,
+				Error:     response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Share = response.Data.(types.ItemShare)
+
+	if request.Share.ShareId.Value == "" {
+		return result.Failure(404, "Share not found")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Remove the share together with the recipient's shared copy
+func RemoveShare(res result.ResultValue) *result.Result {
+	request := res.(RevokeItemShareRequest)
+
+	response := shares.Remove(container.VaultClient(), request.Share)
+
+	if !response.IsSuccess && response.Error.StatusCode == 409 {
+		return result.Failure(404, "Share not found")
+	}
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to revoke share of vault item",
+			struct {
+				Email     string
+				ItemId    string
+				Recipient string
+				Error     types.PasswordCaddyError
+			}{
+				Email:     request.UserId,
+				ItemId:    request.ItemId,
+				Recipient: request.Recipient,
+				Error:     response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	logger.Info(
+		"Revoked share of vault item",
+		struct {
+			Email     string
+			ItemId    string
+			Recipient string
+		}{
+			Email:     request.UserId,
+			ItemId:    request.ItemId,
+			Recipient: request.Recipient,
+		},
+	)
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Email the recipient. The share stays revoked when the email fails
+func SendRevokedEmail(res result.ResultValue) *result.Result {
+	request := res.(RevokeItemShareRequest)
+
+	response := container.SesClient().
+		BuildShareRevokedEmailRequest(request.Recipient, request.UserId).
+		Send()
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to send share revoked email",
+			struct {
+				Email     string
+				ItemId    string
+				Recipient string
+				Error     types.PasswordCaddyError
+			}{
+				Email:     request.UserId,
+				ItemId:    request.ItemId,
+				Recipient: request.Recipient,
+				Error:     response.Error,
+			},
+		)
+	}
+
+	return result.Success(204)
+}
+
+// Handle the revoke item share request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(GetShare).
+		Then(RemoveShare).
+		Then(SendRevokedEmail).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"fmt"
+
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/keys"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/shares"
+	"password-caddy/api/lib/util"
+	"password-caddy/api/lib/vault"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type ShareItemRequest struct {
+	UserId string
+	ItemId string
+	Share  shares.ShareRequest
+}
+
+// Initialize the Share Item Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	var request ShareItemRequest
+
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	err := util.DeserializeJson(event.Body, &request.Share)
+
+	if err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	request.UserId = userId
+	request.ItemId = event.PathParameters["id"]
+
+	if !vault.IsValidItemId(request.ItemId) {
+		return result.Failure(400, "Item id must be a UUID")
+	}
+
+	if err := request.Share.Validate(userId); err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Check that the item exists in the user's vault and is not in the trash
+func CheckItem(res result.ResultValue) *result.Result {
+	request := res.(ShareItemRequest)
+
+	response := vault.GetItem(container.VaultClient(), request.UserId, request.ItemId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch vault item",
+			struct {
+				Email  string
+				ItemId string
+				Error  types.PasswordCaddyError
+			}{
+				Email:  request.UserId,
+				ItemId: request.ItemId,
+				Error:  response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	item := response.Data.(types.VaultItem)
+
+	if item.ItemId.Value == "" {
+		return result.Failure(404, "Vault item not found")
+	}
+
+	if item.DeletedAt.Value != "" {
+		return result.Failure(409, "Vault item is in the trash")
+	}
+
+	response = shares.ListForItem(container.VaultClient(), request.UserId, request.ItemId)
+
+	if !response.IsSuccess {
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	if len(response.Data.([]types.ItemShare)) >= shares.MAX_SHARES_PER_ITEM {
+		return result.Failure(409, fmt.Sprintf("An item can be shared with at most %d users", shares.MAX_SHARES_PER_ITEM))
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Check that the recipient has a key pair and the item key was wrapped with its current public key
+func CheckRecipient(res result.ResultValue) *result.Result {
+	request := res.(ShareItemRequest)
+
+	response := keys.Get(container.VaultClient(), request.Share.Recipient)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch public key",
+			struct {
+				Email     string
+				Recipient string
+				Error     types.PasswordCaddyError
+			}{
+				Email:     request.UserId,
+				Recipient: request.Share.Recipient,
+				Error:     response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	recipientKeys := response.Data.(types.UserKeys)
+
+	// Only registered users who set up a key pair can receive shares
+	if recipientKeys.PublicKey.Value == "" {
+		return result.Failure(404, "Recipient not found")
+	}
+
+	if recipientKeys.Fingerprint.Value != request.Share.Fingerprint {
+		return result.FailureWithDetails(
+			409,
+			"The recipient's public key changed",
+			keys.ToPublicKeyResponse(recipientKeys),
+		)
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Save the pending share in the partitions of the owner and the recipient
+func SaveShare(res result.ResultValue) *result.Result {
+	request := res.(ShareItemRequest)
+
+	response := shares.Create(container.VaultClient(), request.UserId, request.ItemId, request.Share)
+
+	if !response.IsSuccess && response.Error.StatusCode == 409 {
+		return result.Failure(409, "Item is already shared with this user")
+	}
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to share vault item",
+			struct {
+				Email     string
+				ItemId    string
+				Recipient string
+				Error     types.PasswordCaddyError
+			}{
+				Email:     request.UserId,
+				ItemId:    request.ItemId,
+				Recipient: request.Share.Recipient,
+				Error:     response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	logger.Info(
+		"Shared vault item",
+		struct {
+			Email     string
+			ItemId    string
+			Recipient string
+			Level     string
+		}{
+			Email:     request.UserId,
+			ItemId:    request.ItemId,
+			Recipient: request.Share.Recipient,
+			Level:     request.Share.Level,
+		},
+	)
+
+	return result.SuccessWithValue(201, shares.ToShareResponse(response.Data.(types.ItemShare)))
+}
+
+// Handle the share item request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(CheckItem).
+		Then(CheckRecipient).
+		Then(SaveShare).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
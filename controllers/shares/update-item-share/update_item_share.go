@@ -0,0 +1,154 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/orgs"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/shares"
+	"password-caddy/api/lib/util"
+	"password-caddy/api/lib/vault"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type UpdateItemShareRequest struct {
+	UserId    string
+	ItemId    string
+	Recipient string
+	Update    shares.UpdateShareRequest
+	Share     types.ItemShare
+}
+
+// Initialize the Update Item Share Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	var request UpdateItemShareRequest
+
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	err := util.DeserializeJson(event.Body, &request.Update)
+
+	if err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	request.UserId = userId
+	request.ItemId = event.PathParameters["id"]
+	request.Recipient = orgs.EmailParameter(event.PathParameters["email"])
+
+	if !vault.IsValidItemId(request.ItemId) {
+		return result.Failure(400, "Item id must be a UUID")
+	}
+
+	if err := request.Update.Validate(); err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Get the share of the item with the recipient
+func GetShare(res result.ResultValue) *result.Result {
+	request := res.(UpdateItemShareRequest)
+
+	response := shares.Get(container.VaultClient(), request.UserId, request.ItemId, request.Recipient)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch share of vault item",
+			struct {
+				Email     string
+				ItemId    string
+				Recipient string
+				Error     types.PasswordCaddyError
+			}{
+				Email:     request.UserId,
+				ItemId:    request.ItemId,
+				Recipient: request.Recipient,
+				Error:     response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Share = response.Data.(types.ItemShare)
+
+	if request.Share.ShareId.Value == "" {
+		return result.Failure(404, "Share not found")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Change what the recipient is allowed to do with the item
+func SaveLevel(res result.ResultValue) *result.Result {
+	request := res.(UpdateItemShareRequest)
+
+	response := shares.UpdateLevel(container.VaultClient(), request.Share, request.Update.Level)
+
+	if !response.IsSuccess && response.Error.StatusCode == 409 {
+		return result.Failure(404, "Share not found")
+	}
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to update share of vault item",
+			struct {
+				Email     string
+				ItemId    string
+				Recipient string
+				Error     types.PasswordCaddyError
+			}{
+				Email:     request.UserId,
+				ItemId:    request.ItemId,
+				Recipient: request.Recipient,
+				Error:     response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	logger.Info(
+		"Updated share of vault item",
+		struct {
+			Email     string
+			ItemId    string
+			Recipient string
+			Level     string
+		}{
+			Email:     request.UserId,
+			ItemId:    request.ItemId,
+			Recipient: request.Recipient,
+			Level:     request.Update.Level,
+		},
+	)
+
+	return result.SuccessWithValue(200, shares.ToShareResponse(response.Data.(types.ItemShare)))
+}
+
+// Handle the update item share request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(GetShare).
+		Then(SaveLevel).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
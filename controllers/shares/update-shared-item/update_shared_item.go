@@ -0,0 +1,259 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/access"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/shares"
+	"password-caddy/api/lib/util"
+	"password-caddy/api/lib/vault"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// Revision is the revision of the item the recipient last saw. It can also be
+// sent with the If-Match header. The folder of the item stays the owner's
+type UpdateSharedItemRequest struct {
+	UserId   string          `json:"-"`
+	ShareId  string          `json:"-"`
+	Data     string          `json:"data"`
+	Revision int             `json:"revision"`
+	Share    types.ItemShare `json:"-"`
+	Current  types.VaultItem `json:"-"`
+}
+
+// Initialize the Update Shared Item Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	var request UpdateSharedItemRequest
+
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	err := util.DeserializeJson(event.Body, &request)
+
+	if err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	request.UserId = userId
+	request.ShareId = event.PathParameters["id"]
+	request.Revision, err = vault.ExpectedRevision(event.Headers, request.Revision)
+
+	if err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	if !shares.IsValidShareId(request.ShareId) {
+		return result.Failure(400, "Share id must be a UUID")
+	}
+
+	if request.Data == "" {
+		return result.Failure(400, "Item data is required")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Get the share the user received and check that it allows editing the item
+func GetShare(res result.ResultValue) *result.Result {
+	request := res.(UpdateSharedItemRequest)
+
+	response := shares.GetReceived(container.VaultClient(), request.UserId, request.ShareId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch received share",
+			struct {
+				Email   string
+				ShareId string
+				Error   types.PasswordCaddyError
+			}{
+				Email:   request.UserId,
+				ShareId: request.ShareId,
+				Error:   response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Share = response.Data.(types.ItemShare)
+
+	if request.Share.ShareId.Value == "" {
+		return result.Failure(404, "Share not found")
+	}
+
+	if !shares.Allows(request.Share, access.LEVEL_EDIT) {
+		logger.Security(
+			"Attempted to update a shared vault item without edit access",
+			struct {
+				Email   string
+				ShareId string
+				Owner   string
+				Level   string
+			}{
+				Email:   request.UserId,
+				ShareId: request.ShareId,
+				Owner:   request.Share.OwnerId.Value,
+				Level:   request.Share.Level.Value,
+			},
+		)
+
+		return result.Failure(403, "Not allowed to edit this item")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Get the current version of the item from the owner's vault
+func GetCurrentItem(res result.ResultValue) *result.Result {
+	request := res.(UpdateSharedItemRequest)
+
+	response := vault.GetItem(container.VaultClient(), request.Share.OwnerId.Value, request.Share.ItemId.Value)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch shared vault item",
+			struct {
+				Email   string
+				ShareId string
+				Owner   string
+				Error   types.PasswordCaddyError
+			}{
+				Email:   request.UserId,
+				ShareId: request.ShareId,
+				Owner:   request.Share.OwnerId.Value,
+				Error:   response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Current = response.Data.(types.VaultItem)
+
+	if request.Current.ItemId.Value == "" || request.Current.DeletedAt.Value != "" {
+		return result.Failure(404, "Vault item not found")
+	}
+
+	if !vault.MatchesRevision(request.Current, request.Revision) {
+		return result.FailureWithDetails(
+			409,
+			"Vault item was modified by another client",
+			shares.ToSharedItemResponse(request.Share, request.Current),
+		)
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Keep the current version of the item in the owner's history before overwriting it
+func RetainPreviousRevision(res result.ResultValue) *result.Result {
+	request := res.(UpdateSharedItemRequest)
+
+	response := vault.RetainRevision(container.VaultClient(), request.Current)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to retain previous revision of shared vault item",
+			struct {
+				Email    string
+				ShareId  string
+				Revision int
+				Error    types.PasswordCaddyError
+			}{
+				Email:    request.UserId,
+				ShareId:  request.ShareId,
+				Revision: request.Current.Revision.Value,
+				Error:    response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Save the new version of the item in the owner's vault
+func SaveItem(res result.ResultValue) *result.Result {
+	request := res.(UpdateSharedItemRequest)
+
+	item := request.Current
+	item.Data.Value = request.Data
+
+	response := vault.PutItem(container.VaultClient(), item, request.Revision)
+
+	if !response.IsSuccess && response.Error.StatusCode == 409 {
+		return result.Failure(409, "Vault item was modified by another client")
+	}
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to save shared vault item",
+			struct {
+				Email   string
+				ShareId string
+				Owner   string
+				Error   types.PasswordCaddyError
+			}{
+				Email:   request.UserId,
+				ShareId: request.ShareId,
+				Owner:   request.Share.OwnerId.Value,
+				Error:   response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	logger.Info(
+		"Saved shared vault item",
+		struct {
+			Email    string
+			ShareId  string
+			Owner    string
+			Revision int
+		}{
+			Email:    request.UserId,
+			ShareId:  request.ShareId,
+			Owner:    request.Share.OwnerId.Value,
+			Revision: request.Revision + 1,
+		},
+	)
+
+	return result.SuccessWithValue(200, shares.ToSharedItemResponse(request.Share, response.Data.(types.VaultItem)))
+}
+
+// Handle the update shared item request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(GetShare).
+		Then(GetCurrentItem).
+		Then(RetainPreviousRevision).
+		Then(SaveItem).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
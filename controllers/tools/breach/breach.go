@@ -0,0 +1,105 @@
+package main
+
+import (
+	"strings"
+
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/breach"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/result"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+/*
+Only the first 5 characters of the SHA-1 hash of a password are sent, the
+client compares the suffixes itself (k-anonymity)
+*/
+type BreachRequest struct {
+	Prefix string
+	Range  breach.Range
+}
+
+// Initialize the Breach Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	prefix := strings.ToUpper(event.PathParameters["prefix"])
+
+	if !breach.IsValidPrefix(prefix) {
+		return result.Failure(400, "Prefix must be the first 5 hex characters of a SHA-1 hash")
+	}
+
+	return result.SuccessWithValue(200, BreachRequest{Prefix: prefix})
+}
+
+// Get the breached hashes of the prefix
+func GetRange(res result.ResultValue) *result.Result {
+	request := res.(BreachRequest)
+
+	if breach.Source() == breach.SOURCE_FIXTURE {
+		request.Range = breach.GetFixtureRange(request.Prefix)
+		return result.SuccessWithValue(200, request)
+	}
+
+	response := breach.GetRange(container.BreachClient(), request.Prefix)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch breach range",
+			struct {
+				Prefix string
+				Error  types.PasswordCaddyError
+			}{
+				Prefix: request.Prefix,
+				Error:  response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Range = response.Data.(breach.Range)
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Pad the range so its size does not leak the prefix
+func PadRange(res result.ResultValue) *result.Result {
+	request := res.(BreachRequest)
+
+	min, max := breach.PaddingBounds()
+	padded, err := breach.Pad(request.Range, min, max)
+
+	if err != nil {
+		logger.Error(
+			"Failed to pad breach range",
+			struct {
+				Prefix string
+				Error  string
+			}{
+				Prefix: request.Prefix,
+				Error:  err.Error(),
+			},
+		)
+
+		return result.Failure(500, "Failed to pad breach range")
+	}
+
+	return result.SuccessWithValue(200, padded)
+}
+
+// Handle the breach request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(GetRange).
+		Then(PadRange).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
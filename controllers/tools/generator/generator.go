@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/generator"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/result"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// Only the options of Type are used
+type GeneratorRequest struct {
+	Type       string
+	Password   generator.PasswordOptions
+	Passphrase generator.PassphraseOptions
+	Username   generator.UsernameOptions
+}
+
+// Initialize the Generator Request out of the query string
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	query := event.QueryStringParameters
+
+	request := GeneratorRequest{
+		Type:       query["type"],
+		Password:   generator.DefaultPasswordOptions(),
+		Passphrase: generator.DefaultPassphraseOptions(),
+		Username:   generator.DefaultUsernameOptions(),
+	}
+
+	if request.Type == "" {
+		request.Type = generator.TYPE_PASSWORD
+	}
+
+	var err error
+
+	switch request.Type {
+	case generator.TYPE_PASSWORD:
+		options := &request.Password
+		err = firstError(
+			queryInt(query, "length", &options.Length),
+			queryBool(query, "upper", &options.Upper),
+			queryBool(query, "lower", &options.Lower),
+			queryBool(query, "digits", &options.Digits),
+			queryBool(query, "symbols", &options.Symbols),
+			queryBool(query, "excludeAmbiguous", &options.ExcludeAmbiguous),
+			queryInt(query, "minUpper", &options.MinUpper),
+			queryInt(query, "minLower", &options.MinLower),
+			queryInt(query, "minDigits", &options.MinDigits),
+			queryInt(query, "minSymbols", &options.MinSymbols),
+		)
+	case generator.TYPE_PASSPHRASE:
+		options := &request.Passphrase
+		err = firstError(
+			queryInt(query, "words", &options.Words),
+			queryBool(query, "capitalize", &options.Capitalize),
+			queryBool(query, "includeNumber", &options.IncludeNumber),
+		)
+
+		if separator, ok := query["separator"]; ok {
+			options.Separator = separator
+		}
+	case generator.TYPE_USERNAME:
+		options := &request.Username
+		err = firstError(
+			queryBool(query, "capitalize", &options.Capitalize),
+			queryBool(query, "includeNumber", &options.IncludeNumber),
+		)
+
+		if kind, ok := query["kind"]; ok {
+			options.Kind = kind
+		}
+
+		options.Domain = query["domain"]
+		options.Email = query["email"]
+
+		// Plus addresses default to the caller's own email
+		if options.Email == "" {
+			options.Email, _ = auth.CallerId(event)
+		}
+	default:
+		return result.Failure(400, fmt.Sprintf("Type must be one of %s, %s or %s", generator.TYPE_PASSWORD, generator.TYPE_PASSPHRASE, generator.TYPE_USERNAME))
+	}
+
+	if err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Generate the value with the options of the requested type
+func Generate(res result.ResultValue) *result.Result {
+	request := res.(GeneratorRequest)
+
+	var generated generator.Generated
+	var err error
+
+	switch request.Type {
+	case generator.TYPE_PASSPHRASE:
+		err = request.Passphrase.Validate()
+
+		if err == nil {
+			generated, err = generator.GeneratePassphrase(request.Passphrase)
+		}
+	case generator.TYPE_USERNAME:
+		err = request.Username.Validate()
+
+		if err == nil {
+			generated, err = generator.GenerateUsername(request.Username)
+		}
+	default:
+		err = request.Password.Validate()
+
+		if err == nil {
+			generated, err = generator.GeneratePassword(request.Password)
+		}
+	}
+
+	if err != nil {
+		logger.Warn(
+			"Failed to generate value",
+			struct {
+				Type  string
+				Error string
+			}{
+				Type:  request.Type,
+				Error: err.Error(),
+			},
+		)
+
+		return result.Failure(400, err.Error())
+	}
+
+	return result.SuccessWithValue(200, generated)
+}
+
+func queryInt(query map[string]string, key string, value *int) error {
+	raw, ok := query[key]
+
+	if !ok {
+		return nil
+	}
+
+	parsed, err := strconv.Atoi(raw)
+
+	if err != nil {
+		return fmt.Errorf("%s must be a number", key)
+	}
+
+	*value = parsed
+
+	return nil
+}
+
+func queryBool(query map[string]string, key string, value *bool) error {
+	raw, ok := query[key]
+
+	if !ok {
+		return nil
+	}
+
+	parsed, err := strconv.ParseBool(raw)
+
+	if err != nil {
+		return fmt.Errorf("%s must be true or false", key)
+	}
+
+	*value = parsed
+
+	return nil
+}
+
+func firstError(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Handle the generator request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(Generate).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
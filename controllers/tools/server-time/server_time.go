@@ -0,0 +1,41 @@
+package main
+
+import (
+	"time"
+
+	"password-caddy/api/lib/result"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+/*
+The clock of the server. Clients compare it to their own clock to correct
+the skew when they compute TOTP codes
+*/
+type ServerTimeResponse struct {
+	Epoch       int64  `json:"epoch"`
+	EpochMillis int64  `json:"epochMillis"`
+	Time        string `json:"time"`
+}
+
+// Read the clock of the server
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	now := time.Now().UTC()
+
+	return result.SuccessWithValue(200, ServerTimeResponse{
+		Epoch:       now.Unix(),
+		EpochMillis: now.UnixNano() / int64(time.Millisecond),
+		Time:        now.Format(time.RFC3339Nano),
+	})
+}
+
+// Handle the server time request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
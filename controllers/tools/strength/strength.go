@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/strength"
+	"password-caddy/api/lib/util"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// Longest password that is estimated, the estimate grows with the length
+const MAX_PASSWORD_LENGTH = 256
+
+// Upper bound of the user inputs of a request
+const MAX_USER_INPUTS = 20
+
+// The password is never logged
+type StrengthRequest struct {
+	Password   string   `json:"password"`
+	UserInputs []string `json:"userInputs"`
+}
+
+// Initialize the Strength Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	var request StrengthRequest
+
+	err := util.DeserializeJson(event.Body, &request)
+
+	if err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	if request.Password == "" {
+		return result.Failure(400, "Password is required")
+	}
+
+	if utf8.RuneCountInString(request.Password) > MAX_PASSWORD_LENGTH {
+		return result.Failure(400, fmt.Sprintf("Password must be at most %d characters", MAX_PASSWORD_LENGTH))
+	}
+
+	if len(request.UserInputs) > MAX_USER_INPUTS {
+		return result.Failure(400, fmt.Sprintf("At most %d user inputs are allowed", MAX_USER_INPUTS))
+	}
+
+	// The email of the caller and its local part are always user inputs
+	if email, ok := auth.CallerId(event); ok {
+		request.UserInputs = append(request.UserInputs, email, strings.Split(email, "@")[0])
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Estimate the strength of the password
+func Estimate(res result.ResultValue) *result.Result {
+	request := res.(StrengthRequest)
+
+	estimate := strength.Estimate(request.Password, request.UserInputs)
+
+	logger.Info(
+		"Estimated password strength",
+		struct {
+			Score    int
+			CalcTime int64
+		}{
+			Score:    estimate.Score,
+			CalcTime: estimate.CalcTime,
+		},
+	)
+
+	return result.SuccessWithValue(200, estimate)
+}
+
+// Handle the strength request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(Estimate).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
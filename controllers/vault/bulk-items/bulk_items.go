@@ -0,0 +1,283 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/access"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/util"
+	"password-caddy/api/lib/vault"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// FolderId is the target of a move. An empty folder moves the items out of their folder.
+// Items of an organization, selected with the orgId query parameter, can not be moved
+type BulkItemsRequest struct {
+	UserId    string                     `json:"-"`
+	OrgId     string                     `json:"-"`
+	Action    string                     `json:"action"`
+	FolderId  string                     `json:"folderId"`
+	Items     []vault.BulkItem           `json:"items"`
+	Scope     access.Scope               `json:"-"`
+	Current   map[string]types.VaultItem `json:"-"`
+	Histories []types.VaultItemHistory   `json:"-"`
+}
+
+type BulkItemsResponse struct {
+	Results []vault.BulkItemResult `json:"results"`
+}
+
+// Initialize the Bulk Items Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	var request BulkItemsRequest
+
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	err := util.DeserializeJson(event.Body, &request)
+
+	if err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	request.UserId = userId
+	request.OrgId = event.QueryStringParameters["orgId"]
+
+	if !vault.IsValidBulkAction(request.Action) {
+		return result.Failure(400, "Action must be one of move, delete, restore or rekey")
+	}
+
+	if request.Action == vault.BULK_ACTION_MOVE && request.OrgId != "" {
+		return result.Failure(400, "Items of an organization can not be in folders")
+	}
+
+	if request.Action == vault.BULK_ACTION_MOVE && request.FolderId != "" && !vault.IsValidItemId(request.FolderId) {
+		return result.Failure(400, "Folder id must be a UUID")
+	}
+
+	if len(request.Items) == 0 {
+		return result.Failure(400, "Items are required")
+	}
+
+	if len(request.Items) > vault.BulkMaxItems() {
+		return result.Failure(400, fmt.Sprintf("A bulk request can contain at most %d items", vault.BulkMaxItems()))
+	}
+
+	ids := map[string]bool{}
+
+	for _, item := range request.Items {
+		if err := item.Validate(); err != nil {
+			return result.Failure(400, err.Error())
+		}
+
+		if ids[item.Id] {
+			return result.Failure(400, "Items must be unique")
+		}
+
+		ids[item.Id] = true
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Resolve the vault of the items, the user's own or an organization's
+func ResolveScope(res result.ResultValue) *result.Result {
+	request := res.(BulkItemsRequest)
+
+	response := access.Resolve(container.VaultClient(), request.UserId, request.OrgId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to resolve the vault of the request",
+			struct {
+				Email string
+				OrgId string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Scope = response.Data.(access.Scope)
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Check that the folder the items are moved to exists
+func CheckFolder(res result.ResultValue) *result.Result {
+	request := res.(BulkItemsRequest)
+
+	if request.Action != vault.BULK_ACTION_MOVE || request.FolderId == "" {
+		return result.SuccessWithValue(200, request)
+	}
+
+	response := vault.GetFolder(container.VaultClient(), request.UserId, request.FolderId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch folder",
+			struct {
+				Email    string
+				FolderId string
+				Error    types.PasswordCaddyError
+			}{
+				Email:    request.UserId,
+				FolderId: request.FolderId,
+				Error:    response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	if response.Data.(types.VaultFolder).FolderId.Value == "" {
+		return result.Failure(404, "Folder not found")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Get the current items of the vault the user can read
+func GetCurrentItems(res result.ResultValue) *result.Result {
+	request := res.(BulkItemsRequest)
+
+	response := vault.ListItems(container.VaultClient(), request.Scope.Partition())
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch vault items",
+			struct {
+				Email string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Current = map[string]types.VaultItem{}
+
+	for _, item := range request.Scope.ItemsAllowing(response.Data.([]types.VaultItem), access.LEVEL_HIDE_PASSWORDS) {
+		request.Current[item.ItemId.Value] = item
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Get the history of the items of a rekey, which is dropped with it
+func GetHistories(res result.ResultValue) *result.Result {
+	request := res.(BulkItemsRequest)
+
+	if request.Action != vault.BULK_ACTION_REKEY {
+		return result.SuccessWithValue(200, request)
+	}
+
+	response := vault.ListHistories(container.VaultClient(), request.Scope.Partition())
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch vault item history",
+			struct {
+				Email string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Histories = response.Data.([]types.VaultItemHistory)
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Apply the action to every item. Each item gets its own result. Changing
+// items of an organization needs edit access, like a single item
+func ApplyAction(res result.ResultValue) *result.Result {
+	request := res.(BulkItemsRequest)
+
+	results, changes := vault.PlanBulkChanges(request.Action, request.FolderId, request.Items, request.Current, time.Now())
+	changes = request.Scope.AuthorizeBulkChanges(changes, results, access.LEVEL_EDIT)
+
+	if request.Action == vault.BULK_ACTION_REKEY {
+		vault.DropRekeyedHistory(changes, request.Histories)
+	}
+
+	vault.ApplyBulkChanges(container.VaultClient(), request.Scope.Partition(), changes, results)
+	request.Scope.FlagBulkResults(results)
+
+	failed := 0
+
+	for _, itemResult := range results {
+		if itemResult.Status != 200 {
+			failed++
+		}
+	}
+
+	logger.Info(
+		"Applied bulk action to vault items",
+		struct {
+			Email  string
+			OrgId  string
+			Action string
+			Items  int
+			Failed int
+		}{
+			Email:  request.UserId,
+			OrgId:  request.OrgId,
+			Action: request.Action,
+			Items:  len(results),
+			Failed: failed,
+		},
+	)
+
+	return result.SuccessWithValue(200, BulkItemsResponse{Results: results})
+}
+
+// Handle the bulk vault items request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(ResolveScope).
+		Then(CheckFolder).
+		Then(GetCurrentItems).
+		Then(GetHistories).
+		Then(ApplyAction).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
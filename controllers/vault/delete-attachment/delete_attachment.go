@@ -0,0 +1,210 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/access"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/vault"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type DeleteAttachmentRequest struct {
+	UserId       string
+	OrgId        string
+	ItemId       string
+	AttachmentId string
+	Attachment   types.VaultAttachment
+	Scope        access.Scope
+}
+
+// Initialize the Delete Attachment Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	request := DeleteAttachmentRequest{
+		UserId:       userId,
+		OrgId:        event.QueryStringParameters["orgId"],
+		ItemId:       event.PathParameters["id"],
+		AttachmentId: event.PathParameters["attachmentId"],
+	}
+
+	if !vault.IsValidItemId(request.ItemId) || !vault.IsValidItemId(request.AttachmentId) {
+		return result.Failure(400, "Item id and attachment id must be UUIDs")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Resolve the vault of the item, the user's own or an organization's
+func ResolveScope(res result.ResultValue) *result.Result {
+	request := res.(DeleteAttachmentRequest)
+
+	response := access.Resolve(container.VaultClient(), request.UserId, request.OrgId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to resolve the vault of the request",
+			struct {
+				Email string
+				OrgId string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Scope = response.Data.(access.Scope)
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Check that the user can edit the item
+func CheckAccess(res result.ResultValue) *result.Result {
+	request := res.(DeleteAttachmentRequest)
+
+	response := vault.GetItem(container.VaultClient(), request.Scope.Partition(), request.ItemId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch vault item",
+			struct {
+				Email  string
+				ItemId string
+				Error  types.PasswordCaddyError
+			}{
+				Email:  request.UserId,
+				ItemId: request.ItemId,
+				Error:  response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	item := response.Data.(types.VaultItem)
+
+	if !request.Scope.CanRead(item) {
+		return result.Failure(404, "Vault item not found")
+	}
+
+	if !request.Scope.Can(item, access.LEVEL_EDIT) {
+		return result.Failure(403, "Not allowed to edit this item")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Get the metadata of the attachment
+func GetAttachment(res result.ResultValue) *result.Result {
+	request := res.(DeleteAttachmentRequest)
+
+	response := vault.GetAttachment(container.VaultClient(), request.Scope.Partition(), request.ItemId, request.AttachmentId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch attachment",
+			struct {
+				Email        string
+				AttachmentId string
+				Error        types.PasswordCaddyError
+			}{
+				Email:        request.UserId,
+				AttachmentId: request.AttachmentId,
+				Error:        response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Attachment = response.Data.(types.VaultAttachment)
+
+	if request.Attachment.AttachmentId.Value == "" {
+		return result.Failure(404, "Attachment not found")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Delete the attachment and free its quota
+func DeleteAttachment(res result.ResultValue) *result.Result {
+	request := res.(DeleteAttachmentRequest)
+
+	response := vault.DeleteAttachment(container.VaultClient(), container.BlobStore(), request.Attachment)
+
+	if !response.IsSuccess && response.Error.StatusCode == 409 {
+		return result.Failure(404, "Attachment not found")
+	}
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to delete attachment",
+			struct {
+				Email        string
+				AttachmentId string
+				Error        types.PasswordCaddyError
+			}{
+				Email:        request.UserId,
+				AttachmentId: request.AttachmentId,
+				Error:        response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	logger.Info(
+		"Deleted attachment",
+		struct {
+			Email        string
+			ItemId       string
+			AttachmentId string
+		}{
+			Email:        request.UserId,
+			ItemId:       request.ItemId,
+			AttachmentId: request.AttachmentId,
+		},
+	)
+
+	return result.Success(204)
+}
+
+// Handle the delete attachment request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(ResolveScope).
+		Then(CheckAccess).
+		Then(GetAttachment).
+		Then(DeleteAttachment).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
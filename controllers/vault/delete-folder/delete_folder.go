@@ -0,0 +1,123 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/vault"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type DeleteFolderRequest struct {
+	UserId   string
+	FolderId string
+}
+
+// Initialize the Delete Folder Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	request := DeleteFolderRequest{
+		UserId:   userId,
+		FolderId: event.PathParameters["id"],
+	}
+
+	if !vault.IsValidItemId(request.FolderId) {
+		return result.Failure(400, "Folder id must be a UUID")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Check that the folder exists
+func GetFolder(res result.ResultValue) *result.Result {
+	request := res.(DeleteFolderRequest)
+
+	response := vault.GetFolder(container.VaultClient(), request.UserId, request.FolderId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch folder",
+			struct {
+				Email    string
+				FolderId string
+				Error    types.PasswordCaddyError
+			}{
+				Email:    request.UserId,
+				FolderId: request.FolderId,
+				Error:    response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	if response.Data.(types.VaultFolder).FolderId.Value == "" {
+		return result.Failure(404, "Folder not found")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Delete the folder and leave a tombstone for the sync
+func DeleteFolder(res result.ResultValue) *result.Result {
+	request := res.(DeleteFolderRequest)
+
+	response := vault.DeleteFolder(container.VaultClient(), request.UserId, request.FolderId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to delete folder",
+			struct {
+				Email    string
+				FolderId string
+				Error    types.PasswordCaddyError
+			}{
+				Email:    request.UserId,
+				FolderId: request.FolderId,
+				Error:    response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	logger.Info(
+		"Deleted folder",
+		struct {
+			Email    string
+			FolderId string
+		}{
+			Email:    request.UserId,
+			FolderId: request.FolderId,
+		},
+	)
+
+	return result.Success(204)
+}
+
+// Handle the delete folder request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(GetFolder).
+		Then(DeleteFolder).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/base64"
+
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/access"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/vault"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type DownloadAttachmentRequest struct {
+	UserId       string
+	OrgId        string
+	ItemId       string
+	AttachmentId string
+	Attachment   types.VaultAttachment
+	Scope        access.Scope
+}
+
+// Initialize the Download Attachment Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	request := DownloadAttachmentRequest{
+		UserId:       userId,
+		OrgId:        event.QueryStringParameters["orgId"],
+		ItemId:       event.PathParameters["id"],
+		AttachmentId: event.PathParameters["attachmentId"],
+	}
+
+	if !vault.IsValidItemId(request.ItemId) || !vault.IsValidItemId(request.AttachmentId) {
+		return result.Failure(400, "Item id and attachment id must be UUIDs")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Resolve the vault of the item, the user's own or an organization's
+func ResolveScope(res result.ResultValue) *result.Result {
+	request := res.(DownloadAttachmentRequest)
+
+	response := access.Resolve(container.VaultClient(), request.UserId, request.OrgId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to resolve the vault of the request",
+			struct {
+				Email string
+				OrgId string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Scope = response.Data.(access.Scope)
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Check that the user can see the item
+func CheckAccess(res result.ResultValue) *result.Result {
+	request := res.(DownloadAttachmentRequest)
+
+	response := vault.GetItem(container.VaultClient(), request.Scope.Partition(), request.ItemId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch vault item",
+			struct {
+				Email  string
+				ItemId string
+				Error  types.PasswordCaddyError
+			}{
+				Email:  request.UserId,
+				ItemId: request.ItemId,
+				Error:  response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	item := response.Data.(types.VaultItem)
+
+	if !request.Scope.CanRead(item) {
+		return result.Failure(404, "Vault item not found")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Get the metadata of the attachment
+func GetAttachment(res result.ResultValue) *result.Result {
+	request := res.(DownloadAttachmentRequest)
+
+	response := vault.GetAttachment(container.VaultClient(), request.Scope.Partition(), request.ItemId, request.AttachmentId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch attachment",
+			struct {
+				Email        string
+				AttachmentId string
+				Error        types.PasswordCaddyError
+			}{
+				Email:        request.UserId,
+				AttachmentId: request.AttachmentId,
+				Error:        response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Attachment = response.Data.(types.VaultAttachment)
+
+	if request.Attachment.AttachmentId.Value == "" {
+		return result.Failure(404, "Attachment not found")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Read the encrypted file from the blob store
+func GetData(res result.ResultValue) *result.Result {
+	request := res.(DownloadAttachmentRequest)
+
+	response := vault.GetAttachmentData(container.BlobStore(), request.Attachment)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to read attachment",
+			struct {
+				Email        string
+				AttachmentId string
+				Error        types.PasswordCaddyError
+			}{
+				Email:        request.UserId,
+				AttachmentId: request.AttachmentId,
+				Error:        response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	attachment := vault.ToAttachmentResponse(request.Attachment)
+	attachment.Data = base64.StdEncoding.EncodeToString(response.Data.([]byte))
+
+	return result.SuccessWithValue(200, attachment)
+}
+
+// Handle the download attachment request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(ResolveScope).
+		Then(CheckAccess).
+		Then(GetAttachment).
+		Then(GetData).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/access"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/export"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/policies"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/userevents"
+	"password-caddy/api/lib/util"
+	"password-caddy/api/lib/vault"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// With a passphrase the export is password protected and carries Key, the
+// symmetric key of the vault as encoded by the client, so it can be imported
+// into another account. The vault of an organization, selected with the orgId
+// query parameter, is exported with the items whose passwords the user can see
+type ExportVaultRequest struct {
+	UserId     string            `json:"-"`
+	SourceIp   string            `json:"-"`
+	UserAgent  string            `json:"-"`
+	OrgId      string            `json:"-"`
+	Passphrase string            `json:"passphrase"`
+	Key        string            `json:"key"`
+	Scope      access.Scope      `json:"-"`
+	Items      []types.VaultItem `json:"-"`
+	Export     export.Export     `json:"-"`
+}
+
+// Initialize the Export Vault Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	var request ExportVaultRequest
+
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	if event.Body != "" {
+		err := util.DeserializeJson(event.Body, &request)
+
+		if err != nil {
+			return result.Failure(400, err.Error())
+		}
+	}
+
+	request.UserId = userId
+	request.SourceIp = auth.SourceIp(event)
+	request.UserAgent = auth.UserAgent(event)
+	request.OrgId = event.QueryStringParameters["orgId"]
+
+	if request.Passphrase != "" && len(request.Passphrase) < export.MIN_PASSPHRASE_LENGTH {
+		return result.Failure(400, fmt.Sprintf("Passphrase must be at least %d characters", export.MIN_PASSPHRASE_LENGTH))
+	}
+
+	if request.Passphrase == "" && request.Key != "" {
+		return result.Failure(400, "The key can only be exported with a passphrase")
+	}
+
+	if request.Passphrase != "" {
+		if err := export.ValidateKey(request.Key); err != nil {
+			return result.Failure(400, err.Error())
+		}
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Resolve the vault to export, the user's own or an organization's
+func ResolveScope(res result.ResultValue) *result.Result {
+	request := res.(ExportVaultRequest)
+
+	response := access.Resolve(container.VaultClient(), request.UserId, request.OrgId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to resolve the vault of the request",
+			struct {
+				Email string
+				OrgId string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Scope = response.Data.(access.Scope)
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Check that no organization of the user restricts exports
+func CheckPolicies(res result.ResultValue) *result.Result {
+	request := res.(ExportVaultRequest)
+
+	response := policies.Resolve(container.VaultClient(), request.UserId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch organization policies",
+			struct {
+				Email string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	violation := response.Data.(policies.Effective).Check(policies.TYPE_RESTRICT_EXPORT)
+
+	if violation != nil {
+		logger.Security(
+			"Vault export blocked by organization policy",
+			struct {
+				Email  string
+				OrgId  string
+				Policy string
+			}{
+				Email:  request.UserId,
+				OrgId:  violation.OrgId,
+				Policy: violation.Policy,
+			},
+		)
+
+		return policies.ViolationResult(violation)
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Get the items of the vault the user can see the passwords of
+func GetItems(res result.ResultValue) *result.Result {
+	request := res.(ExportVaultRequest)
+
+	response := vault.ListItems(container.VaultClient(), request.Scope.Partition())
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch vault items",
+			struct {
+				Email string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Items = request.Scope.ItemsAllowing(response.Data.([]types.VaultItem), access.LEVEL_READ_ONLY)
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Get the folders of the vault and build the export
+func BuildExport(res result.ResultValue) *result.Result {
+	request := res.(ExportVaultRequest)
+
+	response := vault.ListFolders(container.VaultClient(), request.Scope.Partition())
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch vault folders",
+			struct {
+				Email string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Export = export.New(request.Scope.Partition(), request.Items, response.Data.([]types.VaultFolder), time.Now())
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Protect the export with the passphrase, if any. Every export is logged as a security event
+func ProtectExport(res result.ResultValue) *result.Result {
+	request := res.(ExportVaultRequest)
+
+	var body interface{} = request.Export
+
+	if request.Passphrase != "" {
+		request.Export.Key = request.Key
+
+		sealed, err := export.Seal(request.Export, request.Passphrase, export.KdfIterations())
+
+		if err != nil {
+			logger.Error(
+				"Failed to protect vault export",
+				struct {
+					Email string
+					Error string
+				}{
+					Email: request.UserId,
+					Error: err.Error(),
+				},
+			)
+
+			return result.Failure(500, "Failed to protect the export")
+		}
+
+		body = sealed
+	}
+
+	logger.Security(
+		"Exported vault",
+		struct {
+			Email     string
+			OrgId     string
+			SourceIp  string
+			Encrypted bool
+			Items     int
+			Folders   int
+		}{
+			Email:     request.UserId,
+			OrgId:     request.OrgId,
+			SourceIp:  request.SourceIp,
+			Encrypted: request.Passphrase != "",
+			Items:     len(request.Export.Items),
+			Folders:   len(request.Export.Folders),
+		},
+	)
+
+	detail := "unencrypted"
+
+	if request.Passphrase != "" {
+		detail = "encrypted"
+	}
+
+	if !request.Scope.IsPersonal() {
+		detail += " organization " + request.OrgId
+	}
+
+	userevents.RecordOrLog(container.VaultClient(), request.UserId, userevents.Event{
+		Type:      userevents.TYPE_VAULT_EXPORTED,
+		IpAddress: request.SourceIp,
+		UserAgent: request.UserAgent,
+		Detail:    detail,
+	})
+
+	return result.SuccessWithValue(200, body)
+}
+
+// Handle the export vault request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(ResolveScope).
+		Then(CheckPolicies).
+		Then(GetItems).
+		Then(BuildExport).
+		Then(ProtectExport).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/vault"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// Initialize the Get Domains Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	return result.SuccessWithValue(200, userId)
+}
+
+// Get the equivalent domains of the user
+func GetDomains(res result.ResultValue) *result.Result {
+	userId := res.(string)
+
+	response := vault.GetDomains(container.VaultClient(), userId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch equivalent domains",
+			struct {
+				Email string
+				Error types.PasswordCaddyError
+			}{
+				Email: userId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	return result.SuccessWithValue(200, vault.ToDomainsResponse(response.Data.(types.VaultDomains)))
+}
+
+// Handle the get domains request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(GetDomains).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
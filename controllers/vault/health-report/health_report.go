@@ -0,0 +1,140 @@
+package main
+
+import (
+	"time"
+
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/access"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/vault"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// The report of an organization, selected with the orgId query parameter, covers the items the user can read
+type HealthReportRequest struct {
+	UserId string
+	OrgId  string
+	Scope  access.Scope
+	Items  []types.VaultItem
+}
+
+// Initialize the Health Report Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	return result.SuccessWithValue(200, HealthReportRequest{
+		UserId: userId,
+		OrgId:  event.QueryStringParameters["orgId"],
+	})
+}
+
+// Resolve the vault of the report, the user's own or an organization's
+func ResolveScope(res result.ResultValue) *result.Result {
+	request := res.(HealthReportRequest)
+
+	response := access.Resolve(container.VaultClient(), request.UserId, request.OrgId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to resolve the vault of the request",
+			struct {
+				Email string
+				OrgId string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Scope = response.Data.(access.Scope)
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Get the items of the vault the user can read
+func GetItems(res result.ResultValue) *result.Result {
+	request := res.(HealthReportRequest)
+
+	response := vault.ListItems(container.VaultClient(), request.Scope.Partition())
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch vault items",
+			struct {
+				Email string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Items = request.Scope.ItemsAllowing(response.Data.([]types.VaultItem), access.LEVEL_HIDE_PASSWORDS)
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Aggregate the health of the items
+func BuildReport(res result.ResultValue) *result.Result {
+	request := res.(HealthReportRequest)
+
+	response := vault.ListHealth(container.VaultClient(), request.Scope.Partition())
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch vault item health",
+			struct {
+				Email string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	report := vault.BuildHealthReport(request.Items, response.Data.([]types.VaultItemHealth), time.Now().UTC())
+
+	return result.SuccessWithValue(200, report)
+}
+
+// Handle the vault health report request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(ResolveScope).
+		Then(GetItems).
+		Then(BuildReport).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
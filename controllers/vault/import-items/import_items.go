@@ -0,0 +1,374 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/access"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/export"
+	"password-caddy/api/lib/importers"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/policies"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/util"
+	"password-caddy/api/lib/vault"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// Format is the password manager the items were exported from. A dry run
+// only reports what would be imported. Instead of the items an export of
+// Password Caddy can be sent with the passphrase it is protected with. Its
+// items stay encrypted under the key of the exported vault, so it is only
+// imported into that vault. The client of another account re-encrypts them
+// with the key the export carries and sends them as items of the
+// passwordcaddy format. Items imported into an organization, selected with
+// the orgId query parameter, are put into CollectionIds instead of folders
+type ImportItemsRequest struct {
+	UserId        string                     `json:"-"`
+	OrgId         string                     `json:"-"`
+	Format        string                     `json:"format"`
+	DryRun        bool                       `json:"dryRun"`
+	Folders       []vault.ImportFolder       `json:"folders"`
+	Items         []vault.ImportItem         `json:"items"`
+	Export        json.RawMessage            `json:"export"`
+	Passphrase    string                     `json:"passphrase"`
+	Parsed        export.Export              `json:"-"`
+	CollectionIds []string                   `json:"collectionIds"`
+	Scope         access.Scope               `json:"-"`
+	Current       map[string]types.VaultItem `json:"-"`
+	Plan          vault.ImportPlan           `json:"-"`
+}
+
+// Initialize the Import Items Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	var request ImportItemsRequest
+
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	err := util.DeserializeJson(event.Body, &request)
+
+	if err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	request.UserId = userId
+	request.OrgId = event.QueryStringParameters["orgId"]
+	request.DryRun = request.DryRun || event.QueryStringParameters["dryRun"] == "true"
+
+	if len(request.Export) > 0 {
+		parsed, err := export.Parse(request.Export, request.Passphrase)
+
+		if err != nil {
+			return result.Failure(400, err.Error())
+		}
+
+		request.Parsed = parsed
+		request.Format = export.FORMAT
+		request.Folders = []vault.ImportFolder{}
+		request.Items = []vault.ImportItem{}
+
+		for _, folder := range parsed.Folders {
+			request.Folders = append(request.Folders, vault.ImportFolder{Id: folder.Id, Name: folder.Name})
+		}
+
+		for _, item := range parsed.Items {
+			request.Items = append(request.Items, vault.ImportItem{Id: item.Id, FolderId: item.FolderId, Data: item.Data})
+		}
+	}
+
+	if request.Format != export.FORMAT && !importers.IsValidFormat(request.Format) {
+		return result.Failure(400, "Format must be one of "+strings.Join(importers.Formats(), ", "))
+	}
+
+	if len(request.Items) == 0 {
+		return result.Failure(400, "Items are required")
+	}
+
+	if len(request.Items) > vault.ImportMaxItems() {
+		return result.Failure(400, fmt.Sprintf("An import can contain at most %d items", vault.ImportMaxItems()))
+	}
+
+	if request.OrgId != "" && len(request.Folders) > 0 {
+		return result.Failure(400, "Items of an organization can not be in folders")
+	}
+
+	ids := map[string]bool{}
+
+	for _, folder := range request.Folders {
+		if !vault.IsValidItemId(folder.Id) {
+			return result.Failure(400, "Folder id must be a UUID")
+		}
+
+		if folder.Name == "" {
+			return result.Failure(400, "Folder name is required")
+		}
+
+		if ids[folder.Id] {
+			return result.Failure(400, "Folders must be unique")
+		}
+
+		ids[folder.Id] = true
+	}
+
+	for _, item := range request.Items {
+		if !vault.IsValidItemId(item.Id) {
+			return result.Failure(400, "Item id must be a UUID")
+		}
+
+		if item.FolderId != "" && !vault.IsValidItemId(item.FolderId) {
+			return result.Failure(400, "Folder id must be a UUID")
+		}
+
+		if item.Data == "" {
+			return result.Failure(400, "Item data is required")
+		}
+
+		if ids[item.Id] {
+			return result.Failure(400, "Items must be unique")
+		}
+
+		ids[item.Id] = true
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+/*
+Resolve the vault the items are imported into, the user's own or an
+organization's, and check that the user can add items to the collections
+*/
+func ResolveScope(res result.ResultValue) *result.Result {
+	request := res.(ImportItemsRequest)
+
+	response := access.Resolve(container.VaultClient(), request.UserId, request.OrgId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to resolve the vault of the request",
+			struct {
+				Email string
+				OrgId string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Scope = response.Data.(access.Scope)
+
+	if err := request.Parsed.CheckImportInto(request.Scope.Partition()); err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	if err := request.Scope.CanChangeCollections(nil, request.CollectionIds); err != nil {
+		return result.Failure(403, err.Error())
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Items imported into the personal vault are refused when an organization of the user disabled it
+func CheckPolicies(res result.ResultValue) *result.Result {
+	request := res.(ImportItemsRequest)
+
+	if !request.Scope.IsPersonal() {
+		return result.SuccessWithValue(200, request)
+	}
+
+	response := policies.Resolve(container.VaultClient(), request.UserId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch organization policies",
+			struct {
+				Email string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	violation := response.Data.(policies.Effective).Check(policies.TYPE_DISABLE_PERSONAL_VAULT)
+
+	if violation != nil {
+		logger.Security(
+			"Import blocked by organization policy",
+			struct {
+				Email  string
+				OrgId  string
+				Policy string
+			}{
+				Email:  request.UserId,
+				OrgId:  violation.OrgId,
+				Policy: violation.Policy,
+			},
+		)
+
+		return policies.ViolationResult(violation)
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Get the current items of the vault
+func GetCurrentItems(res result.ResultValue) *result.Result {
+	request := res.(ImportItemsRequest)
+
+	response := vault.ListItems(container.VaultClient(), request.Scope.Partition())
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch vault items",
+			struct {
+				Email string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Current = map[string]types.VaultItem{}
+
+	for _, item := range response.Data.([]types.VaultItem) {
+		request.Current[item.ItemId.Value] = item
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Get the current folders of the vault and plan the import against them
+func PlanImport(res result.ResultValue) *result.Result {
+	request := res.(ImportItemsRequest)
+
+	response := vault.ListFolders(container.VaultClient(), request.Scope.Partition())
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch vault folders",
+			struct {
+				Email string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	currentFolders := map[string]types.VaultFolder{}
+
+	for _, folder := range response.Data.([]types.VaultFolder) {
+		currentFolders[folder.FolderId.Value] = folder
+	}
+
+	request.Plan = vault.PlanImport(request.Scope.Partition(), request.Folders, request.Items, request.Current, currentFolders, time.Now())
+	request.Plan.InCollections(access.EncodeCollectionIds(request.CollectionIds))
+	request.Plan.Report.DryRun = request.DryRun
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Write the folders and items of the import. A dry run returns the report as is
+func ImportItems(res result.ResultValue) *result.Result {
+	request := res.(ImportItemsRequest)
+
+	if request.DryRun {
+		return result.SuccessWithValue(200, request.Plan.Report)
+	}
+
+	report, response := vault.ApplyImport(container.VaultClient(), request.Scope.Partition(), request.Plan)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to import vault items",
+			struct {
+				Email  string
+				Format string
+				Error  types.PasswordCaddyError
+			}{
+				Email:  request.UserId,
+				Format: request.Format,
+				Error:  response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	logger.Info(
+		"Imported vault items",
+		struct {
+			Email   string
+			OrgId   string
+			Format  string
+			Items   int
+			Folders int
+			Skipped int
+		}{
+			Email:   request.UserId,
+			OrgId:   request.OrgId,
+			Format:  request.Format,
+			Items:   report.Items,
+			Folders: report.Folders,
+			Skipped: len(report.Skipped),
+		},
+	)
+
+	return result.SuccessWithValue(200, report)
+}
+
+// Handle the import vault items request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(ResolveScope).
+		Then(CheckPolicies).
+		Then(GetCurrentItems).
+		Then(PlanImport).
+		Then(ImportItems).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/access"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/dynamoclient"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/vault"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type ItemHistoryRequest struct {
+	UserId string
+	OrgId  string
+	ItemId string
+	Scope  access.Scope
+}
+
+type ItemHistoryResponse struct {
+	Id        string                    `json:"id"`
+	Revisions []vault.VaultItemResponse `json:"revisions"`
+}
+
+// Initialize the Item History Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	request := ItemHistoryRequest{
+		UserId: userId,
+		OrgId:  event.QueryStringParameters["orgId"],
+		ItemId: event.PathParameters["id"],
+	}
+
+	if !vault.IsValidItemId(request.ItemId) {
+		return result.Failure(400, "Item id must be a UUID")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Resolve the vault of the item, the user's own or an organization's
+func ResolveScope(res result.ResultValue) *result.Result {
+	request := res.(ItemHistoryRequest)
+
+	response := access.Resolve(container.VaultClient(), request.UserId, request.OrgId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to resolve the vault of the request",
+			struct {
+				Email string
+				OrgId string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Scope = response.Data.(access.Scope)
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Previous revisions show the passwords, users who can not see them do not get the history
+func CheckAccess(res result.ResultValue) *result.Result {
+	request := res.(ItemHistoryRequest)
+
+	response := vault.GetItem(container.VaultClient(), request.Scope.Partition(), request.ItemId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch vault item",
+			struct {
+				Email  string
+				ItemId string
+				Error  types.PasswordCaddyError
+			}{
+				Email:  request.UserId,
+				ItemId: request.ItemId,
+				Error:  response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	item := response.Data.(types.VaultItem)
+
+	if !request.Scope.CanRead(item) {
+		return result.Failure(404, "Vault item not found")
+	}
+
+	if !request.Scope.Can(item, access.LEVEL_READ_ONLY) {
+		return result.Failure(403, "Not allowed to see the history of this item")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Get the previous revisions of the item, newest first
+func GetHistory(res result.ResultValue) *result.Result {
+	request := res.(ItemHistoryRequest)
+
+	dynamoRequest := dynamoclient.DynamoQueryRequest{
+		Key:           request.Scope.Partition(),
+		SortKeyPrefix: vault.HistoryPrefix(request.ItemId),
+		Descending:    true,
+	}
+
+	response := container.VaultClient().
+		Query(dynamoRequest).
+		AsVaultItemHistories()
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch vault item history",
+			struct {
+				Email  string
+				ItemId string
+				Error  types.PasswordCaddyError
+			}{
+				Email:  request.UserId,
+				ItemId: request.ItemId,
+				Error:  response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	histories := response.Data.([]types.VaultItemHistory)
+	revisions := make([]vault.VaultItemResponse, 0, len(histories))
+
+	for _, history := range histories {
+		revisions = append(revisions, vault.ToHistoryResponse(history))
+	}
+
+	return result.SuccessWithValue(
+		200,
+		ItemHistoryResponse{
+			Id:        request.ItemId,
+			Revisions: revisions,
+		},
+	)
+}
+
+// Handle the vault item history request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(ResolveScope).
+		Then(CheckAccess).
+		Then(GetHistory).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/access"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/vault"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type ListAttachmentsRequest struct {
+	UserId string
+	OrgId  string
+	ItemId string
+	Scope  access.Scope
+}
+
+// Initialize the List Attachments Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	request := ListAttachmentsRequest{
+		UserId: userId,
+		OrgId:  event.QueryStringParameters["orgId"],
+		ItemId: event.PathParameters["id"],
+	}
+
+	if !vault.IsValidItemId(request.ItemId) {
+		return result.Failure(400, "Item id must be a UUID")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Resolve the vault of the item, the user's own or an organization's
+func ResolveScope(res result.ResultValue) *result.Result {
+	request := res.(ListAttachmentsRequest)
+
+	response := access.Resolve(container.VaultClient(), request.UserId, request.OrgId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to resolve the vault of the request",
+			struct {
+				Email string
+				OrgId string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Scope = response.Data.(access.Scope)
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Check that the user can see the item
+func CheckAccess(res result.ResultValue) *result.Result {
+	request := res.(ListAttachmentsRequest)
+
+	response := vault.GetItem(container.VaultClient(), request.Scope.Partition(), request.ItemId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch vault item",
+			struct {
+				Email  string
+				ItemId string
+				Error  types.PasswordCaddyError
+			}{
+				Email:  request.UserId,
+				ItemId: request.ItemId,
+				Error:  response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	item := response.Data.(types.VaultItem)
+
+	if !request.Scope.CanRead(item) {
+		return result.Failure(404, "Vault item not found")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Get the metadata of the attachments of the item
+func GetAttachments(res result.ResultValue) *result.Result {
+	request := res.(ListAttachmentsRequest)
+
+	response := vault.ListAttachments(container.VaultClient(), request.Scope.Partition(), request.ItemId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch attachments",
+			struct {
+				Email  string
+				ItemId string
+				Error  types.PasswordCaddyError
+			}{
+				Email:  request.UserId,
+				ItemId: request.ItemId,
+				Error:  response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	attachments := []vault.VaultAttachmentResponse{}
+
+	for _, attachment := range response.Data.([]types.VaultAttachment) {
+		attachments = append(attachments, vault.ToAttachmentResponse(attachment))
+	}
+
+	return result.SuccessWithValue(200, attachments)
+}
+
+// Handle the list attachments request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(ResolveScope).
+		Then(CheckAccess).
+		Then(GetAttachments).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
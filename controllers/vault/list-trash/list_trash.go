@@ -0,0 +1,118 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/access"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/vault"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type ListTrashRequest struct {
+	UserId string
+	OrgId  string
+	Scope  access.Scope
+}
+
+type ListTrashResponse struct {
+	Items []vault.VaultItemResponse `json:"items"`
+}
+
+// Initialize the List Trash Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	request := ListTrashRequest{
+		UserId: userId,
+		OrgId:  event.QueryStringParameters["orgId"],
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Resolve the vault of the trash, the user's own or an organization's
+func ResolveScope(res result.ResultValue) *result.Result {
+	request := res.(ListTrashRequest)
+
+	response := access.Resolve(container.VaultClient(), request.UserId, request.OrgId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to resolve the vault of the request",
+			struct {
+				Email string
+				OrgId string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Scope = response.Data.(access.Scope)
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Get the items of the vault that are in the trash and the user can see
+func GetTrashedItems(res result.ResultValue) *result.Result {
+	request := res.(ListTrashRequest)
+
+	response := vault.ListItems(container.VaultClient(), request.Scope.Partition())
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch vault items",
+			struct {
+				Email string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	items := []vault.VaultItemResponse{}
+
+	for _, item := range response.Data.([]types.VaultItem) {
+		if item.DeletedAt.Value != "" && request.Scope.CanRead(item) {
+			items = append(items, request.Scope.ToItemResponse(item))
+		}
+	}
+
+	return result.SuccessWithValue(200, ListTrashResponse{Items: items})
+}
+
+// Handle the list trash request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(ResolveScope).
+		Then(GetTrashedItems).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/access"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/orgevents"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/shares"
+	"password-caddy/api/lib/vault"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type PurgeItemRequest struct {
+	UserId   string
+	SourceIp string
+	OrgId    string
+	ItemId   string
+	Item     types.VaultItem
+	Scope    access.Scope
+}
+
+// Initialize the Purge Item Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	request := PurgeItemRequest{
+		UserId:   userId,
+		SourceIp: auth.SourceIp(event),
+		OrgId:    event.QueryStringParameters["orgId"],
+		ItemId:   event.PathParameters["id"],
+	}
+
+	if !vault.IsValidItemId(request.ItemId) {
+		return result.Failure(400, "Item id must be a UUID")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Resolve the vault of the item, the user's own or an organization's
+func ResolveScope(res result.ResultValue) *result.Result {
+	request := res.(PurgeItemRequest)
+
+	response := access.Resolve(container.VaultClient(), request.UserId, request.OrgId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to resolve the vault of the request",
+			struct {
+				Email string
+				OrgId string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Scope = response.Data.(access.Scope)
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Only items in the trash can be permanently deleted
+func CheckItemIsTrashed(res result.ResultValue) *result.Result {
+	request := res.(PurgeItemRequest)
+
+	response := vault.GetItem(container.VaultClient(), request.Scope.Partition(), request.ItemId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch vault item",
+			struct {
+				Email  string
+				ItemId string
+				Error  types.PasswordCaddyError
+			}{
+				Email:  request.UserId,
+				ItemId: request.ItemId,
+				Error:  response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	item := response.Data.(types.VaultItem)
+
+	if item.ItemId.Value == "" || item.DeletedAt.Value == "" || !request.Scope.CanRead(item) {
+		return result.Failure(404, "Vault item not found in the trash")
+	}
+
+	if !request.Scope.Can(item, access.LEVEL_MANAGE) {
+		return result.Failure(403, "Not allowed to permanently delete this item")
+	}
+
+	request.Item = item
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Remove the shares of the item so no recipient keeps a copy of a deleted item
+func RemoveShares(res result.ResultValue) *result.Result {
+	request := res.(PurgeItemRequest)
+
+	response := shares.RemoveForItem(container.VaultClient(), request.Scope.Partition(), request.ItemId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to remove shares of vault item",
+			struct {
+				Email  string
+				ItemId string
+				Error  types.PasswordCaddyError
+			}{
+				Email:  request.UserId,
+				ItemId: request.ItemId,
+				Error:  response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Permanently delete the item, its history and its attachments
+func PurgeItem(res result.ResultValue) *result.Result {
+	request := res.(PurgeItemRequest)
+
+	response := vault.PurgeItem(container.VaultClient(), container.BlobStore(), request.Item)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to permanently delete vault item",
+			struct {
+				Email  string
+				ItemId string
+				Error  types.PasswordCaddyError
+			}{
+				Email:  request.UserId,
+				ItemId: request.ItemId,
+				Error:  response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	logger.Info(
+		"Permanently deleted vault item",
+		struct {
+			Email  string
+			ItemId string
+		}{
+			Email:  request.UserId,
+			ItemId: request.ItemId,
+		},
+	)
+
+	if !request.Scope.IsPersonal() {
+		recorded := orgevents.Record(container.VaultClient(), request.OrgId, orgevents.Event{
+			Type:      orgevents.TYPE_ITEM_DELETED,
+			ActorId:   request.UserId,
+			ItemId:    request.ItemId,
+			IpAddress: request.SourceIp,
+		})
+
+		if !recorded.IsSuccess {
+			logger.Error(
+				"Failed to record organization event",
+				struct {
+					Email string
+					OrgId string
+					Type  string
+					Error types.PasswordCaddyError
+				}{
+					Email: request.UserId,
+					OrgId: request.OrgId,
+					Type:  orgevents.TYPE_ITEM_DELETED,
+					Error: recorded.Error,
+				},
+			)
+		}
+	}
+
+	return result.Success(204)
+}
+
+// Handle the permanently delete vault item request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(ResolveScope).
+		Then(CheckItemIsTrashed).
+		Then(RemoveShares).
+		Then(PurgeItem).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
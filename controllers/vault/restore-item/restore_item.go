@@ -0,0 +1,282 @@
+package main
+
+import (
+	"password-caddy/api/core/config"
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/access"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/dynamoclient"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/vault"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// ExpectedRevision comes from the required If-Match header
+type RestoreItemRequest struct {
+	UserId           string
+	OrgId            string
+	ItemId           string
+	Revision         int
+	ExpectedRevision int
+	Current          types.VaultItem
+	Restore          types.VaultItemHistory
+	Scope            access.Scope
+}
+
+// Initialize the Restore Item Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	request := RestoreItemRequest{
+		UserId:   userId,
+		OrgId:    event.QueryStringParameters["orgId"],
+		ItemId:   event.PathParameters["id"],
+		Revision: int(config.ParseInt(event.PathParameters["revision"])),
+	}
+
+	if !vault.IsValidItemId(request.ItemId) {
+		return result.Failure(400, "Item id must be a UUID")
+	}
+
+	if request.Revision <= 0 {
+		return result.Failure(400, "Revision must be a positive number")
+	}
+
+	expectedRevision, err := vault.RequiredRevision(event.Headers)
+
+	if err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	request.ExpectedRevision = expectedRevision
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Resolve the vault of the item, the user's own or an organization's
+func ResolveScope(res result.ResultValue) *result.Result {
+	request := res.(RestoreItemRequest)
+
+	response := access.Resolve(container.VaultClient(), request.UserId, request.OrgId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to resolve the vault of the request",
+			struct {
+				Email string
+				OrgId string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Scope = response.Data.(access.Scope)
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Get the current version of the item
+func GetCurrentItem(res result.ResultValue) *result.Result {
+	request := res.(RestoreItemRequest)
+
+	response := vault.GetItem(container.VaultClient(), request.Scope.Partition(), request.ItemId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch vault item",
+			struct {
+				Email  string
+				ItemId string
+				Error  types.PasswordCaddyError
+			}{
+				Email:  request.UserId,
+				ItemId: request.ItemId,
+				Error:  response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Current = response.Data.(types.VaultItem)
+
+	if request.Current.ItemId.Value == "" || !request.Scope.CanRead(request.Current) {
+		return result.Failure(404, "Vault item not found")
+	}
+
+	if !request.Scope.Can(request.Current, access.LEVEL_EDIT) {
+		return result.Failure(403, "Not allowed to edit this item")
+	}
+
+	if request.Current.DeletedAt.Value != "" {
+		return result.Failure(409, "Vault item is in the trash")
+	}
+
+	if !vault.MatchesRevision(request.Current, request.ExpectedRevision) {
+		return vault.ConflictResult(request.Current)
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Get the revision to restore from the item history
+func GetRevision(res result.ResultValue) *result.Result {
+	request := res.(RestoreItemRequest)
+
+	dynamoRequest := dynamoclient.DynamoGetRequest{
+		Key:     request.Scope.Partition(),
+		SortKey: vault.HistoryKey(request.ItemId, request.Revision),
+	}
+
+	response := container.VaultClient().
+		Get(dynamoRequest).
+		AsVaultItemHistory()
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch vault item revision",
+			struct {
+				Email    string
+				ItemId   string
+				Revision int
+				Error    types.PasswordCaddyError
+			}{
+				Email:    request.UserId,
+				ItemId:   request.ItemId,
+				Revision: request.Revision,
+				Error:    response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Restore = response.Data.(types.VaultItemHistory)
+
+	if request.Restore.ItemId.Value == "" {
+		return result.Failure(404, "Revision not found")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Keep the current version of the item so the restore can be undone
+func RetainCurrentRevision(res result.ResultValue) *result.Result {
+	request := res.(RestoreItemRequest)
+
+	response := vault.RetainRevision(container.VaultClient(), request.Current)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to retain current revision of vault item",
+			struct {
+				Email    string
+				ItemId   string
+				Revision int
+				Error    types.PasswordCaddyError
+			}{
+				Email:    request.UserId,
+				ItemId:   request.ItemId,
+				Revision: request.Current.Revision.Value,
+				Error:    response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Save the restored data as a new revision of the item, in the vault the item is in
+func SaveRestoredItem(res result.ResultValue) *result.Result {
+	request := res.(RestoreItemRequest)
+
+	item, err := vault.RestoreRevision(request.Current, request.Restore)
+
+	if err != nil {
+		return result.Failure(404, err.Error())
+	}
+
+	response := vault.PutItem(container.VaultClient(), item, request.Current.Revision.Value)
+
+	if !response.IsSuccess && response.Error.StatusCode == 409 {
+		return vault.RevisionConflict(container.VaultClient(), request.Scope.Partition(), request.ItemId)
+	}
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to save restored vault item",
+			struct {
+				Email  string
+				ItemId string
+				Error  types.PasswordCaddyError
+			}{
+				Email:  request.UserId,
+				ItemId: request.ItemId,
+				Error:  response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	logger.Info(
+		"Restored vault item revision",
+		struct {
+			Email    string
+			ItemId   string
+			Revision int
+		}{
+			Email:    request.UserId,
+			ItemId:   request.ItemId,
+			Revision: request.Revision,
+		},
+	)
+
+	return result.SuccessWithValue(200, request.Scope.ToItemResponse(response.Data.(types.VaultItem)))
+}
+
+// Handle the restore vault item revision request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(ResolveScope).
+		Then(GetCurrentItem).
+		Then(GetRevision).
+		Then(RetainCurrentRevision).
+		Then(SaveRestoredItem).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
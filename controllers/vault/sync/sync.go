@@ -0,0 +1,213 @@
+package main
+
+import (
+	"time"
+
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/access"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/vault"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type SyncRequest struct {
+	UserId     string
+	OrgId      string
+	Since      int
+	IssuedAt   time.Time
+	FullResync bool
+	Scope      access.Scope
+	Changes    vault.VaultChanges
+}
+
+// Initialize the Sync Request. Without a cursor, or with one that is too old,
+// the client gets the whole vault and has to replace its local copy. The
+// orgId query parameter syncs an organization's vault instead of the user's
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	request := SyncRequest{
+		UserId:     userId,
+		OrgId:      event.QueryStringParameters["orgId"],
+		FullResync: true,
+	}
+
+	cursor := event.QueryStringParameters["since"]
+
+	if cursor == "" {
+		return result.SuccessWithValue(200, request)
+	}
+
+	since, issuedAt, err := vault.DecodeCursor(cursor)
+
+	if err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	if vault.IsCursorExpired(issuedAt, time.Now()) {
+		logger.Info(
+			"Sync cursor expired, requesting a full resync",
+			struct {
+				Email    string
+				IssuedAt time.Time
+			}{
+				Email:    userId,
+				IssuedAt: issuedAt,
+			},
+		)
+
+		return result.SuccessWithValue(200, request)
+	}
+
+	request.Since = since
+	request.IssuedAt = issuedAt
+	request.FullResync = false
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Resolve the vault to sync, the user's own or an organization's
+func ResolveScope(res result.ResultValue) *result.Result {
+	request := res.(SyncRequest)
+
+	response := access.Resolve(container.VaultClient(), request.UserId, request.OrgId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to resolve the vault of the request",
+			struct {
+				Email string
+				OrgId string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Scope = response.Data.(access.Scope)
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Get the next page of what changed in the vault since the cursor and the user can see.
+// When the user's access changed since the cursor the client has to start over
+func GetChanges(res result.ResultValue) *result.Result {
+	request := res.(SyncRequest)
+
+	response := vault.GetChanges(container.VaultClient(), request.Scope.Partition(), request.Since, vault.SyncPageSize())
+
+	if response.IsSuccess && !request.FullResync && request.Scope.ChangedSince(response.Data.(vault.VaultChanges), request.IssuedAt) {
+		logger.Info(
+			"Access to the organization changed, requesting a full resync",
+			struct {
+				Email string
+				OrgId string
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+			},
+		)
+
+		request.Since = 0
+		request.IssuedAt = time.Time{}
+		request.FullResync = true
+
+		response = vault.GetChanges(container.VaultClient(), request.Scope.Partition(), request.Since, vault.SyncPageSize())
+	}
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch vault changes",
+			struct {
+				Email string
+				Since int
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				Since: request.Since,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Changes = request.Scope.FilterChanges(response.Data.(vault.VaultChanges))
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Return the page of changes and the cursor to continue from
+func BuildPage(res result.ResultValue) *result.Result {
+	request := res.(SyncRequest)
+
+	page := vault.BuildSyncPage(
+		request.Changes,
+		request.FullResync,
+		request.IssuedAt,
+		time.Now(),
+	)
+
+	if !request.Scope.IsPersonal() {
+		request.Scope.FlagItems(page.Items, request.Changes.Items)
+		page.Collections = request.Scope.CollectionResponses()
+	}
+
+	logger.Info(
+		"Synced vault changes",
+		struct {
+			Email      string
+			OrgId      string
+			Since      int
+			Items      int
+			Folders    int
+			Tombstones int
+			HasMore    bool
+			FullResync bool
+		}{
+			Email:      request.UserId,
+			OrgId:      request.OrgId,
+			Since:      request.Since,
+			Items:      len(page.Items),
+			Folders:    len(page.Folders),
+			Tombstones: len(page.Tombstones),
+			HasMore:    page.HasMore,
+			FullResync: page.FullResync,
+		},
+	)
+
+	return result.SuccessWithValue(200, page)
+}
+
+// Handle the sync request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(ResolveScope).
+		Then(GetChanges).
+		Then(BuildPage).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
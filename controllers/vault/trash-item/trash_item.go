@@ -0,0 +1,217 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/access"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/orgevents"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/vault"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// ExpectedRevision comes from the required If-Match header
+type TrashItemRequest struct {
+	UserId           string
+	SourceIp         string
+	OrgId            string
+	ItemId           string
+	ExpectedRevision int
+	Current          types.VaultItem
+	Scope            access.Scope
+}
+
+// Initialize the Trash Item Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	request := TrashItemRequest{
+		UserId:   userId,
+		SourceIp: auth.SourceIp(event),
+		OrgId:    event.QueryStringParameters["orgId"],
+		ItemId:   event.PathParameters["id"],
+	}
+
+	if !vault.IsValidItemId(request.ItemId) {
+		return result.Failure(400, "Item id must be a UUID")
+	}
+
+	expectedRevision, err := vault.RequiredRevision(event.Headers)
+
+	if err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	request.ExpectedRevision = expectedRevision
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Resolve the vault of the item, the user's own or an organization's
+func ResolveScope(res result.ResultValue) *result.Result {
+	request := res.(TrashItemRequest)
+
+	response := access.Resolve(container.VaultClient(), request.UserId, request.OrgId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to resolve the vault of the request",
+			struct {
+				Email string
+				OrgId string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Scope = response.Data.(access.Scope)
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Get the item to move into the trash
+func GetCurrentItem(res result.ResultValue) *result.Result {
+	request := res.(TrashItemRequest)
+
+	response := vault.GetItem(container.VaultClient(), request.Scope.Partition(), request.ItemId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch vault item",
+			struct {
+				Email  string
+				ItemId string
+				Error  types.PasswordCaddyError
+			}{
+				Email:  request.UserId,
+				ItemId: request.ItemId,
+				Error:  response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Current = response.Data.(types.VaultItem)
+
+	if request.Current.ItemId.Value == "" || !request.Scope.CanRead(request.Current) {
+		return result.Failure(404, "Vault item not found")
+	}
+
+	if !request.Scope.Can(request.Current, access.LEVEL_EDIT) {
+		return result.Failure(403, "Not allowed to edit this item")
+	}
+
+	if !vault.MatchesRevision(request.Current, request.ExpectedRevision) {
+		return vault.ConflictResult(request.Current)
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Mark the item as deleted. Items already in the trash are returned as is
+func MoveToTrash(res result.ResultValue) *result.Result {
+	request := res.(TrashItemRequest)
+
+	if request.Current.DeletedAt.Value != "" {
+		return result.SuccessWithValue(200, request.Scope.ToItemResponse(request.Current))
+	}
+
+	response := vault.TrashItem(container.VaultClient(), request.Current)
+
+	if !response.IsSuccess && response.Error.StatusCode == 409 {
+		return vault.RevisionConflict(container.VaultClient(), request.Scope.Partition(), request.ItemId)
+	}
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to move vault item to the trash",
+			struct {
+				Email  string
+				ItemId string
+				Error  types.PasswordCaddyError
+			}{
+				Email:  request.UserId,
+				ItemId: request.ItemId,
+				Error:  response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	logger.Info(
+		"Moved vault item to the trash",
+		struct {
+			Email  string
+			ItemId string
+		}{
+			Email:  request.UserId,
+			ItemId: request.ItemId,
+		},
+	)
+
+	if !request.Scope.IsPersonal() {
+		recorded := orgevents.Record(container.VaultClient(), request.OrgId, orgevents.Event{
+			Type:      orgevents.TYPE_ITEM_TRASHED,
+			ActorId:   request.UserId,
+			ItemId:    request.ItemId,
+			IpAddress: request.SourceIp,
+		})
+
+		if !recorded.IsSuccess {
+			logger.Error(
+				"Failed to record organization event",
+				struct {
+					Email string
+					OrgId string
+					Type  string
+					Error types.PasswordCaddyError
+				}{
+					Email: request.UserId,
+					OrgId: request.OrgId,
+					Type:  orgevents.TYPE_ITEM_TRASHED,
+					Error: recorded.Error,
+				},
+			)
+		}
+	}
+
+	return result.SuccessWithValue(200, request.Scope.ToItemResponse(response.Data.(types.VaultItem)))
+}
+
+// Handle the delete vault item request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(ResolveScope).
+		Then(GetCurrentItem).
+		Then(MoveToTrash).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
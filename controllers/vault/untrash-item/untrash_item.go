@@ -0,0 +1,184 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/access"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/vault"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// ExpectedRevision comes from the required If-Match header
+type UntrashItemRequest struct {
+	UserId           string
+	OrgId            string
+	ItemId           string
+	ExpectedRevision int
+	Current          types.VaultItem
+	Scope            access.Scope
+}
+
+// Initialize the Untrash Item Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	request := UntrashItemRequest{
+		UserId: userId,
+		OrgId:  event.QueryStringParameters["orgId"],
+		ItemId: event.PathParameters["id"],
+	}
+
+	if !vault.IsValidItemId(request.ItemId) {
+		return result.Failure(400, "Item id must be a UUID")
+	}
+
+	expectedRevision, err := vault.RequiredRevision(event.Headers)
+
+	if err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	request.ExpectedRevision = expectedRevision
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Resolve the vault of the item, the user's own or an organization's
+func ResolveScope(res result.ResultValue) *result.Result {
+	request := res.(UntrashItemRequest)
+
+	response := access.Resolve(container.VaultClient(), request.UserId, request.OrgId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to resolve the vault of the request",
+			struct {
+				Email string
+				OrgId string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Scope = response.Data.(access.Scope)
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Get the trashed item
+func GetTrashedItem(res result.ResultValue) *result.Result {
+	request := res.(UntrashItemRequest)
+
+	response := vault.GetItem(container.VaultClient(), request.Scope.Partition(), request.ItemId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch vault item",
+			struct {
+				Email  string
+				ItemId string
+				Error  types.PasswordCaddyError
+			}{
+				Email:  request.UserId,
+				ItemId: request.ItemId,
+				Error:  response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Current = response.Data.(types.VaultItem)
+
+	if request.Current.ItemId.Value == "" || request.Current.DeletedAt.Value == "" || !request.Scope.CanRead(request.Current) {
+		return result.Failure(404, "Vault item not found in the trash")
+	}
+
+	if !request.Scope.Can(request.Current, access.LEVEL_EDIT) {
+		return result.Failure(403, "Not allowed to edit this item")
+	}
+
+	if !vault.MatchesRevision(request.Current, request.ExpectedRevision) {
+		return vault.ConflictResult(request.Current)
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Take the item out of the trash
+func RestoreFromTrash(res result.ResultValue) *result.Result {
+	request := res.(UntrashItemRequest)
+
+	response := vault.UntrashItem(container.VaultClient(), request.Current)
+
+	if !response.IsSuccess && response.Error.StatusCode == 409 {
+		return vault.RevisionConflict(container.VaultClient(), request.Scope.Partition(), request.ItemId)
+	}
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to restore vault item from the trash",
+			struct {
+				Email  string
+				ItemId string
+				Error  types.PasswordCaddyError
+			}{
+				Email:  request.UserId,
+				ItemId: request.ItemId,
+				Error:  response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	logger.Info(
+		"Restored vault item from the trash",
+		struct {
+			Email  string
+			ItemId string
+		}{
+			Email:  request.UserId,
+			ItemId: request.ItemId,
+		},
+	)
+
+	return result.SuccessWithValue(200, request.Scope.ToItemResponse(response.Data.(types.VaultItem)))
+}
+
+// Handle the restore from trash request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(ResolveScope).
+		Then(GetTrashedItem).
+		Then(RestoreFromTrash).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/urimatch"
+	"password-caddy/api/lib/util"
+	"password-caddy/api/lib/vault"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// Groups replace all equivalent domains of the user. Revision is the revision
+// the client last saw (0 when there are none yet). It can also be sent with the If-Match header
+type UpdateDomainsRequest struct {
+	UserId   string     `json:"-"`
+	Groups   [][]string `json:"groups"`
+	Revision int        `json:"revision"`
+}
+
+// Initialize the Update Domains Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	var request UpdateDomainsRequest
+
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	err := util.DeserializeJson(event.Body, &request)
+
+	if err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	request.UserId = userId
+	request.Revision, err = vault.ExpectedRevision(event.Headers, request.Revision)
+
+	if err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	request.Groups, err = urimatch.NormalizeDomainGroups(request.Groups)
+
+	if err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Check that the client saw the current equivalent domains
+func CheckRevision(res result.ResultValue) *result.Result {
+	request := res.(UpdateDomainsRequest)
+
+	response := vault.GetDomains(container.VaultClient(), request.UserId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch equivalent domains",
+			struct {
+				Email string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	current := response.Data.(types.VaultDomains)
+
+	if current.Revision.Value != request.Revision {
+		return vault.DomainsConflictResult(current)
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Save the new equivalent domains
+func SaveDomains(res result.ResultValue) *result.Result {
+	request := res.(UpdateDomainsRequest)
+
+	response := vault.PutDomains(container.VaultClient(), request.UserId, request.Groups, request.Revision)
+
+	if !response.IsSuccess && response.Error.StatusCode == 409 {
+		current := vault.GetDomains(container.VaultClient(), request.UserId)
+
+		if current.IsSuccess {
+			return vault.DomainsConflictResult(current.Data.(types.VaultDomains))
+		}
+	}
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to save equivalent domains",
+			struct {
+				Email string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	logger.Info(
+		"Saved equivalent domains",
+		struct {
+			Email  string
+			Groups int
+		}{
+			Email:  request.UserId,
+			Groups: len(request.Groups),
+		},
+	)
+
+	return result.SuccessWithValue(200, vault.ToDomainsResponse(response.Data.(types.VaultDomains)))
+}
+
+// Handle the update domains request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(CheckRevision).
+		Then(SaveDomains).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
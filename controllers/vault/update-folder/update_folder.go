@@ -0,0 +1,160 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/util"
+	"password-caddy/api/lib/vault"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// Revision is the revision of the folder the client last saw (0 for a new folder).
+// It can also be sent with the If-Match header
+type UpdateFolderRequest struct {
+	UserId   string            `json:"-"`
+	FolderId string            `json:"-"`
+	Name     string            `json:"name"`
+	Revision int               `json:"revision"`
+	Current  types.VaultFolder `json:"-"`
+}
+
+// Initialize the Update Folder Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	var request UpdateFolderRequest
+
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	err := util.DeserializeJson(event.Body, &request)
+
+	if err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	request.UserId = userId
+	request.FolderId = event.PathParameters["id"]
+	request.Revision, err = vault.ExpectedRevision(event.Headers, request.Revision)
+
+	if err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	if !vault.IsValidItemId(request.FolderId) {
+		return result.Failure(400, "Folder id must be a UUID")
+	}
+
+	if request.Name == "" {
+		return result.Failure(400, "Folder name is required")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Check that the client saw the current version of the folder
+func CheckRevision(res result.ResultValue) *result.Result {
+	request := res.(UpdateFolderRequest)
+
+	response := vault.GetFolder(container.VaultClient(), request.UserId, request.FolderId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch folder",
+			struct {
+				Email    string
+				FolderId string
+				Error    types.PasswordCaddyError
+			}{
+				Email:    request.UserId,
+				FolderId: request.FolderId,
+				Error:    response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Current = response.Data.(types.VaultFolder)
+
+	if request.Current.Revision.Value != request.Revision {
+		return vault.FolderConflictResult(request.Current)
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Save the new version of the folder
+func SaveFolder(res result.ResultValue) *result.Result {
+	request := res.(UpdateFolderRequest)
+
+	folder := types.VaultFolder{
+		UserId:   types.StringValue{Value: request.UserId},
+		FolderId: types.StringValue{Value: request.FolderId},
+		Name:     types.StringValue{Value: request.Name},
+	}
+
+	response := vault.PutFolder(container.VaultClient(), folder, request.Revision)
+
+	if !response.IsSuccess && response.Error.StatusCode == 409 {
+		current := vault.GetFolder(container.VaultClient(), request.UserId, request.FolderId)
+
+		if current.IsSuccess {
+			return vault.FolderConflictResult(current.Data.(types.VaultFolder))
+		}
+	}
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to save folder",
+			struct {
+				Email    string
+				FolderId string
+				Error    types.PasswordCaddyError
+			}{
+				Email:    request.UserId,
+				FolderId: request.FolderId,
+				Error:    response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	logger.Info(
+		"Saved folder",
+		struct {
+			Email    string
+			FolderId string
+		}{
+			Email:    request.UserId,
+			FolderId: request.FolderId,
+		},
+	)
+
+	return result.SuccessWithValue(200, vault.ToFolderResponse(response.Data.(types.VaultFolder)))
+}
+
+// Handle the update folder request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(CheckRevision).
+		Then(SaveFolder).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/access"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/util"
+	"password-caddy/api/lib/vault"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// Health is the health of the given revision of the item computed by the client.
+// Items of an organization are selected with the orgId query parameter
+type UpdateItemHealthRequest struct {
+	UserId  string
+	OrgId   string
+	ItemId  string
+	Health  vault.VaultItemHealthRequest
+	Scope   access.Scope
+	Current types.VaultItem
+}
+
+// Initialize the Update Item Health Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	var request UpdateItemHealthRequest
+
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	err := util.DeserializeJson(event.Body, &request.Health)
+
+	if err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	request.UserId = userId
+	request.OrgId = event.QueryStringParameters["orgId"]
+	request.ItemId = event.PathParameters["id"]
+
+	if !vault.IsValidItemId(request.ItemId) {
+		return result.Failure(400, "Item id must be a UUID")
+	}
+
+	if err := request.Health.Validate(); err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Resolve the vault of the item, the user's own or an organization's
+func ResolveScope(res result.ResultValue) *result.Result {
+	request := res.(UpdateItemHealthRequest)
+
+	response := access.Resolve(container.VaultClient(), request.UserId, request.OrgId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to resolve the vault of the request",
+			struct {
+				Email string
+				OrgId string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Scope = response.Data.(access.Scope)
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Check that the health is of the current revision of an item the user can edit
+func GetCurrentItem(res result.ResultValue) *result.Result {
+	request := res.(UpdateItemHealthRequest)
+
+	response := vault.GetItem(container.VaultClient(), request.Scope.Partition(), request.ItemId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch vault item",
+			struct {
+				Email  string
+				ItemId string
+				Error  types.PasswordCaddyError
+			}{
+				Email:  request.UserId,
+				ItemId: request.ItemId,
+				Error:  response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	current := response.Data.(types.VaultItem)
+
+	if current.ItemId.Value == "" || !request.Scope.CanRead(current) {
+		return result.Failure(404, "Vault item not found")
+	}
+
+	if !request.Scope.Can(current, access.LEVEL_EDIT) {
+		return result.Failure(403, "Not allowed to edit this item")
+	}
+
+	if current.Revision.Value != request.Health.Revision {
+		return result.FailureWithDetails(
+			409,
+			"Vault item was modified by another client",
+			request.Scope.ToItemResponse(current),
+		)
+	}
+
+	request.Current = current
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Save the health of the item. It is kept for the data of the revision, so
+// revisions that do not change the data keep it
+func SaveHealth(res result.ResultValue) *result.Result {
+	request := res.(UpdateItemHealthRequest)
+
+	health := types.VaultItemHealth{
+		UserId:            types.StringValue{Value: request.Scope.Partition()},
+		ItemId:            types.StringValue{Value: request.ItemId},
+		Revision:          types.NumberValue{Value: request.Health.Revision},
+		DataHash:          types.StringValue{Value: vault.DataHash(request.Current.Data.Value)},
+		Fingerprint:       types.StringValue{Value: request.Health.Fingerprint},
+		Score:             types.NumberValue{Value: request.Health.Score},
+		Breached:          types.BoolValue{Value: request.Health.Breached},
+		PasswordChangedAt: types.StringValue{Value: request.Health.PasswordChangedAt},
+	}
+
+	response := vault.PutHealth(container.VaultClient(), health)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to save vault item health",
+			struct {
+				Email  string
+				ItemId string
+				Error  types.PasswordCaddyError
+			}{
+				Email:  request.UserId,
+				ItemId: request.ItemId,
+				Error:  response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	return result.Success(204)
+}
+
+// Handle the update vault item health request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(ResolveScope).
+		Then(GetCurrentItem).
+		Then(SaveHealth).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
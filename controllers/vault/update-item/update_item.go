@@ -0,0 +1,376 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/access"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/orgevents"
+	"password-caddy/api/lib/policies"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/util"
+	"password-caddy/api/lib/vault"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// Revision is the revision of the item the client last saw (0 for a new item).
+// It can also be sent with the If-Match header. Items of an organization,
+// selected with the orgId query parameter, are in collections instead of folders
+type UpdateItemRequest struct {
+	UserId        string          `json:"-"`
+	SourceIp      string          `json:"-"`
+	OrgId         string          `json:"-"`
+	ItemId        string          `json:"-"`
+	FolderId      string          `json:"folderId"`
+	CollectionIds []string        `json:"collectionIds"`
+	Data          string          `json:"data"`
+	Revision      int             `json:"revision"`
+	Scope         access.Scope    `json:"-"`
+	Current       types.VaultItem `json:"-"`
+}
+
+// Initialize the Update Item Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	var request UpdateItemRequest
+
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	err := util.DeserializeJson(event.Body, &request)
+
+	if err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	request.UserId = userId
+	request.SourceIp = auth.SourceIp(event)
+	request.OrgId = event.QueryStringParameters["orgId"]
+	request.ItemId = event.PathParameters["id"]
+	request.Revision, err = vault.ExpectedRevision(event.Headers, request.Revision)
+
+	if err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	if !vault.IsValidItemId(request.ItemId) {
+		return result.Failure(400, "Item id must be a UUID")
+	}
+
+	if request.FolderId != "" && !vault.IsValidItemId(request.FolderId) {
+		return result.Failure(400, "Folder id must be a UUID")
+	}
+
+	if request.FolderId != "" && request.OrgId != "" {
+		return result.Failure(400, "Items of an organization can not be in folders")
+	}
+
+	if request.Data == "" {
+		return result.Failure(400, "Item data is required")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Resolve the vault of the item, the user's own or an organization's
+func ResolveScope(res result.ResultValue) *result.Result {
+	request := res.(UpdateItemRequest)
+
+	response := access.Resolve(container.VaultClient(), request.UserId, request.OrgId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to resolve the vault of the request",
+			struct {
+				Email string
+				OrgId string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Scope = response.Data.(access.Scope)
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Get the current version of the item, if any
+func GetCurrentItem(res result.ResultValue) *result.Result {
+	request := res.(UpdateItemRequest)
+
+	response := vault.GetItem(container.VaultClient(), request.Scope.Partition(), request.ItemId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch vault item",
+			struct {
+				Email  string
+				ItemId string
+				Error  types.PasswordCaddyError
+			}{
+				Email:  request.UserId,
+				ItemId: request.ItemId,
+				Error:  response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Current = response.Data.(types.VaultItem)
+
+	if request.Current.ItemId.Value != "" && !request.Scope.CanRead(request.Current) {
+		return result.Failure(404, "Vault item not found")
+	}
+
+	if request.Current.ItemId.Value != "" && !request.Scope.Can(request.Current, access.LEVEL_EDIT) {
+		logger.Security(
+			"Attempted to update a vault item without edit access",
+			struct {
+				Email  string
+				OrgId  string
+				ItemId string
+			}{
+				Email:  request.UserId,
+				OrgId:  request.OrgId,
+				ItemId: request.ItemId,
+			},
+		)
+
+		return result.Failure(403, "Not allowed to edit this item")
+	}
+
+	if err := request.Scope.CanChangeCollections(vault.CollectionIdsOf(request.Current), request.CollectionIds); err != nil {
+		return result.Failure(403, err.Error())
+	}
+
+	if request.Current.DeletedAt.Value != "" {
+		return result.Failure(409, "Vault item is in the trash")
+	}
+
+	if !vault.MatchesRevision(request.Current, request.Revision) {
+		logger.Warn(
+			"Attempted to update a vault item from an outdated revision",
+			struct {
+				Email           string
+				ItemId          string
+				Revision        int
+				CurrentRevision int
+			}{
+				Email:           request.UserId,
+				ItemId:          request.ItemId,
+				Revision:        request.Revision,
+				CurrentRevision: request.Current.Revision.Value,
+			},
+		)
+
+		return result.FailureWithDetails(
+			409,
+			"Vault item was modified by another client",
+			request.Scope.ToItemResponse(request.Current),
+		)
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// New items of the personal vault are refused when an organization of the user disabled it
+func CheckPolicies(res result.ResultValue) *result.Result {
+	request := res.(UpdateItemRequest)
+
+	if !request.Scope.IsPersonal() || request.Current.ItemId.Value != "" {
+		return result.SuccessWithValue(200, request)
+	}
+
+	response := policies.Resolve(container.VaultClient(), request.UserId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch organization policies",
+			struct {
+				Email string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	violation := response.Data.(policies.Effective).Check(policies.TYPE_DISABLE_PERSONAL_VAULT)
+
+	if violation != nil {
+		logger.Security(
+			"Personal item blocked by organization policy",
+			struct {
+				Email  string
+				OrgId  string
+				Policy string
+			}{
+				Email:  request.UserId,
+				OrgId:  violation.OrgId,
+				Policy: violation.Policy,
+			},
+		)
+
+		return policies.ViolationResult(violation)
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Keep the current version of the item in its history before overwriting it
+func RetainPreviousRevision(res result.ResultValue) *result.Result {
+	request := res.(UpdateItemRequest)
+
+	response := vault.RetainRevision(container.VaultClient(), request.Current)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to retain previous revision of vault item",
+			struct {
+				Email    string
+				ItemId   string
+				Revision int
+				Error    types.PasswordCaddyError
+			}{
+				Email:    request.UserId,
+				ItemId:   request.ItemId,
+				Revision: request.Current.Revision.Value,
+				Error:    response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Save the new version of the item, unless another client saved one in the meantime
+func SaveItem(res result.ResultValue) *result.Result {
+	request := res.(UpdateItemRequest)
+
+	item := types.VaultItem{
+		UserId:        types.StringValue{Value: request.Scope.Partition()},
+		ItemId:        types.StringValue{Value: request.ItemId},
+		FolderId:      types.StringValue{Value: request.FolderId},
+		Data:          types.StringValue{Value: request.Data},
+		CollectionIds: types.StringValue{Value: access.EncodeCollectionIds(request.CollectionIds)},
+	}
+
+	response := vault.PutItem(container.VaultClient(), item, request.Revision)
+
+	if !response.IsSuccess && response.Error.StatusCode == 409 {
+		return vault.RevisionConflict(container.VaultClient(), request.Scope.Partition(), request.ItemId)
+	}
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to save vault item",
+			struct {
+				Email  string
+				ItemId string
+				Error  types.PasswordCaddyError
+			}{
+				Email:  request.UserId,
+				ItemId: request.ItemId,
+				Error:  response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	logger.Info(
+		"Saved vault item",
+		struct {
+			Email    string
+			OrgId    string
+			ItemId   string
+			Revision int
+		}{
+			Email:    request.UserId,
+			OrgId:    request.OrgId,
+			ItemId:   request.ItemId,
+			Revision: request.Revision + 1,
+		},
+	)
+
+	if !request.Scope.IsPersonal() {
+		eventType := orgevents.TYPE_ITEM_UPDATED
+
+		if request.Revision == 0 {
+			eventType = orgevents.TYPE_ITEM_CREATED
+		}
+
+		recorded := orgevents.Record(container.VaultClient(), request.OrgId, orgevents.Event{
+			Type:      eventType,
+			ActorId:   request.UserId,
+			ItemId:    request.ItemId,
+			IpAddress: request.SourceIp,
+		})
+
+		if !recorded.IsSuccess {
+			logger.Error(
+				"Failed to record organization event",
+				struct {
+					Email string
+					OrgId string
+					Type  string
+					Error types.PasswordCaddyError
+				}{
+					Email: request.UserId,
+					OrgId: request.OrgId,
+					Type:  eventType,
+					Error: recorded.Error,
+				},
+			)
+		}
+	}
+
+	return result.SuccessWithValue(200, request.Scope.ToItemResponse(response.Data.(types.VaultItem)))
+}
+
+// Handle the update vault item request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(ResolveScope).
+		Then(GetCurrentItem).
+		Then(CheckPolicies).
+		Then(RetainPreviousRevision).
+		Then(SaveItem).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"password-caddy/api/core/container"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/access"
+	"password-caddy/api/lib/auth"
+	"password-caddy/api/lib/logger"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/util"
+	"password-caddy/api/lib/vault"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+type UploadAttachmentRequest struct {
+	UserId     string
+	OrgId      string
+	ItemId     string
+	Attachment vault.VaultAttachmentRequest
+	Data       []byte
+	Scope      access.Scope
+}
+
+// Initialize the Upload Attachment Request
+func Init(event events.APIGatewayProxyRequest) *result.Result {
+	userId, ok := auth.CallerId(event)
+
+	if !ok {
+		return result.Failure(401, "Unauthorized")
+	}
+
+	request := UploadAttachmentRequest{
+		UserId: userId,
+		OrgId:  event.QueryStringParameters["orgId"],
+		ItemId: event.PathParameters["id"],
+	}
+
+	if !vault.IsValidItemId(request.ItemId) {
+		return result.Failure(400, "Item id must be a UUID")
+	}
+
+	err := util.DeserializeJson(event.Body, &request.Attachment)
+
+	if err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	request.Data, err = request.Attachment.Decode()
+
+	if err != nil {
+		return result.Failure(400, err.Error())
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Resolve the vault of the item, the user's own or an organization's
+func ResolveScope(res result.ResultValue) *result.Result {
+	request := res.(UploadAttachmentRequest)
+
+	response := access.Resolve(container.VaultClient(), request.UserId, request.OrgId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to resolve the vault of the request",
+			struct {
+				Email string
+				OrgId string
+				Error types.PasswordCaddyError
+			}{
+				Email: request.UserId,
+				OrgId: request.OrgId,
+				Error: response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	request.Scope = response.Data.(access.Scope)
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Check that the item exists, is not in the trash and the user can edit it
+func CheckItem(res result.ResultValue) *result.Result {
+	request := res.(UploadAttachmentRequest)
+
+	response := vault.GetItem(container.VaultClient(), request.Scope.Partition(), request.ItemId)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to fetch vault item",
+			struct {
+				Email  string
+				ItemId string
+				Error  types.PasswordCaddyError
+			}{
+				Email:  request.UserId,
+				ItemId: request.ItemId,
+				Error:  response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	item := response.Data.(types.VaultItem)
+
+	if item.ItemId.Value == "" || item.DeletedAt.Value != "" || !request.Scope.CanRead(item) {
+		return result.Failure(404, "Vault item not found")
+	}
+
+	if !request.Scope.Can(item, access.LEVEL_EDIT) {
+		return result.Failure(403, "Not allowed to edit this item")
+	}
+
+	return result.SuccessWithValue(200, request)
+}
+
+// Store the encrypted file and its metadata
+func SaveAttachment(res result.ResultValue) *result.Result {
+	request := res.(UploadAttachmentRequest)
+
+	response := vault.AddAttachment(
+		container.VaultClient(),
+		container.BlobStore(),
+		request.Scope.Partition(),
+		request.ItemId,
+		request.Attachment,
+		request.Data,
+	)
+
+	if !response.IsSuccess && response.Error.StatusCode == 413 {
+		logger.Warn(
+			"Attachment quota exceeded",
+			struct {
+				Email  string
+				ItemId string
+				Size   int
+			}{
+				Email:  request.UserId,
+				ItemId: request.ItemId,
+				Size:   len(request.Data),
+			},
+		)
+
+		return result.Failure(413, response.Error.Message)
+	}
+
+	// The item was purged since it was checked
+	if !response.IsSuccess && response.Error.StatusCode == 409 {
+		return result.Failure(404, "Vault item not found")
+	}
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to save attachment",
+			struct {
+				Email  string
+				ItemId string
+				Error  types.PasswordCaddyError
+			}{
+				Email:  request.UserId,
+				ItemId: request.ItemId,
+				Error:  response.Error,
+			},
+		)
+
+		return result.Failure(
+			response.Error.StatusCode,
+			response.Error.Message,
+		)
+	}
+
+	attachment := response.Data.(types.VaultAttachment)
+
+	logger.Info(
+		"Saved attachment",
+		struct {
+			Email        string
+			ItemId       string
+			AttachmentId string
+			Size         int
+		}{
+			Email:        request.UserId,
+			ItemId:       request.ItemId,
+			AttachmentId: attachment.AttachmentId.Value,
+			Size:         attachment.Size.Value,
+		},
+	)
+
+	return result.SuccessWithValue(201, vault.ToAttachmentResponse(attachment))
+}
+
+// Handle the upload attachment request
+func Handler(event events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	return Init(event).
+		Then(ResolveScope).
+		Then(CheckItem).
+		Then(SaveAttachment).
+		ToAPIGatewayResponse()
+}
+
+func main() {
+	lambda.Start(Handler)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	appConfig "password-caddy/api/core/config"
+	"password-caddy/api/lib/blobstore"
 	"password-caddy/api/lib/dynamoclient"
 	"password-caddy/api/lib/sesclient"
 
@@ -43,3 +44,48 @@ func DynamoClient() *dynamoclient.DynamoClient {
 	return dynamoclient.Create(LoadAwsConfig()).
 		WithConfig(config)
 }
+
+/*
+DynamoDB client for the vault table. The vault table has a composite
+primary key (USER_ID, ITEM_KEY) so all of a user's vault data lives in one partition
+*/
+func VaultClient() *dynamoclient.DynamoClient {
+	var config dynamoclient.DynamoConfig
+
+	config = dynamoclient.DynamoConfig{
+		TableName: appConfig.Get("VAULT_TABLE", "password-caddy-vault-dev").ToString(),
+	}
+
+	return dynamoclient.Create(LoadAwsConfig()).
+		WithConfig(config)
+}
+
+/*
+DynamoDB client for the table of breached password hashes. The partition key
+holds the SHA-1 prefix and the sort key the number of the range
+*/
+func BreachClient() *dynamoclient.DynamoClient {
+	var config dynamoclient.DynamoConfig
+
+	config = dynamoclient.DynamoConfig{
+		TableName: appConfig.Get("BREACH_TABLE", "password-caddy-breach-dev").ToString(),
+	}
+
+	return dynamoclient.Create(LoadAwsConfig()).
+		WithConfig(config)
+}
+
+/*
+Blob store of the vault attachments. BLOB_STORE=local keeps the blobs on the
+file system below BLOB_STORE_PATH, i.e when running the API locally
+*/
+func BlobStore() blobstore.BlobStore {
+	if appConfig.Get("BLOB_STORE", "s3").ToString() == "local" {
+		return blobstore.NewLocalStore(appConfig.Get("BLOB_STORE_PATH", "/tmp/password-caddy-blobs").ToString())
+	}
+
+	return blobstore.NewS3Store(
+		LoadAwsConfig(),
+		appConfig.Get("ATTACHMENT_BUCKET", "password-caddy-attachments-dev").ToString(),
+	)
+}
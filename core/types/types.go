@@ -5,6 +5,7 @@ package types
 type PasswordCaddyError struct {
 	StatusCode int
 	Message    string
+	Details    interface{}
 }
 
 func (pcError PasswordCaddyError) Error() string {
@@ -17,8 +18,13 @@ type StringValue struct {
 	Value string `json:"Value"`
 }
 
+// DynamoDB numbers are transported as strings
 type NumberValue struct {
-	Value int `json:"Value"`
+	Value int `json:"Value,string"`
+}
+
+type BoolValue struct {
+	Value bool `json:"Value"`
 }
 
 /***** API Types *****/
@@ -27,6 +33,369 @@ type PasswordCaddyUser struct {
 	UserId           StringValue `json:"USER_ID"`
 	Status           StringValue `json:"STATUS"`
 	VerificationCode StringValue `json:"VERIFICATION_CODE"`
+
+	// Unix time the verification code expires at and the invalid codes tried against it
+	VerificationCodeExpiresAt NumberValue `json:"VERIFICATION_CODE_EXPIRES_AT"`
+	VerificationAttempts      NumberValue `json:"VERIFICATION_ATTEMPTS"`
+
+	// Sessions issued before this time are no longer valid
+	SessionsRevokedAt StringValue `json:"SESSIONS_REVOKED_AT"`
+
+	// Set once support scheduled the deletion of the account
+	DeleteAfter StringValue `json:"DELETE_AFTER"`
+}
+
+// Marker of a scheduled account deletion, stored in the vault table
+type AccountDeletionMarker struct {
+	UserId      StringValue `json:"USER_ID"`
+	ItemKey     StringValue `json:"ITEM_KEY"`
+	Email       StringValue `json:"EMAIL"`
+	DeleteAfter StringValue `json:"DELETE_AFTER"`
+}
+
+type VaultItem struct {
+	UserId       StringValue `json:"USER_ID"`
+	ItemKey      StringValue `json:"ITEM_KEY"`
+	ItemId       StringValue `json:"ITEM_ID"`
+	FolderId     StringValue `json:"FOLDER_ID"`
+	Data         StringValue `json:"DATA"`
+	Revision     NumberValue `json:"REVISION"`
+	SyncRevision NumberValue `json:"SYNC_REVISION"`
+	UpdatedAt    StringValue `json:"UPDATED_AT"`
+	DeletedAt    StringValue `json:"DELETED_AT"`
+
+	// JSON encoded ids of the collections of an organization's item
+	CollectionIds StringValue `json:"COLLECTION_IDS"`
+}
+
+// Marker of an item in the trash. OwnerId is the partition of the item
+type VaultTrashMarker struct {
+	UserId    StringValue `json:"USER_ID"`
+	ItemKey   StringValue `json:"ITEM_KEY"`
+	OwnerId   StringValue `json:"OWNER_ID"`
+	ItemId    StringValue `json:"ITEM_ID"`
+	DeletedAt StringValue `json:"DELETED_AT"`
+}
+
+type VaultFolder struct {
+	UserId       StringValue `json:"USER_ID"`
+	ItemKey      StringValue `json:"ITEM_KEY"`
+	FolderId     StringValue `json:"FOLDER_ID"`
+	Name         StringValue `json:"NAME"`
+	Revision     NumberValue `json:"REVISION"`
+	SyncRevision NumberValue `json:"SYNC_REVISION"`
+	UpdatedAt    StringValue `json:"UPDATED_AT"`
+}
+
+/*
+A collection grouping items of an organization, stored in the organization's
+partition. NAME is encrypted with the organization key, ACCESS holds the JSON
+encoded access level of every member granted access, keyed by email
+*/
+type VaultCollection struct {
+	UserId       StringValue `json:"USER_ID"`
+	ItemKey      StringValue `json:"ITEM_KEY"`
+	CollectionId StringValue `json:"COLLECTION_ID"`
+	Name         StringValue `json:"NAME"`
+	Access       StringValue `json:"ACCESS"`
+	Revision     NumberValue `json:"REVISION"`
+	SyncRevision NumberValue `json:"SYNC_REVISION"`
+	UpdatedAt    StringValue `json:"UPDATED_AT"`
+}
+
+// Marks a permanently deleted item, folder or collection for the sync.
+// Expired by the table TTL on EXPIRES_AT
+type VaultTombstone struct {
+	UserId       StringValue `json:"USER_ID"`
+	ItemKey      StringValue `json:"ITEM_KEY"`
+	ObjectId     StringValue `json:"OBJECT_ID"`
+	ObjectType   StringValue `json:"OBJECT_TYPE"`
+	SyncRevision NumberValue `json:"SYNC_REVISION"`
+	DeletedAt    StringValue `json:"DELETED_AT"`
+	ExpiresAt    NumberValue `json:"EXPIRES_AT"`
+}
+
+// Per user counter that is incremented by every write of the vault
+type VaultSyncState struct {
+	UserId       StringValue `json:"USER_ID"`
+	SyncRevision NumberValue `json:"SYNC_REVISION"`
+}
+
+// Metadata of a client encrypted file attached to a vault item. The file
+// itself lives in the blob store, FILE_NAME and KEY are encrypted by the client
+type VaultAttachment struct {
+	UserId       StringValue `json:"USER_ID"`
+	ItemKey      StringValue `json:"ITEM_KEY"`
+	ItemId       StringValue `json:"ITEM_ID"`
+	AttachmentId StringValue `json:"ATTACHMENT_ID"`
+	FileName     StringValue `json:"FILE_NAME"`
+	Key          StringValue `json:"KEY"`
+	Size         NumberValue `json:"SIZE"`
+	CreatedAt    StringValue `json:"CREATED_AT"`
+}
+
+/*
+A secret shared with someone without an account. The partition key holds
+SEND#<id> so recipients can fetch it by id alone. NAME, TEXT and FILE_NAME are
+encrypted by the client with a key that only lives in the URL fragment
+*/
+type Send struct {
+	UserId         StringValue `json:"USER_ID"`
+	ItemKey        StringValue `json:"ITEM_KEY"`
+	SendId         StringValue `json:"SEND_ID"`
+	OwnerId        StringValue `json:"OWNER_ID"`
+	Type           StringValue `json:"TYPE"`
+	Name           StringValue `json:"NAME"`
+	Text           StringValue `json:"TEXT"`
+	FileName       StringValue `json:"FILE_NAME"`
+	Size           NumberValue `json:"SIZE"`
+	PasswordHash   StringValue `json:"PASSWORD_HASH"`
+	MaxAccessCount NumberValue `json:"MAX_ACCESS_COUNT"`
+	AccessCount    NumberValue `json:"ACCESS_COUNT"`
+	FailedAttempts NumberValue `json:"FAILED_ATTEMPTS"`
+	Disabled       BoolValue   `json:"DISABLED"`
+	CreatedAt      StringValue `json:"CREATED_AT"`
+	ExpiresAt      NumberValue `json:"EXPIRES_AT"`
+}
+
+// An organization sharing credentials between its members. The partition key holds ORG#<id>
+type Organization struct {
+	UserId    StringValue `json:"USER_ID"`
+	ItemKey   StringValue `json:"ITEM_KEY"`
+	OrgId     StringValue `json:"ORG_ID"`
+	Name      StringValue `json:"NAME"`
+	CreatedBy StringValue `json:"CREATED_BY"`
+	CreatedAt StringValue `json:"CREATED_AT"`
+}
+
+/*
+Membership of a user in an organization, stored in the organization's
+partition. KEY holds the organization key wrapped with the member's public
+key, PERMISSIONS the JSON encoded permissions of a custom role
+*/
+type OrgMember struct {
+	UserId      StringValue `json:"USER_ID"`
+	ItemKey     StringValue `json:"ITEM_KEY"`
+	OrgId       StringValue `json:"ORG_ID"`
+	Email       StringValue `json:"EMAIL"`
+	Role        StringValue `json:"ROLE"`
+	Permissions StringValue `json:"PERMISSIONS"`
+	Status      StringValue `json:"STATUS"`
+	Key         StringValue `json:"KEY"`
+	InvitedBy   StringValue `json:"INVITED_BY"`
+	CreatedAt   StringValue `json:"CREATED_AT"`
+	UpdatedAt   StringValue `json:"UPDATED_AT"`
+}
+
+/*
+A policy an organization enforces on its members, stored in the organization's
+partition under POLICY#<type>. DATA holds the JSON encoded settings of the policy
+*/
+type OrgPolicy struct {
+	UserId    StringValue `json:"USER_ID"`
+	ItemKey   StringValue `json:"ITEM_KEY"`
+	OrgId     StringValue `json:"ORG_ID"`
+	Type      StringValue `json:"TYPE"`
+	Enabled   BoolValue   `json:"ENABLED"`
+	Data      StringValue `json:"DATA"`
+	UpdatedBy StringValue `json:"UPDATED_BY"`
+	UpdatedAt StringValue `json:"UPDATED_AT"`
+}
+
+/*
+An event of an organization, stored in the organization's partition under
+EVENT#<time>#<event id> so the events sort by time. ACTOR_ID is the member who
+caused it. ITEM_ID, MEMBER_ID and POLICY_TYPE are set depending on the type
+*/
+type OrgEvent struct {
+	UserId     StringValue `json:"USER_ID"`
+	ItemKey    StringValue `json:"ITEM_KEY"`
+	EventId    StringValue `json:"EVENT_ID"`
+	OrgId      StringValue `json:"ORG_ID"`
+	Type       StringValue `json:"TYPE"`
+	ActorId    StringValue `json:"ACTOR_ID"`
+	ItemId     StringValue `json:"ITEM_ID"`
+	MemberId   StringValue `json:"MEMBER_ID"`
+	PolicyType StringValue `json:"POLICY_TYPE"`
+	IpAddress  StringValue `json:"IP_ADDRESS"`
+	CreatedAt  StringValue `json:"CREATED_AT"`
+	ExpiresAt  NumberValue `json:"EXPIRES_AT"`
+}
+
+/*
+A security event of a user, stored in the user's partition under
+SECURITY#<time>#<event id> so the events sort by time. ACTOR_ID is only set
+when someone else caused the event
+*/
+type UserEvent struct {
+	UserId    StringValue `json:"USER_ID"`
+	ItemKey   StringValue `json:"ITEM_KEY"`
+	EventId   StringValue `json:"EVENT_ID"`
+	Type      StringValue `json:"TYPE"`
+	ActorId   StringValue `json:"ACTOR_ID"`
+	IpAddress StringValue `json:"IP_ADDRESS"`
+	UserAgent StringValue `json:"USER_AGENT"`
+	Detail    StringValue `json:"DETAIL"`
+	CreatedAt StringValue `json:"CREATED_AT"`
+	ExpiresAt NumberValue `json:"EXPIRES_AT"`
+}
+
+/*
+A device or IP address a user logged in from, stored in the user's partition
+under KNOWN#DEVICE#<hash of the user agent> or KNOWN#IP#<address>. Known
+devices expire when they are not seen for a while
+*/
+type KnownDevice struct {
+	UserId      StringValue `json:"USER_ID"`
+	ItemKey     StringValue `json:"ITEM_KEY"`
+	Kind        StringValue `json:"KIND"`
+	Value       StringValue `json:"VALUE"`
+	FirstSeenAt StringValue `json:"FIRST_SEEN_AT"`
+	LastSeenAt  StringValue `json:"LAST_SEEN_AT"`
+	ExpiresAt   NumberValue `json:"EXPIRES_AT"`
+}
+
+/*
+Token of the "this wasn't me" link of a new device email, stored in the
+user's partition under LOCK#<SHA-256 of the token>. Only the hash is kept,
+IP_ADDRESS and USER_AGENT are the ones of the login the email was about
+*/
+type LockToken struct {
+	UserId    StringValue `json:"USER_ID"`
+	ItemKey   StringValue `json:"ITEM_KEY"`
+	IpAddress StringValue `json:"IP_ADDRESS"`
+	UserAgent StringValue `json:"USER_AGENT"`
+	CreatedAt StringValue `json:"CREATED_AT"`
+	ExpiresAt NumberValue `json:"EXPIRES_AT"`
+}
+
+/*
+An action support took on a user, stored in its own partition AUDIT#<user id>
+under ENTRY#<time>#<entry id> so it outlives the deletion of the user. Entries
+are only ever created, never updated or deleted
+*/
+type AdminAuditEntry struct {
+	UserId     StringValue `json:"USER_ID"`
+	ItemKey    StringValue `json:"ITEM_KEY"`
+	EntryId    StringValue `json:"ENTRY_ID"`
+	TargetId   StringValue `json:"TARGET_ID"`
+	OperatorId StringValue `json:"OPERATOR_ID"`
+	Action     StringValue `json:"ACTION"`
+	Reason     StringValue `json:"REASON"`
+	IpAddress  StringValue `json:"IP_ADDRESS"`
+	CreatedAt  StringValue `json:"CREATED_AT"`
+}
+
+// The keys of any item in the vault table, for operations that only address items
+type ItemReference struct {
+	UserId  StringValue `json:"USER_ID"`
+	ItemKey StringValue `json:"ITEM_KEY"`
+}
+
+/*
+Key pair of a user for sharing. PUBLIC_KEY holds the base64 encoded public
+key, ENCRYPTED_PRIVATE_KEY the private key encrypted by the client with the
+user's key. FINGERPRINT is the SHA-256 of the public key
+*/
+type UserKeys struct {
+	UserId              StringValue `json:"USER_ID"`
+	ItemKey             StringValue `json:"ITEM_KEY"`
+	Algorithm           StringValue `json:"ALGORITHM"`
+	PublicKey           StringValue `json:"PUBLIC_KEY"`
+	EncryptedPrivateKey StringValue `json:"ENCRYPTED_PRIVATE_KEY"`
+	Fingerprint         StringValue `json:"FINGERPRINT"`
+	Revision            NumberValue `json:"REVISION"`
+	UpdatedAt           StringValue `json:"UPDATED_AT"`
+}
+
+/*
+A vault item shared directly with another user. The owner's partition holds
+the share under SHARE#<item id>#<recipient>, the recipient's partition a copy
+under SHARED#<share id>. KEY is the item key wrapped with the recipient's
+public key, FINGERPRINT the fingerprint of that public key
+*/
+type ItemShare struct {
+	UserId      StringValue `json:"USER_ID"`
+	ItemKey     StringValue `json:"ITEM_KEY"`
+	ShareId     StringValue `json:"SHARE_ID"`
+	OwnerId     StringValue `json:"OWNER_ID"`
+	ItemId      StringValue `json:"ITEM_ID"`
+	Recipient   StringValue `json:"RECIPIENT"`
+	Key         StringValue `json:"KEY"`
+	Fingerprint StringValue `json:"FINGERPRINT"`
+	Level       StringValue `json:"LEVEL"`
+	Status      StringValue `json:"STATUS"`
+	CreatedAt   StringValue `json:"CREATED_AT"`
+	UpdatedAt   StringValue `json:"UPDATED_AT"`
+}
+
+/*
+A trusted contact who can request access to a user's vault. The grantor's
+partition holds it under EMERGENCY#<id>, the grantee's partition a copy under
+GRANTED#<id>. KEY is the grantor's user key wrapped with the grantee's public
+key. RECOVERY_AT is when a recovery request is approved unless the grantor
+rejects it first
+*/
+type EmergencyAccess struct {
+	UserId              StringValue `json:"USER_ID"`
+	ItemKey             StringValue `json:"ITEM_KEY"`
+	AccessId            StringValue `json:"ACCESS_ID"`
+	GrantorId           StringValue `json:"GRANTOR_ID"`
+	GranteeId           StringValue `json:"GRANTEE_ID"`
+	Type                StringValue `json:"TYPE"`
+	WaitDays            NumberValue `json:"WAIT_DAYS"`
+	Status              StringValue `json:"STATUS"`
+	Key                 StringValue `json:"KEY"`
+	RecoveryInitiatedAt StringValue `json:"RECOVERY_INITIATED_AT"`
+	RecoveryAt          StringValue `json:"RECOVERY_AT"`
+	CreatedAt           StringValue `json:"CREATED_AT"`
+	UpdatedAt           StringValue `json:"UPDATED_AT"`
+}
+
+// Groups of equivalent domains of a user, GROUPS holds them as a JSON array of arrays
+type VaultDomains struct {
+	UserId       StringValue `json:"USER_ID"`
+	ItemKey      StringValue `json:"ITEM_KEY"`
+	Groups       StringValue `json:"GROUPS"`
+	Revision     NumberValue `json:"REVISION"`
+	SyncRevision NumberValue `json:"SYNC_REVISION"`
+	UpdatedAt    StringValue `json:"UPDATED_AT"`
+}
+
+// A previous version of a vault item. Expired by the table TTL on EXPIRES_AT
+type VaultItemHistory struct {
+	UserId    StringValue `json:"USER_ID"`
+	ItemKey   StringValue `json:"ITEM_KEY"`
+	ItemId    StringValue `json:"ITEM_ID"`
+	Data      StringValue `json:"DATA"`
+	Revision  NumberValue `json:"REVISION"`
+	UpdatedAt StringValue `json:"UPDATED_AT"`
+	ExpiresAt NumberValue `json:"EXPIRES_AT"`
+}
+
+// Health metadata of a revision of a vault item computed by the clients. The
+// fingerprint is a salted hash of the password, equal for items sharing a password.
+// DataHash is the hash of the encrypted data the health was computed for
+type VaultItemHealth struct {
+	UserId            StringValue `json:"USER_ID"`
+	ItemKey           StringValue `json:"ITEM_KEY"`
+	ItemId            StringValue `json:"ITEM_ID"`
+	Revision          NumberValue `json:"REVISION"`
+	DataHash          StringValue `json:"DATA_HASH"`
+	Fingerprint       StringValue `json:"FINGERPRINT"`
+	Score             NumberValue `json:"SCORE"`
+	Breached          BoolValue   `json:"BREACHED"`
+	PasswordChangedAt StringValue `json:"PASSWORD_CHANGED_AT"`
+	UpdatedAt         StringValue `json:"UPDATED_AT"`
+}
+
+// A range of the breached password hashes sharing a SHA-1 prefix. The partition
+// key holds the prefix, the sort key the number of the range. HASHES holds a
+// "SUFFIX:COUNT" line per hash
+type BreachRange struct {
+	Prefix   StringValue `json:"USER_ID"`
+	RangeKey StringValue `json:"ITEM_KEY"`
+	Hashes   StringValue `json:"HASHES"`
 }
 
 type PasswordCaddyErrorResponse struct {
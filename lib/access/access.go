@@ -0,0 +1,531 @@
+package access
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"time"
+
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/dynamoclient"
+	"password-caddy/api/lib/orgs"
+	"password-caddy/api/lib/util"
+	"password-caddy/api/lib/vault"
+
+	"github.com/google/uuid"
+)
+
+/*
+Access levels of a member to a collection, from the least to the most
+permissive. Members who hide passwords see the items but the clients do not
+show their passwords, read only members see everything but can not change
+anything, editors change the items and managers also the collection itself
+*/
+const (
+	LEVEL_NONE           = ""
+	LEVEL_HIDE_PASSWORDS = "hidePasswords"
+	LEVEL_READ_ONLY      = "readOnly"
+	LEVEL_EDIT           = "edit"
+	LEVEL_MANAGE         = "manage"
+)
+
+const (
+	MAX_COLLECTION_NAME_LENGTH = 1024
+	MAX_COLLECTION_ACCESS      = 500
+)
+
+/*
+A collection of an organization. Name is encrypted with the organization key,
+Access grants members a level keyed by their email. Revision is the revision
+the client last saw (0 for a new collection)
+*/
+type CollectionRequest struct {
+	Name     string            `json:"name"`
+	Access   map[string]string `json:"access"`
+	Revision int               `json:"revision"`
+}
+
+/*
+The vault a request operates on and what the user may do in it. Requests
+without an organization operate on the user's own vault, where the user can
+do everything. Requests of an organization hold the user's membership and
+the organization's collections to evaluate the user's access
+*/
+type Scope struct {
+	UserId      string
+	OrgId       string
+	Member      types.OrgMember
+	Collections []types.VaultCollection
+}
+
+/********** SCOPE **********/
+
+func Personal(userId string) Scope {
+	return Scope{UserId: userId}
+}
+
+func (scope Scope) IsPersonal() bool {
+	return scope.OrgId == ""
+}
+
+// Partition key of the vault, the user's id or the organization's partition
+func (scope Scope) Partition() string {
+	if scope.IsPersonal() {
+		return scope.UserId
+	}
+
+	return orgs.PartitionKey(scope.OrgId)
+}
+
+// Members who manage collections manage all of them and every item
+func (scope Scope) ManagesAllCollections() bool {
+	return scope.IsPersonal() || orgs.HasPermission(scope.Member, orgs.PERMISSION_MANAGE_COLLECTIONS)
+}
+
+/********** EVALUATION **********/
+
+func Levels() []string {
+	return []string{LEVEL_HIDE_PASSWORDS, LEVEL_READ_ONLY, LEVEL_EDIT, LEVEL_MANAGE}
+}
+
+func IsValidLevel(level string) bool {
+	return rank(level) > 0
+}
+
+// Check if a level grants at least the required level
+func Allows(level, required string) bool {
+	return rank(level) >= rank(required)
+}
+
+func (scope Scope) Collection(collectionId string) (types.VaultCollection, bool) {
+	for _, collection := range scope.Collections {
+		if collection.CollectionId.Value == collectionId {
+			return collection, true
+		}
+	}
+
+	return types.VaultCollection{}, false
+}
+
+// The user's level on a collection. Unknown collections grant nothing
+func (scope Scope) CollectionLevel(collectionId string) string {
+	collection, ok := scope.Collection(collectionId)
+
+	if !ok {
+		return LEVEL_NONE
+	}
+
+	return scope.LevelOf(collection)
+}
+
+func (scope Scope) LevelOf(collection types.VaultCollection) string {
+	if scope.ManagesAllCollections() {
+		return LEVEL_MANAGE
+	}
+
+	return vault.AccessOf(collection)[scope.Member.Email.Value]
+}
+
+/*
+The user's level on an item, the most permissive level of the item's
+collections. Items of an organization without any collection are only
+accessible to members who manage all collections
+*/
+func (scope Scope) ItemLevel(item types.VaultItem) string {
+	if scope.ManagesAllCollections() {
+		return LEVEL_MANAGE
+	}
+
+	level := LEVEL_NONE
+
+	for _, collectionId := range vault.CollectionIdsOf(item) {
+		if candidate := scope.CollectionLevel(collectionId); rank(candidate) > rank(level) {
+			level = candidate
+		}
+	}
+
+	return level
+}
+
+func (scope Scope) CanRead(item types.VaultItem) bool {
+	return Allows(scope.ItemLevel(item), LEVEL_HIDE_PASSWORDS)
+}
+
+func (scope Scope) Can(item types.VaultItem, required string) bool {
+	return Allows(scope.ItemLevel(item), required)
+}
+
+/*
+Check that the user can move an item from the current collections to the
+next ones. Adding an item to a collection or taking it out needs edit access
+to that collection. Items of an organization need at least one collection
+unless the user manages all collections, items of a user's vault have none
+*/
+func (scope Scope) CanChangeCollections(current, next []string) error {
+	if scope.IsPersonal() {
+		if len(next) > 0 {
+			return errors.New("Only items of an organization can be in collections")
+		}
+
+		return nil
+	}
+
+	if len(next) == 0 && !scope.ManagesAllCollections() {
+		return errors.New("Items of an organization need at least one collection")
+	}
+
+	for _, collectionId := range next {
+		if _, ok := scope.Collection(collectionId); !ok {
+			return fmt.Errorf("Collection %s not found", collectionId)
+		}
+	}
+
+	for _, collectionId := range difference(current, next) {
+		if !Allows(scope.CollectionLevel(collectionId), LEVEL_EDIT) {
+			return fmt.Errorf("Not allowed to change the items of collection %s", collectionId)
+		}
+	}
+
+	return nil
+}
+
+// Collections in only one of both lists, whose items change
+func difference(current, next []string) []string {
+	changed := []string{}
+
+	for _, collectionId := range current {
+		if !contains(next, collectionId) && !contains(changed, collectionId) {
+			changed = append(changed, collectionId)
+		}
+	}
+
+	for _, collectionId := range next {
+		if !contains(current, collectionId) && !contains(changed, collectionId) {
+			changed = append(changed, collectionId)
+		}
+	}
+
+	return changed
+}
+
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func rank(level string) int {
+	for index, candidate := range Levels() {
+		if candidate == level {
+			return index + 1
+		}
+	}
+
+	return 0
+}
+
+/********** SYNC **********/
+
+/*
+Check if the user's access changed after a sync cursor was issued, because
+the user's membership or a collection changed or a collection was deleted.
+The client then needs a full resync, items that became accessible did not
+change themselves
+*/
+func (scope Scope) ChangedSince(changes vault.VaultChanges, issuedAt time.Time) bool {
+	if scope.IsPersonal() {
+		return false
+	}
+
+	changed := func(timestamp string) bool {
+		at, err := time.Parse(time.RFC3339, timestamp)
+		return err == nil && !at.Before(issuedAt)
+	}
+
+	if changed(scope.Member.UpdatedAt.Value) {
+		return true
+	}
+
+	for _, collection := range scope.Collections {
+		if changed(collection.UpdatedAt.Value) {
+			return true
+		}
+	}
+
+	for _, tombstone := range changes.Tombstones {
+		if tombstone.ObjectType.Value == vault.TOMBSTONE_TYPE_COLLECTION && changed(tombstone.DeletedAt.Value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+Keep the changes the user can see. Items the user can not read are turned
+into tombstones, the client may still hold them from before it lost access.
+An organization's partition has no folders or equivalent domains
+*/
+func (scope Scope) FilterChanges(changes vault.VaultChanges) vault.VaultChanges {
+	if scope.IsPersonal() {
+		return changes
+	}
+
+	filtered := vault.VaultChanges{
+		Items:      []types.VaultItem{},
+		Folders:    []types.VaultFolder{},
+		Tombstones: changes.Tombstones,
+		Last:       changes.Last,
+		HasMore:    changes.HasMore,
+	}
+
+	for _, item := range changes.Items {
+		if scope.CanRead(item) {
+			filtered.Items = append(filtered.Items, item)
+			continue
+		}
+
+		var tombstone types.VaultTombstone
+
+		tombstone.ObjectId.Value = item.ItemId.Value
+		tombstone.ObjectType.Value = vault.TOMBSTONE_TYPE_ITEM
+		tombstone.SyncRevision.Value = item.SyncRevision.Value
+		tombstone.DeletedAt.Value = item.UpdatedAt.Value
+
+		filtered.Tombstones = append(filtered.Tombstones, tombstone)
+	}
+
+	return filtered
+}
+
+/********** ITEMS **********/
+
+// Keep the items the user has at least the required level on
+func (scope Scope) ItemsAllowing(items []types.VaultItem, required string) []types.VaultItem {
+	if scope.IsPersonal() {
+		return items
+	}
+
+	allowed := []types.VaultItem{}
+
+	for _, item := range items {
+		if scope.Can(item, required) {
+			allowed = append(allowed, item)
+		}
+	}
+
+	return allowed
+}
+
+/*
+Refuse the changes of a bulk request on items the user does not have the
+required level on. Their results become a 403, the remaining changes are
+returned. Items the user can not read are left out of the current items
+beforehand, so they are reported as not found
+*/
+func (scope Scope) AuthorizeBulkChanges(changes []vault.BulkChange, results []vault.BulkItemResult, required string) []vault.BulkChange {
+	allowed := []vault.BulkChange{}
+
+	for _, change := range changes {
+		if !scope.Can(change.Item, required) {
+			results[change.Index].Status = 403
+			results[change.Index].Message = "Not allowed to change this item"
+			continue
+		}
+
+		allowed = append(allowed, change)
+	}
+
+	return allowed
+}
+
+/********** RESPONSES **********/
+
+// An item as the user sees it, flagged when the user can not edit it or see its passwords
+func (scope Scope) ToItemResponse(item types.VaultItem) vault.VaultItemResponse {
+	response := vault.ToItemResponse(item)
+	level := scope.ItemLevel(item)
+
+	response.ReadOnly = !Allows(level, LEVEL_EDIT)
+	response.HidePasswords = level == LEVEL_HIDE_PASSWORDS
+
+	return response
+}
+
+// Flag the items of a sync page the same way as ToItemResponse
+func (scope Scope) FlagItems(responses []vault.VaultItemResponse, items []types.VaultItem) {
+	levels := map[string]string{}
+
+	for _, item := range items {
+		levels[item.ItemId.Value] = scope.ItemLevel(item)
+	}
+
+	for index, response := range responses {
+		responses[index].ReadOnly = !Allows(levels[response.Id], LEVEL_EDIT)
+		responses[index].HidePasswords = levels[response.Id] == LEVEL_HIDE_PASSWORDS
+	}
+}
+
+// Flag the items of bulk results the same way as ToItemResponse
+func (scope Scope) FlagBulkResults(results []vault.BulkItemResult) {
+	for _, itemResult := range results {
+		if itemResult.Item == nil {
+			continue
+		}
+
+		var item types.VaultItem
+
+		item.CollectionIds.Value = EncodeCollectionIds(itemResult.Item.CollectionIds)
+		level := scope.ItemLevel(item)
+
+		itemResult.Item.ReadOnly = !Allows(level, LEVEL_EDIT)
+		itemResult.Item.HidePasswords = level == LEVEL_HIDE_PASSWORDS
+	}
+}
+
+// A collection as the user sees it. Only members who manage it see who has access
+func (scope Scope) ToCollectionResponse(collection types.VaultCollection) vault.VaultCollectionResponse {
+	response := vault.ToCollectionResponse(collection)
+	response.Level = scope.LevelOf(collection)
+
+	if response.Level != LEVEL_MANAGE {
+		response.Access = nil
+	}
+
+	return response
+}
+
+// The collections the user has any access to
+func (scope Scope) CollectionResponses() []vault.VaultCollectionResponse {
+	responses := []vault.VaultCollectionResponse{}
+
+	for _, collection := range scope.Collections {
+		if scope.LevelOf(collection) != LEVEL_NONE {
+			responses = append(responses, scope.ToCollectionResponse(collection))
+		}
+	}
+
+	return responses
+}
+
+/********** VALIDATION **********/
+
+func IsValidCollectionId(collectionId string) bool {
+	_, err := uuid.Parse(collectionId)
+	return err == nil
+}
+
+func (request CollectionRequest) Validate() error {
+	if request.Name == "" || len(request.Name) > MAX_COLLECTION_NAME_LENGTH {
+		return fmt.Errorf("Name is required and can have at most %d characters", MAX_COLLECTION_NAME_LENGTH)
+	}
+
+	if request.Revision < 0 {
+		return errors.New("Revision can not be negative")
+	}
+
+	if len(request.Access) > MAX_COLLECTION_ACCESS {
+		return fmt.Errorf("At most %d members can be granted access to a collection", MAX_COLLECTION_ACCESS)
+	}
+
+	for email, level := range request.Access {
+		if address, err := mail.ParseAddress(email); err != nil || address.Address != email {
+			return errors.New("Access must be keyed by the email of members")
+		}
+
+		if !IsValidLevel(level) {
+			return errors.New("Access levels must be hidePasswords, readOnly, edit or manage")
+		}
+	}
+
+	return nil
+}
+
+// Emails of the access of a collection that are no members of the organization
+func UnknownMembers(access map[string]string, members []types.OrgMember) []string {
+	unknown := []string{}
+
+	for email := range access {
+		found := false
+
+		for _, member := range members {
+			if member.Email.Value == email {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			unknown = append(unknown, email)
+		}
+	}
+
+	return unknown
+}
+
+func EncodeAccess(access map[string]string) string {
+	if access == nil {
+		access = map[string]string{}
+	}
+
+	return util.SerializeJson(access)
+}
+
+func EncodeCollectionIds(collectionIds []string) string {
+	if len(collectionIds) == 0 {
+		return ""
+	}
+
+	return util.SerializeJson(collectionIds)
+}
+
+/********** OPERATIONS **********/
+
+/*
+Resolve the vault of a request. Without orgId it is the user's own vault,
+otherwise the organization's, which needs a confirmed membership. A missing
+organization and one the user is no confirmed member of both result in a 404
+*/
+func Resolve(client *dynamoclient.DynamoClient, userId, orgId string) *dynamoclient.DynamoResponse {
+	if orgId == "" {
+		return dynamoclient.SuccessWithValue(Personal(userId))
+	}
+
+	if !orgs.IsValidOrgId(orgId) {
+		return dynamoclient.Failure(types.PasswordCaddyError{
+			StatusCode: 400,
+			Message:    "Organization id must be a UUID",
+		})
+	}
+
+	response := orgs.GetMember(client, orgId, userId)
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	member := response.Data.(types.OrgMember)
+
+	if member.Status.Value != orgs.STATUS_CONFIRMED {
+		return dynamoclient.Failure(types.PasswordCaddyError{
+			StatusCode: 404,
+			Message:    "Organization not found",
+		})
+	}
+
+	scope := Scope{UserId: userId, OrgId: orgId, Member: member}
+
+	response = vault.ListCollections(client, scope.Partition())
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	scope.Collections = response.Data.([]types.VaultCollection)
+
+	return dynamoclient.SuccessWithValue(scope)
+}
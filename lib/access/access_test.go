@@ -0,0 +1,210 @@
+package access
+
+import (
+	"testing"
+	"time"
+
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/orgs"
+	"password-caddy/api/lib/vault"
+)
+
+const orgId = "6f1e4a3c-9a8b-4c2d-8e7f-0a1b2c3d4e5f"
+
+func collection(collectionId string, access map[string]string) types.VaultCollection {
+	var collection types.VaultCollection
+	collection.CollectionId.Value = collectionId
+	collection.Access.Value = EncodeAccess(access)
+	return collection
+}
+
+func item(itemId string, collectionIds ...string) types.VaultItem {
+	var item types.VaultItem
+	item.ItemId.Value = itemId
+	item.CollectionIds.Value = EncodeCollectionIds(collectionIds)
+	return item
+}
+
+func orgScope(email, role string) Scope {
+	var member types.OrgMember
+	member.Email.Value = email
+	member.Role.Value = role
+	member.Status.Value = orgs.STATUS_CONFIRMED
+
+	return Scope{
+		UserId: email,
+		OrgId:  orgId,
+		Member: member,
+		Collections: []types.VaultCollection{
+			collection("a", map[string]string{"bob@example.com": LEVEL_READ_ONLY}),
+			collection("b", map[string]string{"bob@example.com": LEVEL_EDIT}),
+			collection("c", map[string]string{"bob@example.com": LEVEL_HIDE_PASSWORDS}),
+			collection("d", map[string]string{}),
+		},
+	}
+}
+
+/***** Evaluation *****/
+
+func TestPersonalScopeAllowsEverything(t *testing.T) {
+	scope := Personal("bob@example.com")
+
+	if scope.Partition() != "bob@example.com" || !scope.Can(item("1"), LEVEL_MANAGE) {
+		t.Errorf("FAILED - TestPersonalScopeAllowsEverything | Expected: full access to the user's own vault")
+	}
+}
+
+func TestItemLevel(t *testing.T) {
+	scope := orgScope("bob@example.com", orgs.ROLE_USER)
+
+	tests := []struct {
+		item     types.VaultItem
+		expected string
+	}{
+		{item("1", "a"), LEVEL_READ_ONLY},
+		{item("2", "a", "b"), LEVEL_EDIT},
+		{item("3", "c"), LEVEL_HIDE_PASSWORDS},
+		{item("4", "d"), LEVEL_NONE},
+		{item("5"), LEVEL_NONE},
+		{item("6", "unknown"), LEVEL_NONE},
+	}
+
+	for _, test := range tests {
+		if actual := scope.ItemLevel(test.item); actual != test.expected {
+			t.Errorf("FAILED - TestItemLevel | Item: %s | Actual: %q | Expected: %q", test.item.ItemId.Value, actual, test.expected)
+		}
+	}
+}
+
+func TestCollectionManagersManageEverything(t *testing.T) {
+	scope := orgScope("alice@example.com", orgs.ROLE_MANAGER)
+
+	if scope.ItemLevel(item("1", "d")) != LEVEL_MANAGE || scope.ItemLevel(item("2")) != LEVEL_MANAGE {
+		t.Errorf("FAILED - TestCollectionManagersManageEverything | Expected: managers to manage every item")
+	}
+
+	if scope.CollectionLevel("unknown") != LEVEL_NONE {
+		t.Errorf("FAILED - TestCollectionManagersManageEverything | Expected: no access to unknown collections")
+	}
+}
+
+func TestCanChangeCollections(t *testing.T) {
+	scope := orgScope("bob@example.com", orgs.ROLE_USER)
+
+	tests := []struct {
+		current []string
+		next    []string
+		allowed bool
+	}{
+		{[]string{}, []string{"b"}, true},
+		{[]string{}, []string{"a"}, false},
+		{[]string{"a", "b"}, []string{"a", "b"}, true},
+		{[]string{"a", "b"}, []string{"b"}, false},
+		{[]string{"b"}, []string{}, false},
+		{[]string{}, []string{"unknown"}, false},
+	}
+
+	for _, test := range tests {
+		if err := scope.CanChangeCollections(test.current, test.next); (err == nil) != test.allowed {
+			t.Errorf("FAILED - TestCanChangeCollections | %v -> %v | Error: %v | Expected allowed: %v", test.current, test.next, err, test.allowed)
+		}
+	}
+
+	if err := Personal("bob@example.com").CanChangeCollections(nil, []string{"b"}); err == nil {
+		t.Errorf("FAILED - TestCanChangeCollections | Expected: items of a user's vault to have no collections")
+	}
+}
+
+/***** Items *****/
+
+func TestItemsAllowing(t *testing.T) {
+	scope := orgScope("bob@example.com", orgs.ROLE_USER)
+	items := []types.VaultItem{item("1", "a"), item("2", "b"), item("3", "c"), item("4", "d")}
+
+	actual := scope.ItemsAllowing(items, LEVEL_READ_ONLY)
+
+	if len(actual) != 2 || actual[0].ItemId.Value != "1" || actual[1].ItemId.Value != "2" {
+		t.Errorf("FAILED - TestItemsAllowing | Actual: %+v | Expected: items 1 and 2", actual)
+	}
+
+	if len(Personal("bob@example.com").ItemsAllowing(items, LEVEL_MANAGE)) != len(items) {
+		t.Errorf("FAILED - TestItemsAllowing | Expected: every item of the user's own vault")
+	}
+}
+
+func TestAuthorizeBulkChanges(t *testing.T) {
+	scope := orgScope("bob@example.com", orgs.ROLE_USER)
+	results := make([]vault.BulkItemResult, 2)
+	changes := []vault.BulkChange{
+		{Index: 0, Item: item("1", "a")},
+		{Index: 1, Item: item("2", "b")},
+	}
+
+	actual := scope.AuthorizeBulkChanges(changes, results, LEVEL_EDIT)
+
+	if len(actual) != 1 || actual[0].Index != 1 || results[0].Status != 403 || results[1].Status != 0 {
+		t.Errorf("FAILED - TestAuthorizeBulkChanges | Actual: %+v %+v | Expected: only the change of item 2", actual, results)
+	}
+}
+
+/***** Sync *****/
+
+func TestFilterChangesTurnsHiddenItemsIntoTombstones(t *testing.T) {
+	scope := orgScope("bob@example.com", orgs.ROLE_USER)
+	changes := vault.VaultChanges{Items: []types.VaultItem{item("1", "a"), item("2", "d")}}
+
+	filtered := scope.FilterChanges(changes)
+
+	if len(filtered.Items) != 1 || filtered.Items[0].ItemId.Value != "1" {
+		t.Errorf("FAILED - TestFilterChangesTurnsHiddenItemsIntoTombstones | Items: %v", filtered.Items)
+	}
+
+	if len(filtered.Tombstones) != 1 || filtered.Tombstones[0].ObjectId.Value != "2" {
+		t.Errorf("FAILED - TestFilterChangesTurnsHiddenItemsIntoTombstones | Tombstones: %v", filtered.Tombstones)
+	}
+}
+
+func TestChangedSince(t *testing.T) {
+	scope := orgScope("bob@example.com", orgs.ROLE_USER)
+	issuedAt := time.Now().UTC()
+
+	scope.Collections[0].UpdatedAt.Value = issuedAt.Add(-time.Hour).Format(time.RFC3339)
+
+	if scope.ChangedSince(vault.VaultChanges{}, issuedAt) {
+		t.Errorf("FAILED - TestChangedSince | Expected: no change before the cursor was issued")
+	}
+
+	scope.Collections[0].UpdatedAt.Value = issuedAt.Add(time.Minute).Format(time.RFC3339)
+
+	if !scope.ChangedSince(vault.VaultChanges{}, issuedAt) {
+		t.Errorf("FAILED - TestChangedSince | Expected: a collection changed after the cursor was issued")
+	}
+}
+
+func TestItemResponseFlags(t *testing.T) {
+	scope := orgScope("bob@example.com", orgs.ROLE_USER)
+
+	readOnly := scope.ToItemResponse(item("1", "a"))
+	hidden := scope.ToItemResponse(item("2", "c"))
+	editable := scope.ToItemResponse(item("3", "b"))
+
+	if !readOnly.ReadOnly || readOnly.HidePasswords || !hidden.HidePasswords || editable.ReadOnly {
+		t.Errorf("FAILED - TestItemResponseFlags | %v %v %v", readOnly, hidden, editable)
+	}
+}
+
+/***** Validation *****/
+
+func TestCollectionRequestValidate(t *testing.T) {
+	valid := CollectionRequest{Name: "name", Access: map[string]string{"bob@example.com": LEVEL_EDIT}}
+
+	if err := valid.Validate(); err != nil {
+		t.Errorf("FAILED - TestCollectionRequestValidate | Error: %v", err)
+	}
+
+	invalid := CollectionRequest{Name: "name", Access: map[string]string{"bob@example.com": "owner"}}
+
+	if err := invalid.Validate(); err == nil {
+		t.Errorf("FAILED - TestCollectionRequestValidate | Expected: an error for an invalid level")
+	}
+}
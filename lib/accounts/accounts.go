@@ -0,0 +1,408 @@
+package accounts
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"time"
+
+	appConfig "password-caddy/api/core/config"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/dynamoclient"
+
+	dynamoTypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+/*
+Statuses of a user. A user is pending until their first login challenge and
+locked when they reported a login that was not theirs. Support suspends users
+and schedules their deletion. Locked, suspended and deleted users can not log in
+*/
+const (
+	STATUS_PENDING_REGISTRATION = "PENDING_REGISTRATION"
+	STATUS_ACTIVE               = "ACTIVE"
+	STATUS_LOCKED               = "LOCKED"
+	STATUS_SUSPENDED            = "SUSPENDED"
+	STATUS_PENDING_DELETION     = "PENDING_DELETION"
+)
+
+// Sort key prefix of the lock tokens in a user's partition
+const LOCK_PREFIX = "LOCK#"
+
+/*
+While the deletion of a user is scheduled the ACCOUNT_DELETIONS partition of
+the vault table holds a marker under DELETION#<delete after>#<email>, so due
+deletions are found with a query sorted by when they are due instead of a scan
+of the users table, which has no sort key to hold the markers
+*/
+const (
+	DELETIONS_KEY   = "ACCOUNT_DELETIONS"
+	DELETION_PREFIX = "DELETION#"
+)
+
+// Random bytes of a lock token, which is hex encoded
+const LOCK_TOKEN_BYTES = 32
+
+/********** CONFIG **********/
+
+// How long the "this wasn't me" link of a new device email works
+func LockTokenTTL() time.Duration {
+	hours := appConfig.Get("LOCK_TOKEN_TTL_HOURS", "168").ToInt64()
+	return time.Duration(hours) * time.Hour
+}
+
+/*
+Page of the web app behind the "this wasn't me" link. The page posts the token
+to the API, so mail scanners following the link do not lock the account
+*/
+func LockUrl() string {
+	return appConfig.Get("LOCK_ACCOUNT_URL", "https://password-caddy.com/lock-account").ToString()
+}
+
+// How long after support scheduled the deletion of an account it is purged
+func DeletionGrace() time.Duration {
+	days := appConfig.Get("ACCOUNT_DELETION_GRACE_DAYS", "7").ToInt64()
+	return time.Duration(days) * 24 * time.Hour
+}
+
+/********** KEYS **********/
+
+// Only the hash of a lock token is stored, the token itself is only in the email
+func LockKey(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return LOCK_PREFIX + hex.EncodeToString(hash[:])
+}
+
+func DeletionKey(deleteAfter, email string) string {
+	return DELETION_PREFIX + deleteAfter + "#" + email
+}
+
+func IsValidLockToken(token string) bool {
+	value, err := hex.DecodeString(token)
+	return err == nil && len(value) == LOCK_TOKEN_BYTES
+}
+
+// The "this wasn't me" link of a token
+func LockLink(email, token string) string {
+	query := url.Values{}
+	query.Set("email", email)
+	query.Set("token", token)
+
+	return LockUrl() + "?" + query.Encode()
+}
+
+/********** EVALUATION **********/
+
+// Blocked users can not log in or register again
+func IsBlocked(user types.PasswordCaddyUser) bool {
+	switch user.Status.Value {
+	case STATUS_LOCKED, STATUS_SUSPENDED, STATUS_PENDING_DELETION:
+		return true
+	}
+
+	return false
+}
+
+// Why a blocked user can not log in
+func BlockedMessage(user types.PasswordCaddyUser) string {
+	switch user.Status.Value {
+	case STATUS_SUSPENDED:
+		return "Account is suspended"
+	case STATUS_PENDING_DELETION:
+		return "Account is scheduled for deletion"
+	}
+
+	return "Account is locked"
+}
+
+/*
+Check whether the sessions of the user issued at the time were revoked since.
+Revocations are stored to the second, so sessions issued within the second of
+a revocation are revoked too
+*/
+func IsRevoked(user types.PasswordCaddyUser, issuedAt time.Time) bool {
+	if user.SessionsRevokedAt.Value == "" {
+		return false
+	}
+
+	revokedAt, err := time.Parse(time.RFC3339, user.SessionsRevokedAt.Value)
+
+	return err != nil || !issuedAt.After(revokedAt)
+}
+
+// DynamoDB expires items lazily, so expired tokens can still be read for a while
+func IsExpired(token types.LockToken, now time.Time) bool {
+	return now.Unix() >= int64(token.ExpiresAt.Value)
+}
+
+/*
+Condition of the writes that must not touch a blocked user. Users without a
+status are not blocked
+*/
+func NotBlockedCondition() *dynamoclient.DynamoCondition {
+	return &dynamoclient.DynamoCondition{
+		Expression: "attribute_not_exists(#status) OR NOT (#status IN (:locked, :suspended, :pendingDeletion))",
+		Names:      map[string]string{"#status": "STATUS"},
+		Values: map[string]interface{}{
+			":locked":          STATUS_LOCKED,
+			":suspended":       STATUS_SUSPENDED,
+			":pendingDeletion": STATUS_PENDING_DELETION,
+		},
+	}
+}
+
+/********** OPERATIONS **********/
+
+/*
+Create the token of the "this wasn't me" link for a login from the IP address
+and user agent. The token is returned as the response data
+*/
+func CreateLockToken(client *dynamoclient.DynamoClient, email, ipAddress, userAgent string) *dynamoclient.DynamoResponse {
+	buffer := make([]byte, LOCK_TOKEN_BYTES)
+
+	if _, err := rand.Read(buffer); err != nil {
+		return dynamoclient.Failure(types.PasswordCaddyError{
+			StatusCode: 500,
+			Message:    "Failed to generate a lock token",
+		})
+	}
+
+	token := hex.EncodeToString(buffer)
+	now := time.Now().UTC()
+
+	response := client.Put(dynamoclient.DynamoPutRequest{
+		Key:     email,
+		SortKey: LockKey(token),
+		Values: map[string]interface{}{
+			"IP_ADDRESS": ipAddress,
+			"USER_AGENT": userAgent,
+			"CREATED_AT": now.Format(time.RFC3339),
+			"EXPIRES_AT": now.Add(LockTokenTTL()).Unix(),
+		},
+	})
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	return dynamoclient.SuccessWithValue(token)
+}
+
+// Get the lock token of a user. The token is empty when it does not exist
+func GetLockToken(client *dynamoclient.DynamoClient, email, token string) *dynamoclient.DynamoResponse {
+	return client.
+		Get(dynamoclient.DynamoGetRequest{
+			Key:     email,
+			SortKey: LockKey(token),
+		}).
+		AsLockToken()
+}
+
+func DeleteLockToken(client *dynamoclient.DynamoClient, email, token string) *dynamoclient.DynamoResponse {
+	return client.Delete(dynamoclient.DynamoDeleteRequest{
+		Key:     email,
+		SortKey: LockKey(token),
+	})
+}
+
+/*
+Lock a user in the users table and revoke all their sessions. The pending
+verification code is removed so a login that is underway can not finish
+*/
+func Lock(client *dynamoclient.DynamoClient, email string) *dynamoclient.DynamoResponse {
+	return client.Update(dynamoclient.DyanamoUpdateRequest{
+		Key:       email,
+		Values:    revoke(statusUpdate(STATUS_LOCKED)),
+		Condition: exists(),
+	})
+}
+
+// Revoke all sessions of a user, who has to log in again
+func RevokeSessions(client *dynamoclient.DynamoClient, email string) *dynamoclient.DynamoResponse {
+	return client.
+		Update(dynamoclient.DyanamoUpdateRequest{
+			Key:       email,
+			Values:    revoke(map[string]dynamoclient.DynamoUpdateItem{}),
+			Condition: exists(),
+		}).
+		AsUser()
+}
+
+// Unlock a locked user. Fails with a 409 when the user is not locked
+func Unlock(client *dynamoclient.DynamoClient, email string) *dynamoclient.DynamoResponse {
+	return transition(client, email, []string{STATUS_LOCKED}, statusUpdate(STATUS_ACTIVE))
+}
+
+/*
+Suspend a user and revoke all their sessions. Fails with a 409 when the user
+is already suspended or scheduled for deletion
+*/
+func Suspend(client *dynamoclient.DynamoClient, email string) *dynamoclient.DynamoResponse {
+	return transition(
+		client,
+		email,
+		[]string{STATUS_PENDING_REGISTRATION, STATUS_ACTIVE, STATUS_LOCKED},
+		revoke(statusUpdate(STATUS_SUSPENDED)),
+	)
+}
+
+/*
+Lift the suspension of a user, or cancel their deletion while it is only
+scheduled. Fails with a 409 when the user is neither. The marker of a
+scheduled deletion is removed afterwards, one that is left behind is dropped
+by the purge since the user is no longer pending deletion
+*/
+func Unsuspend(vaultClient, usersClient *dynamoclient.DynamoClient, user types.PasswordCaddyUser) *dynamoclient.DynamoResponse {
+	values := statusUpdate(STATUS_ACTIVE)
+	values["DELETE_AFTER"] = dynamoclient.DynamoUpdateItem{Action: dynamoTypes.AttributeActionDelete}
+
+	response := transition(usersClient, user.UserId.Value, []string{STATUS_SUSPENDED, STATUS_PENDING_DELETION}, values)
+
+	if response.IsSuccess && user.DeleteAfter.Value != "" {
+		RemoveDeletionMarker(vaultClient, user.UserId.Value, user.DeleteAfter.Value)
+	}
+
+	return response
+}
+
+/*
+Schedule the deletion of a user after the grace period and revoke all their
+sessions. Fails with a 409 when the deletion is already scheduled. The marker
+is written first, so a deletion is never scheduled without one
+*/
+func ScheduleDeletion(vaultClient, usersClient *dynamoclient.DynamoClient, email string, now time.Time) *dynamoclient.DynamoResponse {
+	deleteAfter := now.Add(DeletionGrace()).UTC().Format(time.RFC3339)
+
+	response := vaultClient.Put(dynamoclient.DynamoPutRequest{
+		Key:     DELETIONS_KEY,
+		SortKey: DeletionKey(deleteAfter, email),
+		Values: map[string]interface{}{
+			"EMAIL":        email,
+			"DELETE_AFTER": deleteAfter,
+		},
+	})
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	values := revoke(statusUpdate(STATUS_PENDING_DELETION))
+	values["DELETE_AFTER"] = dynamoclient.DynamoUpdateItem{
+		Action: dynamoTypes.AttributeActionPut,
+		Value:  deleteAfter,
+	}
+
+	response = transition(
+		usersClient,
+		email,
+		[]string{STATUS_PENDING_REGISTRATION, STATUS_ACTIVE, STATUS_LOCKED, STATUS_SUSPENDED},
+		values,
+	)
+
+	if !response.IsSuccess {
+		RemoveDeletionMarker(vaultClient, email, deleteAfter)
+	}
+
+	return response
+}
+
+/*
+Find the markers of the scheduled deletions that are due. A marker is only
+a hint, IsDueDeletion tells whether the user is still to be deleted
+*/
+func ListDueDeletions(vaultClient *dynamoclient.DynamoClient, now time.Time) *dynamoclient.DynamoResponse {
+	return vaultClient.
+		Query(dynamoclient.DynamoQueryRequest{
+			Key:         DELETIONS_KEY,
+			SortKeyFrom: DELETION_PREFIX,
+			// "~" sorts after the emails of the deletions due at the same second
+			SortKeyTo: DeletionKey(now.UTC().Format(time.RFC3339), "~"),
+		}).
+		AsAccountDeletionMarkers()
+}
+
+// Whether the user of a deletion marker is still scheduled for the deletion of the marker, and it is due
+func IsDueDeletion(user types.PasswordCaddyUser, marker types.AccountDeletionMarker, now time.Time) bool {
+	if user.UserId.Value == "" || user.Status.Value != STATUS_PENDING_DELETION {
+		return false
+	}
+
+	if user.DeleteAfter.Value != marker.DeleteAfter.Value {
+		return false
+	}
+
+	return user.DeleteAfter.Value <= now.UTC().Format(time.RFC3339)
+}
+
+// Delete the marker of a scheduled deletion, once the user is purged or no longer pending deletion
+func RemoveDeletionMarker(vaultClient *dynamoclient.DynamoClient, email, deleteAfter string) *dynamoclient.DynamoResponse {
+	return vaultClient.Delete(dynamoclient.DynamoDeleteRequest{
+		Key:     DELETIONS_KEY,
+		SortKey: DeletionKey(deleteAfter, email),
+	})
+}
+
+/*
+Update a user whose status is one of the statuses. The updated user is
+returned as the response data
+*/
+func transition(client *dynamoclient.DynamoClient, email string, from []string, values map[string]dynamoclient.DynamoUpdateItem) *dynamoclient.DynamoResponse {
+	condition := &dynamoclient.DynamoCondition{
+		Expression: "attribute_exists(#user) AND #status IN (",
+		Names:      map[string]string{"#user": dynamoclient.PARTITION_KEY, "#status": "STATUS"},
+		Values:     map[string]interface{}{},
+	}
+
+	for i, status := range from {
+		placeholder := fmt.Sprintf(":from%d", i)
+
+		if i > 0 {
+			condition.Expression += ", "
+		}
+
+		condition.Expression += placeholder
+		condition.Values[placeholder] = status
+	}
+
+	condition.Expression += ")"
+
+	return client.
+		Update(dynamoclient.DyanamoUpdateRequest{
+			Key:       email,
+			Values:    values,
+			Condition: condition,
+		}).
+		AsUser()
+}
+
+func statusUpdate(status string) map[string]dynamoclient.DynamoUpdateItem {
+	return map[string]dynamoclient.DynamoUpdateItem{
+		"STATUS": {
+			Action: dynamoTypes.AttributeActionPut,
+			Value:  status,
+		},
+	}
+}
+
+/*
+Add revoking the sessions and removing the pending verification code to an
+update. Without a code the user can only log in again through a new login
+challenge sent to their email
+*/
+func revoke(values map[string]dynamoclient.DynamoUpdateItem) map[string]dynamoclient.DynamoUpdateItem {
+	values["SESSIONS_REVOKED_AT"] = dynamoclient.DynamoUpdateItem{
+		Action: dynamoTypes.AttributeActionPut,
+		Value:  time.Now().UTC().Format(time.RFC3339),
+	}
+
+	return removeCode(values)
+}
+
+func exists() *dynamoclient.DynamoCondition {
+	return &dynamoclient.DynamoCondition{
+		Expression: "attribute_exists(#user)",
+		Names:      map[string]string{"#user": dynamoclient.PARTITION_KEY},
+	}
+}
@@ -0,0 +1,204 @@
+package accounts
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"password-caddy/api/core/types"
+)
+
+/***** Keys *****/
+
+func TestIsValidLockToken(t *testing.T) {
+	tests := []struct {
+		token string
+		valid bool
+	}{
+		{strings.Repeat("ab", LOCK_TOKEN_BYTES), true},
+		{strings.Repeat("ab", LOCK_TOKEN_BYTES-1), false},
+		{strings.Repeat("zz", LOCK_TOKEN_BYTES), false},
+		{"", false},
+	}
+
+	for _, test := range tests {
+		if actual := IsValidLockToken(test.token); actual != test.valid {
+			t.Errorf("FAILED - TestIsValidLockToken | Token: %s | Actual: %v | Expected: %v", test.token, actual, test.valid)
+		}
+	}
+}
+
+func TestLockKeyHidesTheToken(t *testing.T) {
+	token := strings.Repeat("ab", LOCK_TOKEN_BYTES)
+	key := LockKey(token)
+
+	if !strings.HasPrefix(key, LOCK_PREFIX) || strings.Contains(key, token) {
+		t.Errorf("FAILED - TestLockKeyHidesTheToken | Actual: %s", key)
+	}
+}
+
+func TestLockLinkEscapesTheEmail(t *testing.T) {
+	link, err := url.Parse(LockLink("foo+bar@baz.com", "abc"))
+
+	if err != nil || link.Query().Get("email") != "foo+bar@baz.com" || link.Query().Get("token") != "abc" {
+		t.Errorf("FAILED - TestLockLinkEscapesTheEmail | Actual: %v | Error: %v", link, err)
+	}
+}
+
+/***** Evaluation *****/
+
+func TestIsExpired(t *testing.T) {
+	now := time.Now()
+
+	var token types.LockToken
+	token.ExpiresAt.Value = int(now.Add(time.Hour).Unix())
+
+	if IsExpired(token, now) {
+		t.Errorf("FAILED - TestIsExpired | Expected: a token expiring in an hour to be valid")
+	}
+
+	if !IsExpired(token, now.Add(2*time.Hour)) {
+		t.Errorf("FAILED - TestIsExpired | Expected: a token past its expiry to be expired")
+	}
+}
+
+func TestIsRevoked(t *testing.T) {
+	revokedAt := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	var user types.PasswordCaddyUser
+
+	if IsRevoked(user, revokedAt) {
+		t.Errorf("FAILED - TestIsRevoked | Expected: sessions of a user who never revoked them to be valid")
+	}
+
+	user.SessionsRevokedAt.Value = revokedAt.Format(time.RFC3339)
+
+	cases := []struct {
+		issuedAt time.Time
+		expected bool
+	}{
+		{revokedAt.Add(-time.Hour), true},
+		{revokedAt, true},
+		{revokedAt.Add(time.Second), false},
+	}
+
+	for _, c := range cases {
+		if actual := IsRevoked(user, c.issuedAt); actual != c.expected {
+			t.Errorf("FAILED - TestIsRevoked | IssuedAt: %s | Actual: %v | Expected: %v", c.issuedAt, actual, c.expected)
+		}
+	}
+
+	user.SessionsRevokedAt.Value = "not a date"
+
+	if !IsRevoked(user, revokedAt.Add(time.Hour)) {
+		t.Errorf("FAILED - TestIsRevoked | Expected: an unreadable revocation to revoke every session")
+	}
+}
+
+/***** Status *****/
+
+func TestIsBlocked(t *testing.T) {
+	tests := []struct {
+		status  string
+		blocked bool
+	}{
+		{"", false},
+		{STATUS_PENDING_REGISTRATION, false},
+		{STATUS_ACTIVE, false},
+		{STATUS_LOCKED, true},
+		{STATUS_SUSPENDED, true},
+		{STATUS_PENDING_DELETION, true},
+	}
+
+	for _, test := range tests {
+		var user types.PasswordCaddyUser
+		user.Status.Value = test.status
+
+		if actual := IsBlocked(user); actual != test.blocked {
+			t.Errorf("FAILED - TestIsBlocked | Status: %s | Actual: %v | Expected: %v", test.status, actual, test.blocked)
+		}
+	}
+}
+
+func TestBlockedMessage(t *testing.T) {
+	tests := []struct {
+		status  string
+		message string
+	}{
+		{STATUS_LOCKED, "Account is locked"},
+		{STATUS_SUSPENDED, "Account is suspended"},
+		{STATUS_PENDING_DELETION, "Account is scheduled for deletion"},
+	}
+
+	for _, test := range tests {
+		var user types.PasswordCaddyUser
+		user.Status.Value = test.status
+
+		if actual := BlockedMessage(user); actual != test.message {
+			t.Errorf("FAILED - TestBlockedMessage | Status: %s | Actual: %s | Expected: %s", test.status, actual, test.message)
+		}
+	}
+}
+
+func TestDeletionKeySortsByDeleteAfter(t *testing.T) {
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	to := DeletionKey(now.Format(time.RFC3339), "~")
+
+	tests := []struct {
+		deleteAfter string
+		due         bool
+	}{
+		{"2022-03-01T11:59:59Z", true},
+		{"2022-03-01T12:00:00Z", true},
+		{"2022-03-01T12:00:01Z", false},
+	}
+
+	for _, test := range tests {
+		key := DeletionKey(test.deleteAfter, "foo@bar.com")
+
+		if actual := key >= DELETION_PREFIX && key <= to; actual != test.due {
+			t.Errorf("FAILED - TestDeletionKeySortsByDeleteAfter | Delete after: %s | Actual: %v | Expected: %v", test.deleteAfter, actual, test.due)
+		}
+	}
+}
+
+func TestIsDueDeletion(t *testing.T) {
+	now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	var marker types.AccountDeletionMarker
+	marker.Email.Value = "foo@bar.com"
+	marker.DeleteAfter.Value = "2022-03-01T00:00:00Z"
+
+	user := func(status, deleteAfter string) types.PasswordCaddyUser {
+		var user types.PasswordCaddyUser
+		user.UserId.Value = "foo@bar.com"
+		user.Status.Value = status
+		user.DeleteAfter.Value = deleteAfter
+		return user
+	}
+
+	tests := []struct {
+		name     string
+		user     types.PasswordCaddyUser
+		expected bool
+	}{
+		{"Due", user(STATUS_PENDING_DELETION, "2022-03-01T00:00:00Z"), true},
+		{"Purged", types.PasswordCaddyUser{}, false},
+		{"Unsuspended", user(STATUS_ACTIVE, ""), false},
+		{"Rescheduled", user(STATUS_PENDING_DELETION, "2022-03-02T00:00:00Z"), false},
+	}
+
+	for _, test := range tests {
+		if actual := IsDueDeletion(test.user, marker, now); actual != test.expected {
+			t.Errorf("FAILED - TestIsDueDeletion - %s | Actual: %v | Expected: %v", test.name, actual, test.expected)
+		}
+	}
+
+	// The user is only purged once the grace period is over
+	marker.DeleteAfter.Value = "2022-03-02T00:00:00Z"
+
+	if IsDueDeletion(user(STATUS_PENDING_DELETION, "2022-03-02T00:00:00Z"), marker, now) {
+		t.Errorf("FAILED - TestIsDueDeletion - Grace period | Expected: the deletion not to be due yet")
+	}
+}
@@ -0,0 +1,166 @@
+package accounts
+
+import (
+	"crypto/subtle"
+	"errors"
+	"time"
+
+	appConfig "password-caddy/api/core/config"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/dynamoclient"
+
+	dynamoTypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+var (
+	ErrInvalidCode     = errors.New("Unauthorized login attempt")
+	ErrExpiredCode     = errors.New("Verification code has expired")
+	ErrTooManyAttempts = errors.New("Too many invalid verification codes")
+)
+
+/********** CONFIG **********/
+
+// How long the code of a login challenge can be used
+func CodeTTL() time.Duration {
+	minutes := appConfig.Get("LOGIN_CODE_TTL_MINUTES", "10").ToInt64()
+	return time.Duration(minutes) * time.Minute
+}
+
+// Invalid codes a login challenge accepts before the user has to ask for a new one
+func MaxCodeAttempts() int {
+	return int(appConfig.Get("LOGIN_CODE_MAX_ATTEMPTS", "5").ToInt64())
+}
+
+/********** EVALUATION **********/
+
+/*
+Check the code of a login verification against the one of the last login
+challenge. Users who do not exist or have no code never match, not even an
+empty code. Codes saved without an expiry are expired
+*/
+func CheckCode(user types.PasswordCaddyUser, code string, now time.Time) error {
+	if code == "" || user.UserId.Value == "" || user.VerificationCode.Value == "" {
+		return ErrInvalidCode
+	}
+
+	if user.VerificationAttempts.Value >= MaxCodeAttempts() {
+		return ErrTooManyAttempts
+	}
+
+	if now.Unix() >= int64(user.VerificationCodeExpiresAt.Value) {
+		return ErrExpiredCode
+	}
+
+	if subtle.ConstantTimeCompare([]byte(user.VerificationCode.Value), []byte(code)) != 1 {
+		return ErrInvalidCode
+	}
+
+	return nil
+}
+
+/********** OPERATIONS **********/
+
+// Save the code of a new login challenge, which replaces the previous one and its attempts
+func SaveCode(client *dynamoclient.DynamoClient, email, code string, now time.Time) *dynamoclient.DynamoResponse {
+	return client.Update(dynamoclient.DyanamoUpdateRequest{
+		Key:    email,
+		Values: codeUpdate(code, now),
+	})
+}
+
+/*
+Count an attempt on the code of a user before it is compared, so concurrent
+guesses can never compare more than MaxCodeAttempts codes. Fails with a 429
+when the attempts are used up or the code is gone
+*/
+func ReserveCodeAttempt(client *dynamoclient.DynamoClient, email string) *dynamoclient.DynamoResponse {
+	response := client.
+		Update(dynamoclient.DyanamoUpdateRequest{
+			Key: email,
+			Values: map[string]dynamoclient.DynamoUpdateItem{
+				"VERIFICATION_ATTEMPTS": {
+					Action: dynamoTypes.AttributeActionAdd,
+					Value:  1,
+				},
+			},
+			Condition: &dynamoclient.DynamoCondition{
+				Expression: "attribute_exists(#code) AND (attribute_not_exists(#attempts) OR #attempts < :max)",
+				Names: map[string]string{
+					"#code":     "VERIFICATION_CODE",
+					"#attempts": "VERIFICATION_ATTEMPTS",
+				},
+				Values: map[string]interface{}{
+					":max": MaxCodeAttempts(),
+				},
+			},
+		}).
+		AsUser()
+
+	if !response.IsSuccess && response.Error.StatusCode == 409 {
+		return dynamoclient.Failure(types.PasswordCaddyError{
+			StatusCode: 429,
+			Message:    ErrTooManyAttempts.Error(),
+		})
+	}
+
+	return response
+}
+
+/*
+Remove the code of a user once it was used to log in. Only succeeds while the
+code is still the stored one, a replayed code fails with a 401
+*/
+func ConsumeCode(client *dynamoclient.DynamoClient, email, code string) *dynamoclient.DynamoResponse {
+	response := client.
+		Update(consumeCode(email, code)).
+		AsUser()
+
+	if !response.IsSuccess && response.Error.StatusCode == 409 {
+		return dynamoclient.Failure(types.PasswordCaddyError{
+			StatusCode: 401,
+			Message:    ErrInvalidCode.Error(),
+		})
+	}
+
+	return response
+}
+
+func codeUpdate(code string, now time.Time) map[string]dynamoclient.DynamoUpdateItem {
+	return map[string]dynamoclient.DynamoUpdateItem{
+		"VERIFICATION_CODE": {
+			Action: dynamoTypes.AttributeActionPut,
+			Value:  code,
+		},
+		"VERIFICATION_CODE_EXPIRES_AT": {
+			Action: dynamoTypes.AttributeActionPut,
+			Value:  now.Add(CodeTTL()).Unix(),
+		},
+		"VERIFICATION_ATTEMPTS": {
+			Action: dynamoTypes.AttributeActionPut,
+			Value:  0,
+		},
+	}
+}
+
+func consumeCode(email, code string) dynamoclient.DyanamoUpdateRequest {
+	return dynamoclient.DyanamoUpdateRequest{
+		Key:    email,
+		Values: removeCode(map[string]dynamoclient.DynamoUpdateItem{}),
+		Condition: &dynamoclient.DynamoCondition{
+			Expression: "#code = :code",
+			Names:      map[string]string{"#code": "VERIFICATION_CODE"},
+			Values:     map[string]interface{}{":code": code},
+		},
+	}
+}
+
+// Add removing the code of the last login challenge to an update
+func removeCode(values map[string]dynamoclient.DynamoUpdateItem) map[string]dynamoclient.DynamoUpdateItem {
+	for _, attribute := range []string{"VERIFICATION_CODE", "VERIFICATION_CODE_EXPIRES_AT", "VERIFICATION_ATTEMPTS"} {
+		values[attribute] = dynamoclient.DynamoUpdateItem{
+			Action: dynamoTypes.AttributeActionDelete,
+		}
+	}
+
+	return values
+}
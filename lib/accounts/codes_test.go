@@ -0,0 +1,159 @@
+package accounts
+
+import (
+	"testing"
+	"time"
+
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/dynamoclient"
+
+	dynamoTypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func userWithCode(code string, now time.Time) types.PasswordCaddyUser {
+	var user types.PasswordCaddyUser
+	user.UserId.Value = "foo@bar.com"
+	user.VerificationCode.Value = code
+	user.VerificationCodeExpiresAt.Value = int(now.Add(CodeTTL()).Unix())
+	return user
+}
+
+/***** Evaluation *****/
+
+func TestCheckCode(t *testing.T) {
+	now := time.Now()
+	user := userWithCode("123456", now)
+
+	if err := CheckCode(user, "123456", now); err != nil {
+		t.Errorf("FAILED - TestCheckCode | Actual: %v | Expected: the stored code to match", err)
+	}
+
+	if err := CheckCode(user, "654321", now); err != ErrInvalidCode {
+		t.Errorf("FAILED - TestCheckCode | Actual: %v | Expected: %v", err, ErrInvalidCode)
+	}
+}
+
+func TestCheckCodeRejectsEmptyCode(t *testing.T) {
+	now := time.Now()
+
+	if err := CheckCode(userWithCode("123456", now), "", now); err != ErrInvalidCode {
+		t.Errorf("FAILED - TestCheckCodeRejectsEmptyCode | Actual: %v | Expected: %v", err, ErrInvalidCode)
+	}
+
+	// A user without a code must not match an empty one
+	if err := CheckCode(userWithCode("", now), "", now); err != ErrInvalidCode {
+		t.Errorf("FAILED - TestCheckCodeRejectsEmptyCode - No stored code | Actual: %v | Expected: %v", err, ErrInvalidCode)
+	}
+}
+
+func TestCheckCodeRejectsUnknownEmail(t *testing.T) {
+	// Getting an email that is not registered gives a zero user
+	var user types.PasswordCaddyUser
+
+	for _, code := range []string{"", "123456"} {
+		if err := CheckCode(user, code, time.Now()); err != ErrInvalidCode {
+			t.Errorf("FAILED - TestCheckCodeRejectsUnknownEmail | Code: %q | Actual: %v | Expected: %v", code, err, ErrInvalidCode)
+		}
+	}
+}
+
+func TestCheckCodeRejectsExpiredCode(t *testing.T) {
+	now := time.Now()
+	user := userWithCode("123456", now)
+
+	if err := CheckCode(user, "123456", now.Add(CodeTTL())); err != ErrExpiredCode {
+		t.Errorf("FAILED - TestCheckCodeRejectsExpiredCode | Actual: %v | Expected: %v", err, ErrExpiredCode)
+	}
+
+	user.VerificationCodeExpiresAt.Value = 0
+
+	if err := CheckCode(user, "123456", now); err != ErrExpiredCode {
+		t.Errorf("FAILED - TestCheckCodeRejectsExpiredCode - No expiry | Actual: %v | Expected: %v", err, ErrExpiredCode)
+	}
+}
+
+func TestCheckCodeRejectsUsedUpAttempts(t *testing.T) {
+	now := time.Now()
+	user := userWithCode("123456", now)
+	user.VerificationAttempts.Value = MaxCodeAttempts()
+
+	if err := CheckCode(user, "123456", now); err != ErrTooManyAttempts {
+		t.Errorf("FAILED - TestCheckCodeRejectsUsedUpAttempts | Actual: %v | Expected: %v", err, ErrTooManyAttempts)
+	}
+}
+
+/***** Operations *****/
+
+func TestCodeUpdateResetsAttempts(t *testing.T) {
+	now := time.Now()
+	values := codeUpdate("123456", now)
+
+	if values["VERIFICATION_CODE"].Value != "123456" {
+		t.Errorf("FAILED - TestCodeUpdateResetsAttempts - Code | Actual: %v | Expected: 123456", values["VERIFICATION_CODE"].Value)
+	}
+
+	if values["VERIFICATION_CODE_EXPIRES_AT"].Value != now.Add(CodeTTL()).Unix() {
+		t.Errorf("FAILED - TestCodeUpdateResetsAttempts - Expiry | Actual: %v", values["VERIFICATION_CODE_EXPIRES_AT"].Value)
+	}
+
+	if values["VERIFICATION_ATTEMPTS"].Value != 0 {
+		t.Errorf("FAILED - TestCodeUpdateResetsAttempts - Attempts | Actual: %v | Expected: 0", values["VERIFICATION_ATTEMPTS"].Value)
+	}
+}
+
+func TestEmptyCodeIsRejectedAfterRevokingSessions(t *testing.T) {
+	now := time.Now()
+	user := userWithCode("123456", now)
+	user.Status.Value = STATUS_ACTIVE
+
+	values := revoke(map[string]dynamoclient.DynamoUpdateItem{})
+
+	for _, attribute := range []string{"VERIFICATION_CODE", "VERIFICATION_CODE_EXPIRES_AT", "VERIFICATION_ATTEMPTS"} {
+		if values[attribute].Action != dynamoTypes.AttributeActionDelete {
+			t.Errorf("FAILED - TestEmptyCodeIsRejectedAfterRevokingSessions | Attribute: %s | Expected: to be removed", attribute)
+		}
+	}
+
+	// A force logout leaves the user active without a code
+	user.VerificationCode.Value = ""
+	user.VerificationCodeExpiresAt.Value = 0
+
+	if IsBlocked(user) {
+		t.Fatalf("FAILED - TestEmptyCodeIsRejectedAfterRevokingSessions | Expected: the user to stay active")
+	}
+
+	if err := CheckCode(user, "", now); err != ErrInvalidCode {
+		t.Errorf("FAILED - TestEmptyCodeIsRejectedAfterRevokingSessions | Actual: %v | Expected: %v", err, ErrInvalidCode)
+	}
+}
+
+func TestReusedCodeIsRejected(t *testing.T) {
+	now := time.Now()
+	request := consumeCode("foo@bar.com", "123456")
+
+	// The code is only removed while it is still the stored one
+	if request.Condition == nil || request.Condition.Values[":code"] != "123456" {
+		t.Fatalf("FAILED - TestReusedCodeIsRejected | Actual: %+v | Expected: a condition on the used code", request.Condition)
+	}
+
+	user := userWithCode("123456", now)
+
+	for attribute, value := range request.Values {
+		if value.Action != dynamoTypes.AttributeActionDelete {
+			t.Errorf("FAILED - TestReusedCodeIsRejected | Attribute: %s | Expected: to be removed", attribute)
+		}
+
+		switch attribute {
+		case "VERIFICATION_CODE":
+			user.VerificationCode.Value = ""
+		case "VERIFICATION_CODE_EXPIRES_AT":
+			user.VerificationCodeExpiresAt.Value = 0
+		case "VERIFICATION_ATTEMPTS":
+			user.VerificationAttempts.Value = 0
+		}
+	}
+
+	if err := CheckCode(user, "123456", now); err != ErrInvalidCode {
+		t.Errorf("FAILED - TestReusedCodeIsRejected | Actual: %v | Expected: %v", err, ErrInvalidCode)
+	}
+}
@@ -0,0 +1,221 @@
+package accounts
+
+import (
+	"time"
+
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/blobstore"
+	"password-caddy/api/lib/dynamoclient"
+	"password-caddy/api/lib/emergency"
+	"password-caddy/api/lib/orgs"
+	"password-caddy/api/lib/sends"
+	"password-caddy/api/lib/shares"
+	"password-caddy/api/lib/vault"
+)
+
+/*
+Get the ids of the organizations the user is the last confirmed owner of. Their
+account can not be deleted, as the organizations would be left without owner
+*/
+func LastOwnedOrgs(client *dynamoclient.DynamoClient, email string) *dynamoclient.DynamoResponse {
+	response := orgs.ListUserOrgs(client, email)
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	orgIds := []string{}
+
+	for _, userOrg := range response.Data.([]orgs.UserOrg) {
+		member := userOrg.Member
+
+		if member.Role.Value != orgs.ROLE_OWNER || member.Status.Value != orgs.STATUS_CONFIRMED {
+			continue
+		}
+
+		members := orgs.ListMembers(client, member.OrgId.Value)
+
+		if !members.IsSuccess {
+			return members
+		}
+
+		if orgs.IsLastOwner(members.Data.([]types.OrgMember), email) {
+			orgIds = append(orgIds, member.OrgId.Value)
+		}
+	}
+
+	return dynamoclient.SuccessWithValue(orgIds)
+}
+
+/*
+Delete a user and everything they own. Memberships, emergency accesses and
+shares are removed from the other partitions as well, sends and attachments
+together with their files. The user is removed from the users table last, so
+a purge that failed halfway is picked up again by the next run. Fails with a
+409 when the user became the last owner of an organization
+*/
+func Purge(vaultClient, usersClient *dynamoclient.DynamoClient, store blobstore.BlobStore, email string) *dynamoclient.DynamoResponse {
+	response := LastOwnedOrgs(vaultClient, email)
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	if orgIds := response.Data.([]string); len(orgIds) > 0 {
+		return dynamoclient.Failure(types.PasswordCaddyError{
+			StatusCode: 409,
+			Message:    "User is the last owner of an organization",
+			Details:    orgIds,
+		})
+	}
+
+	if response = leaveOrgs(vaultClient, email); !response.IsSuccess {
+		return response
+	}
+
+	if response = removeEmergencyAccesses(vaultClient, email); !response.IsSuccess {
+		return response
+	}
+
+	if response = removeShares(vaultClient, email); !response.IsSuccess {
+		return response
+	}
+
+	if response = deleteSends(vaultClient, store, email); !response.IsSuccess {
+		return response
+	}
+
+	if response = deleteAttachments(vaultClient, store, email); !response.IsSuccess {
+		return response
+	}
+
+	if response = deletePartition(vaultClient, email); !response.IsSuccess {
+		return response
+	}
+
+	return usersClient.Delete(dynamoclient.DynamoDeleteRequest{Key: email})
+}
+
+func leaveOrgs(client *dynamoclient.DynamoClient, email string) *dynamoclient.DynamoResponse {
+	response := orgs.ListUserOrgs(client, email)
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	for _, userOrg := range response.Data.([]orgs.UserOrg) {
+		if removed := orgs.Remove(client, userOrg.Member); !removed.IsSuccess && removed.Error.StatusCode != 409 {
+			return removed
+		}
+	}
+
+	return dynamoclient.Success()
+}
+
+// The user's emergency contacts and the users who made them one
+func removeEmergencyAccesses(client *dynamoclient.DynamoClient, email string) *dynamoclient.DynamoResponse {
+	accesses := []types.EmergencyAccess{}
+
+	for _, list := range []func(*dynamoclient.DynamoClient, string) *dynamoclient.DynamoResponse{emergency.ListTrusted, emergency.ListGranted} {
+		response := list(client, email)
+
+		if !response.IsSuccess {
+			return response
+		}
+
+		accesses = append(accesses, response.Data.([]types.EmergencyAccess)...)
+	}
+
+	for _, access := range accesses {
+		if removed := emergency.Remove(client, access); !removed.IsSuccess && removed.Error.StatusCode != 409 {
+			return removed
+		}
+	}
+
+	return dynamoclient.Success()
+}
+
+// The shares of the user's items and the shares the user received
+func removeShares(client *dynamoclient.DynamoClient, email string) *dynamoclient.DynamoResponse {
+	response := client.
+		Query(dynamoclient.DynamoQueryRequest{
+			Key:           email,
+			SortKeyPrefix: shares.SHARE_PREFIX,
+		}).
+		AsItemShares()
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	owned := response.Data.([]types.ItemShare)
+
+	response = shares.ListReceived(client, email)
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	for _, share := range append(owned, response.Data.([]types.ItemShare)...) {
+		if removed := shares.Remove(client, share); !removed.IsSuccess && removed.Error.StatusCode != 409 {
+			return removed
+		}
+	}
+
+	return dynamoclient.Success()
+}
+
+func deleteSends(client *dynamoclient.DynamoClient, store blobstore.BlobStore, email string) *dynamoclient.DynamoResponse {
+	response := sends.List(client, email, time.Now())
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	for _, send := range response.Data.([]types.Send) {
+		if deleted := sends.Delete(client, store, send); !deleted.IsSuccess {
+			return deleted
+		}
+	}
+
+	return dynamoclient.Success()
+}
+
+// The files of the attachments of the user's items
+func deleteAttachments(client *dynamoclient.DynamoClient, store blobstore.BlobStore, email string) *dynamoclient.DynamoResponse {
+	response := vault.ListItems(client, email)
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	for _, item := range response.Data.([]types.VaultItem) {
+		if purged := vault.PurgeAttachments(client, store, email, item.ItemId.Value); !purged.IsSuccess {
+			return purged
+		}
+	}
+
+	return dynamoclient.Success()
+}
+
+// Everything left in the user's partition, from vault items to security events
+func deletePartition(client *dynamoclient.DynamoClient, email string) *dynamoclient.DynamoResponse {
+	response := client.
+		Query(dynamoclient.DynamoQueryRequest{Key: email}).
+		AsItemReferences()
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	deletes := []dynamoclient.DynamoDeleteRequest{}
+
+	for _, reference := range response.Data.([]types.ItemReference) {
+		deletes = append(deletes, dynamoclient.DynamoDeleteRequest{
+			Key:     email,
+			SortKey: reference.ItemKey.Value,
+		})
+	}
+
+	return client.BatchWrite(dynamoclient.DynamoBatchWriteRequest{Deletes: deletes})
+}
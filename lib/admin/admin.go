@@ -0,0 +1,286 @@
+package admin
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/dynamoclient"
+	"password-caddy/api/lib/result"
+
+	"github.com/google/uuid"
+)
+
+/*
+Keys of the audit trail. Every user has their own trail in the partition
+AUDIT#<user id>, apart from the user's partition so it outlives their deletion
+*/
+const (
+	AUDIT_PREFIX = "AUDIT#"
+	ENTRY_PREFIX = "ENTRY#"
+)
+
+// Times in the sort keys have a fixed width so the keys sort by time
+const ENTRY_TIME_FORMAT = "2006-01-02T15:04:05.000000000Z"
+
+// Actions support can take on a user. Looking at a user is an action as well
+const (
+	ACTION_LOOKUP_USER         = "lookupUser"
+	ACTION_VIEW_EVENTS         = "viewSecurityEvents"
+	ACTION_VIEW_AUDIT          = "viewAuditTrail"
+	ACTION_FORCE_LOGOUT        = "forceLogout"
+	ACTION_UNLOCK              = "unlock"
+	ACTION_SUSPEND             = "suspend"
+	ACTION_UNSUSPEND           = "unsuspend"
+	ACTION_RESEND_VERIFICATION = "resendVerification"
+	ACTION_SCHEDULE_DELETION   = "scheduleDeletion"
+	ACTION_PURGE               = "purge"
+)
+
+// Operator of the actions the scheduled jobs take, i.e. purging a deleted user
+const SYSTEM_OPERATOR_ID = "system"
+
+const (
+	MIN_REASON_LENGTH = 10
+	MAX_REASON_LENGTH = 500
+	DEFAULT_PAGE_SIZE = 50
+	MAX_PAGE_SIZE     = 200
+)
+
+/*
+An action of an operator on a user. The reason is required, i.e the id of the
+support ticket the action was taken for
+*/
+type Entry struct {
+	OperatorId string
+	Action     string
+	Reason     string
+	IpAddress  string
+}
+
+// The body of the admin actions that change a user
+type ActionRequest struct {
+	Reason string `json:"reason"`
+}
+
+// Narrows down the audit trail of a user. Cursor continues a previous page
+type Filter struct {
+	Cursor string
+	Limit  int
+}
+
+/*
+What support sees of a user. EmailVerification is the SES verification status
+of the email address
+*/
+type UserResponse struct {
+	Email             string `json:"email"`
+	Status            string `json:"status"`
+	EmailVerification string `json:"emailVerification,omitempty"`
+	SessionsRevokedAt string `json:"sessionsRevokedAt,omitempty"`
+	DeleteAfter       string `json:"deleteAfter,omitempty"`
+}
+
+type EntryResponse struct {
+	Id         string `json:"id"`
+	OperatorId string `json:"operatorId"`
+	Action     string `json:"action"`
+	Reason     string `json:"reason"`
+	IpAddress  string `json:"ipAddress,omitempty"`
+	Date       string `json:"date"`
+}
+
+// Entries from the newest to the oldest. Cursor is only set when there may be more
+type EntriesResponse struct {
+	Entries []EntryResponse `json:"entries"`
+	Cursor  string          `json:"cursor,omitempty"`
+}
+
+// A page of entries and the sort key of its last entry when there may be more
+type Page struct {
+	Entries []types.AdminAuditEntry
+	LastKey string
+}
+
+/********** KEYS **********/
+
+func PartitionKey(userId string) string {
+	return AUDIT_PREFIX + userId
+}
+
+func EntryKey(createdAt time.Time, entryId string) string {
+	return ENTRY_PREFIX + createdAt.UTC().Format(ENTRY_TIME_FORMAT) + "#" + entryId
+}
+
+// The cursor is the sort key of the last entry of the page, which the next page starts after
+func EncodeCursor(lastKey string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(lastKey))
+}
+
+func DecodeCursor(cursor string) (string, error) {
+	value, err := base64.RawURLEncoding.DecodeString(cursor)
+
+	if err != nil || !strings.HasPrefix(string(value), ENTRY_PREFIX) {
+		return "", errors.New("Invalid audit cursor")
+	}
+
+	return string(value), nil
+}
+
+/********** VALIDATION **********/
+
+// Users are looked up by their email address, which is their id
+func ValidateEmail(email string) error {
+	if address, err := mail.ParseAddress(email); err != nil || address.Address != email {
+		return errors.New("User id must be an email address")
+	}
+
+	return nil
+}
+
+func ValidateReason(reason string) error {
+	length := len(strings.TrimSpace(reason))
+
+	if length < MIN_REASON_LENGTH || length > MAX_REASON_LENGTH {
+		return fmt.Errorf("Reason must be between %d and %d characters", MIN_REASON_LENGTH, MAX_REASON_LENGTH)
+	}
+
+	return nil
+}
+
+func (request ActionRequest) Validate() error {
+	return ValidateReason(request.Reason)
+}
+
+// Build the filter out of the cursor and limit query parameters
+func ParseFilter(params map[string]string) (Filter, error) {
+	filter := Filter{
+		Cursor: params["cursor"],
+		Limit:  DEFAULT_PAGE_SIZE,
+	}
+
+	if filter.Cursor != "" {
+		if _, err := DecodeCursor(filter.Cursor); err != nil {
+			return filter, err
+		}
+	}
+
+	if params["limit"] != "" {
+		limit, err := strconv.Atoi(params["limit"])
+
+		if err != nil || limit < 1 || limit > MAX_PAGE_SIZE {
+			return filter, fmt.Errorf("Limit must be between 1 and %d", MAX_PAGE_SIZE)
+		}
+
+		filter.Limit = limit
+	}
+
+	return filter, nil
+}
+
+/********** RESPONSES **********/
+
+func ToUserResponse(user types.PasswordCaddyUser, emailVerification string) UserResponse {
+	return UserResponse{
+		Email:             user.UserId.Value,
+		Status:            user.Status.Value,
+		EmailVerification: emailVerification,
+		SessionsRevokedAt: user.SessionsRevokedAt.Value,
+		DeleteAfter:       user.DeleteAfter.Value,
+	}
+}
+
+func ToEntryResponse(entry types.AdminAuditEntry) EntryResponse {
+	return EntryResponse{
+		Id:         entry.EntryId.Value,
+		OperatorId: entry.OperatorId.Value,
+		Action:     entry.Action.Value,
+		Reason:     entry.Reason.Value,
+		IpAddress:  entry.IpAddress.Value,
+		Date:       entry.CreatedAt.Value,
+	}
+}
+
+func ToEntriesResponse(page Page) EntriesResponse {
+	response := EntriesResponse{Entries: []EntryResponse{}}
+
+	for _, entry := range page.Entries {
+		response.Entries = append(response.Entries, ToEntryResponse(entry))
+	}
+
+	if page.LastKey != "" {
+		response.Cursor = EncodeCursor(page.LastKey)
+	}
+
+	return response
+}
+
+/*
+Build the response of an action that could not be audited. Actions are audited
+before they are taken, so none is ever taken without its entry
+*/
+func AuditFailureResult() *result.Result {
+	return result.Failure(503, "Failed to write the audit trail, no action was taken")
+}
+
+/********** OPERATIONS **********/
+
+/*
+Write an entry to the audit trail of a user. Entries are never overwritten,
+updated or deleted and do not expire
+*/
+func Record(client *dynamoclient.DynamoClient, userId string, entry Entry) *dynamoclient.DynamoResponse {
+	now := time.Now().UTC()
+	entryId := uuid.NewString()
+
+	return client.Put(dynamoclient.DynamoPutRequest{
+		Key:     PartitionKey(userId),
+		SortKey: EntryKey(now, entryId),
+		Values: map[string]interface{}{
+			"ENTRY_ID":    entryId,
+			"TARGET_ID":   userId,
+			"OPERATOR_ID": entry.OperatorId,
+			"ACTION":      entry.Action,
+			"REASON":      strings.TrimSpace(entry.Reason),
+			"IP_ADDRESS":  entry.IpAddress,
+			"CREATED_AT":  now.Format(time.RFC3339),
+		},
+		Condition: &dynamoclient.DynamoCondition{
+			Expression: "attribute_not_exists(#sk)",
+			Names:      map[string]string{"#sk": dynamoclient.SORT_KEY},
+		},
+	})
+}
+
+// Get a page of the audit trail of a user from the newest to the oldest entry
+func List(client *dynamoclient.DynamoClient, userId string, filter Filter) *dynamoclient.DynamoResponse {
+	request := dynamoclient.DynamoQueryRequest{
+		Key:           PartitionKey(userId),
+		SortKeyPrefix: ENTRY_PREFIX,
+		Descending:    true,
+		Limit:         int32(filter.Limit),
+	}
+
+	if filter.Cursor != "" {
+		request.StartAfter, _ = DecodeCursor(filter.Cursor)
+	}
+
+	response := client.Query(request).AsAdminAuditEntries()
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	page := Page{Entries: response.Data.([]types.AdminAuditEntry)}
+
+	if len(page.Entries) > 0 && len(page.Entries) >= filter.Limit {
+		page.LastKey = page.Entries[len(page.Entries)-1].ItemKey.Value
+	}
+
+	return dynamoclient.SuccessWithValue(page)
+}
@@ -0,0 +1,84 @@
+package admin
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateEmail(t *testing.T) {
+	tests := []struct {
+		email string
+		valid bool
+	}{
+		{"jane@example.com", true},
+		{"", false},
+		{"jane", false},
+		{"Jane <jane@example.com>", false},
+	}
+
+	for _, test := range tests {
+		if err := ValidateEmail(test.email); (err == nil) != test.valid {
+			t.Errorf("FAILED - TestValidateEmail | Email: %s | Error: %v | Expected valid: %v", test.email, err, test.valid)
+		}
+	}
+}
+
+func TestValidateReason(t *testing.T) {
+	tests := []struct {
+		reason string
+		valid  bool
+	}{
+		{"Ticket #4821", true},
+		{"", false},
+		{"   short   ", false},
+		{strings.Repeat("a", MAX_REASON_LENGTH), true},
+		{strings.Repeat("a", MAX_REASON_LENGTH+1), false},
+	}
+
+	for _, test := range tests {
+		if err := ValidateReason(test.reason); (err == nil) != test.valid {
+			t.Errorf("FAILED - TestValidateReason | Length: %d | Error: %v | Expected valid: %v", len(test.reason), err, test.valid)
+		}
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	key := EntryKey(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), "id")
+	actual, err := DecodeCursor(EncodeCursor(key))
+
+	if err != nil || actual != key {
+		t.Errorf("FAILED - TestCursorRoundTrip | Actual: %s | Error: %v | Expected: %s", actual, err, key)
+	}
+
+	if _, err := DecodeCursor(EncodeCursor("EVENT#1")); err == nil {
+		t.Errorf("FAILED - TestCursorRoundTrip | Expected: a cursor outside the audit trail to be rejected")
+	}
+}
+
+func TestEntryKeysSortByTime(t *testing.T) {
+	earlier := EntryKey(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), "b")
+	later := EntryKey(time.Date(2024, 1, 2, 3, 4, 5, 500, time.UTC), "a")
+
+	if earlier >= later {
+		t.Errorf("FAILED - TestEntryKeysSortByTime | Earlier: %s | Later: %s", earlier, later)
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	filter, err := ParseFilter(map[string]string{})
+
+	if err != nil || filter.Limit != DEFAULT_PAGE_SIZE || filter.Cursor != "" {
+		t.Errorf("FAILED - TestParseFilter | Actual: %+v | Error: %v | Expected: the default page", filter, err)
+	}
+
+	for _, limit := range []string{"0", "abc", "201"} {
+		if _, err := ParseFilter(map[string]string{"limit": limit}); err == nil {
+			t.Errorf("FAILED - TestParseFilter | Limit: %s | Expected: an error", limit)
+		}
+	}
+
+	if _, err := ParseFilter(map[string]string{"cursor": "!!"}); err == nil {
+		t.Errorf("FAILED - TestParseFilter | Expected: an invalid cursor to be rejected")
+	}
+}
@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// Scope of the tokens of support staff, which the admin routes require
+const ADMIN_SCOPE = "password-caddy/admin"
+
+/*
+Get the id (email address) of the authenticated caller from the authorizer
+context API Gateway attaches to the request. Supports the HTTP API lambda
+and JWT authorizers as well as a flat REST API authorizer context.
+
+Returns false when the request was not authorized
+*/
+func CallerId(event events.APIGatewayProxyRequest) (string, bool) {
+	authorizer := claims(event)
+
+	for _, key := range []string{"userId", "email"} {
+		if value, ok := authorizer[key].(string); ok && value != "" {
+			return value, true
+		}
+	}
+
+	return "", false
+}
+
+/*
+Get the id of the support operator calling an admin route. The token of the
+caller must carry the admin scope in its space separated scope claim, which
+user tokens never do.
+
+Returns false when the request was not authorized for the admin routes
+*/
+func OperatorId(event events.APIGatewayProxyRequest) (string, bool) {
+	operatorId, ok := CallerId(event)
+
+	if !ok || !HasScope(event, ADMIN_SCOPE) {
+		return "", false
+	}
+
+	return operatorId, true
+}
+
+// Check that the token of the caller was granted the scope
+func HasScope(event events.APIGatewayProxyRequest, scope string) bool {
+	value, _ := claims(event)["scope"].(string)
+
+	for _, granted := range strings.Fields(value) {
+		if granted == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+Get the IP address the request came from. Payload format 2.0 events, the
+default of HTTP APIs, have no identity, their address is the last one of
+X-Forwarded-For, which API Gateway appends to whatever the client sent
+*/
+func SourceIp(event events.APIGatewayProxyRequest) string {
+	if event.RequestContext.Identity.SourceIP != "" {
+		return event.RequestContext.Identity.SourceIP
+	}
+
+	forwarded := strings.Split(header(event, "X-Forwarded-For"), ",")
+
+	return strings.TrimSpace(forwarded[len(forwarded)-1])
+}
+
+// Get the user agent of the request, from the User-Agent header of payload format 2.0 events
+func UserAgent(event events.APIGatewayProxyRequest) string {
+	if event.RequestContext.Identity.UserAgent != "" {
+		return event.RequestContext.Identity.UserAgent
+	}
+
+	return header(event, "User-Agent")
+}
+
+// Header names are in lower case in payload format 2.0 events
+func header(event events.APIGatewayProxyRequest, name string) string {
+	for key, value := range event.Headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+
+	return ""
+}
+
+// The claims of the caller, wherever the authorizer put them
+func claims(event events.APIGatewayProxyRequest) map[string]interface{} {
+	authorizer := event.RequestContext.Authorizer
+
+	if authorizer == nil {
+		return map[string]interface{}{}
+	}
+
+	if lambdaContext, ok := authorizer["lambda"].(map[string]interface{}); ok {
+		return lambdaContext
+	}
+
+	if jwt, ok := authorizer["jwt"].(map[string]interface{}); ok {
+		if jwtClaims, ok := jwt["claims"].(map[string]interface{}); ok {
+			return jwtClaims
+		}
+	}
+
+	return authorizer
+}
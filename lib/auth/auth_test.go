@@ -0,0 +1,166 @@
+package auth
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func eventWithAuthorizer(authorizer map[string]interface{}) events.APIGatewayProxyRequest {
+	var event events.APIGatewayProxyRequest
+	event.RequestContext.Authorizer = authorizer
+	return event
+}
+
+func TestCallerIdWithoutAuthorizer(t *testing.T) {
+	_, ok := CallerId(events.APIGatewayProxyRequest{})
+
+	if ok {
+		t.Errorf("FAILED - TestCallerIdWithoutAuthorizer | Actual: %v | Expected: %v", ok, false)
+	}
+}
+
+func TestCallerIdWithLambdaAuthorizer(t *testing.T) {
+	event := eventWithAuthorizer(map[string]interface{}{
+		"lambda": map[string]interface{}{"userId": "foo@bar.com"},
+	})
+
+	actual, _ := CallerId(event)
+	expected := "foo@bar.com"
+
+	if actual != expected {
+		t.Errorf("FAILED - TestCallerIdWithLambdaAuthorizer | Actual: %s | Expected: %s", actual, expected)
+	}
+}
+
+func TestCallerIdWithJwtAuthorizer(t *testing.T) {
+	event := eventWithAuthorizer(map[string]interface{}{
+		"jwt": map[string]interface{}{
+			"claims": map[string]interface{}{"email": "foo@bar.com"},
+		},
+	})
+
+	actual, _ := CallerId(event)
+	expected := "foo@bar.com"
+
+	if actual != expected {
+		t.Errorf("FAILED - TestCallerIdWithJwtAuthorizer | Actual: %s | Expected: %s", actual, expected)
+	}
+}
+
+func TestCallerIdWithEmptyUserId(t *testing.T) {
+	event := eventWithAuthorizer(map[string]interface{}{"userId": ""})
+
+	_, ok := CallerId(event)
+
+	if ok {
+		t.Errorf("FAILED - TestCallerIdWithEmptyUserId | Actual: %v | Expected: %v", ok, false)
+	}
+}
+
+func TestOperatorIdRequiresAdminScope(t *testing.T) {
+	event := eventWithAuthorizer(map[string]interface{}{
+		"jwt": map[string]interface{}{
+			"claims": map[string]interface{}{"email": "support@bar.com", "scope": "openid " + ADMIN_SCOPE},
+		},
+	})
+
+	actual, ok := OperatorId(event)
+
+	if !ok || actual != "support@bar.com" {
+		t.Errorf("FAILED - TestOperatorIdRequiresAdminScope | Actual: %s, %v | Expected: %s, %v", actual, ok, "support@bar.com", true)
+	}
+}
+
+func TestOperatorIdWithUserToken(t *testing.T) {
+	tests := []map[string]interface{}{
+		{"lambda": map[string]interface{}{"userId": "foo@bar.com"}},
+		{"lambda": map[string]interface{}{"userId": "foo@bar.com", "scope": ADMIN_SCOPE + "s"}},
+		{"lambda": map[string]interface{}{"scope": ADMIN_SCOPE}},
+	}
+
+	for _, authorizer := range tests {
+		if _, ok := OperatorId(eventWithAuthorizer(authorizer)); ok {
+			t.Errorf("FAILED - TestOperatorIdWithUserToken | Authorizer: %v | Expected: the caller to be rejected", authorizer)
+		}
+	}
+}
+
+// An HTTP API request with the default payload format 2.0, as API Gateway sends it to the endpoints
+const httpApiEvent = `{
+	"version": "2.0",
+	"routeKey": "GET /api/v1/sync",
+	"rawPath": "/v1/api/v1/sync",
+	"rawQueryString": "",
+	"headers": {
+		"accept": "application/json",
+		"authorization": "Bearer token",
+		"content-length": "0",
+		"host": "api.password-caddy.com",
+		"user-agent": "PasswordCaddy/1.4 (iPhone; iOS 17.2)",
+		"x-amzn-trace-id": "Root=1-65a1b2c3-0123456789abcdef01234567",
+		"x-forwarded-for": "10.0.0.1, 203.0.113.7",
+		"x-forwarded-port": "443",
+		"x-forwarded-proto": "https"
+	},
+	"requestContext": {
+		"accountId": "123456789012",
+		"apiId": "a1b2c3d4e5",
+		"authorizer": {
+			"lambda": {
+				"userId": "foo@bar.com"
+			}
+		},
+		"domainName": "api.password-caddy.com",
+		"domainPrefix": "api",
+		"http": {
+			"method": "GET",
+			"path": "/v1/api/v1/sync",
+			"protocol": "HTTP/1.1",
+			"sourceIp": "203.0.113.7",
+			"userAgent": "PasswordCaddy/1.4 (iPhone; iOS 17.2)"
+		},
+		"requestId": "RgYdUjGLiYcEJLw=",
+		"routeKey": "GET /api/v1/sync",
+		"stage": "v1",
+		"time": "12/Jan/2024:10:15:30 +0000",
+		"timeEpoch": 1705054530000
+	},
+	"isBase64Encoded": false
+}`
+
+func TestRequestOfHttpApiPayload(t *testing.T) {
+	var event events.APIGatewayProxyRequest
+
+	if err := json.Unmarshal([]byte(httpApiEvent), &event); err != nil {
+		t.Fatalf("FAILED - TestRequestOfHttpApiPayload | Error: %v", err)
+	}
+
+	if actual, _ := CallerId(event); actual != "foo@bar.com" {
+		t.Errorf("FAILED - TestRequestOfHttpApiPayload - CallerId | Actual: %s | Expected: foo@bar.com", actual)
+	}
+
+	if actual := SourceIp(event); actual != "203.0.113.7" {
+		t.Errorf("FAILED - TestRequestOfHttpApiPayload - SourceIp | Actual: %s | Expected: 203.0.113.7", actual)
+	}
+
+	if actual := UserAgent(event); actual != "PasswordCaddy/1.4 (iPhone; iOS 17.2)" {
+		t.Errorf("FAILED - TestRequestOfHttpApiPayload - UserAgent | Actual: %s | Expected: PasswordCaddy/1.4 (iPhone; iOS 17.2)", actual)
+	}
+}
+
+func TestRequestOfRestApiPayload(t *testing.T) {
+	var event events.APIGatewayProxyRequest
+	event.Headers = map[string]string{"X-Forwarded-For": "10.0.0.1", "User-Agent": "header"}
+	event.RequestContext.Identity.SourceIP = "203.0.113.7"
+	event.RequestContext.Identity.UserAgent = "identity"
+
+	if actual := SourceIp(event); actual != "203.0.113.7" {
+		t.Errorf("FAILED - TestRequestOfRestApiPayload - SourceIp | Actual: %s | Expected: 203.0.113.7", actual)
+	}
+
+	if actual := UserAgent(event); actual != "identity" {
+		t.Errorf("FAILED - TestRequestOfRestApiPayload - UserAgent | Actual: %s | Expected: identity", actual)
+	}
+}
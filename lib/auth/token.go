@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	appConfig "password-caddy/api/core/config"
+)
+
+// Issuer of the session tokens, which the authorizer only accepts tokens of
+const TOKEN_ISSUER = "password-caddy"
+
+// The only header the session tokens are signed with
+const tokenHeader = `{"alg":"HS256","typ":"JWT"}`
+
+var (
+	ErrInvalidToken = errors.New("Invalid session token")
+	ErrExpiredToken = errors.New("Session token has expired")
+	ErrNoSecret     = errors.New("Session token secret is not configured")
+)
+
+/*
+Claims of a session token. Subject is the id (email address) of the user, times
+are unix seconds
+*/
+type Claims struct {
+	Issuer    string `json:"iss"`
+	Subject   string `json:"sub"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+/********** CONFIG **********/
+
+// Key the session tokens are signed with. Every function issuing or verifying tokens needs the same one
+func TokenSecret() []byte {
+	return []byte(appConfig.Get("SESSION_TOKEN_SECRET", "").ToString())
+}
+
+// How long a session token is valid when no organization limits the session
+func TokenTTL() time.Duration {
+	minutes := appConfig.Get("SESSION_TOKEN_TTL_MINUTES", "60").ToInt64()
+	return time.Duration(minutes) * time.Minute
+}
+
+/********** TOKENS **********/
+
+/*
+Issue a session token for the user. Session tokens are JWTs signed with
+HMAC-SHA256, which the authorizer of the API verifies
+*/
+func IssueToken(secret []byte, userId string, now time.Time, ttl time.Duration) (string, Claims, error) {
+	if len(secret) == 0 {
+		return "", Claims{}, ErrNoSecret
+	}
+
+	claims := Claims{
+		Issuer:    TOKEN_ISSUER,
+		Subject:   userId,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+	}
+
+	payload, err := json.Marshal(claims)
+
+	if err != nil {
+		return "", Claims{}, err
+	}
+
+	unsigned := encodeSegment([]byte(tokenHeader)) + "." + encodeSegment(payload)
+
+	return unsigned + "." + encodeSegment(sign(secret, unsigned)), claims, nil
+}
+
+// Verify the signature and expiry of a session token and get its claims
+func VerifyToken(secret []byte, token string, now time.Time) (Claims, error) {
+	var claims Claims
+
+	if len(secret) == 0 {
+		return claims, ErrNoSecret
+	}
+
+	segments := strings.Split(token, ".")
+
+	if len(segments) != 3 || segments[0] != encodeSegment([]byte(tokenHeader)) {
+		return claims, ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(segments[2])
+
+	if err != nil || !hmac.Equal(signature, sign(secret, segments[0]+"."+segments[1])) {
+		return claims, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(segments[1])
+
+	if err != nil || json.Unmarshal(payload, &claims) != nil {
+		return claims, ErrInvalidToken
+	}
+
+	if claims.Issuer != TOKEN_ISSUER || claims.Subject == "" {
+		return claims, ErrInvalidToken
+	}
+
+	if now.Unix() >= claims.ExpiresAt {
+		return claims, ErrExpiredToken
+	}
+
+	return claims, nil
+}
+
+// Get the token out of an "Authorization: Bearer <token>" header
+func BearerToken(header string) (string, bool) {
+	fields := strings.Fields(header)
+
+	if len(fields) != 2 || !strings.EqualFold(fields[0], "Bearer") {
+		return "", false
+	}
+
+	return fields[1], true
+}
+
+func encodeSegment(value []byte) string {
+	return base64.RawURLEncoding.EncodeToString(value)
+}
+
+func sign(secret []byte, unsigned string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unsigned))
+
+	return mac.Sum(nil)
+}
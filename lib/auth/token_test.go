@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func TestIssueAndVerifyToken(t *testing.T) {
+	now := time.Now()
+
+	token, issued, err := IssueToken(testSecret, "foo@bar.com", now, time.Hour)
+
+	if err != nil {
+		t.Fatalf("FAILED - TestIssueAndVerifyToken | Error: %v", err)
+	}
+
+	claims, err := VerifyToken(testSecret, token, now.Add(time.Minute))
+
+	if err != nil || claims != issued || claims.Subject != "foo@bar.com" {
+		t.Errorf("FAILED - TestIssueAndVerifyToken | Actual: %+v, %v | Expected: %+v", claims, err, issued)
+	}
+}
+
+func TestVerifyExpiredToken(t *testing.T) {
+	now := time.Now()
+	token, _, _ := IssueToken(testSecret, "foo@bar.com", now, time.Hour)
+
+	if _, err := VerifyToken(testSecret, token, now.Add(time.Hour)); err != ErrExpiredToken {
+		t.Errorf("FAILED - TestVerifyExpiredToken | Actual: %v | Expected: %v", err, ErrExpiredToken)
+	}
+}
+
+func TestVerifyTamperedToken(t *testing.T) {
+	now := time.Now()
+	token, _, _ := IssueToken(testSecret, "foo@bar.com", now, time.Hour)
+	other, _, _ := IssueToken(testSecret, "evil@bar.com", now, time.Hour)
+
+	segments := strings.Split(token, ".")
+	forged := segments[0] + "." + strings.Split(other, ".")[1] + "." + segments[2]
+
+	tests := []struct {
+		secret []byte
+		token  string
+	}{
+		{testSecret, forged},
+		{[]byte("another secret"), token},
+		{testSecret, token + "x"},
+		{testSecret, "not.a.token"},
+		{nil, token},
+	}
+
+	for _, test := range tests {
+		if _, err := VerifyToken(test.secret, test.token, now); err == nil {
+			t.Errorf("FAILED - TestVerifyTamperedToken | Token: %s | Expected: the token to be rejected", test.token)
+		}
+	}
+}
+
+func TestIssueTokenWithoutSecret(t *testing.T) {
+	if _, _, err := IssueToken(nil, "foo@bar.com", time.Now(), time.Hour); err != ErrNoSecret {
+		t.Errorf("FAILED - TestIssueTokenWithoutSecret | Actual: %v | Expected: %v", err, ErrNoSecret)
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	tests := []struct {
+		header string
+		token  string
+		ok     bool
+	}{
+		{"Bearer abc", "abc", true},
+		{"bearer abc", "abc", true},
+		{"Basic abc", "", false},
+		{"Bearer", "", false},
+		{"", "", false},
+	}
+
+	for _, test := range tests {
+		if token, ok := BearerToken(test.header); token != test.token || ok != test.ok {
+			t.Errorf("FAILED - TestBearerToken | Header: %s | Actual: %s, %v | Expected: %s, %v", test.header, token, ok, test.token, test.ok)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package blobstore
+
+import (
+	"errors"
+	"strings"
+)
+
+// Returned by Get when there is no blob with the key
+var ErrNotFound = errors.New("Blob not found")
+
+/*
+Storage of opaque blobs like the client encrypted attachments of vault items.
+Keys are slash separated paths (i.e attachments/<user>/<item>/<attachment>)
+*/
+type BlobStore interface {
+	Put(key string, data []byte) error
+	Get(key string) ([]byte, error)
+	// Deleting a key without a blob is not an error
+	Delete(key string) error
+}
+
+// Keys must be relative paths without empty, . or .. segments
+func IsValidKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,64 @@
+package blobstore
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestIsValidKey(t *testing.T) {
+	tests := map[string]bool{
+		"attachments/bob@example.com/item/file": true,
+		"file":                                  true,
+		"":                                      false,
+		"/etc/passwd":                           false,
+		"attachments/../../etc/passwd":          false,
+		"attachments//file":                     false,
+		"attachments\\file":                     false,
+	}
+
+	for key, expected := range tests {
+		if actual := IsValidKey(key); actual != expected {
+			t.Errorf("FAILED - TestIsValidKey | Key: %s | Actual: %v | Expected: %v", key, actual, expected)
+		}
+	}
+}
+
+/***** LocalStore *****/
+
+func TestLocalStore(t *testing.T) {
+	store := NewLocalStore(t.TempDir())
+	key := "attachments/bob@example.com/item/file"
+	data := []byte{0, 1, 2, 255}
+
+	if err := store.Put(key, data); err != nil {
+		t.Fatalf("FAILED - TestLocalStore | Put: %s", err.Error())
+	}
+
+	actual, err := store.Get(key)
+
+	if err != nil || !bytes.Equal(actual, data) {
+		t.Errorf("FAILED - TestLocalStore | Actual: %v %v | Expected: %v", actual, err, data)
+	}
+
+	if err := store.Delete(key); err != nil {
+		t.Errorf("FAILED - TestLocalStore | Delete: %s", err.Error())
+	}
+
+	if _, err := store.Get(key); err != ErrNotFound {
+		t.Errorf("FAILED - TestLocalStore | Actual: %v | Expected: %v", err, ErrNotFound)
+	}
+
+	if err := store.Delete(key); err != nil {
+		t.Errorf("FAILED - TestLocalStore | Deleting a missing blob: %s", err.Error())
+	}
+}
+
+func TestLocalStoreWithInvalidKey(t *testing.T) {
+	store := NewLocalStore(t.TempDir())
+
+	if err := store.Put("../escape", []byte("x")); err == nil {
+		t.Errorf("FAILED - TestLocalStoreWithInvalidKey | Expected: an error")
+	}
+}
+
+/***** End LocalStore *****/
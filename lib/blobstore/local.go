@@ -0,0 +1,72 @@
+package blobstore
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+/*
+BlobStore on the local file system, every blob is a file below Root. Used by
+the tests and when running the API locally
+*/
+type LocalStore struct {
+	Root string
+}
+
+func NewLocalStore(root string) *LocalStore {
+	return &LocalStore{Root: root}
+}
+
+func (store *LocalStore) path(key string) (string, error) {
+	if !IsValidKey(key) {
+		return "", fmt.Errorf("%s is not a valid blob key", key)
+	}
+
+	return filepath.Join(store.Root, filepath.FromSlash(key)), nil
+}
+
+func (store *LocalStore) Put(key string, data []byte) error {
+	path, err := store.path(key)
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0600)
+}
+
+func (store *LocalStore) Get(key string) ([]byte, error) {
+	path, err := store.path(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(path)
+
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+
+	return data, err
+}
+
+func (store *LocalStore) Delete(key string) error {
+	path, err := store.path(key)
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package blobstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3Types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// BlobStore in an S3 bucket. Blobs are encrypted at rest with the bucket's S3 managed key
+type S3Store struct {
+	Client *s3.Client
+	Bucket string
+}
+
+/*
+Create a new instance of the S3 BlobStore
+*/
+func NewS3Store(awsConfig aws.Config, bucket string) *S3Store {
+	return &S3Store{
+		Client: s3.NewFromConfig(awsConfig),
+		Bucket: bucket,
+	}
+}
+
+// @see - https://docs.aws.amazon.com/sdk-for-go/api/service/s3/#S3.PutObject
+func (store *S3Store) Put(key string, data []byte) error {
+	if !IsValidKey(key) {
+		return fmt.Errorf("%s is not a valid blob key", key)
+	}
+
+	_, err := store.Client.PutObject(context.TODO(), &s3.PutObjectInput{
+		Bucket:               aws.String(store.Bucket),
+		Key:                  aws.String(key),
+		Body:                 bytes.NewReader(data),
+		ContentLength:        int64(len(data)),
+		ContentType:          aws.String("application/octet-stream"),
+		ServerSideEncryption: s3Types.ServerSideEncryptionAes256,
+	})
+
+	return err
+}
+
+// @see - https://docs.aws.amazon.com/sdk-for-go/api/service/s3/#S3.GetObject
+func (store *S3Store) Get(key string) ([]byte, error) {
+	output, err := store.Client.GetObject(context.TODO(), &s3.GetObjectInput{
+		Bucket: aws.String(store.Bucket),
+		Key:    aws.String(key),
+	})
+
+	if err != nil {
+		var noSuchKey *s3Types.NoSuchKey
+		if errors.As(err, &noSuchKey) {
+			return nil, ErrNotFound
+		}
+
+		return nil, err
+	}
+
+	defer output.Body.Close()
+
+	return ioutil.ReadAll(output.Body)
+}
+
+// @see - https://docs.aws.amazon.com/sdk-for-go/api/service/s3/#S3.DeleteObject
+func (store *S3Store) Delete(key string) error {
+	_, err := store.Client.DeleteObject(context.TODO(), &s3.DeleteObjectInput{
+		Bucket: aws.String(store.Bucket),
+		Key:    aws.String(key),
+	})
+
+	return err
+}
@@ -0,0 +1,250 @@
+package breach
+
+import (
+	"bufio"
+	"crypto/rand"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	appConfig "password-caddy/api/core/config"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/dynamoclient"
+)
+
+// Lengths of the hex encoded SHA-1 hash and the prefix of a range
+const (
+	HASH_LENGTH   = 40
+	PREFIX_LENGTH = 5
+	SUFFIX_LENGTH = HASH_LENGTH - PREFIX_LENGTH
+)
+
+// Sort key prefix of the ranges of a prefix
+const RANGE_KEY_PREFIX = "RANGE#"
+
+// Hashes per stored range, keeps an item well below the DynamoDB item size limit
+const MAX_RANGE_HASHES = 2000
+
+/*
+Small corpus of the 1000 most common passwords in the HIBP ordered by hash
+format ("HASH:COUNT"). The counts are made up, ordered by how common the
+password is. Served when BREACH_SOURCE is fixture, so the endpoint works
+without the full dataset
+*/
+//go:embed fixture.txt
+var fixture string
+
+// Sources of the hashes
+const (
+	SOURCE_DYNAMO  = "dynamo"
+	SOURCE_FIXTURE = "fixture"
+)
+
+// The suffix of a breached hash and how often it was seen
+type Hash struct {
+	Suffix string `json:"suffix"`
+	Count  int    `json:"count"`
+}
+
+// All the hashes of a prefix. Padding hashes have a count of 0
+type Range struct {
+	Prefix string `json:"prefix"`
+	Hashes []Hash `json:"hashes"`
+}
+
+// Where the hashes are read from, the breach table or the fixture corpus
+func Source() string {
+	return appConfig.Get("BREACH_SOURCE", SOURCE_DYNAMO).ToString()
+}
+
+// Bounds of the number of hashes a padded range has
+func PaddingBounds() (int, int) {
+	min := int(appConfig.Get("BREACH_PADDING_MIN", "800").ToInt64())
+	max := int(appConfig.Get("BREACH_PADDING_MAX", "1000").ToInt64())
+
+	if max < min {
+		max = min
+	}
+
+	return min, max
+}
+
+// A prefix is the first 5 hex characters of a SHA-1 hash
+func IsValidPrefix(prefix string) bool {
+	if len(prefix) != PREFIX_LENGTH {
+		return false
+	}
+
+	_, err := hex.DecodeString(prefix + "0")
+
+	return err == nil
+}
+
+/*
+Parse a line of a HIBP file. Full hashes ("HASH:COUNT") of the ordered by hash
+downloads are split into prefix and suffix. Lines of a range ("SUFFIX:COUNT")
+are returned with an empty prefix
+*/
+func ParseLine(line string) (string, Hash, error) {
+	parts := strings.SplitN(strings.TrimSpace(line), ":", 2)
+
+	if len(parts) != 2 {
+		return "", Hash{}, fmt.Errorf("Line must be of the form HASH:COUNT")
+	}
+
+	hash := strings.ToUpper(parts[0])
+	count, err := strconv.Atoi(parts[1])
+
+	if err != nil || count < 0 {
+		return "", Hash{}, fmt.Errorf("Count must be zero or a positive number")
+	}
+
+	if _, err := hex.DecodeString(hash + strings.Repeat("0", len(hash)%2)); err != nil {
+		return "", Hash{}, fmt.Errorf("Hash must be hex encoded")
+	}
+
+	switch len(hash) {
+	case HASH_LENGTH:
+		return hash[:PREFIX_LENGTH], Hash{Suffix: hash[PREFIX_LENGTH:], Count: count}, nil
+	case SUFFIX_LENGTH:
+		return "", Hash{Suffix: hash, Count: count}, nil
+	}
+
+	return "", Hash{}, fmt.Errorf("Hash must be %d or %d characters", HASH_LENGTH, SUFFIX_LENGTH)
+}
+
+// Get the hashes of a prefix from the breach table
+func GetRange(client *dynamoclient.DynamoClient, prefix string) *dynamoclient.DynamoResponse {
+	response := client.Query(dynamoclient.DynamoQueryRequest{
+		Key:           strings.ToUpper(prefix),
+		SortKeyPrefix: RANGE_KEY_PREFIX,
+	}).AsBreachRanges()
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	result := Range{Prefix: strings.ToUpper(prefix), Hashes: []Hash{}}
+
+	for _, stored := range response.Data.([]types.BreachRange) {
+		for _, line := range strings.Split(stored.Hashes.Value, "\n") {
+			if line == "" {
+				continue
+			}
+
+			if _, hash, err := ParseLine(line); err == nil {
+				result.Hashes = append(result.Hashes, hash)
+			}
+		}
+	}
+
+	return dynamoclient.SuccessWithValue(result)
+}
+
+// Get the hashes of a prefix from the fixture corpus
+func GetFixtureRange(prefix string) Range {
+	prefix = strings.ToUpper(prefix)
+	result := Range{Prefix: prefix, Hashes: []Hash{}}
+	scanner := bufio.NewScanner(strings.NewReader(fixture))
+
+	for scanner.Scan() {
+		hashPrefix, hash, err := ParseLine(scanner.Text())
+
+		if err == nil && hashPrefix == prefix {
+			result.Hashes = append(result.Hashes, hash)
+		}
+	}
+
+	return result
+}
+
+/*
+Store the hashes of a prefix, split into ranges of MAX_RANGE_HASHES.
+The ranges are overwritten, a reload with a newer dataset must not have
+fewer ranges for a prefix
+*/
+func PutRange(client *dynamoclient.DynamoClient, breachRange Range) *dynamoclient.DynamoResponse {
+	return client.BatchWrite(dynamoclient.DynamoBatchWriteRequest{
+		Puts: RangePuts(breachRange),
+	})
+}
+
+// The puts of the ranges of a prefix
+func RangePuts(breachRange Range) []dynamoclient.DynamoPutRequest {
+	puts := []dynamoclient.DynamoPutRequest{}
+
+	for start := 0; start < len(breachRange.Hashes); start += MAX_RANGE_HASHES {
+		end := start + MAX_RANGE_HASHES
+
+		if end > len(breachRange.Hashes) {
+			end = len(breachRange.Hashes)
+		}
+
+		var lines strings.Builder
+
+		for _, hash := range breachRange.Hashes[start:end] {
+			fmt.Fprintf(&lines, "%s:%d\n", hash.Suffix, hash.Count)
+		}
+
+		puts = append(puts, dynamoclient.DynamoPutRequest{
+			Key:     breachRange.Prefix,
+			SortKey: fmt.Sprintf("%s%04d", RANGE_KEY_PREFIX, start/MAX_RANGE_HASHES),
+			Values: map[string]interface{}{
+				"HASHES": lines.String(),
+			},
+		})
+	}
+
+	return puts
+}
+
+/*
+Pad the range with random suffixes of count 0 up to a random size between
+min and max, so the size of a response does not tell which prefix was
+requested. The hashes are sorted, so padding can't be told apart by its position
+*/
+func Pad(breachRange Range, min, max int) (Range, error) {
+	target := min
+
+	if max > min {
+		jitter, err := rand.Int(rand.Reader, big.NewInt(int64(max-min+1)))
+
+		if err != nil {
+			return breachRange, err
+		}
+
+		target += int(jitter.Int64())
+	}
+
+	padded := Range{Prefix: breachRange.Prefix, Hashes: append([]Hash{}, breachRange.Hashes...)}
+	seen := map[string]bool{}
+
+	for _, hash := range padded.Hashes {
+		seen[hash.Suffix] = true
+	}
+
+	for len(padded.Hashes) < target {
+		suffix := make([]byte, (SUFFIX_LENGTH+1)/2)
+
+		if _, err := rand.Read(suffix); err != nil {
+			return breachRange, err
+		}
+
+		value := strings.ToUpper(hex.EncodeToString(suffix))[:SUFFIX_LENGTH]
+
+		if !seen[value] {
+			seen[value] = true
+			padded.Hashes = append(padded.Hashes, Hash{Suffix: value})
+		}
+	}
+
+	sort.Slice(padded.Hashes, func(a, b int) bool {
+		return padded.Hashes[a].Suffix < padded.Hashes[b].Suffix
+	})
+
+	return padded, nil
+}
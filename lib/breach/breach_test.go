@@ -0,0 +1,102 @@
+package breach
+
+import (
+	"testing"
+)
+
+/***** ParseLine *****/
+
+func TestParseLineFullHash(t *testing.T) {
+	prefix, hash, err := ParseLine("5baa61e4c9b93f3f0682250b6cf8331b7ee68fd8:42\r\n")
+
+	if err != nil || prefix != "5BAA6" || hash.Suffix != "1E4C9B93F3F0682250B6CF8331B7EE68FD8" || hash.Count != 42 {
+		t.Errorf("FAILED - TestParseLineFullHash | Actual: %s %+v %v", prefix, hash, err)
+	}
+}
+
+func TestParseLineSuffix(t *testing.T) {
+	prefix, hash, err := ParseLine("1E4C9B93F3F0682250B6CF8331B7EE68FD8:7")
+
+	if err != nil || prefix != "" || hash.Count != 7 {
+		t.Errorf("FAILED - TestParseLineSuffix | Actual: %s %+v %v", prefix, hash, err)
+	}
+}
+
+func TestParseLineInvalid(t *testing.T) {
+	for _, line := range []string{"", "5BAA6", "5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:x", "5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:-1", "ZZAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:1", "5BAA6:1"} {
+		if _, _, err := ParseLine(line); err == nil {
+			t.Errorf("FAILED - TestParseLineInvalid | Line: %s | Expected: an error", line)
+		}
+	}
+}
+
+/***** End ParseLine *****/
+
+func TestIsValidPrefix(t *testing.T) {
+	tests := map[string]bool{
+		"5BAA6":  true,
+		"5baa6":  true,
+		"5BAA":   false,
+		"5BAA61": false,
+		"5BAG6":  false,
+	}
+
+	for prefix, expected := range tests {
+		if actual := IsValidPrefix(prefix); actual != expected {
+			t.Errorf("FAILED - TestIsValidPrefix | Prefix: %s | Actual: %v | Expected: %v", prefix, actual, expected)
+		}
+	}
+}
+
+func TestGetFixtureRange(t *testing.T) {
+	actual := GetFixtureRange("5baa6")
+	found := false
+
+	for _, hash := range actual.Hashes {
+		// SHA-1 of "password"
+		found = found || hash.Suffix == "1E4C9B93F3F0682250B6CF8331B7EE68FD8"
+	}
+
+	if actual.Prefix != "5BAA6" || !found {
+		t.Errorf("FAILED - TestGetFixtureRange | Actual: %+v | Expected: the hash of password", actual)
+	}
+}
+
+func TestRangePuts(t *testing.T) {
+	breachRange := Range{Prefix: "5BAA6"}
+
+	for i := 0; i < MAX_RANGE_HASHES+1; i++ {
+		breachRange.Hashes = append(breachRange.Hashes, Hash{Suffix: "1E4C9B93F3F0682250B6CF8331B7EE68FD8", Count: i})
+	}
+
+	actual := RangePuts(breachRange)
+
+	if len(actual) != 2 || actual[0].SortKey != "RANGE#0000" || actual[1].SortKey != "RANGE#0001" {
+		t.Errorf("FAILED - TestRangePuts | Actual: %d puts | Expected: %d", len(actual), 2)
+	}
+}
+
+func TestPad(t *testing.T) {
+	real := GetFixtureRange("5BAA6")
+	actual, err := Pad(real, 800, 1000)
+
+	if err != nil || len(actual.Hashes) < 800 || len(actual.Hashes) > 1000 {
+		t.Fatalf("FAILED - TestPad | Actual: %d hashes | Expected: between 800 and 1000", len(actual.Hashes))
+	}
+
+	counted := 0
+
+	for i, hash := range actual.Hashes {
+		if len(hash.Suffix) != SUFFIX_LENGTH || (i > 0 && actual.Hashes[i-1].Suffix >= hash.Suffix) {
+			t.Fatalf("FAILED - TestPad | Actual: %s | Expected: sorted suffixes of %d characters", hash.Suffix, SUFFIX_LENGTH)
+		}
+
+		if hash.Count > 0 {
+			counted++
+		}
+	}
+
+	if counted != len(real.Hashes) {
+		t.Errorf("FAILED - TestPad | Actual: %d | Expected: %d", counted, len(real.Hashes))
+	}
+}
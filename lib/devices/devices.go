@@ -0,0 +1,180 @@
+package devices
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	appConfig "password-caddy/api/core/config"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/dynamoclient"
+)
+
+// Sort key prefix of the known devices and IP addresses in a user's partition
+const KNOWN_PREFIX = "KNOWN#"
+
+const MAX_USER_AGENT_LENGTH = 256
+
+// Kinds of what a user is known to log in from
+const (
+	KIND_DEVICE = "DEVICE"
+	KIND_IP     = "IP"
+)
+
+/*
+What a login has in common with the previous ones. First is set when the user
+never logged in before, so there is nothing to compare the login to. The first
+seen times are only set for a known device and IP address
+*/
+type Sighting struct {
+	First             bool
+	NewDevice         bool
+	NewIp             bool
+	DeviceFirstSeenAt string
+	IpFirstSeenAt     string
+}
+
+/********** CONFIG **********/
+
+// How long a device or IP address stays known after it was last seen
+func KnownTTL() time.Duration {
+	days := appConfig.Get("KNOWN_DEVICE_TTL_DAYS", "180").ToInt64()
+	return time.Duration(days) * 24 * time.Hour
+}
+
+/********** KEYS **********/
+
+/*
+Devices are told apart by their user agent, which is hashed since clients set
+it and it can be long
+*/
+func DeviceKey(userAgent string) string {
+	hash := sha256.Sum256([]byte(userAgent))
+	return KNOWN_PREFIX + KIND_DEVICE + "#" + hex.EncodeToString(hash[:])
+}
+
+func IpKey(ipAddress string) string {
+	return KNOWN_PREFIX + KIND_IP + "#" + ipAddress
+}
+
+/********** EVALUATION **********/
+
+// Compare a login to the devices and IP addresses the user is known to log in from
+func Compare(known []types.KnownDevice, ipAddress, userAgent string) Sighting {
+	sighting := Sighting{
+		First:     len(known) == 0,
+		NewDevice: true,
+		NewIp:     true,
+	}
+
+	deviceKey := DeviceKey(userAgent)
+	ipKey := IpKey(ipAddress)
+
+	for _, device := range known {
+		switch device.ItemKey.Value {
+		case deviceKey:
+			sighting.NewDevice = false
+			sighting.DeviceFirstSeenAt = device.FirstSeenAt.Value
+		case ipKey:
+			sighting.NewIp = false
+			sighting.IpFirstSeenAt = device.FirstSeenAt.Value
+		}
+	}
+
+	return sighting
+}
+
+// A login is suspicious when it comes from a device or IP address the user never logged in from
+func (sighting Sighting) IsSuspicious() bool {
+	return !sighting.First && (sighting.NewDevice || sighting.NewIp)
+}
+
+/********** OPERATIONS **********/
+
+// Get the devices and IP addresses a user is known to log in from
+func ListKnown(client *dynamoclient.DynamoClient, userId string) *dynamoclient.DynamoResponse {
+	return client.
+		Query(dynamoclient.DynamoQueryRequest{
+			Key:           userId,
+			SortKeyPrefix: KNOWN_PREFIX,
+		}).
+		AsKnownDevices()
+}
+
+// Check a login against the devices and IP addresses a user is known to log in from
+func Check(client *dynamoclient.DynamoClient, userId, ipAddress, userAgent string) *dynamoclient.DynamoResponse {
+	response := ListKnown(client, userId)
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	return dynamoclient.SuccessWithValue(Compare(response.Data.([]types.KnownDevice), ipAddress, userAgent))
+}
+
+/*
+Remember the device and IP address of a login. Seeing them again pushes back
+their expiry, the first time they were seen comes from the sighting
+*/
+func Remember(client *dynamoclient.DynamoClient, userId, ipAddress, userAgent string, sighting Sighting) *dynamoclient.DynamoResponse {
+	now := time.Now().UTC()
+	lastSeenAt := now.Format(time.RFC3339)
+	expiresAt := now.Add(KnownTTL()).Unix()
+
+	deviceFirstSeenAt := sighting.DeviceFirstSeenAt
+	ipFirstSeenAt := sighting.IpFirstSeenAt
+
+	if deviceFirstSeenAt == "" {
+		deviceFirstSeenAt = lastSeenAt
+	}
+
+	if ipFirstSeenAt == "" {
+		ipFirstSeenAt = lastSeenAt
+	}
+
+	return client.BatchWrite(dynamoclient.DynamoBatchWriteRequest{
+		Puts: []dynamoclient.DynamoPutRequest{
+			{
+				Key:     userId,
+				SortKey: DeviceKey(userAgent),
+				Values: map[string]interface{}{
+					"KIND":          KIND_DEVICE,
+					"VALUE":         truncate(userAgent),
+					"FIRST_SEEN_AT": deviceFirstSeenAt,
+					"LAST_SEEN_AT":  lastSeenAt,
+					"EXPIRES_AT":    expiresAt,
+				},
+			},
+			{
+				Key:     userId,
+				SortKey: IpKey(ipAddress),
+				Values: map[string]interface{}{
+					"KIND":          KIND_IP,
+					"VALUE":         ipAddress,
+					"FIRST_SEEN_AT": ipFirstSeenAt,
+					"LAST_SEEN_AT":  lastSeenAt,
+					"EXPIRES_AT":    expiresAt,
+				},
+			},
+		},
+	})
+}
+
+// Forget the device and IP address of a login, i.e one the user reported as not theirs
+func Forget(client *dynamoclient.DynamoClient, userId, ipAddress, userAgent string) *dynamoclient.DynamoResponse {
+	return client.BatchWrite(dynamoclient.DynamoBatchWriteRequest{
+		Deletes: []dynamoclient.DynamoDeleteRequest{
+			{Key: userId, SortKey: DeviceKey(userAgent)},
+			{Key: userId, SortKey: IpKey(ipAddress)},
+		},
+	})
+}
+
+// User agents are set by clients, so the length of the one kept is capped
+func truncate(userAgent string) string {
+	if len(userAgent) > MAX_USER_AGENT_LENGTH {
+		return userAgent[:MAX_USER_AGENT_LENGTH]
+	}
+
+	return userAgent
+}
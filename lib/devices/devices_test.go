@@ -0,0 +1,71 @@
+package devices
+
+import (
+	"strings"
+	"testing"
+
+	"password-caddy/api/core/types"
+)
+
+func knownDevice(key, firstSeenAt string) types.KnownDevice {
+	var device types.KnownDevice
+	device.ItemKey.Value = key
+	device.FirstSeenAt.Value = firstSeenAt
+	return device
+}
+
+/***** Keys *****/
+
+func TestDeviceKeyHashesTheUserAgent(t *testing.T) {
+	key := DeviceKey(strings.Repeat("Mozilla/5.0 ", 100))
+
+	if !strings.HasPrefix(key, KNOWN_PREFIX+KIND_DEVICE+"#") || len(key) != len(KNOWN_PREFIX+KIND_DEVICE+"#")+64 {
+		t.Errorf("FAILED - TestDeviceKeyHashesTheUserAgent | Actual: %s", key)
+	}
+}
+
+/***** Compare *****/
+
+func TestCompare(t *testing.T) {
+	known := []types.KnownDevice{
+		knownDevice(DeviceKey("Firefox"), "2024-01-01T00:00:00Z"),
+		knownDevice(IpKey("1.1.1.1"), "2024-02-01T00:00:00Z"),
+	}
+
+	tests := []struct {
+		ip         string
+		userAgent  string
+		suspicious bool
+	}{
+		{"1.1.1.1", "Firefox", false},
+		{"2.2.2.2", "Firefox", true},
+		{"1.1.1.1", "Chrome", true},
+		{"2.2.2.2", "Chrome", true},
+	}
+
+	for _, test := range tests {
+		sighting := Compare(known, test.ip, test.userAgent)
+
+		if sighting.IsSuspicious() != test.suspicious {
+			t.Errorf("FAILED - TestCompare | IP: %s | User agent: %s | Actual: %+v | Expected suspicious: %v", test.ip, test.userAgent, sighting, test.suspicious)
+		}
+	}
+}
+
+func TestCompareKeepsFirstSeen(t *testing.T) {
+	known := []types.KnownDevice{knownDevice(DeviceKey("Firefox"), "2024-01-01T00:00:00Z")}
+
+	sighting := Compare(known, "2.2.2.2", "Firefox")
+
+	if sighting.DeviceFirstSeenAt != "2024-01-01T00:00:00Z" || sighting.IpFirstSeenAt != "" {
+		t.Errorf("FAILED - TestCompareKeepsFirstSeen | Actual: %+v", sighting)
+	}
+}
+
+func TestFirstLoginIsNotSuspicious(t *testing.T) {
+	sighting := Compare([]types.KnownDevice{}, "1.1.1.1", "Firefox")
+
+	if !sighting.First || sighting.IsSuspicious() {
+		t.Errorf("FAILED - TestFirstLoginIsNotSuspicious | Actual: %+v", sighting)
+	}
+}
@@ -3,6 +3,11 @@ package dynamoclient
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	apiTypes "password-caddy/api/core/types"
 	"password-caddy/api/lib/util"
@@ -14,6 +19,21 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
+const (
+	PARTITION_KEY = "USER_ID"
+	SORT_KEY      = "ITEM_KEY"
+)
+
+// Cancellation reason of a transaction item whose condition failed
+const CONDITIONAL_CHECK_FAILED = "ConditionalCheckFailed"
+
+// DynamoDB limits of a single batch write and transaction
+const (
+	MAX_BATCH_WRITE_ITEMS    = 25
+	MAX_TRANSACT_ITEMS       = 25
+	MAX_BATCH_WRITE_ATTEMPTS = 5
+)
+
 type DynamoClient struct {
 	Client *dynamodb.Client
 	Config DynamoConfig
@@ -29,23 +49,88 @@ type DynamoResponse struct {
 	Error     apiTypes.PasswordCaddyError
 }
 
+// SortKey is only set for tables that have a composite primary key
 type DynamoGetRequest struct {
-	Key string
+	Key     string
+	SortKey string
 }
 
 type DynamoPutRequest struct {
-	Key    string
-	Values map[string]string
+	Key       string
+	SortKey   string
+	Values    map[string]interface{}
+	Condition *DynamoCondition
 }
 
 type DyanamoUpdateRequest struct {
-	Key    string
-	Values map[string]DynamoUpdateItem
+	Key       string
+	SortKey   string
+	Values    map[string]DynamoUpdateItem
+	Condition *DynamoCondition
+}
+
+// Only write an item when the condition expression holds. A failed condition
+// results in a 409. Placeholders must not start with #u or :u, those are
+// reserved for the generated update expression
+type DynamoCondition struct {
+	Expression string
+	Names      map[string]string
+	Values     map[string]interface{}
 }
 
 type DynamoUpdateItem struct {
 	Action types.AttributeAction
-	Value  string
+	Value  interface{}
+}
+
+type DynamoDeleteRequest struct {
+	Key       string
+	SortKey   string
+	Condition *DynamoCondition
+}
+
+// Query all items in a partition whose sort key begins with SortKeyPrefix,
+// or lies between SortKeyFrom and SortKeyTo when both are set. StartAfter
+// resumes the query after the item with that sort key. Filter is an optional
+// DynamoDB filter expression using the placeholders defined in Names and
+// Values (#pk, #sk, :pk, :sk and :skTo are reserved). IndexName queries a
+// local secondary index instead, whose sort key is narrowed down by
+// KeyCondition (i.e "#syncRevision > :since"). StartAfter only works on the table
+type DynamoQueryRequest struct {
+	Key            string
+	SortKeyPrefix  string
+	SortKeyFrom    string
+	SortKeyTo      string
+	IndexName      string
+	KeyCondition   string
+	StartAfter     string
+	Descending     bool
+	Limit          int32
+	ConsistentRead bool
+	Filter         string
+	Names          map[string]string
+	Values         map[string]interface{}
+}
+
+// Put and delete many items without conditions. Conditions of the requests are ignored
+type DynamoBatchWriteRequest struct {
+	Puts    []DynamoPutRequest
+	Deletes []DynamoDeleteRequest
+}
+
+// A single write of a transaction. Exactly one of the requests is set
+type DynamoTransactItem struct {
+	Put    *DynamoPutRequest
+	Update *DyanamoUpdateRequest
+	Delete *DynamoDeleteRequest
+}
+
+// Scan the whole table. Filter is a DynamoDB filter expression
+// using the placeholders defined in Names and Values
+type DynamoScanRequest struct {
+	Filter string
+	Names  map[string]string
+	Values map[string]interface{}
 }
 
 /*
@@ -66,7 +151,7 @@ func (dynamo *DynamoClient) WithConfig(config DynamoConfig) *DynamoClient {
 func (dynamo *DynamoClient) Get(request DynamoGetRequest) *DynamoResponse {
 	getInput := &dynamodb.GetItemInput{
 		TableName: aws.String(dynamo.Config.TableName),
-		Key:       ConvertToDyanamoGetItem(request.Key),
+		Key:       ConvertToDynamoKey(request.Key, request.SortKey),
 	}
 
 	output, err := dynamo.Client.GetItem(context.TODO(), getInput)
@@ -90,13 +175,14 @@ func (dynamo *DynamoClient) Get(request DynamoGetRequest) *DynamoResponse {
 //
 // @see - https://docs.aws.amazon.com/sdk-for-go/api/service/dynamodb/#DynamoDB.PutItem
 func (dynamo *DynamoClient) Put(request DynamoPutRequest) *DynamoResponse {
-	var putInput *dynamodb.PutItemInput
-
-	request.Values["USER_ID"] = request.Key
+	put := dynamo.buildPut(request)
 
-	putInput = &dynamodb.PutItemInput{
-		TableName: aws.String(dynamo.Config.TableName),
-		Item:      ConvertToDynamoPutItem(request.Values),
+	putInput := &dynamodb.PutItemInput{
+		TableName:                 put.TableName,
+		Item:                      put.Item,
+		ConditionExpression:       put.ConditionExpression,
+		ExpressionAttributeNames:  put.ExpressionAttributeNames,
+		ExpressionAttributeValues: put.ExpressionAttributeValues,
 	}
 
 	_, err := dynamo.Client.PutItem(context.TODO(), putInput)
@@ -120,21 +206,110 @@ func (dynamo *DynamoClient) Put(request DynamoPutRequest) *DynamoResponse {
 //
 // @see - https://docs.aws.amazon.com/sdk-for-go/api/service/dynamodb/#DynamoDB.UpdateItem
 func (dynamo *DynamoClient) Update(request DyanamoUpdateRequest) *DynamoResponse {
-	var updateInput *dynamodb.UpdateItemInput
+	update := dynamo.buildUpdate(request)
 
-	updateInput = &dynamodb.UpdateItemInput{
-		TableName: aws.String(dynamo.Config.TableName),
-		Key: map[string]types.AttributeValue{
-			"USER_ID": &types.AttributeValueMemberS{
-				Value: request.Key,
-			},
-		},
-		AttributeUpdates: ConvertToDynamoUpdateItem(request.Values),
+	updateInput := &dynamodb.UpdateItemInput{
+		TableName:                 update.TableName,
+		Key:                       update.Key,
+		UpdateExpression:          update.UpdateExpression,
+		ConditionExpression:       update.ConditionExpression,
+		ExpressionAttributeNames:  update.ExpressionAttributeNames,
+		ExpressionAttributeValues: update.ExpressionAttributeValues,
+		ReturnValues:              types.ReturnValueAllNew,
+	}
+
+	output, err := dynamo.Client.UpdateItem(context.TODO(), updateInput)
+
+	if err != nil {
+		var awsErr smithy.APIError
+		if errors.As(err, &awsErr) {
+			return Failure(util.AWSErrorToPasswordCaddyError(awsErr))
+		}
+
+		return Failure(apiTypes.PasswordCaddyError{
+			StatusCode: 500,
+			Message:    err.Error(),
+		})
+	}
+
+	return SuccessWithValue(output.Attributes)
+}
+
+// Delete a single item from the DynamoDB table
+//
+// @see - https://docs.aws.amazon.com/sdk-for-go/api/service/dynamodb/#DynamoDB.DeleteItem
+func (dynamo *DynamoClient) Delete(request DynamoDeleteRequest) *DynamoResponse {
+	deleteItem := dynamo.buildDelete(request)
+
+	deleteInput := &dynamodb.DeleteItemInput{
+		TableName:                 deleteItem.TableName,
+		Key:                       deleteItem.Key,
+		ConditionExpression:       deleteItem.ConditionExpression,
+		ExpressionAttributeNames:  deleteItem.ExpressionAttributeNames,
+		ExpressionAttributeValues: deleteItem.ExpressionAttributeValues,
+	}
+
+	_, err := dynamo.Client.DeleteItem(context.TODO(), deleteInput)
+
+	if err != nil {
+		var awsErr smithy.APIError
+		if errors.As(err, &awsErr) {
+			return Failure(util.AWSErrorToPasswordCaddyError(awsErr))
+		}
+
+		return Failure(apiTypes.PasswordCaddyError{
+			StatusCode: 500,
+			Message:    err.Error(),
+		})
+	}
+
+	return Success()
+}
+
+// Write all items in a single transaction. Either all writes succeed or none.
+// When the transaction is canceled the response is a 409 whose error details
+// hold the cancellation reason code of every item (i.e "None", "ConditionalCheckFailed")
+//
+// @see - https://docs.aws.amazon.com/sdk-for-go/api/service/dynamodb/#DynamoDB.TransactWriteItems
+func (dynamo *DynamoClient) TransactWrite(items []DynamoTransactItem) *DynamoResponse {
+	transactItems := make([]types.TransactWriteItem, 0, len(items))
+
+	for _, item := range items {
+		var transactItem types.TransactWriteItem
+
+		if item.Put != nil {
+			transactItem.Put = dynamo.buildPut(*item.Put)
+		} else if item.Update != nil {
+			transactItem.Update = dynamo.buildUpdate(*item.Update)
+		} else if item.Delete != nil {
+			transactItem.Delete = dynamo.buildDelete(*item.Delete)
+		}
+
+		transactItems = append(transactItems, transactItem)
+	}
+
+	transactInput := &dynamodb.TransactWriteItemsInput{
+		TransactItems: transactItems,
 	}
 
-	_, err := dynamo.Client.UpdateItem(context.TODO(), updateInput)
+	_, err := dynamo.Client.TransactWriteItems(context.TODO(), transactInput)
 
 	if err != nil {
+		var canceled *types.TransactionCanceledException
+		if errors.As(err, &canceled) {
+			reasons := make([]string, 0, len(canceled.CancellationReasons))
+
+			for _, reason := range canceled.CancellationReasons {
+				reasons = append(reasons, aws.ToString(reason.Code))
+			}
+
+			return Failure(apiTypes.PasswordCaddyError{
+				StatusCode: 409,
+				Message:    canceled.ErrorMessage(),
+				Details:    reasons,
+			})
+		}
+
 		var awsErr smithy.APIError
 		if errors.As(err, &awsErr) {
 			return Failure(util.AWSErrorToPasswordCaddyError(awsErr))
@@ -149,6 +324,281 @@ func (dynamo *DynamoClient) Update(request DyanamoUpdateRequest) *DynamoResponse
 	return Success()
 }
 
+// Write many items in chunks of MAX_BATCH_WRITE_ITEMS. Items DynamoDB leaves
+// unprocessed (i.e when throttled) are retried with an exponential backoff
+//
+// @see - https://docs.aws.amazon.com/sdk-for-go/api/service/dynamodb/#DynamoDB.BatchWriteItem
+func (dynamo *DynamoClient) BatchWrite(request DynamoBatchWriteRequest) *DynamoResponse {
+	writes := make([]types.WriteRequest, 0, len(request.Puts)+len(request.Deletes))
+
+	for _, put := range request.Puts {
+		writes = append(writes, types.WriteRequest{
+			PutRequest: &types.PutRequest{Item: dynamo.buildPut(put).Item},
+		})
+	}
+
+	for _, deleteRequest := range request.Deletes {
+		writes = append(writes, types.WriteRequest{
+			DeleteRequest: &types.DeleteRequest{Key: ConvertToDynamoKey(deleteRequest.Key, deleteRequest.SortKey)},
+		})
+	}
+
+	for start := 0; start < len(writes); start += MAX_BATCH_WRITE_ITEMS {
+		end := start + MAX_BATCH_WRITE_ITEMS
+
+		if end > len(writes) {
+			end = len(writes)
+		}
+
+		pending := map[string][]types.WriteRequest{
+			dynamo.Config.TableName: writes[start:end],
+		}
+
+		for attempt := 0; len(pending) > 0; attempt++ {
+			if attempt == MAX_BATCH_WRITE_ATTEMPTS {
+				return Failure(apiTypes.PasswordCaddyError{
+					StatusCode: 503,
+					Message:    "Failed to write all items of the batch",
+				})
+			}
+
+			if attempt > 0 {
+				time.Sleep(time.Duration(50<<attempt) * time.Millisecond)
+			}
+
+			output, err := dynamo.Client.BatchWriteItem(context.TODO(), &dynamodb.BatchWriteItemInput{
+				RequestItems: pending,
+			})
+
+			if err != nil {
+				var awsErr smithy.APIError
+				if errors.As(err, &awsErr) {
+					return Failure(util.AWSErrorToPasswordCaddyError(awsErr))
+				}
+
+				return Failure(apiTypes.PasswordCaddyError{
+					StatusCode: 500,
+					Message:    err.Error(),
+				})
+			}
+
+			pending = output.UnprocessedItems
+		}
+	}
+
+	return Success()
+}
+
+func (dynamo *DynamoClient) buildPut(request DynamoPutRequest) *types.Put {
+	request.Values[PARTITION_KEY] = request.Key
+
+	if request.SortKey != "" {
+		request.Values[SORT_KEY] = request.SortKey
+	}
+
+	put := &types.Put{
+		TableName: aws.String(dynamo.Config.TableName),
+		Item:      ConvertToDynamoPutItem(request.Values),
+	}
+
+	if request.Condition != nil {
+		put.ConditionExpression = aws.String(request.Condition.Expression)
+		put.ExpressionAttributeNames, put.ExpressionAttributeValues = ConvertToExpressionAttributes(
+			request.Condition.Names,
+			request.Condition.Values,
+		)
+	}
+
+	return put
+}
+
+func (dynamo *DynamoClient) buildUpdate(request DyanamoUpdateRequest) *types.Update {
+	expression, names, values := ConvertToUpdateExpression(request.Values)
+
+	update := &types.Update{
+		TableName:        aws.String(dynamo.Config.TableName),
+		Key:              ConvertToDynamoKey(request.Key, request.SortKey),
+		UpdateExpression: aws.String(expression),
+	}
+
+	if request.Condition != nil {
+		update.ConditionExpression = aws.String(request.Condition.Expression)
+
+		for key, value := range request.Condition.Names {
+			names[key] = value
+		}
+
+		for key, value := range request.Condition.Values {
+			values[key] = ConvertToAttributeValue(value)
+		}
+	}
+
+	if len(names) > 0 {
+		update.ExpressionAttributeNames = names
+	}
+
+	if len(values) > 0 {
+		update.ExpressionAttributeValues = values
+	}
+
+	return update
+}
+
+func (dynamo *DynamoClient) buildDelete(request DynamoDeleteRequest) *types.Delete {
+	deleteItem := &types.Delete{
+		TableName: aws.String(dynamo.Config.TableName),
+		Key:       ConvertToDynamoKey(request.Key, request.SortKey),
+	}
+
+	if request.Condition != nil {
+		deleteItem.ConditionExpression = aws.String(request.Condition.Expression)
+		deleteItem.ExpressionAttributeNames, deleteItem.ExpressionAttributeValues = ConvertToExpressionAttributes(
+			request.Condition.Names,
+			request.Condition.Values,
+		)
+	}
+
+	return deleteItem
+}
+
+// Query the items of a partition, optionally narrowed down by a sort key prefix.
+// Follows the pagination until all items (or Limit items) are read
+//
+// @see - https://docs.aws.amazon.com/sdk-for-go/api/service/dynamodb/#DynamoDB.Query
+func (dynamo *DynamoClient) Query(request DynamoQueryRequest) *DynamoResponse {
+	keyCondition := "#pk = :pk"
+	names := map[string]string{"#pk": PARTITION_KEY}
+	values := map[string]types.AttributeValue{
+		":pk": &types.AttributeValueMemberS{Value: request.Key},
+	}
+
+	if request.KeyCondition != "" {
+		keyCondition += " AND " + request.KeyCondition
+	} else if request.SortKeyFrom != "" && request.SortKeyTo != "" {
+		keyCondition += " AND #sk BETWEEN :sk AND :skTo"
+		names["#sk"] = SORT_KEY
+		values[":sk"] = &types.AttributeValueMemberS{Value: request.SortKeyFrom}
+		values[":skTo"] = &types.AttributeValueMemberS{Value: request.SortKeyTo}
+	} else if request.SortKeyPrefix != "" {
+		keyCondition += " AND begins_with(#sk, :sk)"
+		names["#sk"] = SORT_KEY
+		values[":sk"] = &types.AttributeValueMemberS{Value: request.SortKeyPrefix}
+	}
+
+	for key, value := range request.Names {
+		names[key] = value
+	}
+
+	for key, value := range request.Values {
+		values[key] = ConvertToAttributeValue(value)
+	}
+
+	items := []map[string]types.AttributeValue{}
+	var startKey map[string]types.AttributeValue
+
+	if request.StartAfter != "" {
+		startKey = ConvertToDynamoKey(request.Key, request.StartAfter)
+	}
+
+	for {
+		queryInput := &dynamodb.QueryInput{
+			TableName:                 aws.String(dynamo.Config.TableName),
+			KeyConditionExpression:    aws.String(keyCondition),
+			ExpressionAttributeNames:  names,
+			ExpressionAttributeValues: values,
+			ScanIndexForward:          aws.Bool(!request.Descending),
+			ConsistentRead:            aws.Bool(request.ConsistentRead),
+			ExclusiveStartKey:         startKey,
+		}
+
+		if request.IndexName != "" {
+			queryInput.IndexName = aws.String(request.IndexName)
+		}
+
+		if request.Filter != "" {
+			queryInput.FilterExpression = aws.String(request.Filter)
+		}
+
+		if request.Limit > 0 {
+			queryInput.Limit = aws.Int32(request.Limit - int32(len(items)))
+		}
+
+		output, err := dynamo.Client.Query(context.TODO(), queryInput)
+
+		if err != nil {
+			var awsErr smithy.APIError
+			if errors.As(err, &awsErr) {
+				return Failure(util.AWSErrorToPasswordCaddyError(awsErr))
+			}
+
+			return Failure(apiTypes.PasswordCaddyError{
+				StatusCode: 500,
+				Message:    err.Error(),
+			})
+		}
+
+		items = append(items, output.Items...)
+		startKey = output.LastEvaluatedKey
+
+		if len(startKey) == 0 || (request.Limit > 0 && int32(len(items)) >= request.Limit) {
+			break
+		}
+	}
+
+	return SuccessWithValue(items)
+}
+
+// Scan all items of the table matching the filter. Follows the pagination
+// until the whole table is read
+//
+// @see - https://docs.aws.amazon.com/sdk-for-go/api/service/dynamodb/#DynamoDB.Scan
+func (dynamo *DynamoClient) Scan(request DynamoScanRequest) *DynamoResponse {
+	values := make(map[string]types.AttributeValue)
+
+	for key, value := range request.Values {
+		values[key] = ConvertToAttributeValue(value)
+	}
+
+	items := []map[string]types.AttributeValue{}
+	var startKey map[string]types.AttributeValue
+
+	for {
+		scanInput := &dynamodb.ScanInput{
+			TableName:         aws.String(dynamo.Config.TableName),
+			ExclusiveStartKey: startKey,
+		}
+
+		if request.Filter != "" {
+			scanInput.FilterExpression = aws.String(request.Filter)
+			scanInput.ExpressionAttributeNames = request.Names
+			scanInput.ExpressionAttributeValues = values
+		}
+
+		output, err := dynamo.Client.Scan(context.TODO(), scanInput)
+
+		if err != nil {
+			var awsErr smithy.APIError
+			if errors.As(err, &awsErr) {
+				return Failure(util.AWSErrorToPasswordCaddyError(awsErr))
+			}
+
+			return Failure(apiTypes.PasswordCaddyError{
+				StatusCode: 500,
+				Message:    err.Error(),
+			})
+		}
+
+		items = append(items, output.Items...)
+		startKey = output.LastEvaluatedKey
+
+		if len(startKey) == 0 {
+			break
+		}
+	}
+
+	return SuccessWithValue(items)
+}
+
 func (response *DynamoResponse) AsUser() *DynamoResponse {
 	var user apiTypes.PasswordCaddyUser
 
@@ -164,48 +614,653 @@ func (response *DynamoResponse) AsUser() *DynamoResponse {
 	return response
 }
 
-func ConvertToDyanamoGetItem(key string) map[string]types.AttributeValue {
-	dynamoItem := make(map[string]types.AttributeValue)
-	dynamoItem["USER_ID"] = &types.AttributeValueMemberS{
-		Value: key,
+func (response *DynamoResponse) AsAccountDeletionMarkers() *DynamoResponse {
+	var markers []apiTypes.AccountDeletionMarker
+
+	if !response.IsSuccess {
+		return response
 	}
 
-	return dynamoItem
+	json := util.SerializeJson(response.Data)
+	util.DeserializeJson(json, &markers)
+
+	response.Data = markers
+
+	return response
 }
 
-/*
-Map a string -> string JSON object to a DynamoDB PutItem
-Update if additional types are needed
-*/
-func ConvertToDynamoPutItem(obj map[string]string) map[string]types.AttributeValue {
-	dynamoItem := make(map[string]types.AttributeValue)
+func (response *DynamoResponse) AsVaultItem() *DynamoResponse {
+	var item apiTypes.VaultItem
 
-	for key, value := range obj {
-		dynamoItem[key] = &types.AttributeValueMemberS{
-			Value: value,
-		}
+	if !response.IsSuccess {
+		return response
 	}
 
-	return dynamoItem
+	json := util.SerializeJson(response.Data)
+	util.DeserializeJson(json, &item)
+
+	response.Data = item
+
+	return response
 }
 
-/*
-Map a string -> string JSON object to a DynamoDB UpdateItem
-Update if additional types are needed
-*/
-func ConvertToDynamoUpdateItem(item map[string]DynamoUpdateItem) map[string]types.AttributeValueUpdate {
-	dynamoItem := make(map[string]types.AttributeValueUpdate)
+func (response *DynamoResponse) AsVaultItems() *DynamoResponse {
+	var items []apiTypes.VaultItem
 
-	for key, value := range item {
-		dynamoItem[key] = types.AttributeValueUpdate{
-			Action: value.Action,
-			Value: &types.AttributeValueMemberS{
-				Value: value.Value,
-			},
-		}
+	if !response.IsSuccess {
+		return response
 	}
 
-	return dynamoItem
+	json := util.SerializeJson(response.Data)
+	util.DeserializeJson(json, &items)
+
+	response.Data = items
+
+	return response
+}
+
+func (response *DynamoResponse) AsVaultTrashMarkers() *DynamoResponse {
+	var markers []apiTypes.VaultTrashMarker
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	json := util.SerializeJson(response.Data)
+	util.DeserializeJson(json, &markers)
+
+	response.Data = markers
+
+	return response
+}
+
+func (response *DynamoResponse) AsVaultItemHistory() *DynamoResponse {
+	var history apiTypes.VaultItemHistory
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	json := util.SerializeJson(response.Data)
+	util.DeserializeJson(json, &history)
+
+	response.Data = history
+
+	return response
+}
+
+func (response *DynamoResponse) AsVaultItemHistories() *DynamoResponse {
+	var histories []apiTypes.VaultItemHistory
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	json := util.SerializeJson(response.Data)
+	util.DeserializeJson(json, &histories)
+
+	response.Data = histories
+
+	return response
+}
+
+func (response *DynamoResponse) AsVaultFolder() *DynamoResponse {
+	var folder apiTypes.VaultFolder
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	json := util.SerializeJson(response.Data)
+	util.DeserializeJson(json, &folder)
+
+	response.Data = folder
+
+	return response
+}
+
+func (response *DynamoResponse) AsVaultFolders() *DynamoResponse {
+	var folders []apiTypes.VaultFolder
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	json := util.SerializeJson(response.Data)
+	util.DeserializeJson(json, &folders)
+
+	response.Data = folders
+
+	return response
+}
+
+func (response *DynamoResponse) AsVaultTombstones() *DynamoResponse {
+	var tombstones []apiTypes.VaultTombstone
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	json := util.SerializeJson(response.Data)
+	util.DeserializeJson(json, &tombstones)
+
+	response.Data = tombstones
+
+	return response
+}
+
+func (response *DynamoResponse) AsVaultSyncState() *DynamoResponse {
+	var state apiTypes.VaultSyncState
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	json := util.SerializeJson(response.Data)
+	util.DeserializeJson(json, &state)
+
+	response.Data = state
+
+	return response
+}
+
+func (response *DynamoResponse) AsOrganization() *DynamoResponse {
+	var org apiTypes.Organization
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	json := util.SerializeJson(response.Data)
+	util.DeserializeJson(json, &org)
+
+	response.Data = org
+
+	return response
+}
+
+func (response *DynamoResponse) AsOrgMember() *DynamoResponse {
+	var member apiTypes.OrgMember
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	json := util.SerializeJson(response.Data)
+	util.DeserializeJson(json, &member)
+
+	response.Data = member
+
+	return response
+}
+
+func (response *DynamoResponse) AsOrgMembers() *DynamoResponse {
+	var members []apiTypes.OrgMember
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	json := util.SerializeJson(response.Data)
+	util.DeserializeJson(json, &members)
+
+	response.Data = members
+
+	return response
+}
+
+func (response *DynamoResponse) AsVaultCollection() *DynamoResponse {
+	var collection apiTypes.VaultCollection
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	json := util.SerializeJson(response.Data)
+	util.DeserializeJson(json, &collection)
+
+	response.Data = collection
+
+	return response
+}
+
+func (response *DynamoResponse) AsVaultCollections() *DynamoResponse {
+	var collections []apiTypes.VaultCollection
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	json := util.SerializeJson(response.Data)
+	util.DeserializeJson(json, &collections)
+
+	response.Data = collections
+
+	return response
+}
+
+func (response *DynamoResponse) AsUserKeys() *DynamoResponse {
+	var keys apiTypes.UserKeys
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	json := util.SerializeJson(response.Data)
+	util.DeserializeJson(json, &keys)
+
+	response.Data = keys
+
+	return response
+}
+
+func (response *DynamoResponse) AsItemShare() *DynamoResponse {
+	var share apiTypes.ItemShare
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	json := util.SerializeJson(response.Data)
+	util.DeserializeJson(json, &share)
+
+	response.Data = share
+
+	return response
+}
+
+func (response *DynamoResponse) AsItemShares() *DynamoResponse {
+	var shares []apiTypes.ItemShare
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	json := util.SerializeJson(response.Data)
+	util.DeserializeJson(json, &shares)
+
+	response.Data = shares
+
+	return response
+}
+
+func (response *DynamoResponse) AsEmergencyAccess() *DynamoResponse {
+	var access apiTypes.EmergencyAccess
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	json := util.SerializeJson(response.Data)
+	util.DeserializeJson(json, &access)
+
+	response.Data = access
+
+	return response
+}
+
+func (response *DynamoResponse) AsEmergencyAccesses() *DynamoResponse {
+	var accesses []apiTypes.EmergencyAccess
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	json := util.SerializeJson(response.Data)
+	util.DeserializeJson(json, &accesses)
+
+	response.Data = accesses
+
+	return response
+}
+
+func (response *DynamoResponse) AsOrgPolicies() *DynamoResponse {
+	var policies []apiTypes.OrgPolicy
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	json := util.SerializeJson(response.Data)
+	util.DeserializeJson(json, &policies)
+
+	response.Data = policies
+
+	return response
+}
+
+func (response *DynamoResponse) AsOrgEvents() *DynamoResponse {
+	var events []apiTypes.OrgEvent
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	json := util.SerializeJson(response.Data)
+	util.DeserializeJson(json, &events)
+
+	response.Data = events
+
+	return response
+}
+
+func (response *DynamoResponse) AsUserEvents() *DynamoResponse {
+	var events []apiTypes.UserEvent
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	json := util.SerializeJson(response.Data)
+	util.DeserializeJson(json, &events)
+
+	response.Data = events
+
+	return response
+}
+
+func (response *DynamoResponse) AsKnownDevices() *DynamoResponse {
+	var devices []apiTypes.KnownDevice
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	json := util.SerializeJson(response.Data)
+	util.DeserializeJson(json, &devices)
+
+	response.Data = devices
+
+	return response
+}
+
+func (response *DynamoResponse) AsLockToken() *DynamoResponse {
+	var token apiTypes.LockToken
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	json := util.SerializeJson(response.Data)
+	util.DeserializeJson(json, &token)
+
+	response.Data = token
+
+	return response
+}
+
+func (response *DynamoResponse) AsUsers() *DynamoResponse {
+	var users []apiTypes.PasswordCaddyUser
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	json := util.SerializeJson(response.Data)
+	util.DeserializeJson(json, &users)
+
+	response.Data = users
+
+	return response
+}
+
+func (response *DynamoResponse) AsAdminAuditEntries() *DynamoResponse {
+	var entries []apiTypes.AdminAuditEntry
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	json := util.SerializeJson(response.Data)
+	util.DeserializeJson(json, &entries)
+
+	response.Data = entries
+
+	return response
+}
+
+func (response *DynamoResponse) AsItemReferences() *DynamoResponse {
+	var references []apiTypes.ItemReference
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	json := util.SerializeJson(response.Data)
+	util.DeserializeJson(json, &references)
+
+	response.Data = references
+
+	return response
+}
+
+func (response *DynamoResponse) AsSend() *DynamoResponse {
+	var send apiTypes.Send
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	json := util.SerializeJson(response.Data)
+	util.DeserializeJson(json, &send)
+
+	response.Data = send
+
+	return response
+}
+
+func (response *DynamoResponse) AsSends() *DynamoResponse {
+	var sends []apiTypes.Send
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	json := util.SerializeJson(response.Data)
+	util.DeserializeJson(json, &sends)
+
+	response.Data = sends
+
+	return response
+}
+
+func (response *DynamoResponse) AsVaultAttachment() *DynamoResponse {
+	var attachment apiTypes.VaultAttachment
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	json := util.SerializeJson(response.Data)
+	util.DeserializeJson(json, &attachment)
+
+	response.Data = attachment
+
+	return response
+}
+
+func (response *DynamoResponse) AsVaultAttachments() *DynamoResponse {
+	var attachments []apiTypes.VaultAttachment
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	json := util.SerializeJson(response.Data)
+	util.DeserializeJson(json, &attachments)
+
+	response.Data = attachments
+
+	return response
+}
+
+func (response *DynamoResponse) AsVaultDomains() *DynamoResponse {
+	var domains apiTypes.VaultDomains
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	json := util.SerializeJson(response.Data)
+	util.DeserializeJson(json, &domains)
+
+	response.Data = domains
+
+	return response
+}
+
+func (response *DynamoResponse) AsVaultItemHealths() *DynamoResponse {
+	var healths []apiTypes.VaultItemHealth
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	json := util.SerializeJson(response.Data)
+	util.DeserializeJson(json, &healths)
+
+	response.Data = healths
+
+	return response
+}
+
+func (response *DynamoResponse) AsBreachRanges() *DynamoResponse {
+	var ranges []apiTypes.BreachRange
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	json := util.SerializeJson(response.Data)
+	util.DeserializeJson(json, &ranges)
+
+	response.Data = ranges
+
+	return response
+}
+
+func ConvertToDyanamoGetItem(key string) map[string]types.AttributeValue {
+	return ConvertToDynamoKey(key, "")
+}
+
+/*
+Build the primary key of an item. The sort key is left out when empty
+*/
+func ConvertToDynamoKey(key, sortKey string) map[string]types.AttributeValue {
+	dynamoItem := make(map[string]types.AttributeValue)
+	dynamoItem[PARTITION_KEY] = &types.AttributeValueMemberS{
+		Value: key,
+	}
+
+	if sortKey != "" {
+		dynamoItem[SORT_KEY] = &types.AttributeValueMemberS{
+			Value: sortKey,
+		}
+	}
+
+	return dynamoItem
+}
+
+/*
+Map a Go value to a DynamoDB attribute value.
+Integers become numbers, bools become booleans and everything else a string.
+Update if additional types are needed
+*/
+func ConvertToAttributeValue(value interface{}) types.AttributeValue {
+	switch v := value.(type) {
+	case string:
+		return &types.AttributeValueMemberS{Value: v}
+	case int:
+		return &types.AttributeValueMemberN{Value: strconv.Itoa(v)}
+	case int64:
+		return &types.AttributeValueMemberN{Value: strconv.FormatInt(v, 10)}
+	case bool:
+		return &types.AttributeValueMemberBOOL{Value: v}
+	default:
+		return &types.AttributeValueMemberS{Value: fmt.Sprint(v)}
+	}
+}
+
+/*
+Map a string -> value JSON object to a DynamoDB PutItem
+*/
+func ConvertToDynamoPutItem(obj map[string]interface{}) map[string]types.AttributeValue {
+	dynamoItem := make(map[string]types.AttributeValue)
+
+	for key, value := range obj {
+		dynamoItem[key] = ConvertToAttributeValue(value)
+	}
+
+	return dynamoItem
+}
+
+/*
+Map the placeholders of an expression to DynamoDB attribute names and values.
+Empty maps are returned as nil since DynamoDB rejects empty placeholder maps
+*/
+func ConvertToExpressionAttributes(names map[string]string, values map[string]interface{}) (map[string]string, map[string]types.AttributeValue) {
+	var attributeValues map[string]types.AttributeValue
+
+	if len(names) == 0 {
+		names = nil
+	}
+
+	if len(values) > 0 {
+		attributeValues = ConvertToDynamoPutItem(values)
+	}
+
+	return names, attributeValues
+}
+
+/*
+Map a string -> value JSON object to a DynamoDB update expression.
+Put actions become SET, Add actions ADD and Delete actions REMOVE clauses
+*/
+func ConvertToUpdateExpression(item map[string]DynamoUpdateItem) (string, map[string]string, map[string]types.AttributeValue) {
+	names := make(map[string]string)
+	values := make(map[string]types.AttributeValue)
+	clauses := make(map[types.AttributeAction][]string)
+
+	keys := make([]string, 0, len(item))
+	for key := range item {
+		keys = append(keys, key)
+	}
+
+	// Sort the attributes so the same update always builds the same expression
+	sort.Strings(keys)
+
+	for i, key := range keys {
+		name := fmt.Sprintf("#u%d", i)
+		value := fmt.Sprintf(":u%d", i)
+		names[name] = key
+
+		switch item[key].Action {
+		case types.AttributeActionDelete:
+			clauses[types.AttributeActionDelete] = append(clauses[types.AttributeActionDelete], name)
+		case types.AttributeActionAdd:
+			clauses[types.AttributeActionAdd] = append(clauses[types.AttributeActionAdd], name+" "+value)
+			values[value] = ConvertToAttributeValue(item[key].Value)
+		default:
+			clauses[types.AttributeActionPut] = append(clauses[types.AttributeActionPut], name+" = "+value)
+			values[value] = ConvertToAttributeValue(item[key].Value)
+		}
+	}
+
+	expression := []string{}
+
+	if len(clauses[types.AttributeActionPut]) > 0 {
+		expression = append(expression, "SET "+strings.Join(clauses[types.AttributeActionPut], ", "))
+	}
+
+	if len(clauses[types.AttributeActionAdd]) > 0 {
+		expression = append(expression, "ADD "+strings.Join(clauses[types.AttributeActionAdd], ", "))
+	}
+
+	if len(clauses[types.AttributeActionDelete]) > 0 {
+		expression = append(expression, "REMOVE "+strings.Join(clauses[types.AttributeActionDelete], ", "))
+	}
+
+	return strings.Join(expression, " "), names, values
 }
 
 func Success() *DynamoResponse {
@@ -0,0 +1,83 @@
+package dynamoclient
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+/***** ConvertToAttributeValue *****/
+
+func TestConvertToAttributeValueWithString(t *testing.T) {
+	actual := ConvertToAttributeValue("foo").(*types.AttributeValueMemberS).Value
+	expected := "foo"
+
+	if actual != expected {
+		t.Errorf("FAILED - TestConvertToAttributeValueWithString | Actual: %s | Expected: %s", actual, expected)
+	}
+}
+
+func TestConvertToAttributeValueWithInt(t *testing.T) {
+	actual := ConvertToAttributeValue(42).(*types.AttributeValueMemberN).Value
+	expected := "42"
+
+	if actual != expected {
+		t.Errorf("FAILED - TestConvertToAttributeValueWithInt | Actual: %s | Expected: %s", actual, expected)
+	}
+}
+
+func TestConvertToAttributeValueWithBool(t *testing.T) {
+	actual := ConvertToAttributeValue(true).(*types.AttributeValueMemberBOOL).Value
+
+	if actual != true {
+		t.Errorf("FAILED - TestConvertToAttributeValueWithBool | Actual: %v | Expected: %v", actual, true)
+	}
+}
+
+/***** End ConvertToAttributeValue *****/
+
+/***** ConvertToDynamoKey *****/
+
+func TestConvertToDynamoKeyWithoutSortKey(t *testing.T) {
+	actual := ConvertToDynamoKey("foo@bar.com", "")
+
+	if len(actual) != 1 || actual[PARTITION_KEY].(*types.AttributeValueMemberS).Value != "foo@bar.com" {
+		t.Errorf("FAILED - TestConvertToDynamoKeyWithoutSortKey | Actual: %+v", actual)
+	}
+}
+
+func TestConvertToDynamoKeyWithSortKey(t *testing.T) {
+	actual := ConvertToDynamoKey("foo@bar.com", "ITEM#1")
+
+	if len(actual) != 2 || actual[SORT_KEY].(*types.AttributeValueMemberS).Value != "ITEM#1" {
+		t.Errorf("FAILED - TestConvertToDynamoKeyWithSortKey | Actual: %+v", actual)
+	}
+}
+
+/***** End ConvertToDynamoKey *****/
+
+/***** ConvertToUpdateExpression *****/
+
+func TestConvertToUpdateExpression(t *testing.T) {
+	expression, names, values := ConvertToUpdateExpression(map[string]DynamoUpdateItem{
+		"STATUS":     {Action: types.AttributeActionPut, Value: "ACTIVE"},
+		"COUNTER":    {Action: types.AttributeActionAdd, Value: 1},
+		"DELETED_AT": {Action: types.AttributeActionDelete},
+	})
+
+	expected := "SET #u2 = :u2 ADD #u0 :u0 REMOVE #u1"
+
+	if expression != expected {
+		t.Errorf("FAILED - TestConvertToUpdateExpression | Actual: %s | Expected: %s", expression, expected)
+	}
+
+	if names["#u0"] != "COUNTER" || names["#u1"] != "DELETED_AT" || names["#u2"] != "STATUS" {
+		t.Errorf("FAILED - TestConvertToUpdateExpression - Names | Actual: %+v", names)
+	}
+
+	if len(values) != 2 {
+		t.Errorf("FAILED - TestConvertToUpdateExpression - Values | Actual: %d | Expected: %d", len(values), 2)
+	}
+}
+
+/***** End ConvertToUpdateExpression *****/
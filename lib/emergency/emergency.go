@@ -0,0 +1,525 @@
+package emergency
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"time"
+
+	appConfig "password-caddy/api/core/config"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/dynamoclient"
+	"password-caddy/api/lib/vault"
+
+	dynamoTypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/google/uuid"
+)
+
+/*
+Keys of an emergency access. The grantor's partition holds it under
+EMERGENCY#<id>, the grantee's partition a copy under GRANTED#<id>. While a
+recovery is initiated the EMERGENCY_RECOVERIES partition holds a marker under
+RECOVERY#<recovery at>#<id>, so due recoveries are found with a query sorted
+by when they are due instead of a scan of the table
+*/
+const (
+	EMERGENCY_PREFIX = "EMERGENCY#"
+	GRANTED_PREFIX   = "GRANTED#"
+	RECOVERIES_KEY   = "EMERGENCY_RECOVERIES"
+	RECOVERY_PREFIX  = "RECOVERY#"
+)
+
+/*
+What a grantee gets once a recovery request is approved. View access reads
+the grantor's vault, takeover additionally replaces the grantor's key pair so
+the grantee can take over an account whose owner lost their key
+*/
+const (
+	TYPE_VIEW     = "view"
+	TYPE_TAKEOVER = "takeover"
+)
+
+/*
+Statuses of an emergency access. The grantee accepts the invite, then the
+grantor confirms it by wrapping their user key with the grantee's public key.
+A confirmed grantee can initiate a recovery, which is approved when the
+grantor approves it or the waiting period elapses, whichever comes first
+*/
+const (
+	STATUS_INVITED            = "invited"
+	STATUS_ACCEPTED           = "accepted"
+	STATUS_CONFIRMED          = "confirmed"
+	STATUS_RECOVERY_INITIATED = "recoveryInitiated"
+	STATUS_RECOVERY_APPROVED  = "recoveryApproved"
+)
+
+const (
+	MIN_WAIT_DAYS  = 1
+	MAX_WAIT_DAYS  = 90
+	MAX_KEY_LENGTH = 4096
+	MAX_GRANTEES   = 10
+)
+
+// WaitDays defaults to the configured waiting period when it is 0
+type InviteRequest struct {
+	Grantee  string `json:"grantee"`
+	Type     string `json:"type"`
+	WaitDays int    `json:"waitDays"`
+}
+
+type ConfirmRequest struct {
+	Key string `json:"key"`
+}
+
+// An emergency access as its grantor or grantee sees it. Key is only sent to the grantee of an approved recovery
+type EmergencyAccessResponse struct {
+	Id                  string `json:"id"`
+	Grantor             string `json:"grantor"`
+	Grantee             string `json:"grantee"`
+	Type                string `json:"type"`
+	WaitDays            int    `json:"waitDays"`
+	Status              string `json:"status"`
+	Key                 string `json:"key,omitempty"`
+	RecoveryInitiatedAt string `json:"recoveryInitiatedAt,omitempty"`
+	RecoveryAt          string `json:"recoveryAt,omitempty"`
+	CreatedAt           string `json:"createdAt"`
+	UpdatedAt           string `json:"updatedAt"`
+}
+
+// The emergency accesses a user granted to others and was granted by others
+type EmergencyAccessListResponse struct {
+	Trusted []EmergencyAccessResponse `json:"trusted"`
+	Granted []EmergencyAccessResponse `json:"granted"`
+}
+
+// The grantor's vault as the grantee of an approved recovery sees it
+type EmergencyVaultResponse struct {
+	Access  EmergencyAccessResponse     `json:"access"`
+	Items   []vault.VaultItemResponse   `json:"items"`
+	Folders []vault.VaultFolderResponse `json:"folders"`
+}
+
+/********** CONFIG **********/
+
+// Waiting period of invites that do not set one
+func DefaultWaitDays() int {
+	return int(appConfig.Get("EMERGENCY_WAIT_DAYS", "7").ToInt64())
+}
+
+// Link of the emails, where users manage their emergency access
+func AccessUrl() string {
+	return appConfig.Get("EMERGENCY_ACCESS_URL", "https://password-caddy.com/emergency-access").ToString()
+}
+
+/********** KEYS **********/
+
+func IsValidAccessId(accessId string) bool {
+	_, err := uuid.Parse(accessId)
+	return err == nil
+}
+
+func GrantorKey(accessId string) string {
+	return EMERGENCY_PREFIX + accessId
+}
+
+func GranteeKey(accessId string) string {
+	return GRANTED_PREFIX + accessId
+}
+
+// recoveryAt is RFC3339 in UTC, so the markers sort by when they are due
+func RecoveryKey(recoveryAt, accessId string) string {
+	return RECOVERY_PREFIX + recoveryAt + "#" + accessId
+}
+
+/********** VALIDATION **********/
+
+func (request InviteRequest) Validate(grantorId string) error {
+	address, err := mail.ParseAddress(request.Grantee)
+
+	if err != nil || address.Address != request.Grantee {
+		return errors.New("Grantee must be an email address")
+	}
+
+	if request.Grantee == grantorId {
+		return errors.New("Emergency access can not be granted to yourself")
+	}
+
+	if request.Type != TYPE_VIEW && request.Type != TYPE_TAKEOVER {
+		return errors.New("Type must be view or takeover")
+	}
+
+	if request.WaitDays != 0 && (request.WaitDays < MIN_WAIT_DAYS || request.WaitDays > MAX_WAIT_DAYS) {
+		return fmt.Errorf("Waiting period must be between %d and %d days", MIN_WAIT_DAYS, MAX_WAIT_DAYS)
+	}
+
+	return nil
+}
+
+// The grantor's user key wrapped by the client, the server never sees it unwrapped
+func (request ConfirmRequest) Validate() error {
+	if request.Key == "" || len(request.Key) > MAX_KEY_LENGTH {
+		return fmt.Errorf("Key is required and can have at most %d characters", MAX_KEY_LENGTH)
+	}
+
+	return nil
+}
+
+/********** STATE **********/
+
+// When a recovery initiated at initiatedAt is approved without the grantor
+func RecoveryAt(initiatedAt time.Time, waitDays int) time.Time {
+	return initiatedAt.Add(time.Duration(waitDays) * 24 * time.Hour)
+}
+
+// Check if the waiting period of an initiated recovery elapsed
+func IsDue(access types.EmergencyAccess, now time.Time) bool {
+	if access.Status.Value != STATUS_RECOVERY_INITIATED {
+		return false
+	}
+
+	recoveryAt, err := time.Parse(time.RFC3339, access.RecoveryAt.Value)
+
+	return err == nil && !now.Before(recoveryAt)
+}
+
+// Check if the grantee can read the grantor's vault
+func CanView(access types.EmergencyAccess) bool {
+	return access.Status.Value == STATUS_RECOVERY_APPROVED
+}
+
+// Check if the grantee can replace the grantor's key pair
+func CanTakeover(access types.EmergencyAccess) bool {
+	return CanView(access) && access.Type.Value == TYPE_TAKEOVER
+}
+
+/********** RESPONSES **********/
+
+func ToAccessResponse(access types.EmergencyAccess) EmergencyAccessResponse {
+	return EmergencyAccessResponse{
+		Id:                  access.AccessId.Value,
+		Grantor:             access.GrantorId.Value,
+		Grantee:             access.GranteeId.Value,
+		Type:                access.Type.Value,
+		WaitDays:            access.WaitDays.Value,
+		Status:              access.Status.Value,
+		RecoveryInitiatedAt: access.RecoveryInitiatedAt.Value,
+		RecoveryAt:          access.RecoveryAt.Value,
+		CreatedAt:           access.CreatedAt.Value,
+		UpdatedAt:           access.UpdatedAt.Value,
+	}
+}
+
+func ToGranteeResponse(access types.EmergencyAccess) EmergencyAccessResponse {
+	response := ToAccessResponse(access)
+
+	if CanView(access) {
+		response.Key = access.Key.Value
+	}
+
+	return response
+}
+
+/********** OPERATIONS **********/
+
+// Get an emergency access the user granted. A missing one results in an empty EmergencyAccess
+func Get(client *dynamoclient.DynamoClient, grantorId, accessId string) *dynamoclient.DynamoResponse {
+	return client.
+		Get(dynamoclient.DynamoGetRequest{
+			Key:     grantorId,
+			SortKey: GrantorKey(accessId),
+		}).
+		AsEmergencyAccess()
+}
+
+// Get an emergency access the user was granted. A missing one results in an empty EmergencyAccess
+func GetGranted(client *dynamoclient.DynamoClient, granteeId, accessId string) *dynamoclient.DynamoResponse {
+	return client.
+		Get(dynamoclient.DynamoGetRequest{
+			Key:     granteeId,
+			SortKey: GranteeKey(accessId),
+		}).
+		AsEmergencyAccess()
+}
+
+// Get the emergency accesses the user granted to trusted contacts
+func ListTrusted(client *dynamoclient.DynamoClient, grantorId string) *dynamoclient.DynamoResponse {
+	return client.
+		Query(dynamoclient.DynamoQueryRequest{
+			Key:           grantorId,
+			SortKeyPrefix: EMERGENCY_PREFIX,
+		}).
+		AsEmergencyAccesses()
+}
+
+// Get the emergency accesses other users granted the user
+func ListGranted(client *dynamoclient.DynamoClient, granteeId string) *dynamoclient.DynamoResponse {
+	return client.
+		Query(dynamoclient.DynamoQueryRequest{
+			Key:           granteeId,
+			SortKeyPrefix: GRANTED_PREFIX,
+		}).
+		AsEmergencyAccesses()
+}
+
+/*
+Find the initiated recoveries of all users whose waiting period elapsed. The
+markers of the recoveries hold what is needed to approve them
+*/
+func ListDue(client *dynamoclient.DynamoClient, now time.Time) *dynamoclient.DynamoResponse {
+	return client.
+		Query(dynamoclient.DynamoQueryRequest{
+			Key:         RECOVERIES_KEY,
+			SortKeyFrom: RECOVERY_PREFIX,
+			// "~" sorts after the ids of the recoveries due at the same second
+			SortKeyTo: RecoveryKey(now.UTC().Format(time.RFC3339), "~"),
+		}).
+		AsEmergencyAccesses()
+}
+
+/*
+Invite a trusted contact. Fails with a 409 when the grantor already has
+MAX_GRANTEES trusted contacts
+*/
+func Invite(client *dynamoclient.DynamoClient, grantorId string, request InviteRequest) *dynamoclient.DynamoResponse {
+	response := ListTrusted(client, grantorId)
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	if len(response.Data.([]types.EmergencyAccess)) >= MAX_GRANTEES {
+		return dynamoclient.Failure(types.PasswordCaddyError{
+			StatusCode: 409,
+			Message:    fmt.Sprintf("An account can have at most %d emergency contacts", MAX_GRANTEES),
+		})
+	}
+
+	var access types.EmergencyAccess
+
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	access.AccessId.Value = uuid.NewString()
+	access.UserId.Value = grantorId
+	access.ItemKey.Value = GrantorKey(access.AccessId.Value)
+	access.GrantorId.Value = grantorId
+	access.GranteeId.Value = request.Grantee
+	access.Type.Value = request.Type
+	access.WaitDays.Value = request.WaitDays
+	access.Status.Value = STATUS_INVITED
+	access.CreatedAt.Value = now
+	access.UpdatedAt.Value = now
+
+	if access.WaitDays.Value == 0 {
+		access.WaitDays.Value = DefaultWaitDays()
+	}
+
+	values := map[string]interface{}{
+		"ACCESS_ID":  access.AccessId.Value,
+		"GRANTOR_ID": access.GrantorId.Value,
+		"GRANTEE_ID": access.GranteeId.Value,
+		"TYPE":       access.Type.Value,
+		"WAIT_DAYS":  access.WaitDays.Value,
+		"STATUS":     access.Status.Value,
+		"CREATED_AT": access.CreatedAt.Value,
+		"UPDATED_AT": access.UpdatedAt.Value,
+	}
+
+	response = client.TransactWrite([]dynamoclient.DynamoTransactItem{
+		{
+			Put: &dynamoclient.DynamoPutRequest{
+				Key:       grantorId,
+				SortKey:   GrantorKey(access.AccessId.Value),
+				Values:    values,
+				Condition: notExists(),
+			},
+		},
+		{
+			Put: &dynamoclient.DynamoPutRequest{
+				Key:       request.Grantee,
+				SortKey:   GranteeKey(access.AccessId.Value),
+				Values:    values,
+				Condition: notExists(),
+			},
+		},
+	})
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	return dynamoclient.SuccessWithValue(access)
+}
+
+// Accept an invite. Fails with a 409 when the emergency access is no longer invited
+func Accept(client *dynamoclient.DynamoClient, access types.EmergencyAccess) *dynamoclient.DynamoResponse {
+	return update(client, access, STATUS_INVITED, map[string]dynamoclient.DynamoUpdateItem{
+		"STATUS": put(STATUS_ACCEPTED),
+	})
+}
+
+// Confirm an accepted grantee with the grantor's user key wrapped with the grantee's public key
+func Confirm(client *dynamoclient.DynamoClient, access types.EmergencyAccess, key string) *dynamoclient.DynamoResponse {
+	return update(client, access, STATUS_ACCEPTED, map[string]dynamoclient.DynamoUpdateItem{
+		"STATUS": put(STATUS_CONFIRMED),
+		"KEY":    put(key),
+	})
+}
+
+// Start the waiting period of a recovery. Fails with a 409 when the emergency access is not confirmed
+func Initiate(client *dynamoclient.DynamoClient, access types.EmergencyAccess, now time.Time) *dynamoclient.DynamoResponse {
+	recoveryAt := RecoveryAt(now, access.WaitDays.Value).UTC().Format(time.RFC3339)
+
+	marker := dynamoclient.DynamoTransactItem{
+		Put: &dynamoclient.DynamoPutRequest{
+			Key:     RECOVERIES_KEY,
+			SortKey: RecoveryKey(recoveryAt, access.AccessId.Value),
+			Values: map[string]interface{}{
+				"ACCESS_ID":   access.AccessId.Value,
+				"GRANTOR_ID":  access.GrantorId.Value,
+				"GRANTEE_ID":  access.GranteeId.Value,
+				"TYPE":        access.Type.Value,
+				"STATUS":      STATUS_RECOVERY_INITIATED,
+				"RECOVERY_AT": recoveryAt,
+			},
+		},
+	}
+
+	return update(client, access, STATUS_CONFIRMED, map[string]dynamoclient.DynamoUpdateItem{
+		"STATUS":                put(STATUS_RECOVERY_INITIATED),
+		"RECOVERY_INITIATED_AT": put(now.UTC().Format(time.RFC3339)),
+		"RECOVERY_AT":           put(recoveryAt),
+	}, marker)
+}
+
+// Approve an initiated recovery, by the grantor or at the end of the waiting period
+func Approve(client *dynamoclient.DynamoClient, access types.EmergencyAccess) *dynamoclient.DynamoResponse {
+	return update(client, access, STATUS_RECOVERY_INITIATED, map[string]dynamoclient.DynamoUpdateItem{
+		"STATUS": put(STATUS_RECOVERY_APPROVED),
+	}, removeMarker(access)...)
+}
+
+// Reject an initiated recovery or take back an approved one. The grantee stays a confirmed trusted contact
+func Reject(client *dynamoclient.DynamoClient, access types.EmergencyAccess) *dynamoclient.DynamoResponse {
+	return update(client, access, access.Status.Value, map[string]dynamoclient.DynamoUpdateItem{
+		"STATUS":                put(STATUS_CONFIRMED),
+		"RECOVERY_INITIATED_AT": {Action: dynamoTypes.AttributeActionDelete},
+		"RECOVERY_AT":           {Action: dynamoTypes.AttributeActionDelete},
+	}, removeMarker(access)...)
+}
+
+// Remove an emergency access, from the partitions of the grantor and the grantee
+func Remove(client *dynamoclient.DynamoClient, access types.EmergencyAccess) *dynamoclient.DynamoResponse {
+	items := []dynamoclient.DynamoTransactItem{
+		{
+			Delete: &dynamoclient.DynamoDeleteRequest{
+				Key:       access.GrantorId.Value,
+				SortKey:   GrantorKey(access.AccessId.Value),
+				Condition: exists(),
+			},
+		},
+		{
+			Delete: &dynamoclient.DynamoDeleteRequest{
+				Key:     access.GranteeId.Value,
+				SortKey: GranteeKey(access.AccessId.Value),
+			},
+		},
+	}
+
+	return client.TransactWrite(append(items, removeMarker(access)...))
+}
+
+// Delete the marker of a recovery, if one was ever initiated
+func removeMarker(access types.EmergencyAccess) []dynamoclient.DynamoTransactItem {
+	if access.RecoveryAt.Value == "" {
+		return nil
+	}
+
+	return []dynamoclient.DynamoTransactItem{
+		{
+			Delete: &dynamoclient.DynamoDeleteRequest{
+				Key:     RECOVERIES_KEY,
+				SortKey: RecoveryKey(access.RecoveryAt.Value, access.AccessId.Value),
+			},
+		},
+	}
+}
+
+/*
+Update both copies of an emergency access in one transaction, as long as the
+grantor's copy still has the status, together with the writes of the recovery
+marker. The updated emergency access is returned as the response data
+*/
+func update(client *dynamoclient.DynamoClient, access types.EmergencyAccess, status string, values map[string]dynamoclient.DynamoUpdateItem, marker ...dynamoclient.DynamoTransactItem) *dynamoclient.DynamoResponse {
+	now := time.Now().UTC().Format(time.RFC3339)
+	values["UPDATED_AT"] = put(now)
+
+	items := []dynamoclient.DynamoTransactItem{
+		{
+			Update: &dynamoclient.DyanamoUpdateRequest{
+				Key:     access.GrantorId.Value,
+				SortKey: GrantorKey(access.AccessId.Value),
+				Values:  values,
+				Condition: &dynamoclient.DynamoCondition{
+					Expression: "#status = :status",
+					Names:      map[string]string{"#status": "STATUS"},
+					Values:     map[string]interface{}{":status": status},
+				},
+			},
+		},
+		{
+			Update: &dynamoclient.DyanamoUpdateRequest{
+				Key:       access.GranteeId.Value,
+				SortKey:   GranteeKey(access.AccessId.Value),
+				Values:    values,
+				Condition: exists(),
+			},
+		},
+	}
+
+	response := client.TransactWrite(append(items, marker...))
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	for attribute, value := range values {
+		updated, _ := value.Value.(string)
+
+		switch attribute {
+		case "STATUS":
+			access.Status.Value = updated
+		case "KEY":
+			access.Key.Value = updated
+		case "RECOVERY_INITIATED_AT":
+			access.RecoveryInitiatedAt.Value = updated
+		case "RECOVERY_AT":
+			access.RecoveryAt.Value = updated
+		case "UPDATED_AT":
+			access.UpdatedAt.Value = updated
+		}
+	}
+
+	return dynamoclient.SuccessWithValue(access)
+}
+
+func put(value string) dynamoclient.DynamoUpdateItem {
+	return dynamoclient.DynamoUpdateItem{
+		Action: dynamoTypes.AttributeActionPut,
+		Value:  value,
+	}
+}
+
+func exists() *dynamoclient.DynamoCondition {
+	return &dynamoclient.DynamoCondition{
+		Expression: "attribute_exists(#sk)",
+		Names:      map[string]string{"#sk": dynamoclient.SORT_KEY},
+	}
+}
+
+func notExists() *dynamoclient.DynamoCondition {
+	return &dynamoclient.DynamoCondition{
+		Expression: "attribute_not_exists(#sk)",
+		Names:      map[string]string{"#sk": dynamoclient.SORT_KEY},
+	}
+}
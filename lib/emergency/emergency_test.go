@@ -0,0 +1,118 @@
+package emergency
+
+import (
+	"testing"
+	"time"
+
+	"password-caddy/api/core/types"
+)
+
+func access(accessType, status string, recoveryAt time.Time) types.EmergencyAccess {
+	var access types.EmergencyAccess
+	access.Type.Value = accessType
+	access.Status.Value = status
+	access.Key.Value = "wrapped"
+	access.RecoveryAt.Value = recoveryAt.UTC().Format(time.RFC3339)
+	return access
+}
+
+/***** Validate *****/
+
+func TestInviteRequestValidate(t *testing.T) {
+	tests := []struct {
+		request InviteRequest
+		valid   bool
+	}{
+		{InviteRequest{Grantee: "bob@example.com", Type: TYPE_VIEW}, true},
+		{InviteRequest{Grantee: "bob@example.com", Type: TYPE_TAKEOVER, WaitDays: MAX_WAIT_DAYS}, true},
+		{InviteRequest{Grantee: "bob@example.com", Type: TYPE_VIEW, WaitDays: MAX_WAIT_DAYS + 1}, false},
+		{InviteRequest{Grantee: "bob@example.com", Type: TYPE_VIEW, WaitDays: -1}, false},
+		{InviteRequest{Grantee: "bob@example.com", Type: "admin"}, false},
+		{InviteRequest{Grantee: "alice@example.com", Type: TYPE_VIEW}, false},
+		{InviteRequest{Grantee: "bob", Type: TYPE_VIEW}, false},
+	}
+
+	for _, test := range tests {
+		if err := test.request.Validate("alice@example.com"); (err == nil) != test.valid {
+			t.Errorf("FAILED - TestInviteRequestValidate | Request: %v | Error: %v | Expected valid: %v", test.request, err, test.valid)
+		}
+	}
+}
+
+/***** Keys *****/
+
+func TestRecoveryKeySortsByDueTime(t *testing.T) {
+	accessId := "6f1e4a3c-9a8b-4c2d-8e7f-0a1b2c3d4e5f"
+	earlier := RecoveryKey("2026-01-01T10:00:00Z", accessId)
+	later := RecoveryKey("2026-01-01T10:00:01Z", accessId)
+	bound := RecoveryKey("2026-01-01T10:00:00Z", "~")
+
+	if !(earlier < later) || !(earlier <= bound) || !(later > bound) {
+		t.Errorf("FAILED - TestRecoveryKeySortsByDueTime | Earlier: %s | Later: %s | Bound: %s", earlier, later, bound)
+	}
+}
+
+/***** State *****/
+
+func TestIsDue(t *testing.T) {
+	now := time.Now()
+
+	if IsDue(access(TYPE_VIEW, STATUS_RECOVERY_INITIATED, now.Add(time.Hour)), now) {
+		t.Errorf("FAILED - TestIsDue | Expected: not due before the waiting period elapsed")
+	}
+
+	if !IsDue(access(TYPE_VIEW, STATUS_RECOVERY_INITIATED, now.Add(-time.Hour)), now) {
+		t.Errorf("FAILED - TestIsDue | Expected: due after the waiting period elapsed")
+	}
+
+	if IsDue(access(TYPE_VIEW, STATUS_CONFIRMED, now.Add(-time.Hour)), now) {
+		t.Errorf("FAILED - TestIsDue | Expected: a rejected recovery to never be due")
+	}
+}
+
+func TestRecoveryAt(t *testing.T) {
+	initiatedAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	expected := time.Date(2024, 1, 8, 12, 0, 0, 0, time.UTC)
+
+	if actual := RecoveryAt(initiatedAt, 7); !actual.Equal(expected) {
+		t.Errorf("FAILED - TestRecoveryAt | Actual: %v | Expected: %v", actual, expected)
+	}
+}
+
+func TestAccessRights(t *testing.T) {
+	now := time.Now()
+
+	view := access(TYPE_VIEW, STATUS_RECOVERY_APPROVED, now)
+	takeover := access(TYPE_TAKEOVER, STATUS_RECOVERY_APPROVED, now)
+	initiated := access(TYPE_TAKEOVER, STATUS_RECOVERY_INITIATED, now)
+
+	if !CanView(view) || CanTakeover(view) {
+		t.Errorf("FAILED - TestAccessRights | Expected: view access only")
+	}
+
+	if !CanTakeover(takeover) {
+		t.Errorf("FAILED - TestAccessRights | Expected: takeover rights after approval")
+	}
+
+	if CanView(initiated) || CanTakeover(initiated) {
+		t.Errorf("FAILED - TestAccessRights | Expected: no access during the waiting period")
+	}
+}
+
+/***** Responses *****/
+
+func TestGranteeResponseOnlyHasKeyAfterApproval(t *testing.T) {
+	now := time.Now()
+
+	if ToGranteeResponse(access(TYPE_VIEW, STATUS_CONFIRMED, now)).Key != "" {
+		t.Errorf("FAILED - TestGranteeResponseOnlyHasKeyAfterApproval | Expected: no key before approval")
+	}
+
+	if ToGranteeResponse(access(TYPE_VIEW, STATUS_RECOVERY_APPROVED, now)).Key != "wrapped" {
+		t.Errorf("FAILED - TestGranteeResponseOnlyHasKeyAfterApproval | Expected: the key after approval")
+	}
+
+	if ToAccessResponse(access(TYPE_VIEW, STATUS_RECOVERY_APPROVED, now)).Key != "" {
+		t.Errorf("FAILED - TestGranteeResponseOnlyHasKeyAfterApproval | Expected: no key for the grantor")
+	}
+}
@@ -0,0 +1,304 @@
+package export
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	appConfig "password-caddy/api/core/config"
+	"password-caddy/api/core/types"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Version of the export format. Bumped whenever the format changes.
+// Version 2 added the vault of an export and the key of password protected ones
+const EXPORT_VERSION = 2
+
+// Name of the format when an export is imported again
+const FORMAT = "passwordcaddy"
+
+// Key derivation of password protected exports. The iterations are capped at
+// KdfIterations, so an uploaded export can not make the server derive for long
+const (
+	KDF_PBKDF2_SHA256  = "pbkdf2-sha256"
+	MIN_KDF_ITERATIONS = 100000
+	SALT_LENGTH        = 16
+	KEY_LENGTH         = 32
+)
+
+// Minimum length of the passphrase of a password protected export
+const MIN_PASSPHRASE_LENGTH = 12
+
+// Maximum length of the client encoded key of a password protected export
+const MAX_KEY_LENGTH = 1024
+
+type Folder struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type Item struct {
+	Id       string `json:"id"`
+	FolderId string `json:"folderId,omitempty"`
+	Data     string `json:"data"`
+}
+
+/*
+Export of the items and folders of a vault. Names and data stay encrypted by
+the client with the symmetric key of the vault, Vault is the user or
+organization it was exported from.
+
+Only a password protected export carries the key, sealed with the rest of the
+export under the passphrase. The client of another account opens the export
+with the passphrase, decrypts the items with Key and re-encrypts them with its
+own key before it imports them as items. The server can not re-encrypt, so it
+only imports an export as is into the vault it was exported from
+*/
+type Export struct {
+	Version    int      `json:"version"`
+	Encrypted  bool     `json:"encrypted"`
+	ExportedAt string   `json:"exportedAt"`
+	Vault      string   `json:"vault,omitempty"`
+	Key        string   `json:"key,omitempty"`
+	Folders    []Folder `json:"folders"`
+	Items      []Item   `json:"items"`
+}
+
+/*
+Password protected export. Data is the export sealed with AES-256-GCM under a
+key derived from the export passphrase. The other fields are authenticated
+with it, so they can not be changed without the passphrase
+*/
+type EncryptedExport struct {
+	Version       int    `json:"version"`
+	Encrypted     bool   `json:"encrypted"`
+	Kdf           string `json:"kdf"`
+	KdfIterations int    `json:"kdfIterations"`
+	Salt          string `json:"salt"`
+	Nonce         string `json:"nonce"`
+	Data          string `json:"data"`
+}
+
+// Number of PBKDF2 iterations of new password protected exports
+func KdfIterations() int {
+	return int(appConfig.Get("EXPORT_KDF_ITERATIONS", "600000").ToInt64())
+}
+
+// Build the export of a vault. Items in the trash are not exported
+func New(vault string, items []types.VaultItem, folders []types.VaultFolder, now time.Time) Export {
+	export := Export{
+		Version:    EXPORT_VERSION,
+		ExportedAt: now.UTC().Format(time.RFC3339),
+		Vault:      vault,
+		Folders:    []Folder{},
+		Items:      []Item{},
+	}
+
+	for _, folder := range folders {
+		export.Folders = append(export.Folders, Folder{
+			Id:   folder.FolderId.Value,
+			Name: folder.Name.Value,
+		})
+	}
+
+	for _, item := range items {
+		if item.DeletedAt.Value != "" {
+			continue
+		}
+
+		export.Items = append(export.Items, Item{
+			Id:       item.ItemId.Value,
+			FolderId: item.FolderId.Value,
+			Data:     item.Data.Value,
+		})
+	}
+
+	return export
+}
+
+// Protect an export with a passphrase. The export must carry the key of its
+// vault, otherwise it could not be imported into another account
+func Seal(export Export, passphrase string, iterations int) (EncryptedExport, error) {
+	if len(passphrase) < MIN_PASSPHRASE_LENGTH {
+		return EncryptedExport{}, fmt.Errorf("Passphrase must be at least %d characters", MIN_PASSPHRASE_LENGTH)
+	}
+
+	if err := ValidateKey(export.Key); err != nil {
+		return EncryptedExport{}, err
+	}
+
+	if iterations < MIN_KDF_ITERATIONS || iterations > KdfIterations() {
+		return EncryptedExport{}, errors.New("Invalid key derivation iterations")
+	}
+
+	salt := make([]byte, SALT_LENGTH)
+
+	if _, err := rand.Read(salt); err != nil {
+		return EncryptedExport{}, err
+	}
+
+	sealed := EncryptedExport{
+		Version:       EXPORT_VERSION,
+		Encrypted:     true,
+		Kdf:           KDF_PBKDF2_SHA256,
+		KdfIterations: iterations,
+		Salt:          base64.StdEncoding.EncodeToString(salt),
+	}
+
+	aead, err := newCipher(passphrase, salt, iterations)
+
+	if err != nil {
+		return EncryptedExport{}, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+
+	if _, err := rand.Read(nonce); err != nil {
+		return EncryptedExport{}, err
+	}
+
+	plaintext, err := json.Marshal(export)
+
+	if err != nil {
+		return EncryptedExport{}, err
+	}
+
+	sealed.Nonce = base64.StdEncoding.EncodeToString(nonce)
+	sealed.Data = base64.StdEncoding.EncodeToString(aead.Seal(nil, nonce, plaintext, sealed.additionalData()))
+
+	return sealed, nil
+}
+
+// Open a password protected export. A wrong passphrase fails to authenticate the data
+func Open(sealed EncryptedExport, passphrase string) (Export, error) {
+	if sealed.Kdf != KDF_PBKDF2_SHA256 {
+		return Export{}, fmt.Errorf("Unsupported key derivation %s", sealed.Kdf)
+	}
+
+	if sealed.KdfIterations < MIN_KDF_ITERATIONS || sealed.KdfIterations > KdfIterations() {
+		return Export{}, errors.New("Invalid key derivation iterations")
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(sealed.Salt)
+
+	if err != nil {
+		return Export{}, errors.New("Invalid salt")
+	}
+
+	nonce, err := base64.StdEncoding.DecodeString(sealed.Nonce)
+
+	if err != nil {
+		return Export{}, errors.New("Invalid nonce")
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(sealed.Data)
+
+	if err != nil {
+		return Export{}, errors.New("Invalid export data")
+	}
+
+	aead, err := newCipher(passphrase, salt, sealed.KdfIterations)
+
+	if err != nil {
+		return Export{}, err
+	}
+
+	if len(nonce) != aead.NonceSize() {
+		return Export{}, errors.New("Invalid nonce")
+	}
+
+	plaintext, err := aead.Open(nil, nonce, ciphertext, sealed.additionalData())
+
+	if err != nil {
+		return Export{}, errors.New("Wrong passphrase or corrupted export")
+	}
+
+	var export Export
+
+	if err := json.Unmarshal(plaintext, &export); err != nil {
+		return Export{}, errors.New("Invalid export data")
+	}
+
+	return export, nil
+}
+
+func ValidateKey(key string) error {
+	if key == "" || len(key) > MAX_KEY_LENGTH {
+		return fmt.Errorf("Key is required and can have at most %d characters", MAX_KEY_LENGTH)
+	}
+
+	return nil
+}
+
+/*
+Check that an export can be imported as is into a vault. The items of an
+export of another vault are encrypted with its key and have to be
+re-encrypted by the client. Exports of version 1 do not know their vault
+*/
+func (export Export) CheckImportInto(vault string) error {
+	if export.Vault != "" && export.Vault != vault {
+		return errors.New("Items exported from another vault must be re-encrypted with the key of this vault and imported as items")
+	}
+
+	return nil
+}
+
+// Parse an export of either format. The passphrase is only used for password protected exports
+func Parse(data []byte, passphrase string) (Export, error) {
+	var header struct {
+		Version   int  `json:"version"`
+		Encrypted bool `json:"encrypted"`
+	}
+
+	if err := json.Unmarshal(data, &header); err != nil {
+		return Export{}, fmt.Errorf("Invalid export: %s", err.Error())
+	}
+
+	if header.Version < 1 || header.Version > EXPORT_VERSION {
+		return Export{}, fmt.Errorf("Unsupported export version %d", header.Version)
+	}
+
+	if !header.Encrypted {
+		var export Export
+		err := json.Unmarshal(data, &export)
+		return export, err
+	}
+
+	var sealed EncryptedExport
+
+	if err := json.Unmarshal(data, &sealed); err != nil {
+		return Export{}, fmt.Errorf("Invalid export: %s", err.Error())
+	}
+
+	return Open(sealed, passphrase)
+}
+
+/*
+Derive a key from a passphrase with PBKDF2 and HMAC-SHA256 (RFC 8018)
+
+@see - https://datatracker.ietf.org/doc/html/rfc8018#section-5.2
+*/
+func DeriveKey(passphrase string, salt []byte, iterations, keyLength int) []byte {
+	return pbkdf2.Key([]byte(passphrase), salt, iterations, keyLength, sha256.New)
+}
+
+func newCipher(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(DeriveKey(passphrase, salt, iterations, KEY_LENGTH))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func (sealed EncryptedExport) additionalData() []byte {
+	return []byte(fmt.Sprintf("%d|%s|%d|%s", sealed.Version, sealed.Kdf, sealed.KdfIterations, sealed.Salt))
+}
@@ -0,0 +1,176 @@
+package export
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"password-caddy/api/core/types"
+	"testing"
+	"time"
+)
+
+const passphrase = "correct horse battery staple"
+
+func testExport() Export {
+	active := types.VaultItem{}
+	active.ItemId.Value = "a"
+	active.FolderId.Value = "f"
+	active.Data.Value = "encrypted"
+
+	trashed := types.VaultItem{}
+	trashed.ItemId.Value = "b"
+	trashed.DeletedAt.Value = "2022-03-01T00:00:00Z"
+
+	folder := types.VaultFolder{}
+	folder.FolderId.Value = "f"
+	folder.Name.Value = "encrypted name"
+
+	export := New("foo@bar.com", []types.VaultItem{active, trashed}, []types.VaultFolder{folder}, time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC))
+	export.Key = "key"
+
+	return export
+}
+
+func TestNewSkipsTrashedItems(t *testing.T) {
+	actual := testExport()
+
+	if actual.Version != EXPORT_VERSION || len(actual.Items) != 1 || actual.Items[0].Id != "a" || len(actual.Folders) != 1 {
+		t.Errorf("FAILED - TestNewSkipsTrashedItems | Actual: %+v | Expected: item a and folder f", actual)
+	}
+
+	if actual.ExportedAt != "2022-03-01T00:00:00Z" {
+		t.Errorf("FAILED - TestNewSkipsTrashedItems | Actual: %s | Expected: %s", actual.ExportedAt, "2022-03-01T00:00:00Z")
+	}
+}
+
+func TestDeriveKey(t *testing.T) {
+	// PBKDF2-HMAC-SHA256 test vectors of RFC 7914
+	actual := hex.EncodeToString(DeriveKey("passwd", []byte("salt"), 1, 64))
+	expected := "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"
+
+	if actual != expected {
+		t.Errorf("FAILED - TestDeriveKey | Actual: %s | Expected: %s", actual, expected)
+	}
+
+	actual = hex.EncodeToString(DeriveKey("password", []byte("salt"), 4096, 32))
+	expected = "c5e478d59288c841aa530db6845c4c8d962893a001ce4e11a4963873aa98134a"
+
+	if actual != expected {
+		t.Errorf("FAILED - TestDeriveKey | Actual: %s | Expected: %s", actual, expected)
+	}
+}
+
+func TestSealAndOpen(t *testing.T) {
+	sealed, err := Seal(testExport(), passphrase, MIN_KDF_ITERATIONS)
+
+	if err != nil {
+		t.Fatalf("FAILED - TestSealAndOpen | Error: %s", err.Error())
+	}
+
+	actual, err := Open(sealed, passphrase)
+
+	if err != nil {
+		t.Fatalf("FAILED - TestSealAndOpen | Error: %s", err.Error())
+	}
+
+	if len(actual.Items) != 1 || actual.Items[0].Data != "encrypted" {
+		t.Errorf("FAILED - TestSealAndOpen | Actual: %+v | Expected: the sealed export", actual)
+	}
+}
+
+func TestOpenWithWrongPassphrase(t *testing.T) {
+	sealed, _ := Seal(testExport(), passphrase, MIN_KDF_ITERATIONS)
+
+	_, err := Open(sealed, "not the passphrase")
+
+	if err == nil {
+		t.Errorf("FAILED - TestOpenWithWrongPassphrase | Actual: %v | Expected: an error", err)
+	}
+}
+
+func TestOpenWithTamperedHeader(t *testing.T) {
+	sealed, _ := Seal(testExport(), passphrase, MIN_KDF_ITERATIONS)
+	sealed.KdfIterations++
+
+	_, err := Open(sealed, passphrase)
+
+	if err == nil {
+		t.Errorf("FAILED - TestOpenWithTamperedHeader | Actual: %v | Expected: an error", err)
+	}
+}
+
+func TestOpenCapsIterations(t *testing.T) {
+	sealed, _ := Seal(testExport(), passphrase, MIN_KDF_ITERATIONS)
+	sealed.KdfIterations = KdfIterations() + 1
+
+	_, err := Open(sealed, passphrase)
+
+	if err == nil || err.Error() != "Invalid key derivation iterations" {
+		t.Errorf("FAILED - TestOpenCapsIterations | Actual: %v | Expected: the iterations to be rejected", err)
+	}
+}
+
+func TestSealWithShortPassphrase(t *testing.T) {
+	_, err := Seal(testExport(), "short", MIN_KDF_ITERATIONS)
+
+	if err == nil {
+		t.Errorf("FAILED - TestSealWithShortPassphrase | Actual: %v | Expected: an error", err)
+	}
+}
+
+func TestParseBothFormats(t *testing.T) {
+	plain, _ := json.Marshal(testExport())
+	sealed, _ := Seal(testExport(), passphrase, MIN_KDF_ITERATIONS)
+	encrypted, _ := json.Marshal(sealed)
+
+	for _, data := range [][]byte{plain, encrypted} {
+		actual, err := Parse(data, passphrase)
+
+		if err != nil || len(actual.Items) != 1 {
+			t.Errorf("FAILED - TestParseBothFormats | Actual: %+v, %v | Expected: the export", actual, err)
+		}
+	}
+}
+
+func TestParseWithUnsupportedVersion(t *testing.T) {
+	_, err := Parse([]byte(`{"version": 99}`), "")
+
+	if err == nil {
+		t.Errorf("FAILED - TestParseWithUnsupportedVersion | Actual: %v | Expected: an error", err)
+	}
+}
+
+func TestKdfIterationsDefault(t *testing.T) {
+	actual := KdfIterations()
+	expected := 600000
+
+	if actual != expected {
+		t.Errorf("FAILED - TestKdfIterationsDefault | Actual: %d | Expected: %d", actual, expected)
+	}
+}
+
+func TestSealRequiresKey(t *testing.T) {
+	export := testExport()
+	export.Key = ""
+
+	if _, err := Seal(export, passphrase, MIN_KDF_ITERATIONS); err == nil {
+		t.Errorf("FAILED - TestSealRequiresKey | Actual: %v | Expected: an export without key to be rejected", err)
+	}
+}
+
+func TestCheckImportInto(t *testing.T) {
+	export := testExport()
+
+	if err := export.CheckImportInto("foo@bar.com"); err != nil {
+		t.Errorf("FAILED - TestCheckImportInto - Same vault | Actual: %v | Expected: no error", err)
+	}
+
+	if err := export.CheckImportInto("bar@foo.com"); err == nil {
+		t.Errorf("FAILED - TestCheckImportInto - Other vault | Expected: an error")
+	}
+
+	export.Vault = ""
+
+	if err := export.CheckImportInto("bar@foo.com"); err != nil {
+		t.Errorf("FAILED - TestCheckImportInto - Version 1 | Actual: %v | Expected: no error", err)
+	}
+}
@@ -0,0 +1,69 @@
+package generator
+
+import (
+	"crypto/rand"
+	"math"
+	"math/big"
+)
+
+// Kinds of generated values
+const (
+	TYPE_PASSWORD   = "password"
+	TYPE_PASSPHRASE = "passphrase"
+	TYPE_USERNAME   = "username"
+)
+
+// A generated value and the bits of entropy it was generated with
+type Generated struct {
+	Type    string  `json:"type"`
+	Value   string  `json:"value"`
+	Entropy float64 `json:"entropy"`
+}
+
+// Uniform random number in [0, max) from the CSPRNG
+func randomInt(max int) (int, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(max)))
+
+	if err != nil {
+		return 0, err
+	}
+
+	return int(n.Int64()), nil
+}
+
+// Random characters of the given alphabet
+func randomString(alphabet string, length int) (string, error) {
+	result := make([]byte, length)
+
+	for i := range result {
+		n, err := randomInt(len(alphabet))
+
+		if err != nil {
+			return "", err
+		}
+
+		result[i] = alphabet[n]
+	}
+
+	return string(result), nil
+}
+
+// Fisher-Yates shuffle with the CSPRNG
+func shuffle(values []byte) error {
+	for i := len(values) - 1; i > 0; i-- {
+		j, err := randomInt(i + 1)
+
+		if err != nil {
+			return err
+		}
+
+		values[i], values[j] = values[j], values[i]
+	}
+
+	return nil
+}
+
+// Round the entropy to 2 decimals for the response
+func bits(entropy float64) float64 {
+	return math.Round(entropy*100) / 100
+}
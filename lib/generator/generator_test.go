@@ -0,0 +1,169 @@
+package generator
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+func countIn(value, chars string) int {
+	count := 0
+
+	for _, r := range value {
+		if strings.ContainsRune(chars, r) {
+			count++
+		}
+	}
+
+	return count
+}
+
+/***** Password *****/
+
+func TestGeneratePasswordDefaults(t *testing.T) {
+	actual, err := GeneratePassword(DefaultPasswordOptions())
+
+	if err != nil {
+		t.Fatalf("FAILED - TestGeneratePasswordDefaults | Error: %s", err.Error())
+	}
+
+	if len(actual.Value) != 16 {
+		t.Errorf("FAILED - TestGeneratePasswordDefaults | Actual: %d | Expected: %d", len(actual.Value), 16)
+	}
+
+	expected := math.Round(16*math.Log2(75)*100) / 100
+
+	if actual.Entropy != expected {
+		t.Errorf("FAILED - TestGeneratePasswordDefaults | Actual: %v | Expected: %v", actual.Entropy, expected)
+	}
+}
+
+func TestGeneratePasswordMinimumCounts(t *testing.T) {
+	options := PasswordOptions{Length: 8, Lower: true, Digits: true, Symbols: true, MinDigits: 3, MinSymbols: 4}
+
+	for i := 0; i < 50; i++ {
+		actual, _ := GeneratePassword(options)
+
+		if countIn(actual.Value, DIGIT_CHARS) < 3 || countIn(actual.Value, SYMBOL_CHARS) < 4 {
+			t.Fatalf("FAILED - TestGeneratePasswordMinimumCounts | Actual: %s | Expected: at least 3 digits and 4 symbols", actual.Value)
+		}
+
+		if countIn(actual.Value, UPPER_CHARS) > 0 {
+			t.Fatalf("FAILED - TestGeneratePasswordMinimumCounts | Actual: %s | Expected: no upper case characters", actual.Value)
+		}
+	}
+}
+
+func TestGeneratePasswordExcludeAmbiguous(t *testing.T) {
+	options := DefaultPasswordOptions()
+	options.Length = 128
+	options.ExcludeAmbiguous = true
+
+	for i := 0; i < 20; i++ {
+		actual, _ := GeneratePassword(options)
+
+		if countIn(actual.Value, AMBIGUOUS_CHARS) > 0 {
+			t.Fatalf("FAILED - TestGeneratePasswordExcludeAmbiguous | Actual: %s | Expected: no ambiguous characters", actual.Value)
+		}
+	}
+}
+
+func TestPasswordOptionsValidate(t *testing.T) {
+	invalid := []PasswordOptions{
+		{Length: 3, Lower: true},
+		{Length: 129, Lower: true},
+		{Length: 16},
+		{Length: 4, Lower: true, Digits: true, MinLower: 3, MinDigits: 2},
+		{Length: 16, Lower: true, MinLower: -1},
+	}
+
+	for _, options := range invalid {
+		if options.Validate() == nil {
+			t.Errorf("FAILED - TestPasswordOptionsValidate | Options: %+v | Expected: an error", options)
+		}
+	}
+}
+
+/***** Passphrase *****/
+
+func TestWords(t *testing.T) {
+	list := Words()
+
+	if len(list) != 7776 || list[0] != "abacus" || list[len(list)-1] != "zoom" {
+		t.Errorf("FAILED - TestWords | Actual: %d words | Expected: the 7776 words of the EFF wordlist", len(list))
+	}
+}
+
+func TestGeneratePassphrase(t *testing.T) {
+	actual, err := GeneratePassphrase(PassphraseOptions{Words: 5, Separator: ".", Capitalize: true, IncludeNumber: true})
+
+	if err != nil {
+		t.Fatalf("FAILED - TestGeneratePassphrase | Error: %s", err.Error())
+	}
+
+	parts := strings.Split(actual.Value, ".")
+
+	if len(parts) != 5 || countIn(actual.Value, DIGIT_CHARS) != 1 {
+		t.Errorf("FAILED - TestGeneratePassphrase | Actual: %s | Expected: 5 words with a single digit", actual.Value)
+	}
+
+	for _, part := range parts {
+		if !unicode.IsUpper(rune(part[0])) {
+			t.Errorf("FAILED - TestGeneratePassphrase | Actual: %s | Expected: capitalized words", actual.Value)
+		}
+	}
+
+	expected := math.Round((5*math.Log2(7776)+math.Log2(10)+math.Log2(5))*100) / 100
+
+	if actual.Entropy != expected {
+		t.Errorf("FAILED - TestGeneratePassphrase | Actual: %v | Expected: %v", actual.Entropy, expected)
+	}
+}
+
+func TestPassphraseOptionsValidate(t *testing.T) {
+	if (PassphraseOptions{Words: 2}).Validate() == nil {
+		t.Errorf("FAILED - TestPassphraseOptionsValidate | Words: 2 | Expected: an error")
+	}
+}
+
+/***** Username *****/
+
+func TestGenerateUsernameWord(t *testing.T) {
+	actual, _ := GenerateUsername(UsernameOptions{Kind: USERNAME_WORD, IncludeNumber: true})
+
+	if countIn(actual.Value, DIGIT_CHARS) != 4 {
+		t.Errorf("FAILED - TestGenerateUsernameWord | Actual: %s | Expected: a word with 4 digits", actual.Value)
+	}
+}
+
+func TestGenerateUsernameCatchall(t *testing.T) {
+	actual, _ := GenerateUsername(UsernameOptions{Kind: USERNAME_CATCHALL, Domain: "Example.com"})
+
+	if !strings.HasSuffix(actual.Value, "@example.com") || len(actual.Value) != EMAIL_RANDOM_LENGTH+len("@example.com") {
+		t.Errorf("FAILED - TestGenerateUsernameCatchall | Actual: %s | Expected: a random address at example.com", actual.Value)
+	}
+}
+
+func TestGenerateUsernameSubaddress(t *testing.T) {
+	actual, _ := GenerateUsername(UsernameOptions{Kind: USERNAME_SUBADDRESS, Email: "bob@example.com"})
+
+	if !strings.HasPrefix(actual.Value, "bob+") || !strings.HasSuffix(actual.Value, "@example.com") {
+		t.Errorf("FAILED - TestGenerateUsernameSubaddress | Actual: %s | Expected: bob+...@example.com", actual.Value)
+	}
+}
+
+func TestUsernameOptionsValidate(t *testing.T) {
+	invalid := []UsernameOptions{
+		{Kind: "random"},
+		{Kind: USERNAME_CATCHALL, Domain: "localhost"},
+		{Kind: USERNAME_CATCHALL, Domain: "-bad.com"},
+		{Kind: USERNAME_SUBADDRESS, Email: "Bob <bob@example.com>"},
+	}
+
+	for _, options := range invalid {
+		if options.Validate() == nil {
+			t.Errorf("FAILED - TestUsernameOptionsValidate | Options: %+v | Expected: an error", options)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package generator
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Bounds of the number of words of passphrases
+const (
+	MIN_PASSPHRASE_WORDS = 3
+	MAX_PASSPHRASE_WORDS = 20
+)
+
+// IncludeNumber appends a random digit to one of the words
+type PassphraseOptions struct {
+	Words         int
+	Separator     string
+	Capitalize    bool
+	IncludeNumber bool
+}
+
+func DefaultPassphraseOptions() PassphraseOptions {
+	return PassphraseOptions{
+		Words:     6,
+		Separator: "-",
+	}
+}
+
+func (options PassphraseOptions) Validate() error {
+	if options.Words < MIN_PASSPHRASE_WORDS || options.Words > MAX_PASSPHRASE_WORDS {
+		return fmt.Errorf("Words must be between %d and %d", MIN_PASSPHRASE_WORDS, MAX_PASSPHRASE_WORDS)
+	}
+
+	if len(options.Separator) > 3 {
+		return fmt.Errorf("Separator can be at most 3 characters")
+	}
+
+	return nil
+}
+
+/*
+Generate a passphrase out of words of the EFF wordlist. Every word adds
+log2(7776) bits, the number adds the bits of the digit and of its position
+*/
+func GeneratePassphrase(options PassphraseOptions) (Generated, error) {
+	if err := options.Validate(); err != nil {
+		return Generated{}, err
+	}
+
+	chosen := make([]string, options.Words)
+
+	for i := range chosen {
+		word, err := randomWord()
+
+		if err != nil {
+			return Generated{}, err
+		}
+
+		if options.Capitalize {
+			word = strings.ToUpper(word[:1]) + word[1:]
+		}
+
+		chosen[i] = word
+	}
+
+	entropy := float64(options.Words) * math.Log2(float64(len(Words())))
+
+	if options.IncludeNumber {
+		position, err := randomInt(options.Words)
+
+		if err != nil {
+			return Generated{}, err
+		}
+
+		digit, err := randomInt(10)
+
+		if err != nil {
+			return Generated{}, err
+		}
+
+		chosen[position] += fmt.Sprint(digit)
+		entropy += math.Log2(10) + math.Log2(float64(options.Words))
+	}
+
+	return Generated{
+		Type:    TYPE_PASSPHRASE,
+		Value:   strings.Join(chosen, options.Separator),
+		Entropy: bits(entropy),
+	}, nil
+}
@@ -0,0 +1,162 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Character classes of passwords
+const (
+	UPPER_CHARS     = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	LOWER_CHARS     = "abcdefghijklmnopqrstuvwxyz"
+	DIGIT_CHARS     = "0123456789"
+	SYMBOL_CHARS    = "!@#$%^&*-_=+?"
+	AMBIGUOUS_CHARS = "Il1O0"
+)
+
+// Bounds of the length of passwords
+const (
+	MIN_PASSWORD_LENGTH = 4
+	MAX_PASSWORD_LENGTH = 128
+)
+
+// The Min counts only apply to classes that are enabled
+type PasswordOptions struct {
+	Length           int
+	Upper            bool
+	Lower            bool
+	Digits           bool
+	Symbols          bool
+	ExcludeAmbiguous bool
+	MinUpper         int
+	MinLower         int
+	MinDigits        int
+	MinSymbols       int
+}
+
+func DefaultPasswordOptions() PasswordOptions {
+	return PasswordOptions{
+		Length:  16,
+		Upper:   true,
+		Lower:   true,
+		Digits:  true,
+		Symbols: true,
+	}
+}
+
+type charClass struct {
+	chars string
+	min   int
+}
+
+// The enabled character classes with their minimum counts
+func (options PasswordOptions) classes() []charClass {
+	classes := []charClass{}
+	enabled := []struct {
+		on    bool
+		chars string
+		min   int
+	}{
+		{options.Upper, UPPER_CHARS, options.MinUpper},
+		{options.Lower, LOWER_CHARS, options.MinLower},
+		{options.Digits, DIGIT_CHARS, options.MinDigits},
+		{options.Symbols, SYMBOL_CHARS, options.MinSymbols},
+	}
+
+	for _, class := range enabled {
+		if !class.on {
+			continue
+		}
+
+		chars := class.chars
+
+		if options.ExcludeAmbiguous {
+			chars = strings.Map(func(r rune) rune {
+				if strings.ContainsRune(AMBIGUOUS_CHARS, r) {
+					return -1
+				}
+				return r
+			}, chars)
+		}
+
+		classes = append(classes, charClass{chars: chars, min: class.min})
+	}
+
+	return classes
+}
+
+func (options PasswordOptions) Validate() error {
+	if options.Length < MIN_PASSWORD_LENGTH || options.Length > MAX_PASSWORD_LENGTH {
+		return fmt.Errorf("Length must be between %d and %d", MIN_PASSWORD_LENGTH, MAX_PASSWORD_LENGTH)
+	}
+
+	classes := options.classes()
+
+	if len(classes) == 0 {
+		return errors.New("At least one character class is required")
+	}
+
+	required := 0
+
+	for _, class := range classes {
+		if class.min < 0 {
+			return errors.New("Minimum counts can not be negative")
+		}
+
+		required += class.min
+	}
+
+	if required > options.Length {
+		return errors.New("Minimum counts exceed the length")
+	}
+
+	return nil
+}
+
+/*
+Generate a password out of the enabled character classes. The minimum count of
+each class is drawn from the class, the rest from all classes, and the result
+is shuffled. The entropy is length * log2(number of characters), the common
+estimate that ignores the small loss of the minimum counts
+*/
+func GeneratePassword(options PasswordOptions) (Generated, error) {
+	if err := options.Validate(); err != nil {
+		return Generated{}, err
+	}
+
+	classes := options.classes()
+	all := ""
+	password := []byte{}
+
+	for _, class := range classes {
+		all += class.chars
+
+		chars, err := randomString(class.chars, class.min)
+
+		if err != nil {
+			return Generated{}, err
+		}
+
+		password = append(password, chars...)
+	}
+
+	rest, err := randomString(all, options.Length-len(password))
+
+	if err != nil {
+		return Generated{}, err
+	}
+
+	password = append(password, rest...)
+
+	if err := shuffle(password); err != nil {
+		return Generated{}, err
+	}
+
+	return Generated{
+		Type:    TYPE_PASSWORD,
+		Value:   string(password),
+		Entropy: bits(float64(options.Length) * math.Log2(float64(len(all)))),
+	}, nil
+}
@@ -0,0 +1,149 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/mail"
+	"strings"
+)
+
+// Kinds of usernames
+const (
+	USERNAME_WORD       = "word"
+	USERNAME_CATCHALL   = "catchall"
+	USERNAME_SUBADDRESS = "subaddress"
+)
+
+// Length of the random part of catch-all and plus addresses
+const EMAIL_RANDOM_LENGTH = 8
+
+const emailRandomChars = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+/*
+Domain is the catch-all domain of a catch-all address.
+Email is the address a plus address (i.e bob+x7k2q9ab@example.com) is built from
+*/
+type UsernameOptions struct {
+	Kind          string
+	Capitalize    bool
+	IncludeNumber bool
+	Domain        string
+	Email         string
+}
+
+func DefaultUsernameOptions() UsernameOptions {
+	return UsernameOptions{Kind: USERNAME_WORD}
+}
+
+func (options UsernameOptions) Validate() error {
+	switch options.Kind {
+	case USERNAME_WORD:
+		return nil
+	case USERNAME_CATCHALL:
+		if !isDomain(options.Domain) {
+			return errors.New("A valid domain is required for catch-all usernames")
+		}
+
+		return nil
+	case USERNAME_SUBADDRESS:
+		address, err := mail.ParseAddress(options.Email)
+
+		if err != nil || address.Address != options.Email {
+			return errors.New("A valid email is required for plus addressed usernames")
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("Username kind must be one of %s, %s or %s", USERNAME_WORD, USERNAME_CATCHALL, USERNAME_SUBADDRESS)
+}
+
+// Generate a username. Random words can get a 4 digit number appended
+func GenerateUsername(options UsernameOptions) (Generated, error) {
+	if err := options.Validate(); err != nil {
+		return Generated{}, err
+	}
+
+	switch options.Kind {
+	case USERNAME_CATCHALL:
+		local, err := randomString(emailRandomChars, EMAIL_RANDOM_LENGTH)
+
+		if err != nil {
+			return Generated{}, err
+		}
+
+		return Generated{
+			Type:    TYPE_USERNAME,
+			Value:   local + "@" + strings.ToLower(options.Domain),
+			Entropy: emailEntropy(),
+		}, nil
+	case USERNAME_SUBADDRESS:
+		tag, err := randomString(emailRandomChars, EMAIL_RANDOM_LENGTH)
+
+		if err != nil {
+			return Generated{}, err
+		}
+
+		at := strings.LastIndex(options.Email, "@")
+
+		return Generated{
+			Type:    TYPE_USERNAME,
+			Value:   options.Email[:at] + "+" + tag + options.Email[at:],
+			Entropy: emailEntropy(),
+		}, nil
+	}
+
+	word, err := randomWord()
+
+	if err != nil {
+		return Generated{}, err
+	}
+
+	if options.Capitalize {
+		word = strings.ToUpper(word[:1]) + word[1:]
+	}
+
+	entropy := math.Log2(float64(len(Words())))
+
+	if options.IncludeNumber {
+		number, err := randomInt(10000)
+
+		if err != nil {
+			return Generated{}, err
+		}
+
+		word += fmt.Sprintf("%04d", number)
+		entropy += math.Log2(10000)
+	}
+
+	return Generated{
+		Type:    TYPE_USERNAME,
+		Value:   word,
+		Entropy: bits(entropy),
+	}, nil
+}
+
+func emailEntropy() float64 {
+	return bits(EMAIL_RANDOM_LENGTH * math.Log2(float64(len(emailRandomChars))))
+}
+
+func isDomain(domain string) bool {
+	if len(domain) == 0 || len(domain) > 253 || !strings.Contains(domain, ".") {
+		return false
+	}
+
+	for _, label := range strings.Split(domain, ".") {
+		if len(label) == 0 || len(label) > 63 || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return false
+		}
+
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-') {
+				return false
+			}
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,49 @@
+package generator
+
+import (
+	_ "embed"
+	"strings"
+	"sync"
+)
+
+/*
+The EFF large wordlist for passphrases (7776 words, one per roll of five dice)
+
+@see - https://www.eff.org/deeplinks/2016/07/new-wordlists-random-passphrases
+*/
+//go:embed eff_large_wordlist.txt
+var effLargeWordlist string
+
+var (
+	words     []string
+	wordsOnce sync.Once
+)
+
+// The words of the EFF wordlist in the order of their rolls
+func Words() []string {
+	wordsOnce.Do(func() {
+		lines := strings.Split(strings.TrimSpace(effLargeWordlist), "\n")
+		words = make([]string, 0, len(lines))
+
+		for _, line := range lines {
+			fields := strings.Fields(line)
+
+			if len(fields) == 2 {
+				words = append(words, fields[1])
+			}
+		}
+	})
+
+	return words
+}
+
+func randomWord() (string, error) {
+	list := Words()
+	n, err := randomInt(len(list))
+
+	if err != nil {
+		return "", err
+	}
+
+	return list[n], nil
+}
@@ -0,0 +1,211 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	appConfig "password-caddy/api/core/config"
+)
+
+// Shown when the location of an address is not known
+const UNKNOWN_LOCATION = "Unknown location"
+
+// Columns of the database before the coordinates, which are not used
+const (
+	COLUMN_START = iota
+	COLUMN_END
+	COLUMN_CONTINENT
+	COLUMN_COUNTRY
+	COLUMN_REGION
+	COLUMN_CITY
+	MIN_COLUMNS
+)
+
+// The approximate location of an IP address. Country is the ISO 3166 code
+type Location struct {
+	Country string
+	Region  string
+	City    string
+}
+
+// The addresses from Start to End, both inclusive, in their 16 byte form
+type Range struct {
+	Start    net.IP
+	End      net.IP
+	Location Location
+}
+
+// Ranges of IPv4 and IPv6 addresses ordered by their start
+type Database struct {
+	Ranges []Range
+}
+
+var (
+	loadOnce sync.Once
+	loaded   *Database
+	loadErr  error
+)
+
+/********** CONFIG **********/
+
+/*
+Path of the offline database, a CSV file in the format of the DB-IP IP to City
+Lite database (start, end, continent, country, region, city, latitude,
+longitude). Locations are unknown when it is not set
+*/
+func DatabasePath() string {
+	return appConfig.Get("GEOIP_DATABASE", "").ToString()
+}
+
+/********** LOADING **********/
+
+// Read a database from CSV. Rows of ranges that are not valid are rejected
+func Load(reader io.Reader) (*Database, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	csvReader.ReuseRecord = true
+
+	database := Database{Ranges: []Range{}}
+
+	for line := 1; ; line++ {
+		record, err := csvReader.Read()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(record) < MIN_COLUMNS {
+			return nil, fmt.Errorf("Line %d of the GeoIP database has too few columns", line)
+		}
+
+		start := net.ParseIP(strings.TrimSpace(record[COLUMN_START]))
+		end := net.ParseIP(strings.TrimSpace(record[COLUMN_END]))
+
+		if start == nil || end == nil || bytes.Compare(start.To16(), end.To16()) > 0 {
+			return nil, fmt.Errorf("Line %d of the GeoIP database has an invalid range", line)
+		}
+
+		database.Ranges = append(database.Ranges, Range{
+			Start: start.To16(),
+			End:   end.To16(),
+			Location: Location{
+				Country: strings.TrimSpace(record[COLUMN_COUNTRY]),
+				Region:  strings.TrimSpace(record[COLUMN_REGION]),
+				City:    strings.TrimSpace(record[COLUMN_CITY]),
+			},
+		})
+	}
+
+	sort.Slice(database.Ranges, func(i, j int) bool {
+		return bytes.Compare(database.Ranges[i].Start, database.Ranges[j].Start) < 0
+	})
+
+	return &database, nil
+}
+
+// Read a database from a CSV file
+func LoadFile(path string) (*Database, error) {
+	file, err := os.Open(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	return Load(file)
+}
+
+/*
+The database at the configured path. It is read once and kept for the
+following invocations of a warm lambda
+*/
+func Default() (*Database, error) {
+	loadOnce.Do(func() {
+		path := DatabasePath()
+
+		if path == "" {
+			loadErr = errors.New("No GeoIP database is configured")
+			return
+		}
+
+		loaded, loadErr = LoadFile(path)
+	})
+
+	return loaded, loadErr
+}
+
+/********** LOOKUP **********/
+
+// Find the location of an IP address. Returns false when no range holds it
+func (database *Database) Lookup(ipAddress string) (Location, bool) {
+	ip := net.ParseIP(ipAddress)
+
+	if database == nil || ip == nil {
+		return Location{}, false
+	}
+
+	ip = ip.To16()
+
+	// The first range that starts after the address, the one before may hold it
+	index := sort.Search(len(database.Ranges), func(i int) bool {
+		return bytes.Compare(database.Ranges[i].Start, ip) > 0
+	})
+
+	if index == 0 {
+		return Location{}, false
+	}
+
+	candidate := database.Ranges[index-1]
+
+	if bytes.Compare(ip, candidate.End) > 0 {
+		return Location{}, false
+	}
+
+	return candidate.Location, true
+}
+
+// The location of an IP address as shown to users, i.e "Berlin, Land Berlin, DE"
+func Describe(ipAddress string) string {
+	database, err := Default()
+
+	if err != nil {
+		return UNKNOWN_LOCATION
+	}
+
+	location, ok := database.Lookup(ipAddress)
+
+	if !ok {
+		return UNKNOWN_LOCATION
+	}
+
+	return location.String()
+}
+
+func (location Location) String() string {
+	parts := []string{}
+
+	for _, part := range []string{location.City, location.Region, location.Country} {
+		if part != "" && part != "-" {
+			parts = append(parts, part)
+		}
+	}
+
+	if len(parts) == 0 {
+		return UNKNOWN_LOCATION
+	}
+
+	return strings.Join(parts, ", ")
+}
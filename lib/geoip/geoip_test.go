@@ -0,0 +1,69 @@
+package geoip
+
+import (
+	"strings"
+	"testing"
+)
+
+const database = `2001:db8::,2001:db8::ffff,EU,DE,Land Berlin,Berlin,52.52,13.40
+1.0.0.0,1.0.0.255,OC,AU,Queensland,South Brisbane,-27.47,153.02
+8.8.8.0,8.8.8.255,NA,US,California,Mountain View,37.38,-122.08
+9.9.9.0,9.9.9.255,EU,CH,-,-,46.81,8.22
+`
+
+/***** Load *****/
+
+func TestLoadRejectsInvalidRanges(t *testing.T) {
+	tests := []string{
+		"1.0.0.0,EU,DE\n",
+		"not an ip,1.0.0.255,OC,AU,Queensland,South Brisbane\n",
+		"1.0.0.255,1.0.0.0,OC,AU,Queensland,South Brisbane\n",
+	}
+
+	for _, test := range tests {
+		if _, err := Load(strings.NewReader(test)); err == nil {
+			t.Errorf("FAILED - TestLoadRejectsInvalidRanges | Database: %q | Expected: an error", test)
+		}
+	}
+}
+
+/***** Lookup *****/
+
+func TestLookup(t *testing.T) {
+	loaded, err := Load(strings.NewReader(database))
+
+	if err != nil {
+		t.Fatalf("FAILED - TestLookup | Error: %v", err)
+	}
+
+	tests := []struct {
+		ip       string
+		expected string
+		found    bool
+	}{
+		{"8.8.8.8", "Mountain View, California, US", true},
+		{"1.0.0.0", "South Brisbane, Queensland, AU", true},
+		{"1.0.0.255", "South Brisbane, Queensland, AU", true},
+		{"2001:db8::1", "Berlin, Land Berlin, DE", true},
+		{"9.9.9.9", "CH", true},
+		{"1.0.1.0", "", false},
+		{"0.0.0.1", "", false},
+		{"not an ip", "", false},
+	}
+
+	for _, test := range tests {
+		location, found := loaded.Lookup(test.ip)
+
+		if found != test.found || (found && location.String() != test.expected) {
+			t.Errorf("FAILED - TestLookup | IP: %s | Actual: %s, %v | Expected: %s, %v", test.ip, location, found, test.expected, test.found)
+		}
+	}
+}
+
+func TestDescribeWithoutDatabase(t *testing.T) {
+	actual := Describe("8.8.8.8")
+
+	if actual != UNKNOWN_LOCATION {
+		t.Errorf("FAILED - TestDescribeWithoutDatabase | Actual: %s | Expected: %s", actual, UNKNOWN_LOCATION)
+	}
+}
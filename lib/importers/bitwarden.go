@@ -0,0 +1,163 @@
+package importers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"password-caddy/api/lib/urimatch"
+)
+
+// Item types of a Bitwarden export
+const (
+	bitwardenLogin    = 1
+	bitwardenNote     = 2
+	bitwardenCard     = 3
+	bitwardenIdentity = 4
+)
+
+// Hidden custom field of a Bitwarden export
+const bitwardenHiddenField = 1
+
+// Match strategies of Bitwarden URIs by their number. Without one the default (domain) applies
+var bitwardenMatches = []string{
+	urimatch.MATCH_DOMAIN,
+	urimatch.MATCH_HOST,
+	urimatch.MATCH_STARTS_WITH,
+	urimatch.MATCH_EXACT,
+	urimatch.MATCH_REGEX,
+	urimatch.MATCH_NEVER,
+}
+
+type bitwardenExport struct {
+	Encrypted bool `json:"encrypted"`
+	Folders   []struct {
+		Id   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"folders"`
+	Items []struct {
+		Type     int     `json:"type"`
+		Name     string  `json:"name"`
+		FolderId *string `json:"folderId"`
+		Notes    string  `json:"notes"`
+		Favorite bool    `json:"favorite"`
+		Login    *struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+			Totp     string `json:"totp"`
+			Uris     []struct {
+				Uri   string `json:"uri"`
+				Match *int   `json:"match"`
+			} `json:"uris"`
+		} `json:"login"`
+		Card     map[string]interface{} `json:"card"`
+		Identity map[string]interface{} `json:"identity"`
+		Fields   []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+			Type  int    `json:"type"`
+		} `json:"fields"`
+	} `json:"items"`
+}
+
+/*
+Parse the unencrypted JSON export of Bitwarden. Cards and identities keep
+their details as fields
+*/
+func ParseBitwarden(data []byte) (Import, error) {
+	var export bitwardenExport
+
+	if err := json.Unmarshal(data, &export); err != nil {
+		return Import{}, fmt.Errorf("Invalid Bitwarden export: %s", err.Error())
+	}
+
+	if export.Encrypted {
+		return Import{}, errors.New("Encrypted Bitwarden exports are not supported")
+	}
+
+	folders := map[string]string{}
+
+	for _, folder := range export.Folders {
+		folders[folder.Id] = folder.Name
+	}
+
+	result := newImport(FORMAT_BITWARDEN)
+
+	for i, entry := range export.Items {
+		item := Item{
+			Name:     entry.Name,
+			Notes:    entry.Notes,
+			Favorite: entry.Favorite,
+		}
+
+		if entry.FolderId != nil {
+			item.Folder = folders[*entry.FolderId]
+		}
+
+		for _, field := range entry.Fields {
+			item.Fields = append(item.Fields, Field{
+				Name:   field.Name,
+				Value:  field.Value,
+				Hidden: field.Type == bitwardenHiddenField,
+			})
+		}
+
+		switch entry.Type {
+		case bitwardenLogin:
+			item.Type = TYPE_LOGIN
+
+			if entry.Login != nil {
+				item.Username = entry.Login.Username
+				item.Password = entry.Login.Password
+				item.Totp = entry.Login.Totp
+
+				for _, uri := range entry.Login.Uris {
+					for _, value := range uris(uri.Uri) {
+						value.Match = bitwardenMatch(uri.Match)
+						item.Uris = append(item.Uris, value)
+					}
+				}
+			}
+		case bitwardenNote:
+			item.Type = TYPE_NOTE
+		case bitwardenCard:
+			item.Type = TYPE_CARD
+			item.Fields = append(item.Fields, detailFields(entry.Card)...)
+		case bitwardenIdentity:
+			item.Type = TYPE_IDENTITY
+			item.Fields = append(item.Fields, detailFields(entry.Identity)...)
+		default:
+			result.skip(i+1, entry.Name, fmt.Sprintf("Unsupported item type %d", entry.Type))
+			continue
+		}
+
+		result.add(i+1, item)
+	}
+
+	return result, nil
+}
+
+// Fields of the details of a card or identity in the order of their names
+func detailFields(details map[string]interface{}) []Field {
+	fields := []Field{}
+
+	for _, name := range sortedKeys(details) {
+		if value, ok := details[name].(string); ok && value != "" {
+			fields = append(fields, Field{
+				Name:   name,
+				Value:  value,
+				Hidden: name == "number" || name == "code" || name == "ssn",
+			})
+		}
+	}
+
+	return fields
+}
+
+func bitwardenMatch(match *int) string {
+	if match == nil || *match <= 0 || *match >= len(bitwardenMatches) {
+		return ""
+	}
+
+	return bitwardenMatches[*match]
+}
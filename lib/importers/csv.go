@@ -0,0 +1,138 @@
+package importers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strings"
+)
+
+// Url LastPass uses for secure notes
+const lastPassNoteUrl = "http://sn"
+
+/*
+Parse the CSV export of LastPass. Secure notes have the url http://sn and
+nested folders are separated with a backslash
+*/
+func ParseLastPass(data []byte) (Import, error) {
+	records, err := readCsv(data, "LastPass", "url", "username", "password", "name")
+
+	if err != nil {
+		return Import{}, err
+	}
+
+	result := newImport(FORMAT_LASTPASS)
+
+	for i, record := range records {
+		item := Item{
+			Type:     TYPE_LOGIN,
+			Name:     record["name"],
+			Folder:   strings.ReplaceAll(record["grouping"], "\\", "/"),
+			Username: record["username"],
+			Password: record["password"],
+			Totp:     record["totp"],
+			Notes:    record["extra"],
+			Favorite: record["fav"] == "1",
+		}
+
+		if record["url"] == lastPassNoteUrl {
+			item.Type = TYPE_NOTE
+		} else {
+			item.Uris = uris(record["url"])
+		}
+
+		result.add(i+1, item)
+	}
+
+	return result, nil
+}
+
+// Parse the CSV export of the password manager of Chrome (and other Chromium browsers)
+func ParseChrome(data []byte) (Import, error) {
+	records, err := readCsv(data, "Chrome", "name", "url", "username", "password")
+
+	if err != nil {
+		return Import{}, err
+	}
+
+	result := newImport(FORMAT_CHROME)
+
+	for i, record := range records {
+		result.add(i+1, Item{
+			Type:     TYPE_LOGIN,
+			Name:     record["name"],
+			Username: record["username"],
+			Password: record["password"],
+			Uris:     uris(record["url"]),
+			Notes:    record["note"],
+		})
+	}
+
+	return result, nil
+}
+
+// Parse the CSV export of Firefox. Logins are named after their host
+func ParseFirefox(data []byte) (Import, error) {
+	records, err := readCsv(data, "Firefox", "url", "username", "password")
+
+	if err != nil {
+		return Import{}, err
+	}
+
+	result := newImport(FORMAT_FIREFOX)
+
+	for i, record := range records {
+		result.add(i+1, Item{
+			Type:     TYPE_LOGIN,
+			Username: record["username"],
+			Password: record["password"],
+			Uris:     uris(record["url"]),
+		})
+	}
+
+	return result, nil
+}
+
+// Read the records of a CSV export keyed by the lower case names of the header
+func readCsv(data []byte, source string, required ...string) ([]map[string]string, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	reader.FieldsPerRecord = -1
+
+	rows, err := reader.ReadAll()
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid %s export: %s", source, err.Error())
+	}
+
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("Invalid %s export: the header is missing", source)
+	}
+
+	header := make([]string, len(rows[0]))
+
+	for i, name := range rows[0] {
+		header[i] = strings.ToLower(strings.TrimSpace(name))
+	}
+
+	for _, name := range required {
+		if !contains(header, name) {
+			return nil, fmt.Errorf("Invalid %s export: the %s column is missing", source, name)
+		}
+	}
+
+	records := make([]map[string]string, 0, len(rows)-1)
+
+	for _, row := range rows[1:] {
+		record := map[string]string{}
+
+		for i, value := range row {
+			if i < len(header) {
+				record[header[i]] = value
+			}
+		}
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
@@ -0,0 +1,261 @@
+package importers
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"password-caddy/api/lib/totp"
+	"password-caddy/api/lib/urimatch"
+)
+
+// Formats of the exports that can be imported
+const (
+	FORMAT_BITWARDEN = "bitwarden"
+	FORMAT_1PASSWORD = "1pux"
+	FORMAT_LASTPASS  = "lastpass"
+	FORMAT_CHROME    = "chrome"
+	FORMAT_FIREFOX   = "firefox"
+	FORMAT_KEEPASS   = "keepass"
+)
+
+// Types of the imported items
+const (
+	TYPE_LOGIN    = "login"
+	TYPE_NOTE     = "note"
+	TYPE_CARD     = "card"
+	TYPE_IDENTITY = "identity"
+)
+
+type Field struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Hidden bool   `json:"hidden,omitempty"`
+}
+
+/*
+An item of another password manager normalized into the data of a vault item.
+Clients encrypt it as the data of the item before it is sent to the vault.
+Folder is the path of the item's folder with "/" between nested folders
+*/
+type Item struct {
+	Type     string         `json:"type"`
+	Name     string         `json:"name"`
+	Folder   string         `json:"folder,omitempty"`
+	Username string         `json:"username,omitempty"`
+	Password string         `json:"password,omitempty"`
+	Totp     string         `json:"totp,omitempty"` // otpauth URI, see lib/totp
+	Uris     []urimatch.Uri `json:"uris,omitempty"`
+	Notes    string         `json:"notes,omitempty"`
+	Fields   []Field        `json:"fields,omitempty"`
+	Favorite bool           `json:"favorite,omitempty"`
+}
+
+// An entry of the export that is not imported. Entry is its 1 based position in the export
+type Skipped struct {
+	Entry  int    `json:"entry"`
+	Name   string `json:"name,omitempty"`
+	Reason string `json:"reason"`
+}
+
+type Import struct {
+	Format  string    `json:"format"`
+	Items   []Item    `json:"items"`
+	Folders []string  `json:"folders"`
+	Skipped []Skipped `json:"skipped"`
+}
+
+// Summary of an import without the items, i.e for a dry run
+type Report struct {
+	Format  string         `json:"format"`
+	Items   int            `json:"items"`
+	Folders int            `json:"folders"`
+	Types   map[string]int `json:"types"`
+	Skipped []Skipped      `json:"skipped"`
+}
+
+var parsers = map[string]func(data []byte) (Import, error){
+	FORMAT_BITWARDEN: ParseBitwarden,
+	FORMAT_1PASSWORD: Parse1Password,
+	FORMAT_LASTPASS:  ParseLastPass,
+	FORMAT_CHROME:    ParseChrome,
+	FORMAT_FIREFOX:   ParseFirefox,
+	FORMAT_KEEPASS:   ParseKeePass,
+}
+
+func Formats() []string {
+	formats := make([]string, 0, len(parsers))
+
+	for format := range parsers {
+		formats = append(formats, format)
+	}
+
+	sort.Strings(formats)
+
+	return formats
+}
+
+func IsValidFormat(format string) bool {
+	_, ok := parsers[format]
+	return ok
+}
+
+// Parse an export of another password manager
+func Parse(format string, data []byte) (Import, error) {
+	parse, ok := parsers[format]
+
+	if !ok {
+		return Import{}, fmt.Errorf("Format must be one of %s", strings.Join(Formats(), ", "))
+	}
+
+	return parse(data)
+}
+
+func (result Import) Report() Report {
+	report := Report{
+		Format:  result.Format,
+		Items:   len(result.Items),
+		Folders: len(result.Folders),
+		Types:   map[string]int{},
+		Skipped: result.Skipped,
+	}
+
+	for _, item := range result.Items {
+		report.Types[item.Type]++
+	}
+
+	return report
+}
+
+func newImport(format string) Import {
+	return Import{
+		Format:  format,
+		Items:   []Item{},
+		Folders: []string{},
+		Skipped: []Skipped{},
+	}
+}
+
+/*
+Add an entry of the export. Logins without a name are named after their
+first uri. Entries without a name or anything worth keeping are skipped
+*/
+func (result *Import) add(entry int, item Item) {
+	item.Name = strings.TrimSpace(item.Name)
+	item.Folder = strings.Trim(strings.TrimSpace(item.Folder), "/")
+
+	if item.Name == "" && len(item.Uris) > 0 {
+		item.Name = hostname(item.Uris[0].Uri)
+	}
+
+	if item.Name == "" {
+		result.skip(entry, "", "Entry has no name")
+		return
+	}
+
+	if item.Username == "" && item.Password == "" && item.Totp == "" &&
+		len(item.Uris) == 0 && item.Notes == "" && len(item.Fields) == 0 {
+		result.skip(entry, item.Name, "Entry is empty")
+		return
+	}
+
+	item.Totp = normalizeTotp(item)
+
+	if item.Folder != "" && !contains(result.Folders, item.Folder) {
+		result.Folders = append(result.Folders, item.Folder)
+	}
+
+	result.Items = append(result.Items, item)
+}
+
+/*
+Write the TOTP of an item as an otpauth URI. Bare secrets are labeled with
+the name and username of the item. Values that can't be parsed are kept as they are
+*/
+func normalizeTotp(item Item) string {
+	if item.Totp == "" {
+		return ""
+	}
+
+	key, err := totp.Parse(item.Totp)
+
+	if err != nil {
+		return item.Totp
+	}
+
+	if key.Issuer == "" && key.Account == "" {
+		key.Issuer = item.Name
+		key.Account = item.Username
+	}
+
+	return key.String()
+}
+
+func (result *Import) skip(entry int, name, reason string) {
+	result.Skipped = append(result.Skipped, Skipped{
+		Entry:  entry,
+		Name:   name,
+		Reason: reason,
+	})
+}
+
+func hostname(uri string) string {
+	if !strings.Contains(uri, "://") {
+		uri = "https://" + uri
+	}
+
+	parsed, err := url.Parse(uri)
+
+	if err != nil {
+		return ""
+	}
+
+	return parsed.Hostname()
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Drop empty values, i.e of optional columns
+func nonEmpty(values ...string) []string {
+	result := []string{}
+
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			result = append(result, value)
+		}
+	}
+
+	return result
+}
+
+// The non empty values as URIs matched by their base domain
+func uris(values ...string) []urimatch.Uri {
+	result := []urimatch.Uri{}
+
+	for _, value := range nonEmpty(values...) {
+		result = append(result, urimatch.Uri{Uri: value})
+	}
+
+	return result
+}
+
+func sortedKeys(values map[string]interface{}) []string {
+	keys := make([]string, 0, len(values))
+
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,277 @@
+package importers
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"password-caddy/api/lib/urimatch"
+)
+
+func TestParseWithUnknownFormat(t *testing.T) {
+	_, err := Parse("dashlane", []byte("{}"))
+
+	if err == nil {
+		t.Errorf("FAILED - TestParseWithUnknownFormat | Actual: %v | Expected: an error", err)
+	}
+}
+
+func TestFormats(t *testing.T) {
+	actual := len(Formats())
+	expected := 6
+
+	if actual != expected {
+		t.Errorf("FAILED - TestFormats | Actual: %d | Expected: %d", actual, expected)
+	}
+}
+
+/***** Bitwarden *****/
+
+const bitwardenExportJson = `{
+	"encrypted": false,
+	"folders": [{"id": "f1", "name": "Social"}],
+	"items": [
+		{"type": 1, "name": "Twitter", "folderId": "f1", "favorite": true,
+			"login": {"username": "bob", "password": "hunter2", "totp": "JBSWY3DPEHPK3PXP", "uris": [{"uri": "https://twitter.com", "match": 1}]},
+			"fields": [{"name": "pin", "value": "1234", "type": 1}]},
+		{"type": 2, "name": "Wifi", "folderId": null, "notes": "password123"},
+		{"type": 3, "name": "Visa", "card": {"cardholderName": "Bob", "number": "4111111111111111", "brand": null}},
+		{"type": 5, "name": "Key"},
+		{"type": 1, "name": "", "login": {"username": "alice"}}
+	]
+}`
+
+func TestParseBitwarden(t *testing.T) {
+	actual, err := ParseBitwarden([]byte(bitwardenExportJson))
+
+	if err != nil {
+		t.Fatalf("FAILED - TestParseBitwarden | Error: %s", err.Error())
+	}
+
+	if len(actual.Items) != 3 || len(actual.Skipped) != 2 {
+		t.Fatalf("FAILED - TestParseBitwarden | Actual: %d items, %d skipped | Expected: 3 items, 2 skipped", len(actual.Items), len(actual.Skipped))
+	}
+
+	login := actual.Items[0]
+
+	if login.Folder != "Social" || login.Password != "hunter2" || login.Totp != "otpauth://totp/Twitter:bob?issuer=Twitter&secret=JBSWY3DPEHPK3PXP" || login.Uris[0].Uri != "https://twitter.com" || login.Uris[0].Match != urimatch.MATCH_HOST || !login.Favorite {
+		t.Errorf("FAILED - TestParseBitwarden | Actual: %+v | Expected: the Twitter login", login)
+	}
+
+	if !login.Fields[0].Hidden {
+		t.Errorf("FAILED - TestParseBitwarden | Actual: %+v | Expected: a hidden field", login.Fields[0])
+	}
+
+	card := actual.Items[2]
+
+	if card.Type != TYPE_CARD || len(card.Fields) != 2 || card.Fields[1].Name != "number" || !card.Fields[1].Hidden {
+		t.Errorf("FAILED - TestParseBitwarden | Actual: %+v | Expected: the card with its number hidden", card)
+	}
+
+	if actual.Skipped[1].Entry != 5 || actual.Skipped[1].Reason != "Entry has no name" {
+		t.Errorf("FAILED - TestParseBitwarden | Actual: %+v | Expected: entry 5 without a name", actual.Skipped[1])
+	}
+}
+
+func TestParseBitwardenEncrypted(t *testing.T) {
+	_, err := ParseBitwarden([]byte(`{"encrypted": true}`))
+
+	if err == nil {
+		t.Errorf("FAILED - TestParseBitwardenEncrypted | Actual: %v | Expected: an error", err)
+	}
+}
+
+/***** 1Password *****/
+
+const onePasswordExportJson = `{"accounts": [{"vaults": [{"attrs": {"name": "Personal"}, "items": [
+	{"state": "active", "categoryUuid": "001", "favIndex": 1,
+		"overview": {"title": "GitHub", "urls": [{"url": "https://github.com"}]},
+		"details": {"loginFields": [{"designation": "username", "value": "bob"}, {"designation": "password", "value": "secret"}],
+			"sections": [{"fields": [{"title": "one-time password", "value": {"totp": "otpauth://totp/GitHub?secret=ABC"}}, {"title": "recovery", "value": {"concealed": "code"}}]}]}},
+	{"state": "archived", "categoryUuid": "003", "overview": {"title": "Alarm"}, "details": {"notesPlain": "1234"}},
+	{"state": "active", "categoryUuid": "110", "overview": {"title": "Server"}},
+	{"state": "trashed", "categoryUuid": "001", "overview": {"title": "Old"}}
+]}]}]}`
+
+func onePasswordArchive(t *testing.T, content string) []byte {
+	var buffer bytes.Buffer
+
+	archive := zip.NewWriter(&buffer)
+	file, err := archive.Create("export.data")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file.Write([]byte(content))
+	archive.Close()
+
+	return buffer.Bytes()
+}
+
+func TestParse1Password(t *testing.T) {
+	actual, err := Parse1Password(onePasswordArchive(t, onePasswordExportJson))
+
+	if err != nil {
+		t.Fatalf("FAILED - TestParse1Password | Error: %s", err.Error())
+	}
+
+	if len(actual.Items) != 2 || len(actual.Skipped) != 2 {
+		t.Fatalf("FAILED - TestParse1Password | Actual: %d items, %d skipped | Expected: 2 items, 2 skipped", len(actual.Items), len(actual.Skipped))
+	}
+
+	login := actual.Items[0]
+
+	if login.Folder != "Personal" || login.Username != "bob" || login.Password != "secret" || login.Totp == "" || len(login.Fields) != 1 || !login.Fields[0].Hidden {
+		t.Errorf("FAILED - TestParse1Password | Actual: %+v | Expected: the GitHub login", login)
+	}
+
+	if actual.Items[1].Type != TYPE_NOTE {
+		t.Errorf("FAILED - TestParse1Password | Actual: %s | Expected: %s", actual.Items[1].Type, TYPE_NOTE)
+	}
+}
+
+func TestParse1PasswordWithoutData(t *testing.T) {
+	_, err := Parse1Password([]byte("not a zip"))
+
+	if err == nil {
+		t.Errorf("FAILED - TestParse1PasswordWithoutData | Actual: %v | Expected: an error", err)
+	}
+}
+
+/***** CSV *****/
+
+func TestParseLastPass(t *testing.T) {
+	data := "url,username,password,totp,extra,name,grouping,fav\n" +
+		"https://example.com,bob,secret,,,Example,Work\\Admin,1\n" +
+		"http://sn,,,,my note,Note,,0\n" +
+		"http://sn,,,,,,,0\n"
+
+	actual, err := ParseLastPass([]byte(data))
+
+	if err != nil {
+		t.Fatalf("FAILED - TestParseLastPass | Error: %s", err.Error())
+	}
+
+	if len(actual.Items) != 2 || len(actual.Skipped) != 1 {
+		t.Fatalf("FAILED - TestParseLastPass | Actual: %d items, %d skipped | Expected: 2 items, 1 skipped", len(actual.Items), len(actual.Skipped))
+	}
+
+	if actual.Items[0].Folder != "Work/Admin" || !actual.Items[0].Favorite {
+		t.Errorf("FAILED - TestParseLastPass | Actual: %+v | Expected: a favorite in Work/Admin", actual.Items[0])
+	}
+
+	if actual.Items[1].Type != TYPE_NOTE || len(actual.Items[1].Uris) != 0 {
+		t.Errorf("FAILED - TestParseLastPass | Actual: %+v | Expected: a secure note", actual.Items[1])
+	}
+}
+
+func TestParseChrome(t *testing.T) {
+	data := "\xef\xbb\xbfname,url,username,password\nexample.com,https://example.com/login,bob,secret\n"
+
+	actual, err := ParseChrome([]byte(data))
+
+	if err != nil {
+		t.Fatalf("FAILED - TestParseChrome | Error: %s", err.Error())
+	}
+
+	if len(actual.Items) != 1 || actual.Items[0].Name != "example.com" || actual.Items[0].Password != "secret" {
+		t.Errorf("FAILED - TestParseChrome | Actual: %+v | Expected: the example.com login", actual.Items)
+	}
+}
+
+func TestParseFirefoxNamesLoginsAfterHost(t *testing.T) {
+	data := `"url","username","password","httpRealm","formActionOrigin","guid","timeCreated","timePasswordChanged","timeLastUsed"` + "\n" +
+		`"https://accounts.example.com:8443","bob","secret",,"https://accounts.example.com","{1}","1","1","1"` + "\n"
+
+	actual, err := ParseFirefox([]byte(data))
+
+	if err != nil {
+		t.Fatalf("FAILED - TestParseFirefoxNamesLoginsAfterHost | Error: %s", err.Error())
+	}
+
+	if len(actual.Items) != 1 || actual.Items[0].Name != "accounts.example.com" {
+		t.Errorf("FAILED - TestParseFirefoxNamesLoginsAfterHost | Actual: %+v | Expected: accounts.example.com", actual.Items)
+	}
+}
+
+func TestParseCsvWithMissingColumn(t *testing.T) {
+	_, err := ParseChrome([]byte("name,url\nfoo,bar\n"))
+
+	if err == nil {
+		t.Errorf("FAILED - TestParseCsvWithMissingColumn | Actual: %v | Expected: an error", err)
+	}
+}
+
+/***** KeePass *****/
+
+const keepassExportXml = `<?xml version="1.0" encoding="utf-8"?>
+<KeePassFile>
+	<Meta><RecycleBinUUID>bin</RecycleBinUUID></Meta>
+	<Root>
+		<Group>
+			<UUID>root</UUID>
+			<Name>Database</Name>
+			<Entry>
+				<String><Key>Title</Key><Value>Router</Value></String>
+				<String><Key>UserName</Key><Value>admin</Value></String>
+				<String><Key>Password</Key><Value ProtectedInMemory="True">secret</Value></String>
+				<String><Key>URL</Key><Value>http://192.168.0.1</Value></String>
+				<String><Key>otp</Key><Value>otpauth://totp/Router?secret=ABC</Value></String>
+				<String><Key>Serial</Key><Value>X1</Value></String>
+				<History><Entry><String><Key>Title</Key><Value>Old Router</Value></String></Entry></History>
+			</Entry>
+			<Group>
+				<UUID>work</UUID>
+				<Name>Work</Name>
+				<Group>
+					<UUID>mail</UUID>
+					<Name>Mail</Name>
+					<Entry><String><Key>Title</Key><Value>Outlook</Value></String><String><Key>Password</Key><Value>pw</Value></String></Entry>
+				</Group>
+			</Group>
+			<Group>
+				<UUID>bin</UUID>
+				<Name>Recycle Bin</Name>
+				<Entry><String><Key>Title</Key><Value>Deleted</Value></String><String><Key>Password</Key><Value>pw</Value></String></Entry>
+			</Group>
+		</Group>
+	</Root>
+</KeePassFile>`
+
+func TestParseKeePass(t *testing.T) {
+	actual, err := ParseKeePass([]byte(keepassExportXml))
+
+	if err != nil {
+		t.Fatalf("FAILED - TestParseKeePass | Error: %s", err.Error())
+	}
+
+	if len(actual.Items) != 2 || len(actual.Skipped) != 1 {
+		t.Fatalf("FAILED - TestParseKeePass | Actual: %d items, %d skipped | Expected: 2 items, 1 skipped", len(actual.Items), len(actual.Skipped))
+	}
+
+	router := actual.Items[0]
+
+	if router.Folder != "" || router.Totp == "" || len(router.Fields) != 1 || router.Fields[0].Name != "Serial" {
+		t.Errorf("FAILED - TestParseKeePass | Actual: %+v | Expected: the router login", router)
+	}
+
+	if actual.Items[1].Folder != "Work/Mail" {
+		t.Errorf("FAILED - TestParseKeePass | Actual: %s | Expected: Work/Mail", actual.Items[1].Folder)
+	}
+
+	if actual.Skipped[0].Reason != "Entry is in the recycle bin" {
+		t.Errorf("FAILED - TestParseKeePass | Actual: %s | Expected: Entry is in the recycle bin", actual.Skipped[0].Reason)
+	}
+}
+
+/***** Report *****/
+
+func TestReport(t *testing.T) {
+	result, _ := ParseBitwarden([]byte(bitwardenExportJson))
+	actual := result.Report()
+
+	if actual.Items != 3 || actual.Folders != 1 || actual.Types[TYPE_LOGIN] != 1 || len(actual.Skipped) != 2 {
+		t.Errorf("FAILED - TestReport | Actual: %+v | Expected: 3 items in 1 folder with 2 skipped", actual)
+	}
+}
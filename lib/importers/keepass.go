@@ -0,0 +1,126 @@
+package importers
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+type keepassEntry struct {
+	Strings []struct {
+		Key   string `xml:"Key"`
+		Value string `xml:"Value"`
+	} `xml:"String"`
+}
+
+type keepassGroup struct {
+	UUID    string         `xml:"UUID"`
+	Name    string         `xml:"Name"`
+	Entries []keepassEntry `xml:"Entry"`
+	Groups  []keepassGroup `xml:"Group"`
+}
+
+type keepassFile struct {
+	Meta struct {
+		RecycleBinUUID string `xml:"RecycleBinUUID"`
+	} `xml:"Meta"`
+	Root struct {
+		Groups []keepassGroup `xml:"Group"`
+	} `xml:"Root"`
+}
+
+// Strings of KeePass entries that are not kept as custom fields
+var keepassStandardStrings = map[string]bool{
+	"Title":                 true,
+	"UserName":              true,
+	"Password":              true,
+	"URL":                   true,
+	"Notes":                 true,
+	"otp":                   true,
+	"TimeOtp-Secret-Base32": true,
+}
+
+/*
+Parse the XML export of KeePass 2 (and KeePassXC). The groups below the root
+group become folders. Entries of the recycle bin are skipped
+*/
+func ParseKeePass(data []byte) (Import, error) {
+	var file keepassFile
+
+	if err := xml.Unmarshal(data, &file); err != nil {
+		return Import{}, fmt.Errorf("Invalid KeePass export: %s", err.Error())
+	}
+
+	result := newImport(FORMAT_KEEPASS)
+	entry := 0
+
+	var walk func(group keepassGroup, folder string, recycled bool)
+
+	walk = func(group keepassGroup, folder string, recycled bool) {
+		recycled = recycled || (group.UUID != "" && group.UUID == file.Meta.RecycleBinUUID)
+
+		for _, source := range group.Entries {
+			entry++
+
+			item := convertKeePassEntry(source)
+			item.Folder = folder
+
+			if recycled {
+				result.skip(entry, item.Name, "Entry is in the recycle bin")
+				continue
+			}
+
+			result.add(entry, item)
+		}
+
+		for _, child := range group.Groups {
+			path := child.Name
+
+			if folder != "" {
+				path = folder + "/" + child.Name
+			}
+
+			walk(child, path, recycled)
+		}
+	}
+
+	// The root group holds the whole database and is not a folder
+	for _, group := range file.Root.Groups {
+		walk(group, "", false)
+	}
+
+	return result, nil
+}
+
+func convertKeePassEntry(source keepassEntry) Item {
+	item := Item{Type: TYPE_LOGIN}
+
+	for _, value := range source.Strings {
+		switch value.Key {
+		case "Title":
+			item.Name = value.Value
+		case "UserName":
+			item.Username = value.Value
+		case "Password":
+			item.Password = value.Value
+		case "URL":
+			item.Uris = uris(value.Value)
+		case "Notes":
+			item.Notes = value.Value
+		case "otp", "TimeOtp-Secret-Base32":
+			if item.Totp == "" {
+				item.Totp = strings.TrimSpace(value.Value)
+			}
+		}
+
+		if !keepassStandardStrings[value.Key] && value.Value != "" {
+			item.Fields = append(item.Fields, Field{Name: value.Key, Value: value.Value})
+		}
+	}
+
+	if item.Username == "" && item.Password == "" && len(item.Uris) == 0 && item.Notes != "" {
+		item.Type = TYPE_NOTE
+	}
+
+	return item
+}
@@ -0,0 +1,214 @@
+package importers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Categories of 1Password items
+const (
+	onePasswordLogin    = "001"
+	onePasswordCard     = "002"
+	onePasswordNote     = "003"
+	onePasswordIdentity = "004"
+	onePasswordPassword = "005"
+)
+
+// File of a 1PUX archive holding the accounts, vaults and items
+const onePasswordData = "export.data"
+
+type onePasswordField struct {
+	Title string                 `json:"title"`
+	Value map[string]interface{} `json:"value"`
+}
+
+type onePasswordItem struct {
+	State        string `json:"state"`
+	CategoryUuid string `json:"categoryUuid"`
+	FavIndex     int    `json:"favIndex"`
+	Overview     struct {
+		Title string `json:"title"`
+		Url   string `json:"url"`
+		Urls  []struct {
+			Url string `json:"url"`
+		} `json:"urls"`
+	} `json:"overview"`
+	Details struct {
+		LoginFields []struct {
+			Designation string `json:"designation"`
+			Value       string `json:"value"`
+		} `json:"loginFields"`
+		NotesPlain string `json:"notesPlain"`
+		Password   string `json:"password"`
+		Sections   []struct {
+			Fields []onePasswordField `json:"fields"`
+		} `json:"sections"`
+	} `json:"details"`
+}
+
+type onePasswordExport struct {
+	Accounts []struct {
+		Vaults []struct {
+			Attrs struct {
+				Name string `json:"name"`
+			} `json:"attrs"`
+			Items []onePasswordItem `json:"items"`
+		} `json:"vaults"`
+	} `json:"accounts"`
+}
+
+/*
+Parse a 1Password Unencrypted Export (1PUX). The archive holds the items as JSON
+in export.data. Every vault of the export becomes a folder
+*/
+func Parse1Password(data []byte) (Import, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+
+	if err != nil {
+		return Import{}, fmt.Errorf("Invalid 1PUX export: %s", err.Error())
+	}
+
+	var content []byte
+
+	for _, file := range archive.File {
+		if file.Name != onePasswordData {
+			continue
+		}
+
+		reader, err := file.Open()
+
+		if err != nil {
+			return Import{}, fmt.Errorf("Invalid 1PUX export: %s", err.Error())
+		}
+
+		content, err = io.ReadAll(reader)
+		reader.Close()
+
+		if err != nil {
+			return Import{}, fmt.Errorf("Invalid 1PUX export: %s", err.Error())
+		}
+	}
+
+	if content == nil {
+		return Import{}, errors.New("Invalid 1PUX export: export.data is missing")
+	}
+
+	var export onePasswordExport
+
+	if err := json.Unmarshal(content, &export); err != nil {
+		return Import{}, fmt.Errorf("Invalid 1PUX export: %s", err.Error())
+	}
+
+	result := newImport(FORMAT_1PASSWORD)
+	entry := 0
+
+	for _, account := range export.Accounts {
+		for _, vault := range account.Vaults {
+			for _, source := range vault.Items {
+				entry++
+
+				if source.State != "" && source.State != "active" && source.State != "archived" {
+					result.skip(entry, source.Overview.Title, "Item is deleted")
+					continue
+				}
+
+				item, ok := convert1PasswordItem(source)
+
+				if !ok {
+					result.skip(entry, source.Overview.Title, fmt.Sprintf("Unsupported category %s", source.CategoryUuid))
+					continue
+				}
+
+				item.Folder = vault.Attrs.Name
+
+				result.add(entry, item)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func convert1PasswordItem(source onePasswordItem) (Item, bool) {
+	item := Item{
+		Name:     source.Overview.Title,
+		Notes:    source.Details.NotesPlain,
+		Favorite: source.FavIndex > 0,
+	}
+
+	switch source.CategoryUuid {
+	case onePasswordLogin, onePasswordPassword:
+		item.Type = TYPE_LOGIN
+	case onePasswordNote:
+		item.Type = TYPE_NOTE
+	case onePasswordCard:
+		item.Type = TYPE_CARD
+	case onePasswordIdentity:
+		item.Type = TYPE_IDENTITY
+	default:
+		return item, false
+	}
+
+	for _, field := range source.Details.LoginFields {
+		switch field.Designation {
+		case "username":
+			item.Username = field.Value
+		case "password":
+			item.Password = field.Value
+		}
+	}
+
+	if item.Password == "" {
+		item.Password = source.Details.Password
+	}
+
+	for _, uri := range source.Overview.Urls {
+		item.Uris = append(item.Uris, uris(uri.Url)...)
+	}
+
+	if len(item.Uris) == 0 {
+		item.Uris = uris(source.Overview.Url)
+	}
+
+	for _, section := range source.Details.Sections {
+		for _, field := range section.Fields {
+			kind, value := onePasswordValue(field.Value)
+
+			if value == "" {
+				continue
+			}
+
+			if kind == "totp" && item.Totp == "" {
+				item.Totp = value
+				continue
+			}
+
+			item.Fields = append(item.Fields, Field{
+				Name:   field.Title,
+				Value:  value,
+				Hidden: kind == "concealed" || kind == "creditCardNumber",
+			})
+		}
+	}
+
+	return item, true
+}
+
+// Values of 1Password fields are objects with a single key naming the kind of the value
+func onePasswordValue(value map[string]interface{}) (string, string) {
+	for _, kind := range sortedKeys(value) {
+		switch typed := value[kind].(type) {
+		case string:
+			return kind, strings.TrimSpace(typed)
+		case float64:
+			return kind, fmt.Sprint(typed)
+		}
+	}
+
+	return "", ""
+}
@@ -0,0 +1,406 @@
+package keys
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/dynamoclient"
+	"password-caddy/api/lib/orgs"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/shares"
+
+	dynamoTypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Sort key of the key pair in the user's partition
+const KEYS_KEY = "KEYS"
+
+/*
+Algorithms of the key pairs. RSA-OAEP public keys are DER encoded
+SubjectPublicKeyInfo, X25519 public keys the raw 32 bytes
+*/
+const (
+	ALGORITHM_RSA_OAEP = "RSA-OAEP"
+	ALGORITHM_X25519   = "X25519"
+)
+
+const (
+	MIN_RSA_BITS             = 2048
+	MAX_PRIVATE_KEY_LENGTH   = 8192
+	MAX_PUBLIC_KEY_LENGTH    = 2048
+	X25519_PUBLIC_KEY_LENGTH = 32
+	MAX_ORG_KEYS             = dynamoclient.MAX_TRANSACT_ITEMS - 1
+)
+
+/*
+A new key pair of the user. The first key pair is saved at revision 0, a
+rotation sends the revision it replaces. OrgKeys holds the key of every
+organization the user is a confirmed member of, keyed by organization id,
+ShareKeys the item key of every share the user received, keyed by share id.
+Both are re-wrapped with the new public key, so the user keeps access to them
+*/
+type KeyPairRequest struct {
+	Algorithm           string            `json:"algorithm"`
+	PublicKey           string            `json:"publicKey"`
+	EncryptedPrivateKey string            `json:"encryptedPrivateKey"`
+	Revision            int               `json:"revision"`
+	OrgKeys             map[string]string `json:"orgKeys"`
+	ShareKeys           map[string]string `json:"shareKeys"`
+}
+
+type KeyPairResponse struct {
+	Algorithm           string `json:"algorithm"`
+	PublicKey           string `json:"publicKey"`
+	EncryptedPrivateKey string `json:"encryptedPrivateKey"`
+	Fingerprint         string `json:"fingerprint"`
+	Revision            int    `json:"revision"`
+	UpdatedAt           string `json:"updatedAt"`
+}
+
+// The public part of a key pair other users look up to share with the user
+type PublicKeyResponse struct {
+	UserId      string `json:"userId"`
+	Algorithm   string `json:"algorithm"`
+	PublicKey   string `json:"publicKey"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+/********** VALIDATION **********/
+
+func (request KeyPairRequest) Validate() error {
+	if request.Revision < 0 {
+		return errors.New("Revision can not be negative")
+	}
+
+	if err := ValidatePublicKey(request.Algorithm, request.PublicKey); err != nil {
+		return err
+	}
+
+	if request.EncryptedPrivateKey == "" || len(request.EncryptedPrivateKey) > MAX_PRIVATE_KEY_LENGTH {
+		return fmt.Errorf("Encrypted private key is required and can have at most %d characters", MAX_PRIVATE_KEY_LENGTH)
+	}
+
+	// Both copies of a share are updated, so a share takes two items of the transaction
+	if len(request.OrgKeys)+2*len(request.ShareKeys) > MAX_ORG_KEYS {
+		return fmt.Errorf("At most %d organization keys, or half as many share keys, can be re-wrapped at once", MAX_ORG_KEYS)
+	}
+
+	for orgId, key := range request.OrgKeys {
+		if !orgs.IsValidOrgId(orgId) {
+			return errors.New("Organization ids must be UUIDs")
+		}
+
+		if key == "" || len(key) > orgs.MAX_KEY_LENGTH {
+			return fmt.Errorf("Organization keys are required and can have at most %d characters", orgs.MAX_KEY_LENGTH)
+		}
+	}
+
+	for shareId, key := range request.ShareKeys {
+		if !shares.IsValidShareId(shareId) {
+			return errors.New("Share ids must be UUIDs")
+		}
+
+		if key == "" || len(key) > shares.MAX_KEY_LENGTH {
+			return fmt.Errorf("Share keys are required and can have at most %d characters", shares.MAX_KEY_LENGTH)
+		}
+	}
+
+	return nil
+}
+
+// Check that a public key is base64 encoded and usable with the algorithm
+func ValidatePublicKey(algorithm, publicKey string) error {
+	if len(publicKey) > MAX_PUBLIC_KEY_LENGTH {
+		return fmt.Errorf("Public key can have at most %d characters", MAX_PUBLIC_KEY_LENGTH)
+	}
+
+	der, err := base64.StdEncoding.DecodeString(publicKey)
+
+	if err != nil || len(der) == 0 {
+		return errors.New("Public key is required and must be base64 encoded")
+	}
+
+	switch algorithm {
+	case ALGORITHM_RSA_OAEP:
+		parsed, err := x509.ParsePKIXPublicKey(der)
+
+		if err != nil {
+			return errors.New("Public key must be a DER encoded RSA public key")
+		}
+
+		rsaKey, ok := parsed.(*rsa.PublicKey)
+
+		if !ok {
+			return errors.New("Public key must be a DER encoded RSA public key")
+		}
+
+		if rsaKey.N.BitLen() < MIN_RSA_BITS {
+			return fmt.Errorf("RSA public keys must have at least %d bits", MIN_RSA_BITS)
+		}
+	case ALGORITHM_X25519:
+		if len(der) != X25519_PUBLIC_KEY_LENGTH {
+			return fmt.Errorf("X25519 public keys must have %d bytes", X25519_PUBLIC_KEY_LENGTH)
+		}
+	default:
+		return errors.New("Algorithm must be RSA-OAEP or X25519")
+	}
+
+	return nil
+}
+
+// SHA-256 of the decoded public key, hex encoded. Users compare it out of band before sharing
+func Fingerprint(publicKey string) string {
+	der, _ := base64.StdEncoding.DecodeString(publicKey)
+	sum := sha256.Sum256(der)
+
+	return hex.EncodeToString(sum[:])
+}
+
+/*
+Compare the re-wrapped organization keys of a rotation with the organizations
+the user is a confirmed member of. missing are organizations without a key,
+unknown are keys of organizations the user is not a confirmed member of
+*/
+func CompareOrgKeys(orgKeys map[string]string, userOrgs []orgs.UserOrg) (missing []string, unknown []string) {
+	confirmed := map[string]bool{}
+
+	for _, userOrg := range userOrgs {
+		if userOrg.Member.Status.Value != orgs.STATUS_CONFIRMED {
+			continue
+		}
+
+		orgId := userOrg.Org.OrgId.Value
+		confirmed[orgId] = true
+
+		if _, ok := orgKeys[orgId]; !ok {
+			missing = append(missing, orgId)
+		}
+	}
+
+	for orgId := range orgKeys {
+		if !confirmed[orgId] {
+			unknown = append(unknown, orgId)
+		}
+	}
+
+	sort.Strings(missing)
+	sort.Strings(unknown)
+
+	return missing, unknown
+}
+
+/*
+Compare the re-wrapped share keys of a rotation with the shares the user
+received. missing are shares without a key, unknown are keys of shares the
+user did not receive
+*/
+func CompareShareKeys(shareKeys map[string]string, received []types.ItemShare) (missing []string, unknown []string) {
+	known := map[string]bool{}
+
+	for _, share := range received {
+		shareId := share.ShareId.Value
+		known[shareId] = true
+
+		if _, ok := shareKeys[shareId]; !ok {
+			missing = append(missing, shareId)
+		}
+	}
+
+	for shareId := range shareKeys {
+		if !known[shareId] {
+			unknown = append(unknown, shareId)
+		}
+	}
+
+	sort.Strings(missing)
+	sort.Strings(unknown)
+
+	return missing, unknown
+}
+
+/********** RESPONSES **********/
+
+func ToKeyPairResponse(keys types.UserKeys) KeyPairResponse {
+	return KeyPairResponse{
+		Algorithm:           keys.Algorithm.Value,
+		PublicKey:           keys.PublicKey.Value,
+		EncryptedPrivateKey: keys.EncryptedPrivateKey.Value,
+		Fingerprint:         keys.Fingerprint.Value,
+		Revision:            keys.Revision.Value,
+		UpdatedAt:           keys.UpdatedAt.Value,
+	}
+}
+
+func ToPublicKeyResponse(keys types.UserKeys) PublicKeyResponse {
+	return PublicKeyResponse{
+		UserId:      keys.UserId.Value,
+		Algorithm:   keys.Algorithm.Value,
+		PublicKey:   keys.PublicKey.Value,
+		Fingerprint: keys.Fingerprint.Value,
+	}
+}
+
+// Build the 409 returned when the client's revision of the key pair is out of date
+func ConflictResult(current types.UserKeys) *result.Result {
+	return result.FailureWithDetails(
+		409,
+		"Key pair was modified by another client",
+		ToKeyPairResponse(current),
+	)
+}
+
+/********** OPERATIONS **********/
+
+// Get the key pair of a user. Without one the response is an empty UserKeys
+func Get(client *dynamoclient.DynamoClient, userId string) *dynamoclient.DynamoResponse {
+	return client.
+		Get(dynamoclient.DynamoGetRequest{
+			Key:     userId,
+			SortKey: KEYS_KEY,
+		}).
+		AsUserKeys()
+}
+
+/*
+Save a key pair as the revision following request.Revision and replace the
+wrapped key of every organization in request.OrgKeys and of every received
+share in request.ShareKeys in a single transaction, so no key is ever left
+wrapped with a replaced public key. received are the shares the user received,
+both copies of a share get the new key and fingerprint. Fails with a 409 when
+the key pair is no longer at the revision, a membership is no longer confirmed
+or a share was removed
+*/
+func Save(client *dynamoclient.DynamoClient, userId string, request KeyPairRequest, received []types.ItemShare) *dynamoclient.DynamoResponse {
+	var keys types.UserKeys
+
+	keys.UserId.Value = userId
+	keys.ItemKey.Value = KEYS_KEY
+	keys.Algorithm.Value = request.Algorithm
+	keys.PublicKey.Value = request.PublicKey
+	keys.EncryptedPrivateKey.Value = request.EncryptedPrivateKey
+	keys.Fingerprint.Value = Fingerprint(request.PublicKey)
+	keys.Revision.Value = request.Revision + 1
+	keys.UpdatedAt.Value = time.Now().UTC().Format(time.RFC3339)
+
+	items := []dynamoclient.DynamoTransactItem{
+		{
+			Put: &dynamoclient.DynamoPutRequest{
+				Key:     userId,
+				SortKey: KEYS_KEY,
+				Values: map[string]interface{}{
+					"ALGORITHM":             keys.Algorithm.Value,
+					"PUBLIC_KEY":            keys.PublicKey.Value,
+					"ENCRYPTED_PRIVATE_KEY": keys.EncryptedPrivateKey.Value,
+					"FINGERPRINT":           keys.Fingerprint.Value,
+					"REVISION":              keys.Revision.Value,
+					"UPDATED_AT":            keys.UpdatedAt.Value,
+				},
+				Condition: revisionCondition(request.Revision),
+			},
+		},
+	}
+
+	for orgId, key := range request.OrgKeys {
+		items = append(items, dynamoclient.DynamoTransactItem{
+			Update: &dynamoclient.DyanamoUpdateRequest{
+				Key:     orgs.PartitionKey(orgId),
+				SortKey: orgs.MemberKey(userId),
+				Values: map[string]dynamoclient.DynamoUpdateItem{
+					"KEY": {
+						Action: dynamoTypes.AttributeActionPut,
+						Value:  key,
+					},
+					"UPDATED_AT": {
+						Action: dynamoTypes.AttributeActionPut,
+						Value:  keys.UpdatedAt.Value,
+					},
+				},
+				Condition: &dynamoclient.DynamoCondition{
+					Expression: "#status = :status",
+					Names:      map[string]string{"#status": "STATUS"},
+					Values:     map[string]interface{}{":status": orgs.STATUS_CONFIRMED},
+				},
+			},
+		})
+	}
+
+	for _, share := range received {
+		key, ok := request.ShareKeys[share.ShareId.Value]
+
+		if !ok {
+			continue
+		}
+
+		values := map[string]dynamoclient.DynamoUpdateItem{
+			"KEY": {
+				Action: dynamoTypes.AttributeActionPut,
+				Value:  key,
+			},
+			"FINGERPRINT": {
+				Action: dynamoTypes.AttributeActionPut,
+				Value:  keys.Fingerprint.Value,
+			},
+			"UPDATED_AT": {
+				Action: dynamoTypes.AttributeActionPut,
+				Value:  keys.UpdatedAt.Value,
+			},
+		}
+
+		items = append(items,
+			dynamoclient.DynamoTransactItem{
+				Update: &dynamoclient.DyanamoUpdateRequest{
+					Key:       share.OwnerId.Value,
+					SortKey:   shares.OwnerKey(share.ItemId.Value, share.Recipient.Value),
+					Values:    values,
+					Condition: exists(),
+				},
+			},
+			dynamoclient.DynamoTransactItem{
+				Update: &dynamoclient.DyanamoUpdateRequest{
+					Key:       userId,
+					SortKey:   shares.RecipientKey(share.ShareId.Value),
+					Values:    values,
+					Condition: exists(),
+				},
+			},
+		)
+	}
+
+	response := client.TransactWrite(items)
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	return dynamoclient.SuccessWithValue(keys)
+}
+
+func revisionCondition(expected int) *dynamoclient.DynamoCondition {
+	if expected == 0 {
+		return &dynamoclient.DynamoCondition{
+			Expression: "attribute_not_exists(#sk)",
+			Names:      map[string]string{"#sk": dynamoclient.SORT_KEY},
+		}
+	}
+
+	return &dynamoclient.DynamoCondition{
+		Expression: "#revision = :revision",
+		Names:      map[string]string{"#revision": "REVISION"},
+		Values:     map[string]interface{}{":revision": expected},
+	}
+}
+
+func exists() *dynamoclient.DynamoCondition {
+	return &dynamoclient.DynamoCondition{
+		Expression: "attribute_exists(#sk)",
+		Names:      map[string]string{"#sk": dynamoclient.SORT_KEY},
+	}
+}
@@ -0,0 +1,145 @@
+package keys
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"reflect"
+	"strings"
+	"testing"
+
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/orgs"
+)
+
+const orgA = "6f1e4a3c-9a8b-4c2d-8e7f-0a1b2c3d4e5f"
+const orgB = "0c9d8e7f-6a5b-4c3d-8e2f-1a0b9c8d7e6f"
+
+func rsaPublicKey(t *testing.T, bits int) string {
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return base64.StdEncoding.EncodeToString(der)
+}
+
+func userOrg(orgId, status string) orgs.UserOrg {
+	var userOrg orgs.UserOrg
+	userOrg.Org.OrgId.Value = orgId
+	userOrg.Member.Status.Value = status
+	return userOrg
+}
+
+/***** Validate *****/
+
+func TestValidatePublicKey(t *testing.T) {
+	x25519 := base64.StdEncoding.EncodeToString(make([]byte, X25519_PUBLIC_KEY_LENGTH))
+
+	tests := []struct {
+		algorithm string
+		publicKey string
+		valid     bool
+	}{
+		{ALGORITHM_RSA_OAEP, rsaPublicKey(t, 2048), true},
+		{ALGORITHM_RSA_OAEP, rsaPublicKey(t, 1024), false},
+		{ALGORITHM_RSA_OAEP, x25519, false},
+		{ALGORITHM_X25519, x25519, true},
+		{ALGORITHM_X25519, base64.StdEncoding.EncodeToString(make([]byte, 31)), false},
+		{ALGORITHM_X25519, "not base64!", false},
+		{"RSA-PSS", x25519, false},
+	}
+
+	for _, test := range tests {
+		if err := ValidatePublicKey(test.algorithm, test.publicKey); (err == nil) != test.valid {
+			t.Errorf("FAILED - TestValidatePublicKey | Algorithm: %s | Error: %v | Expected valid: %v", test.algorithm, err, test.valid)
+		}
+	}
+}
+
+func TestValidateRejectsInvalidOrgKeys(t *testing.T) {
+	request := KeyPairRequest{
+		Algorithm:           ALGORITHM_X25519,
+		PublicKey:           base64.StdEncoding.EncodeToString(make([]byte, X25519_PUBLIC_KEY_LENGTH)),
+		EncryptedPrivateKey: "private",
+		OrgKeys:             map[string]string{"not-an-id": "key"},
+	}
+
+	if err := request.Validate(); err == nil {
+		t.Errorf("FAILED - TestValidateRejectsInvalidOrgKeys | Expected: an error for an invalid organization id")
+	}
+
+	request.OrgKeys = map[string]string{orgA: strings.Repeat("k", orgs.MAX_KEY_LENGTH+1)}
+
+	if err := request.Validate(); err == nil {
+		t.Errorf("FAILED - TestValidateRejectsInvalidOrgKeys | Expected: an error for a key that is too long")
+	}
+
+	request.OrgKeys = map[string]string{orgA: "key"}
+
+	if err := request.Validate(); err != nil {
+		t.Errorf("FAILED - TestValidateRejectsInvalidOrgKeys | Error: %v", err)
+	}
+}
+
+/***** Fingerprint *****/
+
+func TestFingerprint(t *testing.T) {
+	// SHA-256 of an empty input
+	expected := "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+	if actual := Fingerprint(""); actual != expected {
+		t.Errorf("FAILED - TestFingerprint | Actual: %s | Expected: %s", actual, expected)
+	}
+}
+
+/***** CompareShareKeys *****/
+
+func TestCompareShareKeys(t *testing.T) {
+	shareA := "1b7c1d3e-8f9a-4b2c-9d1e-2f3a4b5c6d7e"
+	shareB := "2c8d2e4f-9a0b-4c3d-8e2f-3a4b5c6d7e8f"
+
+	var received types.ItemShare
+	received.ShareId.Value = shareA
+
+	missing, unknown := CompareShareKeys(map[string]string{shareB: "key"}, []types.ItemShare{received})
+
+	if !reflect.DeepEqual(missing, []string{shareA}) || !reflect.DeepEqual(unknown, []string{shareB}) {
+		t.Errorf("FAILED - TestCompareShareKeys | Missing: %v | Unknown: %v", missing, unknown)
+	}
+
+	missing, unknown = CompareShareKeys(map[string]string{shareA: "key"}, []types.ItemShare{received})
+
+	if len(missing) != 0 || len(unknown) != 0 {
+		t.Errorf("FAILED - TestCompareShareKeys | Missing: %v | Unknown: %v", missing, unknown)
+	}
+}
+
+/***** CompareOrgKeys *****/
+
+func TestCompareOrgKeys(t *testing.T) {
+	userOrgs := []orgs.UserOrg{
+		userOrg(orgA, orgs.STATUS_CONFIRMED),
+		userOrg(orgB, orgs.STATUS_ACCEPTED),
+	}
+
+	missing, unknown := CompareOrgKeys(map[string]string{orgB: "key"}, userOrgs)
+
+	if !reflect.DeepEqual(missing, []string{orgA}) || !reflect.DeepEqual(unknown, []string{orgB}) {
+		t.Errorf("FAILED - TestCompareOrgKeys | Missing: %v | Unknown: %v", missing, unknown)
+	}
+
+	missing, unknown = CompareOrgKeys(map[string]string{orgA: "key"}, userOrgs)
+
+	if len(missing) != 0 || len(unknown) != 0 {
+		t.Errorf("FAILED - TestCompareOrgKeys | Missing: %v | Unknown: %v", missing, unknown)
+	}
+}
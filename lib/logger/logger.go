@@ -14,6 +14,9 @@ const (
 	LOG_LEVEL_WARN  = "warn"
 	LOG_LEVEL_ERROR = "error"
 	LOG_LEVEL_DEBUG = "debug"
+
+	// High risk operations of a user, i.e exporting the vault
+	LOG_LEVEL_SECURITY = "security"
 )
 
 type LoggerInterface interface {
@@ -21,6 +24,7 @@ type LoggerInterface interface {
 	Warn(message string, details interface{})
 	Error(message string, details interface{})
 	Debug(message string, details interface{})
+	Security(message string, details interface{})
 }
 
 type LogMessage struct {
@@ -90,3 +94,14 @@ func Debug(message string, details interface{}) {
 
 	Log(logMessage)
 }
+
+func Security(message string, details interface{}) {
+	logMessage := LogMessage{
+		ID:      uuid.New().String(),
+		Level:   LOG_LEVEL_SECURITY,
+		Message: message,
+		Details: details,
+	}
+
+	Log(logMessage)
+}
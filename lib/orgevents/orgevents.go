@@ -0,0 +1,404 @@
+package orgevents
+
+import (
+	"encoding/base64"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	appConfig "password-caddy/api/core/config"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/dynamoclient"
+	"password-caddy/api/lib/orgs"
+
+	"github.com/google/uuid"
+)
+
+// Sort key prefix of the events in an organization's partition
+const EVENT_PREFIX = "EVENT#"
+
+/*
+Times in the sort keys have a fixed width so the keys sort by time. The
+upper bound of a range sorts after every key of the same time
+*/
+const (
+	EVENT_TIME_FORMAT = "2006-01-02T15:04:05.000000000Z"
+	UPPER_BOUND       = "~"
+)
+
+/*
+Types of the events. Viewing an item and copying its password happen in the
+client, which reports them. All others are recorded by the API
+*/
+const (
+	TYPE_ITEM_VIEWED      = "itemViewed"
+	TYPE_PASSWORD_COPIED  = "passwordCopied"
+	TYPE_ITEM_CREATED     = "itemCreated"
+	TYPE_ITEM_UPDATED     = "itemUpdated"
+	TYPE_ITEM_TRASHED     = "itemTrashed"
+	TYPE_ITEM_DELETED     = "itemDeleted"
+	TYPE_MEMBER_INVITED   = "memberInvited"
+	TYPE_MEMBER_CONFIRMED = "memberConfirmed"
+	TYPE_MEMBER_UPDATED   = "memberUpdated"
+	TYPE_MEMBER_REMOVED   = "memberRemoved"
+	TYPE_POLICY_UPDATED   = "policyUpdated"
+)
+
+const (
+	DEFAULT_PAGE_SIZE = 100
+	MAX_PAGE_SIZE     = 500
+	MAX_EXPORT_EVENTS = 10000
+)
+
+// Response header of an export cut at MAX_EXPORT_EVENTS, with the cursor of the next export
+const CURSOR_HEADER = "X-Next-Cursor"
+
+// First characters that make spreadsheets read a cell as a formula
+const formulaPrefixes = "=+-@\t\r"
+
+// What happened in an organization. ItemId, MemberId and PolicyType are set depending on the type
+type Event struct {
+	Type       string
+	ActorId    string
+	ItemId     string
+	MemberId   string
+	PolicyType string
+	IpAddress  string
+}
+
+// An event reported by the client
+type ReportRequest struct {
+	Type   string `json:"type"`
+	ItemId string `json:"itemId"`
+}
+
+/*
+Narrows down the events of an organization. Start is inclusive, End
+exclusive and both are optional. Cursor continues a previous page
+*/
+type Filter struct {
+	Start   time.Time
+	End     time.Time
+	ActorId string
+	Type    string
+	Cursor  string
+	Limit   int
+}
+
+type EventResponse struct {
+	Id         string `json:"id"`
+	Type       string `json:"type"`
+	ActorId    string `json:"actorId"`
+	ItemId     string `json:"itemId,omitempty"`
+	MemberId   string `json:"memberId,omitempty"`
+	PolicyType string `json:"policyType,omitempty"`
+	IpAddress  string `json:"ipAddress,omitempty"`
+	Date       string `json:"date"`
+}
+
+// Events from the newest to the oldest. Cursor is only set when there may be more
+type EventsResponse struct {
+	Events []EventResponse `json:"events"`
+	Cursor string          `json:"cursor,omitempty"`
+}
+
+// A page of events and the sort key of its last event when there may be more
+type Page struct {
+	Events  []types.OrgEvent
+	LastKey string
+}
+
+/********** CONFIG **********/
+
+// How long events are kept before DynamoDB expires them
+func RetentionTTL() time.Duration {
+	days := appConfig.Get("ORG_EVENT_RETENTION_DAYS", "365").ToInt64()
+	return time.Duration(days) * 24 * time.Hour
+}
+
+/********** KEYS **********/
+
+func Types() []string {
+	return []string{
+		TYPE_ITEM_VIEWED,
+		TYPE_PASSWORD_COPIED,
+		TYPE_ITEM_CREATED,
+		TYPE_ITEM_UPDATED,
+		TYPE_ITEM_TRASHED,
+		TYPE_ITEM_DELETED,
+		TYPE_MEMBER_INVITED,
+		TYPE_MEMBER_CONFIRMED,
+		TYPE_MEMBER_UPDATED,
+		TYPE_MEMBER_REMOVED,
+		TYPE_POLICY_UPDATED,
+	}
+}
+
+func IsValidType(eventType string) bool {
+	return contains(Types(), eventType)
+}
+
+// Only views and copies can be reported by the client
+func IsReportedType(eventType string) bool {
+	return eventType == TYPE_ITEM_VIEWED || eventType == TYPE_PASSWORD_COPIED
+}
+
+func EventKey(createdAt time.Time, eventId string) string {
+	return TimeKey(createdAt) + "#" + eventId
+}
+
+// The sort key prefix of the events at a time
+func TimeKey(at time.Time) string {
+	return EVENT_PREFIX + at.UTC().Format(EVENT_TIME_FORMAT)
+}
+
+/********** CURSOR **********/
+
+// The cursor is the sort key of the last event of the page, which the next page starts after
+func EncodeCursor(lastKey string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(lastKey))
+}
+
+func DecodeCursor(cursor string) (string, error) {
+	value, err := base64.RawURLEncoding.DecodeString(cursor)
+
+	if err != nil || !strings.HasPrefix(string(value), EVENT_PREFIX) {
+		return "", errors.New("Invalid event cursor")
+	}
+
+	return string(value), nil
+}
+
+/********** VALIDATION **********/
+
+func (request ReportRequest) Validate() error {
+	if !IsReportedType(request.Type) {
+		return fmt.Errorf("Type must be %s or %s", TYPE_ITEM_VIEWED, TYPE_PASSWORD_COPIED)
+	}
+
+	if _, err := uuid.Parse(request.ItemId); err != nil {
+		return errors.New("Item id must be a UUID")
+	}
+
+	return nil
+}
+
+// Build the filter out of the start, end, actor, type, cursor and limit query parameters
+func ParseFilter(params map[string]string) (Filter, error) {
+	filter := Filter{
+		ActorId: params["actor"],
+		Type:    params["type"],
+		Cursor:  params["cursor"],
+		Limit:   DEFAULT_PAGE_SIZE,
+	}
+
+	var err error
+
+	if params["start"] != "" {
+		if filter.Start, err = time.Parse(time.RFC3339, params["start"]); err != nil {
+			return filter, errors.New("Start must be an RFC 3339 date")
+		}
+	}
+
+	if params["end"] != "" {
+		if filter.End, err = time.Parse(time.RFC3339, params["end"]); err != nil {
+			return filter, errors.New("End must be an RFC 3339 date")
+		}
+	}
+
+	if !filter.Start.IsZero() && !filter.End.IsZero() && !filter.Start.Before(filter.End) {
+		return filter, errors.New("Start must be before end")
+	}
+
+	if filter.Type != "" && !IsValidType(filter.Type) {
+		return filter, errors.New("Unknown event type")
+	}
+
+	if filter.Cursor != "" {
+		if _, err = DecodeCursor(filter.Cursor); err != nil {
+			return filter, err
+		}
+	}
+
+	if params["limit"] != "" {
+		filter.Limit, err = strconv.Atoi(params["limit"])
+
+		if err != nil || filter.Limit < 1 || filter.Limit > MAX_PAGE_SIZE {
+			return filter, fmt.Errorf("Limit must be between 1 and %d", MAX_PAGE_SIZE)
+		}
+	}
+
+	return filter, nil
+}
+
+// The sort keys of the events in the range of the filter
+func (filter Filter) KeyRange() (string, string) {
+	from := EVENT_PREFIX
+	to := EVENT_PREFIX + UPPER_BOUND
+
+	if !filter.Start.IsZero() {
+		from = TimeKey(filter.Start)
+	}
+
+	if !filter.End.IsZero() {
+		to = TimeKey(filter.End)
+	}
+
+	return from, to
+}
+
+/********** RESPONSES **********/
+
+func ToEventResponse(event types.OrgEvent) EventResponse {
+	return EventResponse{
+		Id:         event.EventId.Value,
+		Type:       event.Type.Value,
+		ActorId:    event.ActorId.Value,
+		ItemId:     event.ItemId.Value,
+		MemberId:   event.MemberId.Value,
+		PolicyType: event.PolicyType.Value,
+		IpAddress:  event.IpAddress.Value,
+		Date:       event.CreatedAt.Value,
+	}
+}
+
+func ToEventsResponse(page Page) EventsResponse {
+	response := EventsResponse{Events: []EventResponse{}}
+
+	for _, event := range page.Events {
+		response.Events = append(response.Events, ToEventResponse(event))
+	}
+
+	if page.LastKey != "" {
+		response.Cursor = EncodeCursor(page.LastKey)
+	}
+
+	return response
+}
+
+/*
+Write the events as CSV with a header row. Cells starting like a formula are
+prefixed with a quote so spreadsheets show them as text, actors and reported
+item ids come from the members
+*/
+func ToCSV(events []types.OrgEvent) (string, error) {
+	var builder strings.Builder
+
+	writer := csv.NewWriter(&builder)
+
+	rows := [][]string{{"date", "type", "actor", "itemId", "member", "policy", "ipAddress", "id"}}
+
+	for _, event := range events {
+		rows = append(rows, []string{
+			escapeCell(event.CreatedAt.Value),
+			escapeCell(event.Type.Value),
+			escapeCell(event.ActorId.Value),
+			escapeCell(event.ItemId.Value),
+			escapeCell(event.MemberId.Value),
+			escapeCell(event.PolicyType.Value),
+			escapeCell(event.IpAddress.Value),
+			escapeCell(event.EventId.Value),
+		})
+	}
+
+	if err := writer.WriteAll(rows); err != nil {
+		return "", err
+	}
+
+	return builder.String(), nil
+}
+
+/********** OPERATIONS **********/
+
+// Save an event of an organization. Events expire after the retention period
+func Record(client *dynamoclient.DynamoClient, orgId string, event Event) *dynamoclient.DynamoResponse {
+	now := time.Now().UTC()
+	eventId := uuid.NewString()
+
+	return client.Put(dynamoclient.DynamoPutRequest{
+		Key:     orgs.PartitionKey(orgId),
+		SortKey: EventKey(now, eventId),
+		Values: map[string]interface{}{
+			"EVENT_ID":    eventId,
+			"ORG_ID":      orgId,
+			"TYPE":        event.Type,
+			"ACTOR_ID":    event.ActorId,
+			"ITEM_ID":     event.ItemId,
+			"MEMBER_ID":   event.MemberId,
+			"POLICY_TYPE": event.PolicyType,
+			"IP_ADDRESS":  event.IpAddress,
+			"CREATED_AT":  now.Format(time.RFC3339),
+			"EXPIRES_AT":  now.Add(RetentionTTL()).Unix(),
+		},
+	})
+}
+
+// Get a page of the events of an organization from the newest to the oldest
+func List(client *dynamoclient.DynamoClient, orgId string, filter Filter) *dynamoclient.DynamoResponse {
+	from, to := filter.KeyRange()
+
+	request := dynamoclient.DynamoQueryRequest{
+		Key:         orgs.PartitionKey(orgId),
+		SortKeyFrom: from,
+		SortKeyTo:   to,
+		Descending:  true,
+		Limit:       int32(filter.Limit),
+		Names:       map[string]string{},
+		Values:      map[string]interface{}{},
+	}
+
+	if filter.Cursor != "" {
+		request.StartAfter, _ = DecodeCursor(filter.Cursor)
+	}
+
+	conditions := []string{}
+
+	if filter.ActorId != "" {
+		conditions = append(conditions, "#actor = :actor")
+		request.Names["#actor"] = "ACTOR_ID"
+		request.Values[":actor"] = filter.ActorId
+	}
+
+	if filter.Type != "" {
+		conditions = append(conditions, "#type = :type")
+		request.Names["#type"] = "TYPE"
+		request.Values[":type"] = filter.Type
+	}
+
+	request.Filter = strings.Join(conditions, " AND ")
+
+	response := client.Query(request).AsOrgEvents()
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	page := Page{Events: response.Data.([]types.OrgEvent)}
+
+	if len(page.Events) > 0 && len(page.Events) >= filter.Limit {
+		page.LastKey = page.Events[len(page.Events)-1].ItemKey.Value
+	}
+
+	return dynamoclient.SuccessWithValue(page)
+}
+
+func escapeCell(value string) string {
+	if value != "" && strings.ContainsRune(formulaPrefixes, rune(value[0])) {
+		return "'" + value
+	}
+
+	return value
+}
+
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,172 @@
+package orgevents
+
+import (
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"password-caddy/api/core/types"
+)
+
+/***** Keys *****/
+
+func TestEventKeysSortByTime(t *testing.T) {
+	earlier := EventKey(time.Date(2024, 1, 1, 9, 59, 59, 900000000, time.UTC), "b")
+	later := EventKey(time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC), "a")
+
+	if earlier >= later {
+		t.Errorf("FAILED - TestEventKeysSortByTime | Expected: %s to sort before %s", earlier, later)
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	key := EventKey(time.Now(), "a")
+
+	actual, err := DecodeCursor(EncodeCursor(key))
+
+	if err != nil || actual != key {
+		t.Errorf("FAILED - TestCursorRoundTrip | Actual: %s | Error: %v | Expected: %s", actual, err, key)
+	}
+
+	if _, err := DecodeCursor(EncodeCursor("MEMBER#bob@example.com")); err == nil {
+		t.Errorf("FAILED - TestCursorRoundTrip | Expected: a cursor outside the events to be rejected")
+	}
+}
+
+/***** Validate *****/
+
+func TestReportRequestValidate(t *testing.T) {
+	tests := []struct {
+		request ReportRequest
+		valid   bool
+	}{
+		{ReportRequest{Type: TYPE_ITEM_VIEWED, ItemId: "c5a0e0c8-5f3c-4d4e-9a53-0c1f8a6b1f00"}, true},
+		{ReportRequest{Type: TYPE_PASSWORD_COPIED, ItemId: "c5a0e0c8-5f3c-4d4e-9a53-0c1f8a6b1f00"}, true},
+		{ReportRequest{Type: TYPE_MEMBER_REMOVED, ItemId: "c5a0e0c8-5f3c-4d4e-9a53-0c1f8a6b1f00"}, false},
+		{ReportRequest{Type: TYPE_ITEM_VIEWED, ItemId: "item"}, false},
+	}
+
+	for _, test := range tests {
+		if err := test.request.Validate(); (err == nil) != test.valid {
+			t.Errorf("FAILED - TestReportRequestValidate | Request: %v | Error: %v | Expected valid: %v", test.request, err, test.valid)
+		}
+	}
+}
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		params map[string]string
+		valid  bool
+	}{
+		{map[string]string{}, true},
+		{map[string]string{"start": "2024-01-01T00:00:00Z", "end": "2024-02-01T00:00:00Z", "type": TYPE_ITEM_UPDATED, "limit": "50"}, true},
+		{map[string]string{"start": "2024-02-01T00:00:00Z", "end": "2024-01-01T00:00:00Z"}, false},
+		{map[string]string{"start": "yesterday"}, false},
+		{map[string]string{"type": "itemExploded"}, false},
+		{map[string]string{"limit": "0"}, false},
+		{map[string]string{"cursor": "not a cursor"}, false},
+	}
+
+	for _, test := range tests {
+		if _, err := ParseFilter(test.params); (err == nil) != test.valid {
+			t.Errorf("FAILED - TestParseFilter | Params: %v | Error: %v | Expected valid: %v", test.params, err, test.valid)
+		}
+	}
+}
+
+func TestKeyRange(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	from, to := Filter{Start: start, End: end}.KeyRange()
+
+	inside := EventKey(start, "a")
+	after := EventKey(end, "a")
+
+	if inside < from || inside > to {
+		t.Errorf("FAILED - TestKeyRange | Expected: an event at the start to be in the range")
+	}
+
+	if after >= from && after <= to {
+		t.Errorf("FAILED - TestKeyRange | Expected: an event at the end to be out of the range")
+	}
+
+	from, to = Filter{}.KeyRange()
+
+	if inside < from || inside > to {
+		t.Errorf("FAILED - TestKeyRange | Expected: every event to be in an open range")
+	}
+}
+
+/***** Responses *****/
+
+func TestToCSV(t *testing.T) {
+	var event types.OrgEvent
+	event.EventId.Value = "a"
+	event.Type.Value = TYPE_MEMBER_REMOVED
+	event.ActorId.Value = "alice@example.com"
+	event.MemberId.Value = "bob@example.com"
+	event.CreatedAt.Value = "2024-01-01T00:00:00Z"
+
+	actual, err := ToCSV([]types.OrgEvent{event})
+	expected := "date,type,actor,itemId,member,policy,ipAddress,id\n" +
+		"2024-01-01T00:00:00Z,memberRemoved,alice@example.com,,bob@example.com,,,a\n"
+
+	if err != nil || actual != expected {
+		t.Errorf("FAILED - TestToCSV | Actual: %s | Error: %v | Expected: %s", actual, err, expected)
+	}
+}
+
+func TestToCSVEscapesFormulas(t *testing.T) {
+	cases := []struct {
+		value    string
+		expected string
+	}{
+		{"=HYPERLINK(\"http://example.com\")", "'=HYPERLINK(\"http://example.com\")"},
+		{"+1", "'+1"},
+		{"-1", "'-1"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"alice@example.com", "alice@example.com"},
+		{"", ""},
+	}
+
+	for _, c := range cases {
+		var event types.OrgEvent
+		event.ItemId.Value = c.value
+
+		actual, err := ToCSV([]types.OrgEvent{event})
+
+		if err != nil {
+			t.Fatalf("FAILED - TestToCSVEscapesFormulas | Error: %v", err)
+		}
+
+		rows, err := csv.NewReader(strings.NewReader(actual)).ReadAll()
+
+		if err != nil || len(rows) != 2 || rows[1][3] != c.expected {
+			t.Errorf("FAILED - TestToCSVEscapesFormulas | Actual: %v | Error: %v | Expected: %s", rows, err, c.expected)
+		}
+	}
+}
+
+func TestToEventsResponseOnlyHasCursorWithLastKey(t *testing.T) {
+	if ToEventsResponse(Page{}).Cursor != "" {
+		t.Errorf("FAILED - TestToEventsResponseOnlyHasCursorWithLastKey | Expected: no cursor on the last page")
+	}
+
+	response := ToEventsResponse(Page{LastKey: EVENT_PREFIX + "x"})
+
+	if mustDecode(t, response.Cursor) != EVENT_PREFIX+"x" {
+		t.Errorf("FAILED - TestToEventsResponseOnlyHasCursorWithLastKey | Expected: the cursor of the last key")
+	}
+}
+
+func mustDecode(t *testing.T, cursor string) string {
+	key, err := DecodeCursor(cursor)
+
+	if err != nil {
+		t.Fatalf("FAILED - mustDecode | Error: %v", err)
+	}
+
+	return key
+}
@@ -0,0 +1,627 @@
+package orgs
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"time"
+
+	appConfig "password-caddy/api/core/config"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/dynamoclient"
+	"password-caddy/api/lib/util"
+
+	dynamoTypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/google/uuid"
+)
+
+/*
+Keys of an organization. The organization and its members are stored in the
+organization's partition (ORG#<id>). Every member's partition holds a
+reference (<email>, ORG#<id>) to list the organizations of a user
+*/
+const (
+	ORG_PREFIX    = "ORG#"
+	ORG_KEY       = "ORG"
+	MEMBER_PREFIX = "MEMBER#"
+)
+
+// Roles of the members of an organization
+const (
+	ROLE_OWNER   = "owner"
+	ROLE_ADMIN   = "admin"
+	ROLE_MANAGER = "manager"
+	ROLE_USER    = "user"
+	ROLE_CUSTOM  = "custom"
+)
+
+/*
+Statuses of a membership. Invited users accept the invite, then a member
+who can manage members confirms them by wrapping the organization key with
+the new member's public key
+*/
+const (
+	STATUS_INVITED   = "invited"
+	STATUS_ACCEPTED  = "accepted"
+	STATUS_CONFIRMED = "confirmed"
+)
+
+// Permissions a custom role can be given. Owners and admins have all of them
+const (
+	PERMISSION_MANAGE_MEMBERS     = "manageMembers"
+	PERMISSION_MANAGE_COLLECTIONS = "manageCollections"
+	PERMISSION_MANAGE_POLICIES    = "managePolicies"
+	PERMISSION_ACCESS_EVENT_LOGS  = "accessEventLogs"
+)
+
+const (
+	MAX_NAME_LENGTH = 128
+	MAX_KEY_LENGTH  = 4096
+)
+
+type CreateOrgRequest struct {
+	Name string `json:"name"`
+	Key  string `json:"key"`
+}
+
+type InviteRequest struct {
+	Email       string   `json:"email"`
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions"`
+}
+
+type UpdateMemberRequest struct {
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions"`
+}
+
+type ConfirmMemberRequest struct {
+	Key string `json:"key"`
+}
+
+// An organization as one of its members sees it. Key is only set for confirmed members
+type OrgResponse struct {
+	Id          string   `json:"id"`
+	Name        string   `json:"name"`
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions"`
+	Status      string   `json:"status"`
+	Key         string   `json:"key,omitempty"`
+}
+
+type MemberResponse struct {
+	Email       string   `json:"email"`
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions"`
+	Status      string   `json:"status"`
+	InvitedBy   string   `json:"invitedBy,omitempty"`
+	CreatedAt   string   `json:"createdAt"`
+}
+
+// An organization of a user together with the user's membership
+type UserOrg struct {
+	Org    types.Organization
+	Member types.OrgMember
+}
+
+/********** CONFIG **********/
+
+// Link of the invite email, where invited users accept or decline
+func InviteUrl() string {
+	return appConfig.Get("ORG_INVITE_URL", "https://password-caddy.com/organizations").ToString()
+}
+
+/********** KEYS **********/
+
+func IsValidOrgId(orgId string) bool {
+	_, err := uuid.Parse(orgId)
+	return err == nil
+}
+
+func PartitionKey(orgId string) string {
+	return ORG_PREFIX + orgId
+}
+
+func MemberKey(email string) string {
+	return MEMBER_PREFIX + email
+}
+
+// Emails in the path may still be percent encoded (i.e bob%40example.com)
+func EmailParameter(value string) string {
+	if unescaped, err := url.PathUnescape(value); err == nil {
+		return unescaped
+	}
+
+	return value
+}
+
+// Key of the reference to an organization in a member's partition
+func UserKey(orgId string) string {
+	return ORG_PREFIX + orgId
+}
+
+/********** ROLES **********/
+
+func Roles() []string {
+	return []string{ROLE_OWNER, ROLE_ADMIN, ROLE_MANAGER, ROLE_USER, ROLE_CUSTOM}
+}
+
+func AllPermissions() []string {
+	return []string{
+		PERMISSION_MANAGE_MEMBERS,
+		PERMISSION_MANAGE_COLLECTIONS,
+		PERMISSION_MANAGE_POLICIES,
+		PERMISSION_ACCESS_EVENT_LOGS,
+	}
+}
+
+func IsValidRole(role string) bool {
+	return contains(Roles(), role)
+}
+
+// Only custom roles have permissions of their own, the permissions of the other roles are fixed
+func ValidatePermissions(role string, permissions []string) error {
+	if role != ROLE_CUSTOM && len(permissions) > 0 {
+		return errors.New("Only custom roles can have permissions")
+	}
+
+	for _, permission := range permissions {
+		if !contains(AllPermissions(), permission) {
+			return fmt.Errorf("%s is not a permission", permission)
+		}
+	}
+
+	return nil
+}
+
+// The permissions of a member according to its role
+func PermissionsOf(member types.OrgMember) []string {
+	switch member.Role.Value {
+	case ROLE_OWNER, ROLE_ADMIN:
+		return AllPermissions()
+	case ROLE_MANAGER:
+		return []string{PERMISSION_MANAGE_COLLECTIONS}
+	case ROLE_CUSTOM:
+		permissions := []string{}
+
+		if member.Permissions.Value != "" {
+			util.DeserializeJson(member.Permissions.Value, &permissions)
+		}
+
+		return permissions
+	}
+
+	return []string{}
+}
+
+// Only confirmed members have the permissions of their role
+func HasPermission(member types.OrgMember, permission string) bool {
+	return member.Status.Value == STATUS_CONFIRMED && contains(PermissionsOf(member), permission)
+}
+
+/*
+Check if a member can give a role to another member or take it away. Owners
+manage everyone, admins everyone but owners and other members who can manage
+members only managers, users and custom roles
+*/
+func CanManageRole(actor types.OrgMember, role string) bool {
+	if !HasPermission(actor, PERMISSION_MANAGE_MEMBERS) {
+		return false
+	}
+
+	switch actor.Role.Value {
+	case ROLE_OWNER:
+		return true
+	case ROLE_ADMIN:
+		return role != ROLE_OWNER
+	}
+
+	return role != ROLE_OWNER && role != ROLE_ADMIN
+}
+
+/*
+Check if a member can give a role with its permissions to another member.
+Owners and admins can give every permission, other members only the ones they
+have themselves so they can not grant more than they hold
+*/
+func CanGrantPermissions(actor types.OrgMember, role string, permissions []string) bool {
+	if actor.Role.Value == ROLE_OWNER || actor.Role.Value == ROLE_ADMIN {
+		return true
+	}
+
+	granted := PermissionsOf(newMember("", "", role, permissions, "", ""))
+	held := PermissionsOf(actor)
+
+	for _, permission := range granted {
+		if !contains(held, permission) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// An organization always keeps at least one confirmed owner
+func IsLastOwner(members []types.OrgMember, email string) bool {
+	for _, member := range members {
+		if member.Role.Value == ROLE_OWNER && member.Status.Value == STATUS_CONFIRMED && member.Email.Value != email {
+			return false
+		}
+	}
+
+	return true
+}
+
+/********** VALIDATION **********/
+
+func (request CreateOrgRequest) Validate() error {
+	if request.Name == "" || len(request.Name) > MAX_NAME_LENGTH {
+		return fmt.Errorf("Name is required and can have at most %d characters", MAX_NAME_LENGTH)
+	}
+
+	return validateKey(request.Key)
+}
+
+func (request InviteRequest) Validate() error {
+	address, err := mail.ParseAddress(request.Email)
+
+	if err != nil || address.Address != request.Email {
+		return errors.New("Email must be an email address")
+	}
+
+	if !IsValidRole(request.Role) {
+		return errors.New("Role must be owner, admin, manager, user or custom")
+	}
+
+	return ValidatePermissions(request.Role, request.Permissions)
+}
+
+func (request UpdateMemberRequest) Validate() error {
+	if !IsValidRole(request.Role) {
+		return errors.New("Role must be owner, admin, manager, user or custom")
+	}
+
+	return ValidatePermissions(request.Role, request.Permissions)
+}
+
+func (request ConfirmMemberRequest) Validate() error {
+	return validateKey(request.Key)
+}
+
+// The organization key wrapped by the client, the server never sees it unwrapped
+func validateKey(key string) error {
+	if key == "" || len(key) > MAX_KEY_LENGTH {
+		return fmt.Errorf("Key is required and can have at most %d characters", MAX_KEY_LENGTH)
+	}
+
+	return nil
+}
+
+/********** RESPONSES **********/
+
+func ToOrgResponse(org types.Organization, member types.OrgMember) OrgResponse {
+	response := OrgResponse{
+		Id:          org.OrgId.Value,
+		Name:        org.Name.Value,
+		Role:        member.Role.Value,
+		Permissions: PermissionsOf(member),
+		Status:      member.Status.Value,
+	}
+
+	if member.Status.Value == STATUS_CONFIRMED {
+		response.Key = member.Key.Value
+	}
+
+	return response
+}
+
+func ToMemberResponse(member types.OrgMember) MemberResponse {
+	return MemberResponse{
+		Email:       member.Email.Value,
+		Role:        member.Role.Value,
+		Permissions: PermissionsOf(member),
+		Status:      member.Status.Value,
+		InvitedBy:   member.InvitedBy.Value,
+		CreatedAt:   member.CreatedAt.Value,
+	}
+}
+
+/********** OPERATIONS **********/
+
+// Get an organization. A missing organization results in an empty Organization
+func GetOrg(client *dynamoclient.DynamoClient, orgId string) *dynamoclient.DynamoResponse {
+	return client.
+		Get(dynamoclient.DynamoGetRequest{
+			Key:     PartitionKey(orgId),
+			SortKey: ORG_KEY,
+		}).
+		AsOrganization()
+}
+
+// Get the membership of a user. A missing membership results in an empty OrgMember
+func GetMember(client *dynamoclient.DynamoClient, orgId, email string) *dynamoclient.DynamoResponse {
+	return client.
+		Get(dynamoclient.DynamoGetRequest{
+			Key:     PartitionKey(orgId),
+			SortKey: MemberKey(email),
+		}).
+		AsOrgMember()
+}
+
+// Get all members of an organization, including the invited ones
+func ListMembers(client *dynamoclient.DynamoClient, orgId string) *dynamoclient.DynamoResponse {
+	return client.
+		Query(dynamoclient.DynamoQueryRequest{
+			Key:           PartitionKey(orgId),
+			SortKeyPrefix: MEMBER_PREFIX,
+		}).
+		AsOrgMembers()
+}
+
+// Get the organizations a user is a member of or invited to
+func ListUserOrgs(client *dynamoclient.DynamoClient, email string) *dynamoclient.DynamoResponse {
+	response := client.
+		Query(dynamoclient.DynamoQueryRequest{
+			Key:           email,
+			SortKeyPrefix: ORG_PREFIX,
+		}).
+		AsOrgMembers()
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	userOrgs := []UserOrg{}
+
+	for _, reference := range response.Data.([]types.OrgMember) {
+		orgId := reference.OrgId.Value
+
+		response = GetOrg(client, orgId)
+
+		if !response.IsSuccess {
+			return response
+		}
+
+		org := response.Data.(types.Organization)
+
+		response = GetMember(client, orgId, email)
+
+		if !response.IsSuccess {
+			return response
+		}
+
+		member := response.Data.(types.OrgMember)
+
+		if org.OrgId.Value != "" && member.Email.Value != "" {
+			userOrgs = append(userOrgs, UserOrg{Org: org, Member: member})
+		}
+	}
+
+	return dynamoclient.SuccessWithValue(userOrgs)
+}
+
+// Create an organization with its creator as the confirmed owner. key is the
+// organization key wrapped with the owner's public key
+func CreateOrg(client *dynamoclient.DynamoClient, ownerId string, request CreateOrgRequest) *dynamoclient.DynamoResponse {
+	var org types.Organization
+
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	org.OrgId.Value = uuid.NewString()
+	org.UserId.Value = PartitionKey(org.OrgId.Value)
+	org.ItemKey.Value = ORG_KEY
+	org.Name.Value = request.Name
+	org.CreatedBy.Value = ownerId
+	org.CreatedAt.Value = now
+
+	owner := newMember(org.OrgId.Value, ownerId, ROLE_OWNER, nil, "", now)
+	owner.Status.Value = STATUS_CONFIRMED
+	owner.Key.Value = request.Key
+
+	response := client.TransactWrite([]dynamoclient.DynamoTransactItem{
+		{
+			Put: &dynamoclient.DynamoPutRequest{
+				Key:     org.UserId.Value,
+				SortKey: ORG_KEY,
+				Values: map[string]interface{}{
+					"ORG_ID":     org.OrgId.Value,
+					"NAME":       org.Name.Value,
+					"CREATED_BY": org.CreatedBy.Value,
+					"CREATED_AT": org.CreatedAt.Value,
+				},
+				Condition: notExists(),
+			},
+		},
+		memberPut(owner),
+		referencePut(owner),
+	})
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	return dynamoclient.SuccessWithValue(UserOrg{Org: org, Member: owner})
+}
+
+// Invite a user to an organization. Fails with a 409 when the user already is a member or invited
+func Invite(client *dynamoclient.DynamoClient, orgId, invitedBy string, request InviteRequest) *dynamoclient.DynamoResponse {
+	member := newMember(orgId, request.Email, request.Role, request.Permissions, invitedBy, time.Now().UTC().Format(time.RFC3339))
+
+	response := client.TransactWrite([]dynamoclient.DynamoTransactItem{
+		memberPut(member),
+		referencePut(member),
+	})
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	return dynamoclient.SuccessWithValue(member)
+}
+
+// Accept an invite. Fails with a 409 when the membership is no longer invited
+func Accept(client *dynamoclient.DynamoClient, member types.OrgMember) *dynamoclient.DynamoResponse {
+	return updateMember(client, member, STATUS_INVITED, map[string]interface{}{
+		"STATUS": STATUS_ACCEPTED,
+	})
+}
+
+// Confirm an accepted member with the organization key wrapped with the member's public key
+func Confirm(client *dynamoclient.DynamoClient, member types.OrgMember, key string) *dynamoclient.DynamoResponse {
+	return updateMember(client, member, STATUS_ACCEPTED, map[string]interface{}{
+		"STATUS": STATUS_CONFIRMED,
+		"KEY":    key,
+	})
+}
+
+// Change the role of a member, whatever the status of the membership
+func UpdateRole(client *dynamoclient.DynamoClient, member types.OrgMember, role string, permissions []string) *dynamoclient.DynamoResponse {
+	return updateMember(client, member, "", map[string]interface{}{
+		"ROLE":        role,
+		"PERMISSIONS": encodePermissions(permissions),
+	})
+}
+
+// Remove a member or decline an invite, removing the reference in the member's partition as well
+func Remove(client *dynamoclient.DynamoClient, member types.OrgMember) *dynamoclient.DynamoResponse {
+	return client.TransactWrite([]dynamoclient.DynamoTransactItem{
+		{
+			Delete: &dynamoclient.DynamoDeleteRequest{
+				Key:       PartitionKey(member.OrgId.Value),
+				SortKey:   MemberKey(member.Email.Value),
+				Condition: exists(),
+			},
+		},
+		{
+			Delete: &dynamoclient.DynamoDeleteRequest{
+				Key:     member.Email.Value,
+				SortKey: UserKey(member.OrgId.Value),
+			},
+		},
+	})
+}
+
+func newMember(orgId, email, role string, permissions []string, invitedBy, now string) types.OrgMember {
+	var member types.OrgMember
+
+	member.UserId.Value = PartitionKey(orgId)
+	member.ItemKey.Value = MemberKey(email)
+	member.OrgId.Value = orgId
+	member.Email.Value = email
+	member.Role.Value = role
+	member.Permissions.Value = encodePermissions(permissions)
+	member.Status.Value = STATUS_INVITED
+	member.InvitedBy.Value = invitedBy
+	member.CreatedAt.Value = now
+	member.UpdatedAt.Value = now
+
+	return member
+}
+
+func memberPut(member types.OrgMember) dynamoclient.DynamoTransactItem {
+	values := map[string]interface{}{
+		"ORG_ID":      member.OrgId.Value,
+		"EMAIL":       member.Email.Value,
+		"ROLE":        member.Role.Value,
+		"PERMISSIONS": member.Permissions.Value,
+		"STATUS":      member.Status.Value,
+		"CREATED_AT":  member.CreatedAt.Value,
+		"UPDATED_AT":  member.UpdatedAt.Value,
+	}
+
+	if member.Key.Value != "" {
+		values["KEY"] = member.Key.Value
+	}
+
+	if member.InvitedBy.Value != "" {
+		values["INVITED_BY"] = member.InvitedBy.Value
+	}
+
+	return dynamoclient.DynamoTransactItem{
+		Put: &dynamoclient.DynamoPutRequest{
+			Key:       member.UserId.Value,
+			SortKey:   member.ItemKey.Value,
+			Values:    values,
+			Condition: notExists(),
+		},
+	}
+}
+
+func referencePut(member types.OrgMember) dynamoclient.DynamoTransactItem {
+	return dynamoclient.DynamoTransactItem{
+		Put: &dynamoclient.DynamoPutRequest{
+			Key:     member.Email.Value,
+			SortKey: UserKey(member.OrgId.Value),
+			Values: map[string]interface{}{
+				"ORG_ID": member.OrgId.Value,
+			},
+		},
+	}
+}
+
+// Update attributes of a membership that still exists and, unless status is empty, still has the status
+func updateMember(client *dynamoclient.DynamoClient, member types.OrgMember, status string, values map[string]interface{}) *dynamoclient.DynamoResponse {
+	values["UPDATED_AT"] = time.Now().UTC().Format(time.RFC3339)
+
+	updates := map[string]dynamoclient.DynamoUpdateItem{}
+
+	for key, value := range values {
+		updates[key] = dynamoclient.DynamoUpdateItem{
+			Action: dynamoTypes.AttributeActionPut,
+			Value:  value,
+		}
+	}
+
+	condition := exists()
+
+	if status != "" {
+		condition = &dynamoclient.DynamoCondition{
+			Expression: "#status = :status",
+			Names:      map[string]string{"#status": "STATUS"},
+			Values:     map[string]interface{}{":status": status},
+		}
+	}
+
+	return client.
+		Update(dynamoclient.DyanamoUpdateRequest{
+			Key:       PartitionKey(member.OrgId.Value),
+			SortKey:   MemberKey(member.Email.Value),
+			Values:    updates,
+			Condition: condition,
+		}).
+		AsOrgMember()
+}
+
+func encodePermissions(permissions []string) string {
+	if permissions == nil {
+		permissions = []string{}
+	}
+
+	return util.SerializeJson(permissions)
+}
+
+func exists() *dynamoclient.DynamoCondition {
+	return &dynamoclient.DynamoCondition{
+		Expression: "attribute_exists(#sk)",
+		Names:      map[string]string{"#sk": dynamoclient.SORT_KEY},
+	}
+}
+
+func notExists() *dynamoclient.DynamoCondition {
+	return &dynamoclient.DynamoCondition{
+		Expression: "attribute_not_exists(#sk)",
+		Names:      map[string]string{"#sk": dynamoclient.SORT_KEY},
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+
+	return false
+}
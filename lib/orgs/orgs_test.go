@@ -0,0 +1,145 @@
+package orgs
+
+import (
+	"reflect"
+	"testing"
+
+	"password-caddy/api/core/types"
+)
+
+func memberWith(email, role, status string, permissions ...string) types.OrgMember {
+	member := newMember("org", email, role, permissions, "", "")
+	member.Status.Value = status
+	return member
+}
+
+/***** Roles *****/
+
+func TestPermissionsOf(t *testing.T) {
+	tests := []struct {
+		member   types.OrgMember
+		expected []string
+	}{
+		{memberWith("a", ROLE_OWNER, STATUS_CONFIRMED), AllPermissions()},
+		{memberWith("a", ROLE_ADMIN, STATUS_CONFIRMED), AllPermissions()},
+		{memberWith("a", ROLE_MANAGER, STATUS_CONFIRMED), []string{PERMISSION_MANAGE_COLLECTIONS}},
+		{memberWith("a", ROLE_USER, STATUS_CONFIRMED), []string{}},
+		{memberWith("a", ROLE_CUSTOM, STATUS_CONFIRMED, PERMISSION_ACCESS_EVENT_LOGS), []string{PERMISSION_ACCESS_EVENT_LOGS}},
+	}
+
+	for _, test := range tests {
+		if actual := PermissionsOf(test.member); !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("FAILED - TestPermissionsOf | Role: %s | Actual: %v | Expected: %v", test.member.Role.Value, actual, test.expected)
+		}
+	}
+}
+
+func TestHasPermissionRequiresConfirmation(t *testing.T) {
+	if HasPermission(memberWith("a", ROLE_OWNER, STATUS_ACCEPTED), PERMISSION_MANAGE_MEMBERS) {
+		t.Errorf("FAILED - TestHasPermissionRequiresConfirmation | Expected: members that are not confirmed to have no permissions")
+	}
+}
+
+func TestCanManageRole(t *testing.T) {
+	owner := memberWith("a", ROLE_OWNER, STATUS_CONFIRMED)
+	admin := memberWith("b", ROLE_ADMIN, STATUS_CONFIRMED)
+	custom := memberWith("c", ROLE_CUSTOM, STATUS_CONFIRMED, PERMISSION_MANAGE_MEMBERS)
+	user := memberWith("d", ROLE_USER, STATUS_CONFIRMED)
+
+	tests := []struct {
+		actor    types.OrgMember
+		role     string
+		expected bool
+	}{
+		{owner, ROLE_OWNER, true},
+		{admin, ROLE_OWNER, false},
+		{admin, ROLE_ADMIN, true},
+		{custom, ROLE_ADMIN, false},
+		{custom, ROLE_USER, true},
+		{user, ROLE_USER, false},
+	}
+
+	for _, test := range tests {
+		if actual := CanManageRole(test.actor, test.role); actual != test.expected {
+			t.Errorf("FAILED - TestCanManageRole | Actor: %s | Role: %s | Actual: %v | Expected: %v", test.actor.Role.Value, test.role, actual, test.expected)
+		}
+	}
+}
+
+func TestCanGrantPermissions(t *testing.T) {
+	admin := memberWith("b", ROLE_ADMIN, STATUS_CONFIRMED)
+	custom := memberWith("c", ROLE_CUSTOM, STATUS_CONFIRMED, PERMISSION_MANAGE_MEMBERS, PERMISSION_ACCESS_EVENT_LOGS)
+
+	tests := []struct {
+		actor       types.OrgMember
+		role        string
+		permissions []string
+		expected    bool
+	}{
+		{admin, ROLE_CUSTOM, AllPermissions(), true},
+		{custom, ROLE_USER, nil, true},
+		{custom, ROLE_CUSTOM, []string{PERMISSION_ACCESS_EVENT_LOGS}, true},
+		{custom, ROLE_CUSTOM, []string{PERMISSION_MANAGE_POLICIES}, false},
+		{custom, ROLE_MANAGER, nil, false},
+	}
+
+	for _, test := range tests {
+		if actual := CanGrantPermissions(test.actor, test.role, test.permissions); actual != test.expected {
+			t.Errorf("FAILED - TestCanGrantPermissions | Actor: %s | Role: %s %v | Actual: %v | Expected: %v", test.actor.Role.Value, test.role, test.permissions, actual, test.expected)
+		}
+	}
+}
+
+func TestIsLastOwner(t *testing.T) {
+	members := []types.OrgMember{
+		memberWith("a", ROLE_OWNER, STATUS_CONFIRMED),
+		memberWith("b", ROLE_OWNER, STATUS_INVITED),
+		memberWith("c", ROLE_ADMIN, STATUS_CONFIRMED),
+	}
+
+	if !IsLastOwner(members, "a") || IsLastOwner(members, "c") {
+		t.Errorf("FAILED - TestIsLastOwner | Expected: only a to be the last confirmed owner")
+	}
+}
+
+/***** End Roles *****/
+
+func TestInviteRequestValidate(t *testing.T) {
+	valid := InviteRequest{Email: "bob@example.com", Role: ROLE_CUSTOM, Permissions: []string{PERMISSION_MANAGE_MEMBERS}}
+
+	if err := valid.Validate(); err != nil {
+		t.Errorf("FAILED - TestInviteRequestValidate | Error: %s", err.Error())
+	}
+
+	invalid := []InviteRequest{
+		{Email: "bob", Role: ROLE_USER},
+		{Email: "Bob <bob@example.com>", Role: ROLE_USER},
+		{Email: "bob@example.com", Role: "guest"},
+		{Email: "bob@example.com", Role: ROLE_USER, Permissions: []string{PERMISSION_MANAGE_MEMBERS}},
+		{Email: "bob@example.com", Role: ROLE_CUSTOM, Permissions: []string{"deleteEverything"}},
+	}
+
+	for _, request := range invalid {
+		if err := request.Validate(); err == nil {
+			t.Errorf("FAILED - TestInviteRequestValidate | Request: %+v | Expected: an error", request)
+		}
+	}
+}
+
+func TestToOrgResponseOnlyHasKeyOfConfirmedMembers(t *testing.T) {
+	var org types.Organization
+	org.OrgId.Value = "org"
+
+	member := memberWith("a", ROLE_USER, STATUS_ACCEPTED)
+	member.Key.Value = "wrapped"
+
+	if ToOrgResponse(org, member).Key != "" {
+		t.Errorf("FAILED - TestToOrgResponseOnlyHasKeyOfConfirmedMembers | Expected: no key before the member is confirmed")
+	}
+
+	member.Status.Value = STATUS_CONFIRMED
+
+	if ToOrgResponse(org, member).Key != "wrapped" {
+		t.Errorf("FAILED - TestToOrgResponseOnlyHasKeyOfConfirmedMembers | Expected: the key of a confirmed member")
+	}
+}
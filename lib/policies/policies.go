@@ -0,0 +1,385 @@
+package policies
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/dynamoclient"
+	"password-caddy/api/lib/orgs"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/util"
+)
+
+// Sort key prefix of the policies in an organization's partition
+const POLICY_PREFIX = "POLICY#"
+
+/*
+Types of the policies an organization can enforce. Requiring two-step login is
+listed but not supported, there is no two-step enrollment for it to check
+*/
+const (
+	TYPE_REQUIRE_TWO_STEP_LOGIN = "requireTwoStepLogin"
+	TYPE_MASTER_PASSWORD        = "masterPassword"
+	TYPE_DISABLE_PERSONAL_VAULT = "disablePersonalVault"
+	TYPE_DISABLE_SEND           = "disableSend"
+	TYPE_RESTRICT_EXPORT        = "restrictExport"
+	TYPE_SINGLE_ORG             = "singleOrg"
+	TYPE_SESSION_TIMEOUT        = "sessionTimeout"
+)
+
+const (
+	MAX_PASSWORD_SCORE          = 4
+	MIN_SESSION_TIMEOUT_MINUTES = 1
+	MAX_SESSION_TIMEOUT_MINUTES = 7 * 24 * 60
+)
+
+/*
+Settings of a policy. MinScore is the minimum strength score (0-4) of the
+master password, Minutes the longest a session can last. Policies without
+settings leave both out
+*/
+type PolicyData struct {
+	MinScore int `json:"minScore,omitempty"`
+	Minutes  int `json:"minutes,omitempty"`
+}
+
+type PolicyRequest struct {
+	Enabled bool       `json:"enabled"`
+	Data    PolicyData `json:"data"`
+}
+
+type PolicyResponse struct {
+	Type      string     `json:"type"`
+	Enabled   bool       `json:"enabled"`
+	Data      PolicyData `json:"data"`
+	UpdatedBy string     `json:"updatedBy,omitempty"`
+	UpdatedAt string     `json:"updatedAt,omitempty"`
+}
+
+// The policy a request violates, sent back with the 403
+type Violation struct {
+	Policy string `json:"policy"`
+	OrgId  string `json:"orgId"`
+}
+
+/*
+The policies that concern a user. Policies holds the enabled policies of
+every organization the user is a member of or invited to, keyed by
+organization id. They only apply in the organizations the user is a
+confirmed member of without being an owner or admin
+*/
+type Effective struct {
+	UserOrgs []orgs.UserOrg
+	Policies map[string][]types.OrgPolicy
+}
+
+/********** KEYS **********/
+
+func Types() []string {
+	return []string{
+		TYPE_REQUIRE_TWO_STEP_LOGIN,
+		TYPE_MASTER_PASSWORD,
+		TYPE_DISABLE_PERSONAL_VAULT,
+		TYPE_DISABLE_SEND,
+		TYPE_RESTRICT_EXPORT,
+		TYPE_SINGLE_ORG,
+		TYPE_SESSION_TIMEOUT,
+	}
+}
+
+func IsValidType(policyType string) bool {
+	for _, candidate := range Types() {
+		if candidate == policyType {
+			return true
+		}
+	}
+
+	return false
+}
+
+func PolicyKey(policyType string) string {
+	return POLICY_PREFIX + policyType
+}
+
+/********** VALIDATION **********/
+
+// Only the master password and session timeout policies have settings, which are required once they are enabled
+func (request PolicyRequest) Validate(policyType string) error {
+	if !IsValidType(policyType) {
+		return errors.New("Unknown policy type")
+	}
+
+	if policyType == TYPE_REQUIRE_TWO_STEP_LOGIN {
+		return errors.New("The two-step login policy is not supported")
+	}
+
+	switch policyType {
+	case TYPE_MASTER_PASSWORD:
+		if request.Data.Minutes != 0 {
+			return errors.New("The master password policy only has a minimum score")
+		}
+
+		if request.Data.MinScore < 0 || request.Data.MinScore > MAX_PASSWORD_SCORE || (request.Enabled && request.Data.MinScore == 0) {
+			return fmt.Errorf("Minimum score must be between 1 and %d", MAX_PASSWORD_SCORE)
+		}
+	case TYPE_SESSION_TIMEOUT:
+		if request.Data.MinScore != 0 {
+			return errors.New("The session timeout policy only has minutes")
+		}
+
+		if request.Data.Minutes != 0 || request.Enabled {
+			if request.Data.Minutes < MIN_SESSION_TIMEOUT_MINUTES || request.Data.Minutes > MAX_SESSION_TIMEOUT_MINUTES {
+				return fmt.Errorf("Minutes must be between %d and %d", MIN_SESSION_TIMEOUT_MINUTES, MAX_SESSION_TIMEOUT_MINUTES)
+			}
+		}
+	default:
+		if request.Data != (PolicyData{}) {
+			return errors.New("This policy has no settings")
+		}
+	}
+
+	return nil
+}
+
+/********** RESPONSES **********/
+
+func DataOf(policy types.OrgPolicy) PolicyData {
+	var data PolicyData
+
+	if policy.Data.Value != "" {
+		util.DeserializeJson(policy.Data.Value, &data)
+	}
+
+	return data
+}
+
+func ToPolicyResponse(policy types.OrgPolicy) PolicyResponse {
+	return PolicyResponse{
+		Type:      policy.Type.Value,
+		Enabled:   policy.Enabled.Value,
+		Data:      DataOf(policy),
+		UpdatedBy: policy.UpdatedBy.Value,
+		UpdatedAt: policy.UpdatedAt.Value,
+	}
+}
+
+// Every policy type of an organization, the ones that were never saved as disabled
+func ToPolicyResponses(saved []types.OrgPolicy) []PolicyResponse {
+	byType := map[string]types.OrgPolicy{}
+
+	for _, policy := range saved {
+		byType[policy.Type.Value] = policy
+	}
+
+	responses := []PolicyResponse{}
+
+	for _, policyType := range Types() {
+		if policy, ok := byType[policyType]; ok {
+			responses = append(responses, ToPolicyResponse(policy))
+		} else {
+			responses = append(responses, PolicyResponse{Type: policyType})
+		}
+	}
+
+	return responses
+}
+
+func (violation Violation) Error() string {
+	return fmt.Sprintf("Blocked by the %s policy of an organization", violation.Policy)
+}
+
+// Build the 403 returned for a request that violates a policy
+func ViolationResult(violation *Violation) *result.Result {
+	return result.FailureWithDetails(403, violation.Error(), *violation)
+}
+
+/********** EVALUATION **********/
+
+// Owners and admins set the policies of an organization, so they are not bound by them
+func AppliesTo(member types.OrgMember) bool {
+	return member.Status.Value == orgs.STATUS_CONFIRMED &&
+		member.Role.Value != orgs.ROLE_OWNER &&
+		member.Role.Value != orgs.ROLE_ADMIN
+}
+
+// The enabled policies of the type in the organizations they apply to the user in
+func (effective Effective) Applicable(policyType string) []types.OrgPolicy {
+	applicable := []types.OrgPolicy{}
+
+	for _, userOrg := range effective.UserOrgs {
+		if !AppliesTo(userOrg.Member) {
+			continue
+		}
+
+		for _, policy := range effective.Policies[userOrg.Org.OrgId.Value] {
+			if policy.Type.Value == policyType && policy.Enabled.Value {
+				applicable = append(applicable, policy)
+			}
+		}
+	}
+
+	return applicable
+}
+
+// Check that no organization of the user enforces a policy that forbids the request
+func (effective Effective) Check(policyType string) *Violation {
+	applicable := effective.Applicable(policyType)
+
+	if len(applicable) == 0 {
+		return nil
+	}
+
+	return &Violation{Policy: policyType, OrgId: applicable[0].OrgId.Value}
+}
+
+/*
+Check that the strength score of the master password is enough for every
+organization of the user. The score is reported by the client, which is the
+only one to see the master password, so the policy is advisory
+*/
+func (effective Effective) CheckPasswordScore(score int) *Violation {
+	for _, policy := range effective.Applicable(TYPE_MASTER_PASSWORD) {
+		if score < DataOf(policy).MinScore {
+			return &Violation{Policy: TYPE_MASTER_PASSWORD, OrgId: policy.OrgId.Value}
+		}
+	}
+
+	return nil
+}
+
+/*
+The shortest session timeout of the organizations of the user in minutes. 0
+when none enforces one. Login verification caps the session token at it
+*/
+func (effective Effective) SessionTimeout() int {
+	timeout := 0
+
+	for _, policy := range effective.Applicable(TYPE_SESSION_TIMEOUT) {
+		if minutes := DataOf(policy).Minutes; timeout == 0 || minutes < timeout {
+			timeout = minutes
+		}
+	}
+
+	return timeout
+}
+
+/*
+Check that the user can join an organization as the member, or create one
+when member is empty. Members bound by the single organization policy can not
+join other organizations, and an organization with the policy only takes
+members who are in no other organization
+*/
+func (effective Effective) CheckJoin(member types.OrgMember) *Violation {
+	orgId := member.OrgId.Value
+
+	for _, userOrg := range effective.UserOrgs {
+		if userOrg.Org.OrgId.Value == orgId || !AppliesTo(userOrg.Member) {
+			continue
+		}
+
+		for _, policy := range effective.Policies[userOrg.Org.OrgId.Value] {
+			if policy.Type.Value == TYPE_SINGLE_ORG && policy.Enabled.Value {
+				return &Violation{Policy: TYPE_SINGLE_ORG, OrgId: userOrg.Org.OrgId.Value}
+			}
+		}
+	}
+
+	if orgId == "" || member.Role.Value == orgs.ROLE_OWNER || member.Role.Value == orgs.ROLE_ADMIN {
+		return nil
+	}
+
+	for _, policy := range effective.Policies[orgId] {
+		if policy.Type.Value != TYPE_SINGLE_ORG || !policy.Enabled.Value {
+			continue
+		}
+
+		for _, userOrg := range effective.UserOrgs {
+			status := userOrg.Member.Status.Value
+
+			if userOrg.Org.OrgId.Value != orgId && (status == orgs.STATUS_ACCEPTED || status == orgs.STATUS_CONFIRMED) {
+				return &Violation{Policy: TYPE_SINGLE_ORG, OrgId: orgId}
+			}
+		}
+	}
+
+	return nil
+}
+
+/********** OPERATIONS **********/
+
+// Get the policies saved for an organization, enabled or not
+func ListPolicies(client *dynamoclient.DynamoClient, orgId string) *dynamoclient.DynamoResponse {
+	return client.
+		Query(dynamoclient.DynamoQueryRequest{
+			Key:           orgs.PartitionKey(orgId),
+			SortKeyPrefix: POLICY_PREFIX,
+		}).
+		AsOrgPolicies()
+}
+
+// Save a policy of an organization. The saved policy is returned as the response data
+func PutPolicy(client *dynamoclient.DynamoClient, orgId, policyType, updatedBy string, request PolicyRequest) *dynamoclient.DynamoResponse {
+	var policy types.OrgPolicy
+
+	policy.UserId.Value = orgs.PartitionKey(orgId)
+	policy.ItemKey.Value = PolicyKey(policyType)
+	policy.OrgId.Value = orgId
+	policy.Type.Value = policyType
+	policy.Enabled.Value = request.Enabled
+	policy.Data.Value = util.SerializeJson(request.Data)
+	policy.UpdatedBy.Value = updatedBy
+	policy.UpdatedAt.Value = time.Now().UTC().Format(time.RFC3339)
+
+	response := client.Put(dynamoclient.DynamoPutRequest{
+		Key:     policy.UserId.Value,
+		SortKey: policy.ItemKey.Value,
+		Values: map[string]interface{}{
+			"ORG_ID":     policy.OrgId.Value,
+			"TYPE":       policy.Type.Value,
+			"ENABLED":    policy.Enabled.Value,
+			"DATA":       policy.Data.Value,
+			"UPDATED_BY": policy.UpdatedBy.Value,
+			"UPDATED_AT": policy.UpdatedAt.Value,
+		},
+	})
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	return dynamoclient.SuccessWithValue(policy)
+}
+
+// Load the enabled policies of every organization of a user
+func Resolve(client *dynamoclient.DynamoClient, userId string) *dynamoclient.DynamoResponse {
+	response := orgs.ListUserOrgs(client, userId)
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	effective := Effective{
+		UserOrgs: response.Data.([]orgs.UserOrg),
+		Policies: map[string][]types.OrgPolicy{},
+	}
+
+	for _, userOrg := range effective.UserOrgs {
+		orgId := userOrg.Org.OrgId.Value
+
+		response = ListPolicies(client, orgId)
+
+		if !response.IsSuccess {
+			return response
+		}
+
+		for _, policy := range response.Data.([]types.OrgPolicy) {
+			if policy.Enabled.Value {
+				effective.Policies[orgId] = append(effective.Policies[orgId], policy)
+			}
+		}
+	}
+
+	return dynamoclient.SuccessWithValue(effective)
+}
@@ -0,0 +1,188 @@
+package policies
+
+import (
+	"testing"
+
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/orgs"
+	"password-caddy/api/lib/util"
+)
+
+func userOrg(orgId, role, status string) orgs.UserOrg {
+	var userOrg orgs.UserOrg
+	userOrg.Org.OrgId.Value = orgId
+	userOrg.Member.OrgId.Value = orgId
+	userOrg.Member.Role.Value = role
+	userOrg.Member.Status.Value = status
+	return userOrg
+}
+
+func policy(orgId, policyType string, data PolicyData) types.OrgPolicy {
+	var policy types.OrgPolicy
+	policy.OrgId.Value = orgId
+	policy.Type.Value = policyType
+	policy.Enabled.Value = true
+	policy.Data.Value = util.SerializeJson(data)
+	return policy
+}
+
+/***** Validate *****/
+
+func TestPolicyRequestValidate(t *testing.T) {
+	tests := []struct {
+		policyType string
+		request    PolicyRequest
+		valid      bool
+	}{
+		{TYPE_DISABLE_SEND, PolicyRequest{Enabled: true}, true},
+		{TYPE_DISABLE_SEND, PolicyRequest{Enabled: true, Data: PolicyData{Minutes: 5}}, false},
+		{TYPE_MASTER_PASSWORD, PolicyRequest{Enabled: true, Data: PolicyData{MinScore: 3}}, true},
+		{TYPE_MASTER_PASSWORD, PolicyRequest{Enabled: true}, false},
+		{TYPE_MASTER_PASSWORD, PolicyRequest{Enabled: true, Data: PolicyData{MinScore: MAX_PASSWORD_SCORE + 1}}, false},
+		{TYPE_MASTER_PASSWORD, PolicyRequest{Enabled: false}, true},
+		{TYPE_SESSION_TIMEOUT, PolicyRequest{Enabled: true, Data: PolicyData{Minutes: 30}}, true},
+		{TYPE_SESSION_TIMEOUT, PolicyRequest{Enabled: true}, false},
+		{TYPE_SESSION_TIMEOUT, PolicyRequest{Enabled: true, Data: PolicyData{Minutes: MAX_SESSION_TIMEOUT_MINUTES + 1}}, false},
+		{"allowEverything", PolicyRequest{Enabled: true}, false},
+		{TYPE_REQUIRE_TWO_STEP_LOGIN, PolicyRequest{Enabled: true}, false},
+		{TYPE_REQUIRE_TWO_STEP_LOGIN, PolicyRequest{Enabled: false}, false},
+	}
+
+	for _, test := range tests {
+		if err := test.request.Validate(test.policyType); (err == nil) != test.valid {
+			t.Errorf("FAILED - TestPolicyRequestValidate | Type: %s | Request: %v | Error: %v | Expected valid: %v", test.policyType, test.request, err, test.valid)
+		}
+	}
+}
+
+/***** Evaluation *****/
+
+func TestCheckOnlyAppliesToConfirmedNonAdmins(t *testing.T) {
+	policies := map[string][]types.OrgPolicy{
+		"a": {policy("a", TYPE_DISABLE_SEND, PolicyData{})},
+	}
+
+	tests := []struct {
+		member   orgs.UserOrg
+		violated bool
+	}{
+		{userOrg("a", orgs.ROLE_USER, orgs.STATUS_CONFIRMED), true},
+		{userOrg("a", orgs.ROLE_MANAGER, orgs.STATUS_CONFIRMED), true},
+		{userOrg("a", orgs.ROLE_ADMIN, orgs.STATUS_CONFIRMED), false},
+		{userOrg("a", orgs.ROLE_OWNER, orgs.STATUS_CONFIRMED), false},
+		{userOrg("a", orgs.ROLE_USER, orgs.STATUS_INVITED), false},
+		{userOrg("a", orgs.ROLE_USER, orgs.STATUS_ACCEPTED), false},
+	}
+
+	for _, test := range tests {
+		effective := Effective{UserOrgs: []orgs.UserOrg{test.member}, Policies: policies}
+
+		violation := effective.Check(TYPE_DISABLE_SEND)
+
+		if (violation != nil) != test.violated {
+			t.Errorf("FAILED - TestCheckOnlyAppliesToConfirmedNonAdmins | Member: %v | Violation: %v | Expected violated: %v", test.member.Member, violation, test.violated)
+		}
+
+		if violation != nil && (violation.Policy != TYPE_DISABLE_SEND || violation.OrgId != "a") {
+			t.Errorf("FAILED - TestCheckOnlyAppliesToConfirmedNonAdmins | Actual: %v | Expected: the disableSend policy of org a", violation)
+		}
+	}
+}
+
+func TestCheckPasswordScoreUsesStrictestOrg(t *testing.T) {
+	effective := Effective{
+		UserOrgs: []orgs.UserOrg{
+			userOrg("a", orgs.ROLE_USER, orgs.STATUS_CONFIRMED),
+			userOrg("b", orgs.ROLE_USER, orgs.STATUS_CONFIRMED),
+		},
+		Policies: map[string][]types.OrgPolicy{
+			"a": {policy("a", TYPE_MASTER_PASSWORD, PolicyData{MinScore: 2})},
+			"b": {policy("b", TYPE_MASTER_PASSWORD, PolicyData{MinScore: 4})},
+		},
+	}
+
+	if violation := effective.CheckPasswordScore(3); violation == nil || violation.OrgId != "b" {
+		t.Errorf("FAILED - TestCheckPasswordScoreUsesStrictestOrg | Actual: %v | Expected: the policy of org b", violation)
+	}
+
+	if violation := effective.CheckPasswordScore(4); violation != nil {
+		t.Errorf("FAILED - TestCheckPasswordScoreUsesStrictestOrg | Actual: %v | Expected: no violation", violation)
+	}
+}
+
+func TestSessionTimeoutUsesShortest(t *testing.T) {
+	effective := Effective{
+		UserOrgs: []orgs.UserOrg{
+			userOrg("a", orgs.ROLE_USER, orgs.STATUS_CONFIRMED),
+			userOrg("b", orgs.ROLE_USER, orgs.STATUS_CONFIRMED),
+		},
+		Policies: map[string][]types.OrgPolicy{
+			"a": {policy("a", TYPE_SESSION_TIMEOUT, PolicyData{Minutes: 60})},
+			"b": {policy("b", TYPE_SESSION_TIMEOUT, PolicyData{Minutes: 15})},
+		},
+	}
+
+	if actual := effective.SessionTimeout(); actual != 15 {
+		t.Errorf("FAILED - TestSessionTimeoutUsesShortest | Actual: %d | Expected: 15", actual)
+	}
+
+	if actual := (Effective{}).SessionTimeout(); actual != 0 {
+		t.Errorf("FAILED - TestSessionTimeoutUsesShortest | Actual: %d | Expected: 0 without policies", actual)
+	}
+}
+
+func TestCheckJoin(t *testing.T) {
+	singleOrg := map[string][]types.OrgPolicy{
+		"a": {policy("a", TYPE_SINGLE_ORG, PolicyData{})},
+	}
+
+	joining := func(orgId, role string) types.OrgMember {
+		var member types.OrgMember
+		member.OrgId.Value = orgId
+		member.Role.Value = role
+		return member
+	}
+
+	tests := []struct {
+		name     string
+		userOrgs []orgs.UserOrg
+		member   types.OrgMember
+		violated bool
+	}{
+		{"bound member joins another org", []orgs.UserOrg{userOrg("a", orgs.ROLE_USER, orgs.STATUS_CONFIRMED)}, joining("b", orgs.ROLE_USER), true},
+		{"bound member creates an org", []orgs.UserOrg{userOrg("a", orgs.ROLE_USER, orgs.STATUS_CONFIRMED)}, types.OrgMember{}, true},
+		{"admin of the org joins another org", []orgs.UserOrg{userOrg("a", orgs.ROLE_ADMIN, orgs.STATUS_CONFIRMED)}, joining("b", orgs.ROLE_USER), false},
+		{"member of another org joins the org", []orgs.UserOrg{userOrg("a", orgs.ROLE_USER, orgs.STATUS_INVITED), userOrg("b", orgs.ROLE_USER, orgs.STATUS_CONFIRMED)}, joining("a", orgs.ROLE_USER), true},
+		{"invitee of another org joins the org", []orgs.UserOrg{userOrg("a", orgs.ROLE_USER, orgs.STATUS_INVITED), userOrg("b", orgs.ROLE_USER, orgs.STATUS_INVITED)}, joining("a", orgs.ROLE_USER), false},
+		{"member of another org joins the org as admin", []orgs.UserOrg{userOrg("a", orgs.ROLE_ADMIN, orgs.STATUS_INVITED), userOrg("b", orgs.ROLE_USER, orgs.STATUS_CONFIRMED)}, joining("a", orgs.ROLE_ADMIN), false},
+		{"user without orgs joins the org", []orgs.UserOrg{userOrg("a", orgs.ROLE_USER, orgs.STATUS_INVITED)}, joining("a", orgs.ROLE_USER), false},
+	}
+
+	for _, test := range tests {
+		effective := Effective{UserOrgs: test.userOrgs, Policies: singleOrg}
+
+		if violation := effective.CheckJoin(test.member); (violation != nil) != test.violated {
+			t.Errorf("FAILED - TestCheckJoin | Case: %s | Violation: %v | Expected violated: %v", test.name, violation, test.violated)
+		}
+	}
+}
+
+/***** Responses *****/
+
+func TestToPolicyResponsesListsEveryType(t *testing.T) {
+	responses := ToPolicyResponses([]types.OrgPolicy{policy("a", TYPE_SESSION_TIMEOUT, PolicyData{Minutes: 30})})
+
+	if len(responses) != len(Types()) {
+		t.Fatalf("FAILED - TestToPolicyResponsesListsEveryType | Actual: %d | Expected: %d", len(responses), len(Types()))
+	}
+
+	for _, response := range responses {
+		if response.Type == TYPE_SESSION_TIMEOUT && (!response.Enabled || response.Data.Minutes != 30) {
+			t.Errorf("FAILED - TestToPolicyResponsesListsEveryType | Actual: %v | Expected: the saved session timeout", response)
+		}
+
+		if response.Type != TYPE_SESSION_TIMEOUT && response.Enabled {
+			t.Errorf("FAILED - TestToPolicyResponsesListsEveryType | Actual: %v | Expected: unsaved policies to be disabled", response)
+		}
+	}
+}
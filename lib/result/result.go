@@ -1,6 +1,8 @@
 package result
 
 import (
+	"fmt"
+
 	"password-caddy/api/core/types"
 	"password-caddy/api/lib/util"
 
@@ -8,12 +10,23 @@ import (
 )
 
 type ResultError struct {
-	StatusCode int    `json:"statusCode"`
-	Message    string `json:"message"`
+	StatusCode int         `json:"statusCode"`
+	Message    string      `json:"message"`
+	Details    interface{} `json:"details,omitempty"`
 }
 
 type ResultValue interface{}
 
+// A successful value sent as is instead of as JSON (i.e a CSV download).
+// With a Filename the client is asked to save the body as a file. Headers are
+// added to the response
+type RawValue struct {
+	ContentType string
+	Filename    string
+	Body        string
+	Headers     map[string]string
+}
+
 type Resulter interface {
 	GetValue() ResultValue
 	Then(f func(res ResultValue) *Result) *Result
@@ -71,6 +84,25 @@ func Failure(statusCode int, message string) *Result {
 	}
 }
 
+// Create a new failure Result with additional details for the client
+// (i.e the current state of a resource on a conflict)
+func FailureWithDetails(statusCode int, message string, details interface{}) *Result {
+	result := Failure(statusCode, message)
+	result.Error.Details = details
+
+	return result
+}
+
+// Convert the Result to an error for handlers that are not invoked by API Gateway.
+// Returns nil for a successful Result
+func (result *Result) ToError() error {
+	if result.IsSuccess {
+		return nil
+	}
+
+	return result.Error
+}
+
 // Convert the Result to an API Gateway Proxy Reponse
 func (result *Result) ToAPIGatewayResponse() (events.APIGatewayProxyResponse, error) {
 	defaultHeaders := map[string]string{
@@ -85,6 +117,17 @@ func (result *Result) ToAPIGatewayResponse() (events.APIGatewayProxyResponse, er
 
 	if result.IsSuccess && result.Value == nil {
 		return response, nil
+	} else if raw, ok := result.Value.(RawValue); ok && result.IsSuccess {
+		response.Headers["Content-Type"] = raw.ContentType
+		response.Body = raw.Body
+
+		if raw.Filename != "" {
+			response.Headers["Content-Disposition"] = fmt.Sprintf("attachment; filename=%q", raw.Filename)
+		}
+
+		for name, value := range raw.Headers {
+			response.Headers[name] = value
+		}
 	} else if result.IsSuccess {
 		body := util.SerializeJson(result.Value)
 		response.Body = body
@@ -93,6 +136,7 @@ func (result *Result) ToAPIGatewayResponse() (events.APIGatewayProxyResponse, er
 			Error: ResultError{
 				StatusCode: result.Error.StatusCode,
 				Message:    result.Error.Message,
+				Details:    result.Error.Details,
 			},
 		}
 
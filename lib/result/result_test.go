@@ -114,6 +114,33 @@ func TestFailure(t *testing.T) {
 	}
 }
 
+func TestToAPIGatewayResponseWithFailureDetails(t *testing.T) {
+	res := FailureWithDetails(409, "Conflict", map[string]int{"revision": 3})
+	actual, _ := res.ToAPIGatewayResponse()
+	expected := "{\"error\":{\"statusCode\":409,\"message\":\"Conflict\",\"details\":{\"revision\":3}}}"
+
+	if actual.Body != expected {
+		t.Errorf("FAILED - TestToAPIGatewayResponseWithFailureDetails - Body | Actual: %s | Expected: %s", actual.Body, expected)
+	}
+}
+
+func TestToErrorWithSuccessfulResult(t *testing.T) {
+	actual := Success(200).ToError()
+
+	if actual != nil {
+		t.Errorf("FAILED - TestToErrorWithSuccessfulResult | Actual: %+v | Expected: nil", actual)
+	}
+}
+
+func TestToErrorWithFailureResult(t *testing.T) {
+	actual := Failure(500, "Internal Error").ToError()
+	expected := "Internal Error"
+
+	if actual == nil || actual.Error() != expected {
+		t.Errorf("FAILED - TestToErrorWithFailureResult | Actual: %+v | Expected: %s", actual, expected)
+	}
+}
+
 func TestToAPIGatewayResponseWithSuccessfulResult(t *testing.T) {
 	res := SuccessWithValue(200, "Foo")
 	actual, _ := res.ToAPIGatewayResponse()
@@ -163,6 +190,32 @@ func TestToAPIGatewayResponseWithSuccessfulResultWithNoBody(t *testing.T) {
 	}
 }
 
+func TestToAPIGatewayResponseWithRawValue(t *testing.T) {
+	res := SuccessWithValue(200, RawValue{
+		ContentType: "text/csv",
+		Filename:    "events.csv",
+		Body:        "a,b\n",
+		Headers:     map[string]string{"X-Next-Cursor": "abc"},
+	})
+	actual, _ := res.ToAPIGatewayResponse()
+
+	if actual.Headers["Content-Type"] != "text/csv" {
+		t.Errorf("FAILED - TestToAPIGatewayResponseWithRawValue - Content-Type | Actual: %s | Expected: text/csv", actual.Headers["Content-Type"])
+	}
+
+	if actual.Headers["Content-Disposition"] != `attachment; filename="events.csv"` {
+		t.Errorf("FAILED - TestToAPIGatewayResponseWithRawValue - Content-Disposition | Actual: %s", actual.Headers["Content-Disposition"])
+	}
+
+	if actual.Headers["X-Next-Cursor"] != "abc" {
+		t.Errorf("FAILED - TestToAPIGatewayResponseWithRawValue - Headers | Actual: %s | Expected: abc", actual.Headers["X-Next-Cursor"])
+	}
+
+	if actual.Body != "a,b\n" {
+		t.Errorf("FAILED - TestToAPIGatewayResponseWithRawValue - Body | Actual: %s | Expected: the raw body", actual.Body)
+	}
+}
+
 func TestToAPIGatewayResponseWithFailureResult(t *testing.T) {
 	res := Failure(500, "Internal Error")
 	actual, _ := res.ToAPIGatewayResponse()
@@ -0,0 +1,649 @@
+package sends
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	appConfig "password-caddy/api/core/config"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/blobstore"
+	"password-caddy/api/lib/dynamoclient"
+
+	dynamoTypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Types of content a send can hold
+const (
+	TYPE_TEXT = "text"
+	TYPE_FILE = "file"
+)
+
+/*
+Keys of a send. The send itself is stored in its own partition (SEND#<id>,
+SEND) so recipients can fetch it by id. The owner's partition holds a
+reference (<owner>, SEND#<id>) to list the sends of an account
+*/
+const (
+	SEND_PREFIX = "SEND#"
+	SEND_KEY    = "SEND"
+)
+
+const (
+	MAX_ACTIVE_SENDS        = 100
+	MAX_TEXT_LENGTH         = 1048576
+	MAX_NAME_LENGTH         = 1024
+	DEFAULT_EXPIRY          = 7 * 24 * time.Hour
+	PASSWORD_ITERATIONS     = 100000
+	PASSWORD_SALT_LENGTH    = 16
+	PASSWORD_HASH_LENGTH    = 32
+	PASSWORD_HEADER         = "X-Send-Password"
+	PASSWORD_HASH_SEPARATOR = "$"
+)
+
+/*
+A send to create. Name, Text and FileName are encrypted by the client, File
+holds the encrypted file base64 encoded. ExpiresAt defaults to 7 days from
+now. A MaxAccessCount of 0 allows any number of accesses until the send expires
+*/
+type SendRequest struct {
+	Type           string `json:"type"`
+	Name           string `json:"name"`
+	Text           string `json:"text"`
+	FileName       string `json:"fileName"`
+	File           string `json:"file"`
+	Password       string `json:"password"`
+	MaxAccessCount int    `json:"maxAccessCount"`
+	ExpiresAt      string `json:"expiresAt"`
+	Disabled       bool   `json:"disabled"`
+}
+
+// A send as its owner sees it, without the content
+type SendResponse struct {
+	Id             string `json:"id"`
+	Type           string `json:"type"`
+	Name           string `json:"name"`
+	Size           int    `json:"size,omitempty"`
+	MaxAccessCount int    `json:"maxAccessCount"`
+	AccessCount    int    `json:"accessCount"`
+	HasPassword    bool   `json:"hasPassword"`
+	Locked         bool   `json:"locked"`
+	Disabled       bool   `json:"disabled"`
+	CreatedAt      string `json:"createdAt"`
+	ExpiresAt      string `json:"expiresAt"`
+}
+
+// A send as its recipient sees it
+type SendAccessResponse struct {
+	Id        string `json:"id"`
+	Type      string `json:"type"`
+	Name      string `json:"name"`
+	Text      string `json:"text,omitempty"`
+	FileName  string `json:"fileName,omitempty"`
+	File      string `json:"file,omitempty"`
+	Size      int    `json:"size,omitempty"`
+	ExpiresAt string `json:"expiresAt"`
+}
+
+/********** CONFIG **********/
+
+// Sends expire at the latest this long after they are created
+func MaxExpiry() time.Duration {
+	days := appConfig.Get("SEND_MAX_DAYS", "31").ToInt64()
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// Largest file of a send in bytes. Lambda payloads are limited to 6 MB and the file is base64 encoded
+func MaxFileSize() int {
+	return int(appConfig.Get("SEND_MAX_FILE_SIZE", "4194304").ToInt64())
+}
+
+/*
+Invalid passwords a send accepts before it is locked. A locked send stays
+locked, its owner can delete it and create a new one
+*/
+func MaxPasswordAttempts() int {
+	return int(appConfig.Get("SEND_MAX_PASSWORD_ATTEMPTS", "10").ToInt64())
+}
+
+/********** KEYS **********/
+
+func IsValidSendId(sendId string) bool {
+	_, err := uuid.Parse(sendId)
+	return err == nil
+}
+
+func PartitionKey(sendId string) string {
+	return SEND_PREFIX + sendId
+}
+
+func OwnerKey(sendId string) string {
+	return SEND_PREFIX + sendId
+}
+
+// Files of sends that expired are removed by the lifecycle rule of the bucket on the sends/ prefix
+func BlobKey(sendId string) string {
+	return "sends/" + sendId
+}
+
+/********** VALIDATION **********/
+
+/*
+Validate the request and get when the send expires and its decoded file.
+The file is nil for a text send
+*/
+func (request SendRequest) Validate(now time.Time) (time.Time, []byte, error) {
+	var file []byte
+
+	expiresAt := now.Add(DEFAULT_EXPIRY)
+
+	if request.ExpiresAt != "" {
+		parsed, err := time.Parse(time.RFC3339, request.ExpiresAt)
+
+		if err != nil {
+			return expiresAt, nil, errors.New("Expires at must be an RFC 3339 timestamp")
+		}
+
+		expiresAt = parsed
+	}
+
+	if !expiresAt.After(now) || expiresAt.After(now.Add(MaxExpiry())) {
+		return expiresAt, nil, fmt.Errorf("Expires at must be in the next %d days", int(MaxExpiry().Hours()/24))
+	}
+
+	if request.Name == "" || len(request.Name) > MAX_NAME_LENGTH {
+		return expiresAt, nil, fmt.Errorf("Name is required and can have at most %d characters", MAX_NAME_LENGTH)
+	}
+
+	if request.MaxAccessCount < 0 {
+		return expiresAt, nil, errors.New("Max access count must not be negative")
+	}
+
+	switch request.Type {
+	case TYPE_TEXT:
+		if request.Text == "" || len(request.Text) > MAX_TEXT_LENGTH {
+			return expiresAt, nil, fmt.Errorf("Text is required and can have at most %d characters", MAX_TEXT_LENGTH)
+		}
+	case TYPE_FILE:
+		if request.FileName == "" || len(request.FileName) > MAX_NAME_LENGTH {
+			return expiresAt, nil, fmt.Errorf("File name is required and can have at most %d characters", MAX_NAME_LENGTH)
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(request.File)
+
+		if err != nil || len(decoded) == 0 {
+			return expiresAt, nil, errors.New("File is required and must be base64 encoded")
+		}
+
+		if len(decoded) > MaxFileSize() {
+			return expiresAt, nil, fmt.Errorf("Files can have at most %d bytes", MaxFileSize())
+		}
+
+		file = decoded
+	default:
+		return expiresAt, nil, fmt.Errorf("Type must be %s or %s", TYPE_TEXT, TYPE_FILE)
+	}
+
+	return expiresAt, file, nil
+}
+
+/********** PASSWORDS **********/
+
+/*
+Hash the password of a send with PBKDF2-SHA256 and a random salt. The hash
+is stored as <iterations>$<salt>$<hash>
+*/
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, PASSWORD_SALT_LENGTH)
+
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	hash := pbkdf2.Key([]byte(password), salt, PASSWORD_ITERATIONS, PASSWORD_HASH_LENGTH, sha256.New)
+
+	return strings.Join([]string{
+		fmt.Sprint(PASSWORD_ITERATIONS),
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(hash),
+	}, PASSWORD_HASH_SEPARATOR), nil
+}
+
+// A send without a password hash accepts any password
+func VerifyPassword(passwordHash, password string) bool {
+	if passwordHash == "" {
+		return true
+	}
+
+	parts := strings.Split(passwordHash, PASSWORD_HASH_SEPARATOR)
+
+	if len(parts) != 3 {
+		return false
+	}
+
+	var iterations int
+
+	if _, err := fmt.Sscanf(parts[0], "%d", &iterations); err != nil || iterations < 1 {
+		return false
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[1])
+
+	if err != nil {
+		return false
+	}
+
+	expected, err := base64.RawStdEncoding.DecodeString(parts[2])
+
+	if err != nil {
+		return false
+	}
+
+	actual := pbkdf2.Key([]byte(password), salt, iterations, len(expected), sha256.New)
+
+	return subtle.ConstantTimeCompare(actual, expected) == 1
+}
+
+// Get the password a recipient sent with the X-Send-Password header
+func PasswordFromHeaders(headers map[string]string) string {
+	for key, value := range headers {
+		if strings.EqualFold(key, PASSWORD_HEADER) {
+			return value
+		}
+	}
+
+	return ""
+}
+
+/********** RESPONSES **********/
+
+// A send can be accessed until it is disabled, expires or reaches its max access count
+func IsAvailable(send types.Send, now time.Time) bool {
+	if send.SendId.Value == "" || send.Disabled.Value || now.Unix() >= int64(send.ExpiresAt.Value) {
+		return false
+	}
+
+	return send.MaxAccessCount.Value == 0 || send.AccessCount.Value < send.MaxAccessCount.Value
+}
+
+// A send with a password is locked once it used up its password attempts
+func IsLocked(send types.Send) bool {
+	return send.PasswordHash.Value != "" && send.FailedAttempts.Value >= MaxPasswordAttempts()
+}
+
+func ToSendResponse(send types.Send) SendResponse {
+	return SendResponse{
+		Id:             send.SendId.Value,
+		Type:           send.Type.Value,
+		Name:           send.Name.Value,
+		Size:           send.Size.Value,
+		MaxAccessCount: send.MaxAccessCount.Value,
+		AccessCount:    send.AccessCount.Value,
+		HasPassword:    send.PasswordHash.Value != "",
+		Locked:         IsLocked(send),
+		Disabled:       send.Disabled.Value,
+		CreatedAt:      send.CreatedAt.Value,
+		ExpiresAt:      time.Unix(int64(send.ExpiresAt.Value), 0).UTC().Format(time.RFC3339),
+	}
+}
+
+// file is the decoded file of a file send
+func ToAccessResponse(send types.Send, file []byte) SendAccessResponse {
+	response := SendAccessResponse{
+		Id:        send.SendId.Value,
+		Type:      send.Type.Value,
+		Name:      send.Name.Value,
+		Text:      send.Text.Value,
+		FileName:  send.FileName.Value,
+		Size:      send.Size.Value,
+		ExpiresAt: time.Unix(int64(send.ExpiresAt.Value), 0).UTC().Format(time.RFC3339),
+	}
+
+	if file != nil {
+		response.File = base64.StdEncoding.EncodeToString(file)
+	}
+
+	return response
+}
+
+/********** OPERATIONS **********/
+
+// Get a send by its id. A missing send results in an empty Send
+func Get(client *dynamoclient.DynamoClient, sendId string) *dynamoclient.DynamoResponse {
+	return client.
+		Get(dynamoclient.DynamoGetRequest{
+			Key:     PartitionKey(sendId),
+			SortKey: SEND_KEY,
+		}).
+		AsSend()
+}
+
+// Get the sends of an owner that did not expire yet, the expired ones may not be deleted by the TTL yet
+func List(client *dynamoclient.DynamoClient, ownerId string, now time.Time) *dynamoclient.DynamoResponse {
+	response := client.
+		Query(dynamoclient.DynamoQueryRequest{
+			Key:           ownerId,
+			SortKeyPrefix: SEND_PREFIX,
+			Filter:        "#expiresAt > :now",
+			Names:         map[string]string{"#expiresAt": "EXPIRES_AT"},
+			Values:        map[string]interface{}{":now": now.Unix()},
+		}).
+		AsSends()
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	sends := []types.Send{}
+
+	for _, reference := range response.Data.([]types.Send) {
+		response = Get(client, reference.SendId.Value)
+
+		if !response.IsSuccess {
+			return response
+		}
+
+		if send := response.Data.(types.Send); send.SendId.Value != "" {
+			sends = append(sends, send)
+		}
+	}
+
+	return dynamoclient.SuccessWithValue(sends)
+}
+
+/*
+Create a send for its owner. The file of a file send is stored first so the
+send never points to a missing file. Fails with a 400 when the owner already
+has MAX_ACTIVE_SENDS sends
+*/
+func Create(client *dynamoclient.DynamoClient, store blobstore.BlobStore, ownerId string, request SendRequest, expiresAt time.Time, file []byte) *dynamoclient.DynamoResponse {
+	response := List(client, ownerId, time.Now())
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	if len(response.Data.([]types.Send)) >= MAX_ACTIVE_SENDS {
+		return dynamoclient.Failure(types.PasswordCaddyError{
+			StatusCode: 400,
+			Message:    fmt.Sprintf("An account can have at most %d active sends", MAX_ACTIVE_SENDS),
+		})
+	}
+
+	var send types.Send
+
+	send.SendId.Value = uuid.NewString()
+	send.UserId.Value = PartitionKey(send.SendId.Value)
+	send.ItemKey.Value = SEND_KEY
+	send.OwnerId.Value = ownerId
+	send.Type.Value = request.Type
+	send.Name.Value = request.Name
+	send.MaxAccessCount.Value = request.MaxAccessCount
+	send.Disabled.Value = request.Disabled
+	send.CreatedAt.Value = time.Now().UTC().Format(time.RFC3339)
+	send.ExpiresAt.Value = int(expiresAt.Unix())
+
+	values := map[string]interface{}{
+		"SEND_ID":          send.SendId.Value,
+		"OWNER_ID":         send.OwnerId.Value,
+		"TYPE":             send.Type.Value,
+		"NAME":             send.Name.Value,
+		"MAX_ACCESS_COUNT": send.MaxAccessCount.Value,
+		"ACCESS_COUNT":     0,
+		"DISABLED":         send.Disabled.Value,
+		"CREATED_AT":       send.CreatedAt.Value,
+		"EXPIRES_AT":       send.ExpiresAt.Value,
+	}
+
+	if request.Password != "" {
+		hash, err := HashPassword(request.Password)
+
+		if err != nil {
+			return dynamoclient.Failure(types.PasswordCaddyError{
+				StatusCode: 500,
+				Message:    err.Error(),
+			})
+		}
+
+		send.PasswordHash.Value = hash
+		values["PASSWORD_HASH"] = hash
+	}
+
+	if send.Type.Value == TYPE_TEXT {
+		send.Text.Value = request.Text
+		values["TEXT"] = send.Text.Value
+	} else {
+		send.FileName.Value = request.FileName
+		send.Size.Value = len(file)
+		values["FILE_NAME"] = send.FileName.Value
+		values["SIZE"] = send.Size.Value
+
+		if err := store.Put(BlobKey(send.SendId.Value), file); err != nil {
+			return dynamoclient.Failure(types.PasswordCaddyError{
+				StatusCode: 500,
+				Message:    err.Error(),
+			})
+		}
+	}
+
+	response = client.TransactWrite([]dynamoclient.DynamoTransactItem{
+		{
+			Put: &dynamoclient.DynamoPutRequest{
+				Key:     send.UserId.Value,
+				SortKey: SEND_KEY,
+				Values:  values,
+			},
+		},
+		{
+			Put: &dynamoclient.DynamoPutRequest{
+				Key:     ownerId,
+				SortKey: OwnerKey(send.SendId.Value),
+				Values: map[string]interface{}{
+					"SEND_ID":    send.SendId.Value,
+					"EXPIRES_AT": send.ExpiresAt.Value,
+				},
+			},
+		},
+	})
+
+	if !response.IsSuccess {
+		if send.Type.Value == TYPE_FILE {
+			store.Delete(BlobKey(send.SendId.Value))
+		}
+
+		return response
+	}
+
+	return dynamoclient.SuccessWithValue(send)
+}
+
+/*
+Count an access of a send and get the send. The access is only counted while
+the send is available, checked in the same write so concurrent recipients can
+never exceed the max access count. Fails with a 404 when it is not available
+*/
+func Access(client *dynamoclient.DynamoClient, sendId string, now time.Time) *dynamoclient.DynamoResponse {
+	response := client.
+		Update(dynamoclient.DyanamoUpdateRequest{
+			Key:     PartitionKey(sendId),
+			SortKey: SEND_KEY,
+			Values: map[string]dynamoclient.DynamoUpdateItem{
+				"ACCESS_COUNT": {
+					Action: dynamoTypes.AttributeActionAdd,
+					Value:  1,
+				},
+			},
+			Condition: &dynamoclient.DynamoCondition{
+				Expression: "attribute_exists(#sk) AND #disabled = :false AND #expiresAt > :now AND (#max = :zero OR #count < #max)",
+				Names: map[string]string{
+					"#sk":        dynamoclient.SORT_KEY,
+					"#disabled":  "DISABLED",
+					"#expiresAt": "EXPIRES_AT",
+					"#max":       "MAX_ACCESS_COUNT",
+					"#count":     "ACCESS_COUNT",
+				},
+				Values: map[string]interface{}{
+					":false": false,
+					":now":   now.Unix(),
+					":zero":  0,
+				},
+			},
+		}).
+		AsSend()
+
+	if !response.IsSuccess && response.Error.StatusCode == 409 {
+		return dynamoclient.Failure(types.PasswordCaddyError{
+			StatusCode: 404,
+			Message:    "Send not found",
+		})
+	}
+
+	return response
+}
+
+/*
+Count a password attempt on a send before the password is checked, so
+concurrent guesses can never run more than MaxPasswordAttempts hashes. Fails
+with a 429 when the send is locked. A correct password gives the attempt back
+with ReleaseAttempt
+*/
+func ReserveAttempt(client *dynamoclient.DynamoClient, sendId string) *dynamoclient.DynamoResponse {
+	response := client.
+		Update(dynamoclient.DyanamoUpdateRequest{
+			Key:     PartitionKey(sendId),
+			SortKey: SEND_KEY,
+			Values: map[string]dynamoclient.DynamoUpdateItem{
+				"FAILED_ATTEMPTS": {
+					Action: dynamoTypes.AttributeActionAdd,
+					Value:  1,
+				},
+			},
+			Condition: &dynamoclient.DynamoCondition{
+				Expression: "attribute_exists(#sk) AND (attribute_not_exists(#attempts) OR #attempts < :max)",
+				Names: map[string]string{
+					"#sk":       dynamoclient.SORT_KEY,
+					"#attempts": "FAILED_ATTEMPTS",
+				},
+				Values: map[string]interface{}{
+					":max": MaxPasswordAttempts(),
+				},
+			},
+		}).
+		AsSend()
+
+	if !response.IsSuccess && response.Error.StatusCode == 409 {
+		return dynamoclient.Failure(types.PasswordCaddyError{
+			StatusCode: 429,
+			Message:    "Too many invalid send passwords",
+		})
+	}
+
+	return response
+}
+
+// Give back an attempt reserved for a password that turned out to be correct
+func ReleaseAttempt(client *dynamoclient.DynamoClient, sendId string) *dynamoclient.DynamoResponse {
+	return client.Update(dynamoclient.DyanamoUpdateRequest{
+		Key:     PartitionKey(sendId),
+		SortKey: SEND_KEY,
+		Values: map[string]dynamoclient.DynamoUpdateItem{
+			"FAILED_ATTEMPTS": {
+				Action: dynamoTypes.AttributeActionAdd,
+				Value:  -1,
+			},
+		},
+		Condition: &dynamoclient.DynamoCondition{
+			Expression: "#attempts > :zero",
+			Names:      map[string]string{"#attempts": "FAILED_ATTEMPTS"},
+			Values:     map[string]interface{}{":zero": 0},
+		},
+	})
+}
+
+// Get the file of a file send. A file missing from the blob store results in a 404
+func GetFile(store blobstore.BlobStore, send types.Send) *dynamoclient.DynamoResponse {
+	file, err := store.Get(BlobKey(send.SendId.Value))
+
+	if err == blobstore.ErrNotFound {
+		return dynamoclient.Failure(types.PasswordCaddyError{
+			StatusCode: 404,
+			Message:    "Send not found",
+		})
+	}
+
+	if err != nil {
+		return dynamoclient.Failure(types.PasswordCaddyError{
+			StatusCode: 500,
+			Message:    err.Error(),
+		})
+	}
+
+	return dynamoclient.SuccessWithValue(file)
+}
+
+// Disable or enable a send. Disabled sends can not be accessed but still count as active
+func SetDisabled(client *dynamoclient.DynamoClient, send types.Send, disabled bool) *dynamoclient.DynamoResponse {
+	response := client.Update(dynamoclient.DyanamoUpdateRequest{
+		Key:     send.UserId.Value,
+		SortKey: SEND_KEY,
+		Values: map[string]dynamoclient.DynamoUpdateItem{
+			"DISABLED": {
+				Action: dynamoTypes.AttributeActionPut,
+				Value:  disabled,
+			},
+		},
+		Condition: &dynamoclient.DynamoCondition{
+			Expression: "attribute_exists(#sk)",
+			Names:      map[string]string{"#sk": dynamoclient.SORT_KEY},
+		},
+	})
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	send.Disabled.Value = disabled
+
+	return dynamoclient.SuccessWithValue(send)
+}
+
+// Delete a send, its reference in the owner's partition and its file
+func Delete(client *dynamoclient.DynamoClient, store blobstore.BlobStore, send types.Send) *dynamoclient.DynamoResponse {
+	response := client.TransactWrite([]dynamoclient.DynamoTransactItem{
+		{
+			Delete: &dynamoclient.DynamoDeleteRequest{
+				Key:     send.UserId.Value,
+				SortKey: SEND_KEY,
+			},
+		},
+		{
+			Delete: &dynamoclient.DynamoDeleteRequest{
+				Key:     send.OwnerId.Value,
+				SortKey: OwnerKey(send.SendId.Value),
+			},
+		},
+	})
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	if send.Type.Value == TYPE_FILE {
+		if err := store.Delete(BlobKey(send.SendId.Value)); err != nil {
+			return dynamoclient.Failure(types.PasswordCaddyError{
+				StatusCode: 500,
+				Message:    err.Error(),
+			})
+		}
+	}
+
+	return dynamoclient.Success()
+}
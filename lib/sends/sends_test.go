@@ -0,0 +1,151 @@
+package sends
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"password-caddy/api/core/types"
+)
+
+func sendAt(expiresAt time.Time, maxAccessCount, accessCount int) types.Send {
+	var send types.Send
+	send.SendId.Value = "6f1e4a3c-9a8b-4c2d-8e7f-0a1b2c3d4e5f"
+	send.ExpiresAt.Value = int(expiresAt.Unix())
+	send.MaxAccessCount.Value = maxAccessCount
+	send.AccessCount.Value = accessCount
+	return send
+}
+
+/***** Validate *****/
+
+func TestValidateTextSend(t *testing.T) {
+	now := time.Now()
+	request := SendRequest{Type: TYPE_TEXT, Name: "name", Text: "secret"}
+
+	expiresAt, file, err := request.Validate(now)
+
+	if err != nil || file != nil || !expiresAt.Equal(now.Add(DEFAULT_EXPIRY)) {
+		t.Errorf("FAILED - TestValidateTextSend | Actual: %s %v %v", expiresAt, file, err)
+	}
+}
+
+func TestValidateFileSend(t *testing.T) {
+	request := SendRequest{
+		Type:     TYPE_FILE,
+		Name:     "name",
+		FileName: "file",
+		File:     base64.StdEncoding.EncodeToString([]byte("encrypted")),
+	}
+
+	_, file, err := request.Validate(time.Now())
+
+	if err != nil || string(file) != "encrypted" {
+		t.Errorf("FAILED - TestValidateFileSend | Actual: %s %v | Expected: encrypted", file, err)
+	}
+}
+
+func TestValidateWithInvalidRequest(t *testing.T) {
+	now := time.Now()
+
+	invalid := []SendRequest{
+		{Type: "note", Name: "name", Text: "secret"},
+		{Type: TYPE_TEXT, Text: "secret"},
+		{Type: TYPE_TEXT, Name: "name"},
+		{Type: TYPE_TEXT, Name: "name", Text: "secret", MaxAccessCount: -1},
+		{Type: TYPE_TEXT, Name: "name", Text: "secret", ExpiresAt: "tomorrow"},
+		{Type: TYPE_TEXT, Name: "name", Text: "secret", ExpiresAt: now.Add(-time.Hour).Format(time.RFC3339)},
+		{Type: TYPE_TEXT, Name: "name", Text: "secret", ExpiresAt: now.Add(MaxExpiry() + time.Hour).Format(time.RFC3339)},
+		{Type: TYPE_FILE, Name: "name", FileName: "file", File: "not base64!"},
+		{Type: TYPE_FILE, Name: "name", File: base64.StdEncoding.EncodeToString([]byte("encrypted"))},
+	}
+
+	for _, request := range invalid {
+		if _, _, err := request.Validate(now); err == nil {
+			t.Errorf("FAILED - TestValidateWithInvalidRequest | Request: %+v | Expected: an error", request)
+		}
+	}
+}
+
+/***** End Validate *****/
+
+/***** Passwords *****/
+
+func TestVerifyPassword(t *testing.T) {
+	hash, err := HashPassword("correct horse")
+
+	if err != nil {
+		t.Fatalf("FAILED - TestVerifyPassword | Error: %s", err.Error())
+	}
+
+	if !VerifyPassword(hash, "correct horse") || VerifyPassword(hash, "battery staple") {
+		t.Errorf("FAILED - TestVerifyPassword | Expected: only the correct password to verify")
+	}
+
+	if !VerifyPassword("", "anything") || VerifyPassword("garbage", "") {
+		t.Errorf("FAILED - TestVerifyPassword | Expected: sends without a password to accept any and invalid hashes none")
+	}
+}
+
+func TestPasswordFromHeaders(t *testing.T) {
+	actual := PasswordFromHeaders(map[string]string{"x-send-password": "secret"})
+
+	if actual != "secret" {
+		t.Errorf("FAILED - TestPasswordFromHeaders | Actual: %s | Expected: secret", actual)
+	}
+}
+
+/***** End Passwords *****/
+
+func TestIsAvailable(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		send     types.Send
+		expected bool
+	}{
+		{sendAt(now.Add(time.Hour), 0, 10), true},
+		{sendAt(now.Add(time.Hour), 2, 1), true},
+		{sendAt(now.Add(time.Hour), 2, 2), false},
+		{sendAt(now.Add(-time.Hour), 0, 0), false},
+		{types.Send{}, false},
+	}
+
+	for _, test := range tests {
+		if actual := IsAvailable(test.send, now); actual != test.expected {
+			t.Errorf("FAILED - TestIsAvailable | Send: %+v | Actual: %v | Expected: %v", test.send, actual, test.expected)
+		}
+	}
+
+	disabled := sendAt(now.Add(time.Hour), 0, 0)
+	disabled.Disabled.Value = true
+
+	if IsAvailable(disabled, now) {
+		t.Errorf("FAILED - TestIsAvailable | Expected: a disabled send to be unavailable")
+	}
+}
+
+/***** IsLocked *****/
+
+func TestIsLocked(t *testing.T) {
+	tests := []struct {
+		passwordHash   string
+		failedAttempts int
+		expected       bool
+	}{
+		{"hash", 0, false},
+		{"hash", MaxPasswordAttempts() - 1, false},
+		{"hash", MaxPasswordAttempts(), true},
+		{"", MaxPasswordAttempts(), false},
+	}
+
+	for _, test := range tests {
+		send := sendAt(time.Now().Add(time.Hour), 0, 0)
+		send.PasswordHash.Value = test.passwordHash
+		send.FailedAttempts.Value = test.failedAttempts
+
+		if actual := IsLocked(send); actual != test.expected {
+			t.Errorf("FAILED - TestIsLocked | Send: %+v | Actual: %v | Expected: %v", send, actual, test.expected)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"html"
 
 	apiTypes "password-caddy/api/core/types"
 	"password-caddy/api/lib/util"
@@ -35,6 +36,73 @@ const OTP_EMAIL_TEMPLATE = `
 </p>
 `
 
+const INVITE_EMAIL_TEMPLATE = `
+<h4>%s invited you to join %s on Password Caddy.</h4>
+<p>
+	Log into Password Caddy to accept or decline the invite: <a href="%s">%[3]s</a>
+</p>
+<p>
+	If you do not know the organization, please ignore this email.
+</p>
+`
+
+const SHARE_REVOKED_EMAIL_TEMPLATE = `
+<h4>%s stopped sharing a vault item with you on Password Caddy.</h4>
+<p>
+	The item was removed from your shared items and is no longer accessible.
+</p>
+`
+
+const EMERGENCY_INVITE_EMAIL_TEMPLATE = `
+<h4>%s added you as an emergency contact on Password Caddy.</h4>
+<p>
+	As an emergency contact you can request access to their vault when they can not.
+	Log into Password Caddy to accept or decline: <a href="%s">%[2]s</a>
+</p>
+<p>
+	If you do not know the sender, please ignore this email.
+</p>
+`
+
+const EMERGENCY_REQUEST_EMAIL_TEMPLATE = `
+<h4>%s requested emergency %s access to your vault on Password Caddy.</h4>
+<p>
+	Access is granted automatically in %d days unless you reject the request.
+	Log into Password Caddy to approve or reject it: <a href="%s">%[4]s</a>
+</p>
+<p>
+	If you did not expect this request, reject it and remove the emergency contact.
+</p>
+`
+
+const EMERGENCY_APPROVED_EMAIL_TEMPLATE = `
+<h4>Your emergency access request to the vault of %s was approved on Password Caddy.</h4>
+<p>
+	Log into Password Caddy to access the vault: <a href="%s">%[2]s</a>
+</p>
+`
+
+const EMERGENCY_REJECTED_EMAIL_TEMPLATE = `
+<h4>%s rejected your emergency access request on Password Caddy.</h4>
+<p>
+	You remain their emergency contact and can request access again later.
+</p>
+`
+
+const NEW_DEVICE_EMAIL_TEMPLATE = `
+<h4>Your Password Caddy account was logged into from a new device or location.</h4>
+<p>
+	Time: %s<br/>
+	Device: %s<br/>
+	IP address: %s<br/>
+	Approximate location: %s
+</p>
+<p>
+	If this was you, you can ignore this email.
+	If it wasn't you, lock your account and sign out everywhere: <a href="%s">This wasn't me</a>
+</p>
+`
+
 /*
 Create a new instance of the AWS Ses Client
 */
@@ -100,12 +168,104 @@ func (client *SesClient) GetVerificationStatus(email string) *SesResponse {
 Build the email input with the sender and appropriate receiver
 */
 func (client *SesClient) BuildEmailRequest(email, otp string) *SesClient {
+	body := fmt.Sprintf(OTP_EMAIL_TEMPLATE, otp)
+
+	return client.buildEmail(email, "Verification for Password Caddy", body)
+}
+
+/*
+Build the email inviting a user to an organization
+*/
+func (client *SesClient) BuildInviteEmailRequest(email, orgName, invitedBy, link string) *SesClient {
+	body := fmt.Sprintf(
+		INVITE_EMAIL_TEMPLATE,
+		html.EscapeString(invitedBy),
+		html.EscapeString(orgName),
+		html.EscapeString(link),
+	)
+
+	return client.buildEmail(email, "You are invited to "+orgName+" on Password Caddy", body)
+}
+
+/*
+Build the email telling a user that an item is no longer shared with them
+*/
+func (client *SesClient) BuildShareRevokedEmailRequest(email, ownerId string) *SesClient {
+	body := fmt.Sprintf(SHARE_REVOKED_EMAIL_TEMPLATE, html.EscapeString(ownerId))
+
+	return client.buildEmail(email, "A vault item is no longer shared with you", body)
+}
+
+/*
+Build the email inviting a user to be the emergency contact of a grantor
+*/
+func (client *SesClient) BuildEmergencyInviteEmailRequest(email, grantorId, link string) *SesClient {
+	body := fmt.Sprintf(
+		EMERGENCY_INVITE_EMAIL_TEMPLATE,
+		html.EscapeString(grantorId),
+		html.EscapeString(link),
+	)
+
+	return client.buildEmail(email, "You were added as an emergency contact on Password Caddy", body)
+}
+
+/*
+Build the email telling a grantor that an emergency contact requested access to their vault
+*/
+func (client *SesClient) BuildEmergencyRequestEmailRequest(email, granteeId, accessType string, waitDays int, link string) *SesClient {
+	body := fmt.Sprintf(
+		EMERGENCY_REQUEST_EMAIL_TEMPLATE,
+		html.EscapeString(granteeId),
+		html.EscapeString(accessType),
+		waitDays,
+		html.EscapeString(link),
+	)
+
+	return client.buildEmail(email, "Emergency access to your vault was requested", body)
+}
+
+/*
+Build the email telling a grantee that their emergency access was approved
+*/
+func (client *SesClient) BuildEmergencyApprovedEmailRequest(email, grantorId, link string) *SesClient {
+	body := fmt.Sprintf(
+		EMERGENCY_APPROVED_EMAIL_TEMPLATE,
+		html.EscapeString(grantorId),
+		html.EscapeString(link),
+	)
+
+	return client.buildEmail(email, "Your emergency access was approved", body)
+}
+
+/*
+Build the email telling a grantee that their emergency access request was rejected
+*/
+func (client *SesClient) BuildEmergencyRejectedEmailRequest(email, grantorId string) *SesClient {
+	body := fmt.Sprintf(EMERGENCY_REJECTED_EMAIL_TEMPLATE, html.EscapeString(grantorId))
+
+	return client.buildEmail(email, "Your emergency access request was rejected", body)
+}
+
+/*
+Build the email telling a user about a login from a device or IP address they never logged in from
+*/
+func (client *SesClient) BuildNewDeviceEmailRequest(email, loggedInAt, userAgent, ipAddress, location, lockLink string) *SesClient {
+	body := fmt.Sprintf(
+		NEW_DEVICE_EMAIL_TEMPLATE,
+		html.EscapeString(loggedInAt),
+		html.EscapeString(userAgent),
+		html.EscapeString(ipAddress),
+		html.EscapeString(location),
+		html.EscapeString(lockLink),
+	)
+
+	return client.buildEmail(email, "New login to your Password Caddy account", body)
+}
+
+func (client *SesClient) buildEmail(email, subject, body string) *SesClient {
 	var sender string = "me@samuelsouik.com" // update after having password-caddy.com email
 	var emails []string = []string{email}
 	var charSet string = "UTF-8"
-	var subject string = "Verification for Password Caddy"
-
-	html := fmt.Sprintf(OTP_EMAIL_TEMPLATE, otp)
 
 	var input ses.SendEmailInput = ses.SendEmailInput{
 		Source: &sender,
@@ -120,7 +280,7 @@ func (client *SesClient) BuildEmailRequest(email, otp string) *SesClient {
 			Body: &types.Body{
 				Html: &types.Content{
 					Charset: &charSet,
-					Data:    aws.String(html),
+					Data:    aws.String(body),
 				},
 			},
 		},
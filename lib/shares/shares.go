@@ -0,0 +1,413 @@
+package shares
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"time"
+
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/access"
+	"password-caddy/api/lib/dynamoclient"
+	"password-caddy/api/lib/vault"
+
+	dynamoTypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/google/uuid"
+)
+
+/*
+Keys of a share. The owner's partition holds the share under
+SHARE#<item id>#<recipient>, so an item is shared at most once with a user.
+The recipient's partition holds the shared copy under SHARED#<share id>
+*/
+const (
+	SHARE_PREFIX  = "SHARE#"
+	SHARED_PREFIX = "SHARED#"
+)
+
+// Statuses of a share. The recipient only gets access to the item once the share is accepted
+const (
+	STATUS_PENDING  = "pending"
+	STATUS_ACCEPTED = "accepted"
+)
+
+const (
+	MAX_KEY_LENGTH         = 4096
+	MAX_SHARES_PER_ITEM    = 50
+	MAX_FINGERPRINT_LENGTH = 128
+)
+
+/*
+A share of an item with another user. Key is the item key wrapped by the
+client with the recipient's public key, Fingerprint the fingerprint of the
+public key it was wrapped with
+*/
+type ShareRequest struct {
+	Recipient   string `json:"recipient"`
+	Key         string `json:"key"`
+	Fingerprint string `json:"fingerprint"`
+	Level       string `json:"level"`
+}
+
+type UpdateShareRequest struct {
+	Level string `json:"level"`
+}
+
+// A share as its owner or recipient sees it. Key is only sent to the recipient
+type ShareResponse struct {
+	Id          string `json:"id"`
+	ItemId      string `json:"itemId"`
+	Owner       string `json:"owner"`
+	Recipient   string `json:"recipient"`
+	Level       string `json:"level"`
+	Status      string `json:"status"`
+	Fingerprint string `json:"fingerprint"`
+	Key         string `json:"key,omitempty"`
+	CreatedAt   string `json:"createdAt"`
+	UpdatedAt   string `json:"updatedAt"`
+}
+
+// A shared item as its recipient sees it, with the wrapped item key to decrypt it
+type SharedItemResponse struct {
+	Share ShareResponse           `json:"share"`
+	Item  vault.VaultItemResponse `json:"item"`
+}
+
+/********** KEYS **********/
+
+func IsValidShareId(shareId string) bool {
+	_, err := uuid.Parse(shareId)
+	return err == nil
+}
+
+// Prefix of the shares of an item in the owner's partition
+func OwnerPrefix(itemId string) string {
+	return SHARE_PREFIX + itemId + "#"
+}
+
+func OwnerKey(itemId, recipient string) string {
+	return OwnerPrefix(itemId) + recipient
+}
+
+func RecipientKey(shareId string) string {
+	return SHARED_PREFIX + shareId
+}
+
+/********** LEVELS **********/
+
+// Levels an item can be shared with. Shared items can not be managed by the recipient
+func Levels() []string {
+	return []string{access.LEVEL_HIDE_PASSWORDS, access.LEVEL_READ_ONLY, access.LEVEL_EDIT}
+}
+
+func IsValidLevel(level string) bool {
+	for _, candidate := range Levels() {
+		if candidate == level {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Check if an accepted share allows its recipient the required level
+func Allows(share types.ItemShare, required string) bool {
+	return share.Status.Value == STATUS_ACCEPTED && access.Allows(share.Level.Value, required)
+}
+
+/********** VALIDATION **********/
+
+func (request ShareRequest) Validate(ownerId string) error {
+	address, err := mail.ParseAddress(request.Recipient)
+
+	if err != nil || address.Address != request.Recipient {
+		return errors.New("Recipient must be an email address")
+	}
+
+	if request.Recipient == ownerId {
+		return errors.New("Items can not be shared with yourself")
+	}
+
+	if request.Key == "" || len(request.Key) > MAX_KEY_LENGTH {
+		return fmt.Errorf("Key is required and can have at most %d characters", MAX_KEY_LENGTH)
+	}
+
+	if request.Fingerprint == "" || len(request.Fingerprint) > MAX_FINGERPRINT_LENGTH {
+		return errors.New("Fingerprint of the recipient's public key is required")
+	}
+
+	if !IsValidLevel(request.Level) {
+		return errors.New("Level must be hidePasswords, readOnly or edit")
+	}
+
+	return nil
+}
+
+func (request UpdateShareRequest) Validate() error {
+	if !IsValidLevel(request.Level) {
+		return errors.New("Level must be hidePasswords, readOnly or edit")
+	}
+
+	return nil
+}
+
+/********** RESPONSES **********/
+
+func ToShareResponse(share types.ItemShare) ShareResponse {
+	return ShareResponse{
+		Id:          share.ShareId.Value,
+		ItemId:      share.ItemId.Value,
+		Owner:       share.OwnerId.Value,
+		Recipient:   share.Recipient.Value,
+		Level:       share.Level.Value,
+		Status:      share.Status.Value,
+		Fingerprint: share.Fingerprint.Value,
+		CreatedAt:   share.CreatedAt.Value,
+		UpdatedAt:   share.UpdatedAt.Value,
+	}
+}
+
+func ToReceivedResponse(share types.ItemShare) ShareResponse {
+	response := ToShareResponse(share)
+	response.Key = share.Key.Value
+	return response
+}
+
+// The owner's item flagged with what the recipient is allowed to do with it
+func ToSharedItemResponse(share types.ItemShare, item types.VaultItem) SharedItemResponse {
+	response := vault.ToItemResponse(item)
+	response.FolderId = ""
+	response.CollectionIds = nil
+	response.ReadOnly = !access.Allows(share.Level.Value, access.LEVEL_EDIT)
+	response.HidePasswords = share.Level.Value == access.LEVEL_HIDE_PASSWORDS
+
+	return SharedItemResponse{
+		Share: ToReceivedResponse(share),
+		Item:  response,
+	}
+}
+
+/********** OPERATIONS **********/
+
+// Get the share of an item with a recipient. A missing share results in an empty ItemShare
+func Get(client *dynamoclient.DynamoClient, ownerId, itemId, recipient string) *dynamoclient.DynamoResponse {
+	return client.
+		Get(dynamoclient.DynamoGetRequest{
+			Key:     ownerId,
+			SortKey: OwnerKey(itemId, recipient),
+		}).
+		AsItemShare()
+}
+
+// Get all shares of an item
+func ListForItem(client *dynamoclient.DynamoClient, ownerId, itemId string) *dynamoclient.DynamoResponse {
+	return client.
+		Query(dynamoclient.DynamoQueryRequest{
+			Key:           ownerId,
+			SortKeyPrefix: OwnerPrefix(itemId),
+		}).
+		AsItemShares()
+}
+
+// Get a share a user received. A missing share results in an empty ItemShare
+func GetReceived(client *dynamoclient.DynamoClient, recipient, shareId string) *dynamoclient.DynamoResponse {
+	return client.
+		Get(dynamoclient.DynamoGetRequest{
+			Key:     recipient,
+			SortKey: RecipientKey(shareId),
+		}).
+		AsItemShare()
+}
+
+// Get all shares a user received, pending and accepted
+func ListReceived(client *dynamoclient.DynamoClient, recipient string) *dynamoclient.DynamoResponse {
+	return client.
+		Query(dynamoclient.DynamoQueryRequest{
+			Key:           recipient,
+			SortKeyPrefix: SHARED_PREFIX,
+		}).
+		AsItemShares()
+}
+
+/*
+Share an item with a recipient, pending until the recipient accepts. Fails
+with a 409 when the item is already shared with the recipient
+*/
+func Create(client *dynamoclient.DynamoClient, ownerId, itemId string, request ShareRequest) *dynamoclient.DynamoResponse {
+	var share types.ItemShare
+
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	share.ShareId.Value = uuid.NewString()
+	share.OwnerId.Value = ownerId
+	share.ItemId.Value = itemId
+	share.Recipient.Value = request.Recipient
+	share.Key.Value = request.Key
+	share.Fingerprint.Value = request.Fingerprint
+	share.Level.Value = request.Level
+	share.Status.Value = STATUS_PENDING
+	share.CreatedAt.Value = now
+	share.UpdatedAt.Value = now
+
+	values := map[string]interface{}{
+		"SHARE_ID":    share.ShareId.Value,
+		"OWNER_ID":    share.OwnerId.Value,
+		"ITEM_ID":     share.ItemId.Value,
+		"RECIPIENT":   share.Recipient.Value,
+		"KEY":         share.Key.Value,
+		"FINGERPRINT": share.Fingerprint.Value,
+		"LEVEL":       share.Level.Value,
+		"STATUS":      share.Status.Value,
+		"CREATED_AT":  share.CreatedAt.Value,
+		"UPDATED_AT":  share.UpdatedAt.Value,
+	}
+
+	response := client.TransactWrite([]dynamoclient.DynamoTransactItem{
+		{
+			Put: &dynamoclient.DynamoPutRequest{
+				Key:       ownerId,
+				SortKey:   OwnerKey(itemId, request.Recipient),
+				Values:    values,
+				Condition: notExists(),
+			},
+		},
+		{
+			Put: &dynamoclient.DynamoPutRequest{
+				Key:       request.Recipient,
+				SortKey:   RecipientKey(share.ShareId.Value),
+				Values:    values,
+				Condition: notExists(),
+			},
+		},
+	})
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	share.UserId.Value = ownerId
+	share.ItemKey.Value = OwnerKey(itemId, request.Recipient)
+
+	return dynamoclient.SuccessWithValue(share)
+}
+
+// Accept a share. Fails with a 409 when the share is no longer pending
+func Accept(client *dynamoclient.DynamoClient, share types.ItemShare) *dynamoclient.DynamoResponse {
+	return update(client, share, &dynamoclient.DynamoCondition{
+		Expression: "#status = :status",
+		Names:      map[string]string{"#status": "STATUS"},
+		Values:     map[string]interface{}{":status": STATUS_PENDING},
+	}, map[string]interface{}{
+		"STATUS": STATUS_ACCEPTED,
+	})
+}
+
+// Change the level of a share, whatever its status
+func UpdateLevel(client *dynamoclient.DynamoClient, share types.ItemShare, level string) *dynamoclient.DynamoResponse {
+	return update(client, share, exists(), map[string]interface{}{
+		"LEVEL": level,
+	})
+}
+
+// Revoke or decline a share, removing the shared copy in the recipient's partition as well
+func Remove(client *dynamoclient.DynamoClient, share types.ItemShare) *dynamoclient.DynamoResponse {
+	return client.TransactWrite([]dynamoclient.DynamoTransactItem{
+		{
+			Delete: &dynamoclient.DynamoDeleteRequest{
+				Key:       share.OwnerId.Value,
+				SortKey:   OwnerKey(share.ItemId.Value, share.Recipient.Value),
+				Condition: exists(),
+			},
+		},
+		{
+			Delete: &dynamoclient.DynamoDeleteRequest{
+				Key:     share.Recipient.Value,
+				SortKey: RecipientKey(share.ShareId.Value),
+			},
+		},
+	})
+}
+
+/*
+Remove every share of an item before the item is permanently deleted, so no
+share or shared copy outlives it. Shares removed concurrently are skipped
+*/
+func RemoveForItem(client *dynamoclient.DynamoClient, ownerId, itemId string) *dynamoclient.DynamoResponse {
+	response := ListForItem(client, ownerId, itemId)
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	for _, share := range response.Data.([]types.ItemShare) {
+		if removed := Remove(client, share); !removed.IsSuccess && removed.Error.StatusCode != 409 {
+			return removed
+		}
+	}
+
+	return dynamoclient.Success()
+}
+
+// Update both copies of a share in one transaction. The condition applies to the owner's copy
+func update(client *dynamoclient.DynamoClient, share types.ItemShare, condition *dynamoclient.DynamoCondition, values map[string]interface{}) *dynamoclient.DynamoResponse {
+	values["UPDATED_AT"] = time.Now().UTC().Format(time.RFC3339)
+
+	updates := map[string]dynamoclient.DynamoUpdateItem{}
+
+	for key, value := range values {
+		updates[key] = dynamoclient.DynamoUpdateItem{
+			Action: dynamoTypes.AttributeActionPut,
+			Value:  value,
+		}
+	}
+
+	response := client.TransactWrite([]dynamoclient.DynamoTransactItem{
+		{
+			Update: &dynamoclient.DyanamoUpdateRequest{
+				Key:       share.OwnerId.Value,
+				SortKey:   OwnerKey(share.ItemId.Value, share.Recipient.Value),
+				Values:    updates,
+				Condition: condition,
+			},
+		},
+		{
+			Update: &dynamoclient.DyanamoUpdateRequest{
+				Key:       share.Recipient.Value,
+				SortKey:   RecipientKey(share.ShareId.Value),
+				Values:    updates,
+				Condition: exists(),
+			},
+		},
+	})
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	if status, ok := values["STATUS"]; ok {
+		share.Status.Value = status.(string)
+	}
+
+	if level, ok := values["LEVEL"]; ok {
+		share.Level.Value = level.(string)
+	}
+
+	share.UpdatedAt.Value = values["UPDATED_AT"].(string)
+
+	return dynamoclient.SuccessWithValue(share)
+}
+
+func exists() *dynamoclient.DynamoCondition {
+	return &dynamoclient.DynamoCondition{
+		Expression: "attribute_exists(#sk)",
+		Names:      map[string]string{"#sk": dynamoclient.SORT_KEY},
+	}
+}
+
+func notExists() *dynamoclient.DynamoCondition {
+	return &dynamoclient.DynamoCondition{
+		Expression: "attribute_not_exists(#sk)",
+		Names:      map[string]string{"#sk": dynamoclient.SORT_KEY},
+	}
+}
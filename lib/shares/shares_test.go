@@ -0,0 +1,79 @@
+package shares
+
+import (
+	"testing"
+
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/access"
+)
+
+func share(level, status string) types.ItemShare {
+	var share types.ItemShare
+	share.ShareId.Value = "6f1e4a3c-9a8b-4c2d-8e7f-0a1b2c3d4e5f"
+	share.Key.Value = "wrapped"
+	share.Level.Value = level
+	share.Status.Value = status
+	return share
+}
+
+/***** Validate *****/
+
+func TestShareRequestValidate(t *testing.T) {
+	valid := ShareRequest{Recipient: "bob@example.com", Key: "wrapped", Fingerprint: "abc", Level: access.LEVEL_READ_ONLY}
+
+	if err := valid.Validate("alice@example.com"); err != nil {
+		t.Errorf("FAILED - TestShareRequestValidate | Error: %v", err)
+	}
+
+	tests := []ShareRequest{
+		{Recipient: "bob", Key: "wrapped", Fingerprint: "abc", Level: access.LEVEL_READ_ONLY},
+		{Recipient: "alice@example.com", Key: "wrapped", Fingerprint: "abc", Level: access.LEVEL_READ_ONLY},
+		{Recipient: "bob@example.com", Fingerprint: "abc", Level: access.LEVEL_READ_ONLY},
+		{Recipient: "bob@example.com", Key: "wrapped", Level: access.LEVEL_READ_ONLY},
+		{Recipient: "bob@example.com", Key: "wrapped", Fingerprint: "abc", Level: access.LEVEL_MANAGE},
+	}
+
+	for _, test := range tests {
+		if err := test.Validate("alice@example.com"); err == nil {
+			t.Errorf("FAILED - TestShareRequestValidate | Request: %v | Expected: an error", test)
+		}
+	}
+}
+
+/***** Access *****/
+
+func TestAllowsOnlyAcceptedShares(t *testing.T) {
+	if Allows(share(access.LEVEL_EDIT, STATUS_PENDING), access.LEVEL_HIDE_PASSWORDS) {
+		t.Errorf("FAILED - TestAllowsOnlyAcceptedShares | Expected: no access before the share is accepted")
+	}
+
+	if !Allows(share(access.LEVEL_EDIT, STATUS_ACCEPTED), access.LEVEL_EDIT) {
+		t.Errorf("FAILED - TestAllowsOnlyAcceptedShares | Expected: edit access with an accepted edit share")
+	}
+
+	if Allows(share(access.LEVEL_READ_ONLY, STATUS_ACCEPTED), access.LEVEL_EDIT) {
+		t.Errorf("FAILED - TestAllowsOnlyAcceptedShares | Expected: no edit access with a read only share")
+	}
+}
+
+/***** Responses *****/
+
+func TestSharedItemResponse(t *testing.T) {
+	var item types.VaultItem
+	item.ItemId.Value = "1"
+	item.FolderId.Value = "owner-folder"
+
+	response := ToSharedItemResponse(share(access.LEVEL_HIDE_PASSWORDS, STATUS_ACCEPTED), item)
+
+	if !response.Item.ReadOnly || !response.Item.HidePasswords || response.Item.FolderId != "" {
+		t.Errorf("FAILED - TestSharedItemResponse | Item: %v", response.Item)
+	}
+
+	if response.Share.Key != "wrapped" {
+		t.Errorf("FAILED - TestSharedItemResponse | Expected: the wrapped key for the recipient")
+	}
+
+	if ToShareResponse(share(access.LEVEL_EDIT, STATUS_PENDING)).Key != "" {
+		t.Errorf("FAILED - TestSharedItemResponse | Expected: no wrapped key for the owner")
+	}
+}
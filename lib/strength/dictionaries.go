@@ -0,0 +1,81 @@
+package strength
+
+import (
+	"embed"
+	"strings"
+	"sync"
+)
+
+/*
+Frequency lists of zxcvbn, one lower case word per line with the most common
+word first. The rank of a word is its line number
+
+@see - https://github.com/dropbox/zxcvbn/tree/master/data
+*/
+//go:embed data/*.txt
+var frequencyLists embed.FS
+
+// Names of the frequency lists. Matches of user inputs use USER_INPUTS
+const (
+	PASSWORDS    = "passwords"
+	ENGLISH      = "english"
+	SURNAMES     = "surnames"
+	MALE_NAMES   = "male_names"
+	FEMALE_NAMES = "female_names"
+	USER_INPUTS  = "user_inputs"
+)
+
+var (
+	rankedDictionaries     map[string]map[string]int
+	longestWords           map[string]int
+	rankedDictionariesOnce sync.Once
+)
+
+// The ranked frequency lists by name
+func dictionaries() map[string]map[string]int {
+	rankedDictionariesOnce.Do(func() {
+		rankedDictionaries = map[string]map[string]int{}
+		longestWords = map[string]int{}
+
+		for _, name := range []string{PASSWORDS, ENGLISH, SURNAMES, MALE_NAMES, FEMALE_NAMES} {
+			data, err := frequencyLists.ReadFile("data/" + name + ".txt")
+
+			if err != nil {
+				panic(err)
+			}
+
+			rankedDictionaries[name] = rankedDictionary(strings.Split(strings.TrimSpace(string(data)), "\n"))
+			longestWords[name] = longestWord(rankedDictionaries[name])
+		}
+	})
+
+	return rankedDictionaries
+}
+
+// Rank the words by their position, starting at 1. Later duplicates are ignored
+func rankedDictionary(words []string) map[string]int {
+	ranked := make(map[string]int, len(words))
+
+	for i, word := range words {
+		word = strings.ToLower(strings.TrimSpace(word))
+
+		if _, ok := ranked[word]; !ok && word != "" {
+			ranked[word] = i + 1
+		}
+	}
+
+	return ranked
+}
+
+// Length in runes of the longest word of a dictionary
+func longestWord(dictionary map[string]int) int {
+	longest := 0
+
+	for word := range dictionary {
+		if length := len([]rune(word)); length > longest {
+			longest = length
+		}
+	}
+
+	return longest
+}
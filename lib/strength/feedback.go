@@ -0,0 +1,142 @@
+package strength
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Warning and suggestions for a password that is easy to guess
+type Feedback struct {
+	Warning     string   `json:"warning"`
+	Suggestions []string `json:"suggestions"`
+}
+
+// Scores above which no feedback is given
+const MAX_FEEDBACK_SCORE = 2
+
+/*
+Feedback on the match covering the most of the password, the same
+feedback zxcvbn gives
+*/
+func feedback(score int, sequence []*Match) Feedback {
+	if len(sequence) == 0 {
+		return Feedback{
+			Suggestions: []string{
+				"Use a few words, avoid common phrases",
+				"No need for symbols, digits, or uppercase letters",
+			},
+		}
+	}
+
+	if score > MAX_FEEDBACK_SCORE {
+		return Feedback{Suggestions: []string{}}
+	}
+
+	longest := sequence[0]
+
+	for _, match := range sequence[1:] {
+		if len([]rune(match.Token)) > len([]rune(longest.Token)) {
+			longest = match
+		}
+	}
+
+	result := matchFeedback(longest, len(sequence) == 1)
+	result.Suggestions = append([]string{"Add another word or two. Uncommon words are better."}, result.Suggestions...)
+
+	return result
+}
+
+func matchFeedback(match *Match, isSoleMatch bool) Feedback {
+	switch match.Pattern {
+	case PATTERN_DICTIONARY:
+		return dictionaryFeedback(match, isSoleMatch)
+	case PATTERN_SPATIAL:
+		warning := "Short keyboard patterns are easy to guess"
+
+		if match.Turns == 1 {
+			warning = "Straight rows of keys are easy to guess"
+		}
+
+		return Feedback{
+			Warning:     warning,
+			Suggestions: []string{"Use a longer keyboard pattern with more turns"},
+		}
+	case PATTERN_REPEAT:
+		warning := `Repeats like "abcabcabc" are only slightly harder to guess than "abc"`
+
+		if len([]rune(match.BaseToken)) == 1 {
+			warning = `Repeats like "aaa" are easy to guess`
+		}
+
+		return Feedback{
+			Warning:     warning,
+			Suggestions: []string{"Avoid repeated words and characters"},
+		}
+	case PATTERN_SEQUENCE:
+		return Feedback{
+			Warning:     "Sequences like abc or 6543 are easy to guess",
+			Suggestions: []string{"Avoid sequences"},
+		}
+	case PATTERN_REGEX:
+		return Feedback{
+			Warning:     "Recent years are easy to guess",
+			Suggestions: []string{"Avoid recent years", "Avoid years that are associated with you"},
+		}
+	case PATTERN_DATE:
+		return Feedback{
+			Warning:     "Dates are often easy to guess",
+			Suggestions: []string{"Avoid dates and years that are associated with you"},
+		}
+	}
+
+	return Feedback{Suggestions: []string{}}
+}
+
+func dictionaryFeedback(match *Match, isSoleMatch bool) Feedback {
+	result := Feedback{Suggestions: []string{}}
+
+	switch match.DictionaryName {
+	case PASSWORDS:
+		if isSoleMatch && !match.L33t && !match.Reversed {
+			switch {
+			case match.Rank <= 10:
+				result.Warning = "This is a top-10 common password"
+			case match.Rank <= 100:
+				result.Warning = "This is a top-100 common password"
+			default:
+				result.Warning = "This is a very common password"
+			}
+		} else if math.Log10(match.Guesses) <= 4 {
+			result.Warning = "This is similar to a commonly used password"
+		}
+	case ENGLISH:
+		if isSoleMatch {
+			result.Warning = "A word by itself is easy to guess"
+		}
+	case SURNAMES, MALE_NAMES, FEMALE_NAMES:
+		if isSoleMatch {
+			result.Warning = "Names and surnames by themselves are easy to guess"
+		} else {
+			result.Warning = "Common names and surnames are easy to guess"
+		}
+	}
+
+	token := []rune(match.Token)
+
+	if startUpper(token) {
+		result.Suggestions = append(result.Suggestions, "Capitalization doesn't help very much")
+	} else if strings.IndexFunc(match.Token, unicode.IsLower) < 0 && strings.ToLower(match.Token) != match.Token {
+		result.Suggestions = append(result.Suggestions, "All-uppercase is almost as easy to guess as all-lowercase")
+	}
+
+	if match.Reversed && len(token) >= 4 {
+		result.Suggestions = append(result.Suggestions, "Reversed words aren't much harder to guess")
+	}
+
+	if match.L33t {
+		result.Suggestions = append(result.Suggestions, "Predictable substitutions like '@' instead of 'a' don't help very much")
+	}
+
+	return result
+}
@@ -0,0 +1,126 @@
+package strength
+
+import (
+	"strings"
+	"sync"
+)
+
+// Keyboard layouts of the spatial matching. Every row of a slanted
+// keyboard is indented one more space than the row above it
+const (
+	QWERTY = "qwerty"
+	DVORAK = "dvorak"
+	KEYPAD = "keypad"
+)
+
+const qwertyLayout = `
+` + "`~" + ` 1! 2@ 3# 4$ 5% 6^ 7& 8* 9( 0) -_ =+
+    qQ wW eE rR tT yY uU iI oO pP [{ ]} \|
+     aA sS dD fF gG hH jJ kK lL ;: '"
+      zZ xX cC vV bB nN mM ,< .> /?
+`
+
+const dvorakLayout = `
+` + "`~" + ` 1! 2@ 3# 4$ 5% 6^ 7& 8* 9( 0) [{ ]}
+    '" ,< .> pP yY fF gG cC rR lL /? =+ \|
+     aA oO eE uU iI dD hH tT nN sS -_
+      ;: qQ jJ kK xX bB mM wW vV zZ
+`
+
+const keypadLayout = `
+  / * -
+7 8 9 +
+4 5 6
+1 2 3
+  0 .
+`
+
+// Characters typed with shift on qwerty and dvorak keyboards
+const shiftedChars = "~!@#$%^&*()_+QWERTYUIOP{}|ASDFGHJKL:\"ZXCVBNM<>?"
+
+/*
+Adjacency graph of a keyboard. For every character it holds the keys around
+it in a fixed order of directions, empty where there is no key
+*/
+type keyboard struct {
+	graph         map[rune][]string
+	startingKeys  int
+	averageDegree float64
+}
+
+var (
+	keyboards     map[string]keyboard
+	keyboardsOnce sync.Once
+)
+
+func keyboardGraphs() map[string]keyboard {
+	keyboardsOnce.Do(func() {
+		keyboards = map[string]keyboard{
+			QWERTY: buildKeyboard(qwertyLayout, true),
+			DVORAK: buildKeyboard(dvorakLayout, true),
+			KEYPAD: buildKeyboard(keypadLayout, false),
+		}
+	})
+
+	return keyboards
+}
+
+// Build the adjacency graph of a layout, as in the data scripts of zxcvbn
+func buildKeyboard(layout string, slanted bool) keyboard {
+	type coord struct{ x, y int }
+
+	positions := map[coord]string{}
+	lines := strings.Split(layout, "\n")
+	tokenSize := len(strings.Fields(layout)[0])
+	unit := tokenSize + 1
+
+	for y, line := range lines {
+		slant := 0
+
+		if slanted {
+			slant = y - 1
+		}
+
+		for x := 0; x < len(line); {
+			if line[x] == ' ' {
+				x++
+				continue
+			}
+
+			positions[coord{(x - slant) / unit, y}] = line[x : x+tokenSize]
+			x += tokenSize
+		}
+	}
+
+	neighbours := func(x, y int) []coord {
+		if slanted {
+			return []coord{{x - 1, y}, {x, y - 1}, {x + 1, y - 1}, {x + 1, y}, {x, y + 1}, {x - 1, y + 1}}
+		}
+
+		return []coord{{x - 1, y}, {x - 1, y - 1}, {x, y - 1}, {x + 1, y - 1}, {x + 1, y}, {x + 1, y + 1}, {x, y + 1}, {x - 1, y + 1}}
+	}
+
+	result := keyboard{graph: map[rune][]string{}}
+	degrees := 0
+
+	for position, chars := range positions {
+		adjacent := []string{}
+
+		for _, neighbour := range neighbours(position.x, position.y) {
+			adjacent = append(adjacent, positions[neighbour])
+
+			if positions[neighbour] != "" {
+				degrees += len(chars)
+			}
+		}
+
+		for _, char := range chars {
+			result.graph[char] = adjacent
+		}
+	}
+
+	result.startingKeys = len(result.graph)
+	result.averageDegree = float64(degrees) / float64(len(result.graph))
+
+	return result
+}
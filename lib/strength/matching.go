@@ -0,0 +1,782 @@
+package strength
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Patterns of matches
+const (
+	PATTERN_DICTIONARY = "dictionary"
+	PATTERN_SPATIAL    = "spatial"
+	PATTERN_REPEAT     = "repeat"
+	PATTERN_SEQUENCE   = "sequence"
+	PATTERN_REGEX      = "regex"
+	PATTERN_DATE       = "date"
+	PATTERN_BRUTEFORCE = "bruteforce"
+)
+
+// Bounds of the years a date can have
+const (
+	DATE_MIN_YEAR = 1000
+	DATE_MAX_YEAR = 2050
+)
+
+// Largest difference of the code points of a sequence (i.e "aceg")
+const MAX_SEQUENCE_DELTA = 5
+
+// Upper bound of the l33t substitutions tried per password
+const MAX_L33T_SUBS = 100
+
+/*
+A part of a password the estimator recognized. I and J are the positions of
+the first and last rune of the token. The remaining fields depend on the pattern
+*/
+type Match struct {
+	Pattern string `json:"pattern"`
+	I       int    `json:"i"`
+	J       int    `json:"j"`
+	Token   string `json:"token"`
+
+	// dictionary
+	MatchedWord    string        `json:"matchedWord,omitempty"`
+	Rank           int           `json:"rank,omitempty"`
+	DictionaryName string        `json:"dictionaryName,omitempty"`
+	Reversed       bool          `json:"reversed,omitempty"`
+	L33t           bool          `json:"l33t,omitempty"`
+	Sub            map[rune]rune `json:"-"`
+
+	// spatial
+	Graph        string `json:"graph,omitempty"`
+	Turns        int    `json:"turns,omitempty"`
+	ShiftedCount int    `json:"shiftedCount,omitempty"`
+
+	// repeat
+	BaseToken   string  `json:"baseToken,omitempty"`
+	BaseGuesses float64 `json:"baseGuesses,omitempty"`
+	RepeatCount int     `json:"repeatCount,omitempty"`
+
+	// sequence
+	SequenceName  string `json:"sequenceName,omitempty"`
+	SequenceSpace int    `json:"sequenceSpace,omitempty"`
+	Ascending     bool   `json:"ascending,omitempty"`
+
+	// regex
+	RegexName string `json:"regexName,omitempty"`
+
+	// date
+	Separator string `json:"separator,omitempty"`
+	Year      int    `json:"year,omitempty"`
+	Month     int    `json:"month,omitempty"`
+	Day       int    `json:"day,omitempty"`
+
+	Guesses float64 `json:"guesses"`
+}
+
+// Substitutions of letters in l33t speak
+var l33tTable = map[rune][]rune{
+	'a': []rune("4@"),
+	'b': []rune("8"),
+	'c': []rune("({[<"),
+	'e': []rune("3"),
+	'g': []rune("69"),
+	'i': []rune("1!|"),
+	'l': []rune("1|7"),
+	'o': []rune("0"),
+	's': []rune("$5"),
+	't': []rune("+7"),
+	'x': []rune("%"),
+	'z': []rune("2"),
+}
+
+var recentYear = regexp.MustCompile(`19\d\d|20\d\d`)
+
+// Every match of every pattern, ordered by position
+func omnimatch(password []rune, userInputs map[string]int) []*Match {
+	ranked := map[string]map[string]int{}
+
+	for name, dictionary := range dictionaries() {
+		ranked[name] = dictionary
+	}
+
+	if len(userInputs) > 0 {
+		ranked[USER_INPUTS] = userInputs
+	}
+
+	matches := dictionaryMatch(password, ranked)
+	matches = append(matches, reverseDictionaryMatch(password, ranked)...)
+	matches = append(matches, l33tMatch(password, ranked)...)
+	matches = append(matches, spatialMatch(password)...)
+	matches = append(matches, repeatMatch(password, userInputs)...)
+	matches = append(matches, sequenceMatch(password)...)
+	matches = append(matches, regexMatch(password)...)
+	matches = append(matches, dateMatch(password)...)
+
+	sortMatches(matches)
+
+	return matches
+}
+
+func sortMatches(matches []*Match) {
+	sort.SliceStable(matches, func(a, b int) bool {
+		if matches[a].I != matches[b].I {
+			return matches[a].I < matches[b].I
+		}
+
+		return matches[a].J < matches[b].J
+	})
+}
+
+/********** DICTIONARY **********/
+
+func dictionaryMatch(password []rune, ranked map[string]map[string]int) []*Match {
+	matches := []*Match{}
+	lower := []rune(strings.ToLower(string(password)))
+
+	if len(lower) != len(password) {
+		lower = password
+	}
+
+	for name, dictionary := range ranked {
+		longest, ok := longestWords[name]
+
+		if !ok || name == USER_INPUTS {
+			longest = longestWord(dictionary)
+		}
+
+		for i := range password {
+			for j := i; j < len(password) && j-i < longest; j++ {
+				word := string(lower[i : j+1])
+
+				if rank, ok := dictionary[word]; ok {
+					matches = append(matches, &Match{
+						Pattern:        PATTERN_DICTIONARY,
+						I:              i,
+						J:              j,
+						Token:          string(password[i : j+1]),
+						MatchedWord:    word,
+						Rank:           rank,
+						DictionaryName: name,
+					})
+				}
+			}
+		}
+	}
+
+	return matches
+}
+
+func reverseDictionaryMatch(password []rune, ranked map[string]map[string]int) []*Match {
+	reversed := reverseRunes(password)
+	matches := dictionaryMatch(reversed, ranked)
+
+	for _, match := range matches {
+		match.Token = string(reverseRunes([]rune(match.Token)))
+		match.Reversed = true
+		match.I, match.J = len(password)-1-match.J, len(password)-1-match.I
+	}
+
+	return matches
+}
+
+/*
+Match dictionary words written with l33t substitutions (i.e "p4ssw0rd").
+Every combination of the substitutions of the characters in the password
+is tried, up to MAX_L33T_SUBS
+*/
+func l33tMatch(password []rune, ranked map[string]map[string]int) []*Match {
+	matches := []*Match{}
+
+	for _, sub := range l33tSubs(password) {
+		if len(sub) == 0 {
+			continue
+		}
+
+		translated := make([]rune, len(password))
+
+		for i, char := range password {
+			if letter, ok := sub[char]; ok {
+				translated[i] = letter
+			} else {
+				translated[i] = char
+			}
+		}
+
+		for _, match := range dictionaryMatch(translated, ranked) {
+			token := []rune(string(password[match.I : match.J+1]))
+
+			// Only keep matches that contain a substitution
+			if strings.ToLower(string(token)) == match.MatchedWord || len(token) <= 1 {
+				continue
+			}
+
+			used := map[rune]rune{}
+
+			for _, char := range token {
+				if letter, ok := sub[char]; ok {
+					used[char] = letter
+				}
+			}
+
+			match.Token = string(token)
+			match.L33t = true
+			match.Sub = used
+			matches = append(matches, match)
+		}
+	}
+
+	return matches
+}
+
+// Combinations of substitutions of the l33t characters in the password
+func l33tSubs(password []rune) []map[rune]rune {
+	present := map[rune]bool{}
+
+	for _, char := range password {
+		present[char] = true
+	}
+
+	candidates := map[rune][]rune{}
+	letters := []rune{}
+
+	for letter, subs := range l33tTable {
+		for _, sub := range subs {
+			if present[sub] {
+				candidates[sub] = append(candidates[sub], letter)
+			}
+		}
+	}
+
+	for sub, subLetters := range candidates {
+		sort.Slice(subLetters, func(a, b int) bool { return subLetters[a] < subLetters[b] })
+		letters = append(letters, sub)
+	}
+
+	sort.Slice(letters, func(a, b int) bool { return letters[a] < letters[b] })
+
+	subs := []map[rune]rune{{}}
+
+	for _, char := range letters {
+		next := []map[rune]rune{}
+
+		for _, sub := range subs {
+			for _, letter := range candidates[char] {
+				combined := map[rune]rune{char: letter}
+
+				for k, v := range sub {
+					combined[k] = v
+				}
+
+				next = append(next, combined)
+
+				if len(next) >= MAX_L33T_SUBS {
+					break
+				}
+			}
+
+			if len(next) >= MAX_L33T_SUBS {
+				break
+			}
+		}
+
+		subs = next
+	}
+
+	return subs
+}
+
+/********** SPATIAL **********/
+
+func spatialMatch(password []rune) []*Match {
+	matches := []*Match{}
+
+	for name, keyboard := range keyboardGraphs() {
+		matches = append(matches, spatialMatchKeyboard(password, name, keyboard)...)
+	}
+
+	return matches
+}
+
+// Runs of at least 3 adjacent keys. Turns counts the changes of direction
+func spatialMatchKeyboard(password []rune, name string, keyboard keyboard) []*Match {
+	matches := []*Match{}
+
+	for i := 0; i < len(password)-1; {
+		j := i + 1
+		lastDirection := -1
+		turns := 0
+		shifted := 0
+
+		if name != KEYPAD && strings.ContainsRune(shiftedChars, password[i]) {
+			shifted = 1
+		}
+
+		for {
+			found := false
+
+			if j < len(password) {
+				for direction, adjacent := range keyboard.graph[password[j-1]] {
+					index := strings.IndexRune(adjacent, password[j])
+
+					if adjacent == "" || index < 0 {
+						continue
+					}
+
+					found = true
+
+					if index > 0 {
+						shifted++
+					}
+
+					if lastDirection != direction {
+						turns++
+						lastDirection = direction
+					}
+
+					break
+				}
+			}
+
+			if found {
+				j++
+				continue
+			}
+
+			if j-i > 2 {
+				matches = append(matches, &Match{
+					Pattern:      PATTERN_SPATIAL,
+					I:            i,
+					J:            j - 1,
+					Token:        string(password[i:j]),
+					Graph:        name,
+					Turns:        turns,
+					ShiftedCount: shifted,
+				})
+			}
+
+			i = j
+			break
+		}
+	}
+
+	return matches
+}
+
+/********** REPEAT **********/
+
+/*
+Repeated tokens (i.e "aaa" or "abcabc"). Like the regular expressions of
+zxcvbn the longest repetition at the leftmost position wins and the base
+token is the shortest unit it repeats
+*/
+func repeatMatch(password []rune, userInputs map[string]int) []*Match {
+	matches := []*Match{}
+
+	for i := 0; i < len(password); {
+		start, end := -1, -1
+
+		for s := i; s < len(password) && start < 0; s++ {
+			for length := (len(password) - s) / 2; length >= 1; length-- {
+				if repeats(password, s, length) > 1 {
+					total := length * repeats(password, s, length)
+
+					if s+total > end || start < 0 {
+						start, end = s, s+total
+					}
+				}
+			}
+		}
+
+		if start < 0 {
+			break
+		}
+
+		token := password[start:end]
+		base := shortestUnit(token)
+		baseMatches := omnimatch(base, userInputs)
+		baseGuesses := mostGuessableMatchSequence(base, baseMatches, false).Guesses
+
+		matches = append(matches, &Match{
+			Pattern:     PATTERN_REPEAT,
+			I:           start,
+			J:           end - 1,
+			Token:       string(token),
+			BaseToken:   string(base),
+			BaseGuesses: baseGuesses,
+			RepeatCount: len(token) / len(base),
+		})
+
+		i = end
+	}
+
+	return matches
+}
+
+// How often the unit at start with the given length repeats back to back
+func repeats(password []rune, start, length int) int {
+	count := 1
+
+	for next := start + length; next+length <= len(password); next += length {
+		if string(password[next:next+length]) != string(password[start:start+length]) {
+			break
+		}
+
+		count++
+	}
+
+	return count
+}
+
+func shortestUnit(token []rune) []rune {
+	for length := 1; length <= len(token)/2; length++ {
+		if len(token)%length == 0 && repeats(token, 0, length)*length == len(token) {
+			return token[:length]
+		}
+	}
+
+	return token
+}
+
+/********** SEQUENCE **********/
+
+// Runs of characters with a constant difference of their code points (i.e "abc", "9753")
+func sequenceMatch(password []rune) []*Match {
+	matches := []*Match{}
+
+	if len(password) <= 1 {
+		return matches
+	}
+
+	update := func(i, j, delta int) {
+		abs := delta
+
+		if abs < 0 {
+			abs = -abs
+		}
+
+		if j-i > 1 || abs == 1 {
+			if abs > 0 && abs <= MAX_SEQUENCE_DELTA {
+				token := string(password[i : j+1])
+				name, space := "unicode", 26
+
+				switch {
+				case isAll(token, unicode.IsLower) && isASCII(token):
+					name, space = "lower", 26
+				case isAll(token, unicode.IsUpper) && isASCII(token):
+					name, space = "upper", 26
+				case isAll(token, unicode.IsDigit):
+					name, space = "digits", 10
+				}
+
+				matches = append(matches, &Match{
+					Pattern:       PATTERN_SEQUENCE,
+					I:             i,
+					J:             j,
+					Token:         token,
+					SequenceName:  name,
+					SequenceSpace: space,
+					Ascending:     delta > 0,
+				})
+			}
+		}
+	}
+
+	i := 0
+	lastDelta := math.MinInt32
+
+	for k := 1; k < len(password); k++ {
+		delta := int(password[k]) - int(password[k-1])
+
+		if lastDelta == math.MinInt32 {
+			lastDelta = delta
+		}
+
+		if delta == lastDelta {
+			continue
+		}
+
+		update(i, k-1, lastDelta)
+		i = k - 1
+		lastDelta = delta
+	}
+
+	update(i, len(password)-1, lastDelta)
+
+	return matches
+}
+
+/********** REGEX **********/
+
+func regexMatch(password []rune) []*Match {
+	matches := []*Match{}
+	value := string(password)
+
+	for _, location := range recentYear.FindAllStringIndex(value, -1) {
+		i := len([]rune(value[:location[0]]))
+		token := value[location[0]:location[1]]
+
+		matches = append(matches, &Match{
+			Pattern:   PATTERN_REGEX,
+			I:         i,
+			J:         i + len([]rune(token)) - 1,
+			Token:     token,
+			RegexName: "recent_year",
+		})
+	}
+
+	return matches
+}
+
+/********** DATE **********/
+
+// Ways to split dates without separators into day, month and year by their length
+var dateSplits = map[int][][2]int{
+	4: {{1, 2}, {2, 3}},
+	5: {{1, 3}, {2, 3}},
+	6: {{1, 2}, {2, 4}, {4, 5}},
+	7: {{1, 3}, {2, 3}, {4, 5}, {4, 6}},
+	8: {{2, 4}, {4, 6}},
+}
+
+const dateSeparators = " /\\_.-"
+
+/*
+Dates with or without separators (i.e "13.5.1991", "1991-05-13", "130591").
+Of the ways to read a date the one closest to the current year wins. Dates
+inside of other dates are dropped
+*/
+func dateMatch(password []rune) []*Match {
+	matches := []*Match{}
+	referenceYear := currentYear()
+
+	for i := 0; i <= len(password)-4; i++ {
+		for j := i + 3; j <= i+7 && j < len(password); j++ {
+			token := string(password[i : j+1])
+
+			if !isAll(token, unicode.IsDigit) || !isASCII(token) {
+				continue
+			}
+
+			var best *dmy
+
+			for _, split := range dateSplits[len(token)] {
+				candidate := mapIntsToDmy([3]int{atoi(token[:split[0]]), atoi(token[split[0]:split[1]]), atoi(token[split[1]:])})
+
+				if candidate != nil && (best == nil || distance(candidate.year, referenceYear) < distance(best.year, referenceYear)) {
+					best = candidate
+				}
+			}
+
+			if best != nil {
+				matches = append(matches, dateMatchOf(password, i, j, "", best))
+			}
+		}
+	}
+
+	for i := 0; i <= len(password)-6; i++ {
+		for j := i + 5; j <= i+9 && j < len(password); j++ {
+			parts, separator, ok := splitDate(string(password[i : j+1]))
+
+			if !ok {
+				continue
+			}
+
+			if candidate := mapIntsToDmy(parts); candidate != nil {
+				matches = append(matches, dateMatchOf(password, i, j, separator, candidate))
+			}
+		}
+	}
+
+	// Drop dates that are part of other dates (i.e "1991" of "13.5.1991")
+	result := []*Match{}
+
+	for _, match := range matches {
+		contained := false
+
+		for _, other := range matches {
+			if match != other && other.I <= match.I && other.J >= match.J {
+				contained = true
+				break
+			}
+		}
+
+		if !contained {
+			result = append(result, match)
+		}
+	}
+
+	return result
+}
+
+type dmy struct {
+	day, month, year int
+}
+
+func dateMatchOf(password []rune, i, j int, separator string, date *dmy) *Match {
+	return &Match{
+		Pattern:   PATTERN_DATE,
+		I:         i,
+		J:         j,
+		Token:     string(password[i : j+1]),
+		Separator: separator,
+		Year:      date.year,
+		Month:     date.month,
+		Day:       date.day,
+	}
+}
+
+// Split a date of the form 1-4 digits, separator, 1-2 digits, the same separator, 1-4 digits
+func splitDate(token string) ([3]int, string, bool) {
+	first := strings.IndexAny(token, dateSeparators)
+
+	if first < 1 || first > 4 {
+		return [3]int{}, "", false
+	}
+
+	separator := token[first : first+1]
+	parts := strings.Split(token, separator)
+
+	if len(parts) != 3 || len(parts[1]) < 1 || len(parts[1]) > 2 || len(parts[2]) < 1 || len(parts[2]) > 4 {
+		return [3]int{}, "", false
+	}
+
+	for _, part := range parts {
+		if !isAll(part, unicode.IsDigit) || !isASCII(part) {
+			return [3]int{}, "", false
+		}
+	}
+
+	return [3]int{atoi(parts[0]), atoi(parts[1]), atoi(parts[2])}, separator, true
+}
+
+func mapIntsToDmy(ints [3]int) *dmy {
+	if ints[1] > 31 || ints[1] <= 0 {
+		return nil
+	}
+
+	over12, over31, under1 := 0, 0, 0
+
+	for _, value := range ints {
+		if (value > 99 && value < DATE_MIN_YEAR) || value > DATE_MAX_YEAR {
+			return nil
+		}
+
+		if value > 31 {
+			over31++
+		}
+
+		if value > 12 {
+			over12++
+		}
+
+		if value <= 0 {
+			under1++
+		}
+	}
+
+	if over31 >= 2 || over12 == 3 || under1 >= 2 {
+		return nil
+	}
+
+	splits := []struct {
+		year int
+		rest [2]int
+	}{
+		{ints[2], [2]int{ints[0], ints[1]}},
+		{ints[0], [2]int{ints[1], ints[2]}},
+	}
+
+	for _, split := range splits {
+		if split.year >= DATE_MIN_YEAR && split.year <= DATE_MAX_YEAR {
+			day, month, ok := mapIntsToDm(split.rest)
+
+			if !ok {
+				return nil
+			}
+
+			return &dmy{day: day, month: month, year: split.year}
+		}
+	}
+
+	for _, split := range splits {
+		if day, month, ok := mapIntsToDm(split.rest); ok {
+			return &dmy{day: day, month: month, year: twoToFourDigitYear(split.year)}
+		}
+	}
+
+	return nil
+}
+
+func mapIntsToDm(ints [2]int) (int, int, bool) {
+	for _, pair := range [][2]int{{ints[0], ints[1]}, {ints[1], ints[0]}} {
+		if pair[0] >= 1 && pair[0] <= 31 && pair[1] >= 1 && pair[1] <= 12 {
+			return pair[0], pair[1], true
+		}
+	}
+
+	return 0, 0, false
+}
+
+func twoToFourDigitYear(year int) int {
+	switch {
+	case year > 99:
+		return year
+	case year > 50:
+		return 1900 + year
+	}
+
+	return 2000 + year
+}
+
+/********** HELPERS **********/
+
+func reverseRunes(value []rune) []rune {
+	reversed := make([]rune, len(value))
+
+	for i, char := range value {
+		reversed[len(value)-1-i] = char
+	}
+
+	return reversed
+}
+
+func isAll(value string, predicate func(rune) bool) bool {
+	if value == "" {
+		return false
+	}
+
+	for _, char := range value {
+		if !predicate(char) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isASCII(value string) bool {
+	for _, char := range value {
+		if char > unicode.MaxASCII {
+			return false
+		}
+	}
+
+	return true
+}
+
+func atoi(value string) int {
+	number, _ := strconv.Atoi(value)
+	return number
+}
+
+func distance(a, b int) int {
+	if a > b {
+		return a - b
+	}
+
+	return b - a
+}
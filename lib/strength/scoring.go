@@ -0,0 +1,411 @@
+package strength
+
+import (
+	"math"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Guesses of a single character when brute forced
+const BRUTEFORCE_CARDINALITY = 10
+
+// Lower bounds of the guesses of a match shorter than the password
+const (
+	MIN_SUBMATCH_GUESSES_SINGLE_CHAR = 10
+	MIN_SUBMATCH_GUESSES_MULTI_CHAR  = 50
+)
+
+// Lower bound of the years between the year of a date and the current year
+const MIN_YEAR_SPACE = 20
+
+// Matches covering a password and the guesses needed for them
+type matchSequence struct {
+	Guesses  float64
+	Sequence []*Match
+}
+
+/*
+The matches that cover the password with the fewest guesses. Characters no
+match covers are filled with bruteforce matches. When excludeAdditive is set
+the penalty for the number of matches is left out, as for the base of repeats
+
+@see - https://github.com/dropbox/zxcvbn/blob/master/src/scoring.coffee
+*/
+func mostGuessableMatchSequence(password []rune, matches []*Match, excludeAdditive bool) matchSequence {
+	n := len(password)
+
+	if n == 0 {
+		return matchSequence{Guesses: 1, Sequence: []*Match{}}
+	}
+
+	byEnd := make([][]*Match, n)
+
+	for _, match := range matches {
+		byEnd[match.J] = append(byEnd[match.J], match)
+	}
+
+	// Best sequences ending at position k by their length
+	type step struct {
+		match *Match
+		pi    float64
+		g     float64
+	}
+
+	best := make([]map[int]step, n)
+
+	for k := range best {
+		best[k] = map[int]step{}
+	}
+
+	update := func(match *Match, length int) {
+		k := match.J
+		pi := estimateGuesses(match, n)
+
+		if length > 1 {
+			pi *= best[match.I-1][length-1].pi
+		}
+
+		g := factorial(length) * pi
+
+		if !excludeAdditive {
+			g += math.Pow(MIN_GUESSES_BEFORE_GROWING_SEQUENCE, float64(length-1))
+		}
+
+		for competing, other := range best[k] {
+			if competing > length {
+				continue
+			}
+
+			if other.g <= g {
+				return
+			}
+		}
+
+		best[k][length] = step{match: match, pi: pi, g: g}
+	}
+
+	bruteforceUpdate := func(k int) {
+		update(bruteforceMatch(password, 0, k), 1)
+
+		for i := 1; i <= k; i++ {
+			match := bruteforceMatch(password, i, k)
+
+			for length, last := range best[i-1] {
+				// Consecutive bruteforce matches are never better than a single one
+				if last.match.Pattern == PATTERN_BRUTEFORCE {
+					continue
+				}
+
+				update(match, length+1)
+			}
+		}
+	}
+
+	for k := 0; k < n; k++ {
+		for _, match := range byEnd[k] {
+			if match.I > 0 {
+				for length := range best[match.I-1] {
+					update(match, length+1)
+				}
+			} else {
+				update(match, 1)
+			}
+		}
+
+		bruteforceUpdate(k)
+	}
+
+	// Unwind the best sequence from the end of the password
+	optimalLength := 0
+	guesses := math.Inf(1)
+
+	for length, last := range best[n-1] {
+		if last.g < guesses || (last.g == guesses && length < optimalLength) {
+			optimalLength = length
+			guesses = last.g
+		}
+	}
+
+	sequence := make([]*Match, optimalLength)
+
+	for k, length := n-1, optimalLength; length > 0; length-- {
+		match := best[k][length].match
+		sequence[length-1] = match
+		k = match.I - 1
+	}
+
+	return matchSequence{Guesses: guesses, Sequence: sequence}
+}
+
+// Additive penalty per extra match of a sequence
+const MIN_GUESSES_BEFORE_GROWING_SEQUENCE = 10000
+
+func bruteforceMatch(password []rune, i, j int) *Match {
+	return &Match{
+		Pattern: PATTERN_BRUTEFORCE,
+		I:       i,
+		J:       j,
+		Token:   string(password[i : j+1]),
+	}
+}
+
+// Guesses of a match, cached on the match
+func estimateGuesses(match *Match, passwordLength int) float64 {
+	if match.Guesses != 0 {
+		return match.Guesses
+	}
+
+	length := len([]rune(match.Token))
+	minGuesses := 1.0
+
+	if length < passwordLength {
+		if length == 1 {
+			minGuesses = MIN_SUBMATCH_GUESSES_SINGLE_CHAR
+		} else {
+			minGuesses = MIN_SUBMATCH_GUESSES_MULTI_CHAR
+		}
+	}
+
+	var guesses float64
+
+	switch match.Pattern {
+	case PATTERN_BRUTEFORCE:
+		guesses = bruteforceGuesses(match)
+	case PATTERN_DICTIONARY:
+		guesses = dictionaryGuesses(match)
+	case PATTERN_SPATIAL:
+		guesses = spatialGuesses(match)
+	case PATTERN_REPEAT:
+		guesses = match.BaseGuesses * float64(match.RepeatCount)
+	case PATTERN_SEQUENCE:
+		guesses = sequenceGuesses(match)
+	case PATTERN_REGEX:
+		guesses = math.Max(float64(distance(atoi(match.Token), currentYear())), MIN_YEAR_SPACE)
+	case PATTERN_DATE:
+		guesses = dateGuesses(match)
+	}
+
+	match.Guesses = math.Max(guesses, minGuesses)
+
+	return match.Guesses
+}
+
+func bruteforceGuesses(match *Match) float64 {
+	length := len([]rune(match.Token))
+	guesses := math.Pow(BRUTEFORCE_CARDINALITY, float64(length))
+
+	if math.IsInf(guesses, 1) {
+		guesses = math.MaxFloat64
+	}
+
+	// Bruteforce matches must be guessed harder than any other match of the same length
+	minimum := float64(MIN_SUBMATCH_GUESSES_MULTI_CHAR + 1)
+
+	if length == 1 {
+		minimum = MIN_SUBMATCH_GUESSES_SINGLE_CHAR + 1
+	}
+
+	return math.Max(guesses, minimum)
+}
+
+func dictionaryGuesses(match *Match) float64 {
+	guesses := float64(match.Rank) * uppercaseVariations(match.Token) * l33tVariations(match)
+
+	if match.Reversed {
+		guesses *= 2
+	}
+
+	return guesses
+}
+
+var (
+	startUpper = func(token []rune) bool { return unicode.IsUpper(token[0]) && isLower(token[1:]) }
+	endUpper   = func(token []rune) bool { return isLower(token[:len(token)-1]) && unicode.IsUpper(token[len(token)-1]) }
+)
+
+// Ways the upper case letters of a token could be placed
+func uppercaseVariations(token string) float64 {
+	runes := []rune(token)
+
+	if isLower(runes) {
+		return 1
+	}
+
+	// Capitalized, all caps and upper case at the end are the most common variations
+	if startUpper(runes) || endUpper(runes) || strings.IndexFunc(token, unicode.IsLower) < 0 {
+		return 2
+	}
+
+	upper, lower := 0, 0
+
+	for _, char := range runes {
+		if unicode.IsUpper(char) {
+			upper++
+		} else if unicode.IsLower(char) {
+			lower++
+		}
+	}
+
+	variations := 0.0
+
+	for i := 1; i <= minInt(upper, lower); i++ {
+		variations += nCk(upper+lower, i)
+	}
+
+	return variations
+}
+
+func l33tVariations(match *Match) float64 {
+	if !match.L33t {
+		return 1
+	}
+
+	variations := 1.0
+	token := []rune(strings.ToLower(match.Token))
+
+	for subbed, unsubbed := range match.Sub {
+		s, u := 0, 0
+
+		for _, char := range token {
+			if char == subbed {
+				s++
+			} else if char == unsubbed {
+				u++
+			}
+		}
+
+		if s == 0 || u == 0 {
+			// The token is entirely subbed or unsubbed, as in "p4ssword"
+			variations *= 2
+			continue
+		}
+
+		possibilities := 0.0
+
+		for i := 1; i <= minInt(s, u); i++ {
+			possibilities += nCk(s+u, i)
+		}
+
+		variations *= possibilities
+	}
+
+	return variations
+}
+
+func spatialGuesses(match *Match) float64 {
+	keyboard := keyboardGraphs()[match.Graph]
+	s := float64(keyboard.startingKeys)
+	d := keyboard.averageDegree
+	length := len([]rune(match.Token))
+	guesses := 0.0
+
+	// Every possible path of the length with up to the number of turns
+	for i := 2; i <= length; i++ {
+		for j := 1; j <= minInt(match.Turns, i-1); j++ {
+			guesses += nCk(i-1, j-1) * s * math.Pow(d, float64(j))
+		}
+	}
+
+	if match.ShiftedCount > 0 {
+		shifted := match.ShiftedCount
+		unshifted := length - shifted
+
+		if unshifted == 0 {
+			guesses *= 2
+		} else {
+			variations := 0.0
+
+			for i := 1; i <= minInt(shifted, unshifted); i++ {
+				variations += nCk(shifted+unshifted, i)
+			}
+
+			guesses *= variations
+		}
+	}
+
+	return guesses
+}
+
+func sequenceGuesses(match *Match) float64 {
+	first := []rune(match.Token)[0]
+	base := 26.0
+
+	switch {
+	// Obvious starts like "a", "z", "1" or "9"
+	case strings.ContainsRune("aAzZ019", first):
+		base = 4
+	case unicode.IsDigit(first):
+		base = 10
+	}
+
+	if !match.Ascending {
+		base *= 2
+	}
+
+	return base * float64(len([]rune(match.Token)))
+}
+
+func dateGuesses(match *Match) float64 {
+	yearSpace := math.Max(float64(distance(match.Year, currentYear())), MIN_YEAR_SPACE)
+	guesses := yearSpace * 365
+
+	if match.Separator != "" {
+		guesses *= 4
+	}
+
+	return guesses
+}
+
+func currentYear() int {
+	return time.Now().UTC().Year()
+}
+
+func isLower(token []rune) bool {
+	for _, char := range token {
+		if unicode.IsUpper(char) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Binomial coefficient of n choose k
+func nCk(n, k int) float64 {
+	if k > n {
+		return 0
+	}
+
+	if k == 0 {
+		return 1
+	}
+
+	result := 1.0
+
+	for d := 1; d <= k; d++ {
+		result *= float64(n)
+		result /= float64(d)
+		n--
+	}
+
+	return result
+}
+
+func factorial(n int) float64 {
+	result := 1.0
+
+	for i := 2; i <= n; i++ {
+		result *= float64(i)
+	}
+
+	return result
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
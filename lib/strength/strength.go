@@ -0,0 +1,136 @@
+package strength
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Guesses below which a password has the score of the index
+var scoreThresholds = []float64{1e3 + 5, 1e6 + 5, 1e8 + 5, 1e10 + 5}
+
+// Attack scenarios and the guesses per second of each
+var crackScenarios = []struct {
+	name string
+	rate float64
+}{
+	{"onlineThrottling100PerHour", 100.0 / 3600},
+	{"onlineNoThrottling10PerSecond", 10},
+	{"offlineSlowHashing1e4PerSecond", 1e4},
+	{"offlineFastHashing1e10PerSecond", 1e10},
+}
+
+/*
+Strength of a password. Score goes from 0 (too guessable) to 4 (very
+unguessable). The crack times are keyed by the attack scenario
+*/
+type Result struct {
+	Score             int                `json:"score"`
+	Guesses           float64            `json:"guesses"`
+	GuessesLog10      float64            `json:"guessesLog10"`
+	CrackTimesSeconds map[string]float64 `json:"crackTimesSeconds"`
+	CrackTimesDisplay map[string]string  `json:"crackTimesDisplay"`
+	Feedback          Feedback           `json:"feedback"`
+	CalcTime          int64              `json:"calcTime"`
+	Sequence          []*Match           `json:"-"`
+}
+
+/*
+Estimate the strength of a password the way zxcvbn does. User inputs are
+words the password should not be based on, like the name or email of the user
+
+@see - https://github.com/dropbox/zxcvbn
+*/
+func Estimate(password string, userInputs []string) Result {
+	start := time.Now()
+	runes := []rune(password)
+
+	sanitized := []string{}
+
+	for _, input := range userInputs {
+		sanitized = append(sanitized, strings.ToLower(input))
+	}
+
+	matches := omnimatch(runes, rankedDictionary(sanitized))
+	sequence := mostGuessableMatchSequence(runes, matches, false)
+	score := guessesToScore(sequence.Guesses)
+
+	result := Result{
+		Score:             score,
+		Guesses:           sequence.Guesses,
+		GuessesLog10:      math.Log10(sequence.Guesses),
+		CrackTimesSeconds: map[string]float64{},
+		CrackTimesDisplay: map[string]string{},
+		Feedback:          feedback(score, sequence.Sequence),
+		Sequence:          sequence.Sequence,
+	}
+
+	for _, scenario := range crackScenarios {
+		seconds := sequence.Guesses / scenario.rate
+		result.CrackTimesSeconds[scenario.name] = seconds
+		result.CrackTimesDisplay[scenario.name] = displayTime(seconds)
+	}
+
+	result.CalcTime = time.Since(start).Milliseconds()
+
+	return result
+}
+
+func guessesToScore(guesses float64) int {
+	for score, threshold := range scoreThresholds {
+		if guesses < threshold {
+			return score
+		}
+	}
+
+	return len(scoreThresholds)
+}
+
+// Human readable crack time, from "less than a second" to "centuries"
+func displayTime(seconds float64) string {
+	const (
+		minute  = 60.0
+		hour    = minute * 60
+		day     = hour * 24
+		month   = day * 31
+		year    = month * 12
+		century = year * 100
+	)
+
+	units := []struct {
+		name    string
+		seconds float64
+	}{
+		{"second", 1},
+		{"minute", minute},
+		{"hour", hour},
+		{"day", day},
+		{"month", month},
+		{"year", year},
+	}
+
+	if seconds < 1 {
+		return "less than a second"
+	}
+
+	if seconds >= century {
+		return "centuries"
+	}
+
+	unit := units[0]
+
+	for _, candidate := range units {
+		if seconds >= candidate.seconds {
+			unit = candidate
+		}
+	}
+
+	amount := int64(math.Round(seconds / unit.seconds))
+
+	if amount == 1 {
+		return fmt.Sprintf("1 %s", unit.name)
+	}
+
+	return fmt.Sprintf("%d %ss", amount, unit.name)
+}
@@ -0,0 +1,141 @@
+package strength
+
+import (
+	"testing"
+)
+
+func hasMatch(sequence []*Match, pattern, token string) bool {
+	for _, match := range sequence {
+		if match.Pattern == pattern && match.Token == token {
+			return true
+		}
+	}
+
+	return false
+}
+
+/***** Estimate *****/
+
+func TestEstimateScores(t *testing.T) {
+	tests := []struct {
+		password string
+		score    int
+	}{
+		{"password", 0},
+		{"P@ssw0rd", 0},
+		{"qwerty123", 1},
+		{"13.5.1991", 1},
+		{"correcthorsebatterystaple", 4},
+		{"x7$Kq!vR2#pLmZ9w", 4},
+	}
+
+	for _, test := range tests {
+		actual := Estimate(test.password, nil)
+
+		if actual.Score != test.score {
+			t.Errorf("FAILED - TestEstimateScores | Password: %s | Actual: %d | Expected: %d", test.password, actual.Score, test.score)
+		}
+	}
+}
+
+func TestEstimatePatterns(t *testing.T) {
+	tests := []struct {
+		password string
+		pattern  string
+		token    string
+	}{
+		{"P@ssw0rd", PATTERN_DICTIONARY, "P@ssw0rd"},
+		{"drowssap", PATTERN_DICTIONARY, "drowssap"},
+		{"aaaaaaaa", PATTERN_REPEAT, "aaaaaaaa"},
+		{"abcdefg", PATTERN_SEQUENCE, "abcdefg"},
+		{"1991", PATTERN_REGEX, "1991"},
+		{"13.5.1991", PATTERN_DATE, "13.5.1991"},
+		{"mju7ygv", PATTERN_SPATIAL, "mju7ygv"},
+	}
+
+	for _, test := range tests {
+		actual := Estimate(test.password, nil)
+
+		if !hasMatch(actual.Sequence, test.pattern, test.token) {
+			t.Errorf("FAILED - TestEstimatePatterns | Password: %s | Expected: a %s match of %s", test.password, test.pattern, test.token)
+		}
+	}
+}
+
+func TestEstimateUserInputs(t *testing.T) {
+	without := Estimate("zaphodbeeblebrox", nil)
+	with := Estimate("zaphodbeeblebrox", []string{"ZaphodBeeblebrox"})
+
+	if with.Guesses >= without.Guesses || !hasMatch(with.Sequence, PATTERN_DICTIONARY, "zaphodbeeblebrox") {
+		t.Errorf("FAILED - TestEstimateUserInputs | Actual: %v | Expected: less than %v", with.Guesses, without.Guesses)
+	}
+}
+
+func TestEstimateFeedback(t *testing.T) {
+	actual := Estimate("password", nil)
+	expected := "This is a top-10 common password"
+
+	if actual.Feedback.Warning != expected {
+		t.Errorf("FAILED - TestEstimateFeedback | Actual: %s | Expected: %s", actual.Feedback.Warning, expected)
+	}
+
+	if strong := Estimate("correcthorsebatterystaple", nil); strong.Feedback.Warning != "" || len(strong.Feedback.Suggestions) != 0 {
+		t.Errorf("FAILED - TestEstimateFeedback | Actual: %+v | Expected: no feedback", strong.Feedback)
+	}
+}
+
+func TestEstimateEmpty(t *testing.T) {
+	actual := Estimate("", nil)
+
+	if actual.Score != 0 || actual.Guesses != 1 || len(actual.Feedback.Suggestions) != 2 {
+		t.Errorf("FAILED - TestEstimateEmpty | Actual: %+v | Expected: a score of 0 with the default suggestions", actual)
+	}
+}
+
+/***** Scoring *****/
+
+func TestDisplayTime(t *testing.T) {
+	tests := map[float64]string{
+		0.5:   "less than a second",
+		1:     "1 second",
+		90:    "2 minutes",
+		7200:  "2 hours",
+		86400: "1 day",
+		1e8:   "3 years",
+		1e10:  "centuries",
+	}
+
+	for seconds, expected := range tests {
+		if actual := displayTime(seconds); actual != expected {
+			t.Errorf("FAILED - TestDisplayTime | Seconds: %v | Actual: %s | Expected: %s", seconds, actual, expected)
+		}
+	}
+}
+
+func TestUppercaseVariations(t *testing.T) {
+	tests := map[string]float64{
+		"password": 1,
+		"Password": 2,
+		"passworD": 2,
+		"PASSWORD": 2,
+		"PaSsword": 28 + 8,
+	}
+
+	for token, expected := range tests {
+		if actual := uppercaseVariations(token); actual != expected {
+			t.Errorf("FAILED - TestUppercaseVariations | Token: %s | Actual: %v | Expected: %v", token, actual, expected)
+		}
+	}
+}
+
+func TestKeyboardGraphs(t *testing.T) {
+	qwerty := keyboardGraphs()[QWERTY]
+
+	if qwerty.startingKeys != 94 || len(qwerty.graph['q']) != 6 || qwerty.graph['q'][3] != "wW" {
+		t.Errorf("FAILED - TestKeyboardGraphs | Actual: %d keys, q: %v | Expected: 94 keys, w right of q", qwerty.startingKeys, qwerty.graph['q'])
+	}
+
+	if keypad := keyboardGraphs()[KEYPAD]; keypad.startingKeys != 15 {
+		t.Errorf("FAILED - TestKeyboardGraphs | Actual: %d | Expected: %d", keypad.startingKeys, 15)
+	}
+}
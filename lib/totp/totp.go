@@ -0,0 +1,304 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Hash algorithms of the HMAC
+const (
+	ALGORITHM_SHA1   = "SHA1"
+	ALGORITHM_SHA256 = "SHA256"
+	ALGORITHM_SHA512 = "SHA512"
+)
+
+// Kinds of codes. Steam codes are 5 characters of the Steam alphabet
+const (
+	TYPE_TOTP  = "totp"
+	TYPE_STEAM = "steam"
+)
+
+// Defaults of an otpauth URI without the parameters
+const (
+	DEFAULT_ALGORITHM = ALGORITHM_SHA1
+	DEFAULT_DIGITS    = 6
+	DEFAULT_PERIOD    = 30
+)
+
+const (
+	STEAM_ALPHABET = "23456789BCDFGHJKMNPQRTVWXY"
+	STEAM_DIGITS   = 5
+)
+
+// Shortest secret accepted, 80 bits as recommended by RFC 4226
+const MIN_SECRET_BYTES = 10
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+/*
+The key of an authenticator, as stored in the TOTP field of a login item.
+Keys are written as otpauth URIs
+
+@see - https://github.com/google/google-authenticator/wiki/Key-Uri-Format
+*/
+type Key struct {
+	Type      string
+	Issuer    string
+	Account   string
+	Secret    []byte
+	Algorithm string
+	Digits    int
+	Period    int
+}
+
+/*
+Parse the TOTP field of an item. Accepts otpauth URIs (otpauth://totp/ and
+otpauth://steam/ or encoder=steam), steam:// URIs and bare base32 secrets
+*/
+func Parse(value string) (Key, error) {
+	value = strings.TrimSpace(value)
+	key := Key{Type: TYPE_TOTP, Algorithm: DEFAULT_ALGORITHM, Digits: DEFAULT_DIGITS, Period: DEFAULT_PERIOD}
+
+	switch {
+	case strings.HasPrefix(strings.ToLower(value), "steam://"):
+		key.Type = TYPE_STEAM
+		key.Digits = STEAM_DIGITS
+		return key, key.setSecret(value[len("steam://"):])
+	case !strings.HasPrefix(strings.ToLower(value), "otpauth://"):
+		return key, key.setSecret(value)
+	}
+
+	uri, err := url.Parse(value)
+
+	if err != nil {
+		return key, errors.New("TOTP must be an otpauth URI or a base32 secret")
+	}
+
+	query := uri.Query()
+
+	switch strings.ToLower(uri.Host) {
+	case TYPE_TOTP:
+	case TYPE_STEAM:
+		key.Type = TYPE_STEAM
+	default:
+		return key, fmt.Errorf("Unsupported otpauth type %s", uri.Host)
+	}
+
+	if strings.EqualFold(query.Get("encoder"), TYPE_STEAM) {
+		key.Type = TYPE_STEAM
+	}
+
+	label := strings.TrimPrefix(uri.Path, "/")
+
+	if parts := strings.SplitN(label, ":", 2); len(parts) == 2 {
+		key.Issuer = strings.TrimSpace(parts[0])
+		key.Account = strings.TrimSpace(parts[1])
+	} else {
+		key.Account = strings.TrimSpace(label)
+	}
+
+	if issuer := query.Get("issuer"); issuer != "" {
+		key.Issuer = issuer
+	}
+
+	if algorithm := query.Get("algorithm"); algorithm != "" {
+		key.Algorithm = strings.ToUpper(strings.ReplaceAll(algorithm, "-", ""))
+	}
+
+	if digits := query.Get("digits"); digits != "" {
+		if key.Digits, err = strconv.Atoi(digits); err != nil {
+			return key, errors.New("Digits must be a number")
+		}
+	}
+
+	if period := query.Get("period"); period != "" {
+		if key.Period, err = strconv.Atoi(period); err != nil {
+			return key, errors.New("Period must be a number")
+		}
+	}
+
+	if key.Type == TYPE_STEAM {
+		key.Digits = STEAM_DIGITS
+	}
+
+	if err := key.setSecret(query.Get("secret")); err != nil {
+		return key, err
+	}
+
+	return key, key.Validate()
+}
+
+func (key *Key) setSecret(secret string) error {
+	secret = strings.ToUpper(strings.Map(func(r rune) rune {
+		if r == ' ' || r == '-' || r == '=' {
+			return -1
+		}
+
+		return r
+	}, secret))
+
+	decoded, err := encoding.DecodeString(secret)
+
+	if err != nil || secret == "" {
+		return errors.New("Secret must be base32 encoded")
+	}
+
+	if len(decoded) < MIN_SECRET_BYTES {
+		return fmt.Errorf("Secret must be at least %d bytes", MIN_SECRET_BYTES)
+	}
+
+	key.Secret = decoded
+
+	return nil
+}
+
+// Check the parameters are supported: SHA1, SHA256 or SHA512, 6 or 8 digits and a period of 30 or 60 seconds
+func (key Key) Validate() error {
+	if key.Algorithm != ALGORITHM_SHA1 && key.Algorithm != ALGORITHM_SHA256 && key.Algorithm != ALGORITHM_SHA512 {
+		return fmt.Errorf("Algorithm must be one of %s, %s or %s", ALGORITHM_SHA1, ALGORITHM_SHA256, ALGORITHM_SHA512)
+	}
+
+	if key.Type == TYPE_STEAM && key.Digits != STEAM_DIGITS {
+		return fmt.Errorf("Steam codes have %d characters", STEAM_DIGITS)
+	}
+
+	if key.Type == TYPE_TOTP && key.Digits != 6 && key.Digits != 8 {
+		return errors.New("Digits must be 6 or 8")
+	}
+
+	if key.Period != 30 && key.Period != 60 {
+		return errors.New("Period must be 30 or 60 seconds")
+	}
+
+	if len(key.Secret) < MIN_SECRET_BYTES {
+		return fmt.Errorf("Secret must be at least %d bytes", MIN_SECRET_BYTES)
+	}
+
+	return nil
+}
+
+// The key as an otpauth URI. Parameters with default values are left out
+func (key Key) String() string {
+	query := url.Values{}
+	query.Set("secret", encoding.EncodeToString(key.Secret))
+
+	if key.Issuer != "" {
+		query.Set("issuer", key.Issuer)
+	}
+
+	if key.Algorithm != DEFAULT_ALGORITHM {
+		query.Set("algorithm", key.Algorithm)
+	}
+
+	if key.Type == TYPE_TOTP && key.Digits != DEFAULT_DIGITS {
+		query.Set("digits", strconv.Itoa(key.Digits))
+	}
+
+	if key.Period != DEFAULT_PERIOD {
+		query.Set("period", strconv.Itoa(key.Period))
+	}
+
+	label := key.Account
+
+	if key.Issuer != "" {
+		label = key.Issuer + ":" + key.Account
+	}
+
+	uri := url.URL{
+		Scheme:   "otpauth",
+		Host:     key.Type,
+		Path:     "/" + label,
+		RawQuery: query.Encode(),
+	}
+
+	return uri.String()
+}
+
+// The code of the key at the time
+func (key Key) Code(at time.Time) string {
+	return key.codeAt(uint64(key.counter(at)))
+}
+
+/*
+Check a code against the codes of the periods around the time, allowing for
+skew periods of clock drift in each direction. Codes are compared in constant time
+*/
+func (key Key) Verify(code string, at time.Time, skew int) bool {
+	counter := key.counter(at)
+	valid := false
+
+	for offset := -skew; offset <= skew; offset++ {
+		if counter+int64(offset) < 0 {
+			continue
+		}
+
+		if hmac.Equal([]byte(key.codeAt(uint64(counter+int64(offset)))), []byte(code)) {
+			valid = true
+		}
+	}
+
+	return valid
+}
+
+// Seconds until the code of the time changes
+func (key Key) Remaining(at time.Time) int {
+	return key.Period - int(at.Unix()%int64(key.Period))
+}
+
+func (key Key) counter(at time.Time) int64 {
+	return at.Unix() / int64(key.Period)
+}
+
+// HOTP of RFC 4226 with the hash of the key, or the Steam alphabet for Steam keys
+func (key Key) codeAt(counter uint64) string {
+	message := make([]byte, 8)
+	binary.BigEndian.PutUint64(message, counter)
+
+	mac := hmac.New(key.hash(), key.Secret)
+	mac.Write(message)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	if key.Type == TYPE_STEAM {
+		code := make([]byte, STEAM_DIGITS)
+
+		for i := range code {
+			code[i] = STEAM_ALPHABET[value%uint32(len(STEAM_ALPHABET))]
+			value /= uint32(len(STEAM_ALPHABET))
+		}
+
+		return string(code)
+	}
+
+	modulo := uint32(1)
+
+	for i := 0; i < key.Digits; i++ {
+		modulo *= 10
+	}
+
+	return fmt.Sprintf("%0*d", key.Digits, value%modulo)
+}
+
+func (key Key) hash() func() hash.Hash {
+	switch key.Algorithm {
+	case ALGORITHM_SHA256:
+		return sha256.New
+	case ALGORITHM_SHA512:
+		return sha512.New
+	}
+
+	return sha1.New
+}
@@ -0,0 +1,122 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+/***** Code *****/
+
+// Test vectors of RFC 6238, appendix B
+func TestCodeRfc6238(t *testing.T) {
+	secrets := map[string]string{
+		ALGORITHM_SHA1:   "12345678901234567890",
+		ALGORITHM_SHA256: "12345678901234567890123456789012",
+		ALGORITHM_SHA512: "1234567890123456789012345678901234567890123456789012345678901234",
+	}
+
+	tests := []struct {
+		at        int64
+		algorithm string
+		expected  string
+	}{
+		{59, ALGORITHM_SHA1, "94287082"},
+		{59, ALGORITHM_SHA256, "46119246"},
+		{59, ALGORITHM_SHA512, "90693936"},
+		{1111111109, ALGORITHM_SHA1, "07081804"},
+		{1111111109, ALGORITHM_SHA256, "68084774"},
+		{1111111109, ALGORITHM_SHA512, "25091201"},
+		{20000000000, ALGORITHM_SHA1, "65353130"},
+		{20000000000, ALGORITHM_SHA256, "77737706"},
+		{20000000000, ALGORITHM_SHA512, "47863826"},
+	}
+
+	for _, test := range tests {
+		key := Key{Type: TYPE_TOTP, Secret: []byte(secrets[test.algorithm]), Algorithm: test.algorithm, Digits: 8, Period: 30}
+		actual := key.Code(time.Unix(test.at, 0))
+
+		if actual != test.expected {
+			t.Errorf("FAILED - TestCodeRfc6238 | Time: %d %s | Actual: %s | Expected: %s", test.at, test.algorithm, actual, test.expected)
+		}
+	}
+}
+
+func TestCodeSteam(t *testing.T) {
+	key, _ := Parse("steam://JBSWY3DPEHPK3PXP")
+	actual := key.Code(time.Unix(1111111109, 0))
+
+	if len(actual) != STEAM_DIGITS || strings.Trim(actual, STEAM_ALPHABET) != "" {
+		t.Errorf("FAILED - TestCodeSteam | Actual: %s | Expected: %d characters of the Steam alphabet", actual, STEAM_DIGITS)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	key, _ := Parse("JBSWY3DPEHPK3PXP")
+	now := time.Unix(1111111109, 0)
+	previous := key.Code(now.Add(-30 * time.Second))
+
+	if !key.Verify(previous, now, 1) || key.Verify(previous, now, 0) {
+		t.Errorf("FAILED - TestVerify | Expected: the code of the previous period to only verify with a skew of 1")
+	}
+}
+
+/***** End Code *****/
+
+/***** Parse *****/
+
+func TestParseOtpauth(t *testing.T) {
+	key, err := Parse("otpauth://totp/ACME%20Co:john@example.com?secret=HXDMVJECJJWSRB3HWIZR4IFUGFTMXBOZ&issuer=ACME%20Co&algorithm=SHA256&digits=8&period=60")
+
+	if err != nil {
+		t.Fatalf("FAILED - TestParseOtpauth | Error: %s", err.Error())
+	}
+
+	if key.Issuer != "ACME Co" || key.Account != "john@example.com" || key.Algorithm != ALGORITHM_SHA256 || key.Digits != 8 || key.Period != 60 {
+		t.Errorf("FAILED - TestParseOtpauth | Actual: %+v", key)
+	}
+
+	roundTrip, err := Parse(key.String())
+
+	if err != nil || roundTrip.String() != key.String() || string(roundTrip.Secret) != string(key.Secret) {
+		t.Errorf("FAILED - TestParseOtpauth | Actual: %s | Expected: %s", roundTrip.String(), key.String())
+	}
+}
+
+func TestParseSteam(t *testing.T) {
+	for _, value := range []string{"steam://JBSWY3DPEHPK3PXP", "otpauth://totp/Steam:bob?secret=JBSWY3DPEHPK3PXP&encoder=steam", "otpauth://steam/Steam:bob?secret=JBSWY3DPEHPK3PXP"} {
+		key, err := Parse(value)
+
+		if err != nil || key.Type != TYPE_STEAM || key.Digits != STEAM_DIGITS {
+			t.Errorf("FAILED - TestParseSteam | Value: %s | Actual: %+v %v", value, key, err)
+		}
+	}
+}
+
+func TestParseSecret(t *testing.T) {
+	key, err := Parse("jbsw y3dp ehpk 3pxp")
+
+	if err != nil || key.String() != "otpauth://totp/?secret=JBSWY3DPEHPK3PXP" {
+		t.Errorf("FAILED - TestParseSecret | Actual: %s %v", key.String(), err)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	invalid := []string{
+		"",
+		"not base32!",
+		"JBSWY3DP",
+		"otpauth://hotp/bob?secret=JBSWY3DPEHPK3PXP",
+		"otpauth://totp/bob?secret=JBSWY3DPEHPK3PXP&algorithm=MD5",
+		"otpauth://totp/bob?secret=JBSWY3DPEHPK3PXP&digits=7",
+		"otpauth://totp/bob?secret=JBSWY3DPEHPK3PXP&period=45",
+	}
+
+	for _, value := range invalid {
+		if _, err := Parse(value); err == nil {
+			t.Errorf("FAILED - TestParseInvalid | Value: %s | Expected: an error", value)
+		}
+	}
+}
+
+/***** End Parse *****/
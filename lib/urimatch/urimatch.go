@@ -0,0 +1,229 @@
+package urimatch
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// Strategies to match the URI of a login against the URL of a page
+const (
+	MATCH_DOMAIN      = "domain"
+	MATCH_HOST        = "host"
+	MATCH_STARTS_WITH = "startsWith"
+	MATCH_EXACT       = "exact"
+	MATCH_REGEX       = "regex"
+	MATCH_NEVER       = "never"
+)
+
+// Limits of the equivalent domains of an account
+const (
+	MAX_DOMAIN_GROUPS = 100
+	MAX_GROUP_DOMAINS = 50
+)
+
+/*
+A URI of a login and how it is matched. Without a match strategy the base
+domain is matched
+*/
+type Uri struct {
+	Uri   string `json:"uri"`
+	Match string `json:"match,omitempty"`
+}
+
+// A login to match, only its id and URIs are needed
+type Item struct {
+	Id   string `json:"id"`
+	Uris []Uri  `json:"uris"`
+}
+
+// The match strategies a URI can have
+func Strategies() []string {
+	return []string{MATCH_DOMAIN, MATCH_HOST, MATCH_STARTS_WITH, MATCH_EXACT, MATCH_REGEX, MATCH_NEVER}
+}
+
+// An empty strategy is valid, it falls back to the base domain
+func IsValidStrategy(strategy string) bool {
+	if strategy == "" {
+		return true
+	}
+
+	for _, valid := range Strategies() {
+		if strategy == valid {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+The registrable domain of a host using the Public Suffix List
+(i.e accounts.google.co.uk -> google.co.uk). IP addresses, single label
+hosts like localhost and public suffixes are returned as they are
+*/
+func BaseDomain(host string) string {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+
+	if host == "" || net.ParseIP(host) != nil || !strings.Contains(host, ".") {
+		return host
+	}
+
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+
+	if err != nil {
+		return host
+	}
+
+	return domain
+}
+
+/*
+Parse the URI of a login or the URL of a page. URIs without a scheme, as
+people often type them (i.e "github.com/login"), are read as https
+*/
+func parse(value string) (*url.URL, error) {
+	value = strings.TrimSpace(value)
+
+	if !strings.Contains(value, "://") {
+		value = "https://" + value
+	}
+
+	parsed, err := url.Parse(value)
+
+	if err != nil || parsed.Hostname() == "" {
+		return nil, fmt.Errorf("%s is not a URL", value)
+	}
+
+	return parsed, nil
+}
+
+/*
+Check if a URI of a login applies to the URL of a page. Equivalent domains
+are groups of base domains that are treated as the same site by the domain
+strategy (i.e google.com and youtube.com)
+*/
+func Matches(page string, uri Uri, equivalentDomains [][]string) bool {
+	switch uri.Match {
+	case MATCH_NEVER:
+		return false
+	case MATCH_STARTS_WITH:
+		return strings.HasPrefix(page, strings.TrimSpace(uri.Uri))
+	case MATCH_EXACT:
+		return page == strings.TrimSpace(uri.Uri)
+	case MATCH_REGEX:
+		pattern, err := regexp.Compile("(?i)" + uri.Uri)
+		return err == nil && pattern.MatchString(page)
+	}
+
+	pageUrl, err := parse(page)
+
+	if err != nil {
+		return false
+	}
+
+	loginUrl, err := parse(uri.Uri)
+
+	if err != nil {
+		return false
+	}
+
+	if uri.Match == MATCH_HOST {
+		return strings.EqualFold(pageUrl.Host, loginUrl.Host)
+	}
+
+	pageDomain := BaseDomain(pageUrl.Hostname())
+	loginDomain := BaseDomain(loginUrl.Hostname())
+
+	if pageDomain == loginDomain {
+		return true
+	}
+
+	for _, group := range equivalentDomains {
+		if contains(group, pageDomain) && contains(group, loginDomain) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// The ids of the items with a URI that applies to the URL of a page, in the order of the items
+func Match(page string, items []Item, equivalentDomains [][]string) []string {
+	ids := []string{}
+
+	for _, item := range items {
+		for _, uri := range item.Uris {
+			if Matches(page, uri, equivalentDomains) {
+				ids = append(ids, item.Id)
+				break
+			}
+		}
+	}
+
+	return ids
+}
+
+/*
+Normalize groups of equivalent domains into lower case base domains. Every
+group needs at least two domains and a domain can only be in one group
+*/
+func NormalizeDomainGroups(groups [][]string) ([][]string, error) {
+	if len(groups) > MAX_DOMAIN_GROUPS {
+		return nil, fmt.Errorf("At most %d groups of equivalent domains are allowed", MAX_DOMAIN_GROUPS)
+	}
+
+	normalized := [][]string{}
+	seen := map[string]bool{}
+
+	for _, group := range groups {
+		if len(group) > MAX_GROUP_DOMAINS {
+			return nil, fmt.Errorf("A group can have at most %d domains", MAX_GROUP_DOMAINS)
+		}
+
+		domains := []string{}
+
+		for _, domain := range group {
+			parsed, err := parse(domain)
+
+			if err != nil {
+				return nil, fmt.Errorf("%s is not a domain", domain)
+			}
+
+			base := BaseDomain(parsed.Hostname())
+
+			if contains(domains, base) {
+				continue
+			}
+
+			if seen[base] {
+				return nil, fmt.Errorf("%s is in more than one group", base)
+			}
+
+			seen[base] = true
+			domains = append(domains, base)
+		}
+
+		if len(domains) < 2 {
+			return nil, fmt.Errorf("A group needs at least two different domains")
+		}
+
+		normalized = append(normalized, domains)
+	}
+
+	return normalized, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+
+	return false
+}
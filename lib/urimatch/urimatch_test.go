@@ -0,0 +1,95 @@
+package urimatch
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBaseDomain(t *testing.T) {
+	tests := map[string]string{
+		"accounts.google.com": "google.com",
+		"login.bbc.co.uk":     "bbc.co.uk",
+		"Foo.GitHub.io":       "foo.github.io",
+		"localhost":           "localhost",
+		"192.168.1.1":         "192.168.1.1",
+		"co.uk":               "co.uk",
+		"www.example.com.":    "example.com",
+	}
+
+	for host, expected := range tests {
+		if actual := BaseDomain(host); actual != expected {
+			t.Errorf("FAILED - TestBaseDomain | Host: %s | Actual: %s | Expected: %s", host, actual, expected)
+		}
+	}
+}
+
+/***** Matches *****/
+
+func TestMatches(t *testing.T) {
+	equivalent := [][]string{{"google.com", "youtube.com"}}
+
+	tests := []struct {
+		page     string
+		uri      Uri
+		expected bool
+	}{
+		{"https://accounts.google.com/signin", Uri{Uri: "https://google.com"}, true},
+		{"https://www.youtube.com/", Uri{Uri: "google.com"}, true},
+		{"https://gmail.com/", Uri{Uri: "google.com"}, false},
+		{"https://bob.github.io/", Uri{Uri: "https://alice.github.io"}, false},
+		{"https://accounts.google.com/", Uri{Uri: "https://google.com", Match: MATCH_HOST}, false},
+		{"https://google.com:8443/", Uri{Uri: "google.com:8443", Match: MATCH_HOST}, true},
+		{"https://github.com/login?return=1", Uri{Uri: "https://github.com/login", Match: MATCH_STARTS_WITH}, true},
+		{"https://github.com/logout", Uri{Uri: "https://github.com/login", Match: MATCH_STARTS_WITH}, false},
+		{"https://github.com/login", Uri{Uri: "https://github.com/login", Match: MATCH_EXACT}, true},
+		{"https://github.com/login?x", Uri{Uri: "https://github.com/login", Match: MATCH_EXACT}, false},
+		{"https://intranet.corp.example/app", Uri{Uri: `^https://[a-z]+\.corp\.example/`, Match: MATCH_REGEX}, true},
+		{"https://example.com", Uri{Uri: `([`, Match: MATCH_REGEX}, false},
+		{"https://google.com", Uri{Uri: "https://google.com", Match: MATCH_NEVER}, false},
+	}
+
+	for _, test := range tests {
+		if actual := Matches(test.page, test.uri, equivalent); actual != test.expected {
+			t.Errorf("FAILED - TestMatches | Page: %s | Uri: %+v | Actual: %v | Expected: %v", test.page, test.uri, actual, test.expected)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	items := []Item{
+		{Id: "a", Uris: []Uri{{Uri: "https://example.com", Match: MATCH_NEVER}, {Uri: "https://other.com"}}},
+		{Id: "b", Uris: []Uri{{Uri: "https://www.example.com"}}},
+		{Id: "c", Uris: []Uri{{Uri: "https://login.example.com", Match: MATCH_HOST}}},
+	}
+
+	actual := Match("https://login.example.com/", items, nil)
+	expected := []string{"b", "c"}
+
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("FAILED - TestMatch | Actual: %v | Expected: %v", actual, expected)
+	}
+}
+
+/***** End Matches *****/
+
+func TestNormalizeDomainGroups(t *testing.T) {
+	actual, err := NormalizeDomainGroups([][]string{{"Google.com", "www.youtube.com", "https://mail.google.com"}})
+	expected := [][]string{{"google.com", "youtube.com"}}
+
+	if err != nil || !reflect.DeepEqual(actual, expected) {
+		t.Errorf("FAILED - TestNormalizeDomainGroups | Actual: %v %v | Expected: %v", actual, err, expected)
+	}
+
+	invalid := [][][]string{
+		{{"google.com"}},
+		{{"google.com", "www.google.com"}},
+		{{"google.com", "youtube.com"}, {"youtube.com", "gmail.com"}},
+		{{"google.com", "http://"}},
+	}
+
+	for _, groups := range invalid {
+		if _, err := NormalizeDomainGroups(groups); err == nil {
+			t.Errorf("FAILED - TestNormalizeDomainGroups | Groups: %v | Expected: an error", groups)
+		}
+	}
+}
@@ -0,0 +1,307 @@
+package userevents
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	appConfig "password-caddy/api/core/config"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/dynamoclient"
+	"password-caddy/api/lib/logger"
+
+	"github.com/google/uuid"
+)
+
+// Sort key prefix of the security events in a user's partition
+const SECURITY_PREFIX = "SECURITY#"
+
+// Times in the sort keys have a fixed width so the keys sort by time
+const EVENT_TIME_FORMAT = "2006-01-02T15:04:05.000000000Z"
+
+/*
+Types of the security events of a user. Events of the emergency access are
+caused by a grantee, events of support actions by the operator, who is the
+actor of the event
+*/
+const (
+	TYPE_ACCOUNT_CREATED        = "accountCreated"
+	TYPE_LOGIN_CHALLENGED       = "loginChallenged"
+	TYPE_LOGIN_SUCCEEDED        = "loginSucceeded"
+	TYPE_LOGIN_FAILED           = "loginFailed"
+	TYPE_LOGIN_BLOCKED          = "loginBlocked"
+	TYPE_NEW_DEVICE_LOGIN       = "newDeviceLogin"
+	TYPE_ACCOUNT_LOCKED         = "accountLocked"
+	TYPE_VAULT_EXPORTED         = "vaultExported"
+	TYPE_KEYS_ROTATED           = "keysRotated"
+	TYPE_EMERGENCY_INITIATED    = "emergencyRecoveryInitiated"
+	TYPE_EMERGENCY_VAULT_VIEWED = "emergencyVaultViewed"
+	TYPE_ACCOUNT_TAKEN_OVER     = "accountTakenOver"
+	TYPE_SESSIONS_REVOKED       = "sessionsRevoked"
+	TYPE_ACCOUNT_UNLOCKED       = "accountUnlocked"
+	TYPE_ACCOUNT_SUSPENDED      = "accountSuspended"
+	TYPE_ACCOUNT_UNSUSPENDED    = "accountUnsuspended"
+)
+
+const (
+	DEFAULT_PAGE_SIZE = 50
+	MAX_PAGE_SIZE     = 200
+	MAX_DETAIL_LENGTH = 256
+)
+
+/*
+A security event of a user. ActorId is only set when someone else caused it.
+Detail adds context depending on the type (i.e the policy that blocked a login)
+*/
+type Event struct {
+	Type      string
+	ActorId   string
+	IpAddress string
+	UserAgent string
+	Detail    string
+}
+
+// Narrows down the security events of a user. Cursor continues a previous page
+type Filter struct {
+	Type   string
+	Cursor string
+	Limit  int
+}
+
+type EventResponse struct {
+	Id        string `json:"id"`
+	Type      string `json:"type"`
+	ActorId   string `json:"actorId,omitempty"`
+	IpAddress string `json:"ipAddress,omitempty"`
+	UserAgent string `json:"userAgent,omitempty"`
+	Detail    string `json:"detail,omitempty"`
+	Date      string `json:"date"`
+}
+
+// Events from the newest to the oldest. Cursor is only set when there may be more
+type EventsResponse struct {
+	Events []EventResponse `json:"events"`
+	Cursor string          `json:"cursor,omitempty"`
+}
+
+// A page of events and the sort key of its last event when there may be more
+type Page struct {
+	Events  []types.UserEvent
+	LastKey string
+}
+
+/********** CONFIG **********/
+
+// How long security events are kept before DynamoDB expires them
+func RetentionTTL() time.Duration {
+	days := appConfig.Get("USER_EVENT_RETENTION_DAYS", "365").ToInt64()
+	return time.Duration(days) * 24 * time.Hour
+}
+
+/********** KEYS **********/
+
+func Types() []string {
+	return []string{
+		TYPE_ACCOUNT_CREATED,
+		TYPE_LOGIN_CHALLENGED,
+		TYPE_LOGIN_SUCCEEDED,
+		TYPE_LOGIN_FAILED,
+		TYPE_LOGIN_BLOCKED,
+		TYPE_NEW_DEVICE_LOGIN,
+		TYPE_ACCOUNT_LOCKED,
+		TYPE_VAULT_EXPORTED,
+		TYPE_KEYS_ROTATED,
+		TYPE_EMERGENCY_INITIATED,
+		TYPE_EMERGENCY_VAULT_VIEWED,
+		TYPE_ACCOUNT_TAKEN_OVER,
+		TYPE_SESSIONS_REVOKED,
+		TYPE_ACCOUNT_UNLOCKED,
+		TYPE_ACCOUNT_SUSPENDED,
+		TYPE_ACCOUNT_UNSUSPENDED,
+	}
+}
+
+func IsValidType(eventType string) bool {
+	for _, candidate := range Types() {
+		if candidate == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
+func EventKey(createdAt time.Time, eventId string) string {
+	return SECURITY_PREFIX + createdAt.UTC().Format(EVENT_TIME_FORMAT) + "#" + eventId
+}
+
+/********** CURSOR **********/
+
+// The cursor is the sort key of the last event of the page, which the next page starts after
+func EncodeCursor(lastKey string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(lastKey))
+}
+
+func DecodeCursor(cursor string) (string, error) {
+	value, err := base64.RawURLEncoding.DecodeString(cursor)
+
+	if err != nil || !strings.HasPrefix(string(value), SECURITY_PREFIX) {
+		return "", errors.New("Invalid event cursor")
+	}
+
+	return string(value), nil
+}
+
+/********** VALIDATION **********/
+
+// Build the filter out of the type, cursor and limit query parameters
+func ParseFilter(params map[string]string) (Filter, error) {
+	filter := Filter{
+		Type:   params["type"],
+		Cursor: params["cursor"],
+		Limit:  DEFAULT_PAGE_SIZE,
+	}
+
+	if filter.Type != "" && !IsValidType(filter.Type) {
+		return filter, errors.New("Unknown event type")
+	}
+
+	if filter.Cursor != "" {
+		if _, err := DecodeCursor(filter.Cursor); err != nil {
+			return filter, err
+		}
+	}
+
+	if params["limit"] != "" {
+		limit, err := strconv.Atoi(params["limit"])
+
+		if err != nil || limit < 1 || limit > MAX_PAGE_SIZE {
+			return filter, fmt.Errorf("Limit must be between 1 and %d", MAX_PAGE_SIZE)
+		}
+
+		filter.Limit = limit
+	}
+
+	return filter, nil
+}
+
+/********** RESPONSES **********/
+
+func ToEventResponse(event types.UserEvent) EventResponse {
+	return EventResponse{
+		Id:        event.EventId.Value,
+		Type:      event.Type.Value,
+		ActorId:   event.ActorId.Value,
+		IpAddress: event.IpAddress.Value,
+		UserAgent: event.UserAgent.Value,
+		Detail:    event.Detail.Value,
+		Date:      event.CreatedAt.Value,
+	}
+}
+
+func ToEventsResponse(page Page) EventsResponse {
+	response := EventsResponse{Events: []EventResponse{}}
+
+	for _, event := range page.Events {
+		response.Events = append(response.Events, ToEventResponse(event))
+	}
+
+	if page.LastKey != "" {
+		response.Cursor = EncodeCursor(page.LastKey)
+	}
+
+	return response
+}
+
+/********** OPERATIONS **********/
+
+// Save a security event of a user. Events expire after the retention period
+func Record(client *dynamoclient.DynamoClient, userId string, event Event) *dynamoclient.DynamoResponse {
+	now := time.Now().UTC()
+	eventId := uuid.NewString()
+
+	return client.Put(dynamoclient.DynamoPutRequest{
+		Key:     userId,
+		SortKey: EventKey(now, eventId),
+		Values: map[string]interface{}{
+			"EVENT_ID":   eventId,
+			"TYPE":       event.Type,
+			"ACTOR_ID":   event.ActorId,
+			"IP_ADDRESS": event.IpAddress,
+			"USER_AGENT": truncate(event.UserAgent),
+			"DETAIL":     truncate(event.Detail),
+			"CREATED_AT": now.Format(time.RFC3339),
+			"EXPIRES_AT": now.Add(RetentionTTL()).Unix(),
+		},
+	})
+}
+
+/*
+Save a security event of a user, logging instead of failing when it can not be
+saved. The events are an audit trail for the user, the operation they record
+already happened
+*/
+func RecordOrLog(client *dynamoclient.DynamoClient, userId string, event Event) {
+	response := Record(client, userId, event)
+
+	if !response.IsSuccess {
+		logger.Error(
+			"Failed to record security event",
+			struct {
+				Email string
+				Type  string
+				Error types.PasswordCaddyError
+			}{
+				Email: userId,
+				Type:  event.Type,
+				Error: response.Error,
+			},
+		)
+	}
+}
+
+// Get a page of the security events of a user from the newest to the oldest
+func List(client *dynamoclient.DynamoClient, userId string, filter Filter) *dynamoclient.DynamoResponse {
+	request := dynamoclient.DynamoQueryRequest{
+		Key:           userId,
+		SortKeyPrefix: SECURITY_PREFIX,
+		Descending:    true,
+		Limit:         int32(filter.Limit),
+	}
+
+	if filter.Cursor != "" {
+		request.StartAfter, _ = DecodeCursor(filter.Cursor)
+	}
+
+	if filter.Type != "" {
+		request.Filter = "#type = :type"
+		request.Names = map[string]string{"#type": "TYPE"}
+		request.Values = map[string]interface{}{":type": filter.Type}
+	}
+
+	response := client.Query(request).AsUserEvents()
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	page := Page{Events: response.Data.([]types.UserEvent)}
+
+	if len(page.Events) > 0 && len(page.Events) >= filter.Limit {
+		page.LastKey = page.Events[len(page.Events)-1].ItemKey.Value
+	}
+
+	return dynamoclient.SuccessWithValue(page)
+}
+
+// User agents and details are set by clients, so their length is capped
+func truncate(value string) string {
+	if len(value) > MAX_DETAIL_LENGTH {
+		return value[:MAX_DETAIL_LENGTH]
+	}
+
+	return value
+}
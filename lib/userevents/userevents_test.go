@@ -0,0 +1,76 @@
+package userevents
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+/***** Keys *****/
+
+func TestEventKeysSortByTime(t *testing.T) {
+	earlier := EventKey(time.Date(2024, 1, 1, 9, 59, 59, 900000000, time.UTC), "b")
+	later := EventKey(time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC), "a")
+
+	if earlier >= later {
+		t.Errorf("FAILED - TestEventKeysSortByTime | Expected: %s to sort before %s", earlier, later)
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	key := EventKey(time.Now(), "a")
+
+	actual, err := DecodeCursor(EncodeCursor(key))
+
+	if err != nil || actual != key {
+		t.Errorf("FAILED - TestCursorRoundTrip | Actual: %s | Error: %v | Expected: %s", actual, err, key)
+	}
+
+	if _, err := DecodeCursor(EncodeCursor("ITEM#a")); err == nil {
+		t.Errorf("FAILED - TestCursorRoundTrip | Expected: a cursor outside the security events to be rejected")
+	}
+}
+
+/***** Validate *****/
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		params map[string]string
+		valid  bool
+	}{
+		{map[string]string{}, true},
+		{map[string]string{"type": TYPE_LOGIN_FAILED, "limit": "20"}, true},
+		{map[string]string{"type": "loginExploded"}, false},
+		{map[string]string{"limit": "0"}, false},
+		{map[string]string{"limit": "many"}, false},
+		{map[string]string{"cursor": "not a cursor"}, false},
+	}
+
+	for _, test := range tests {
+		if _, err := ParseFilter(test.params); (err == nil) != test.valid {
+			t.Errorf("FAILED - TestParseFilter | Params: %v | Error: %v | Expected valid: %v", test.params, err, test.valid)
+		}
+	}
+}
+
+func TestParseFilterDefaultLimit(t *testing.T) {
+	filter, _ := ParseFilter(map[string]string{})
+
+	if filter.Limit != DEFAULT_PAGE_SIZE {
+		t.Errorf("FAILED - TestParseFilterDefaultLimit | Actual: %d | Expected: %d", filter.Limit, DEFAULT_PAGE_SIZE)
+	}
+}
+
+/***** Record *****/
+
+func TestTruncate(t *testing.T) {
+	long := strings.Repeat("a", MAX_DETAIL_LENGTH+10)
+
+	if actual := truncate(long); len(actual) != MAX_DETAIL_LENGTH {
+		t.Errorf("FAILED - TestTruncate | Actual: %d | Expected: %d", len(actual), MAX_DETAIL_LENGTH)
+	}
+
+	if actual := truncate("Mozilla/5.0"); actual != "Mozilla/5.0" {
+		t.Errorf("FAILED - TestTruncate | Actual: %s | Expected: short values as is", actual)
+	}
+}
@@ -12,7 +12,8 @@ import (
 // TODO - Expand on this big time
 var (
 	AWS_ERRORS_TO_STATUS_CODES = map[string]int{
-		"ValidationException": 400,
+		"ValidationException":             400,
+		"ConditionalCheckFailedException": 409,
 	}
 )
 
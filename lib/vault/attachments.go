@@ -0,0 +1,368 @@
+package vault
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	appConfig "password-caddy/api/core/config"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/blobstore"
+	"password-caddy/api/lib/dynamoclient"
+
+	dynamoTypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/google/uuid"
+)
+
+// Encrypted file names and keys are short, anything longer is not a file name
+const MAX_ATTACHMENT_METADATA_LENGTH = 1024
+
+/*
+A file to attach to an item. The client encrypts the file with a random key
+and sends the encrypted file base64 encoded in Data. FileName and Key are
+encrypted with the user's key, the server never sees them in plain text
+*/
+type VaultAttachmentRequest struct {
+	FileName string `json:"fileName"`
+	Key      string `json:"key"`
+	Data     string `json:"data"`
+}
+
+type VaultAttachmentResponse struct {
+	Id        string `json:"id"`
+	ItemId    string `json:"itemId"`
+	FileName  string `json:"fileName"`
+	Key       string `json:"key"`
+	Size      int    `json:"size"`
+	CreatedAt string `json:"createdAt"`
+	Data      string `json:"data,omitempty"`
+}
+
+/********** CONFIG **********/
+
+// Largest attachment in bytes. Lambda payloads are limited to 6 MB and the file is base64 encoded
+func AttachmentMaxSize() int {
+	return int(appConfig.Get("ATTACHMENT_MAX_SIZE", "4194304").ToInt64())
+}
+
+// Bytes of attachments a single account can store
+func AttachmentQuota() int {
+	return int(appConfig.Get("ATTACHMENT_QUOTA", "1073741824").ToInt64())
+}
+
+/********** ATTACHMENTS **********/
+
+// Attachments of all users live in the same blob store, keyed by user, item and attachment
+func BlobKey(userId, itemId, attachmentId string) string {
+	return fmt.Sprintf("attachments/%s/%s/%s", userId, itemId, attachmentId)
+}
+
+// Validate the request and decode the encrypted file
+func (request VaultAttachmentRequest) Decode() ([]byte, error) {
+	if request.FileName == "" || len(request.FileName) > MAX_ATTACHMENT_METADATA_LENGTH {
+		return nil, fmt.Errorf("File name is required and can have at most %d characters", MAX_ATTACHMENT_METADATA_LENGTH)
+	}
+
+	if request.Key == "" || len(request.Key) > MAX_ATTACHMENT_METADATA_LENGTH {
+		return nil, fmt.Errorf("Key is required and can have at most %d characters", MAX_ATTACHMENT_METADATA_LENGTH)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(request.Data)
+
+	if err != nil {
+		return nil, errors.New("Data must be base64 encoded")
+	}
+
+	if len(data) == 0 {
+		return nil, errors.New("Data is required")
+	}
+
+	if len(data) > AttachmentMaxSize() {
+		return nil, fmt.Errorf("Attachments can have at most %d bytes", AttachmentMaxSize())
+	}
+
+	return data, nil
+}
+
+func ToAttachmentResponse(attachment types.VaultAttachment) VaultAttachmentResponse {
+	return VaultAttachmentResponse{
+		Id:        attachment.AttachmentId.Value,
+		ItemId:    attachment.ItemId.Value,
+		FileName:  attachment.FileName.Value,
+		Key:       attachment.Key.Value,
+		Size:      attachment.Size.Value,
+		CreatedAt: attachment.CreatedAt.Value,
+	}
+}
+
+// Get the metadata of an attachment. A missing attachment results in an empty VaultAttachment
+func GetAttachment(client *dynamoclient.DynamoClient, userId, itemId, attachmentId string) *dynamoclient.DynamoResponse {
+	return client.
+		Get(dynamoclient.DynamoGetRequest{
+			Key:     userId,
+			SortKey: AttachmentKey(itemId, attachmentId),
+		}).
+		AsVaultAttachment()
+}
+
+// Get the metadata of all attachments of an item
+func ListAttachments(client *dynamoclient.DynamoClient, userId, itemId string) *dynamoclient.DynamoResponse {
+	return client.
+		Query(dynamoclient.DynamoQueryRequest{
+			Key:           userId,
+			SortKeyPrefix: AttachmentPrefix(itemId),
+		}).
+		AsVaultAttachments()
+}
+
+/*
+Store an attachment of an item. The size of the file is reserved from the
+account's quota first, then the file is stored and its metadata saved last,
+so metadata never points to a missing file. A failed step releases the
+reserved quota again. Fails with a 413 when the quota is used up.
+
+The metadata is committed together with a new revision of the item, so other
+clients learn about the attachment on their next sync
+*/
+func AddAttachment(client *dynamoclient.DynamoClient, store blobstore.BlobStore, userId, itemId string, request VaultAttachmentRequest, data []byte) *dynamoclient.DynamoResponse {
+	var attachment types.VaultAttachment
+
+	attachment.UserId.Value = userId
+	attachment.ItemId.Value = itemId
+	attachment.AttachmentId.Value = uuid.NewString()
+	attachment.ItemKey.Value = AttachmentKey(itemId, attachment.AttachmentId.Value)
+	attachment.FileName.Value = request.FileName
+	attachment.Key.Value = request.Key
+	attachment.Size.Value = len(data)
+	attachment.CreatedAt.Value = time.Now().UTC().Format(time.RFC3339)
+
+	response := reserveQuota(client, userId, attachment.Size.Value, AttachmentQuota())
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	blobKey := BlobKey(userId, itemId, attachment.AttachmentId.Value)
+
+	if err := store.Put(blobKey, data); err != nil {
+		releaseQuota(client, userId, attachment.Size.Value)
+
+		return dynamoclient.Failure(types.PasswordCaddyError{
+			StatusCode: 500,
+			Message:    err.Error(),
+		})
+	}
+
+	response = commit(client, userId, func(syncRevision int) []dynamoclient.DynamoTransactItem {
+		return []dynamoclient.DynamoTransactItem{
+			{
+				Put: &dynamoclient.DynamoPutRequest{
+					Key:     userId,
+					SortKey: attachment.ItemKey.Value,
+					Values: map[string]interface{}{
+						"ITEM_ID":       attachment.ItemId.Value,
+						"ATTACHMENT_ID": attachment.AttachmentId.Value,
+						"FILE_NAME":     attachment.FileName.Value,
+						"KEY":           attachment.Key.Value,
+						"SIZE":          attachment.Size.Value,
+						"CREATED_AT":    attachment.CreatedAt.Value,
+					},
+				},
+			},
+			touchItem(userId, itemId, syncRevision),
+		}
+	})
+
+	if !response.IsSuccess {
+		store.Delete(blobKey)
+		releaseQuota(client, userId, attachment.Size.Value)
+		return response
+	}
+
+	return dynamoclient.SuccessWithValue(attachment)
+}
+
+// Get the encrypted file of an attachment. A file missing from the blob store results in a 404
+func GetAttachmentData(store blobstore.BlobStore, attachment types.VaultAttachment) *dynamoclient.DynamoResponse {
+	data, err := store.Get(BlobKey(attachment.UserId.Value, attachment.ItemId.Value, attachment.AttachmentId.Value))
+
+	if err == blobstore.ErrNotFound {
+		return dynamoclient.Failure(types.PasswordCaddyError{
+			StatusCode: 404,
+			Message:    "Attachment not found",
+		})
+	}
+
+	if err != nil {
+		return dynamoclient.Failure(types.PasswordCaddyError{
+			StatusCode: 500,
+			Message:    err.Error(),
+		})
+	}
+
+	return dynamoclient.SuccessWithValue(data)
+}
+
+/*
+Delete an attachment and give its size back to the account's quota. The
+metadata is deleted first so a failure never leaves metadata of a missing
+file behind, at worst an unreferenced file. Like adding one, deleting an
+attachment is committed together with a new revision of the item
+*/
+func DeleteAttachment(client *dynamoclient.DynamoClient, store blobstore.BlobStore, attachment types.VaultAttachment) *dynamoclient.DynamoResponse {
+	userId := attachment.UserId.Value
+
+	response := commit(client, userId, func(syncRevision int) []dynamoclient.DynamoTransactItem {
+		return []dynamoclient.DynamoTransactItem{
+			{Delete: attachmentDelete(attachment)},
+			touchItem(userId, attachment.ItemId.Value, syncRevision),
+		}
+	})
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	return releaseAttachment(client, store, attachment)
+}
+
+/*
+Delete all attachments of an item, i.e when the item is purged. The item is
+not touched, the tombstone of the purge tells the other clients
+*/
+func PurgeAttachments(client *dynamoclient.DynamoClient, store blobstore.BlobStore, userId, itemId string) *dynamoclient.DynamoResponse {
+	response := ListAttachments(client, userId, itemId)
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	for _, attachment := range response.Data.([]types.VaultAttachment) {
+		response = client.Delete(*attachmentDelete(attachment))
+
+		// Already deleted by another request
+		if !response.IsSuccess && response.Error.StatusCode == 409 {
+			continue
+		}
+
+		if !response.IsSuccess {
+			return response
+		}
+
+		response = releaseAttachment(client, store, attachment)
+
+		if !response.IsSuccess {
+			return response
+		}
+	}
+
+	return dynamoclient.Success()
+}
+
+// Fails with a 409 when the attachment was deleted in the meantime
+func attachmentDelete(attachment types.VaultAttachment) *dynamoclient.DynamoDeleteRequest {
+	return &dynamoclient.DynamoDeleteRequest{
+		Key:     attachment.UserId.Value,
+		SortKey: attachment.ItemKey.Value,
+		Condition: &dynamoclient.DynamoCondition{
+			Expression: "attribute_exists(#sk)",
+			Names:      map[string]string{"#sk": dynamoclient.SORT_KEY},
+		},
+	}
+}
+
+// Give the size of a deleted attachment back to the quota and delete its file
+func releaseAttachment(client *dynamoclient.DynamoClient, store blobstore.BlobStore, attachment types.VaultAttachment) *dynamoclient.DynamoResponse {
+	userId := attachment.UserId.Value
+
+	response := releaseQuota(client, userId, attachment.Size.Value)
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	if err := store.Delete(BlobKey(userId, attachment.ItemId.Value, attachment.AttachmentId.Value)); err != nil {
+		return dynamoclient.Failure(types.PasswordCaddyError{
+			StatusCode: 500,
+			Message:    err.Error(),
+		})
+	}
+
+	return dynamoclient.Success()
+}
+
+/*
+Bump the revision of the item an attachment was added to or deleted from, so
+it is part of the next sync. Fails with a 409 when the item was purged
+*/
+func touchItem(userId, itemId string, syncRevision int) dynamoclient.DynamoTransactItem {
+	return dynamoclient.DynamoTransactItem{
+		Update: &dynamoclient.DyanamoUpdateRequest{
+			Key:     userId,
+			SortKey: ItemKey(itemId),
+			Values: map[string]dynamoclient.DynamoUpdateItem{
+				"REVISION": {
+					Action: dynamoTypes.AttributeActionAdd,
+					Value:  1,
+				},
+				"UPDATED_AT": {
+					Action: dynamoTypes.AttributeActionPut,
+					Value:  time.Now().UTC().Format(time.RFC3339),
+				},
+				"SYNC_REVISION": {
+					Action: dynamoTypes.AttributeActionPut,
+					Value:  syncRevision,
+				},
+			},
+			Condition: &dynamoclient.DynamoCondition{
+				Expression: "attribute_exists(#sk)",
+				Names:      map[string]string{"#sk": dynamoclient.SORT_KEY},
+			},
+		},
+	}
+}
+
+/********** QUOTA **********/
+
+// Add the size of an attachment to the bytes used by an account unless it exceeds the quota
+func reserveQuota(client *dynamoclient.DynamoClient, userId string, size, quota int) *dynamoclient.DynamoResponse {
+	response := client.Update(dynamoclient.DyanamoUpdateRequest{
+		Key:     userId,
+		SortKey: USAGE_KEY,
+		Values: map[string]dynamoclient.DynamoUpdateItem{
+			"ATTACHMENT_BYTES": {
+				Action: dynamoTypes.AttributeActionAdd,
+				Value:  size,
+			},
+		},
+		Condition: &dynamoclient.DynamoCondition{
+			Expression: "attribute_not_exists(#bytes) OR #bytes <= :limit",
+			Names:      map[string]string{"#bytes": "ATTACHMENT_BYTES"},
+			Values:     map[string]interface{}{":limit": quota - size},
+		},
+	})
+
+	if !response.IsSuccess && response.Error.StatusCode == 409 {
+		return dynamoclient.Failure(types.PasswordCaddyError{
+			StatusCode: 413,
+			Message:    fmt.Sprintf("Attachments of an account can have at most %d bytes", quota),
+		})
+	}
+
+	return response
+}
+
+func releaseQuota(client *dynamoclient.DynamoClient, userId string, size int) *dynamoclient.DynamoResponse {
+	return client.Update(dynamoclient.DyanamoUpdateRequest{
+		Key:     userId,
+		SortKey: USAGE_KEY,
+		Values: map[string]dynamoclient.DynamoUpdateItem{
+			"ATTACHMENT_BYTES": {
+				Action: dynamoTypes.AttributeActionAdd,
+				Value:  -size,
+			},
+		},
+	})
+}
@@ -0,0 +1,57 @@
+package vault
+
+import (
+	"encoding/base64"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestAttachmentKey(t *testing.T) {
+	actual := AttachmentKey("item", "file")
+	expected := "ATTACHMENT#item#file"
+
+	if actual != expected || !strings.HasPrefix(actual, AttachmentPrefix("item")) {
+		t.Errorf("FAILED - TestAttachmentKey | Actual: %s | Expected: %s", actual, expected)
+	}
+}
+
+/***** Decode *****/
+
+func TestAttachmentDecode(t *testing.T) {
+	request := VaultAttachmentRequest{
+		FileName: "2.encrypted-name",
+		Key:      "2.encrypted-key",
+		Data:     base64.StdEncoding.EncodeToString([]byte("encrypted file")),
+	}
+
+	data, err := request.Decode()
+
+	if err != nil || string(data) != "encrypted file" {
+		t.Errorf("FAILED - TestAttachmentDecode | Actual: %s %v | Expected: encrypted file", data, err)
+	}
+}
+
+func TestAttachmentDecodeWithInvalidRequest(t *testing.T) {
+	os.Setenv("ATTACHMENT_MAX_SIZE", "4")
+	defer os.Unsetenv("ATTACHMENT_MAX_SIZE")
+
+	data := base64.StdEncoding.EncodeToString([]byte("abcd"))
+
+	invalid := []VaultAttachmentRequest{
+		{Key: "key", Data: data},
+		{FileName: "name", Data: data},
+		{FileName: "name", Key: "key"},
+		{FileName: "name", Key: "key", Data: "not base64!"},
+		{FileName: "name", Key: "key", Data: base64.StdEncoding.EncodeToString([]byte("abcde"))},
+		{FileName: strings.Repeat("a", MAX_ATTACHMENT_METADATA_LENGTH+1), Key: "key", Data: data},
+	}
+
+	for _, request := range invalid {
+		if _, err := request.Decode(); err == nil {
+			t.Errorf("FAILED - TestAttachmentDecodeWithInvalidRequest | Request: %+v | Expected: an error", request)
+		}
+	}
+}
+
+/***** End Decode *****/
@@ -0,0 +1,403 @@
+package vault
+
+import (
+	"errors"
+	"time"
+
+	appConfig "password-caddy/api/core/config"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/dynamoclient"
+
+	dynamoTypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Actions of a bulk request
+const (
+	BULK_ACTION_MOVE    = "move"
+	BULK_ACTION_DELETE  = "delete"
+	BULK_ACTION_RESTORE = "restore"
+	BULK_ACTION_REKEY   = "rekey"
+)
+
+// Revision is the one the client last saw, required like for a single item. Data is the re-encrypted item of a rekey
+type BulkItem struct {
+	Id       string `json:"id"`
+	Revision *int   `json:"revision"`
+	Data     string `json:"data"`
+}
+
+// Outcome of a single item of a bulk request. Status is an HTTP status code
+type BulkItemResult struct {
+	Id      string             `json:"id"`
+	Status  int                `json:"status"`
+	Message string             `json:"message,omitempty"`
+	Item    *VaultItemResponse `json:"item,omitempty"`
+}
+
+// Write of a single item of a bulk request. Index points into the results.
+// A change that creates the item writes it with Values as its attributes.
+// Deletes are the sort keys deleted together with the item, Markers the
+// writes of its trash marker
+type BulkChange struct {
+	Index    int
+	Item     types.VaultItem
+	Revision int
+	Values   map[string]dynamoclient.DynamoUpdateItem
+	Create   bool
+	Deletes  []string
+	Markers  []dynamoclient.DynamoTransactItem
+}
+
+// Maximum number of items of a single bulk request
+func BulkMaxItems() int {
+	return int(appConfig.Get("VAULT_BULK_MAX_ITEMS", "500").ToInt64())
+}
+
+// A bulk change must not overwrite blindly, so every item needs the revision it changes
+func (item BulkItem) Validate() error {
+	if !IsValidItemId(item.Id) {
+		return errors.New("Item id must be a UUID")
+	}
+
+	if item.Revision == nil {
+		return errors.New("Revision is required for every item")
+	}
+
+	if *item.Revision < 0 {
+		return errors.New("Revision must not be negative")
+	}
+
+	return nil
+}
+
+func IsValidBulkAction(action string) bool {
+	switch action {
+	case BULK_ACTION_MOVE, BULK_ACTION_DELETE, BULK_ACTION_RESTORE, BULK_ACTION_REKEY:
+		return true
+	}
+
+	return false
+}
+
+/*
+Plan the writes of a bulk request against the current items of the vault.
+Items that fail or need no write get their result right away, the others a
+change with status 0 that is filled in by ApplyBulkChanges.
+
+Items in the trash can only be restored or rekeyed, so a rotated key also
+covers the items the user may restore later
+*/
+func PlanBulkChanges(action, folderId string, items []BulkItem, current map[string]types.VaultItem, now time.Time) ([]BulkItemResult, []BulkChange) {
+	results := make([]BulkItemResult, len(items))
+	changes := []BulkChange{}
+
+	for i, requested := range items {
+		results[i].Id = requested.Id
+
+		item, ok := current[requested.Id]
+
+		if !ok {
+			results[i].Status = 404
+			results[i].Message = "Vault item not found"
+			continue
+		}
+
+		if requested.Revision == nil || *requested.Revision < 0 {
+			results[i].Status = 400
+			results[i].Message = "Revision is required"
+			continue
+		}
+
+		if !MatchesRevision(item, *requested.Revision) {
+			results[i] = bulkResult(requested.Id, 409, "Vault item was modified by another client", item)
+			continue
+		}
+
+		trashed := item.DeletedAt.Value != ""
+		values := map[string]dynamoclient.DynamoUpdateItem{}
+		markers := []dynamoclient.DynamoTransactItem{}
+
+		switch action {
+		case BULK_ACTION_MOVE:
+			if trashed {
+				results[i] = bulkResult(requested.Id, 409, "Vault item is in the trash", item)
+				continue
+			}
+
+			if item.FolderId.Value == folderId {
+				results[i] = bulkResult(requested.Id, 200, "", item)
+				continue
+			}
+
+			item.FolderId.Value = folderId
+			values["FOLDER_ID"] = removeWhenEmpty(folderId)
+		case BULK_ACTION_DELETE:
+			if trashed {
+				results[i] = bulkResult(requested.Id, 200, "", item)
+				continue
+			}
+
+			item.DeletedAt.Value = now.UTC().Format(time.RFC3339)
+			values["DELETED_AT"] = dynamoclient.DynamoUpdateItem{
+				Action: dynamoTypes.AttributeActionPut,
+				Value:  item.DeletedAt.Value,
+			}
+			markers = append(markers, trashMarker(item))
+		case BULK_ACTION_RESTORE:
+			if !trashed {
+				results[i].Status = 404
+				results[i].Message = "Vault item not found in the trash"
+				continue
+			}
+
+			markers = removeTrashMarker(item)
+			item.DeletedAt.Value = ""
+			values["DELETED_AT"] = removeWhenEmpty("")
+		case BULK_ACTION_REKEY:
+			if requested.Data == "" {
+				results[i].Status = 400
+				results[i].Message = "Item data is required"
+				continue
+			}
+
+			item.Data.Value = requested.Data
+			values["DATA"] = dynamoclient.DynamoUpdateItem{
+				Action: dynamoTypes.AttributeActionPut,
+				Value:  requested.Data,
+			}
+		}
+
+		revision := item.Revision.Value
+
+		item.Revision.Value++
+		item.UpdatedAt.Value = now.UTC().Format(time.RFC3339)
+
+		values["REVISION"] = dynamoclient.DynamoUpdateItem{
+			Action: dynamoTypes.AttributeActionPut,
+			Value:  item.Revision.Value,
+		}
+		values["UPDATED_AT"] = dynamoclient.DynamoUpdateItem{
+			Action: dynamoTypes.AttributeActionPut,
+			Value:  item.UpdatedAt.Value,
+		}
+
+		changes = append(changes, BulkChange{
+			Index:    i,
+			Item:     item,
+			Revision: revision,
+			Values:   values,
+			Markers:  markers,
+		})
+	}
+
+	return results, changes
+}
+
+/*
+Drop the history of the rekeyed items together with their rekey. The history
+is encrypted under the replaced key, so restoring it would bring back data the
+clients can no longer decrypt
+*/
+func DropRekeyedHistory(changes []BulkChange, histories []types.VaultItemHistory) {
+	keys := map[string][]string{}
+
+	for _, history := range histories {
+		keys[history.ItemId.Value] = append(keys[history.ItemId.Value], history.ItemKey.Value)
+	}
+
+	for i := range changes {
+		changes[i].Deletes = keys[changes[i].Item.ItemId.Value]
+	}
+}
+
+/*
+Write the changes of a bulk request and fill in their results.
+
+The changes are committed in transactions of up to MAX_TRANSACT_ITEMS writes
+together with the user's sync revision. When an item of a transaction was
+modified in the meantime it is reported as a conflict and the rest of the
+transaction is retried. A change with more writes than fit in a transaction,
+i.e a rekey dropping a long history, fails with a 400
+*/
+func ApplyBulkChanges(client *dynamoclient.DynamoClient, userId string, changes []BulkChange, results []BulkItemResult) {
+	chunk := []BulkChange{}
+	writes := 1
+
+	for _, change := range changes {
+		count := len(change.writes(userId, 0))
+
+		if 1+count > dynamoclient.MAX_TRANSACT_ITEMS {
+			results[change.Index].Status = 400
+			results[change.Index].Message = "Vault item has too many revisions to change in a single transaction"
+			continue
+		}
+
+		if writes+count > dynamoclient.MAX_TRANSACT_ITEMS {
+			commitBulkChanges(client, userId, chunk, results)
+			chunk = []BulkChange{}
+			writes = 1
+		}
+
+		chunk = append(chunk, change)
+		writes += count
+	}
+
+	if len(chunk) > 0 {
+		commitBulkChanges(client, userId, chunk, results)
+	}
+}
+
+func commitBulkChanges(client *dynamoclient.DynamoClient, userId string, pending []BulkChange, results []BulkItemResult) {
+	for attempt := 0; attempt < MAX_COMMIT_ATTEMPTS && len(pending) > 0; attempt++ {
+		response := GetSyncRevision(client, userId)
+
+		if !response.IsSuccess {
+			failBulkChanges(pending, results, response.Error.StatusCode, response.Error.Message)
+			return
+		}
+
+		current := response.Data.(int)
+		items := []dynamoclient.DynamoTransactItem{syncRevisionUpdate(userId, current)}
+		offsets := make([]int, len(pending))
+
+		for i, change := range pending {
+			offsets[i] = len(items)
+			items = append(items, change.writes(userId, current+1)...)
+		}
+
+		response = client.TransactWrite(items)
+
+		if response.IsSuccess {
+			for _, change := range pending {
+				change.Item.SyncRevision.Value = current + 1
+				results[change.Index] = bulkResult(change.Item.ItemId.Value, change.status(), "", change.Item)
+			}
+
+			return
+		}
+
+		reasons, _ := response.Error.Details.([]string)
+
+		if len(reasons) != len(items) {
+			failBulkChanges(pending, results, response.Error.StatusCode, response.Error.Message)
+			return
+		}
+
+		if reasons[0] == dynamoclient.CONDITIONAL_CHECK_FAILED {
+			continue
+		}
+
+		remaining := []BulkChange{}
+
+		for i, change := range pending {
+			if reasons[offsets[i]] == dynamoclient.CONDITIONAL_CHECK_FAILED {
+				results[change.Index].Status = 409
+				results[change.Index].Message = change.conflictMessage()
+				continue
+			}
+
+			remaining = append(remaining, change)
+		}
+
+		if len(remaining) == len(pending) {
+			failBulkChanges(pending, results, response.Error.StatusCode, response.Error.Message)
+			return
+		}
+
+		pending = remaining
+	}
+
+	failBulkChanges(pending, results, 409, "Too many concurrent writes to the vault")
+}
+
+// The writes of a change at the given sync revision. The first write is the item itself
+func (change BulkChange) writes(userId string, syncRevision int) []dynamoclient.DynamoTransactItem {
+	values := map[string]dynamoclient.DynamoUpdateItem{
+		"SYNC_REVISION": {
+			Action: dynamoTypes.AttributeActionPut,
+			Value:  syncRevision,
+		},
+	}
+
+	for key, value := range change.Values {
+		values[key] = value
+	}
+
+	writes := []dynamoclient.DynamoTransactItem{{
+		Update: &dynamoclient.DyanamoUpdateRequest{
+			Key:       userId,
+			SortKey:   change.Item.ItemKey.Value,
+			Values:    values,
+			Condition: revisionCondition(change.Revision),
+		},
+	}}
+
+	for _, sortKey := range change.Deletes {
+		writes = append(writes, dynamoclient.DynamoTransactItem{
+			Delete: &dynamoclient.DynamoDeleteRequest{
+				Key:     userId,
+				SortKey: sortKey,
+			},
+		})
+	}
+
+	writes = append(writes, change.Markers...)
+
+	if change.Create {
+		// An item that is recreated with the id of a purged item is no longer deleted
+		writes = append(writes, dynamoclient.DynamoTransactItem{
+			Delete: &dynamoclient.DynamoDeleteRequest{
+				Key:     userId,
+				SortKey: TombstoneKey(change.Item.ItemId.Value),
+			},
+		})
+	}
+
+	return writes
+}
+
+func (change BulkChange) status() int {
+	if change.Create {
+		return 201
+	}
+
+	return 200
+}
+
+func (change BulkChange) conflictMessage() string {
+	if change.Create {
+		return "Vault item already exists"
+	}
+
+	return "Vault item was modified by another client"
+}
+
+func failBulkChanges(changes []BulkChange, results []BulkItemResult, statusCode int, message string) {
+	for _, change := range changes {
+		results[change.Index].Status = statusCode
+		results[change.Index].Message = message
+	}
+}
+
+func bulkResult(id string, status int, message string, item types.VaultItem) BulkItemResult {
+	response := ToItemResponse(item)
+
+	return BulkItemResult{
+		Id:      id,
+		Status:  status,
+		Message: message,
+		Item:    &response,
+	}
+}
+
+// Set an attribute or remove it when the value is empty
+func removeWhenEmpty(value string) dynamoclient.DynamoUpdateItem {
+	if value == "" {
+		return dynamoclient.DynamoUpdateItem{Action: dynamoTypes.AttributeActionDelete}
+	}
+
+	return dynamoclient.DynamoUpdateItem{
+		Action: dynamoTypes.AttributeActionPut,
+		Value:  value,
+	}
+}
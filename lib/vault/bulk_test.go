@@ -0,0 +1,204 @@
+package vault
+
+import (
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/dynamoclient"
+	"testing"
+	"time"
+)
+
+func bulkItems() map[string]types.VaultItem {
+	active := types.VaultItem{}
+	active.ItemId.Value = "a"
+	active.ItemKey.Value = ItemKey("a")
+	active.Revision.Value = 3
+
+	trashed := types.VaultItem{}
+	trashed.ItemId.Value = "b"
+	trashed.ItemKey.Value = ItemKey("b")
+	trashed.Revision.Value = 1
+	trashed.DeletedAt.Value = "2022-03-01T00:00:00Z"
+
+	return map[string]types.VaultItem{"a": active, "b": trashed}
+}
+
+func revision(value int) *int {
+	return &value
+}
+
+func TestIsValidBulkAction(t *testing.T) {
+	actions := map[string]bool{"move": true, "delete": true, "restore": true, "rekey": true, "purge": false, "": false}
+
+	for action, expected := range actions {
+		actual := IsValidBulkAction(action)
+		if actual != expected {
+			t.Errorf("FAILED - TestIsValidBulkAction | Action: %s | Actual: %v | Expected: %v", action, actual, expected)
+		}
+	}
+}
+
+func TestBulkMaxItemsDefault(t *testing.T) {
+	actual := BulkMaxItems()
+	expected := 500
+
+	if actual != expected {
+		t.Errorf("FAILED - TestBulkMaxItemsDefault | Actual: %d | Expected: %d", actual, expected)
+	}
+}
+
+func TestPlanBulkChangesMissingItem(t *testing.T) {
+	results, changes := PlanBulkChanges(BULK_ACTION_DELETE, "", []BulkItem{{Id: "c", Revision: revision(1)}}, bulkItems(), time.Now())
+
+	if len(changes) != 0 || results[0].Status != 404 {
+		t.Errorf("FAILED - TestPlanBulkChangesMissingItem | Actual: %d changes, status %d | Expected: 0 changes, status 404", len(changes), results[0].Status)
+	}
+}
+
+func TestPlanBulkChangesRevisionConflict(t *testing.T) {
+	results, changes := PlanBulkChanges(BULK_ACTION_REKEY, "", []BulkItem{{Id: "a", Revision: revision(2), Data: "new"}}, bulkItems(), time.Now())
+
+	if len(changes) != 0 || results[0].Status != 409 || results[0].Item.Revision != 3 {
+		t.Errorf("FAILED - TestPlanBulkChangesRevisionConflict | Actual: %+v | Expected: 409 with the current item", results[0])
+	}
+}
+
+func TestPlanBulkChangesMove(t *testing.T) {
+	results, changes := PlanBulkChanges(BULK_ACTION_MOVE, "f", []BulkItem{{Id: "a", Revision: revision(3)}, {Id: "b", Revision: revision(1)}}, bulkItems(), time.Now())
+
+	if len(changes) != 1 || changes[0].Index != 0 || changes[0].Item.FolderId.Value != "f" {
+		t.Errorf("FAILED - TestPlanBulkChangesMove | Actual: %+v | Expected: a single change moving item a", changes)
+	}
+
+	if changes[0].Revision != 3 || changes[0].Item.Revision.Value != 4 {
+		t.Errorf("FAILED - TestPlanBulkChangesMove | Actual: %d -> %d | Expected: 3 -> 4", changes[0].Revision, changes[0].Item.Revision.Value)
+	}
+
+	if results[1].Status != 409 {
+		t.Errorf("FAILED - TestPlanBulkChangesMove | Actual: %d | Expected: 409 for an item in the trash", results[1].Status)
+	}
+}
+
+func TestPlanBulkChangesDeleteSkipsTrashedItems(t *testing.T) {
+	results, changes := PlanBulkChanges(BULK_ACTION_DELETE, "", []BulkItem{{Id: "a", Revision: revision(3)}, {Id: "b", Revision: revision(1)}}, bulkItems(), time.Now())
+
+	if len(changes) != 1 || changes[0].Item.DeletedAt.Value == "" {
+		t.Errorf("FAILED - TestPlanBulkChangesDeleteSkipsTrashedItems | Actual: %+v | Expected: a single change trashing item a", changes)
+	}
+
+	if results[1].Status != 200 {
+		t.Errorf("FAILED - TestPlanBulkChangesDeleteSkipsTrashedItems | Actual: %d | Expected: 200", results[1].Status)
+	}
+}
+
+func TestPlanBulkChangesRestore(t *testing.T) {
+	results, changes := PlanBulkChanges(BULK_ACTION_RESTORE, "", []BulkItem{{Id: "a", Revision: revision(3)}, {Id: "b", Revision: revision(1)}}, bulkItems(), time.Now())
+
+	if len(changes) != 1 || changes[0].Index != 1 || changes[0].Item.DeletedAt.Value != "" {
+		t.Errorf("FAILED - TestPlanBulkChangesRestore | Actual: %+v | Expected: a single change restoring item b", changes)
+	}
+
+	if results[0].Status != 404 {
+		t.Errorf("FAILED - TestPlanBulkChangesRestore | Actual: %d | Expected: 404", results[0].Status)
+	}
+}
+
+func TestPlanBulkChangesRekeyIncludesTrashedItems(t *testing.T) {
+	_, changes := PlanBulkChanges(BULK_ACTION_REKEY, "", []BulkItem{{Id: "a", Revision: revision(3), Data: "x"}, {Id: "b", Revision: revision(1), Data: "y"}}, bulkItems(), time.Now())
+
+	if len(changes) != 2 || changes[1].Item.Data.Value != "y" {
+		t.Errorf("FAILED - TestPlanBulkChangesRekeyIncludesTrashedItems | Actual: %+v | Expected: both items rekeyed", changes)
+	}
+}
+
+func TestDropRekeyedHistory(t *testing.T) {
+	_, changes := PlanBulkChanges(BULK_ACTION_REKEY, "", []BulkItem{{Id: "a", Revision: revision(3), Data: "x"}, {Id: "b", Revision: revision(1), Data: "y"}}, bulkItems(), time.Now())
+
+	history := types.VaultItemHistory{}
+	history.ItemId.Value = "a"
+	history.ItemKey.Value = HistoryKey("a", 2)
+
+	DropRekeyedHistory(changes, []types.VaultItemHistory{history})
+
+	writes := changes[0].writes("user", 1)
+
+	if len(writes) != 2 || writes[1].Delete == nil || writes[1].Delete.SortKey != HistoryKey("a", 2) {
+		t.Errorf("FAILED - TestDropRekeyedHistory | Actual: %+v | Expected: the history of item a deleted with its rekey", writes)
+	}
+
+	if len(changes[1].writes("user", 1)) != 1 {
+		t.Errorf("FAILED - TestDropRekeyedHistory | Expected: no deletes for item b without history")
+	}
+}
+
+func TestBulkItemValidateRequiresRevision(t *testing.T) {
+	id := "0f8fad5b-d9cb-469f-a165-70867728950e"
+
+	tests := []struct {
+		item  BulkItem
+		valid bool
+	}{
+		{BulkItem{Id: id, Revision: revision(0)}, true},
+		{BulkItem{Id: id, Revision: revision(3)}, true},
+		{BulkItem{Id: id}, false},
+		{BulkItem{Id: id, Revision: revision(ANY_REVISION)}, false},
+		{BulkItem{Id: "a", Revision: revision(1)}, false},
+	}
+
+	for _, test := range tests {
+		if err := test.item.Validate(); (err == nil) != test.valid {
+			t.Errorf("FAILED - TestBulkItemValidateRequiresRevision | Item: %+v | Error: %v | Expected valid: %v", test.item, err, test.valid)
+		}
+	}
+}
+
+func TestPlanBulkChangesWithoutRevision(t *testing.T) {
+	for _, action := range []string{BULK_ACTION_MOVE, BULK_ACTION_DELETE, BULK_ACTION_RESTORE, BULK_ACTION_REKEY} {
+		results, changes := PlanBulkChanges(action, "f", []BulkItem{{Id: "a", Data: "x"}, {Id: "b", Data: "y"}}, bulkItems(), time.Now())
+
+		if len(changes) != 0 || results[0].Status != 400 || results[1].Status != 400 {
+			t.Errorf("FAILED - TestPlanBulkChangesWithoutRevision | Action: %s | Actual: %+v | Expected: 400 for every item", action, results)
+		}
+	}
+}
+
+func TestApplyBulkChangesRejectsOversizedChange(t *testing.T) {
+	results, changes := PlanBulkChanges(BULK_ACTION_REKEY, "", []BulkItem{{Id: "a", Revision: revision(3), Data: "x"}}, bulkItems(), time.Now())
+
+	histories := []types.VaultItemHistory{}
+
+	for i := 1; i < dynamoclient.MAX_TRANSACT_ITEMS; i++ {
+		history := types.VaultItemHistory{}
+		history.ItemId.Value = "a"
+		history.ItemKey.Value = HistoryKey("a", i)
+		histories = append(histories, history)
+	}
+
+	DropRekeyedHistory(changes, histories)
+
+	// Nothing is written, so no client is needed
+	ApplyBulkChanges(nil, "user", changes, results)
+
+	if results[0].Status != 400 {
+		t.Errorf("FAILED - TestApplyBulkChangesRejectsOversizedChange | Actual: %+v | Expected: 400", results[0])
+	}
+}
+
+func TestPlanBulkChangesWritesTrashMarkers(t *testing.T) {
+	now := time.Date(2022, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	_, changes := PlanBulkChanges(BULK_ACTION_DELETE, "", []BulkItem{{Id: "a", Revision: revision(3)}}, bulkItems(), now)
+
+	if len(changes) != 1 || len(changes[0].Markers) != 1 || changes[0].Markers[0].Put == nil || changes[0].Markers[0].Put.SortKey != TrashKey("2022-04-01T00:00:00Z", "", "a") {
+		t.Errorf("FAILED - TestPlanBulkChangesWritesTrashMarkers - Delete | Actual: %+v | Expected: a marker at the time of the delete", changes)
+	}
+
+	_, changes = PlanBulkChanges(BULK_ACTION_RESTORE, "", []BulkItem{{Id: "b", Revision: revision(1)}}, bulkItems(), now)
+
+	if len(changes) != 1 || len(changes[0].Markers) != 1 || changes[0].Markers[0].Delete == nil || changes[0].Markers[0].Delete.SortKey != TrashKey("2022-03-01T00:00:00Z", "", "b") {
+		t.Errorf("FAILED - TestPlanBulkChangesWritesTrashMarkers - Restore | Actual: %+v | Expected: the marker of the trashed item to be removed", changes)
+	}
+
+	if writes := changes[0].writes("", 1); len(writes) != 2 {
+		t.Errorf("FAILED - TestPlanBulkChangesWritesTrashMarkers - Writes | Actual: %d | Expected: 2", len(writes))
+	}
+}
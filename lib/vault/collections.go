@@ -0,0 +1,122 @@
+package vault
+
+import (
+	"time"
+
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/dynamoclient"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/util"
+)
+
+// Collection names are encrypted with the organization key. Access is only
+// sent to members who can manage the collection
+type VaultCollectionResponse struct {
+	Id        string            `json:"id"`
+	Name      string            `json:"name"`
+	Access    map[string]string `json:"access,omitempty"`
+	Level     string            `json:"level,omitempty"`
+	Revision  int               `json:"revision"`
+	UpdatedAt string            `json:"updatedAt"`
+}
+
+// The access level of every member granted access to a collection, keyed by email
+func AccessOf(collection types.VaultCollection) map[string]string {
+	access := map[string]string{}
+
+	if collection.Access.Value != "" {
+		util.DeserializeJson(collection.Access.Value, &access)
+	}
+
+	return access
+}
+
+func ToCollectionResponse(collection types.VaultCollection) VaultCollectionResponse {
+	return VaultCollectionResponse{
+		Id:        collection.CollectionId.Value,
+		Name:      collection.Name.Value,
+		Access:    AccessOf(collection),
+		Revision:  collection.Revision.Value,
+		UpdatedAt: collection.UpdatedAt.Value,
+	}
+}
+
+// Build the 409 returned when the client's revision of a collection is out of date
+func CollectionConflictResult(current types.VaultCollection) *result.Result {
+	return result.FailureWithDetails(
+		409,
+		"Collection was modified by another client",
+		ToCollectionResponse(current),
+	)
+}
+
+// Get a single collection. A missing collection results in an empty VaultCollection
+func GetCollection(client *dynamoclient.DynamoClient, partition, collectionId string) *dynamoclient.DynamoResponse {
+	return client.
+		Get(dynamoclient.DynamoGetRequest{
+			Key:     partition,
+			SortKey: CollectionKey(collectionId),
+		}).
+		AsVaultCollection()
+}
+
+// Get all collections of an organization's partition
+func ListCollections(client *dynamoclient.DynamoClient, partition string) *dynamoclient.DynamoResponse {
+	return client.
+		Query(dynamoclient.DynamoQueryRequest{
+			Key:            partition,
+			SortKeyPrefix:  COLLECTION_PREFIX,
+			ConsistentRead: true,
+		}).
+		AsVaultCollections()
+}
+
+// Save a collection as the revision following expectedRevision.
+// Fails with a 409 when the collection is no longer at expectedRevision.
+// The saved collection is returned as the response data
+func PutCollection(client *dynamoclient.DynamoClient, collection types.VaultCollection, expectedRevision int) *dynamoclient.DynamoResponse {
+	collection.ItemKey.Value = CollectionKey(collection.CollectionId.Value)
+	collection.Revision.Value = expectedRevision + 1
+	collection.UpdatedAt.Value = time.Now().UTC().Format(time.RFC3339)
+
+	response := commit(client, collection.UserId.Value, func(syncRevision int) []dynamoclient.DynamoTransactItem {
+		collection.SyncRevision.Value = syncRevision
+
+		return []dynamoclient.DynamoTransactItem{
+			{
+				Put: &dynamoclient.DynamoPutRequest{
+					Key:     collection.UserId.Value,
+					SortKey: collection.ItemKey.Value,
+					Values: map[string]interface{}{
+						"COLLECTION_ID": collection.CollectionId.Value,
+						"NAME":          collection.Name.Value,
+						"ACCESS":        collection.Access.Value,
+						"REVISION":      collection.Revision.Value,
+						"SYNC_REVISION": collection.SyncRevision.Value,
+						"UPDATED_AT":    collection.UpdatedAt.Value,
+					},
+					Condition: revisionCondition(expectedRevision),
+				},
+			},
+			{
+				Delete: &dynamoclient.DynamoDeleteRequest{
+					Key:     collection.UserId.Value,
+					SortKey: TombstoneKey(collection.CollectionId.Value),
+				},
+			},
+		}
+	})
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	return dynamoclient.SuccessWithValue(collection)
+}
+
+// Delete a collection and leave a tombstone for the sync. The items of the
+// collection keep its id, only members who manage all collections see
+// items left without any collection
+func DeleteCollection(client *dynamoclient.DynamoClient, partition, collectionId string) *dynamoclient.DynamoResponse {
+	return deleteWithTombstone(client, partition, CollectionKey(collectionId), collectionId, TOMBSTONE_TYPE_COLLECTION)
+}
@@ -0,0 +1,93 @@
+package vault
+
+import (
+	"time"
+
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/dynamoclient"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/util"
+)
+
+/*
+Groups of base domains the user wants treated as the same site when logins
+are matched (i.e google.com and youtube.com)
+*/
+type EquivalentDomainsResponse struct {
+	Groups    [][]string `json:"groups"`
+	Revision  int        `json:"revision"`
+	UpdatedAt string     `json:"updatedAt,omitempty"`
+}
+
+// An account without equivalent domains has no groups at revision 0
+func ToDomainsResponse(domains types.VaultDomains) EquivalentDomainsResponse {
+	groups := [][]string{}
+
+	if domains.Groups.Value != "" {
+		util.DeserializeJson(domains.Groups.Value, &groups)
+	}
+
+	return EquivalentDomainsResponse{
+		Groups:    groups,
+		Revision:  domains.Revision.Value,
+		UpdatedAt: domains.UpdatedAt.Value,
+	}
+}
+
+// Build the 409 returned when the client's revision of the equivalent domains is out of date
+func DomainsConflictResult(current types.VaultDomains) *result.Result {
+	return result.FailureWithDetails(
+		409,
+		"Equivalent domains were modified by another client",
+		ToDomainsResponse(current),
+	)
+}
+
+// Get the equivalent domains of a user. Without any the response is an empty VaultDomains
+func GetDomains(client *dynamoclient.DynamoClient, userId string) *dynamoclient.DynamoResponse {
+	return client.
+		Get(dynamoclient.DynamoGetRequest{
+			Key:     userId,
+			SortKey: DOMAINS_KEY,
+		}).
+		AsVaultDomains()
+}
+
+// Save the equivalent domains of a user as the revision following expectedRevision.
+// Fails with a 409 when they are no longer at expectedRevision.
+// The saved domains are returned as the response data
+func PutDomains(client *dynamoclient.DynamoClient, userId string, groups [][]string, expectedRevision int) *dynamoclient.DynamoResponse {
+	var domains types.VaultDomains
+
+	domains.UserId.Value = userId
+	domains.ItemKey.Value = DOMAINS_KEY
+	domains.Groups.Value = util.SerializeJson(groups)
+	domains.Revision.Value = expectedRevision + 1
+	domains.UpdatedAt.Value = time.Now().UTC().Format(time.RFC3339)
+
+	response := commit(client, userId, func(syncRevision int) []dynamoclient.DynamoTransactItem {
+		domains.SyncRevision.Value = syncRevision
+
+		return []dynamoclient.DynamoTransactItem{
+			{
+				Put: &dynamoclient.DynamoPutRequest{
+					Key:     userId,
+					SortKey: DOMAINS_KEY,
+					Values: map[string]interface{}{
+						"GROUPS":        domains.Groups.Value,
+						"REVISION":      domains.Revision.Value,
+						"SYNC_REVISION": domains.SyncRevision.Value,
+						"UPDATED_AT":    domains.UpdatedAt.Value,
+					},
+					Condition: revisionCondition(expectedRevision),
+				},
+			},
+		}
+	})
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	return dynamoclient.SuccessWithValue(domains)
+}
@@ -0,0 +1,103 @@
+package vault
+
+import (
+	"time"
+
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/dynamoclient"
+	"password-caddy/api/lib/result"
+)
+
+// Folder names are encrypted by the clients like the item data
+type VaultFolderResponse struct {
+	Id        string `json:"id"`
+	Name      string `json:"name"`
+	Revision  int    `json:"revision"`
+	UpdatedAt string `json:"updatedAt"`
+}
+
+func ToFolderResponse(folder types.VaultFolder) VaultFolderResponse {
+	return VaultFolderResponse{
+		Id:        folder.FolderId.Value,
+		Name:      folder.Name.Value,
+		Revision:  folder.Revision.Value,
+		UpdatedAt: folder.UpdatedAt.Value,
+	}
+}
+
+// Build the 409 returned when the client's revision of a folder is out of date
+func FolderConflictResult(current types.VaultFolder) *result.Result {
+	return result.FailureWithDetails(
+		409,
+		"Folder was modified by another client",
+		ToFolderResponse(current),
+	)
+}
+
+// Get a single folder. A missing folder results in an empty VaultFolder
+func GetFolder(client *dynamoclient.DynamoClient, userId, folderId string) *dynamoclient.DynamoResponse {
+	return client.
+		Get(dynamoclient.DynamoGetRequest{
+			Key:     userId,
+			SortKey: FolderKey(folderId),
+		}).
+		AsVaultFolder()
+}
+
+// Get all folders of a user's vault
+func ListFolders(client *dynamoclient.DynamoClient, userId string) *dynamoclient.DynamoResponse {
+	return client.
+		Query(dynamoclient.DynamoQueryRequest{
+			Key:           userId,
+			SortKeyPrefix: FOLDER_PREFIX,
+		}).
+		AsVaultFolders()
+}
+
+// Save a folder as the revision following expectedRevision.
+// Fails with a 409 when the folder is no longer at expectedRevision.
+// The saved folder is returned as the response data
+func PutFolder(client *dynamoclient.DynamoClient, folder types.VaultFolder, expectedRevision int) *dynamoclient.DynamoResponse {
+	folder.ItemKey.Value = FolderKey(folder.FolderId.Value)
+	folder.Revision.Value = expectedRevision + 1
+	folder.UpdatedAt.Value = time.Now().UTC().Format(time.RFC3339)
+
+	response := commit(client, folder.UserId.Value, func(syncRevision int) []dynamoclient.DynamoTransactItem {
+		folder.SyncRevision.Value = syncRevision
+
+		return []dynamoclient.DynamoTransactItem{
+			{
+				Put: &dynamoclient.DynamoPutRequest{
+					Key:     folder.UserId.Value,
+					SortKey: folder.ItemKey.Value,
+					Values: map[string]interface{}{
+						"FOLDER_ID":     folder.FolderId.Value,
+						"NAME":          folder.Name.Value,
+						"REVISION":      folder.Revision.Value,
+						"SYNC_REVISION": folder.SyncRevision.Value,
+						"UPDATED_AT":    folder.UpdatedAt.Value,
+					},
+					Condition: revisionCondition(expectedRevision),
+				},
+			},
+			{
+				Delete: &dynamoclient.DynamoDeleteRequest{
+					Key:     folder.UserId.Value,
+					SortKey: TombstoneKey(folder.FolderId.Value),
+				},
+			},
+		}
+	})
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	return dynamoclient.SuccessWithValue(folder)
+}
+
+// Delete a folder and leave a tombstone for the sync. The items of the
+// folder keep their folder id, clients show them outside of any folder
+func DeleteFolder(client *dynamoclient.DynamoClient, userId, folderId string) *dynamoclient.DynamoResponse {
+	return deleteWithTombstone(client, userId, FolderKey(folderId), folderId, TOMBSTONE_TYPE_FOLDER)
+}
@@ -0,0 +1,256 @@
+package vault
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"regexp"
+	"sort"
+	"time"
+
+	appConfig "password-caddy/api/core/config"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/dynamoclient"
+)
+
+// Bounds of the score of the strength estimator
+const (
+	MIN_HEALTH_SCORE = 0
+	MAX_HEALTH_SCORE = 4
+)
+
+// Fingerprints are hex or base64 encoded hashes computed by the clients
+var fingerprintPattern = regexp.MustCompile(`^[A-Za-z0-9+/=_-]{16,128}$`)
+
+/*
+Health of a revision of an item as reported by a client. The server never
+sees the password, the client computes the salted fingerprint, the strength
+score and if the password is breached
+*/
+type VaultItemHealthRequest struct {
+	Revision          int    `json:"revision"`
+	Fingerprint       string `json:"fingerprint"`
+	Score             int    `json:"score"`
+	Breached          bool   `json:"breached"`
+	PasswordChangedAt string `json:"passwordChangedAt"`
+}
+
+type VaultItemHealthResponse struct {
+	Id                string `json:"id"`
+	Revision          int    `json:"revision"`
+	Score             int    `json:"score"`
+	Reused            bool   `json:"reused"`
+	Weak              bool   `json:"weak"`
+	Breached          bool   `json:"breached"`
+	Stale             bool   `json:"stale"`
+	PasswordChangedAt string `json:"passwordChangedAt,omitempty"`
+}
+
+/*
+Counts of the items of a vault with health issues. Items without health
+metadata of their current data are unreported. Items lists the reported
+items with at least one issue
+*/
+type VaultHealthReport struct {
+	Total        int                       `json:"total"`
+	Reported     int                       `json:"reported"`
+	Unreported   int                       `json:"unreported"`
+	Reused       int                       `json:"reused"`
+	ReusedGroups int                       `json:"reusedGroups"`
+	Weak         int                       `json:"weak"`
+	Breached     int                       `json:"breached"`
+	Stale        int                       `json:"stale"`
+	Items        []VaultItemHealthResponse `json:"items"`
+}
+
+// Scores up to the weak score count as weak passwords
+func HealthWeakScore() int {
+	return int(appConfig.Get("VAULT_HEALTH_WEAK_SCORE", "2").ToInt64())
+}
+
+// Passwords that were not changed for longer count as stale
+func HealthStaleAge() time.Duration {
+	days := appConfig.Get("VAULT_HEALTH_STALE_DAYS", "365").ToInt64()
+	return time.Duration(days) * 24 * time.Hour
+}
+
+func (request VaultItemHealthRequest) Validate() error {
+	if !fingerprintPattern.MatchString(request.Fingerprint) {
+		return errors.New("Fingerprint must be a hex or base64 encoded hash of 16 to 128 characters")
+	}
+
+	if request.Score < MIN_HEALTH_SCORE || request.Score > MAX_HEALTH_SCORE {
+		return errors.New("Score must be between 0 and 4")
+	}
+
+	if request.Revision < 1 {
+		return errors.New("Revision must be the revision of the item the health was computed for")
+	}
+
+	if request.PasswordChangedAt != "" {
+		if _, err := time.Parse(time.RFC3339, request.PasswordChangedAt); err != nil {
+			return errors.New("Password changed at must be an RFC 3339 timestamp")
+		}
+	}
+
+	return nil
+}
+
+// Hash of the encrypted data of an item, which its health is kept for
+func DataHash(data string) string {
+	hash := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(hash[:])
+}
+
+/*
+Check if the health is of the current data of the item. Moving, trashing and
+restoring an item bump its revision without touching its data, so they keep
+the health. Health saved without a data hash only matches its revision
+*/
+func HealthMatches(health types.VaultItemHealth, item types.VaultItem) bool {
+	if health.DataHash.Value == "" {
+		return health.Revision.Value == item.Revision.Value
+	}
+
+	return health.DataHash.Value == DataHash(item.Data.Value)
+}
+
+// Get the health metadata of all items of a user's vault
+func ListHealth(client *dynamoclient.DynamoClient, userId string) *dynamoclient.DynamoResponse {
+	return client.
+		Query(dynamoclient.DynamoQueryRequest{
+			Key:           userId,
+			SortKeyPrefix: HEALTH_PREFIX,
+		}).
+		AsVaultItemHealths()
+}
+
+// Save the health metadata of an item, replacing the one of a previous revision
+func PutHealth(client *dynamoclient.DynamoClient, health types.VaultItemHealth) *dynamoclient.DynamoResponse {
+	health.ItemKey.Value = HealthKey(health.ItemId.Value)
+	health.UpdatedAt.Value = time.Now().UTC().Format(time.RFC3339)
+
+	values := map[string]interface{}{
+		"ITEM_ID":     health.ItemId.Value,
+		"REVISION":    health.Revision.Value,
+		"DATA_HASH":   health.DataHash.Value,
+		"FINGERPRINT": health.Fingerprint.Value,
+		"SCORE":       health.Score.Value,
+		"BREACHED":    health.Breached.Value,
+		"UPDATED_AT":  health.UpdatedAt.Value,
+	}
+
+	if health.PasswordChangedAt.Value != "" {
+		values["PASSWORD_CHANGED_AT"] = health.PasswordChangedAt.Value
+	}
+
+	response := client.Put(dynamoclient.DynamoPutRequest{
+		Key:     health.UserId.Value,
+		SortKey: health.ItemKey.Value,
+		Values:  values,
+	})
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	return dynamoclient.SuccessWithValue(health)
+}
+
+/*
+Aggregate the health of the items that are not in the trash. The health of
+older data of an item is ignored. Without a password changed at the
+last update of the item is used
+*/
+func BuildHealthReport(items []types.VaultItem, healths []types.VaultItemHealth, now time.Time) VaultHealthReport {
+	report := VaultHealthReport{Items: []VaultItemHealthResponse{}}
+	byItem := map[string]types.VaultItemHealth{}
+
+	for _, health := range healths {
+		byItem[health.ItemId.Value] = health
+	}
+
+	weakScore := HealthWeakScore()
+	staleBefore := now.Add(-HealthStaleAge())
+	reported := []VaultItemHealthResponse{}
+	fingerprints := map[string][]int{}
+
+	for _, item := range items {
+		if item.DeletedAt.Value != "" {
+			continue
+		}
+
+		report.Total++
+
+		health, ok := byItem[item.ItemId.Value]
+
+		if !ok || !HealthMatches(health, item) {
+			report.Unreported++
+			continue
+		}
+
+		changedAt := health.PasswordChangedAt.Value
+
+		if changedAt == "" {
+			changedAt = item.UpdatedAt.Value
+		}
+
+		response := VaultItemHealthResponse{
+			Id:                item.ItemId.Value,
+			Revision:          health.Revision.Value,
+			Score:             health.Score.Value,
+			Weak:              health.Score.Value <= weakScore,
+			Breached:          health.Breached.Value,
+			PasswordChangedAt: health.PasswordChangedAt.Value,
+		}
+
+		if changed, err := time.Parse(time.RFC3339, changedAt); err == nil {
+			response.Stale = changed.Before(staleBefore)
+		}
+
+		fingerprints[health.Fingerprint.Value] = append(fingerprints[health.Fingerprint.Value], len(reported))
+		reported = append(reported, response)
+	}
+
+	for _, indexes := range fingerprints {
+		if len(indexes) < 2 {
+			continue
+		}
+
+		report.ReusedGroups++
+
+		for _, index := range indexes {
+			reported[index].Reused = true
+		}
+	}
+
+	for _, response := range reported {
+		report.Reported++
+
+		if response.Reused {
+			report.Reused++
+		}
+
+		if response.Weak {
+			report.Weak++
+		}
+
+		if response.Breached {
+			report.Breached++
+		}
+
+		if response.Stale {
+			report.Stale++
+		}
+
+		if response.Reused || response.Weak || response.Breached || response.Stale {
+			report.Items = append(report.Items, response)
+		}
+	}
+
+	sort.Slice(report.Items, func(a, b int) bool {
+		return report.Items[a].Id < report.Items[b].Id
+	})
+
+	return report
+}
@@ -0,0 +1,106 @@
+package vault
+
+import (
+	"password-caddy/api/core/types"
+	"testing"
+	"time"
+)
+
+func healthItem(id string, revision int, updatedAt string) types.VaultItem {
+	item := types.VaultItem{}
+	item.ItemId.Value = id
+	item.Revision.Value = revision
+	item.UpdatedAt.Value = updatedAt
+
+	return item
+}
+
+func itemHealth(id string, revision int, fingerprint string, score int, breached bool, changedAt string) types.VaultItemHealth {
+	health := types.VaultItemHealth{}
+	health.ItemId.Value = id
+	health.Revision.Value = revision
+	health.Fingerprint.Value = fingerprint
+	health.Score.Value = score
+	health.Breached.Value = breached
+	health.PasswordChangedAt.Value = changedAt
+
+	return health
+}
+
+func TestBuildHealthReport(t *testing.T) {
+	now := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	recent := "2022-05-01T00:00:00Z"
+
+	trashed := healthItem("e", 1, recent)
+	trashed.DeletedAt.Value = recent
+
+	items := []types.VaultItem{
+		healthItem("a", 1, recent),
+		healthItem("b", 2, recent),
+		healthItem("c", 1, "2020-01-01T00:00:00Z"),
+		healthItem("d", 3, recent),
+		trashed,
+	}
+
+	healths := []types.VaultItemHealth{
+		itemHealth("a", 1, "fingerprint-0000001", 4, false, ""),
+		itemHealth("b", 2, "fingerprint-0000001", 1, true, ""),
+		itemHealth("c", 1, "fingerprint-0000002", 4, false, ""),
+		// Health of an older revision
+		itemHealth("d", 2, "fingerprint-0000003", 0, false, ""),
+		itemHealth("e", 1, "fingerprint-0000004", 0, false, ""),
+	}
+
+	actual := BuildHealthReport(items, healths, now)
+	expected := VaultHealthReport{Total: 4, Reported: 3, Unreported: 1, Reused: 2, ReusedGroups: 1, Weak: 1, Breached: 1, Stale: 1}
+
+	if actual.Total != expected.Total || actual.Reported != expected.Reported || actual.Unreported != expected.Unreported ||
+		actual.Reused != expected.Reused || actual.ReusedGroups != expected.ReusedGroups || actual.Weak != expected.Weak ||
+		actual.Breached != expected.Breached || actual.Stale != expected.Stale {
+		t.Errorf("FAILED - TestBuildHealthReport | Actual: %+v | Expected: %+v", actual, expected)
+	}
+
+	if len(actual.Items) != 3 || actual.Items[0].Id != "a" || !actual.Items[1].Weak || !actual.Items[2].Stale {
+		t.Errorf("FAILED - TestBuildHealthReport | Actual: %+v | Expected: the items a, b and c", actual.Items)
+	}
+}
+
+func TestVaultItemHealthRequestValidate(t *testing.T) {
+	valid := VaultItemHealthRequest{Revision: 1, Fingerprint: "c2FsdGVkLWZpbmdlcnByaW50", Score: 3}
+
+	if err := valid.Validate(); err != nil {
+		t.Errorf("FAILED - TestVaultItemHealthRequestValidate | Actual: %s | Expected: no error", err.Error())
+	}
+
+	invalid := []VaultItemHealthRequest{
+		{Revision: 1, Fingerprint: "short", Score: 3},
+		{Revision: 1, Fingerprint: "c2FsdGVkLWZpbmdlcnByaW50", Score: 5},
+		{Revision: 0, Fingerprint: "c2FsdGVkLWZpbmdlcnByaW50", Score: 3},
+		{Revision: 1, Fingerprint: "c2FsdGVkLWZpbmdlcnByaW50", Score: 3, PasswordChangedAt: "yesterday"},
+	}
+
+	for _, request := range invalid {
+		if request.Validate() == nil {
+			t.Errorf("FAILED - TestVaultItemHealthRequestValidate | Request: %+v | Expected: an error", request)
+		}
+	}
+}
+
+func TestHealthMatchesData(t *testing.T) {
+	item := healthItem("a", 5, "")
+	item.Data.Value = "encrypted"
+
+	// Moved twice since the health was reported
+	health := itemHealth("a", 3, "fingerprint-0000001", 4, false, "")
+	health.DataHash.Value = DataHash("encrypted")
+
+	if !HealthMatches(health, item) {
+		t.Errorf("FAILED - TestHealthMatchesData | Expected: the health to survive revisions that keep the data")
+	}
+
+	health.DataHash.Value = DataHash("changed")
+
+	if HealthMatches(health, item) {
+		t.Errorf("FAILED - TestHealthMatchesData | Expected: the health of other data to be ignored")
+	}
+}
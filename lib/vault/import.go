@@ -0,0 +1,207 @@
+package vault
+
+import (
+	"time"
+
+	appConfig "password-caddy/api/core/config"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/dynamoclient"
+
+	dynamoTypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Kinds of the objects of an import
+const (
+	IMPORT_TYPE_ITEM   = "item"
+	IMPORT_TYPE_FOLDER = "folder"
+)
+
+// Name is encrypted by the client like the name of any other folder
+type ImportFolder struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// Data is the client encrypted item, i.e an item normalized by lib/importers
+type ImportItem struct {
+	Id       string `json:"id"`
+	FolderId string `json:"folderId"`
+	Data     string `json:"data"`
+}
+
+type ImportSkipped struct {
+	Id     string `json:"id"`
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+}
+
+// What was (or on a dry run would be) imported and what was skipped
+type ImportReport struct {
+	DryRun  bool            `json:"dryRun"`
+	Items   int             `json:"items"`
+	Folders int             `json:"folders"`
+	Skipped []ImportSkipped `json:"skipped"`
+}
+
+// Folders and item changes to write for an import
+type ImportPlan struct {
+	Folders []types.VaultFolder
+	Changes []BulkChange
+	Report  ImportReport
+}
+
+// Maximum number of items of a single import
+func ImportMaxItems() int {
+	return int(appConfig.Get("VAULT_IMPORT_MAX_ITEMS", "1000").ToInt64())
+}
+
+/*
+Plan an import against the current items and folders of the vault. Imports
+never overwrite anything, objects whose id already exists are skipped. Items
+can be imported into new or existing folders
+*/
+func PlanImport(userId string, folders []ImportFolder, items []ImportItem, currentItems map[string]types.VaultItem, currentFolders map[string]types.VaultFolder, now time.Time) ImportPlan {
+	plan := ImportPlan{
+		Folders: []types.VaultFolder{},
+		Changes: []BulkChange{},
+		Report:  ImportReport{Skipped: []ImportSkipped{}},
+	}
+
+	known := map[string]bool{}
+
+	for id := range currentFolders {
+		known[id] = true
+	}
+
+	for _, requested := range folders {
+		if known[requested.Id] {
+			plan.skip(requested.Id, IMPORT_TYPE_FOLDER, "Folder already exists")
+			continue
+		}
+
+		folder := types.VaultFolder{}
+		folder.UserId.Value = userId
+		folder.FolderId.Value = requested.Id
+		folder.Name.Value = requested.Name
+
+		known[requested.Id] = true
+		plan.Folders = append(plan.Folders, folder)
+	}
+
+	updatedAt := now.UTC().Format(time.RFC3339)
+
+	for _, requested := range items {
+		if _, ok := currentItems[requested.Id]; ok {
+			plan.skip(requested.Id, IMPORT_TYPE_ITEM, "Vault item already exists")
+			continue
+		}
+
+		if requested.FolderId != "" && !known[requested.FolderId] {
+			plan.skip(requested.Id, IMPORT_TYPE_ITEM, "Folder not found")
+			continue
+		}
+
+		item := types.VaultItem{}
+		item.UserId.Value = userId
+		item.ItemKey.Value = ItemKey(requested.Id)
+		item.ItemId.Value = requested.Id
+		item.FolderId.Value = requested.FolderId
+		item.Data.Value = requested.Data
+		item.Revision.Value = 1
+		item.UpdatedAt.Value = updatedAt
+
+		values := map[string]dynamoclient.DynamoUpdateItem{
+			"ITEM_ID":    {Action: dynamoTypes.AttributeActionPut, Value: requested.Id},
+			"DATA":       {Action: dynamoTypes.AttributeActionPut, Value: requested.Data},
+			"REVISION":   {Action: dynamoTypes.AttributeActionPut, Value: 1},
+			"UPDATED_AT": {Action: dynamoTypes.AttributeActionPut, Value: updatedAt},
+		}
+
+		if requested.FolderId != "" {
+			values["FOLDER_ID"] = dynamoclient.DynamoUpdateItem{Action: dynamoTypes.AttributeActionPut, Value: requested.FolderId}
+		}
+
+		plan.Changes = append(plan.Changes, BulkChange{
+			Index:  len(plan.Changes),
+			Item:   item,
+			Values: values,
+			Create: true,
+		})
+	}
+
+	plan.Report.Items = len(plan.Changes)
+	plan.Report.Folders = len(plan.Folders)
+
+	return plan
+}
+
+/*
+Put every item of an import into the collections of an organization.
+collectionIds are encoded like the COLLECTION_IDS of any other item, items of
+a user's vault have none
+*/
+func (plan *ImportPlan) InCollections(collectionIds string) {
+	if collectionIds == "" {
+		return
+	}
+
+	for i := range plan.Changes {
+		plan.Changes[i].Item.CollectionIds.Value = collectionIds
+		plan.Changes[i].Values["COLLECTION_IDS"] = dynamoclient.DynamoUpdateItem{Action: dynamoTypes.AttributeActionPut, Value: collectionIds}
+	}
+}
+
+func (plan *ImportPlan) skip(id, objectType, reason string) {
+	plan.Report.Skipped = append(plan.Report.Skipped, ImportSkipped{
+		Id:     id,
+		Type:   objectType,
+		Reason: reason,
+	})
+}
+
+/*
+Write the folders and items of an import and update its report. Folders are
+written first so no item ends up in a folder that does not exist yet
+*/
+func ApplyImport(client *dynamoclient.DynamoClient, userId string, plan ImportPlan) (ImportReport, *dynamoclient.DynamoResponse) {
+	report := plan.Report
+
+	for _, folder := range plan.Folders {
+		response := PutFolder(client, folder, 0)
+
+		if !response.IsSuccess && response.Error.StatusCode == 409 {
+			report.Folders--
+			report.Skipped = append(report.Skipped, ImportSkipped{
+				Id:     folder.FolderId.Value,
+				Type:   IMPORT_TYPE_FOLDER,
+				Reason: "Folder already exists",
+			})
+			continue
+		}
+
+		if !response.IsSuccess {
+			return report, response
+		}
+	}
+
+	results := make([]BulkItemResult, len(plan.Changes))
+
+	for i, change := range plan.Changes {
+		results[i].Id = change.Item.ItemId.Value
+	}
+
+	ApplyBulkChanges(client, userId, plan.Changes, results)
+
+	for _, result := range results {
+		if result.Status != 201 {
+			report.Items--
+			report.Skipped = append(report.Skipped, ImportSkipped{
+				Id:     result.Id,
+				Type:   IMPORT_TYPE_ITEM,
+				Reason: result.Message,
+			})
+		}
+	}
+
+	return report, dynamoclient.Success()
+}
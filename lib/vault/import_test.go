@@ -0,0 +1,201 @@
+package vault
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/export"
+	"testing"
+	"time"
+)
+
+// Encryption of a client, which the server never sees. The key is base64 encoded
+func clientEncrypt(t *testing.T, key, plaintext string) string {
+	aead := clientCipher(t, key)
+	nonce := make([]byte, aead.NonceSize())
+	rand.Read(nonce)
+
+	return base64.StdEncoding.EncodeToString(aead.Seal(nonce, nonce, []byte(plaintext), nil))
+}
+
+func clientDecrypt(t *testing.T, key, ciphertext string) string {
+	aead := clientCipher(t, key)
+	data, _ := base64.StdEncoding.DecodeString(ciphertext)
+
+	if len(data) < aead.NonceSize() {
+		t.Fatalf("FAILED - clientDecrypt | Ciphertext too short")
+	}
+
+	plaintext, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+
+	if err != nil {
+		t.Fatalf("FAILED - clientDecrypt | Error: %v", err)
+	}
+
+	return string(plaintext)
+}
+
+func clientCipher(t *testing.T, key string) cipher.AEAD {
+	raw, _ := base64.StdEncoding.DecodeString(key)
+	block, err := aes.NewCipher(raw)
+
+	if err != nil {
+		t.Fatalf("FAILED - clientCipher | Error: %v", err)
+	}
+
+	aead, _ := cipher.NewGCM(block)
+	return aead
+}
+
+func clientKey() string {
+	key := make([]byte, 32)
+	rand.Read(key)
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+func TestImportMaxItemsDefault(t *testing.T) {
+	actual := ImportMaxItems()
+	expected := 1000
+
+	if actual != expected {
+		t.Errorf("FAILED - TestImportMaxItemsDefault | Actual: %d | Expected: %d", actual, expected)
+	}
+}
+
+func TestPlanImport(t *testing.T) {
+	existingFolder := types.VaultFolder{}
+	existingFolder.FolderId.Value = "f1"
+
+	folders := []ImportFolder{{Id: "f1", Name: "old"}, {Id: "f2", Name: "new"}}
+	items := []ImportItem{
+		{Id: "a", Data: "existing"},
+		{Id: "c", FolderId: "f1", Data: "x"},
+		{Id: "d", FolderId: "f2", Data: "y"},
+		{Id: "e", FolderId: "f3", Data: "z"},
+	}
+
+	actual := PlanImport("bob@example.com", folders, items, bulkItems(), map[string]types.VaultFolder{"f1": existingFolder}, time.Now())
+
+	if len(actual.Folders) != 1 || actual.Folders[0].FolderId.Value != "f2" {
+		t.Errorf("FAILED - TestPlanImport | Actual: %+v | Expected: only folder f2 is created", actual.Folders)
+	}
+
+	if len(actual.Changes) != 2 || actual.Changes[0].Item.ItemId.Value != "c" || actual.Changes[1].Index != 1 {
+		t.Errorf("FAILED - TestPlanImport | Actual: %+v | Expected: items c and d are created", actual.Changes)
+	}
+
+	if !actual.Changes[0].Create || actual.Changes[0].Revision != 0 || actual.Changes[0].Item.Revision.Value != 1 {
+		t.Errorf("FAILED - TestPlanImport | Actual: %+v | Expected: a create at revision 1", actual.Changes[0])
+	}
+
+	if actual.Report.Items != 2 || actual.Report.Folders != 1 || len(actual.Report.Skipped) != 3 {
+		t.Errorf("FAILED - TestPlanImport | Actual: %+v | Expected: 2 items, 1 folder, 3 skipped", actual.Report)
+	}
+}
+
+func TestImportPlanInCollections(t *testing.T) {
+	plan := PlanImport("ORG#1", nil, []ImportItem{{Id: "c", Data: "x"}}, map[string]types.VaultItem{}, map[string]types.VaultFolder{}, time.Now())
+	plan.InCollections(`["a"]`)
+
+	change := plan.Changes[0]
+
+	if change.Item.CollectionIds.Value != `["a"]` || change.Values["COLLECTION_IDS"].Value != `["a"]` {
+		t.Errorf("FAILED - TestImportPlanInCollections | Actual: %+v | Expected: the item in collection a", change)
+	}
+}
+
+func TestBulkChangeCreateDeletesTombstone(t *testing.T) {
+	change := BulkChange{Create: true}
+	change.Item.ItemId.Value = "a"
+	change.Item.ItemKey.Value = ItemKey("a")
+
+	writes := change.writes("bob@example.com", 5)
+
+	if len(writes) != 2 || writes[1].Delete == nil || writes[1].Delete.SortKey != TombstoneKey("a") {
+		t.Errorf("FAILED - TestBulkChangeCreateDeletesTombstone | Actual: %+v | Expected: the item and its tombstone", writes)
+	}
+
+	if writes[0].Update.Values["SYNC_REVISION"].Value != 5 {
+		t.Errorf("FAILED - TestBulkChangeCreateDeletesTombstone | Actual: %v | Expected: %d", writes[0].Update.Values["SYNC_REVISION"].Value, 5)
+	}
+}
+
+func TestImportSealedExportIntoAnotherAccount(t *testing.T) {
+	const passphrase = "correct horse battery staple"
+	aliceKey := clientKey()
+	bobKey := clientKey()
+
+	var item types.VaultItem
+	item.UserId.Value = "alice@example.com"
+	item.ItemId.Value = "0f8fad5b-d9cb-469f-a165-70867728950e"
+	item.FolderId.Value = "7c9e6679-7425-40de-944b-e07fc1f90ae7"
+	item.Data.Value = clientEncrypt(t, aliceKey, `{"name":"bank"}`)
+
+	var folder types.VaultFolder
+	folder.FolderId.Value = item.FolderId.Value
+	folder.Name.Value = clientEncrypt(t, aliceKey, "finance")
+
+	// Alice exports her vault with a passphrase
+	exported := export.New("alice@example.com", []types.VaultItem{item}, []types.VaultFolder{folder}, time.Now())
+	exported.Key = aliceKey
+
+	sealed, err := export.Seal(exported, passphrase, export.MIN_KDF_ITERATIONS)
+
+	if err != nil {
+		t.Fatalf("FAILED - TestImportSealedExportIntoAnotherAccount - Seal | Error: %v", err)
+	}
+
+	file, _ := json.Marshal(sealed)
+
+	// Bob's client opens the file with the passphrase and re-encrypts it with his key
+	opened, err := export.Parse(file, passphrase)
+
+	if err != nil {
+		t.Fatalf("FAILED - TestImportSealedExportIntoAnotherAccount - Open | Error: %v", err)
+	}
+
+	if err := opened.CheckImportInto("bob@example.com"); err == nil {
+		t.Errorf("FAILED - TestImportSealedExportIntoAnotherAccount | Expected: the export not to be imported into Bob's vault as is")
+	}
+
+	folders := []ImportFolder{}
+	items := []ImportItem{}
+
+	for _, folder := range opened.Folders {
+		folders = append(folders, ImportFolder{
+			Id:   folder.Id,
+			Name: clientEncrypt(t, bobKey, clientDecrypt(t, opened.Key, folder.Name)),
+		})
+	}
+
+	for _, item := range opened.Items {
+		items = append(items, ImportItem{
+			Id:       item.Id,
+			FolderId: item.FolderId,
+			Data:     clientEncrypt(t, bobKey, clientDecrypt(t, opened.Key, item.Data)),
+		})
+	}
+
+	plan := PlanImport("bob@example.com", folders, items, map[string]types.VaultItem{}, map[string]types.VaultFolder{}, time.Now())
+
+	if len(plan.Changes) != 1 || len(plan.Folders) != 1 {
+		t.Fatalf("FAILED - TestImportSealedExportIntoAnotherAccount - Plan | Actual: %+v | Expected: the item and folder", plan.Report)
+	}
+
+	imported := plan.Changes[0].Item
+
+	if imported.UserId.Value != "bob@example.com" || imported.FolderId.Value != item.FolderId.Value {
+		t.Errorf("FAILED - TestImportSealedExportIntoAnotherAccount - Vault | Actual: %s in %s", imported.UserId.Value, imported.FolderId.Value)
+	}
+
+	if actual := clientDecrypt(t, bobKey, imported.Data.Value); actual != `{"name":"bank"}` {
+		t.Errorf("FAILED - TestImportSealedExportIntoAnotherAccount - Item | Actual: %s | Expected: Bob can read the item", actual)
+	}
+
+	if actual := clientDecrypt(t, bobKey, plan.Folders[0].Name.Value); actual != "finance" {
+		t.Errorf("FAILED - TestImportSealedExportIntoAnotherAccount - Folder | Actual: %s | Expected: Bob can read the folder", actual)
+	}
+}
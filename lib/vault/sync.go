@@ -0,0 +1,399 @@
+package vault
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	appConfig "password-caddy/api/core/config"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/dynamoclient"
+	"password-caddy/api/lib/util"
+
+	dynamoTypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Types of objects a tombstone can stand for
+const (
+	TOMBSTONE_TYPE_ITEM       = "item"
+	TOMBSTONE_TYPE_FOLDER     = "folder"
+	TOMBSTONE_TYPE_COLLECTION = "collection"
+)
+
+// How often a write is retried when another write of the same user took its sync revision
+const MAX_COMMIT_ATTEMPTS = 5
+
+// Local secondary index of the vault table sorted by SYNC_REVISION, which the sync pages through
+const SYNC_REVISION_INDEX = "SYNC_REVISION_INDEX"
+
+type TombstoneResponse struct {
+	Id        string `json:"id"`
+	Type      string `json:"type"`
+	DeletedAt string `json:"deletedAt"`
+}
+
+type SyncResponse struct {
+	Items      []VaultItemResponse   `json:"items"`
+	Folders    []VaultFolderResponse `json:"folders"`
+	Tombstones []TombstoneResponse   `json:"tombstones"`
+	Cursor     string                `json:"cursor"`
+	HasMore    bool                  `json:"hasMore"`
+	FullResync bool                  `json:"fullResync"`
+	// Only present when the equivalent domains changed
+	EquivalentDomains *EquivalentDomainsResponse `json:"equivalentDomains,omitempty"`
+	// All collections the user can access, only present on the sync of an organization
+	Collections []VaultCollectionResponse `json:"collections,omitempty"`
+}
+
+// A page of what changed in a vault after a sync revision. Last is the sync
+// revision the page goes up to, HasMore tells whether changes follow it
+type VaultChanges struct {
+	Items      []types.VaultItem
+	Folders    []types.VaultFolder
+	Tombstones []types.VaultTombstone
+	Domains    *types.VaultDomains
+	Last       int
+	HasMore    bool
+}
+
+// Sort keys and sync revisions of the changes read from SYNC_REVISION_INDEX
+type syncEntry struct {
+	ItemKey      types.StringValue `json:"ITEM_KEY"`
+	SyncRevision types.NumberValue `json:"SYNC_REVISION"`
+}
+
+/********** CONFIG **********/
+
+// Maximum number of changes read by a single sync request. The changes of a
+// single commit are never split, so a page can hold up to a commit more
+func SyncPageSize() int {
+	return int(appConfig.Get("VAULT_SYNC_PAGE_SIZE", "500").ToInt64())
+}
+
+/********** CURSOR **********/
+
+/*
+Encode the opaque sync cursor. It holds the last sync revision the client
+has seen and when the cursor was issued
+*/
+func EncodeCursor(syncRevision int, issuedAt time.Time) string {
+	value := fmt.Sprintf("%d:%d", syncRevision, issuedAt.Unix())
+	return base64.RawURLEncoding.EncodeToString([]byte(value))
+}
+
+func DecodeCursor(cursor string) (int, time.Time, error) {
+	var syncRevision int
+	var issuedAt int64
+
+	value, err := base64.RawURLEncoding.DecodeString(cursor)
+
+	if err == nil {
+		_, err = fmt.Sscanf(string(value), "%d:%d", &syncRevision, &issuedAt)
+	}
+
+	if err != nil || syncRevision < 0 {
+		return 0, time.Time{}, errors.New("Invalid sync cursor")
+	}
+
+	return syncRevision, time.Unix(issuedAt, 0), nil
+}
+
+// A cursor is too old when the tombstones issued after it may already have expired
+func IsCursorExpired(issuedAt, now time.Time) bool {
+	return now.Sub(issuedAt) > TombstoneTTL()
+}
+
+/********** PAGINATION **********/
+
+/*
+Build the sync response out of a page of changes. On a full resync the
+tombstones are left out since the client starts over anyway.
+
+The cursor of a page that is followed by more keeps the time the client's
+cursor was issued, so access changes made while the client catches up are
+still compared against it
+*/
+func BuildSyncPage(changes VaultChanges, fullResync bool, issuedAt, now time.Time) SyncResponse {
+	if fullResync {
+		changes.Tombstones = nil
+	}
+
+	if !changes.HasMore || issuedAt.IsZero() {
+		issuedAt = now
+	}
+
+	response := SyncResponse{
+		Items:      []VaultItemResponse{},
+		Folders:    []VaultFolderResponse{},
+		Tombstones: []TombstoneResponse{},
+		Cursor:     EncodeCursor(changes.Last, issuedAt),
+		HasMore:    changes.HasMore,
+		FullResync: fullResync,
+	}
+
+	for _, item := range changes.Items {
+		response.Items = append(response.Items, ToItemResponse(item))
+	}
+
+	for _, folder := range changes.Folders {
+		response.Folders = append(response.Folders, ToFolderResponse(folder))
+	}
+
+	for _, tombstone := range changes.Tombstones {
+		response.Tombstones = append(response.Tombstones, TombstoneResponse{
+			Id:        tombstone.ObjectId.Value,
+			Type:      tombstone.ObjectType.Value,
+			DeletedAt: tombstone.DeletedAt.Value,
+		})
+	}
+
+	if changes.Domains != nil {
+		domains := ToDomainsResponse(*changes.Domains)
+		response.EquivalentDomains = &domains
+	}
+
+	return response
+}
+
+/*
+Sort a page read from SYNC_REVISION_INDEX into the items, folders, tombstones
+and equivalent domains of the vault. Everything else carrying a sync revision,
+like the sync state itself, is no change of its own
+*/
+func toChanges(page []map[string]dynamoTypes.AttributeValue, since int, hasMore bool) VaultChanges {
+	changes := VaultChanges{
+		Items:      []types.VaultItem{},
+		Folders:    []types.VaultFolder{},
+		Tombstones: []types.VaultTombstone{},
+		Last:       since,
+		HasMore:    hasMore,
+	}
+
+	json := util.SerializeJson(page)
+
+	var entries []syncEntry
+	var items []types.VaultItem
+	var folders []types.VaultFolder
+	var tombstones []types.VaultTombstone
+	var domains []types.VaultDomains
+
+	util.DeserializeJson(json, &entries)
+	util.DeserializeJson(json, &items)
+	util.DeserializeJson(json, &folders)
+	util.DeserializeJson(json, &tombstones)
+	util.DeserializeJson(json, &domains)
+
+	for i, entry := range entries {
+		key := entry.ItemKey.Value
+
+		switch {
+		case strings.HasPrefix(key, ITEM_PREFIX):
+			changes.Items = append(changes.Items, items[i])
+		case strings.HasPrefix(key, FOLDER_PREFIX):
+			changes.Folders = append(changes.Folders, folders[i])
+		case strings.HasPrefix(key, TOMBSTONE_PREFIX):
+			changes.Tombstones = append(changes.Tombstones, tombstones[i])
+		case key == DOMAINS_KEY:
+			changes.Domains = &domains[i]
+		}
+
+		if entry.SyncRevision.Value > changes.Last {
+			changes.Last = entry.SyncRevision.Value
+		}
+	}
+
+	return changes
+}
+
+/*
+Replace the changes of the last sync revision of a full page with all changes
+of that revision. All changes of a commit share its sync revision, so a page
+never ends in the middle of a commit
+*/
+func completeLastRevision(page, last []map[string]dynamoTypes.AttributeValue) []map[string]dynamoTypes.AttributeValue {
+	var entries []syncEntry
+	util.DeserializeJson(util.SerializeJson(page), &entries)
+
+	if len(entries) == 0 {
+		return page
+	}
+
+	revision := entries[len(entries)-1].SyncRevision.Value
+	end := len(entries)
+
+	for end > 0 && entries[end-1].SyncRevision.Value == revision {
+		end--
+	}
+
+	return append(page[:end:end], last...)
+}
+
+/********** OPERATIONS **********/
+
+// Get the current sync revision of a user's vault. 0 for a vault that was never written
+func GetSyncRevision(client *dynamoclient.DynamoClient, userId string) *dynamoclient.DynamoResponse {
+	response := client.
+		Get(dynamoclient.DynamoGetRequest{
+			Key:     userId,
+			SortKey: SYNC_KEY,
+		}).
+		AsVaultSyncState()
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	return dynamoclient.SuccessWithValue(response.Data.(types.VaultSyncState).SyncRevision.Value)
+}
+
+/*
+Get a page of the items, folders, tombstones and equivalent domains written
+after a sync revision, in order of their sync revision. The page is read from
+SYNC_REVISION_INDEX, so it costs a page of reads however far behind the
+client is. A pageSize of 0 reads all changes
+*/
+func GetChanges(client *dynamoclient.DynamoClient, userId string, since, pageSize int) *dynamoclient.DynamoResponse {
+	response := client.Query(syncQuery(userId, ">", since, pageSize))
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	page := response.Data.([]map[string]dynamoTypes.AttributeValue)
+	hasMore := pageSize > 0 && len(page) >= pageSize
+
+	if hasMore {
+		var last syncEntry
+		util.DeserializeJson(util.SerializeJson(page[len(page)-1]), &last)
+
+		response = client.Query(syncQuery(userId, "=", last.SyncRevision.Value, 0))
+
+		if !response.IsSuccess {
+			return response
+		}
+
+		page = completeLastRevision(page, response.Data.([]map[string]dynamoTypes.AttributeValue))
+	}
+
+	return dynamoclient.SuccessWithValue(toChanges(page, since, hasMore))
+}
+
+// Query the changes of a vault whose sync revision compares to syncRevision with the operator
+func syncQuery(userId, operator string, syncRevision, limit int) dynamoclient.DynamoQueryRequest {
+	return dynamoclient.DynamoQueryRequest{
+		Key:            userId,
+		IndexName:      SYNC_REVISION_INDEX,
+		KeyCondition:   "#syncRevision " + operator + " :syncRevision",
+		Limit:          int32(limit),
+		ConsistentRead: true,
+		Names:          map[string]string{"#syncRevision": "SYNC_REVISION"},
+		Values:         map[string]interface{}{":syncRevision": syncRevision},
+	}
+}
+
+/*
+Commit a write of a user's vault in one transaction with the increment of the
+user's sync revision. write builds the writes for the given sync revision.
+
+The sync revision is only incremented if nobody else did in the meantime, which
+keeps the sync revisions in the same order as the writes. When another write
+won the race the commit is retried with the next sync revision. A failed
+condition of the write itself results in a 409
+*/
+func commit(client *dynamoclient.DynamoClient, userId string, write func(syncRevision int) []dynamoclient.DynamoTransactItem) *dynamoclient.DynamoResponse {
+	for attempt := 0; attempt < MAX_COMMIT_ATTEMPTS; attempt++ {
+		response := GetSyncRevision(client, userId)
+
+		if !response.IsSuccess {
+			return response
+		}
+
+		current := response.Data.(int)
+		items := append([]dynamoclient.DynamoTransactItem{syncRevisionUpdate(userId, current)}, write(current+1)...)
+
+		response = client.TransactWrite(items)
+
+		if response.IsSuccess {
+			return dynamoclient.SuccessWithValue(current + 1)
+		}
+
+		reasons, _ := response.Error.Details.([]string)
+
+		if len(reasons) == 0 {
+			return response
+		}
+
+		if reasons[0] == dynamoclient.CONDITIONAL_CHECK_FAILED {
+			continue
+		}
+
+		return dynamoclient.Failure(types.PasswordCaddyError{
+			StatusCode: 409,
+			Message:    "The conditional request failed",
+			Details:    reasons,
+		})
+	}
+
+	return dynamoclient.Failure(types.PasswordCaddyError{
+		StatusCode: 409,
+		Message:    "Too many concurrent writes to the vault",
+	})
+}
+
+func syncRevisionUpdate(userId string, current int) dynamoclient.DynamoTransactItem {
+	condition := &dynamoclient.DynamoCondition{
+		Expression: "attribute_not_exists(#sk)",
+		Names:      map[string]string{"#sk": dynamoclient.SORT_KEY},
+	}
+
+	if current > 0 {
+		condition = &dynamoclient.DynamoCondition{
+			Expression: "#syncRevision = :syncRevision",
+			Names:      map[string]string{"#syncRevision": "SYNC_REVISION"},
+			Values:     map[string]interface{}{":syncRevision": current},
+		}
+	}
+
+	return dynamoclient.DynamoTransactItem{
+		Update: &dynamoclient.DyanamoUpdateRequest{
+			Key:     userId,
+			SortKey: SYNC_KEY,
+			Values: map[string]dynamoclient.DynamoUpdateItem{
+				"SYNC_REVISION": {
+					Action: dynamoTypes.AttributeActionPut,
+					Value:  current + 1,
+				},
+			},
+			Condition: condition,
+		},
+	}
+}
+
+// Delete an item or folder and leave a tombstone for the sync in the same commit, together with any extra writes
+func deleteWithTombstone(client *dynamoclient.DynamoClient, userId, sortKey, objectId, objectType string, extra ...dynamoclient.DynamoTransactItem) *dynamoclient.DynamoResponse {
+	return commit(client, userId, func(syncRevision int) []dynamoclient.DynamoTransactItem {
+		return append([]dynamoclient.DynamoTransactItem{
+			{
+				Delete: &dynamoclient.DynamoDeleteRequest{
+					Key:     userId,
+					SortKey: sortKey,
+				},
+			},
+			{
+				Put: &dynamoclient.DynamoPutRequest{
+					Key:     userId,
+					SortKey: TombstoneKey(objectId),
+					Values: map[string]interface{}{
+						"OBJECT_ID":     objectId,
+						"OBJECT_TYPE":   objectType,
+						"SYNC_REVISION": syncRevision,
+						"DELETED_AT":    time.Now().UTC().Format(time.RFC3339),
+						"EXPIRES_AT":    time.Now().Add(TombstoneTTL()).Unix(),
+					},
+				},
+			},
+		}, extra...)
+	})
+}
@@ -0,0 +1,220 @@
+package vault
+
+import (
+	"password-caddy/api/core/types"
+	"strconv"
+	"testing"
+	"time"
+
+	dynamoTypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+func itemAt(id string, syncRevision int) types.VaultItem {
+	var item types.VaultItem
+	item.ItemId.Value = id
+	item.SyncRevision.Value = syncRevision
+	return item
+}
+
+func folderAt(id string, syncRevision int) types.VaultFolder {
+	var folder types.VaultFolder
+	folder.FolderId.Value = id
+	folder.SyncRevision.Value = syncRevision
+	return folder
+}
+
+func tombstoneAt(id string, syncRevision int) types.VaultTombstone {
+	var tombstone types.VaultTombstone
+	tombstone.ObjectId.Value = id
+	tombstone.ObjectType.Value = TOMBSTONE_TYPE_ITEM
+	tombstone.SyncRevision.Value = syncRevision
+	return tombstone
+}
+
+/***** Cursor *****/
+
+func TestCursorRoundTrip(t *testing.T) {
+	issuedAt := time.Unix(1650000000, 0)
+
+	syncRevision, actualIssuedAt, err := DecodeCursor(EncodeCursor(42, issuedAt))
+
+	if err != nil || syncRevision != 42 || !actualIssuedAt.Equal(issuedAt) {
+		t.Errorf("FAILED - TestCursorRoundTrip | Actual: %d, %s, %v | Expected: 42, %s", syncRevision, actualIssuedAt, err, issuedAt)
+	}
+}
+
+func TestDecodeCursorWithInvalidCursor(t *testing.T) {
+	invalidCursors := [3]string{"foo", "!!!", EncodeCursor(-1, time.Now())}
+
+	for _, cursor := range invalidCursors {
+		if _, _, err := DecodeCursor(cursor); err == nil {
+			t.Errorf("FAILED - TestDecodeCursorWithInvalidCursor | Cursor: %s | DecodeCursor did not return error", cursor)
+		}
+	}
+}
+
+func TestIsCursorExpired(t *testing.T) {
+	now := time.Now()
+
+	if IsCursorExpired(now.Add(-24*time.Hour), now) {
+		t.Errorf("FAILED - TestIsCursorExpired | A day old cursor must not be expired")
+	}
+
+	if !IsCursorExpired(now.Add(-TombstoneTTL()-time.Hour), now) {
+		t.Errorf("FAILED - TestIsCursorExpired | A cursor older than the tombstone TTL must be expired")
+	}
+}
+
+/***** End Cursor *****/
+
+/***** BuildSyncPage *****/
+
+func TestBuildSyncPageWithoutChanges(t *testing.T) {
+	now := time.Now()
+
+	actual := BuildSyncPage(VaultChanges{Last: 7}, false, now.Add(-time.Hour), now)
+
+	if actual.Cursor != EncodeCursor(7, now) || actual.HasMore || len(actual.Items) != 0 {
+		t.Errorf("FAILED - TestBuildSyncPageWithoutChanges | Actual: %+v", actual)
+	}
+}
+
+func TestBuildSyncPageWithAllChanges(t *testing.T) {
+	now := time.Now()
+	changes := VaultChanges{
+		Items:      []types.VaultItem{itemAt("a", 3), itemAt("b", 5)},
+		Folders:    []types.VaultFolder{folderAt("f", 4)},
+		Tombstones: []types.VaultTombstone{tombstoneAt("c", 6)},
+		Last:       6,
+	}
+
+	actual := BuildSyncPage(changes, false, now.Add(-time.Hour), now)
+
+	if len(actual.Items) != 2 || len(actual.Folders) != 1 || len(actual.Tombstones) != 1 {
+		t.Errorf("FAILED - TestBuildSyncPageWithAllChanges | Actual: %+v", actual)
+	}
+
+	if actual.HasMore || actual.Cursor != EncodeCursor(6, now) {
+		t.Errorf("FAILED - TestBuildSyncPageWithAllChanges - Cursor | Actual: %+v", actual)
+	}
+}
+
+func TestBuildSyncPageWithMoreChanges(t *testing.T) {
+	now := time.Now()
+	issuedAt := now.Add(-time.Hour)
+	changes := VaultChanges{
+		Items:   []types.VaultItem{itemAt("a", 3)},
+		Folders: []types.VaultFolder{folderAt("f", 4)},
+		Last:    4,
+		HasMore: true,
+	}
+
+	actual := BuildSyncPage(changes, false, issuedAt, now)
+
+	// The next page is still compared against when the client's cursor was issued
+	if !actual.HasMore || actual.Cursor != EncodeCursor(4, issuedAt) {
+		t.Errorf("FAILED - TestBuildSyncPageWithMoreChanges - Cursor | Actual: %+v", actual)
+	}
+
+	actual = BuildSyncPage(changes, true, time.Time{}, now)
+
+	if actual.Cursor != EncodeCursor(4, now) {
+		t.Errorf("FAILED - TestBuildSyncPageWithMoreChanges - Full resync | Actual: %+v", actual)
+	}
+}
+
+func TestBuildSyncPageWithFullResyncOmitsTombstones(t *testing.T) {
+	changes := VaultChanges{
+		Items:      []types.VaultItem{itemAt("a", 3)},
+		Tombstones: []types.VaultTombstone{tombstoneAt("c", 6)},
+		Last:       6,
+	}
+
+	actual := BuildSyncPage(changes, true, time.Time{}, time.Now())
+
+	if !actual.FullResync || len(actual.Tombstones) != 0 || len(actual.Items) != 1 {
+		t.Errorf("FAILED - TestBuildSyncPageWithFullResyncOmitsTombstones | Actual: %+v", actual)
+	}
+}
+
+func TestBuildSyncPageWithEquivalentDomains(t *testing.T) {
+	var domains types.VaultDomains
+	domains.Groups.Value = `[["google.com","youtube.com"]]`
+	domains.Revision.Value = 2
+	domains.SyncRevision.Value = 5
+
+	changes := VaultChanges{
+		Items:   []types.VaultItem{itemAt("a", 3)},
+		Domains: &domains,
+		Last:    5,
+	}
+
+	actual := BuildSyncPage(changes, false, time.Now(), time.Now())
+
+	if actual.EquivalentDomains == nil || len(actual.EquivalentDomains.Groups) != 1 || actual.EquivalentDomains.Revision != 2 {
+		t.Errorf("FAILED - TestBuildSyncPageWithEquivalentDomains | Actual: %+v", actual.EquivalentDomains)
+	}
+}
+
+/***** End BuildSyncPage *****/
+
+/***** Sync Index *****/
+
+func entryAt(sortKey, id string, syncRevision int) map[string]dynamoTypes.AttributeValue {
+	return map[string]dynamoTypes.AttributeValue{
+		"ITEM_KEY":      &dynamoTypes.AttributeValueMemberS{Value: sortKey},
+		"ITEM_ID":       &dynamoTypes.AttributeValueMemberS{Value: id},
+		"FOLDER_ID":     &dynamoTypes.AttributeValueMemberS{Value: id},
+		"OBJECT_ID":     &dynamoTypes.AttributeValueMemberS{Value: id},
+		"SYNC_REVISION": &dynamoTypes.AttributeValueMemberN{Value: strconv.Itoa(syncRevision)},
+	}
+}
+
+func TestToChanges(t *testing.T) {
+	page := []map[string]dynamoTypes.AttributeValue{
+		entryAt(ItemKey("a"), "a", 3),
+		entryAt(FolderKey("f"), "f", 4),
+		entryAt(TombstoneKey("c"), "c", 5),
+		entryAt(DOMAINS_KEY, "", 6),
+		entryAt(SYNC_KEY, "", 6),
+	}
+
+	actual := toChanges(page, 2, true)
+
+	if len(actual.Items) != 1 || actual.Items[0].ItemId.Value != "a" || actual.Items[0].SyncRevision.Value != 3 {
+		t.Errorf("FAILED - TestToChanges - Items | Actual: %+v", actual.Items)
+	}
+
+	if len(actual.Folders) != 1 || actual.Folders[0].FolderId.Value != "f" || len(actual.Tombstones) != 1 || actual.Tombstones[0].ObjectId.Value != "c" {
+		t.Errorf("FAILED - TestToChanges - Folders and tombstones | Actual: %+v %+v", actual.Folders, actual.Tombstones)
+	}
+
+	if actual.Domains == nil || actual.Last != 6 || !actual.HasMore {
+		t.Errorf("FAILED - TestToChanges - Page | Actual: %+v", actual)
+	}
+
+	if actual := toChanges(nil, 7, false); actual.Last != 7 || len(actual.Items) != 0 {
+		t.Errorf("FAILED - TestToChanges - Empty page | Actual: %+v | Expected: the page to end at the cursor", actual)
+	}
+}
+
+func TestCompleteLastRevisionDoesNotSplitACommit(t *testing.T) {
+	// The page ended after the first item of the commit at sync revision 4
+	page := []map[string]dynamoTypes.AttributeValue{
+		entryAt(ItemKey("a"), "a", 3),
+		entryAt(ItemKey("b"), "b", 4),
+	}
+	last := []map[string]dynamoTypes.AttributeValue{
+		entryAt(ItemKey("b"), "b", 4),
+		entryAt(ItemKey("c"), "c", 4),
+		entryAt(TombstoneKey("d"), "d", 4),
+	}
+
+	actual := toChanges(completeLastRevision(page, last), 2, true)
+
+	if len(actual.Items) != 3 || len(actual.Tombstones) != 1 || actual.Last != 4 {
+		t.Errorf("FAILED - TestCompleteLastRevisionDoesNotSplitACommit | Actual: %+v | Expected: a, b, c and d up to 4", actual)
+	}
+}
+
+/***** End Sync Index *****/
@@ -0,0 +1,600 @@
+package vault
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	appConfig "password-caddy/api/core/config"
+	"password-caddy/api/core/types"
+	"password-caddy/api/lib/blobstore"
+	"password-caddy/api/lib/dynamoclient"
+	"password-caddy/api/lib/result"
+	"password-caddy/api/lib/util"
+
+	dynamoTypes "github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/google/uuid"
+)
+
+// Sort keys of the items stored in a user's vault partition. An organization's
+// partition holds its shared items and collections the same way
+const (
+	ITEM_PREFIX       = "ITEM#"
+	HISTORY_PREFIX    = "HISTORY#"
+	FOLDER_PREFIX     = "FOLDER#"
+	TOMBSTONE_PREFIX  = "TOMBSTONE#"
+	HEALTH_PREFIX     = "HEALTH#"
+	ATTACHMENT_PREFIX = "ATTACHMENT#"
+	COLLECTION_PREFIX = "COLLECTION#"
+	SYNC_KEY          = "SYNC"
+	DOMAINS_KEY       = "DOMAINS"
+	USAGE_KEY         = "USAGE"
+)
+
+/*
+While an item is in the trash the VAULT_TRASH partition holds a marker under
+TRASH#<deleted at>#<partition>#<item id>, so the items to purge are found with
+a query sorted by when they were trashed instead of a scan of the table
+*/
+const (
+	TRASH_KEY    = "VAULT_TRASH"
+	TRASH_PREFIX = "TRASH#"
+)
+
+// Expected revision of a write that does not care about the current revision
+const ANY_REVISION = -1
+
+type VaultItemResponse struct {
+	Id            string   `json:"id"`
+	FolderId      string   `json:"folderId,omitempty"`
+	CollectionIds []string `json:"collectionIds,omitempty"`
+	Data          string   `json:"data"`
+	Revision      int      `json:"revision"`
+	UpdatedAt     string   `json:"updatedAt"`
+	DeletedAt     string   `json:"deletedAt,omitempty"`
+
+	// Set on items of an organization the user can not edit or see the passwords of
+	ReadOnly      bool `json:"readOnly,omitempty"`
+	HidePasswords bool `json:"hidePasswords,omitempty"`
+}
+
+/********** KEYS **********/
+
+// Item ids are generated by the clients and must be UUIDs
+func IsValidItemId(itemId string) bool {
+	_, err := uuid.Parse(itemId)
+	return err == nil
+}
+
+func ItemKey(itemId string) string {
+	return ITEM_PREFIX + itemId
+}
+
+func FolderKey(folderId string) string {
+	return FOLDER_PREFIX + folderId
+}
+
+func TombstoneKey(objectId string) string {
+	return TOMBSTONE_PREFIX + objectId
+}
+
+func CollectionKey(collectionId string) string {
+	return COLLECTION_PREFIX + collectionId
+}
+
+func HealthKey(itemId string) string {
+	return HEALTH_PREFIX + itemId
+}
+
+func AttachmentPrefix(itemId string) string {
+	return ATTACHMENT_PREFIX + itemId + "#"
+}
+
+func AttachmentKey(itemId, attachmentId string) string {
+	return AttachmentPrefix(itemId) + attachmentId
+}
+
+func HistoryPrefix(itemId string) string {
+	return HISTORY_PREFIX + itemId + "#"
+}
+
+func TrashKey(deletedAt, partition, itemId string) string {
+	return TRASH_PREFIX + deletedAt + "#" + partition + "#" + itemId
+}
+
+// Revisions are zero padded so the history sorts by revision
+func HistoryKey(itemId string, revision int) string {
+	return fmt.Sprintf("%s%010d", HistoryPrefix(itemId), revision)
+}
+
+/********** REVISIONS **********/
+
+/*
+Get the revision the client expects to overwrite from the If-Match header
+(i.e If-Match: "5"). Falls back to the revision of the body when the header is
+missing. Neither can be negative, ANY_REVISION is never up to the client
+*/
+func ExpectedRevision(headers map[string]string, fallback int) (int, error) {
+	if fallback < 0 {
+		return 0, errors.New("Revision must not be negative")
+	}
+
+	revision, found, err := ifMatch(headers)
+
+	if err != nil || found {
+		return revision, err
+	}
+
+	return fallback, nil
+}
+
+/*
+Get the revision the client expects to overwrite from the If-Match header of a
+write without a body to carry it. Such writes must not overwrite blindly either
+*/
+func RequiredRevision(headers map[string]string) (int, error) {
+	revision, found, err := ifMatch(headers)
+
+	if err == nil && !found {
+		return 0, errors.New("If-Match must be set to the revision of the item")
+	}
+
+	return revision, err
+}
+
+func ifMatch(headers map[string]string) (int, bool, error) {
+	for key, value := range headers {
+		if !strings.EqualFold(key, "If-Match") {
+			continue
+		}
+
+		value = strings.TrimPrefix(strings.TrimSpace(value), "W/")
+		revision, err := strconv.Atoi(strings.Trim(value, "\""))
+
+		if err != nil || revision < 0 {
+			return 0, true, errors.New("If-Match must be a revision number")
+		}
+
+		return revision, true, nil
+	}
+
+	return 0, false, nil
+}
+
+// Check if the item is at the revision the client expects. A missing item is at revision 0
+func MatchesRevision(item types.VaultItem, expected int) bool {
+	return expected == ANY_REVISION || item.Revision.Value == expected
+}
+
+/*
+The item with the data of one of its earlier revisions, to save as its next
+revision. Everything else, i.e the collections of an organization's item, stays
+as it is. The revision must be of the same item in the same vault
+*/
+func RestoreRevision(current types.VaultItem, revision types.VaultItemHistory) (types.VaultItem, error) {
+	if revision.ItemId.Value == "" || revision.ItemId.Value != current.ItemId.Value || revision.UserId.Value != current.UserId.Value {
+		return current, errors.New("Revision not found")
+	}
+
+	current.Data = revision.Data
+
+	return current, nil
+}
+
+// Build the 409 returned when the client's revision is out of date.
+// The current item is sent back so the client can merge its changes
+func ConflictResult(current types.VaultItem) *result.Result {
+	return result.FailureWithDetails(
+		409,
+		"Vault item was modified by another client",
+		ToItemResponse(current),
+	)
+}
+
+// Build the 409 for a conditional write that failed, fetching the current item
+func RevisionConflict(client *dynamoclient.DynamoClient, userId, itemId string) *result.Result {
+	response := GetItem(client, userId, itemId)
+
+	if !response.IsSuccess {
+		return result.Failure(409, "Vault item was modified by another client")
+	}
+
+	return ConflictResult(response.Data.(types.VaultItem))
+}
+
+// Only write the item when it is still at the expected revision.
+// Expecting revision 0 means the item must not exist yet
+func revisionCondition(expected int) *dynamoclient.DynamoCondition {
+	if expected == 0 {
+		return &dynamoclient.DynamoCondition{
+			Expression: "attribute_not_exists(#sk)",
+			Names:      map[string]string{"#sk": dynamoclient.SORT_KEY},
+		}
+	}
+
+	return &dynamoclient.DynamoCondition{
+		Expression: "#revision = :revision",
+		Names:      map[string]string{"#revision": "REVISION"},
+		Values:     map[string]interface{}{":revision": expected},
+	}
+}
+
+/********** CONFIG **********/
+
+// Number of previous revisions kept per item. 0 disables the history
+func HistoryCount() int {
+	return int(appConfig.Get("VAULT_HISTORY_COUNT", "10").ToInt64())
+}
+
+// How long a previous revision is kept before the table TTL removes it
+func HistoryTTL() time.Duration {
+	days := appConfig.Get("VAULT_HISTORY_TTL_DAYS", "365").ToInt64()
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// How long the sync remembers permanently deleted items and folders.
+// Clients that did not sync for longer have to do a full resync
+func TombstoneTTL() time.Duration {
+	days := appConfig.Get("VAULT_TOMBSTONE_TTL_DAYS", "90").ToInt64()
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// How long an item stays in the trash before it is purged
+func TrashRetention() time.Duration {
+	days := appConfig.Get("VAULT_TRASH_RETENTION_DAYS", "30").ToInt64()
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// How long a single run of the trash purge may take before it leaves the rest to the next run
+func TrashPurgeBudget() time.Duration {
+	seconds := appConfig.Get("VAULT_TRASH_PURGE_BUDGET_SECONDS", "20").ToInt64()
+	return time.Duration(seconds) * time.Second
+}
+
+/********** CONVERTERS **********/
+
+func ToItemResponse(item types.VaultItem) VaultItemResponse {
+	return VaultItemResponse{
+		Id:            item.ItemId.Value,
+		FolderId:      item.FolderId.Value,
+		CollectionIds: CollectionIdsOf(item),
+		Data:          item.Data.Value,
+		Revision:      item.Revision.Value,
+		UpdatedAt:     item.UpdatedAt.Value,
+		DeletedAt:     item.DeletedAt.Value,
+	}
+}
+
+// The collections of an organization's item. Items of a user's vault have none
+func CollectionIdsOf(item types.VaultItem) []string {
+	collectionIds := []string{}
+
+	if item.CollectionIds.Value != "" {
+		util.DeserializeJson(item.CollectionIds.Value, &collectionIds)
+	}
+
+	return collectionIds
+}
+
+func ToHistoryResponse(history types.VaultItemHistory) VaultItemResponse {
+	return VaultItemResponse{
+		Id:        history.ItemId.Value,
+		Data:      history.Data.Value,
+		Revision:  history.Revision.Value,
+		UpdatedAt: history.UpdatedAt.Value,
+	}
+}
+
+/********** OPERATIONS **********/
+
+// Get a single vault item. A missing item results in an empty VaultItem
+func GetItem(client *dynamoclient.DynamoClient, userId, itemId string) *dynamoclient.DynamoResponse {
+	return client.
+		Get(dynamoclient.DynamoGetRequest{
+			Key:     userId,
+			SortKey: ItemKey(itemId),
+		}).
+		AsVaultItem()
+}
+
+// Get all items of a user's vault, including the ones in the trash
+func ListItems(client *dynamoclient.DynamoClient, userId string) *dynamoclient.DynamoResponse {
+	return client.
+		Query(dynamoclient.DynamoQueryRequest{
+			Key:           userId,
+			SortKeyPrefix: ITEM_PREFIX,
+		}).
+		AsVaultItems()
+}
+
+// Save a vault item as the revision following expectedRevision.
+// Fails with a 409 when the item is no longer at expectedRevision.
+// The saved item is returned as the response data
+func PutItem(client *dynamoclient.DynamoClient, item types.VaultItem, expectedRevision int) *dynamoclient.DynamoResponse {
+	item.ItemKey.Value = ItemKey(item.ItemId.Value)
+	item.Revision.Value = expectedRevision + 1
+	item.UpdatedAt.Value = time.Now().UTC().Format(time.RFC3339)
+	item.DeletedAt.Value = ""
+
+	response := commit(client, item.UserId.Value, func(syncRevision int) []dynamoclient.DynamoTransactItem {
+		item.SyncRevision.Value = syncRevision
+
+		values := map[string]interface{}{
+			"ITEM_ID":       item.ItemId.Value,
+			"DATA":          item.Data.Value,
+			"REVISION":      item.Revision.Value,
+			"SYNC_REVISION": item.SyncRevision.Value,
+			"UPDATED_AT":    item.UpdatedAt.Value,
+		}
+
+		if item.FolderId.Value != "" {
+			values["FOLDER_ID"] = item.FolderId.Value
+		}
+
+		if item.CollectionIds.Value != "" {
+			values["COLLECTION_IDS"] = item.CollectionIds.Value
+		}
+
+		return []dynamoclient.DynamoTransactItem{
+			{
+				Put: &dynamoclient.DynamoPutRequest{
+					Key:       item.UserId.Value,
+					SortKey:   item.ItemKey.Value,
+					Values:    values,
+					Condition: revisionCondition(expectedRevision),
+				},
+			},
+			// An item that is recreated with the id of a purged item is no longer deleted
+			{
+				Delete: &dynamoclient.DynamoDeleteRequest{
+					Key:     item.UserId.Value,
+					SortKey: TombstoneKey(item.ItemId.Value),
+				},
+			},
+		}
+	})
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	return dynamoclient.SuccessWithValue(item)
+}
+
+// Copy the current state of an item into its history and drop the
+// revisions that exceed the configured history count
+func RetainRevision(client *dynamoclient.DynamoClient, item types.VaultItem) *dynamoclient.DynamoResponse {
+	count := HistoryCount()
+
+	if count <= 0 || item.ItemId.Value == "" {
+		return dynamoclient.Success()
+	}
+
+	response := client.Put(dynamoclient.DynamoPutRequest{
+		Key:     item.UserId.Value,
+		SortKey: HistoryKey(item.ItemId.Value, item.Revision.Value),
+		Values: map[string]interface{}{
+			"ITEM_ID":    item.ItemId.Value,
+			"DATA":       item.Data.Value,
+			"REVISION":   item.Revision.Value,
+			"UPDATED_AT": item.UpdatedAt.Value,
+			"EXPIRES_AT": time.Now().Add(HistoryTTL()).Unix(),
+		},
+	})
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	response = client.
+		Query(dynamoclient.DynamoQueryRequest{
+			Key:           item.UserId.Value,
+			SortKeyPrefix: HistoryPrefix(item.ItemId.Value),
+			Descending:    true,
+		}).
+		AsVaultItemHistories()
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	histories := response.Data.([]types.VaultItemHistory)
+
+	if len(histories) <= count {
+		return dynamoclient.Success()
+	}
+
+	return client.BatchWrite(dynamoclient.DynamoBatchWriteRequest{
+		Deletes: historyDeletes(item.UserId.Value, histories[count:]),
+	})
+}
+
+// Get the history of all items of a vault
+func ListHistories(client *dynamoclient.DynamoClient, userId string) *dynamoclient.DynamoResponse {
+	return client.
+		Query(dynamoclient.DynamoQueryRequest{
+			Key:           userId,
+			SortKeyPrefix: HISTORY_PREFIX,
+		}).
+		AsVaultItemHistories()
+}
+
+func historyDeletes(userId string, histories []types.VaultItemHistory) []dynamoclient.DynamoDeleteRequest {
+	deletes := make([]dynamoclient.DynamoDeleteRequest, 0, len(histories))
+
+	for _, history := range histories {
+		deletes = append(deletes, dynamoclient.DynamoDeleteRequest{
+			Key:     userId,
+			SortKey: history.ItemKey.Value,
+		})
+	}
+
+	return deletes
+}
+
+// Move an item into the trash. The updated item is returned as the response data
+func TrashItem(client *dynamoclient.DynamoClient, item types.VaultItem) *dynamoclient.DynamoResponse {
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	item.DeletedAt.Value = now
+
+	return updateTrashState(client, item, dynamoclient.DynamoUpdateItem{
+		Action: dynamoTypes.AttributeActionPut,
+		Value:  now,
+	}, trashMarker(item))
+}
+
+// Take an item out of the trash. The updated item is returned as the response data
+func UntrashItem(client *dynamoclient.DynamoClient, item types.VaultItem) *dynamoclient.DynamoResponse {
+	marker := removeTrashMarker(item)
+
+	item.DeletedAt.Value = ""
+
+	return updateTrashState(client, item, dynamoclient.DynamoUpdateItem{
+		Action: dynamoTypes.AttributeActionDelete,
+	}, marker...)
+}
+
+/*
+Find the markers of the items of all vaults that were trashed before the
+cutoff, at most limit of them after the marker with the sort key startAfter
+*/
+func ListTrashedBefore(client *dynamoclient.DynamoClient, cutoff time.Time, startAfter string, limit int32) *dynamoclient.DynamoResponse {
+	return client.
+		Query(dynamoclient.DynamoQueryRequest{
+			Key:         TRASH_KEY,
+			SortKeyFrom: TRASH_PREFIX,
+			// Markers of items trashed at the cutoff sort after it
+			SortKeyTo:  TRASH_PREFIX + cutoff.UTC().Format(time.RFC3339),
+			StartAfter: startAfter,
+			Limit:      limit,
+		}).
+		AsVaultTrashMarkers()
+}
+
+/*
+Delete the marker of an item that is no longer in the trash since it was
+written, i.e because the item was overwritten or its vault was deleted
+*/
+func RemoveStaleTrashMarker(client *dynamoclient.DynamoClient, marker types.VaultTrashMarker) *dynamoclient.DynamoResponse {
+	return client.Delete(dynamoclient.DynamoDeleteRequest{
+		Key:     TRASH_KEY,
+		SortKey: marker.ItemKey.Value,
+	})
+}
+
+// Write the marker of an item moved into the trash
+func trashMarker(item types.VaultItem) dynamoclient.DynamoTransactItem {
+	return dynamoclient.DynamoTransactItem{
+		Put: &dynamoclient.DynamoPutRequest{
+			Key:     TRASH_KEY,
+			SortKey: TrashKey(item.DeletedAt.Value, item.UserId.Value, item.ItemId.Value),
+			Values: map[string]interface{}{
+				"OWNER_ID":   item.UserId.Value,
+				"ITEM_ID":    item.ItemId.Value,
+				"DELETED_AT": item.DeletedAt.Value,
+			},
+		},
+	}
+}
+
+// Delete the marker of an item, if it is in the trash
+func removeTrashMarker(item types.VaultItem) []dynamoclient.DynamoTransactItem {
+	if item.DeletedAt.Value == "" {
+		return nil
+	}
+
+	return []dynamoclient.DynamoTransactItem{
+		{
+			Delete: &dynamoclient.DynamoDeleteRequest{
+				Key:     TRASH_KEY,
+				SortKey: TrashKey(item.DeletedAt.Value, item.UserId.Value, item.ItemId.Value),
+			},
+		},
+	}
+}
+
+// Fails with a 409 when the item changed since it was read. The marker writes are committed together with the item
+func updateTrashState(client *dynamoclient.DynamoClient, item types.VaultItem, deletedAt dynamoclient.DynamoUpdateItem, marker ...dynamoclient.DynamoTransactItem) *dynamoclient.DynamoResponse {
+	condition := revisionCondition(item.Revision.Value)
+
+	item.Revision.Value++
+	item.UpdatedAt.Value = time.Now().UTC().Format(time.RFC3339)
+
+	response := commit(client, item.UserId.Value, func(syncRevision int) []dynamoclient.DynamoTransactItem {
+		item.SyncRevision.Value = syncRevision
+
+		return append([]dynamoclient.DynamoTransactItem{{
+			Update: &dynamoclient.DyanamoUpdateRequest{
+				Key:     item.UserId.Value,
+				SortKey: item.ItemKey.Value,
+				Values: map[string]dynamoclient.DynamoUpdateItem{
+					"DELETED_AT": deletedAt,
+					"REVISION": {
+						Action: dynamoTypes.AttributeActionPut,
+						Value:  item.Revision.Value,
+					},
+					"SYNC_REVISION": {
+						Action: dynamoTypes.AttributeActionPut,
+						Value:  item.SyncRevision.Value,
+					},
+					"UPDATED_AT": {
+						Action: dynamoTypes.AttributeActionPut,
+						Value:  item.UpdatedAt.Value,
+					},
+				},
+				Condition: condition,
+			},
+		}}, marker...)
+	})
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	return dynamoclient.SuccessWithValue(item)
+}
+
+// Permanently delete an item together with its history, health, attachments and
+// trash marker. A tombstone is left behind so other clients learn about the
+// deletion on sync. Callers remove the shares of the item first with shares.RemoveForItem
+func PurgeItem(client *dynamoclient.DynamoClient, store blobstore.BlobStore, item types.VaultItem) *dynamoclient.DynamoResponse {
+	userId := item.UserId.Value
+	itemId := item.ItemId.Value
+
+	response := PurgeAttachments(client, store, userId, itemId)
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	response = client.
+		Query(dynamoclient.DynamoQueryRequest{
+			Key:           userId,
+			SortKeyPrefix: HistoryPrefix(itemId),
+		}).
+		AsVaultItemHistories()
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	deletes := historyDeletes(userId, response.Data.([]types.VaultItemHistory))
+	deletes = append(deletes, dynamoclient.DynamoDeleteRequest{
+		Key:     userId,
+		SortKey: HealthKey(itemId),
+	})
+
+	response = client.BatchWrite(dynamoclient.DynamoBatchWriteRequest{
+		Deletes: deletes,
+	})
+
+	if !response.IsSuccess {
+		return response
+	}
+
+	return deleteWithTombstone(client, userId, ItemKey(itemId), itemId, TOMBSTONE_TYPE_ITEM, removeTrashMarker(item)...)
+}
@@ -0,0 +1,231 @@
+package vault
+
+import (
+	"os"
+	"password-caddy/api/core/types"
+	"testing"
+	"time"
+)
+
+func TestIsValidItemIdWithUUID(t *testing.T) {
+	actual := IsValidItemId("6f1c1c1e-9a4f-4c1b-8a46-0c8d1b3b6f57")
+
+	if actual != true {
+		t.Errorf("FAILED - TestIsValidItemIdWithUUID | Actual: %v | Expected: %v", actual, true)
+	}
+}
+
+func TestIsValidItemIdWithInvalidId(t *testing.T) {
+	invalidIds := [3]string{"", "foo", "HISTORY#6f1c1c1e-9a4f-4c1b-8a46-0c8d1b3b6f57"}
+
+	for _, id := range invalidIds {
+		actual := IsValidItemId(id)
+		if actual != false {
+			t.Errorf("FAILED - TestIsValidItemIdWithInvalidId | Id: %s | Actual: %v | Expected: %v", id, actual, false)
+		}
+	}
+}
+
+func TestItemKey(t *testing.T) {
+	actual := ItemKey("abc")
+	expected := "ITEM#abc"
+
+	if actual != expected {
+		t.Errorf("FAILED - TestItemKey | Actual: %s | Expected: %s", actual, expected)
+	}
+}
+
+func TestHistoryKeyIsZeroPadded(t *testing.T) {
+	actual := HistoryKey("abc", 42)
+	expected := "HISTORY#abc#0000000042"
+
+	if actual != expected {
+		t.Errorf("FAILED - TestHistoryKeyIsZeroPadded | Actual: %s | Expected: %s", actual, expected)
+	}
+}
+
+func TestHistoryKeySortsByRevision(t *testing.T) {
+	if HistoryKey("abc", 9) >= HistoryKey("abc", 10) {
+		t.Errorf("FAILED - TestHistoryKeySortsByRevision | %s >= %s", HistoryKey("abc", 9), HistoryKey("abc", 10))
+	}
+}
+
+func TestHistoryCountDefault(t *testing.T) {
+	actual := HistoryCount()
+	expected := 10
+
+	if actual != expected {
+		t.Errorf("FAILED - TestHistoryCountDefault | Actual: %d | Expected: %d", actual, expected)
+	}
+}
+
+func TestHistoryTTLFromEnv(t *testing.T) {
+	os.Setenv("VAULT_HISTORY_TTL_DAYS", "2")
+	defer os.Unsetenv("VAULT_HISTORY_TTL_DAYS")
+
+	actual := HistoryTTL()
+	expected := 48 * time.Hour
+
+	if actual != expected {
+		t.Errorf("FAILED - TestHistoryTTLFromEnv | Actual: %s | Expected: %s", actual, expected)
+	}
+}
+
+func TestExpectedRevisionFromIfMatch(t *testing.T) {
+	headers := map[string]string{"if-match": "W/\"7\""}
+
+	actual, err := ExpectedRevision(headers, 3)
+	expected := 7
+
+	if err != nil || actual != expected {
+		t.Errorf("FAILED - TestExpectedRevisionFromIfMatch | Actual: %d, %v | Expected: %d", actual, err, expected)
+	}
+}
+
+func TestExpectedRevisionWithoutIfMatch(t *testing.T) {
+	actual, err := ExpectedRevision(map[string]string{}, 3)
+	expected := 3
+
+	if err != nil || actual != expected {
+		t.Errorf("FAILED - TestExpectedRevisionWithoutIfMatch | Actual: %d, %v | Expected: %d", actual, err, expected)
+	}
+}
+
+func TestExpectedRevisionRejectsNegativeBodyRevision(t *testing.T) {
+	// A body revision of ANY_REVISION would skip the revision check
+	for _, headers := range []map[string]string{{}, {"If-Match": "\"3\""}} {
+		if _, err := ExpectedRevision(headers, ANY_REVISION); err == nil {
+			t.Errorf("FAILED - TestExpectedRevisionRejectsNegativeBodyRevision | Headers: %v | ExpectedRevision did not return error", headers)
+		}
+	}
+}
+
+func TestExpectedRevisionWithInvalidIfMatch(t *testing.T) {
+	_, err := ExpectedRevision(map[string]string{"If-Match": "*"}, 3)
+
+	if err == nil {
+		t.Errorf("FAILED - TestExpectedRevisionWithInvalidIfMatch | ExpectedRevision did not return error")
+	}
+}
+
+func TestRequiredRevision(t *testing.T) {
+	if _, err := RequiredRevision(map[string]string{}); err == nil {
+		t.Errorf("FAILED - TestRequiredRevision | RequiredRevision did not return error without If-Match")
+	}
+
+	actual, err := RequiredRevision(map[string]string{"if-match": "\"0\""})
+
+	if err != nil || actual != 0 {
+		t.Errorf("FAILED - TestRequiredRevision | Actual: %d, %v | Expected: %d", actual, err, 0)
+	}
+}
+
+func TestMatchesRevision(t *testing.T) {
+	var item types.VaultItem
+	item.Revision.Value = 4
+
+	if !MatchesRevision(item, 4) || !MatchesRevision(item, ANY_REVISION) || MatchesRevision(item, 3) {
+		t.Errorf("FAILED - TestMatchesRevision | Revision: %d", item.Revision.Value)
+	}
+}
+
+func TestMatchesRevisionWithNewItem(t *testing.T) {
+	var item types.VaultItem
+
+	if !MatchesRevision(item, 0) || MatchesRevision(item, 1) {
+		t.Errorf("FAILED - TestMatchesRevisionWithNewItem | A missing item must only match revision 0")
+	}
+}
+
+func TestConflictResultCarriesCurrentItem(t *testing.T) {
+	var item types.VaultItem
+	item.ItemId.Value = "abc"
+	item.Revision.Value = 4
+
+	actual := ConflictResult(item)
+
+	if actual.StatusCode != 409 || actual.Error.Details.(VaultItemResponse).Revision != 4 {
+		t.Errorf("FAILED - TestConflictResultCarriesCurrentItem | Actual: %+v", actual.Error)
+	}
+}
+
+func TestRestoreRevisionOfOrganizationItem(t *testing.T) {
+	var current types.VaultItem
+	current.UserId.Value = "ORG#8c1e6d2a-4b7f-4f0e-9a3d-2f6b5c7e9d10"
+	current.ItemId.Value = "0f8fad5b-d9cb-469f-a165-70867728950e"
+	current.Data.Value = "current"
+	current.Revision.Value = 3
+	current.CollectionIds.Value = `["c1"]`
+
+	var revision types.VaultItemHistory
+	revision.UserId.Value = current.UserId.Value
+	revision.ItemId.Value = current.ItemId.Value
+	revision.Data.Value = "restored"
+	revision.Revision.Value = 1
+
+	actual, err := RestoreRevision(current, revision)
+
+	if err != nil {
+		t.Fatalf("FAILED - TestRestoreRevisionOfOrganizationItem | Error: %v", err)
+	}
+
+	if actual.UserId.Value != current.UserId.Value || actual.CollectionIds.Value != current.CollectionIds.Value {
+		t.Errorf("FAILED - TestRestoreRevisionOfOrganizationItem - Vault | Actual: %s %s | Expected: the organization and collections of the item", actual.UserId.Value, actual.CollectionIds.Value)
+	}
+
+	if actual.Data.Value != "restored" || actual.Revision.Value != 3 {
+		t.Errorf("FAILED - TestRestoreRevisionOfOrganizationItem - Data | Actual: %s at %d | Expected: restored at 3", actual.Data.Value, actual.Revision.Value)
+	}
+
+	// A revision of the personal vault is not one of the organization's item
+	revision.UserId.Value = "foo@bar.com"
+
+	if _, err := RestoreRevision(current, revision); err == nil {
+		t.Errorf("FAILED - TestRestoreRevisionOfOrganizationItem | Expected: a revision from another vault to be rejected")
+	}
+}
+
+func TestTrashKeySortsByDeletedAt(t *testing.T) {
+	cutoff := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := TRASH_PREFIX + cutoff.Format(time.RFC3339)
+
+	tests := []struct {
+		deletedAt string
+		expected  bool
+	}{
+		{"2022-02-28T23:59:59Z", true},
+		{"2022-03-01T00:00:00Z", false},
+		{"2022-03-01T00:00:01Z", false},
+	}
+
+	for _, test := range tests {
+		key := TrashKey(test.deletedAt, "foo@bar.com", "0f8fad5b-d9cb-469f-a165-70867728950e")
+
+		if actual := key >= TRASH_PREFIX && key <= to; actual != test.expected {
+			t.Errorf("FAILED - TestTrashKeySortsByDeletedAt | Deleted at: %s | Actual: %v | Expected: %v", test.deletedAt, actual, test.expected)
+		}
+	}
+}
+
+func TestTrashMarkerIsRemovedWithTheTrashedItem(t *testing.T) {
+	var item types.VaultItem
+	item.UserId.Value = "foo@bar.com"
+	item.ItemId.Value = "0f8fad5b-d9cb-469f-a165-70867728950e"
+
+	if removeTrashMarker(item) != nil {
+		t.Errorf("FAILED - TestTrashMarkerIsRemovedWithTheTrashedItem | Expected: no marker for an item outside the trash")
+	}
+
+	item.DeletedAt.Value = "2022-03-01T00:00:00Z"
+
+	written := trashMarker(item)
+	removed := removeTrashMarker(item)
+
+	if len(removed) != 1 || removed[0].Delete.Key != TRASH_KEY || removed[0].Delete.SortKey != written.Put.SortKey {
+		t.Errorf("FAILED - TestTrashMarkerIsRemovedWithTheTrashedItem | Actual: %+v | Expected: the delete of %s", removed, written.Put.SortKey)
+	}
+
+	if written.Put.Values["OWNER_ID"] != item.UserId.Value || written.Put.Values["DELETED_AT"] != item.DeletedAt.Value {
+		t.Errorf("FAILED - TestTrashMarkerIsRemovedWithTheTrashedItem | Actual: %+v | Expected: the owner and deletion time of the item", written.Put.Values)
+	}
+}